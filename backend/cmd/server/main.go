@@ -6,12 +6,17 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
-	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/jobs"
+	"github.com/SimpleAjax/Xephyr/internal/migrations"
+	"github.com/SimpleAjax/Xephyr/internal/middleware"
+	"github.com/SimpleAjax/Xephyr/internal/notify"
 	"github.com/SimpleAjax/Xephyr/internal/repositories"
 	"github.com/SimpleAjax/Xephyr/internal/routes"
+	"github.com/SimpleAjax/Xephyr/internal/services"
 
 	_ "github.com/SimpleAjax/Xephyr/docs"
 )
@@ -51,48 +56,115 @@ func main() {
 		SSLMode:  getEnv("DB_SSL_MODE", "disable"),
 	}
 
-	repo, err := repositories.NewRepository(dbConfig)
+	// Run database migrations as the schema owner
+	log.Println("Running database migrations...")
+	owner, err := repositories.NewRepository(dbConfig)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	defer repo.Close()
-
-	// Run database migrations
-	log.Println("Running database migrations...")
-	if err := repo.Migrate(
-		&models.User{},
-		&models.Organization{},
-		&models.OrganizationMember{},
-		&models.Skill{},
-		&models.UserSkill{},
-		&models.TaskSkill{},
-		&models.Project{},
-		&models.ProjectMember{},
-		&models.Task{},
-		&models.TaskDependency{},
-		&models.Nudge{},
-		&models.NudgeAction{},
-		&models.AssignmentSuggestion{},
-		&models.WorkloadEntry{},
-		&models.Scenario{},
-		&models.ScenarioImpactAnalysis{},
-	); err != nil {
+	if err := migrations.Run(owner.DB(), migrations.All()); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
+	owner.Close()
 	log.Println("Database migrations completed")
 
+	// Requests connect as the tenant role, which row-level security limits to the
+	// organization each request transaction is scoped to
+	tenantConfig := dbConfig
+	tenantConfig.Role = repositories.TenantRole
+	repo, err := repositories.NewRepository(tenantConfig)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer repo.Close()
+
+	// Background workers sweep every organization, so they connect as the bypass role
+	systemConfig := dbConfig
+	systemConfig.Role = repositories.BypassRole
+	systemRepo, err := repositories.NewRepository(systemConfig)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer systemRepo.Close()
+
 	// Check database health
 	if err := repo.Health(); err != nil {
 		log.Fatalf("Database health check failed: %v", err)
 	}
 	log.Println("Database connection established")
 
-	// Create repository provider
+	// Create repository providers
 	repos := repositories.NewProvider(repo.DB())
+	systemRepos := repositories.NewProvider(systemRepo.DB())
 	log.Println("Repository provider initialized")
 
+	// Create the background job queue; services register their handlers during route setup.
+	// It claims jobs across organizations but runs each handler scoped to the job's own.
+	queue := jobs.NewQueue(systemRepos.GetJob(), jobs.DefaultConfig())
+	queue.SetScope(repos.InOrganization)
+
+	// Deliver notifications by email when SMTP is configured, and always by webhook and Slack
+	notifiers := []notify.Notifier{notify.NewWebhookNotifier(nil), notify.NewSlackNotifier(nil)}
+	var mailer notify.Notifier = notify.NewLogNotifier()
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		mailer = notify.NewSMTPNotifier(notify.SMTPConfig{
+			Addr:     addr,
+			From:     getEnv("SMTP_FROM", "xephyr@localhost"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		})
+		notifiers = append(notifiers, mailer)
+	}
+	notifier := services.NewNotificationDispatcher(repos, notifiers...)
+	systemNotifier := services.NewNotificationDispatcher(systemRepos, notifiers...)
+
+	// Authenticate API callers with JWTs; AUTH_DEV_MODE also accepts identity headers
+	authMiddleware, err := middleware.NewAuthMiddleware(middleware.AuthConfig{
+		JWTSecret: os.Getenv("JWT_SECRET"),
+		JWKSFile:  os.Getenv("JWT_JWKS_FILE"),
+		ClockSkew: getEnvDuration("JWT_CLOCK_SKEW", time.Minute),
+		DevMode:   getEnv("AUTH_DEV_MODE", "false") == "true",
+	})
+	if err != nil {
+		log.Fatalf("Failed to configure authentication: %v", err)
+	}
+
+	// Password login issues tokens signed with JWT_SECRET; it is off when only a JWKS is configured
+	var authService services.AuthService
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		authConfig := services.DefaultAuthServiceConfig()
+		authConfig.Secret = []byte(secret)
+		authConfig.AccessTokenTTL = getEnvDuration("AUTH_ACCESS_TOKEN_TTL", authConfig.AccessTokenTTL)
+		authConfig.RefreshTokenTTL = getEnvDuration("AUTH_REFRESH_TOKEN_TTL", authConfig.RefreshTokenTTL)
+		authConfig.MaxFailedLogins = getEnvInt("AUTH_MAX_FAILED_LOGINS", authConfig.MaxFailedLogins)
+		authConfig.LockoutDuration = getEnvDuration("AUTH_LOCKOUT_DURATION", authConfig.LockoutDuration)
+		authConfig.ResetURL = getEnv("AUTH_RESET_URL", authConfig.ResetURL)
+		if authService, err = services.NewAuthService(repos, authConfig, mailer); err != nil {
+			log.Fatalf("Failed to configure auth service: %v", err)
+		}
+	}
+
+	// Invitations are signed with JWT_SECRET too; without it members can still be managed
+	membershipConfig := services.DefaultMembershipServiceConfig()
+	membershipConfig.Secret = []byte(os.Getenv("JWT_SECRET"))
+	membershipConfig.InvitationTTL = getEnvDuration("INVITATION_TTL", membershipConfig.InvitationTTL)
+	membershipConfig.AcceptURL = getEnv("INVITATION_ACCEPT_URL", membershipConfig.AcceptURL)
+	membershipService := services.NewMembershipService(repos, membershipConfig, mailer)
+
 	// Setup routes with real services
-	router := routes.SetupRoutesWithRepos(repos)
+	router := routes.SetupRoutesWithRepos(repos, queue, notifier, authMiddleware, authService, membershipService)
+
+	// Start background job workers
+	if err := queue.Start(context.Background()); err != nil {
+		log.Fatalf("Failed to start job queue: %v", err)
+	}
+
+	// Start periodic nudge maintenance
+	maintenance := services.DefaultNudgeMaintenanceConfig()
+	maintenance.EscalationDelay = getEnvDuration("NUDGE_ESCALATION_DELAY", maintenance.EscalationDelay)
+	maintenance.Retention = getEnvDuration("NUDGE_RETENTION", maintenance.Retention)
+	scheduler := newNudgeScheduler(systemRepos, systemNotifier, getEnvDuration("NUDGE_SCHEDULER_INTERVAL", 5*time.Minute), maintenance, getEnvInt("NOTIFY_DIGEST_HOUR", 8))
+	scheduler.Start()
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -123,6 +195,10 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Let in-flight jobs finish; unfinished ones are requeued on next start
+	queue.Stop()
+	scheduler.Stop()
+
 	log.Println("Server exited")
 }
 
@@ -133,3 +209,29 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid duration %q for %s, using %s", value, key, defaultValue)
+		return defaultValue
+	}
+	return d
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid integer %q for %s, using %d", value, key, defaultValue)
+		return defaultValue
+	}
+	return n
+}
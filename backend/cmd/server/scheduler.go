@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// nudgeScheduler periodically runs nudge maintenance: waking snoozed nudges,
+// escalating unread high-severity nudges, expiring and purging old ones.
+// Once a day, on the first sweep after digestHour (UTC), it sends the daily digests.
+type nudgeScheduler struct {
+	repos      *repositories.Provider
+	notifier   services.NotificationDispatcher
+	interval   time.Duration
+	config     services.NudgeMaintenanceConfig
+	digestHour int
+	lastDigest time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// newNudgeScheduler creates a scheduler that sweeps every interval
+func newNudgeScheduler(repos *repositories.Provider, notifier services.NotificationDispatcher, interval time.Duration, config services.NudgeMaintenanceConfig, digestHour int) *nudgeScheduler {
+	return &nudgeScheduler{repos: repos, notifier: notifier, interval: interval, config: config, digestHour: digestHour}
+}
+
+// Start runs a sweep immediately and then on every tick until Stop is called
+func (s *nudgeScheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.sweep(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.Printf("[NudgeScheduler] Started, sweeping every %s", s.interval)
+}
+
+// Stop waits for an in-flight sweep to finish
+func (s *nudgeScheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+	log.Println("[NudgeScheduler] Stopped")
+}
+
+func (s *nudgeScheduler) sweep(ctx context.Context) {
+	now := time.Now().UTC()
+	result, err := services.RunNudgeMaintenance(ctx, s.repos, s.notifier, s.config, now)
+	if err != nil && ctx.Err() == nil {
+		log.Printf("[NudgeScheduler] Sweep failed: %v", err)
+	}
+	if result.Woken+result.Escalated+result.Expired+result.Deleted > 0 {
+		log.Printf("[NudgeScheduler] Woke %d, escalated %d, expired %d, deleted %d nudges",
+			result.Woken, result.Escalated, result.Expired, result.Deleted)
+	}
+	s.sendDigests(ctx, now)
+}
+
+// sendDigests sends the daily digests once per UTC day, after digestHour
+func (s *nudgeScheduler) sendDigests(ctx context.Context, now time.Time) {
+	if s.notifier == nil || now.Hour() < s.digestHour {
+		return
+	}
+	today := now.Truncate(24 * time.Hour)
+	if !s.lastDigest.Before(today) {
+		return
+	}
+	sent, err := s.notifier.SendDigests(ctx, now)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[NudgeScheduler] Sending digests failed: %v", err)
+		}
+		return
+	}
+	s.lastDigest = today
+	if sent > 0 {
+		log.Printf("[NudgeScheduler] Sent %d daily digests", sent)
+	}
+}
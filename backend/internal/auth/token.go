@@ -0,0 +1,249 @@
+// Package auth issues and verifies the JSON Web Tokens that identify API callers.
+// Only the compact JWS form with HS256 or RS256 signatures is supported.
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken is returned when a token is malformed, has a bad signature or uses
+	// an algorithm the verifier is not configured for
+	ErrInvalidToken = errors.New("invalid token")
+
+	// ErrTokenExpired is returned when a token's exp is in the past, beyond the clock skew
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// Token types carried in the typ claim
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	TokenTypeInvite  = "invite"
+)
+
+// Membership is one organization the subject belongs to and their role in it
+type Membership struct {
+	OrganizationID string `json:"id"`
+	Role           string `json:"role"`
+}
+
+// Claims are the registered JWT claims Xephyr uses plus its organization claims.
+// OrganizationID and Role name the default organization; Organizations lists every
+// organization the subject may act in.
+type Claims struct {
+	Subject   string `json:"sub"`
+	Issuer    string `json:"iss,omitempty"`
+	ExpiresAt int64  `json:"exp"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	ID        string `json:"jti,omitempty"`
+	Type      string `json:"typ,omitempty"`
+
+	OrganizationID string       `json:"org,omitempty"`
+	Role           string       `json:"role,omitempty"`
+	Organizations  []Membership `json:"orgs,omitempty"`
+}
+
+// Membership returns the subject's membership in an organization
+func (c *Claims) Membership(orgID string) (Membership, bool) {
+	for _, m := range c.Organizations {
+		if strings.EqualFold(m.OrganizationID, orgID) {
+			return m, true
+		}
+	}
+	if c.OrganizationID != "" && strings.EqualFold(c.OrganizationID, orgID) {
+		return Membership{OrganizationID: c.OrganizationID, Role: c.Role}, true
+	}
+	return Membership{}, false
+}
+
+// DefaultMembership returns the organization to act in when the caller does not pick one:
+// the org claim, or the only membership
+func (c *Claims) DefaultMembership() (Membership, bool) {
+	if c.OrganizationID != "" {
+		return c.Membership(c.OrganizationID)
+	}
+	if len(c.Organizations) == 1 {
+		return c.Organizations[0], true
+	}
+	return Membership{}, false
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// SignHS256 encodes and signs claims with an HMAC-SHA256 secret
+func SignHS256(claims Claims, secret []byte) (string, error) {
+	if len(secret) == 0 {
+		return "", errors.New("signing secret is empty")
+	}
+	signingInput, err := encodeSegments(header{Alg: "HS256", Typ: "JWT"}, claims)
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + encodeSegment(hmacSHA256(secret, signingInput)), nil
+}
+
+// SignRS256 encodes and signs claims with an RSA private key, naming it by kid
+func SignRS256(claims Claims, key *rsa.PrivateKey, kid string) (string, error) {
+	signingInput, err := encodeSegments(header{Alg: "RS256", Typ: "JWT", Kid: kid}, claims)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + encodeSegment(sig), nil
+}
+
+// VerifierConfig selects which keys a Verifier trusts. A token is only accepted with
+// an algorithm whose key is configured, so an HS256 token cannot be forged from a public RSA key.
+type VerifierConfig struct {
+	// Secret verifies HS256 tokens
+	Secret []byte
+	// Keys verify RS256 tokens by key ID
+	Keys map[string]*rsa.PublicKey
+	// ClockSkew is the tolerance applied to exp and nbf
+	ClockSkew time.Duration
+	// Issuer, when set, must match the iss claim
+	Issuer string
+}
+
+// Verifier checks token signatures and validity windows
+type Verifier struct {
+	cfg VerifierConfig
+}
+
+// NewVerifier creates a verifier; at least one of Secret or Keys must be set
+func NewVerifier(cfg VerifierConfig) (*Verifier, error) {
+	if len(cfg.Secret) == 0 && len(cfg.Keys) == 0 {
+		return nil, errors.New("a JWT secret or signing keys are required")
+	}
+	return &Verifier{cfg: cfg}, nil
+}
+
+// Verify checks a compact token and returns its claims
+func (v *Verifier) Verify(token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	if err := v.verifySignature(h, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := v.validate(&claims, now); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+func (v *Verifier) verifySignature(h header, signingInput string, sig []byte) error {
+	switch h.Alg {
+	case "HS256":
+		if len(v.cfg.Secret) == 0 {
+			break
+		}
+		if !hmac.Equal(sig, hmacSHA256(v.cfg.Secret, signingInput)) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	case "RS256":
+		key := v.cfg.Keys[h.Kid]
+		if key == nil && h.Kid == "" && len(v.cfg.Keys) == 1 {
+			for _, k := range v.cfg.Keys {
+				key = k
+			}
+		}
+		if key == nil {
+			return fmt.Errorf("%w: unknown key %q", ErrInvalidToken, h.Kid)
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, h.Alg)
+}
+
+func (v *Verifier) validate(c *Claims, now time.Time) error {
+	if c.Subject == "" {
+		return fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+	if c.ExpiresAt == 0 {
+		return fmt.Errorf("%w: missing expiry", ErrInvalidToken)
+	}
+	if now.After(time.Unix(c.ExpiresAt, 0).Add(v.cfg.ClockSkew)) {
+		return ErrTokenExpired
+	}
+	if c.NotBefore != 0 && now.Add(v.cfg.ClockSkew).Before(time.Unix(c.NotBefore, 0)) {
+		return fmt.Errorf("%w: token not yet valid", ErrInvalidToken)
+	}
+	if v.cfg.Issuer != "" && c.Issuer != v.cfg.Issuer {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, c.Issuer)
+	}
+	return nil
+}
+
+func hmacSHA256(secret []byte, signingInput string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+func encodeSegments(h header, claims Claims) (string, error) {
+	hb, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	cb, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	return encodeSegment(hb) + "." + encodeSegment(cb), nil
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidToken)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidToken)
+	}
+	return nil
+}
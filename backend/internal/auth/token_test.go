@@ -0,0 +1,135 @@
+package auth_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/SimpleAjax/Xephyr/internal/auth"
+)
+
+var _ = Describe("Tokens", func() {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	secret := []byte("test-secret")
+
+	claims := func() auth.Claims {
+		return auth.Claims{
+			Subject:        "5f0c2c34-4a7b-4d8e-9c1a-1b2c3d4e5f60",
+			ExpiresAt:      now.Add(time.Hour).Unix(),
+			OrganizationID: "org-1",
+			Role:           "pm",
+			Organizations:  []auth.Membership{{OrganizationID: "org-1", Role: "pm"}, {OrganizationID: "org-2", Role: "member"}},
+		}
+	}
+
+	Context("Given an HS256 verifier", func() {
+		var verifier *auth.Verifier
+
+		BeforeEach(func() {
+			var err error
+			verifier, err = auth.NewVerifier(auth.VerifierConfig{Secret: secret, ClockSkew: time.Minute})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should round-trip claims", func() {
+			token, err := auth.SignHS256(claims(), secret)
+			Expect(err).NotTo(HaveOccurred())
+
+			got, err := verifier.Verify(token, now)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.Subject).To(Equal(claims().Subject))
+			m, ok := got.Membership("org-2")
+			Expect(ok).To(BeTrue())
+			Expect(m.Role).To(Equal("member"))
+		})
+
+		It("should reject a token signed with another secret", func() {
+			token, _ := auth.SignHS256(claims(), []byte("other"))
+			_, err := verifier.Verify(token, now)
+			Expect(err).To(MatchError(auth.ErrInvalidToken))
+		})
+
+		It("should reject a tampered payload", func() {
+			token, _ := auth.SignHS256(claims(), secret)
+			forged := claims()
+			forged.Subject = "someone-else"
+			other, _ := auth.SignHS256(forged, []byte("other"))
+			parts, otherParts := strings.Split(token, "."), strings.Split(other, ".")
+
+			_, err := verifier.Verify(parts[0]+"."+otherParts[1]+"."+parts[2], now)
+			Expect(err).To(MatchError(auth.ErrInvalidToken))
+		})
+
+		It("should reject unsigned tokens", func() {
+			header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+			payload := strings.Split(mustSign(claims(), secret), ".")[1]
+			_, err := verifier.Verify(header+"."+payload+".", now)
+			Expect(err).To(MatchError(auth.ErrInvalidToken))
+		})
+
+		It("should honour expiry with clock skew", func() {
+			c := claims()
+			c.ExpiresAt = now.Add(-30 * time.Second).Unix()
+			token, _ := auth.SignHS256(c, secret)
+
+			_, err := verifier.Verify(token, now)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = verifier.Verify(token, now.Add(time.Minute))
+			Expect(err).To(MatchError(auth.ErrTokenExpired))
+		})
+
+		It("should require an expiry", func() {
+			c := claims()
+			c.ExpiresAt = 0
+			token, _ := auth.SignHS256(c, secret)
+			_, err := verifier.Verify(token, now)
+			Expect(err).To(MatchError(auth.ErrInvalidToken))
+		})
+
+		It("should not accept RS256 tokens without configured keys", func() {
+			key, _ := rsa.GenerateKey(rand.Reader, 2048)
+			token, _ := auth.SignRS256(claims(), key, "k1")
+			_, err := verifier.Verify(token, now)
+			Expect(err).To(MatchError(auth.ErrInvalidToken))
+		})
+	})
+
+	Context("Given a JWKS document", func() {
+		It("should verify RS256 tokens by key ID", func() {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).NotTo(HaveOccurred())
+			keys, err := auth.ParseJWKS([]byte(jwksFor("k1", &key.PublicKey)))
+			Expect(err).NotTo(HaveOccurred())
+			verifier, _ := auth.NewVerifier(auth.VerifierConfig{Keys: keys})
+
+			token, _ := auth.SignRS256(claims(), key, "k1")
+			_, err = verifier.Verify(token, now)
+			Expect(err).NotTo(HaveOccurred())
+
+			unknown, _ := auth.SignRS256(claims(), key, "k2")
+			_, err = verifier.Verify(unknown, now)
+			Expect(err).To(MatchError(auth.ErrInvalidToken))
+		})
+	})
+})
+
+func mustSign(c auth.Claims, secret []byte) string {
+	token, err := auth.SignHS256(c, secret)
+	Expect(err).NotTo(HaveOccurred())
+	return token
+}
+
+func jwksFor(kid string, key *rsa.PublicKey) string {
+	n := base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+	e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	return fmt.Sprintf(`{"keys":[{"kty":"RSA","kid":%q,"use":"sig","alg":"RS256","n":%q,"e":%q}]}`, kid, n, e)
+}
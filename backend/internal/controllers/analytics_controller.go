@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// AnalyticsController handles flow analytics HTTP requests
+type AnalyticsController struct {
+	service services.TaskHistoryService
+}
+
+// NewAnalyticsController creates a new analytics controller
+func NewAnalyticsController(service services.TaskHistoryService) *AnalyticsController {
+	return &AnalyticsController{service: service}
+}
+
+// GetProjectFlow godoc
+// @Summary Get project flow metrics
+// @Description Get cycle time, lead time, time in each status and weekly throughput of the project's tasks completed in a period
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Param projectId path string true "Project ID"
+// @Param from query string false "Start of the period, as a date or RFC 3339 time; twelve weeks before its end by default"
+// @Param to query string false "End of the period, as a date or RFC 3339 time; now by default"
+// @Success 200 {object} dto.ApiResponse{data=dto.FlowMetricsResponse}
+// @Failure 400 {object} dto.ApiResponse
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /analytics/projects/{projectId}/flow [get]
+func (c *AnalyticsController) GetProjectFlow(ctx *gin.Context) {
+	projectID, err := uuid.Parse(ctx.Param("projectId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid project ID", nil, ctx.GetString("requestId")))
+		return
+	}
+	from, to, ok := c.period(ctx)
+	if !ok {
+		return
+	}
+
+	metrics, err := c.service.ProjectFlow(ctx.Request.Context(), organizationUUID(ctx), projectID, from, to)
+	if err != nil {
+		c.respondError(ctx, err, "Project not found")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(metrics, dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+// GetPersonFlow godoc
+// @Summary Get personal flow metrics
+// @Description Get cycle time, lead time, time in each status and weekly throughput of the tasks assigned to a member and completed in a period
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Param personId path string true "User ID"
+// @Param from query string false "Start of the period, as a date or RFC 3339 time; twelve weeks before its end by default"
+// @Param to query string false "End of the period, as a date or RFC 3339 time; now by default"
+// @Success 200 {object} dto.ApiResponse{data=dto.FlowMetricsResponse}
+// @Failure 400 {object} dto.ApiResponse
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /analytics/people/{personId}/flow [get]
+func (c *AnalyticsController) GetPersonFlow(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("personId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid user ID", nil, ctx.GetString("requestId")))
+		return
+	}
+	from, to, ok := c.period(ctx)
+	if !ok {
+		return
+	}
+
+	metrics, err := c.service.PersonFlow(ctx.Request.Context(), organizationUUID(ctx), userID, from, to)
+	if err != nil {
+		c.respondError(ctx, err, "User not found")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(metrics, dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+// period reads the from and to query parameters, either of which may be left out. A
+// date given for to covers that whole day.
+func (c *AnalyticsController) period(ctx *gin.Context) (time.Time, time.Time, bool) {
+	var bounds [2]time.Time
+	for i, name := range []string{"from", "to"} {
+		value := ctx.Query(name)
+		if value == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			bounds[i] = t
+			continue
+		}
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", name+" must be a date or an RFC 3339 time", nil, ctx.GetString("requestId")))
+			return time.Time{}, time.Time{}, false
+		}
+		if name == "to" {
+			day = day.Add(24*time.Hour - time.Nanosecond)
+		}
+		bounds[i] = day
+	}
+	return bounds[0], bounds[1], true
+}
+
+func (c *AnalyticsController) respondError(ctx *gin.Context, err error, notFound string) {
+	if errors.Is(err, services.ErrInvalidPeriod) {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "from must not be after to", nil, ctx.GetString("requestId")))
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", notFound, nil, ctx.GetString("requestId")))
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -77,6 +78,17 @@ func (c *AssignmentController) AssignTask(ctx *gin.Context) {
 	}
 
 	result, err := c.service.AssignTask(ctx.Request.Context(), taskID, req, orgID, assignedBy)
+	if errors.Is(err, services.ErrAssignmentTaskNotFound) {
+		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+	if errors.Is(err, services.ErrInvalidAssignment) {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("INVALID_ASSIGNMENT", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+	if respondArchived(ctx, err) {
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// AuthController handles sign-up, login and session HTTP requests
+type AuthController struct {
+	service services.AuthService
+}
+
+// NewAuthController creates a new auth controller
+func NewAuthController(service services.AuthService) *AuthController {
+	return &AuthController{service: service}
+}
+
+// Register godoc
+// @Summary Register
+// @Description Create an account, optionally with a new organization, and log it in
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.RegisterRequest true "Registration"
+// @Success 201 {object} dto.ApiResponse{data=dto.AuthTokensResponse}
+// @Failure 400 {object} dto.ApiResponse
+// @Failure 409 {object} dto.ApiResponse
+// @Router /auth/register [post]
+func (c *AuthController) Register(ctx *gin.Context) {
+	var req dto.RegisterRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	result, err := c.service.Register(ctx.Request.Context(), req, ctx.Request.UserAgent())
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, dto.NewSuccessResponse(result, dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+// Login godoc
+// @Summary Log in
+// @Description Exchange an email and password for an access token and a refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.LoginRequest true "Credentials"
+// @Success 200 {object} dto.ApiResponse{data=dto.AuthTokensResponse}
+// @Failure 401 {object} dto.ApiResponse
+// @Failure 423 {object} dto.ApiResponse
+// @Router /auth/login [post]
+func (c *AuthController) Login(ctx *gin.Context) {
+	var req dto.LoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	result, err := c.service.Login(ctx.Request.Context(), req, ctx.Request.UserAgent())
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(result, dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+// Refresh godoc
+// @Summary Refresh tokens
+// @Description Rotate a refresh token; the old one stops working
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.RefreshRequest true "Refresh token"
+// @Success 200 {object} dto.ApiResponse{data=dto.AuthTokensResponse}
+// @Failure 401 {object} dto.ApiResponse
+// @Router /auth/refresh [post]
+func (c *AuthController) Refresh(ctx *gin.Context) {
+	var req dto.RefreshRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	result, err := c.service.Refresh(ctx.Request.Context(), req.RefreshToken, ctx.Request.UserAgent())
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(result, dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revoke a refresh token and every token rotated from the same login
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.LogoutRequest true "Refresh token"
+// @Success 200 {object} dto.ApiResponse{data=dto.AuthStatusResponse}
+// @Router /auth/logout [post]
+func (c *AuthController) Logout(ctx *gin.Context) {
+	var req dto.LogoutRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	if err := c.service.Logout(ctx.Request.Context(), req.RefreshToken); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	c.respondStatus(ctx, "Logged out")
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Change the current user's password; all sessions are logged out
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ChangePasswordRequest true "Passwords"
+// @Success 200 {object} dto.ApiResponse{data=dto.AuthStatusResponse}
+// @Failure 400 {object} dto.ApiResponse
+// @Failure 401 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /auth/password/change [post]
+func (c *AuthController) ChangePassword(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.GetString("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, dto.NewErrorResponse("UNAUTHORIZED", "Authentication required", nil, ctx.GetString("requestId")))
+		return
+	}
+
+	var req dto.ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	if err := c.service.ChangePassword(ctx.Request.Context(), userID, req); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	c.respondStatus(ctx, "Password changed")
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a password reset link if the address has an account
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ForgotPasswordRequest true "Email"
+// @Success 202 {object} dto.ApiResponse{data=dto.AuthStatusResponse}
+// @Router /auth/password/forgot [post]
+func (c *AuthController) ForgotPassword(ctx *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	if err := c.service.RequestPasswordReset(ctx.Request.Context(), req.Email); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, dto.NewSuccessResponse(dto.AuthStatusResponse{
+		Message: "If the address has an account, a reset link has been sent",
+	}, dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password with a reset token; all sessions are logged out
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ResetPasswordRequest true "Reset"
+// @Success 200 {object} dto.ApiResponse{data=dto.AuthStatusResponse}
+// @Failure 400 {object} dto.ApiResponse
+// @Router /auth/password/reset [post]
+func (c *AuthController) ResetPassword(ctx *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	if err := c.service.ResetPassword(ctx.Request.Context(), req); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	c.respondStatus(ctx, "Password reset")
+}
+
+func (c *AuthController) respondStatus(ctx *gin.Context, message string) {
+	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(dto.AuthStatusResponse{Message: message}, dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+func (c *AuthController) respondError(ctx *gin.Context, err error) {
+	status, code := http.StatusInternalServerError, "INTERNAL_ERROR"
+	switch {
+	case errors.Is(err, services.ErrEmailTaken):
+		status, code = http.StatusConflict, "EMAIL_TAKEN"
+	case errors.Is(err, services.ErrInvalidCredentials):
+		status, code = http.StatusUnauthorized, "INVALID_CREDENTIALS"
+	case errors.Is(err, services.ErrAccountLocked):
+		status, code = http.StatusLocked, "ACCOUNT_LOCKED"
+	case errors.Is(err, services.ErrInvalidRefreshToken):
+		status, code = http.StatusUnauthorized, "INVALID_REFRESH_TOKEN"
+	case errors.Is(err, services.ErrInvalidResetToken):
+		status, code = http.StatusBadRequest, "INVALID_RESET_TOKEN"
+	case errors.Is(err, services.ErrPasswordTooLong):
+		status, code = http.StatusBadRequest, "VALIDATION_ERROR"
+	}
+	ctx.JSON(status, dto.NewErrorResponse(code, err.Error(), nil, ctx.GetString("requestId")))
+}
@@ -1,9 +1,11 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"github.com/SimpleAjax/Xephyr/internal/dto"
 	"github.com/SimpleAjax/Xephyr/internal/services"
@@ -43,7 +45,7 @@ func (c *DependencyController) GetTaskDependencies(ctx *gin.Context) {
 
 	deps, err := c.service.GetTaskDependencies(ctx.Request.Context(), taskID, params.IncludeIndirect, orgID)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Task not found", nil, ctx.GetString("requestId")))
+		c.respondError(ctx, err, "Task not found")
 		return
 	}
 
@@ -62,6 +64,7 @@ func (c *DependencyController) GetTaskDependencies(ctx *gin.Context) {
 // @Param request body dto.CreateDependencyRequest true "Dependency creation request"
 // @Success 201 {object} dto.ApiResponse{data=dto.CreateDependencyResponse}
 // @Failure 400 {object} dto.ApiResponse
+// @Failure 404 {object} dto.ApiResponse
 // @Failure 409 {object} dto.ApiResponse
 // @Security BearerAuth
 // @Router /dependencies [post]
@@ -76,14 +79,21 @@ func (c *DependencyController) CreateDependency(ctx *gin.Context) {
 	dep, err := c.service.CreateDependency(ctx.Request.Context(), req, orgID)
 	if err != nil {
 		// Check for circular dependency error
-		if circularErr, ok := err.(*services.CircularDependencyError); ok {
+		var circularErr *services.CircularDependencyError
+		if errors.As(err, &circularErr) {
+			// Every link of the cycle but the proposed first one already exists
+			existing := []dto.ExistingDependency{}
+			for i := 1; i+1 < len(circularErr.Cycle); i++ {
+				existing = append(existing, dto.ExistingDependency{From: circularErr.Cycle[i], To: circularErr.Cycle[i+1]})
+			}
 			details := map[string]interface{}{
-				"cycle": circularErr.Cycle,
+				"cycle":                circularErr.Cycle,
+				"existingDependencies": existing,
 			}
 			ctx.JSON(http.StatusConflict, dto.NewErrorResponse("CIRCULAR_DEPENDENCY", "This dependency would create a circular reference", details, ctx.GetString("requestId")))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		c.respondError(ctx, err, "Task not found")
 		return
 	}
 
@@ -109,7 +119,7 @@ func (c *DependencyController) DeleteDependency(ctx *gin.Context) {
 	orgID := ctx.GetString("organizationId")
 
 	if err := c.service.DeleteDependency(ctx.Request.Context(), dependencyID, orgID); err != nil {
-		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Dependency not found", nil, ctx.GetString("requestId")))
+		c.respondError(ctx, err, "Dependency not found")
 		return
 	}
 
@@ -133,7 +143,7 @@ func (c *DependencyController) GetCriticalPath(ctx *gin.Context) {
 
 	path, err := c.service.GetCriticalPath(ctx.Request.Context(), projectID, orgID)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Project not found", nil, ctx.GetString("requestId")))
+		c.respondError(ctx, err, "Project not found")
 		return
 	}
 
@@ -152,6 +162,7 @@ func (c *DependencyController) GetCriticalPath(ctx *gin.Context) {
 // @Param request body dto.ValidateDependencyRequest true "Validation request"
 // @Success 200 {object} dto.ApiResponse{data=dto.ValidateDependencyResponse}
 // @Failure 400 {object} dto.ApiResponse
+// @Failure 404 {object} dto.ApiResponse
 // @Security BearerAuth
 // @Router /dependencies/validate [post]
 func (c *DependencyController) ValidateDependency(ctx *gin.Context) {
@@ -164,7 +175,7 @@ func (c *DependencyController) ValidateDependency(ctx *gin.Context) {
 	orgID := ctx.GetString("organizationId")
 	result, err := c.service.ValidateDependency(ctx.Request.Context(), req, orgID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		c.respondError(ctx, err, "Task not found")
 		return
 	}
 
@@ -176,22 +187,64 @@ func (c *DependencyController) ValidateDependency(ctx *gin.Context) {
 
 // GetDependencyGraph godoc
 // @Summary Get dependency graph
-// @Description Get the dependency graph for visualization
+// @Description Get the dependency graph for visualization, as JSON or as diagram source to paste into docs: Graphviz DOT, a Mermaid flowchart or GraphML. Diagrams highlight the critical path, cluster subtasks under their top-level task and label dependencies with their type and lag.
 // @Tags dependencies
 // @Accept json
-// @Produce json
+// @Produce json,plain,xml
 // @Param projectId path string true "Project ID"
+// @Param format query string false "Output format" Enums(json, dot, mermaid, graphml) default(json)
 // @Success 200 {object} dto.ApiResponse{data=dto.DependencyGraphResponse}
+// @Failure 400 {object} dto.ApiResponse
 // @Failure 404 {object} dto.ApiResponse
 // @Security BearerAuth
 // @Router /dependencies/graph/{projectId} [get]
 func (c *DependencyController) GetDependencyGraph(ctx *gin.Context) {
 	projectID := ctx.Param("projectId")
 	orgID := ctx.GetString("organizationId")
+	format := ctx.DefaultQuery("format", "json")
+	if format != "json" && !services.SupportsGraphFormat(format) {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "format must be one of json, dot, mermaid, graphml", nil, ctx.GetString("requestId")))
+		return
+	}
 
 	graph, err := c.service.GetDependencyGraph(ctx.Request.Context(), projectID, orgID)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Project not found", nil, ctx.GetString("requestId")))
+		c.respondError(ctx, err, "Project not found")
+		return
+	}
+
+	if format != "json" {
+		// Diagram source is served bare so it can be pasted as is
+		content, contentType, err := services.RenderDependencyGraph(graph, format)
+		if err != nil {
+			c.respondError(ctx, err, "Project not found")
+			return
+		}
+		ctx.Data(http.StatusOK, contentType, []byte(content))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(graph, dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+// GetPortfolioGraph godoc
+// @Summary Get portfolio dependency graph
+// @Description Get how the organization's projects depend on each other: the dependencies crossing projects, how many are still blocking, and the critical path across the whole portfolio
+// @Tags dependencies
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.ApiResponse{data=dto.PortfolioDependencyGraphResponse}
+// @Security BearerAuth
+// @Router /dependencies/portfolio [get]
+func (c *DependencyController) GetPortfolioGraph(ctx *gin.Context) {
+	orgID := ctx.GetString("organizationId")
+
+	graph, err := c.service.GetPortfolioGraph(ctx.Request.Context(), orgID)
+	if err != nil {
+		c.respondError(ctx, err, "Organization not found")
 		return
 	}
 
@@ -200,3 +253,19 @@ func (c *DependencyController) GetDependencyGraph(ctx *gin.Context) {
 		RequestID: ctx.GetString("requestId"),
 	}))
 }
+
+func (c *DependencyController) respondError(ctx *gin.Context, err error, notFound string) {
+	if respondArchived(ctx, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrSelfDependency):
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("SELF_DEPENDENCY", "A task cannot depend on itself", nil, ctx.GetString("requestId")))
+	case errors.Is(err, services.ErrDuplicateDependency):
+		ctx.JSON(http.StatusConflict, dto.NewErrorResponse("DUPLICATE_DEPENDENCY", err.Error(), nil, ctx.GetString("requestId")))
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", notFound, nil, ctx.GetString("requestId")))
+	default:
+		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+	}
+}
@@ -1,9 +1,11 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"github.com/SimpleAjax/Xephyr/internal/dto"
 	"github.com/SimpleAjax/Xephyr/internal/services"
@@ -139,6 +141,10 @@ func (c *HealthController) GetHealthTrends(ctx *gin.Context) {
 	orgID := ctx.GetString("organizationId")
 	trends, err := c.service.GetHealthTrends(ctx.Request.Context(), params.ProjectID, params.Days, orgID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Project not found", nil, ctx.GetString("requestId")))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
 	}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+// JobController handles background job HTTP requests
+type JobController struct {
+	repos repositories.Repositories
+}
+
+// NewJobController creates a new job controller
+func NewJobController(repos repositories.Repositories) *JobController {
+	return &JobController{repos: repos}
+}
+
+// GetJob godoc
+// @Summary Get background job status
+// @Description Get the status, progress and result of an asynchronous job
+// @Tags jobs
+// @Produce json
+// @Param jobId path string true "Job ID"
+// @Success 200 {object} dto.ApiResponse{data=dto.JobResponse}
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /jobs/{jobId} [get]
+func (c *JobController) GetJob(ctx *gin.Context) {
+	jobID, err := uuid.Parse(ctx.Param("jobId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid job ID", nil, ctx.GetString("requestId")))
+		return
+	}
+
+	job, err := c.repos.GetJob().GetByID(ctx.Request.Context(), organizationUUID(ctx), jobID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Job not found", nil, ctx.GetString("requestId")))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(toJobResponse(job), dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+func toJobResponse(job *models.Job) dto.JobResponse {
+	resp := dto.JobResponse{
+		JobID:       job.ID.String(),
+		Type:        job.Type,
+		Status:      string(job.Status),
+		Progress:    job.Progress,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		Result:      job.Result,
+		Error:       job.LastError,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		CompletedAt: job.CompletedAt,
+	}
+	if job.Status == models.JobStatusQueued {
+		runAt := job.RunAt
+		resp.NextRunAt = &runAt
+	}
+	return resp
+}
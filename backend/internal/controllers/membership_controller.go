@@ -0,0 +1,371 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// MembershipController handles organization invitation and membership HTTP requests
+type MembershipController struct {
+	service services.MembershipService
+}
+
+// NewMembershipController creates a new membership controller
+func NewMembershipController(service services.MembershipService) *MembershipController {
+	return &MembershipController{service: service}
+}
+
+// ListMembers godoc
+// @Summary List members
+// @Description List the members of the organization with their roles
+// @Tags organization
+// @Produce json
+// @Success 200 {object} dto.ApiResponse{data=[]dto.MemberResponse}
+// @Security BearerAuth
+// @Router /organization/members [get]
+func (c *MembershipController) ListMembers(ctx *gin.Context) {
+	orgID, ok := c.organizationID(ctx)
+	if !ok {
+		return
+	}
+
+	members, err := c.service.ListMembers(ctx.Request.Context(), orgID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	c.respond(ctx, http.StatusOK, members)
+}
+
+// UpdateMemberRole godoc
+// @Summary Change a member's role
+// @Description Change a member's role. The owner stays an admin and the last admin cannot be demoted.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param request body dto.UpdateMemberRoleRequest true "Role"
+// @Success 200 {object} dto.ApiResponse{data=dto.MemberResponse}
+// @Failure 404 {object} dto.ApiResponse
+// @Failure 409 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /organization/members/{userId}/role [patch]
+func (c *MembershipController) UpdateMemberRole(ctx *gin.Context) {
+	orgID, ok := c.organizationID(ctx)
+	if !ok {
+		return
+	}
+	userID, ok := c.pathID(ctx, "userId")
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateMemberRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	member, err := c.service.ChangeRole(ctx.Request.Context(), orgID, c.actorID(ctx), userID, models.UserRole(req.Role))
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	c.respond(ctx, http.StatusOK, member)
+}
+
+// RemoveMember godoc
+// @Summary Remove a member
+// @Description Remove a member from the organization. The owner and the last admin cannot be removed.
+// @Tags organization
+// @Param userId path string true "User ID"
+// @Success 204
+// @Failure 404 {object} dto.ApiResponse
+// @Failure 409 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /organization/members/{userId} [delete]
+func (c *MembershipController) RemoveMember(ctx *gin.Context) {
+	orgID, ok := c.organizationID(ctx)
+	if !ok {
+		return
+	}
+	userID, ok := c.pathID(ctx, "userId")
+	if !ok {
+		return
+	}
+
+	if err := c.service.RemoveMember(ctx.Request.Context(), orgID, c.actorID(ctx), userID); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// TransferOwnership godoc
+// @Summary Transfer ownership
+// @Description Make another member the owner of the organization, promoting them to admin
+// @Tags organization
+// @Accept json
+// @Param request body dto.TransferOwnershipRequest true "New owner"
+// @Success 204
+// @Failure 403 {object} dto.ApiResponse
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /organization/ownership/transfer [post]
+func (c *MembershipController) TransferOwnership(ctx *gin.Context) {
+	orgID, ok := c.organizationID(ctx)
+	if !ok {
+		return
+	}
+
+	var req dto.TransferOwnershipRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	if err := c.service.TransferOwnership(ctx.Request.Context(), orgID, c.actorID(ctx), req.UserID); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListInvitations godoc
+// @Summary List pending invitations
+// @Description List invitations that have not been answered and have not expired
+// @Tags organization
+// @Produce json
+// @Success 200 {object} dto.ApiResponse{data=[]dto.InvitationResponse}
+// @Security BearerAuth
+// @Router /organization/invitations [get]
+func (c *MembershipController) ListInvitations(ctx *gin.Context) {
+	orgID, ok := c.organizationID(ctx)
+	if !ok {
+		return
+	}
+
+	invitations, err := c.service.ListInvitations(ctx.Request.Context(), orgID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	c.respond(ctx, http.StatusOK, invitations)
+}
+
+// CreateInvitation godoc
+// @Summary Invite someone
+// @Description Email a signed, expiring invitation to join the organization with a role
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateInvitationRequest true "Invitation"
+// @Success 201 {object} dto.ApiResponse{data=dto.InvitationResponse}
+// @Failure 409 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /organization/invitations [post]
+func (c *MembershipController) CreateInvitation(ctx *gin.Context) {
+	orgID, ok := c.organizationID(ctx)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateInvitationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	invitation, err := c.service.Invite(ctx.Request.Context(), orgID, c.actorID(ctx), req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	c.respond(ctx, http.StatusCreated, invitation)
+}
+
+// RevokeInvitation godoc
+// @Summary Revoke an invitation
+// @Description Withdraw a pending invitation so its token can no longer be used
+// @Tags organization
+// @Param invitationId path string true "Invitation ID"
+// @Success 204
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /organization/invitations/{invitationId} [delete]
+func (c *MembershipController) RevokeInvitation(ctx *gin.Context) {
+	orgID, ok := c.organizationID(ctx)
+	if !ok {
+		return
+	}
+	invitationID, ok := c.pathID(ctx, "invitationId")
+	if !ok {
+		return
+	}
+
+	if err := c.service.RevokeInvitation(ctx.Request.Context(), orgID, c.actorID(ctx), invitationID); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListAuditEvents godoc
+// @Summary List audit events
+// @Description List membership changes in the organization, newest first
+// @Tags organization
+// @Produce json
+// @Param limit query int false "Limit results" default(20)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} dto.ApiResponse{data=dto.AuditEventListResponse}
+// @Security BearerAuth
+// @Router /organization/audit-events [get]
+func (c *MembershipController) ListAuditEvents(ctx *gin.Context) {
+	orgID, ok := c.organizationID(ctx)
+	if !ok {
+		return
+	}
+
+	var params repositories.ListParams
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		params = repositories.DefaultListParams()
+	}
+
+	events, err := c.service.ListAuditEvents(ctx.Request.Context(), orgID, params)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	c.respond(ctx, http.StatusOK, events)
+}
+
+// AcceptInvitation godoc
+// @Summary Accept an invitation
+// @Description Join the organization named by an invitation token. The token must have been sent to the caller's email.
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Param request body dto.InvitationTokenRequest true "Invitation token"
+// @Success 200 {object} dto.ApiResponse{data=dto.MemberResponse}
+// @Failure 400 {object} dto.ApiResponse
+// @Failure 403 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /invitations/accept [post]
+func (c *MembershipController) AcceptInvitation(ctx *gin.Context) {
+	var req dto.InvitationTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	member, err := c.service.AcceptInvitation(ctx.Request.Context(), c.actorID(ctx), req.Token)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	c.respond(ctx, http.StatusOK, member)
+}
+
+// DeclineInvitation godoc
+// @Summary Decline an invitation
+// @Description Turn down the invitation named by a token
+// @Tags invitations
+// @Accept json
+// @Param request body dto.InvitationTokenRequest true "Invitation token"
+// @Success 204
+// @Failure 400 {object} dto.ApiResponse
+// @Failure 403 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /invitations/decline [post]
+func (c *MembershipController) DeclineInvitation(ctx *gin.Context) {
+	var req dto.InvitationTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	if err := c.service.DeclineInvitation(ctx.Request.Context(), c.actorID(ctx), req.Token); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *MembershipController) organizationID(ctx *gin.Context) (uuid.UUID, bool) {
+	orgID, err := uuid.Parse(ctx.GetString("organizationId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid organization ID", nil, ctx.GetString("requestId")))
+		return uuid.Nil, false
+	}
+	return orgID, true
+}
+
+func (c *MembershipController) pathID(ctx *gin.Context, param string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param(param))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid "+param, nil, ctx.GetString("requestId")))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// actorID is the authenticated caller; AuthMiddleware guarantees it parses
+func (c *MembershipController) actorID(ctx *gin.Context) uuid.UUID {
+	userID, _ := uuid.Parse(ctx.GetString("userId"))
+	return userID
+}
+
+func (c *MembershipController) respond(ctx *gin.Context, status int, data interface{}) {
+	ctx.JSON(status, dto.NewSuccessResponse(data, dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+func (c *MembershipController) respondError(ctx *gin.Context, err error) {
+	status, code := http.StatusInternalServerError, "INTERNAL_ERROR"
+	switch {
+	case errors.Is(err, services.ErrInvitationsDisabled):
+		status, code = http.StatusServiceUnavailable, "INVITATIONS_DISABLED"
+	case errors.Is(err, services.ErrInvalidInvitation):
+		status, code = http.StatusBadRequest, "INVALID_INVITATION"
+	case errors.Is(err, services.ErrInvitationEmailMismatch):
+		status, code = http.StatusForbidden, "INVITATION_EMAIL_MISMATCH"
+	case errors.Is(err, services.ErrOwnerRequired):
+		status, code = http.StatusForbidden, "OWNER_REQUIRED"
+	case errors.Is(err, services.ErrInvitationNotFound):
+		status, code = http.StatusNotFound, "INVITATION_NOT_FOUND"
+	case errors.Is(err, services.ErrMemberNotFound):
+		status, code = http.StatusNotFound, "MEMBER_NOT_FOUND"
+	case errors.Is(err, services.ErrAlreadyMember):
+		status, code = http.StatusConflict, "ALREADY_MEMBER"
+	case errors.Is(err, services.ErrInvitationPending):
+		status, code = http.StatusConflict, "INVITATION_PENDING"
+	case errors.Is(err, services.ErrLastAdmin):
+		status, code = http.StatusConflict, "LAST_ADMIN"
+	case errors.Is(err, services.ErrOwnerChange):
+		status, code = http.StatusConflict, "OWNER_CHANGE"
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status, code = http.StatusNotFound, "NOT_FOUND"
+	}
+	ctx.JSON(status, dto.NewErrorResponse(code, err.Error(), nil, ctx.GetString("requestId")))
+}
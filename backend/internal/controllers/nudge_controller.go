@@ -1,10 +1,12 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"github.com/SimpleAjax/Xephyr/internal/dto"
 	"github.com/SimpleAjax/Xephyr/internal/services"
@@ -93,7 +95,7 @@ func (c *NudgeController) GetNudge(ctx *gin.Context) {
 
 // TakeNudgeAction godoc
 // @Summary Take action on nudge
-// @Description Perform an action on a nudge (accept, dismiss, etc.)
+// @Description Perform an action on a nudge. reassign, extend_due_date, split_task and add_dependency change the related task; snooze and escalate change the nudge. Every action is recorded in the nudge history. Actions that change a task need the permission making the change directly needs.
 // @Tags nudges
 // @Accept json
 // @Produce json
@@ -101,6 +103,8 @@ func (c *NudgeController) GetNudge(ctx *gin.Context) {
 // @Param request body dto.NudgeActionRequest true "Action request"
 // @Success 200 {object} dto.ApiResponse{data=dto.NudgeActionResponse}
 // @Failure 400 {object} dto.ApiResponse
+// @Failure 403 {object} dto.ApiResponse
+// @Failure 404 {object} dto.ApiResponse
 // @Security BearerAuth
 // @Router /nudges/{nudgeId}/actions [post]
 func (c *NudgeController) TakeNudgeAction(ctx *gin.Context) {
@@ -117,6 +121,17 @@ func (c *NudgeController) TakeNudgeAction(ctx *gin.Context) {
 
 	result, err := c.service.TakeNudgeAction(ctx.Request.Context(), nudgeID, req, orgID, userID)
 	if err != nil {
+		if errors.Is(err, services.ErrNudgeNotFound) {
+			ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Nudge not found", nil, ctx.GetString("requestId")))
+			return
+		}
+		if errors.Is(err, services.ErrInvalidNudgeAction) {
+			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+			return
+		}
+		if respondArchived(ctx, err) {
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
 	}
@@ -151,6 +166,10 @@ func (c *NudgeController) UpdateNudgeStatus(ctx *gin.Context) {
 
 	nudge, err := c.service.UpdateNudgeStatus(ctx.Request.Context(), nudgeID, req.Status, orgID)
 	if err != nil {
+		if errors.Is(err, services.ErrNudgeNotFound) {
+			ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Nudge not found", nil, ctx.GetString("requestId")))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
 	}
@@ -168,7 +187,8 @@ func (c *NudgeController) UpdateNudgeStatus(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body dto.GenerateNudgesRequest true "Generation request"
-// @Success 202 {object} dto.ApiResponse{data=dto.IDResponse}
+// @Success 200 {object} dto.ApiResponse{data=dto.GenerateNudgesResponse}
+// @Success 202 {object} dto.ApiResponse{data=dto.GenerateNudgesResponse}
 // @Failure 400 {object} dto.ApiResponse
 // @Security BearerAuth
 // @Router /nudges/generate [post]
@@ -180,13 +200,25 @@ func (c *NudgeController) GenerateNudges(ctx *gin.Context) {
 	}
 
 	orgID := ctx.GetString("organizationId")
-	jobID, err := c.service.GenerateNudges(ctx.Request.Context(), req, orgID)
+	result, err := c.service.GenerateNudges(ctx.Request.Context(), req, orgID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Project not found", nil, ctx.GetString("requestId")))
+			return
+		}
+		if errors.Is(err, services.ErrInvalidNudgeGeneration) {
+			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
 	}
 
-	ctx.JSON(http.StatusAccepted, dto.NewSuccessResponse(dto.IDResponse{ID: jobID}, dto.ResponseMeta{
+	status := http.StatusOK
+	if req.Async {
+		status = http.StatusAccepted
+	}
+	ctx.JSON(status, dto.NewSuccessResponse(result, dto.ResponseMeta{
 		Timestamp: getTimestamp(),
 		RequestID: ctx.GetString("requestId"),
 	}))
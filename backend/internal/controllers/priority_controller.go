@@ -1,10 +1,12 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
 	"github.com/SimpleAjax/Xephyr/internal/dto"
 	"github.com/SimpleAjax/Xephyr/internal/services"
@@ -68,6 +70,10 @@ func (c *PriorityController) GetBulkTaskPriorities(ctx *gin.Context) {
 	orgID := ctx.GetString("organizationId")
 	priorities, err := c.service.GetBulkTaskPriorities(ctx.Request.Context(), req, orgID)
 	if err != nil {
+		if errors.Is(err, services.ErrTaskNotInOrganization) {
+			ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", err.Error(), nil, ctx.GetString("requestId")))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
 	}
@@ -143,6 +149,14 @@ func (c *PriorityController) RecalculatePriorities(ctx *gin.Context) {
 	orgID := ctx.GetString("organizationId")
 	syncResp, asyncResp, err := c.service.RecalculatePriorities(ctx.Request.Context(), req, orgID)
 	if err != nil {
+		if errors.Is(err, services.ErrTaskNotInOrganization) {
+			ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", err.Error(), nil, ctx.GetString("requestId")))
+			return
+		}
+		if errors.Is(err, services.ErrInvalidRecalculation) {
+			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
 	}
@@ -161,7 +175,109 @@ func (c *PriorityController) RecalculatePriorities(ctx *gin.Context) {
 	}))
 }
 
+// GetPriorityConfig godoc
+// @Summary Get priority scoring configuration
+// @Description Get the organization's priority weights and deadline urgency curve
+// @Tags priorities
+// @Produce json
+// @Success 200 {object} dto.ApiResponse{data=dto.PriorityConfigResponse}
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /priorities/config [get]
+func (c *PriorityController) GetPriorityConfig(ctx *gin.Context) {
+	orgID := ctx.GetString("organizationId")
+
+	cfg, err := c.service.GetPriorityConfig(ctx.Request.Context(), orgID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Organization not found", nil, ctx.GetString("requestId")))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(cfg, dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+// UpdatePriorityConfig godoc
+// @Summary Update priority scoring configuration
+// @Description Save the organization's priority weights and deadline urgency curve. Weights must sum to 100.
+// @Tags priorities
+// @Accept json
+// @Produce json
+// @Param request body dto.PriorityConfig true "Priority configuration"
+// @Success 200 {object} dto.ApiResponse{data=dto.PriorityConfigResponse}
+// @Failure 400 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /priorities/config [put]
+func (c *PriorityController) UpdatePriorityConfig(ctx *gin.Context) {
+	var req dto.PriorityConfig
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	orgID := ctx.GetString("organizationId")
+	cfg, err := c.service.UpdatePriorityConfig(ctx.Request.Context(), req, orgID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPriorityConfig) {
+			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(cfg, dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+// PreviewPriorityConfig godoc
+// @Summary Preview priority configuration
+// @Description Show how a project's task ranking would change under a candidate configuration without saving it
+// @Tags priorities
+// @Accept json
+// @Produce json
+// @Param request body dto.PriorityConfigPreviewRequest true "Preview request"
+// @Success 200 {object} dto.ApiResponse{data=dto.PriorityConfigPreviewResponse}
+// @Failure 400 {object} dto.ApiResponse
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /priorities/config/preview [post]
+func (c *PriorityController) PreviewPriorityConfig(ctx *gin.Context) {
+	var req dto.PriorityConfigPreviewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	orgID := ctx.GetString("organizationId")
+	preview, err := c.service.PreviewPriorityConfig(ctx.Request.Context(), req, orgID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPriorityConfig) {
+			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+			return
+		}
+		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Project not found", nil, ctx.GetString("requestId")))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(preview, dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
 // Helper function
 func getTimestamp() time.Time {
 	return time.Now().UTC()
 }
+
+// organizationUUID returns the caller's organization, which RequireOrganization has
+// already validated. A missing ID yields uuid.Nil, which matches no records.
+func organizationUUID(ctx *gin.Context) uuid.UUID {
+	orgID, _ := uuid.Parse(ctx.GetString("organizationId"))
+	return orgID
+}
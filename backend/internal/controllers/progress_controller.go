@@ -1,9 +1,11 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"github.com/SimpleAjax/Xephyr/internal/dto"
 	"github.com/SimpleAjax/Xephyr/internal/services"
@@ -21,11 +23,12 @@ func NewProgressController(service services.ProgressService) *ProgressController
 
 // GetProjectProgress godoc
 // @Summary Get project progress
-// @Description Get progress information for a project
+// @Description Get progress information for a project, rolled up from its tasks
 // @Tags progress
 // @Accept json
 // @Produce json
 // @Param projectId path string true "Project ID"
+// @Param method query string false "Calculation method: task_count, weighted_hours or business_value" default(weighted_hours)
 // @Success 200 {object} dto.ApiResponse{data=dto.ProjectProgressResponse}
 // @Failure 404 {object} dto.ApiResponse
 // @Security BearerAuth
@@ -34,9 +37,14 @@ func (c *ProgressController) GetProjectProgress(ctx *gin.Context) {
 	projectID := ctx.Param("projectId")
 	orgID := ctx.GetString("organizationId")
 
-	progress, err := c.service.GetProjectProgress(ctx.Request.Context(), projectID, orgID)
+	method, ok := c.method(ctx)
+	if !ok {
+		return
+	}
+
+	progress, err := c.service.GetProjectProgress(ctx.Request.Context(), projectID, method, orgID)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Project not found", nil, ctx.GetString("requestId")))
+		c.respondError(ctx, err, "Project not found")
 		return
 	}
 
@@ -63,7 +71,7 @@ func (c *ProgressController) GetTaskProgress(ctx *gin.Context) {
 
 	progress, err := c.service.GetTaskProgress(ctx.Request.Context(), taskID, orgID)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Task not found", nil, ctx.GetString("requestId")))
+		c.respondError(ctx, err, "Task not found")
 		return
 	}
 
@@ -98,7 +106,7 @@ func (c *ProgressController) UpdateTaskProgress(ctx *gin.Context) {
 
 	result, err := c.service.UpdateTaskProgress(ctx.Request.Context(), taskID, req, orgID, userID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		c.respondError(ctx, err, "Task not found")
 		return
 	}
 
@@ -110,11 +118,12 @@ func (c *ProgressController) UpdateTaskProgress(ctx *gin.Context) {
 
 // GetProjectRollup godoc
 // @Summary Get project rollup
-// @Description Get hierarchical progress rollup for a project
+// @Description Get hierarchical progress rollup for a project. Subtask progress rolls up into parents and the project.
 // @Tags progress
 // @Accept json
 // @Produce json
 // @Param projectId path string true "Project ID"
+// @Param method query string false "Calculation method: task_count, weighted_hours or business_value" default(weighted_hours)
 // @Success 200 {object} dto.ApiResponse{data=dto.ProjectRollupResponse}
 // @Failure 404 {object} dto.ApiResponse
 // @Security BearerAuth
@@ -123,9 +132,14 @@ func (c *ProgressController) GetProjectRollup(ctx *gin.Context) {
 	projectID := ctx.Param("projectId")
 	orgID := ctx.GetString("organizationId")
 
-	rollup, err := c.service.GetProjectRollup(ctx.Request.Context(), projectID, orgID)
+	method, ok := c.method(ctx)
+	if !ok {
+		return
+	}
+
+	rollup, err := c.service.GetProjectRollup(ctx.Request.Context(), projectID, method, orgID)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Project not found", nil, ctx.GetString("requestId")))
+		c.respondError(ctx, err, "Project not found")
 		return
 	}
 
@@ -134,3 +148,24 @@ func (c *ProgressController) GetProjectRollup(ctx *gin.Context) {
 		RequestID: ctx.GetString("requestId"),
 	}))
 }
+
+// method reads the calculation method from the query string
+func (c *ProgressController) method(ctx *gin.Context) (services.ProgressMethod, bool) {
+	method, err := services.ParseProgressMethod(ctx.Query("method"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return "", false
+	}
+	return method, true
+}
+
+func (c *ProgressController) respondError(ctx *gin.Context, err error, notFound string) {
+	if respondArchived(ctx, err) || respondWorkflowError(ctx, err) {
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", notFound, nil, ctx.GetString("requestId")))
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+}
@@ -1,26 +1,32 @@
 package controllers
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"github.com/SimpleAjax/Xephyr/internal/dto"
 	"github.com/SimpleAjax/Xephyr/internal/models"
 	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
 )
 
-// ProjectController handles project CRUD HTTP requests
+// ProjectController handles project CRUD and lifecycle HTTP requests
 type ProjectController struct {
-	repos repositories.Repositories
+	repos     repositories.Repositories
+	lifecycle services.ProjectLifecycleService
+	workflow  services.TaskWorkflowService
 }
 
 // NewProjectController creates a new project controller
-func NewProjectController(repos repositories.Repositories) *ProjectController {
-	return &ProjectController{repos: repos}
+func NewProjectController(repos repositories.Repositories, lifecycle services.ProjectLifecycleService, workflow services.TaskWorkflowService) *ProjectController {
+	return &ProjectController{repos: repos, lifecycle: lifecycle, workflow: workflow}
 }
 
 // ListProjects godoc
@@ -39,7 +45,7 @@ func (c *ProjectController) ListProjects(ctx *gin.Context) {
 	orgID := ctx.GetString("organizationId")
 	log.Printf("[ProjectController] ListProjects called with orgID from context: %s", orgID)
 	log.Printf("[ProjectController] X-Organization-Id header: %s", ctx.GetHeader("X-Organization-Id"))
-	
+
 	orgUUID, err := uuid.Parse(orgID)
 	if err != nil {
 		log.Printf("[ProjectController] Error parsing orgID: %v", err)
@@ -53,7 +59,7 @@ func (c *ProjectController) ListProjects(ctx *gin.Context) {
 	if err := ctx.ShouldBindQuery(&params); err != nil {
 		params = repositories.DefaultListParams()
 	}
-	
+
 	// Ensure valid pagination params
 	if params.Limit <= 0 {
 		params.Limit = 20
@@ -67,7 +73,7 @@ func (c *ProjectController) ListProjects(ctx *gin.Context) {
 	if params.SortOrder == "" {
 		params.SortOrder = "desc"
 	}
-	
+
 	log.Printf("[ProjectController] Using params: limit=%d, offset=%d, sort=%s %s", params.Limit, params.Offset, params.SortBy, params.SortOrder)
 
 	var projects []models.Project
@@ -75,7 +81,7 @@ func (c *ProjectController) ListProjects(ctx *gin.Context) {
 
 	if status != "" {
 		projectStatus := models.ProjectStatus(status)
-		projects, total, err = c.repos.GetProject().ListByStatus(ctx.Request.Context(), projectStatus, params)
+		projects, total, err = c.repos.GetProject().ListByStatus(ctx.Request.Context(), orgUUID, projectStatus, params)
 	} else {
 		projects, total, err = c.repos.GetProject().ListByOrganization(ctx.Request.Context(), orgUUID, params)
 	}
@@ -124,7 +130,7 @@ func (c *ProjectController) GetProject(ctx *gin.Context) {
 		return
 	}
 
-	project, err := c.repos.GetProject().GetByID(ctx.Request.Context(), projUUID)
+	project, err := c.repos.GetProject().GetByID(ctx.Request.Context(), organizationUUID(ctx), projUUID)
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Project not found", nil, ctx.GetString("requestId")))
 		return
@@ -178,7 +184,7 @@ func (c *ProjectController) CreateProject(ctx *gin.Context) {
 		project.TargetEndDate = req.TargetEndDate
 	}
 
-	if err := c.repos.GetProject().Create(ctx.Request.Context(), project); err != nil {
+	if err := c.repos.GetProject().Create(ctx.Request.Context(), orgUUID, project); err != nil {
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
 	}
@@ -191,7 +197,7 @@ func (c *ProjectController) CreateProject(ctx *gin.Context) {
 
 // UpdateProject godoc
 // @Summary Update a project
-// @Description Update an existing project
+// @Description Update an existing project. A status change must be a valid lifecycle transition; archived projects only accept a status change.
 // @Tags projects
 // @Accept json
 // @Produce json
@@ -199,6 +205,7 @@ func (c *ProjectController) CreateProject(ctx *gin.Context) {
 // @Param request body UpdateProjectRequest true "Project update request"
 // @Success 200 {object} dto.ApiResponse{data=ProjectResponse}
 // @Failure 404 {object} dto.ApiResponse
+// @Failure 409 {object} dto.ApiResponse
 // @Security BearerAuth
 // @Router /projects/{projectId} [patch]
 func (c *ProjectController) UpdateProject(ctx *gin.Context) {
@@ -209,7 +216,7 @@ func (c *ProjectController) UpdateProject(ctx *gin.Context) {
 		return
 	}
 
-	project, err := c.repos.GetProject().GetByID(ctx.Request.Context(), projUUID)
+	project, err := c.repos.GetProject().GetByID(ctx.Request.Context(), organizationUUID(ctx), projUUID)
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Project not found", nil, ctx.GetString("requestId")))
 		return
@@ -222,14 +229,14 @@ func (c *ProjectController) UpdateProject(ctx *gin.Context) {
 	}
 
 	// Update fields
+	changed := false
 	if req.Name != "" {
 		project.Name = req.Name
+		changed = true
 	}
 	if req.Description != "" {
 		project.Description = req.Description
-	}
-	if req.Status != "" {
-		project.Status = models.ProjectStatus(req.Status)
+		changed = true
 	}
 	if req.Priority != 0 {
 		project.Priority = req.Priority
@@ -243,10 +250,24 @@ func (c *ProjectController) UpdateProject(ctx *gin.Context) {
 	if req.TargetEndDate != nil {
 		project.TargetEndDate = req.TargetEndDate
 	}
+	if req.AutoSchedule != nil {
+		project.AutoSchedule = *req.AutoSchedule
+	}
+	changed = changed || req.Priority != 0 || req.HealthScore != nil || req.Progress != nil || req.TargetEndDate != nil || req.AutoSchedule != nil
 
-	if err := c.repos.GetProject().Update(ctx.Request.Context(), project); err != nil {
-		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
-		return
+	// Fields are saved before the status changes, so a project can be edited and
+	// archived in one request but must be unarchived before it is edited
+	if changed {
+		if err := c.repos.GetProject().Update(ctx.Request.Context(), organizationUUID(ctx), project); err != nil {
+			c.respondError(ctx, err)
+			return
+		}
+	}
+	if req.Status != "" {
+		if project, err = c.lifecycle.Transition(ctx.Request.Context(), organizationUUID(ctx), projUUID, models.ProjectStatus(req.Status)); err != nil {
+			c.respondError(ctx, err)
+			return
+		}
 	}
 
 	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(toProjectResponse(project), dto.ResponseMeta{
@@ -257,7 +278,7 @@ func (c *ProjectController) UpdateProject(ctx *gin.Context) {
 
 // DeleteProject godoc
 // @Summary Delete a project
-// @Description Soft-delete a project
+// @Description Soft-delete a project with its team, tasks, dependencies and nudges. The project can be restored.
 // @Tags projects
 // @Accept json
 // @Produce json
@@ -274,14 +295,286 @@ func (c *ProjectController) DeleteProject(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.repos.GetProject().Delete(ctx.Request.Context(), projUUID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+	if err := c.lifecycle.Delete(ctx.Request.Context(), organizationUUID(ctx), projUUID); err != nil {
+		c.respondError(ctx, err)
 		return
 	}
 
 	ctx.Status(http.StatusNoContent)
 }
 
+// UpdateProjectStatus godoc
+// @Summary Change a project's status
+// @Description Pause, resume, complete, reopen, archive or unarchive a project. Archived projects are read-only.
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param projectId path string true "Project ID"
+// @Param request body UpdateProjectStatusRequest true "New status"
+// @Success 200 {object} dto.ApiResponse{data=ProjectResponse}
+// @Failure 404 {object} dto.ApiResponse
+// @Failure 409 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /projects/{projectId}/status [post]
+func (c *ProjectController) UpdateProjectStatus(ctx *gin.Context) {
+	projUUID, err := uuid.Parse(ctx.Param("projectId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid project ID", nil, ctx.GetString("requestId")))
+		return
+	}
+
+	var req UpdateProjectStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	project, err := c.lifecycle.Transition(ctx.Request.Context(), organizationUUID(ctx), projUUID, models.ProjectStatus(req.Status))
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(toProjectResponse(project), dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+// RestoreProject godoc
+// @Summary Restore a deleted project
+// @Description Undelete a project together with everything that was deleted along with it
+// @Tags projects
+// @Produce json
+// @Param projectId path string true "Project ID"
+// @Success 200 {object} dto.ApiResponse{data=ProjectResponse}
+// @Failure 404 {object} dto.ApiResponse
+// @Failure 409 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /projects/{projectId}/restore [post]
+func (c *ProjectController) RestoreProject(ctx *gin.Context) {
+	projUUID, err := uuid.Parse(ctx.Param("projectId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid project ID", nil, ctx.GetString("requestId")))
+		return
+	}
+
+	project, err := c.lifecycle.Restore(ctx.Request.Context(), organizationUUID(ctx), projUUID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(toProjectResponse(project), dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+// CloneProject godoc
+// @Summary Clone a project
+// @Description Copy a project's task hierarchy, skill requirements and internal dependencies into a new project, shifting dates to the new start date
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param projectId path string true "Project ID"
+// @Param request body CloneProjectRequest true "Clone request"
+// @Success 201 {object} dto.ApiResponse{data=CloneProjectResponse}
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /projects/{projectId}/clone [post]
+func (c *ProjectController) CloneProject(ctx *gin.Context) {
+	projUUID, err := uuid.Parse(ctx.Param("projectId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid project ID", nil, ctx.GetString("requestId")))
+		return
+	}
+
+	var req CloneProjectRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	result, err := c.lifecycle.Clone(ctx.Request.Context(), organizationUUID(ctx), projUUID, req.Name, req.StartDate)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, dto.NewSuccessResponse(CloneProjectResponse{
+		Project:            toProjectResponse(result.Project),
+		TasksCopied:        result.Tasks,
+		SkillsCopied:       result.Skills,
+		DependenciesCopied: result.Dependencies,
+	}, dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+func (c *ProjectController) respondError(ctx *gin.Context, err error) {
+	if respondArchived(ctx, err) {
+		return
+	}
+	status, code := http.StatusInternalServerError, "INTERNAL_ERROR"
+	switch {
+	case errors.Is(err, services.ErrInvalidProjectTransition):
+		status, code = http.StatusConflict, "INVALID_STATUS_TRANSITION"
+	case errors.Is(err, repositories.ErrProjectNotDeleted):
+		status, code = http.StatusConflict, "PROJECT_NOT_DELETED"
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status, code = http.StatusNotFound, "NOT_FOUND"
+	}
+	ctx.JSON(status, dto.NewErrorResponse(code, err.Error(), nil, ctx.GetString("requestId")))
+}
+
+// GetProjectWorkflow godoc
+// @Summary Get a project's task workflow
+// @Description Get the statuses tasks in the project move through and the transitions allowed between them. Projects without their own workflow use the default one.
+// @Tags projects
+// @Produce json
+// @Param projectId path string true "Project ID"
+// @Success 200 {object} dto.ApiResponse{data=WorkflowResponse}
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /projects/{projectId}/workflow [get]
+func (c *ProjectController) GetProjectWorkflow(ctx *gin.Context) {
+	projUUID, err := uuid.Parse(ctx.Param("projectId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid project ID", nil, ctx.GetString("requestId")))
+		return
+	}
+
+	workflow, err := c.workflow.Workflow(ctx.Request.Context(), organizationUUID(ctx), projUUID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	c.respondWorkflow(ctx, workflow)
+}
+
+// UpdateProjectWorkflow godoc
+// @Summary Define a project's task workflow
+// @Description Replace the project's workflow with custom statuses, each mapped onto a canonical category, and the transitions allowed between them. Guards are assignee_required, subtasks_done and predecessors_done.
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param projectId path string true "Project ID"
+// @Param request body UpdateWorkflowRequest true "Workflow"
+// @Success 200 {object} dto.ApiResponse{data=WorkflowResponse}
+// @Failure 400 {object} dto.ApiResponse
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /projects/{projectId}/workflow [put]
+func (c *ProjectController) UpdateProjectWorkflow(ctx *gin.Context) {
+	projUUID, err := uuid.Parse(ctx.Param("projectId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid project ID", nil, ctx.GetString("requestId")))
+		return
+	}
+
+	var req UpdateWorkflowRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	workflow := &models.Workflow{ProjectID: projUUID}
+	for _, status := range req.Statuses {
+		workflow.Statuses = append(workflow.Statuses, models.WorkflowStatus{
+			Key:      status.Key,
+			Name:     status.Name,
+			Category: models.TaskStatus(status.Category),
+		})
+	}
+	for _, transition := range req.Transitions {
+		workflow.Transitions = append(workflow.Transitions, models.WorkflowTransition{
+			FromStatus: transition.From,
+			ToStatus:   transition.To,
+			Guards:     strings.Join(transition.Guards, ","),
+		})
+	}
+
+	workflow, err = c.workflow.SetWorkflow(ctx.Request.Context(), organizationUUID(ctx), workflow)
+	if err != nil {
+		if respondWorkflowError(ctx, err) {
+			return
+		}
+		c.respondError(ctx, err)
+		return
+	}
+	c.respondWorkflow(ctx, workflow)
+}
+
+// ResetProjectWorkflow godoc
+// @Summary Reset a project's task workflow
+// @Description Return the project to the default workflow. Tasks keep their canonical status.
+// @Tags projects
+// @Produce json
+// @Param projectId path string true "Project ID"
+// @Success 200 {object} dto.ApiResponse{data=WorkflowResponse}
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /projects/{projectId}/workflow [delete]
+func (c *ProjectController) ResetProjectWorkflow(ctx *gin.Context) {
+	projUUID, err := uuid.Parse(ctx.Param("projectId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid project ID", nil, ctx.GetString("requestId")))
+		return
+	}
+
+	if err := c.workflow.ResetWorkflow(ctx.Request.Context(), organizationUUID(ctx), projUUID); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	c.respondWorkflow(ctx, services.DefaultWorkflow(projUUID))
+}
+
+func (c *ProjectController) respondWorkflow(ctx *gin.Context, workflow *models.Workflow) {
+	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(toWorkflowResponse(workflow), dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+// respondWorkflowError answers a rejected workflow definition or task status change
+// with a code naming the reason
+func respondWorkflowError(ctx *gin.Context, err error) bool {
+	status, code := http.StatusConflict, ""
+	switch {
+	case errors.Is(err, services.ErrInvalidWorkflow):
+		status, code = http.StatusBadRequest, "INVALID_WORKFLOW"
+	case errors.Is(err, services.ErrUnknownTaskStatus):
+		status, code = http.StatusBadRequest, "UNKNOWN_STATUS"
+	case errors.Is(err, services.ErrInvalidTaskTransition):
+		code = "INVALID_STATUS_TRANSITION"
+	case errors.Is(err, services.ErrAssigneeRequired):
+		code = "ASSIGNEE_REQUIRED"
+	case errors.Is(err, services.ErrSubtasksIncomplete):
+		code = "SUBTASKS_INCOMPLETE"
+	case errors.Is(err, services.ErrPredecessorsIncomplete):
+		code = "PREDECESSORS_INCOMPLETE"
+	default:
+		return false
+	}
+	ctx.JSON(status, dto.NewErrorResponse(code, err.Error(), nil, ctx.GetString("requestId")))
+	return true
+}
+
+// respondArchived answers 409 when err is a write to an archived project or task
+func respondArchived(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, repositories.ErrProjectArchived):
+		ctx.JSON(http.StatusConflict, dto.NewErrorResponse("PROJECT_ARCHIVED", "Project is archived and read-only", nil, ctx.GetString("requestId")))
+	case errors.Is(err, repositories.ErrTaskArchived):
+		ctx.JSON(http.StatusConflict, dto.NewErrorResponse("TASK_ARCHIVED", "Task is archived and read-only", nil, ctx.GetString("requestId")))
+	default:
+		return false
+	}
+	return true
+}
+
 // GetProjectTeam godoc
 // @Summary Get project team members
 // @Description Get all team members assigned to a project
@@ -301,7 +594,7 @@ func (c *ProjectController) GetProjectTeam(ctx *gin.Context) {
 		return
 	}
 
-	project, err := c.repos.GetProject().GetByID(ctx.Request.Context(), projUUID)
+	project, err := c.repos.GetProject().GetByID(ctx.Request.Context(), organizationUUID(ctx), projUUID)
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Project not found", nil, ctx.GetString("requestId")))
 		return
@@ -343,6 +636,51 @@ type UpdateProjectRequest struct {
 	HealthScore   *int       `json:"healthScore"`
 	Progress      *int       `json:"progress"`
 	TargetEndDate *time.Time `json:"targetEndDate"`
+	AutoSchedule  *bool      `json:"autoSchedule"` // move dependent tasks when a task's dates or status change
+}
+
+type UpdateProjectStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active paused completed archived"`
+}
+
+// UpdateWorkflowRequest defines a project's task workflow
+type UpdateWorkflowRequest struct {
+	Statuses    []WorkflowStatusDefinition     `json:"statuses" binding:"required,min=1,dive"`
+	Transitions []WorkflowTransitionDefinition `json:"transitions" binding:"dive"`
+}
+
+// WorkflowStatusDefinition is a workflow status and the canonical category it counts as
+type WorkflowStatusDefinition struct {
+	Key      string `json:"key" binding:"required"`
+	Name     string `json:"name"`
+	Category string `json:"category" binding:"required,oneof=backlog ready in_progress review done"`
+}
+
+// WorkflowTransitionDefinition allows a move between two statuses once its guards pass
+type WorkflowTransitionDefinition struct {
+	From   string   `json:"from" binding:"required"`
+	To     string   `json:"to" binding:"required"`
+	Guards []string `json:"guards,omitempty"`
+}
+
+// WorkflowResponse is a project's task workflow
+type WorkflowResponse struct {
+	ProjectID   string                         `json:"projectId"`
+	IsDefault   bool                           `json:"isDefault"`
+	Statuses    []WorkflowStatusDefinition     `json:"statuses"`
+	Transitions []WorkflowTransitionDefinition `json:"transitions"`
+}
+
+type CloneProjectRequest struct {
+	Name      string    `json:"name"`
+	StartDate time.Time `json:"startDate" binding:"required"`
+}
+
+type CloneProjectResponse struct {
+	Project            ProjectResponse `json:"project"`
+	TasksCopied        int             `json:"tasksCopied"`
+	SkillsCopied       int             `json:"skillsCopied"`
+	DependenciesCopied int             `json:"dependenciesCopied"`
 }
 
 type ProjectResponse struct {
@@ -356,6 +694,7 @@ type ProjectResponse struct {
 	StartDate     *time.Time `json:"startDate,omitempty"`
 	TargetEndDate *time.Time `json:"targetEndDate,omitempty"`
 	Budget        float64    `json:"budget"`
+	AutoSchedule  bool       `json:"autoSchedule"`
 	CreatedAt     time.Time  `json:"createdAt"`
 	UpdatedAt     time.Time  `json:"updatedAt"`
 }
@@ -387,7 +726,36 @@ func toProjectResponse(p *models.Project) ProjectResponse {
 		StartDate:     p.StartDate,
 		TargetEndDate: p.TargetEndDate,
 		Budget:        p.Budget,
+		AutoSchedule:  p.AutoSchedule,
 		CreatedAt:     p.CreatedAt,
 		UpdatedAt:     p.UpdatedAt,
 	}
 }
+
+func toWorkflowResponse(w *models.Workflow) WorkflowResponse {
+	resp := WorkflowResponse{
+		ProjectID:   w.ProjectID.String(),
+		IsDefault:   w.ID == uuid.Nil,
+		Statuses:    make([]WorkflowStatusDefinition, 0, len(w.Statuses)),
+		Transitions: make([]WorkflowTransitionDefinition, 0, len(w.Transitions)),
+	}
+	for _, status := range w.Statuses {
+		resp.Statuses = append(resp.Statuses, WorkflowStatusDefinition{
+			Key:      status.Key,
+			Name:     status.Name,
+			Category: string(status.Category),
+		})
+	}
+	for _, transition := range w.Transitions {
+		var guards []string
+		for _, guard := range transition.GuardList() {
+			guards = append(guards, string(guard))
+		}
+		resp.Transitions = append(resp.Transitions, WorkflowTransitionDefinition{
+			From:   transition.FromStatus,
+			To:     transition.ToStatus,
+			Guards: guards,
+		})
+	}
+	return resp
+}
@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// ProjectTemplateController handles project template HTTP requests
+type ProjectTemplateController struct {
+	service services.ProjectTemplateService
+}
+
+// NewProjectTemplateController creates a new project template controller
+func NewProjectTemplateController(service services.ProjectTemplateService) *ProjectTemplateController {
+	return &ProjectTemplateController{service: service}
+}
+
+// ListTemplates godoc
+// @Summary List project templates
+// @Description List the organization's project templates
+// @Tags project-templates
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page"
+// @Success 200 {object} dto.ApiResponse{data=dto.ProjectTemplateListResponse}
+// @Security BearerAuth
+// @Router /project-templates [get]
+func (c *ProjectTemplateController) ListTemplates(ctx *gin.Context) {
+	var params repositories.ListParams
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		params = repositories.DefaultListParams()
+	}
+
+	templates, err := c.service.List(ctx.Request.Context(), organizationUUID(ctx), params)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	c.respond(ctx, http.StatusOK, templates)
+}
+
+// CreateTemplate godoc
+// @Summary Save a project as a template
+// @Description Capture a project's task tree, skill requirements, milestones and dependencies as a template. Dates become day offsets from the project start.
+// @Tags project-templates
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateTemplateRequest true "Source project"
+// @Success 201 {object} dto.ApiResponse{data=dto.ProjectTemplateResponse}
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /project-templates [post]
+func (c *ProjectTemplateController) CreateTemplate(ctx *gin.Context) {
+	var req dto.CreateTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	template, err := c.service.CreateFromProject(ctx.Request.Context(), organizationUUID(ctx), c.actorID(ctx), req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	c.respond(ctx, http.StatusCreated, template)
+}
+
+// GetTemplate godoc
+// @Summary Get a project template
+// @Description Get a template with its tasks and dependencies. Task keys are template task IDs.
+// @Tags project-templates
+// @Produce json
+// @Param templateId path string true "Template ID"
+// @Success 200 {object} dto.ApiResponse{data=dto.ProjectTemplateResponse}
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /project-templates/{templateId} [get]
+func (c *ProjectTemplateController) GetTemplate(ctx *gin.Context) {
+	templateID, ok := c.templateID(ctx)
+	if !ok {
+		return
+	}
+
+	template, err := c.service.Get(ctx.Request.Context(), organizationUUID(ctx), templateID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	c.respond(ctx, http.StatusOK, template)
+}
+
+// DeleteTemplate godoc
+// @Summary Delete a project template
+// @Description Delete a template. Projects created from it are unaffected.
+// @Tags project-templates
+// @Param templateId path string true "Template ID"
+// @Success 204
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /project-templates/{templateId} [delete]
+func (c *ProjectTemplateController) DeleteTemplate(ctx *gin.Context) {
+	templateID, ok := c.templateID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.service.Delete(ctx.Request.Context(), organizationUUID(ctx), templateID); err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// InstantiateTemplate godoc
+// @Summary Create a project from a template
+// @Description Create a new project from a template, placing task dates relative to the start date
+// @Tags project-templates
+// @Accept json
+// @Produce json
+// @Param templateId path string true "Template ID"
+// @Param request body dto.InstantiateTemplateRequest true "New project"
+// @Success 201 {object} dto.ApiResponse{data=InstantiateTemplateResponse}
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /project-templates/{templateId}/instantiate [post]
+func (c *ProjectTemplateController) InstantiateTemplate(ctx *gin.Context) {
+	templateID, ok := c.templateID(ctx)
+	if !ok {
+		return
+	}
+
+	var req dto.InstantiateTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	result, err := c.service.Instantiate(ctx.Request.Context(), organizationUUID(ctx), templateID, req)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	c.respond(ctx, http.StatusCreated, InstantiateTemplateResponse{
+		Project:             toProjectResponse(result.Project),
+		TasksCreated:        result.Tasks,
+		SkillsCreated:       result.Skills,
+		DependenciesCreated: result.Dependencies,
+	})
+}
+
+// ExportTemplate godoc
+// @Summary Export a project template
+// @Description Download a template as a JSON document that can be imported into any organization
+// @Tags project-templates
+// @Produce json
+// @Param templateId path string true "Template ID"
+// @Success 200 {object} dto.ProjectTemplateDocument
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /project-templates/{templateId}/export [get]
+func (c *ProjectTemplateController) ExportTemplate(ctx *gin.Context) {
+	templateID, ok := c.templateID(ctx)
+	if !ok {
+		return
+	}
+
+	doc, err := c.service.Export(ctx.Request.Context(), organizationUUID(ctx), templateID)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+
+	// The document is served bare so the download can be imported as is
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", templateFilename(doc.Name)))
+	ctx.JSON(http.StatusOK, doc)
+}
+
+// ImportTemplate godoc
+// @Summary Import a project template
+// @Description Save an exported template document in the organization. Skills are matched by name and created when missing.
+// @Tags project-templates
+// @Accept json
+// @Produce json
+// @Param request body dto.ProjectTemplateDocument true "Template document"
+// @Success 201 {object} dto.ApiResponse{data=dto.ProjectTemplateResponse}
+// @Failure 400 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /project-templates/import [post]
+func (c *ProjectTemplateController) ImportTemplate(ctx *gin.Context) {
+	var doc dto.ProjectTemplateDocument
+	if err := ctx.ShouldBindJSON(&doc); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	template, err := c.service.Import(ctx.Request.Context(), organizationUUID(ctx), c.actorID(ctx), doc)
+	if err != nil {
+		c.respondError(ctx, err)
+		return
+	}
+	c.respond(ctx, http.StatusCreated, template)
+}
+
+// InstantiateTemplateResponse is a project created from a template
+type InstantiateTemplateResponse struct {
+	Project             ProjectResponse `json:"project"`
+	TasksCreated        int             `json:"tasksCreated"`
+	SkillsCreated       int             `json:"skillsCreated"`
+	DependenciesCreated int             `json:"dependenciesCreated"`
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// templateFilename turns a template name into a download filename
+func templateFilename(name string) string {
+	slug := strings.Trim(unsafeFilenameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if slug == "" {
+		slug = "project-template"
+	}
+	return slug + ".json"
+}
+
+func (c *ProjectTemplateController) templateID(ctx *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param("templateId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid template ID", nil, ctx.GetString("requestId")))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// actorID is the authenticated caller; AuthMiddleware guarantees it parses
+func (c *ProjectTemplateController) actorID(ctx *gin.Context) uuid.UUID {
+	userID, _ := uuid.Parse(ctx.GetString("userId"))
+	return userID
+}
+
+func (c *ProjectTemplateController) respond(ctx *gin.Context, status int, data interface{}) {
+	ctx.JSON(status, dto.NewSuccessResponse(data, dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+func (c *ProjectTemplateController) respondError(ctx *gin.Context, err error) {
+	status, code := http.StatusInternalServerError, "INTERNAL_ERROR"
+	switch {
+	case errors.Is(err, services.ErrInvalidTemplate):
+		status, code = http.StatusBadRequest, "INVALID_TEMPLATE"
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status, code = http.StatusNotFound, "NOT_FOUND"
+	}
+	ctx.JSON(status, dto.NewErrorResponse(code, err.Error(), nil, ctx.GetString("requestId")))
+}
@@ -1,10 +1,12 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"github.com/SimpleAjax/Xephyr/internal/dto"
 	"github.com/SimpleAjax/Xephyr/internal/services"
@@ -142,6 +144,10 @@ func (c *ScenarioController) SimulateScenario(ctx *gin.Context) {
 
 	result, err := c.service.SimulateScenario(ctx.Request.Context(), scenarioID, req, orgID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Scenario not found", nil, ctx.GetString("requestId")))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
 	}
@@ -178,6 +184,10 @@ func (c *ScenarioController) ApplyScenario(ctx *gin.Context) {
 
 	result, err := c.service.ApplyScenario(ctx.Request.Context(), scenarioID, req, orgID, appliedBy)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Scenario not found", nil, ctx.GetString("requestId")))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
 	}
@@ -241,6 +251,10 @@ func (c *ScenarioController) ModifyScenario(ctx *gin.Context) {
 
 	scenario, err := c.service.ModifyScenario(ctx.Request.Context(), scenarioID, req, orgID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Scenario not found", nil, ctx.GetString("requestId")))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
 	}
@@ -127,14 +127,14 @@ func (c *SkillController) GetSkillGaps(ctx *gin.Context) {
 
 	// For each project, check task skills
 	for _, project := range projects {
-		tasks, _, err := c.repos.GetTask().ListByProject(ctx.Request.Context(), project.ID, repositories.ListParams{Limit: 100})
+		tasks, _, err := c.repos.GetTask().ListByProject(ctx.Request.Context(), orgUUID, project.ID, repositories.ListParams{Limit: 100})
 		if err != nil {
 			continue
 		}
 
 		for _, task := range tasks {
 			// Get task with skills
-			taskWithSkills, err := c.repos.GetTask().GetByID(ctx.Request.Context(), task.ID)
+			taskWithSkills, err := c.repos.GetTask().GetByID(ctx.Request.Context(), orgUUID, task.ID)
 			if err != nil {
 				continue
 			}
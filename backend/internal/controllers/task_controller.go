@@ -1,25 +1,35 @@
 package controllers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"github.com/SimpleAjax/Xephyr/internal/dto"
 	"github.com/SimpleAjax/Xephyr/internal/models"
 	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
 )
 
 // TaskController handles task CRUD HTTP requests
 type TaskController struct {
-	repos repositories.Repositories
+	repos        repositories.Repositories
+	hierarchy    services.TaskHierarchyService
+	workflow     services.TaskWorkflowService
+	progress     services.ProgressService
+	history      services.TaskHistoryService
+	dependencies services.DependencyService
+	schedule     services.TaskScheduleService
 }
 
 // NewTaskController creates a new task controller
-func NewTaskController(repos repositories.Repositories) *TaskController {
-	return &TaskController{repos: repos}
+func NewTaskController(repos repositories.Repositories, hierarchy services.TaskHierarchyService, workflow services.TaskWorkflowService, progress services.ProgressService, history services.TaskHistoryService, dependencies services.DependencyService, schedule services.TaskScheduleService) *TaskController {
+	return &TaskController{repos: repos, hierarchy: hierarchy, workflow: workflow, progress: progress, history: history, dependencies: dependencies, schedule: schedule}
 }
 
 // ListTasks godoc
@@ -49,6 +59,7 @@ func (c *TaskController) ListTasks(ctx *gin.Context) {
 		params.Limit = 50
 	}
 
+	orgUUID := organizationUUID(ctx)
 	var tasks []models.Task
 	var total int64
 	var err error
@@ -59,19 +70,19 @@ func (c *TaskController) ListTasks(ctx *gin.Context) {
 			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid project ID", nil, ctx.GetString("requestId")))
 			return
 		}
-		tasks, total, err = c.repos.GetTask().ListByProject(ctx.Request.Context(), projUUID, params)
+		tasks, total, err = c.repos.GetTask().ListByProject(ctx.Request.Context(), orgUUID, projUUID, params)
 	} else if assigneeID != "" {
 		userUUID, err := uuid.Parse(assigneeID)
 		if err != nil {
 			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid assignee ID", nil, ctx.GetString("requestId")))
 			return
 		}
-		tasks, total, err = c.repos.GetTask().ListByAssignee(ctx.Request.Context(), userUUID, params)
+		tasks, total, err = c.repos.GetTask().ListByAssignee(ctx.Request.Context(), orgUUID, userUUID, params)
 	} else if status != "" {
 		taskStatus := models.TaskStatus(status)
-		tasks, total, err = c.repos.GetTask().ListByStatus(ctx.Request.Context(), taskStatus, params)
+		tasks, total, err = c.repos.GetTask().ListByStatus(ctx.Request.Context(), orgUUID, taskStatus, params)
 	} else {
-		tasks, total, err = c.repos.GetTask().List(ctx.Request.Context(), params)
+		tasks, total, err = c.repos.GetTask().List(ctx.Request.Context(), orgUUID, params)
 	}
 
 	if err != nil {
@@ -113,7 +124,7 @@ func (c *TaskController) GetTask(ctx *gin.Context) {
 		return
 	}
 
-	task, err := c.repos.GetTask().GetByID(ctx.Request.Context(), taskUUID)
+	task, err := c.repos.GetTask().GetByID(ctx.Request.Context(), organizationUUID(ctx), taskUUID)
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Task not found", nil, ctx.GetString("requestId")))
 		return
@@ -127,7 +138,7 @@ func (c *TaskController) GetTask(ctx *gin.Context) {
 
 // CreateTask godoc
 // @Summary Create a new task
-// @Description Create a new task in a project
+// @Description Create a new task in a project. A subtask's parent must be in the same project and its hierarchy level is derived from the parent, at most three levels deep. The status is a status of the project's workflow, by key or canonical category, and defaults to backlog.
 // @Tags tasks
 // @Accept json
 // @Produce json
@@ -156,18 +167,16 @@ func (c *TaskController) CreateTask(ctx *gin.Context) {
 		Status:         models.TaskStatusBacklog,
 		Priority:       models.TaskPriority(req.Priority),
 		EstimatedHours: req.EstimatedHours,
-		HierarchyLevel: req.HierarchyLevel,
 		IsMilestone:    req.IsMilestone,
 	}
 
-	if req.Status != "" {
-		task.Status = models.TaskStatus(req.Status)
-	}
 	if req.ParentTaskID != "" {
 		parentUUID, err := uuid.Parse(req.ParentTaskID)
-		if err == nil {
-			task.ParentTaskID = &parentUUID
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid parent task ID", nil, ctx.GetString("requestId")))
+			return
 		}
+		task.ParentTaskID = &parentUUID
 	}
 	if req.AssigneeID != "" {
 		assigneeUUID, err := uuid.Parse(req.AssigneeID)
@@ -176,10 +185,32 @@ func (c *TaskController) CreateTask(ctx *gin.Context) {
 		}
 	}
 
-	if err := c.repos.GetTask().Create(ctx.Request.Context(), task); err != nil {
+	if task.AssigneeID != nil && !c.requireMember(ctx, *task.AssigneeID) {
+		return
+	}
+	if err := c.workflow.InitialStatus(ctx.Request.Context(), organizationUUID(ctx), task, req.Status); err != nil {
+		if !respondWorkflowError(ctx, err) {
+			ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		}
+		return
+	}
+
+	if err := c.hierarchy.Create(ctx.Request.Context(), organizationUUID(ctx), task); err != nil {
+		if respondArchived(ctx, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidHierarchy) {
+			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("INVALID_HIERARCHY", err.Error(), nil, ctx.GetString("requestId")))
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Project not found", nil, ctx.GetString("requestId")))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
 	}
+	c.refreshProjects(ctx, task.ProjectID)
 
 	ctx.JSON(http.StatusCreated, dto.NewSuccessResponse(toTaskResponse(task), dto.ResponseMeta{
 		Timestamp: getTimestamp(),
@@ -189,13 +220,14 @@ func (c *TaskController) CreateTask(ctx *gin.Context) {
 
 // UpdateTask godoc
 // @Summary Update a task
-// @Description Update an existing task
+// @Description Update an existing task. A status change follows the project's workflow and is applied after the other fields. In an auto-scheduled project, tasks depending on this one move with its new dates or status unless their dates are pinned. Only project leads, PMs and admins may edit tasks; assignees report status and progress through their own endpoints.
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param taskId path string true "Task ID"
 // @Param request body UpdateTaskRequest true "Task update request"
 // @Success 200 {object} dto.ApiResponse{data=TaskResponse}
+// @Failure 403 {object} dto.ApiResponse
 // @Failure 404 {object} dto.ApiResponse
 // @Security BearerAuth
 // @Router /tasks/{taskId} [patch]
@@ -207,7 +239,7 @@ func (c *TaskController) UpdateTask(ctx *gin.Context) {
 		return
 	}
 
-	task, err := c.repos.GetTask().GetByID(ctx.Request.Context(), taskUUID)
+	task, err := c.repos.GetTask().GetByID(ctx.Request.Context(), organizationUUID(ctx), taskUUID)
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Task not found", nil, ctx.GetString("requestId")))
 		return
@@ -220,15 +252,13 @@ func (c *TaskController) UpdateTask(ctx *gin.Context) {
 	}
 
 	// Update fields
+	before := *task
 	if req.Title != "" {
 		task.Title = req.Title
 	}
 	if req.Description != "" {
 		task.Description = req.Description
 	}
-	if req.Status != "" {
-		task.Status = models.TaskStatus(req.Status)
-	}
 	if req.Priority != "" {
 		task.Priority = models.TaskPriority(req.Priority)
 	}
@@ -248,11 +278,46 @@ func (c *TaskController) UpdateTask(ctx *gin.Context) {
 			}
 		}
 	}
+	if req.StartDate != nil {
+		task.StartDate = req.StartDate
+	}
+	if req.DueDate != nil {
+		task.DueDate = req.DueDate
+	}
+	if req.DatesPinned != nil {
+		task.DatesPinned = *req.DatesPinned
+	}
+
+	if task.AssigneeID != nil && !c.requireMember(ctx, *task.AssigneeID) {
+		return
+	}
 
-	if err := c.repos.GetTask().Update(ctx.Request.Context(), task); err != nil {
+	if err := c.repos.GetTask().Update(ctx.Request.Context(), organizationUUID(ctx), task); err != nil {
+		if respondArchived(ctx, err) {
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Task not found", nil, ctx.GetString("requestId")))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
 	}
+	c.recordChanges(ctx, &before, task)
+
+	// The status moves last so a task can be assigned and started in one update
+	if req.Status != "" {
+		moved, err := c.workflow.Transition(ctx.Request.Context(), organizationUUID(ctx), taskUUID, c.actorID(ctx), req.Status)
+		if err != nil {
+			c.cascade(ctx, &before)
+			c.refreshProjects(ctx, task.ProjectID)
+			c.respondStatusError(ctx, err)
+			return
+		}
+		task = moved
+	}
+	c.cascade(ctx, &before)
+	c.refreshProjects(ctx, task.ProjectID)
 
 	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(toTaskResponse(task), dto.ResponseMeta{
 		Timestamp: getTimestamp(),
@@ -262,13 +327,15 @@ func (c *TaskController) UpdateTask(ctx *gin.Context) {
 
 // UpdateTaskStatus godoc
 // @Summary Update task status
-// @Description Update just the status of a task
+// @Description Move a task to a status of its project's workflow, by key or canonical category. The workflow decides which moves are allowed and what must hold first. In an auto-scheduled project, a task finishing late moves the tasks depending on it.
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param taskId path string true "Task ID"
 // @Param request body UpdateTaskStatusRequest true "Status update request"
 // @Success 200 {object} dto.ApiResponse{data=TaskResponse}
+// @Failure 400 {object} dto.ApiResponse
+// @Failure 409 {object} dto.ApiResponse
 // @Security BearerAuth
 // @Router /tasks/{taskId}/status [post]
 func (c *TaskController) UpdateTaskStatus(ctx *gin.Context) {
@@ -285,18 +352,19 @@ func (c *TaskController) UpdateTaskStatus(ctx *gin.Context) {
 		return
 	}
 
-	taskStatus := models.TaskStatus(req.Status)
-	if err := c.repos.GetTask().UpdateStatus(ctx.Request.Context(), taskUUID, taskStatus); err != nil {
-		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+	before, err := c.repos.GetTask().GetByID(ctx.Request.Context(), organizationUUID(ctx), taskUUID)
+	if err != nil {
+		c.respondStatusError(ctx, err)
 		return
 	}
 
-	// Fetch updated task
-	task, err := c.repos.GetTask().GetByID(ctx.Request.Context(), taskUUID)
+	task, err := c.workflow.Transition(ctx.Request.Context(), organizationUUID(ctx), taskUUID, c.actorID(ctx), req.Status)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		c.respondStatusError(ctx, err)
 		return
 	}
+	c.cascade(ctx, before)
+	c.refreshProjects(ctx, task.ProjectID)
 
 	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(toTaskResponse(task), dto.ResponseMeta{
 		Timestamp: getTimestamp(),
@@ -304,6 +372,39 @@ func (c *TaskController) UpdateTaskStatus(ctx *gin.Context) {
 	}))
 }
 
+// PreviewSchedule godoc
+// @Summary Preview a schedule change
+// @Description Show how new dates or a new status for a task would move the tasks depending on it, which pinned tasks would conflict, and whether the project's target end date would be pushed. Nothing is saved.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param taskId path string true "Task ID"
+// @Param request body dto.ScheduleChangeRequest true "Proposed change"
+// @Success 200 {object} dto.ApiResponse{data=dto.SchedulePreviewResponse}
+// @Failure 400 {object} dto.ApiResponse
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /tasks/{taskId}/schedule/preview [post]
+func (c *TaskController) PreviewSchedule(ctx *gin.Context) {
+	taskUUID, ok := c.taskID(ctx)
+	if !ok {
+		return
+	}
+
+	var req dto.ScheduleChangeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	preview, err := c.schedule.Preview(ctx.Request.Context(), organizationUUID(ctx), taskUUID, req)
+	if err != nil {
+		c.respondStatusError(ctx, err)
+		return
+	}
+	c.respond(ctx, http.StatusOK, preview)
+}
+
 // AssignTask godoc
 // @Summary Assign task to user
 // @Description Assign a task to a specific user
@@ -336,19 +437,36 @@ func (c *TaskController) AssignTask(ctx *gin.Context) {
 			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid user ID", nil, ctx.GetString("requestId")))
 			return
 		}
+		if !c.requireMember(ctx, userUUID) {
+			return
+		}
 		assigneeID = &userUUID
 	}
 
-	if err := c.repos.GetTask().UpdateAssignee(ctx.Request.Context(), taskUUID, assigneeID); err != nil {
+	before, err := c.repos.GetTask().GetByID(ctx.Request.Context(), organizationUUID(ctx), taskUUID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Task not found", nil, ctx.GetString("requestId")))
+		return
+	}
+
+	if err := c.repos.GetTask().UpdateAssignee(ctx.Request.Context(), organizationUUID(ctx), taskUUID, assigneeID); err != nil {
+		if respondArchived(ctx, err) {
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Task not found", nil, ctx.GetString("requestId")))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
 	}
 
-	task, err := c.repos.GetTask().GetByID(ctx.Request.Context(), taskUUID)
+	task, err := c.repos.GetTask().GetByID(ctx.Request.Context(), organizationUUID(ctx), taskUUID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
 	}
+	c.recordChanges(ctx, before, task)
 
 	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(toTaskResponse(task), dto.ResponseMeta{
 		Timestamp: getTimestamp(),
@@ -356,9 +474,43 @@ func (c *TaskController) AssignTask(ctx *gin.Context) {
 	}))
 }
 
+// GetTaskHistory godoc
+// @Summary Get task history
+// @Description Get the changes to a task's status, assignee, estimate and due date, oldest first
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param taskId path string true "Task ID"
+// @Success 200 {object} dto.ApiResponse{data=TaskHistoryResponse}
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /tasks/{taskId}/history [get]
+func (c *TaskController) GetTaskHistory(ctx *gin.Context) {
+	taskUUID, ok := c.taskID(ctx)
+	if !ok {
+		return
+	}
+
+	events, err := c.history.History(ctx.Request.Context(), organizationUUID(ctx), taskUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Task not found", nil, ctx.GetString("requestId")))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	resp := TaskHistoryResponse{TaskID: taskUUID.String(), Events: make([]TaskEventResponse, 0, len(events))}
+	for _, e := range events {
+		resp.Events = append(resp.Events, toTaskEventResponse(&e))
+	}
+	c.respond(ctx, http.StatusOK, resp)
+}
+
 // DeleteTask godoc
 // @Summary Delete a task
-// @Description Soft-delete a task
+// @Description Soft-delete a task together with its subtasks, their skill requirements, dependencies and nudges
 // @Tags tasks
 // @Accept json
 // @Produce json
@@ -375,14 +527,286 @@ func (c *TaskController) DeleteTask(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.repos.GetTask().Delete(ctx.Request.Context(), taskUUID); err != nil {
+	// The project is read first so its progress can be refreshed once the task is gone
+	task, err := c.repos.GetTask().GetByID(ctx.Request.Context(), organizationUUID(ctx), taskUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Task not found", nil, ctx.GetString("requestId")))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	if err := c.hierarchy.DeleteSubtree(ctx.Request.Context(), organizationUUID(ctx), taskUUID); err != nil {
+		if respondArchived(ctx, err) {
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Task not found", nil, ctx.GetString("requestId")))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
 	}
+	c.refreshProjects(ctx, task.ProjectID)
 
 	ctx.Status(http.StatusNoContent)
 }
 
+// GetTaskTree godoc
+// @Summary Get a task tree
+// @Description Get a task with all of its subtasks nested beneath it
+// @Tags tasks
+// @Produce json
+// @Param taskId path string true "Task ID"
+// @Success 200 {object} dto.ApiResponse{data=TaskTreeResponse}
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /tasks/{taskId}/tree [get]
+func (c *TaskController) GetTaskTree(ctx *gin.Context) {
+	taskUUID, ok := c.taskID(ctx)
+	if !ok {
+		return
+	}
+
+	tree, err := c.hierarchy.Tree(ctx.Request.Context(), organizationUUID(ctx), taskUUID)
+	if err != nil {
+		c.respondHierarchyError(ctx, err)
+		return
+	}
+	c.respond(ctx, http.StatusOK, toTaskTreeResponse(tree))
+}
+
+// GetProjectTaskTree godoc
+// @Summary Get a project's task tree
+// @Description Get every task in a project, nested under their parents
+// @Tags tasks
+// @Produce json
+// @Param projectId query string true "Project ID"
+// @Success 200 {object} dto.ApiResponse{data=TaskForestResponse}
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /tasks/tree [get]
+func (c *TaskController) GetProjectTaskTree(ctx *gin.Context) {
+	projUUID, err := uuid.Parse(ctx.Query("projectId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid project ID", nil, ctx.GetString("requestId")))
+		return
+	}
+
+	roots, err := c.hierarchy.ProjectTree(ctx.Request.Context(), organizationUUID(ctx), projUUID)
+	if err != nil {
+		c.respondHierarchyError(ctx, err)
+		return
+	}
+	response := TaskForestResponse{Tasks: make([]TaskTreeResponse, 0, len(roots))}
+	for _, root := range roots {
+		response.Tasks = append(response.Tasks, toTaskTreeResponse(root))
+	}
+	c.respond(ctx, http.StatusOK, response)
+}
+
+// MoveTask godoc
+// @Summary Move a task
+// @Description Move a task with its subtasks under another parent, or to the top level of a project. Hierarchy levels are recomputed and may not exceed three.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param taskId path string true "Task ID"
+// @Param request body MoveTaskRequest true "New position"
+// @Success 200 {object} dto.ApiResponse{data=TaskTreeResponse}
+// @Failure 400 {object} dto.ApiResponse
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /tasks/{taskId}/move [post]
+func (c *TaskController) MoveTask(ctx *gin.Context) {
+	taskUUID, ok := c.taskID(ctx)
+	if !ok {
+		return
+	}
+
+	var req MoveTaskRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	var parentID, projectID *uuid.UUID
+	if req.ParentTaskID != "" {
+		id, err := uuid.Parse(req.ParentTaskID)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid parent task ID", nil, ctx.GetString("requestId")))
+			return
+		}
+		parentID = &id
+	}
+	if req.ProjectID != "" {
+		id, err := uuid.Parse(req.ProjectID)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid project ID", nil, ctx.GetString("requestId")))
+			return
+		}
+		projectID = &id
+	}
+
+	task, err := c.repos.GetTask().GetByID(ctx.Request.Context(), organizationUUID(ctx), taskUUID)
+	if err != nil {
+		c.respondHierarchyError(ctx, err)
+		return
+	}
+
+	tree, err := c.hierarchy.Move(ctx.Request.Context(), organizationUUID(ctx), taskUUID, parentID, projectID)
+	if err != nil {
+		c.respondHierarchyError(ctx, err)
+		return
+	}
+	c.refreshProjects(ctx, task.ProjectID, tree.Task.ProjectID)
+	c.respond(ctx, http.StatusOK, toTaskTreeResponse(tree))
+}
+
+// ArchiveTask godoc
+// @Summary Archive a task
+// @Description Archive a task with its subtasks. Archived tasks are read-only until unarchived.
+// @Tags tasks
+// @Produce json
+// @Param taskId path string true "Task ID"
+// @Success 200 {object} dto.ApiResponse{data=TaskTreeResponse}
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /tasks/{taskId}/archive [post]
+func (c *TaskController) ArchiveTask(ctx *gin.Context) {
+	c.setArchived(ctx, true)
+}
+
+// UnarchiveTask godoc
+// @Summary Unarchive a task
+// @Description Unarchive a task with its subtasks
+// @Tags tasks
+// @Produce json
+// @Param taskId path string true "Task ID"
+// @Success 200 {object} dto.ApiResponse{data=TaskTreeResponse}
+// @Failure 404 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /tasks/{taskId}/unarchive [post]
+func (c *TaskController) UnarchiveTask(ctx *gin.Context) {
+	c.setArchived(ctx, false)
+}
+
+func (c *TaskController) setArchived(ctx *gin.Context, archived bool) {
+	taskUUID, ok := c.taskID(ctx)
+	if !ok {
+		return
+	}
+
+	tree, err := c.hierarchy.ArchiveSubtree(ctx.Request.Context(), organizationUUID(ctx), taskUUID, archived)
+	if err != nil {
+		c.respondHierarchyError(ctx, err)
+		return
+	}
+	c.refreshProjects(ctx, tree.Task.ProjectID)
+	c.respond(ctx, http.StatusOK, toTaskTreeResponse(tree))
+}
+
+// refreshProjects recalculates the stored progress and critical path of projects whose
+// tasks changed. The task change has already succeeded, so a failure here is logged
+// rather than returned.
+func (c *TaskController) refreshProjects(ctx *gin.Context, projectIDs ...uuid.UUID) {
+	seen := make(map[uuid.UUID]bool, len(projectIDs))
+	for _, projectID := range projectIDs {
+		if seen[projectID] {
+			continue
+		}
+		seen[projectID] = true
+		if err := c.progress.RecalculateProjectProgress(ctx.Request.Context(), projectID.String(), organizationUUID(ctx).String()); err != nil {
+			log.Printf("[TaskController] Failed to recalculate progress for project %s: %v", projectID, err)
+		}
+		if err := c.dependencies.RecalculateCriticalPath(ctx.Request.Context(), projectID.String(), organizationUUID(ctx).String()); err != nil {
+			log.Printf("[TaskController] Failed to recalculate the critical path of project %s: %v", projectID, err)
+		}
+	}
+}
+
+// cascade moves the tasks depending on a changed task in an auto-scheduled project. The
+// task change has already succeeded, so a failure here is logged rather than returned.
+func (c *TaskController) cascade(ctx *gin.Context, before *models.Task) {
+	if _, err := c.schedule.Cascade(ctx.Request.Context(), organizationUUID(ctx), c.actorID(ctx), before); err != nil {
+		log.Printf("[TaskController] Failed to reschedule tasks depending on task %s: %v", before.ID, err)
+	}
+}
+
+// recordChanges logs a task's changes in its history. The change has already been saved,
+// so a failure here is logged rather than returned.
+func (c *TaskController) recordChanges(ctx *gin.Context, before, after *models.Task) {
+	if err := c.history.RecordChanges(ctx.Request.Context(), organizationUUID(ctx), c.actorID(ctx), before, after); err != nil {
+		log.Printf("[TaskController] Failed to record changes to task %s: %v", after.ID, err)
+	}
+}
+
+// actorID is the user making the change, as set by AuthMiddleware
+func (c *TaskController) actorID(ctx *gin.Context) uuid.UUID {
+	userID, _ := uuid.Parse(ctx.GetString("userId"))
+	return userID
+}
+
+func (c *TaskController) respondStatusError(ctx *gin.Context, err error) {
+	if respondArchived(ctx, err) || respondWorkflowError(ctx, err) {
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Task not found", nil, ctx.GetString("requestId")))
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+}
+
+func (c *TaskController) taskID(ctx *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param("taskId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid task ID", nil, ctx.GetString("requestId")))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (c *TaskController) respond(ctx *gin.Context, status int, data interface{}) {
+	ctx.JSON(status, dto.NewSuccessResponse(data, dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+func (c *TaskController) respondHierarchyError(ctx *gin.Context, err error) {
+	if respondArchived(ctx, err) {
+		return
+	}
+	status, code := http.StatusInternalServerError, "INTERNAL_ERROR"
+	switch {
+	case errors.Is(err, services.ErrInvalidHierarchy):
+		status, code = http.StatusBadRequest, "INVALID_HIERARCHY"
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status, code = http.StatusNotFound, "NOT_FOUND"
+	}
+	ctx.JSON(status, dto.NewErrorResponse(code, err.Error(), nil, ctx.GetString("requestId")))
+}
+
+// requireMember checks that a prospective assignee belongs to the caller's organization,
+// writing a 400 response and returning false when they do not
+func (c *TaskController) requireMember(ctx *gin.Context, userID uuid.UUID) bool {
+	member, err := c.repos.GetOrganization().IsMember(ctx.Request.Context(), organizationUUID(ctx), userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return false
+	}
+	if !member {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Assignee is not a member of this organization", map[string]interface{}{
+			"assigneeId": userID.String(),
+		}, ctx.GetString("requestId")))
+		return false
+	}
+	return true
+}
+
 // Request/Response types
 
 type CreateTaskRequest struct {
@@ -393,19 +817,22 @@ type CreateTaskRequest struct {
 	Status         string  `json:"status,omitempty"`
 	Priority       string  `json:"priority,omitempty"`
 	EstimatedHours float64 `json:"estimatedHours"`
-	HierarchyLevel int     `json:"hierarchyLevel,omitempty"`
+	HierarchyLevel int     `json:"hierarchyLevel,omitempty"` // ignored; derived from the parent
 	AssigneeID     string  `json:"assigneeId,omitempty"`
 	IsMilestone    bool    `json:"isMilestone,omitempty"`
 }
 
 type UpdateTaskRequest struct {
-	Title          string  `json:"title"`
-	Description    string  `json:"description"`
-	Status         string  `json:"status"`
-	Priority       string  `json:"priority"`
-	EstimatedHours float64 `json:"estimatedHours"`
-	ActualHours    float64 `json:"actualHours"`
-	AssigneeID     *string `json:"assigneeId"`
+	Title          string     `json:"title"`
+	Description    string     `json:"description"`
+	Status         string     `json:"status"`
+	Priority       string     `json:"priority"`
+	EstimatedHours float64    `json:"estimatedHours"`
+	ActualHours    float64    `json:"actualHours"`
+	AssigneeID     *string    `json:"assigneeId"`
+	StartDate      *time.Time `json:"startDate"`
+	DueDate        *time.Time `json:"dueDate"`
+	DatesPinned    *bool      `json:"datesPinned"` // pinned dates are not moved by auto-scheduling
 }
 
 type UpdateTaskStatusRequest struct {
@@ -421,9 +848,11 @@ type TaskResponse struct {
 	ID             string     `json:"id"`
 	ProjectID      string     `json:"projectId"`
 	ParentTaskID   *string    `json:"parentTaskId,omitempty"`
+	HierarchyLevel int        `json:"hierarchyLevel"`
 	Title          string     `json:"title"`
 	Description    string     `json:"description"`
 	Status         string     `json:"status"`
+	WorkflowStatus string     `json:"workflowStatus"`
 	Priority       string     `json:"priority"`
 	EstimatedHours float64    `json:"estimatedHours"`
 	ActualHours    float64    `json:"actualHours"`
@@ -432,11 +861,48 @@ type TaskResponse struct {
 	IsMilestone    bool       `json:"isMilestone"`
 	IsCriticalPath bool       `json:"isCriticalPath"`
 	RiskScore      int        `json:"riskScore"`
+	StartDate      *time.Time `json:"startDate,omitempty"`
 	DueDate        *time.Time `json:"dueDate,omitempty"`
+	DatesPinned    bool       `json:"datesPinned"`
+	ArchivedAt     *time.Time `json:"archivedAt,omitempty"`
 	CreatedAt      time.Time  `json:"createdAt"`
 	UpdatedAt      time.Time  `json:"updatedAt"`
 }
 
+// MoveTaskRequest places a task under a parent, or at the top level of a project when
+// no parent is given
+type MoveTaskRequest struct {
+	ParentTaskID string `json:"parentTaskId,omitempty"`
+	ProjectID    string `json:"projectId,omitempty"`
+}
+
+// TaskTreeResponse is a task with its subtasks nested beneath it
+type TaskTreeResponse struct {
+	TaskResponse
+	Subtasks []TaskTreeResponse `json:"subtasks"`
+}
+
+// TaskForestResponse is a project's top-level tasks with their subtasks nested
+type TaskForestResponse struct {
+	Tasks []TaskTreeResponse `json:"tasks"`
+}
+
+// TaskEventResponse is one change to a task
+type TaskEventResponse struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	FromValue  string    `json:"fromValue"`
+	ToValue    string    `json:"toValue"`
+	ActorID    *string   `json:"actorId,omitempty"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// TaskHistoryResponse is a task's changes, oldest first
+type TaskHistoryResponse struct {
+	TaskID string              `json:"taskId"`
+	Events []TaskEventResponse `json:"events"`
+}
+
 type TaskListResponse struct {
 	Tasks []TaskResponse `json:"tasks"`
 	Total int            `json:"total"`
@@ -446,19 +912,27 @@ func toTaskResponse(t *models.Task) TaskResponse {
 	resp := TaskResponse{
 		ID:             t.ID.String(),
 		ProjectID:      t.ProjectID.String(),
+		HierarchyLevel: t.HierarchyLevel,
 		Title:          t.Title,
 		Description:    t.Description,
 		Status:         string(t.Status),
+		WorkflowStatus: t.WorkflowStatus,
 		Priority:       string(t.Priority),
 		EstimatedHours: t.EstimatedHours,
 		ActualHours:    t.ActualHours,
 		IsMilestone:    t.IsMilestone,
 		IsCriticalPath: t.IsCriticalPath,
 		RiskScore:      t.RiskScore,
+		StartDate:      t.StartDate,
 		DueDate:        t.DueDate,
+		DatesPinned:    t.DatesPinned,
+		ArchivedAt:     t.ArchivedAt,
 		CreatedAt:      t.CreatedAt,
 		UpdatedAt:      t.UpdatedAt,
 	}
+	if resp.WorkflowStatus == "" {
+		resp.WorkflowStatus = resp.Status
+	}
 
 	if t.ParentTaskID != nil {
 		parentID := t.ParentTaskID.String()
@@ -475,3 +949,29 @@ func toTaskResponse(t *models.Task) TaskResponse {
 
 	return resp
 }
+
+func toTaskEventResponse(e *models.TaskEvent) TaskEventResponse {
+	resp := TaskEventResponse{
+		ID:         e.ID.String(),
+		Type:       string(e.Type),
+		FromValue:  e.FromValue,
+		ToValue:    e.ToValue,
+		OccurredAt: e.OccurredAt,
+	}
+	if e.ActorID != nil {
+		actorID := e.ActorID.String()
+		resp.ActorID = &actorID
+	}
+	return resp
+}
+
+func toTaskTreeResponse(node *services.TaskNode) TaskTreeResponse {
+	resp := TaskTreeResponse{
+		TaskResponse: toTaskResponse(&node.Task),
+		Subtasks:     make([]TaskTreeResponse, 0, len(node.Subtasks)),
+	}
+	for _, child := range node.Subtasks {
+		resp.Subtasks = append(resp.Subtasks, toTaskTreeResponse(child))
+	}
+	return resp
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -9,7 +10,9 @@ import (
 
 	"github.com/SimpleAjax/Xephyr/internal/dto"
 	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/notify"
 	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
 )
 
 // UserController handles user CRUD HTTP requests
@@ -99,9 +102,8 @@ func (c *UserController) GetUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.repos.GetUser().GetByID(ctx.Request.Context(), userUUID)
-	if err != nil {
-		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "User not found", nil, ctx.GetString("requestId")))
+	user, ok := c.loadMember(ctx, userUUID)
+	if !ok {
 		return
 	}
 
@@ -111,6 +113,26 @@ func (c *UserController) GetUser(ctx *gin.Context) {
 	}))
 }
 
+// loadMember loads a user who belongs to the caller's organization. Users are shared
+// across organizations, so anyone outside it is reported as not found.
+func (c *UserController) loadMember(ctx *gin.Context, userID uuid.UUID) (*models.User, bool) {
+	member, err := c.repos.GetOrganization().IsMember(ctx.Request.Context(), organizationUUID(ctx), userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return nil, false
+	}
+	if !member {
+		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "User not found", nil, ctx.GetString("requestId")))
+		return nil, false
+	}
+	user, err := c.repos.GetUser().GetByID(ctx.Request.Context(), userID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "User not found", nil, ctx.GetString("requestId")))
+		return nil, false
+	}
+	return user, true
+}
+
 // GetUserSkills godoc
 // @Summary Get user skills
 // @Description Get skills for a specific user
@@ -130,9 +152,8 @@ func (c *UserController) GetUserSkills(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.repos.GetUser().GetByID(ctx.Request.Context(), userUUID)
-	if err != nil {
-		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "User not found", nil, ctx.GetString("requestId")))
+	user, ok := c.loadMember(ctx, userUUID)
+	if !ok {
 		return
 	}
 
@@ -177,15 +198,14 @@ func (c *UserController) GetUserWorkload(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.repos.GetUser().GetByID(ctx.Request.Context(), userUUID)
-	if err != nil {
-		ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "User not found", nil, ctx.GetString("requestId")))
+	user, ok := c.loadMember(ctx, userUUID)
+	if !ok {
 		return
 	}
 
 	// Get workload entries for current week
 	weekStart := getCurrentWeekStart()
-	entry, err := c.repos.GetWorkload().GetByUserAndWeek(ctx.Request.Context(), userUUID, weekStart)
+	entry, err := c.repos.GetWorkload().GetByUserAndWeek(ctx.Request.Context(), organizationUUID(ctx), userUUID, weekStart)
 	if err != nil {
 		// Return empty workload
 		ctx.JSON(http.StatusOK, dto.NewSuccessResponse(UserWorkloadResponse{
@@ -215,6 +235,114 @@ func (c *UserController) GetUserWorkload(ctx *gin.Context) {
 	}))
 }
 
+// GetNotificationPreferences godoc
+// @Summary Get notification preferences
+// @Description Get which nudge types and severities are sent to which channel. Users without preferences get the default: email with a daily digest for low severity.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param userId path string true "User ID"
+// @Success 200 {object} dto.ApiResponse{data=NotificationPreferencesResponse}
+// @Failure 403 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /users/{userId}/notification-preferences [get]
+func (c *UserController) GetNotificationPreferences(ctx *gin.Context) {
+	userUUID, ok := selfUserID(ctx)
+	if !ok {
+		return
+	}
+
+	prefs, err := c.repos.GetNotification().ListPreferences(ctx.Request.Context(), userUUID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(toNotificationPreferencesResponse(userUUID, prefs), dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+// UpdateNotificationPreferences godoc
+// @Summary Replace notification preferences
+// @Description Replace all of the user's notification preferences. An empty list restores the default.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param userId path string true "User ID"
+// @Param request body UpdateNotificationPreferencesRequest true "Preferences"
+// @Success 200 {object} dto.ApiResponse{data=NotificationPreferencesResponse}
+// @Failure 400 {object} dto.ApiResponse
+// @Failure 403 {object} dto.ApiResponse
+// @Security BearerAuth
+// @Router /users/{userId}/notification-preferences [put]
+func (c *UserController) UpdateNotificationPreferences(ctx *gin.Context) {
+	userUUID, ok := selfUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req UpdateNotificationPreferencesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	prefs := make([]models.NotificationPreference, 0, len(req.Preferences))
+	for _, p := range req.Preferences {
+		channel := models.NotificationChannel(p.Channel)
+		if channel != models.NotificationChannelEmail {
+			if err := notify.ValidateTarget(channel, p.Target); err != nil {
+				ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+				return
+			}
+		}
+		minSeverity := models.NudgeSeverity(p.MinSeverity)
+		if minSeverity == "" {
+			minSeverity = models.NudgeSeverityLow
+		}
+		types := make([]string, 0, len(p.NudgeTypes))
+		for _, t := range p.NudgeTypes {
+			if t = strings.TrimSpace(t); t != "" {
+				types = append(types, t)
+			}
+		}
+		prefs = append(prefs, models.NotificationPreference{
+			Channel:     channel,
+			Target:      p.Target,
+			NudgeTypes:  strings.Join(types, ","),
+			MinSeverity: minSeverity,
+			DailyDigest: p.DailyDigest,
+		})
+	}
+
+	if err := c.repos.GetNotification().ReplacePreferences(ctx.Request.Context(), userUUID, prefs); err != nil {
+		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.NewSuccessResponse(toNotificationPreferencesResponse(userUUID, prefs), dto.ResponseMeta{
+		Timestamp: getTimestamp(),
+		RequestID: ctx.GetString("requestId"),
+	}))
+}
+
+// selfUserID parses the userId path parameter and checks that it names the caller,
+// since notification preferences are only visible to their owner
+func selfUserID(ctx *gin.Context) (uuid.UUID, bool) {
+	userUUID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Invalid user ID", nil, ctx.GetString("requestId")))
+		return uuid.Nil, false
+	}
+	if userUUID.String() != ctx.GetString("userId") {
+		ctx.JSON(http.StatusForbidden, dto.NewErrorResponse("FORBIDDEN", "You can only manage your own notification preferences", nil, ctx.GetString("requestId")))
+		return uuid.Nil, false
+	}
+	return userUUID, true
+}
+
 // Request/Response types
 
 type UserResponse struct {
@@ -259,6 +387,32 @@ type UserWorkloadResponse struct {
 	AssignedTasks       int     `json:"assignedTasks"`
 }
 
+type NotificationPreferenceRequest struct {
+	Channel     string   `json:"channel" binding:"required,oneof=email webhook slack"`
+	Target      string   `json:"target"`
+	NudgeTypes  []string `json:"nudgeTypes"`
+	MinSeverity string   `json:"minSeverity" binding:"omitempty,oneof=low medium high"`
+	DailyDigest bool     `json:"dailyDigest"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceRequest `json:"preferences" binding:"max=20,dive"`
+}
+
+type NotificationPreferenceResponse struct {
+	Channel     string   `json:"channel"`
+	Target      string   `json:"target"`
+	NudgeTypes  []string `json:"nudgeTypes"`
+	MinSeverity string   `json:"minSeverity"`
+	DailyDigest bool     `json:"dailyDigest"`
+}
+
+type NotificationPreferencesResponse struct {
+	UserID      string                           `json:"userId"`
+	IsDefault   bool                             `json:"isDefault"`
+	Preferences []NotificationPreferenceResponse `json:"preferences"`
+}
+
 func toUserResponse(u *models.User) UserResponse {
 	return UserResponse{
 		ID:         u.ID.String(),
@@ -280,3 +434,28 @@ func getCurrentWeekStart() time.Time {
 	}
 	return now.AddDate(0, 0, -weekday+1).Truncate(24 * time.Hour)
 }
+
+func toNotificationPreferencesResponse(userID uuid.UUID, prefs []models.NotificationPreference) NotificationPreferencesResponse {
+	response := NotificationPreferencesResponse{
+		UserID:      userID.String(),
+		Preferences: make([]NotificationPreferenceResponse, 0, len(prefs)),
+	}
+	if len(prefs) == 0 {
+		response.IsDefault = true
+		prefs = []models.NotificationPreference{services.DefaultNotificationPreference()}
+	}
+	for _, p := range prefs {
+		types := []string{}
+		if p.NudgeTypes != "" {
+			types = strings.Split(p.NudgeTypes, ",")
+		}
+		response.Preferences = append(response.Preferences, NotificationPreferenceResponse{
+			Channel:     string(p.Channel),
+			Target:      p.Target,
+			NudgeTypes:  types,
+			MinSeverity: string(p.MinSeverity),
+			DailyDigest: p.DailyDigest,
+		})
+	}
+	return response
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -99,6 +100,10 @@ func (c *WorkloadController) GetWorkloadForecast(ctx *gin.Context) {
 	orgID := ctx.GetString("organizationId")
 	forecast, err := c.service.GetWorkloadForecast(ctx.Request.Context(), params.PersonID, params.Weeks, orgID)
 	if err != nil {
+		if errors.Is(err, services.ErrPersonNotFound) {
+			ctx.JSON(http.StatusNotFound, dto.NewErrorResponse("NOT_FOUND", "Person not found", nil, ctx.GetString("requestId")))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse("INTERNAL_ERROR", err.Error(), nil, ctx.GetString("requestId")))
 		return
 	}
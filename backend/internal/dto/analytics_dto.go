@@ -0,0 +1,41 @@
+package dto
+
+import "time"
+
+// ===== Analytics Module DTOs =====
+
+// DurationStats summarizes a set of durations in hours
+type DurationStats struct {
+	Count        int     `json:"count"`
+	AverageHours float64 `json:"averageHours"`
+	MedianHours  float64 `json:"medianHours"`
+	P85Hours     float64 `json:"p85Hours"`
+}
+
+// StatusTime represents the time completed tasks spent in one status
+type StatusTime struct {
+	Status       string  `json:"status"`
+	Tasks        int     `json:"tasks"`
+	TotalHours   float64 `json:"totalHours"`
+	AverageHours float64 `json:"averageHours"`
+}
+
+// WeeklyThroughput represents the tasks completed in a week starting on Monday
+type WeeklyThroughput struct {
+	WeekStart time.Time `json:"weekStart"`
+	Completed int       `json:"completed"`
+}
+
+// FlowMetricsResponse represents the flow of tasks completed in a period, for a project
+// or a person
+type FlowMetricsResponse struct {
+	ProjectID      string             `json:"projectId,omitempty"`
+	UserID         string             `json:"userId,omitempty"`
+	From           time.Time          `json:"from"`
+	To             time.Time          `json:"to"`
+	CompletedTasks int                `json:"completedTasks"`
+	CycleTime      DurationStats      `json:"cycleTime"`
+	LeadTime       DurationStats      `json:"leadTime"`
+	TimeInStatus   []StatusTime       `json:"timeInStatus"`
+	Throughput     []WeeklyThroughput `json:"throughput"`
+}
@@ -0,0 +1,76 @@
+package dto
+
+import "time"
+
+// ===== Auth Module DTOs =====
+
+// RegisterRequest represents a sign-up request. When OrganizationName is given a new
+// organization is created with the user as its admin.
+type RegisterRequest struct {
+	Email            string `json:"email" binding:"required,email,max=254"`
+	Password         string `json:"password" binding:"required,min=8,max=72"`
+	Name             string `json:"name" binding:"required,max=100"`
+	OrganizationName string `json:"organizationName" binding:"omitempty,max=100"`
+}
+
+// LoginRequest represents a password login
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshRequest exchanges a refresh token for a new token pair
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// LogoutRequest revokes a refresh token and every token rotated from the same login
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// ChangePasswordRequest changes the current user's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=8,max=72"`
+}
+
+// ForgotPasswordRequest asks for a password reset email
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest sets a new password with a reset token
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required,min=8,max=72"`
+}
+
+// AuthMembership is an organization the user belongs to
+type AuthMembership struct {
+	OrganizationID string `json:"organizationId"`
+	Role           string `json:"role"`
+}
+
+// AuthUser is the authenticated user
+type AuthUser struct {
+	ID          string           `json:"id"`
+	Email       string           `json:"email"`
+	Name        string           `json:"name"`
+	Memberships []AuthMembership `json:"memberships"`
+}
+
+// AuthTokensResponse is returned by register, login and refresh
+type AuthTokensResponse struct {
+	AccessToken      string    `json:"accessToken"`
+	TokenType        string    `json:"tokenType"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	RefreshToken     string    `json:"refreshToken"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
+	User             AuthUser  `json:"user"`
+}
+
+// AuthStatusResponse acknowledges requests that return no data
+type AuthStatusResponse struct {
+	Message string `json:"message"`
+}
@@ -12,6 +12,8 @@ type DependencyInfo struct {
 	LagHours       int    `json:"lagHours"`
 	Status         string `json:"status"`
 	IsBlocking     bool   `json:"isBlocking"`
+	ProjectID      string `json:"projectId"`
+	CrossProject   bool   `json:"crossProject"` // the task depended on is in another project
 }
 
 // IndirectDependency represents an indirect dependency path
@@ -25,6 +27,8 @@ type DependentInfo struct {
 	TaskID         string `json:"taskId"`
 	DependencyType string `json:"dependencyType"`
 	IsBlocked      bool   `json:"isBlocked"`
+	ProjectID      string `json:"projectId"`
+	CrossProject   bool   `json:"crossProject"`
 }
 
 // ChainAnalysis represents dependency chain analysis
@@ -32,6 +36,7 @@ type ChainAnalysis struct {
 	LongestChain       int    `json:"longestChain"`
 	CriticalPathPosition string `json:"criticalPathPosition"`
 	FloatHours         int    `json:"floatHours"`
+	FreeFloatHours     int    `json:"freeFloatHours"`
 }
 
 // TaskDependenciesResponse represents task dependencies response
@@ -90,6 +95,8 @@ type CreateDependencyResponse struct {
 // CriticalPathTask represents a task on the critical path
 type CriticalPathTask struct {
 	TaskID         string     `json:"taskId"`
+	ProjectID      string     `json:"projectId"`
+	External       bool       `json:"external"` // a task of another project the project waits on
 	Title          string     `json:"title"`
 	EstimatedHours int        `json:"estimatedHours"`
 	EarliestStart  *time.Time `json:"earliestStart,omitempty"`
@@ -108,9 +115,10 @@ type CriticalPathInfo struct {
 
 // NonCriticalTask represents a task not on the critical path
 type NonCriticalTask struct {
-	TaskID     string `json:"taskId"`
-	Title      string `json:"title"`
-	FloatHours int    `json:"floatHours"`
+	TaskID         string `json:"taskId"`
+	Title          string `json:"title"`
+	FloatHours     int    `json:"floatHours"`
+	FreeFloatHours int    `json:"freeFloatHours"`
 }
 
 // CriticalPathResponse represents the critical path response
@@ -119,6 +127,7 @@ type CriticalPathResponse struct {
 	CriticalPath      CriticalPathInfo  `json:"criticalPath"`
 	NonCriticalTasks  []NonCriticalTask `json:"nonCriticalTasks"`
 	ProjectDuration   int               `json:"projectDuration"`
+	ProjectEndDate    *time.Time        `json:"projectEndDate,omitempty"`
 	CalculatedAt      time.Time         `json:"calculatedAt"`
 }
 
@@ -151,27 +160,79 @@ type ValidateDependencyResponse struct {
 
 // DependencyGraphNode represents a node in the dependency graph
 type DependencyGraphNode struct {
-	ID       string `json:"id"`
-	Title    string `json:"title"`
-	Status   string `json:"status"`
-	X        int    `json:"x"`
-	Y        int    `json:"y"`
+	ID        string `json:"id"`
+	ProjectID string `json:"projectId"`
+	External  bool   `json:"external"`
+	Title     string `json:"title"`
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	ClusterID string `json:"clusterId,omitempty"` // the top-level task it falls under
+	X         int    `json:"x"`
+	Y         int    `json:"y"`
 }
 
 // DependencyGraphEdge represents an edge in the dependency graph
 type DependencyGraphEdge struct {
-	ID       string `json:"id"`
-	Source   string `json:"source"`
-	Target   string `json:"target"`
-	Type     string `json:"type"`
-	LagHours int    `json:"lagHours"`
+	ID           string `json:"id"`
+	Source       string `json:"source"`
+	Target       string `json:"target"`
+	Type         string `json:"type"`
+	LagHours     int    `json:"lagHours"`
+	CrossProject bool   `json:"crossProject"`
+	Critical     bool   `json:"critical"`
+}
+
+// DependencyGraphCluster is a top-level task with its subtasks, drawn as one group
+type DependencyGraphCluster struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
 }
 
 // DependencyGraphResponse represents the dependency graph response
 type DependencyGraphResponse struct {
-	ProjectID string                `json:"projectId"`
-	Nodes     []DependencyGraphNode `json:"nodes"`
-	Edges     []DependencyGraphEdge `json:"edges"`
+	ProjectID   string                   `json:"projectId"`
+	ProjectName string                   `json:"projectName"`
+	Nodes       []DependencyGraphNode    `json:"nodes"`
+	Edges       []DependencyGraphEdge    `json:"edges"`
+	Clusters    []DependencyGraphCluster `json:"clusters"`
+}
+
+// PortfolioProject is a project of the portfolio dependency graph with the
+// dependencies it has on other projects
+type PortfolioProject struct {
+	ProjectID      string     `json:"projectId"`
+	Name           string     `json:"name"`
+	Status         string     `json:"status"`
+	ProjectEndDate *time.Time `json:"projectEndDate,omitempty"`
+	TargetEndDate  *time.Time `json:"targetEndDate,omitempty"`
+	DependsOn      int        `json:"dependsOn"`    // dependencies of its tasks on other projects
+	DependedOnBy   int        `json:"dependedOnBy"` // dependencies of other projects on its tasks
+	BlockedBy      int        `json:"blockedBy"`    // of DependsOn, those still waiting
+	Blocking       int        `json:"blocking"`     // of DependedOnBy, those still waiting
+	OnCriticalPath bool       `json:"onCriticalPath"`
+}
+
+// PortfolioProjectLink is every dependency of one project on another, from the project
+// depended on to the one waiting
+type PortfolioProjectLink struct {
+	Source       string `json:"source"`
+	Target       string `json:"target"`
+	Dependencies int    `json:"dependencies"`
+	Blocking     int    `json:"blocking"`
+	Critical     bool   `json:"critical"`
+}
+
+// PortfolioDependencyGraphResponse is the organization's projects linked by the
+// dependencies between their tasks. Nodes and edges hold only the tasks and
+// dependencies crossing projects.
+type PortfolioDependencyGraphResponse struct {
+	Projects         []PortfolioProject     `json:"projects"`
+	Links            []PortfolioProjectLink `json:"links"`
+	Nodes            []DependencyGraphNode  `json:"nodes"`
+	Edges            []DependencyGraphEdge  `json:"edges"`
+	CriticalPath     CriticalPathInfo       `json:"criticalPath"`
+	PortfolioEndDate *time.Time             `json:"portfolioEndDate,omitempty"`
+	CalculatedAt     time.Time              `json:"calculatedAt"`
 }
 
 // TaskDependenciesQueryParams represents query parameters for task dependencies
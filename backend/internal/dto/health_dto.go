@@ -60,9 +60,24 @@ type CompletionDetails struct {
 
 // DependencyDetails represents dependency health details
 type DependencyDetails struct {
-	Total   int `json:"total"`
-	Blocked int `json:"blocked"`
-	AtRisk  int `json:"atRisk"`
+	Total            int               `json:"total"`
+	Blocked          int               `json:"blocked"` // tasks waiting on an unfinished predecessor
+	AtRisk           int               `json:"atRisk"`
+	External         int               `json:"external"`        // dependencies on tasks of other projects
+	ExternalBlocked  int               `json:"externalBlocked"` // of those, the ones still waiting
+	ExternalBlockers []ExternalBlocker `json:"externalBlockers"`
+}
+
+// ExternalBlocker is a task of another project that a task of the project is waiting on
+type ExternalBlocker struct {
+	TaskID             string     `json:"taskId"`
+	TaskTitle          string     `json:"taskTitle"`
+	BlockedByTaskID    string     `json:"blockedByTaskId"`
+	BlockedByTitle     string     `json:"blockedByTitle"`
+	BlockedByProjectID string     `json:"blockedByProjectId"`
+	DependencyType     string     `json:"dependencyType"`
+	DueDate            *time.Time `json:"dueDate,omitempty"` // when the blocking task is due
+	Overdue            bool       `json:"overdue"`
 }
 
 // ResourceDetails represents resource health details
@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+// ===== Job Module DTOs =====
+
+// JobResponse represents the status of a background job
+type JobResponse struct {
+	JobID       string                 `json:"jobId"`
+	Type        string                 `json:"type"`
+	Status      string                 `json:"status"`
+	Progress    int                    `json:"progress"`
+	Attempts    int                    `json:"attempts"`
+	MaxAttempts int                    `json:"maxAttempts"`
+	Result      map[string]interface{} `json:"result,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	CreatedAt   time.Time              `json:"createdAt"`
+	NextRunAt   *time.Time             `json:"nextRunAt,omitempty"`
+	StartedAt   *time.Time             `json:"startedAt,omitempty"`
+	CompletedAt *time.Time             `json:"completedAt,omitempty"`
+}
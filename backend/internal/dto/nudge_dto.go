@@ -74,16 +74,31 @@ type NudgeListQueryParams struct {
 
 // NudgeActionRequest represents a request to take action on a nudge
 type NudgeActionRequest struct {
-	ActionType string                 `json:"actionType" binding:"required,oneof=accept_suggestion dismiss custom_action ask_alternatives snooze"`
+	ActionType string                 `json:"actionType" binding:"required,oneof=accept_suggestion dismiss custom_action ask_alternatives snooze reassign extend_due_date split_task add_dependency escalate"`
 	Parameters map[string]interface{} `json:"parameters,omitempty"`
 }
 
 // NudgeActionResult represents the result of an action
 type NudgeActionResult struct {
-	TaskReassigned bool   `json:"taskReassigned"`
-	FromUserID     string `json:"fromUserId,omitempty"`
-	ToUserID       string `json:"toUserId,omitempty"`
-	TaskID         string `json:"taskId,omitempty"`
+	TaskReassigned bool          `json:"taskReassigned"`
+	FromUserID     string        `json:"fromUserId,omitempty"`
+	ToUserID       string        `json:"toUserId,omitempty"`
+	TaskID         string        `json:"taskId,omitempty"`
+	Summary        string        `json:"summary"`
+	Changes        []NudgeChange `json:"changes"`
+	CreatedTaskIDs []string      `json:"createdTaskIds,omitempty"`
+	DependencyID   string        `json:"dependencyId,omitempty"`
+	SnoozedUntil   *time.Time    `json:"snoozedUntil,omitempty"`
+	EscalatedTo    []string      `json:"escalatedTo,omitempty"`
+}
+
+// NudgeChange describes one field changed by a nudge action
+type NudgeChange struct {
+	Entity   string      `json:"entity"` // task, dependency, nudge
+	EntityID string      `json:"entityId"`
+	Field    string      `json:"field"`
+	From     interface{} `json:"from,omitempty"`
+	To       interface{} `json:"to,omitempty"`
 }
 
 // NudgeActionResponse represents the response after taking a nudge action
@@ -109,6 +124,17 @@ type GenerateNudgesRequest struct {
 	Async     bool     `json:"async"`
 }
 
+// GenerateNudgesResponse represents the outcome of a nudge generation request.
+// Async requests only carry the job ID and status.
+type GenerateNudgesResponse struct {
+	JobID     string   `json:"jobId,omitempty"`
+	Status    string   `json:"status"`
+	Generated int      `json:"generated"`
+	Refreshed int      `json:"refreshed"`
+	Resolved  int      `json:"resolved"`
+	NudgeIDs  []string `json:"nudgeIds"`
+}
+
 // NudgeTypeStats represents statistics for a specific nudge type
 type NudgeTypeStats struct {
 	Generated int `json:"generated"`
@@ -121,6 +147,7 @@ type NudgeStatsResponse struct {
 	Generated       int                       `json:"generated"`
 	Acted           int                       `json:"acted"`
 	Dismissed       int                       `json:"dismissed"`
+	AutoResolved    int                       `json:"autoResolved"`
 	Expired         int                       `json:"expired"`
 	ActionRate      float64                   `json:"actionRate"`
 	AvgTimeToAction string                    `json:"avgTimeToAction"`
@@ -134,9 +161,10 @@ type NudgeStatsQueryParams struct {
 
 // NudgeAction represents a nudge action history entry
 type NudgeAction struct {
-	Action    string    `json:"action"`
-	Timestamp time.Time `json:"timestamp"`
-	UserID    uuid.UUID `json:"userId"`
+	Action    string                 `json:"action"`
+	Timestamp time.Time              `json:"timestamp"`
+	UserID    *uuid.UUID             `json:"userId,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
 }
 
 // NudgeDetailResponse represents detailed nudge with history
@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ===== Organization Membership DTOs =====
+
+// CreateInvitationRequest invites an email address to join the organization
+type CreateInvitationRequest struct {
+	Email string `json:"email" binding:"required,email,max=254"`
+	Role  string `json:"role" binding:"required,oneof=admin pm member"`
+}
+
+// InvitationTokenRequest accepts or declines an invitation with the token from its email
+type InvitationTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// UpdateMemberRoleRequest changes a member's role
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin pm member"`
+}
+
+// TransferOwnershipRequest hands the organization to another member
+type TransferOwnershipRequest struct {
+	UserID uuid.UUID `json:"userId" binding:"required"`
+}
+
+// InvitationResponse represents an invitation. The token is only ever sent by email.
+type InvitationResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organizationId"`
+	Email          string     `json:"email"`
+	Role           string     `json:"role"`
+	Status         string     `json:"status"`
+	InvitedByID    uuid.UUID  `json:"invitedById"`
+	ExpiresAt      time.Time  `json:"expiresAt"`
+	RespondedAt    *time.Time `json:"respondedAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// MemberResponse represents a member of the organization
+type MemberResponse struct {
+	UserID   uuid.UUID `json:"userId"`
+	Email    string    `json:"email"`
+	Name     string    `json:"name"`
+	Role     string    `json:"role"`
+	IsOwner  bool      `json:"isOwner"`
+	JoinedAt time.Time `json:"joinedAt"`
+}
+
+// AuditEventResponse represents one entry in the organization's audit trail
+type AuditEventResponse struct {
+	ID            uuid.UUID              `json:"id"`
+	Action        string                 `json:"action"`
+	ActorID       uuid.UUID              `json:"actorId"`
+	SubjectUserID *uuid.UUID             `json:"subjectUserId,omitempty"`
+	SubjectEmail  string                 `json:"subjectEmail,omitempty"`
+	Details       map[string]interface{} `json:"details,omitempty"`
+	CreatedAt     time.Time              `json:"createdAt"`
+}
+
+// AuditEventListResponse is a page of audit events
+type AuditEventListResponse struct {
+	Events []AuditEventResponse `json:"events"`
+	Total  int                  `json:"total"`
+}
@@ -72,8 +72,9 @@ type ProjectTaskRankingResponse struct {
 // RecalculatePriorityRequest represents a request to recalculate priorities
 type RecalculatePriorityRequest struct {
 	Scope     string  `json:"scope" binding:"required,oneof=project organization task"`
-	ProjectID *string `json:"projectId,omitempty"`
-	Async     bool    `json:"async"`
+	ProjectID *string  `json:"projectId,omitempty"`
+	TaskIDs   []string `json:"taskIds,omitempty"`
+	Async     bool     `json:"async"`
 }
 
 // RecalculatePrioritySyncResponse represents sync recalculation response
@@ -98,3 +99,61 @@ type ProjectRankingQueryParams struct {
 	Limit      int    `form:"limit,default=50" binding:"min=1,max=100"`
 	Offset     int    `form:"offset,default=0" binding:"min=0"`
 }
+
+// PriorityWeights represents the weight of each priority component; weights must sum to 100
+type PriorityWeights struct {
+	ProjectPriority    int `json:"projectPriority"`
+	BusinessValue      int `json:"businessValue"`
+	DeadlineUrgency    int `json:"deadlineUrgency"`
+	CriticalPathWeight int `json:"criticalPathWeight"`
+	DependencyImpact   int `json:"dependencyImpact"`
+}
+
+// UrgencyThreshold maps tasks due within MaxDays to an urgency factor (0-100)
+type UrgencyThreshold struct {
+	MaxDays int `json:"maxDays"`
+	Urgency int `json:"urgency"`
+}
+
+// PriorityConfig represents an organization's priority scoring configuration
+type PriorityConfig struct {
+	Weights                 PriorityWeights    `json:"weights"`
+	UrgencyCurve            []UrgencyThreshold `json:"urgencyCurve"`
+	OverdueUrgency          int                `json:"overdueUrgency"`
+	DistantUrgency          int                `json:"distantUrgency"`
+	NoDueDateUrgency        int                `json:"noDueDateUrgency"`
+	DependencyImpactPerTask int                `json:"dependencyImpactPerTask"`
+}
+
+// PriorityConfigResponse represents the priority configuration of an organization
+type PriorityConfigResponse struct {
+	OrganizationID string         `json:"organizationId"`
+	Config         PriorityConfig `json:"config"`
+	IsDefault      bool           `json:"isDefault"`
+}
+
+// PriorityConfigPreviewRequest represents a request to preview rankings under a candidate configuration
+type PriorityConfigPreviewRequest struct {
+	ProjectID string         `json:"projectId" binding:"required"`
+	Config    PriorityConfig `json:"config"`
+	Limit     int            `json:"limit" binding:"min=0,max=100"`
+}
+
+// RankingChange represents how a task's rank and score would change under a candidate configuration
+type RankingChange struct {
+	TaskID       string `json:"taskId"`
+	Title        string `json:"title"`
+	CurrentRank  int    `json:"currentRank"`
+	PreviewRank  int    `json:"previewRank"`
+	CurrentScore int    `json:"currentScore"`
+	PreviewScore int    `json:"previewScore"`
+	RankDelta    int    `json:"rankDelta"`
+}
+
+// PriorityConfigPreviewResponse represents the ranking preview for a candidate configuration
+type PriorityConfigPreviewResponse struct {
+	ProjectID  string          `json:"projectId"`
+	Changes    []RankingChange `json:"changes"`
+	MovedTasks int             `json:"movedTasks"`
+	Total      int             `json:"total"`
+}
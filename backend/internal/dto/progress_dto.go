@@ -45,6 +45,7 @@ type Milestone struct {
 	TaskID      string     `json:"taskId"`
 	Title       string     `json:"title"`
 	Status      string     `json:"status"`
+	DueDate     *time.Time `json:"dueDate,omitempty"`
 	CompletedAt *time.Time `json:"completedAt,omitempty"`
 }
 
@@ -90,7 +91,7 @@ type SubtaskProgress struct {
 
 // UpdateTaskProgressRequest represents a request to update task progress
 type UpdateTaskProgressRequest struct {
-	Status             string  `json:"status,omitempty" binding:"omitempty,oneof=backlog ready in_progress review done"`
+	Status             string  `json:"status,omitempty"` // a workflow status key or canonical category
 	ProgressPercentage *int    `json:"progressPercentage,omitempty" binding:"omitempty,min=0,max=100"`
 	ActualHours        float64 `json:"actualHours,omitempty" binding:"omitempty,min=0"`
 	Note               string  `json:"note,omitempty"`
@@ -130,6 +131,7 @@ type RollupItem struct {
 type ProjectRollupResponse struct {
 	ProjectID        string       `json:"projectId"`
 	ProgressPercentage int        `json:"progressPercentage"`
+	CalculationMethod string      `json:"calculationMethod"`
 	Tasks            []RollupItem `json:"tasks"`
 	CalculatedAt     time.Time    `json:"calculatedAt"`
 }
@@ -0,0 +1,48 @@
+package dto
+
+import "time"
+
+// ===== Auto-scheduling DTOs =====
+
+// ScheduleChangeRequest is a proposed change to a task's dates or status
+type ScheduleChangeRequest struct {
+	StartDate *time.Time `json:"startDate"`
+	DueDate   *time.Time `json:"dueDate"`
+	Status    string     `json:"status"`
+}
+
+// ScheduledTaskChange is a task whose dates move with the tasks it depends on
+type ScheduledTaskChange struct {
+	TaskID       string     `json:"taskId"`
+	Title        string     `json:"title"`
+	IsMilestone  bool       `json:"isMilestone"`
+	OldStartDate *time.Time `json:"oldStartDate,omitempty"`
+	NewStartDate *time.Time `json:"newStartDate,omitempty"`
+	OldDueDate   *time.Time `json:"oldDueDate,omitempty"`
+	NewDueDate   *time.Time `json:"newDueDate,omitempty"`
+	ShiftHours   float64    `json:"shiftHours"`
+	CausedBy     string     `json:"causedBy"` // the predecessor that pushed the task
+}
+
+// ScheduleConflict is a task with pinned dates that a dependency would have moved
+type ScheduleConflict struct {
+	TaskID          string  `json:"taskId"`
+	Title           string  `json:"title"`
+	DependsOnTaskID string  `json:"dependsOnTaskId"`
+	OverlapHours    float64 `json:"overlapHours"`
+}
+
+// SchedulePreviewResponse is what a change to a task does to the dates of the tasks
+// depending on it
+type SchedulePreviewResponse struct {
+	TaskID                 string                `json:"taskId"`
+	ProjectID              string                `json:"projectId"`
+	AutoSchedule           bool                  `json:"autoSchedule"`
+	Changes                []ScheduledTaskChange `json:"changes"`
+	Conflicts              []ScheduleConflict    `json:"conflicts"`
+	PreviousProjectEndDate *time.Time            `json:"previousProjectEndDate,omitempty"`
+	ProjectEndDate         *time.Time            `json:"projectEndDate,omitempty"`
+	TargetEndDate          *time.Time            `json:"targetEndDate,omitempty"`
+	PushesTargetEndDate    bool                  `json:"pushesTargetEndDate"`
+	NudgeIDs               []string              `json:"nudgeIds,omitempty"`
+}
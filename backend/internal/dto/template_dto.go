@@ -0,0 +1,99 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ===== Project Template DTOs =====
+
+// TemplateDocumentFormat identifies an exported project template
+const TemplateDocumentFormat = "xephyr.project-template"
+
+// TemplateDocumentVersion is the template document version this server reads and writes
+const TemplateDocumentVersion = 1
+
+// CreateTemplateRequest saves an existing project as a template
+type CreateTemplateRequest struct {
+	ProjectID   uuid.UUID `json:"projectId" binding:"required"`
+	Name        string    `json:"name" binding:"required,max=200"`
+	Description string    `json:"description"`
+}
+
+// InstantiateTemplateRequest creates a project from a template
+type InstantiateTemplateRequest struct {
+	Name      string    `json:"name" binding:"required,max=200"`
+	StartDate time.Time `json:"startDate" binding:"required"`
+}
+
+// ProjectTemplateDocument is the portable form of a template, used to share templates
+// between organizations. Tasks refer to each other by key and skills are named rather
+// than referenced, since IDs mean nothing in another organization.
+type ProjectTemplateDocument struct {
+	Format       string                       `json:"format"`
+	Version      int                          `json:"version"`
+	Name         string                       `json:"name"`
+	Description  string                       `json:"description,omitempty"`
+	DurationDays int                          `json:"durationDays"`
+	Tasks        []TemplateTaskDocument       `json:"tasks"`
+	Dependencies []TemplateDependencyDocument `json:"dependencies"`
+}
+
+// TemplateTaskDocument is a task in a template document. Offsets are days from the
+// project start.
+type TemplateTaskDocument struct {
+	Key             string                  `json:"key"`
+	Parent          string                  `json:"parent,omitempty"`
+	Title           string                  `json:"title"`
+	Description     string                  `json:"description,omitempty"`
+	Priority        string                  `json:"priority,omitempty"`
+	BusinessValue   int                     `json:"businessValue,omitempty"`
+	EstimatedHours  float64                 `json:"estimatedHours"`
+	StartOffsetDays *int                    `json:"startOffsetDays,omitempty"`
+	DueOffsetDays   *int                    `json:"dueOffsetDays,omitempty"`
+	IsMilestone     bool                    `json:"isMilestone,omitempty"`
+	Skills          []TemplateSkillDocument `json:"skills,omitempty"`
+}
+
+// TemplateSkillDocument is a skill requirement named by skill
+type TemplateSkillDocument struct {
+	Name                string `json:"name"`
+	Category            string `json:"category,omitempty"`
+	ProficiencyRequired int    `json:"proficiencyRequired"`
+	IsRequired          bool   `json:"isRequired"`
+}
+
+// TemplateDependencyDocument links two tasks of a template document by key
+type TemplateDependencyDocument struct {
+	Task      string `json:"task"`
+	DependsOn string `json:"dependsOn"`
+	Type      string `json:"type,omitempty"`
+	LagHours  int    `json:"lagHours,omitempty"`
+}
+
+// ProjectTemplateSummary describes a template without its contents
+type ProjectTemplateSummary struct {
+	ID              uuid.UUID  `json:"id"`
+	Name            string     `json:"name"`
+	Description     string     `json:"description"`
+	DurationDays    int        `json:"durationDays"`
+	TaskCount       int        `json:"taskCount"`
+	SourceProjectID *uuid.UUID `json:"sourceProjectId,omitempty"`
+	CreatedByID     uuid.UUID  `json:"createdById"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+// ProjectTemplateResponse is a template with its contents. Task keys are the template
+// task IDs.
+type ProjectTemplateResponse struct {
+	ProjectTemplateSummary
+	Tasks        []TemplateTaskDocument       `json:"tasks"`
+	Dependencies []TemplateDependencyDocument `json:"dependencies"`
+}
+
+// ProjectTemplateListResponse is a page of templates
+type ProjectTemplateListResponse struct {
+	Templates []ProjectTemplateSummary `json:"templates"`
+	Total     int64                    `json:"total"`
+}
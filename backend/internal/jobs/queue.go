@@ -0,0 +1,365 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+// ProgressFunc reports the progress (0-100) of a running job
+type ProgressFunc func(progress int)
+
+// Handler processes a single job and returns its result payload
+type Handler func(ctx context.Context, job *models.Job, report ProgressFunc) (models.JSONB, error)
+
+// Scope runs fn on behalf of an organization, typically in a transaction the database
+// restricts to that organization's rows
+type Scope func(ctx context.Context, orgID uuid.UUID, fn func(ctx context.Context) error) error
+
+// ErrUnknownJobType is returned when enqueuing a job type with no registered handler
+var ErrUnknownJobType = errors.New("unknown job type")
+
+// permanentError is a handler error that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error as one that retrying cannot fix, such as an invalid
+// payload or a record that no longer exists. The job fails without further attempts.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether an error was marked with Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// Config holds worker pool settings
+type Config struct {
+	Workers      int
+	PollInterval time.Duration
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	MaxAttempts  int
+	JobTimeout   time.Duration
+}
+
+// DefaultConfig returns default worker pool settings
+func DefaultConfig() Config {
+	return Config{
+		Workers:      4,
+		PollInterval: time.Second,
+		BaseBackoff:  5 * time.Second,
+		MaxBackoff:   10 * time.Minute,
+		MaxAttempts:  5,
+		JobTimeout:   10 * time.Minute,
+	}
+}
+
+// Queue is a database-backed job queue with an in-process worker pool.
+// Jobs are persisted before they run, so queued work survives a restart.
+type Queue struct {
+	repo   repositories.JobRepository
+	config Config
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	scope    Scope
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewQueue creates a new job queue
+func NewQueue(repo repositories.JobRepository, config Config) *Queue {
+	defaults := DefaultConfig()
+	if config.Workers <= 0 {
+		config.Workers = defaults.Workers
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = defaults.BaseBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaults.MaxBackoff
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.JobTimeout <= 0 {
+		config.JobTimeout = defaults.JobTimeout
+	}
+
+	return &Queue{
+		repo:     repo,
+		config:   config,
+		handlers: make(map[string]Handler),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Register sets the handler for a job type
+func (q *Queue) Register(jobType string, handler Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = handler
+}
+
+// SetScope makes handlers run inside scope for their job's organization. Without one,
+// handlers run directly.
+func (q *Queue) SetScope(scope Scope) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.scope = scope
+}
+
+// Enqueue persists a new job and wakes an idle worker
+func (q *Queue) Enqueue(ctx context.Context, orgID uuid.UUID, jobType string, payload interface{}) (*models.Job, error) {
+	if _, ok := q.handler(jobType); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownJobType, jobType)
+	}
+
+	encoded, err := EncodePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		OrganizationID: orgID,
+		Type:           jobType,
+		Status:         models.JobStatusQueued,
+		Payload:        encoded,
+		MaxAttempts:    q.config.MaxAttempts,
+		RunAt:          time.Now(),
+	}
+	if err := q.repo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Start requeues jobs interrupted by a previous shutdown and starts the workers
+func (q *Queue) Start(ctx context.Context) error {
+	requeued, err := q.repo.RequeueRunning(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to requeue interrupted jobs: %w", err)
+	}
+	if requeued > 0 {
+		log.Printf("[JobQueue] Requeued %d interrupted jobs", requeued)
+	}
+
+	workerCtx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+	for i := 0; i < q.config.Workers; i++ {
+		q.wg.Add(1)
+		go q.work(workerCtx)
+	}
+	log.Printf("[JobQueue] Started %d workers", q.config.Workers)
+	return nil
+}
+
+// Stop signals the workers to exit and waits for in-flight jobs to finish
+func (q *Queue) Stop() {
+	if q.cancel == nil {
+		return
+	}
+	q.cancel()
+	q.wg.Wait()
+	log.Println("[JobQueue] Stopped")
+}
+
+func (q *Queue) work(ctx context.Context) {
+	defer q.wg.Done()
+
+	for {
+		job, err := q.repo.ClaimNext(ctx, q.types())
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[JobQueue] Error claiming job: %v", err)
+		}
+		if job != nil {
+			q.process(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-time.After(q.config.PollInterval):
+		}
+	}
+}
+
+// process runs a claimed job and records its outcome. Bookkeeping writes use a
+// fresh context so outcomes are recorded even while the queue is stopping.
+func (q *Queue) process(ctx context.Context, job *models.Job) {
+	handler, ok := q.handler(job.Type)
+	if !ok {
+		q.fail(job, fmt.Sprintf("no handler registered for job type %s", job.Type))
+		return
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, q.config.JobTimeout)
+	defer cancel()
+
+	report := func(progress int) {
+		if progress < 0 {
+			progress = 0
+		}
+		if progress > 100 {
+			progress = 100
+		}
+		if err := q.repo.UpdateProgress(context.Background(), job.ID, progress); err != nil {
+			log.Printf("[JobQueue] Error updating progress of job %s: %v", job.ID, err)
+		}
+	}
+
+	var result models.JSONB
+	err := q.runScoped(jobCtx, job, func(ctx context.Context) error {
+		var err error
+		result, err = runHandler(ctx, handler, job, report)
+		return err
+	})
+	if err == nil {
+		if err := q.repo.MarkSucceeded(context.Background(), job.ID, result); err != nil {
+			log.Printf("[JobQueue] Error completing job %s: %v", job.ID, err)
+		}
+		return
+	}
+
+	maxAttempts := job.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = q.config.MaxAttempts
+	}
+	if IsPermanent(err) || (ctx.Err() == nil && job.Attempts >= maxAttempts) {
+		q.fail(job, err.Error())
+		return
+	}
+
+	// Jobs interrupted by shutdown are retried immediately on the next start
+	runAt := time.Now()
+	if ctx.Err() == nil {
+		runAt = runAt.Add(Backoff(job.Attempts, q.config.BaseBackoff, q.config.MaxBackoff))
+	}
+	log.Printf("[JobQueue] Job %s (%s) attempt %d failed, retrying at %s: %v",
+		job.ID, job.Type, job.Attempts, runAt.Format(time.RFC3339), err)
+	if err := q.repo.Reschedule(context.Background(), job.ID, runAt, err.Error()); err != nil {
+		log.Printf("[JobQueue] Error rescheduling job %s: %v", job.ID, err)
+	}
+}
+
+func (q *Queue) fail(job *models.Job, errMsg string) {
+	log.Printf("[JobQueue] Job %s (%s) failed permanently: %s", job.ID, job.Type, errMsg)
+	if err := q.repo.MarkFailed(context.Background(), job.ID, errMsg); err != nil {
+		log.Printf("[JobQueue] Error failing job %s: %v", job.ID, err)
+	}
+}
+
+func (q *Queue) handler(jobType string) (Handler, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	h, ok := q.handlers[jobType]
+	return h, ok
+}
+
+func (q *Queue) types() []string {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	types := make([]string, 0, len(q.handlers))
+	for t := range q.handlers {
+		types = append(types, t)
+	}
+	return types
+}
+
+// runScoped runs fn inside the queue's scope for the job's organization
+func (q *Queue) runScoped(ctx context.Context, job *models.Job, fn func(ctx context.Context) error) error {
+	q.mu.RLock()
+	scope := q.scope
+	q.mu.RUnlock()
+	if scope == nil {
+		return fn(ctx)
+	}
+	return scope(ctx, job.OrganizationID, fn)
+}
+
+// runHandler invokes a handler, converting a panic into an error
+func runHandler(ctx context.Context, handler Handler, job *models.Job, report ProgressFunc) (result models.JSONB, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job, report)
+}
+
+// Backoff returns the delay before retrying after the given attempt,
+// doubling from base and capped at max
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
+
+// EncodePayload converts a payload struct to a JSONB value
+func EncodePayload(payload interface{}) (models.JSONB, error) {
+	if payload == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	var out models.JSONB
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("job payload must be a JSON object: %w", err)
+	}
+	return out, nil
+}
+
+// DecodePayload decodes a job's payload into v. A payload that does not decode is a
+// permanent error.
+func DecodePayload(job *models.Job, v interface{}) error {
+	data, err := json.Marshal(job.Payload)
+	if err != nil {
+		return Permanent(err)
+	}
+	return Permanent(json.Unmarshal(data, v))
+}
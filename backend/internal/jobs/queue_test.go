@@ -0,0 +1,262 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/SimpleAjax/Xephyr/internal/jobs"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// memoryJobRepository is an in-memory JobRepository for queue specs
+type memoryJobRepository struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*models.Job
+}
+
+func newMemoryJobRepository() *memoryJobRepository {
+	return &memoryJobRepository{jobs: make(map[uuid.UUID]*models.Job)}
+}
+
+func (r *memoryJobRepository) Create(ctx context.Context, job *models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job.ID == uuid.Nil {
+		job.ID = uuid.New()
+	}
+	job.CreatedAt = time.Now()
+	copied := *job
+	r.jobs[job.ID] = &copied
+	return nil
+}
+
+func (r *memoryJobRepository) GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok || job.OrganizationID != orgID {
+		return nil, fmt.Errorf("job not found")
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (r *memoryJobRepository) ClaimNext(ctx context.Context, types []string) (*models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, job := range r.jobs {
+		if job.Status != models.JobStatusQueued || job.RunAt.After(time.Now()) {
+			continue
+		}
+		for _, t := range types {
+			if t == job.Type {
+				now := time.Now()
+				job.Status = models.JobStatusRunning
+				job.Attempts++
+				job.StartedAt = &now
+				copied := *job
+				return &copied, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryJobRepository) update(id uuid.UUID, fn func(job *models.Job)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job, ok := r.jobs[id]; ok {
+		fn(job)
+	}
+	return nil
+}
+
+func (r *memoryJobRepository) UpdateProgress(ctx context.Context, id uuid.UUID, progress int) error {
+	return r.update(id, func(job *models.Job) { job.Progress = progress })
+}
+
+func (r *memoryJobRepository) MarkSucceeded(ctx context.Context, id uuid.UUID, result models.JSONB) error {
+	return r.update(id, func(job *models.Job) {
+		job.Status = models.JobStatusSucceeded
+		job.Progress = 100
+		job.Result = result
+	})
+}
+
+func (r *memoryJobRepository) MarkFailed(ctx context.Context, id uuid.UUID, errMsg string) error {
+	return r.update(id, func(job *models.Job) {
+		job.Status = models.JobStatusFailed
+		job.LastError = errMsg
+	})
+}
+
+func (r *memoryJobRepository) Reschedule(ctx context.Context, id uuid.UUID, runAt time.Time, errMsg string) error {
+	return r.update(id, func(job *models.Job) {
+		job.Status = models.JobStatusQueued
+		job.RunAt = runAt
+		job.LastError = errMsg
+	})
+}
+
+func (r *memoryJobRepository) RequeueRunning(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for _, job := range r.jobs {
+		if job.Status == models.JobStatusRunning {
+			job.Status = models.JobStatusQueued
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memoryJobRepository) status(id uuid.UUID) models.JobStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.jobs[id].Status
+}
+
+var _ = Describe("Job Queue", func() {
+	var repo *memoryJobRepository
+	var queue *jobs.Queue
+	orgID := uuid.New()
+
+	BeforeEach(func() {
+		repo = newMemoryJobRepository()
+		queue = jobs.NewQueue(repo, jobs.Config{
+			Workers:      2,
+			PollInterval: 5 * time.Millisecond,
+			BaseBackoff:  time.Millisecond,
+			MaxBackoff:   5 * time.Millisecond,
+			MaxAttempts:  3,
+		})
+	})
+
+	AfterEach(func() {
+		queue.Stop()
+	})
+
+	Context("Given a registered handler that succeeds", func() {
+		It("should run the job and store its result", func() {
+			queue.Register("echo", func(ctx context.Context, job *models.Job, report jobs.ProgressFunc) (models.JSONB, error) {
+				var payload struct {
+					Message string `json:"message"`
+				}
+				Expect(jobs.DecodePayload(job, &payload)).To(Succeed())
+				report(50)
+				return models.JSONB{"echo": payload.Message}, nil
+			})
+			Expect(queue.Start(context.Background())).To(Succeed())
+
+			job, err := queue.Enqueue(context.Background(), orgID, "echo", map[string]string{"message": "hi"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Status).To(Equal(models.JobStatusQueued))
+
+			Eventually(func() models.JobStatus { return repo.status(job.ID) }).Should(Equal(models.JobStatusSucceeded))
+			stored, _ := repo.GetByID(context.Background(), orgID, job.ID)
+			Expect(stored.Result).To(HaveKeyWithValue("echo", "hi"))
+			Expect(stored.Progress).To(Equal(100))
+		})
+	})
+
+	Context("Given a tenant scope", func() {
+		It("should run the handler inside the scope for the job's organization", func() {
+			type scopeKey struct{}
+			var scopedOrg uuid.UUID
+			queue.SetScope(func(ctx context.Context, org uuid.UUID, fn func(ctx context.Context) error) error {
+				scopedOrg = org
+				return fn(context.WithValue(ctx, scopeKey{}, org))
+			})
+			queue.Register("scoped", func(ctx context.Context, job *models.Job, report jobs.ProgressFunc) (models.JSONB, error) {
+				return models.JSONB{"org": ctx.Value(scopeKey{}).(uuid.UUID).String()}, nil
+			})
+			Expect(queue.Start(context.Background())).To(Succeed())
+
+			job, err := queue.Enqueue(context.Background(), orgID, "scoped", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func() models.JobStatus { return repo.status(job.ID) }).Should(Equal(models.JobStatusSucceeded))
+			stored, _ := repo.GetByID(context.Background(), orgID, job.ID)
+			Expect(stored.Result).To(HaveKeyWithValue("org", orgID.String()))
+			Expect(scopedOrg).To(Equal(orgID))
+		})
+	})
+
+	Context("Given a handler that keeps failing", func() {
+		It("should retry with backoff and fail after the max attempts", func() {
+			queue.Register("flaky", func(ctx context.Context, job *models.Job, report jobs.ProgressFunc) (models.JSONB, error) {
+				return nil, errors.New("boom")
+			})
+			Expect(queue.Start(context.Background())).To(Succeed())
+
+			job, err := queue.Enqueue(context.Background(), orgID, "flaky", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func() models.JobStatus { return repo.status(job.ID) }).Should(Equal(models.JobStatusFailed))
+			stored, _ := repo.GetByID(context.Background(), orgID, job.ID)
+			Expect(stored.Attempts).To(Equal(3))
+			Expect(stored.LastError).To(Equal("boom"))
+		})
+
+		It("should fail without retrying when the error is permanent", func() {
+			queue.Register("invalid", func(ctx context.Context, job *models.Job, report jobs.ProgressFunc) (models.JSONB, error) {
+				return nil, jobs.Permanent(errors.New("project not found"))
+			})
+			Expect(queue.Start(context.Background())).To(Succeed())
+
+			job, err := queue.Enqueue(context.Background(), orgID, "invalid", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func() models.JobStatus { return repo.status(job.ID) }).Should(Equal(models.JobStatusFailed))
+			stored, _ := repo.GetByID(context.Background(), orgID, job.ID)
+			Expect(stored.Attempts).To(Equal(1))
+			Expect(stored.LastError).To(Equal("project not found"))
+		})
+
+		It("should recover from a panicking handler", func() {
+			queue.Register("panics", func(ctx context.Context, job *models.Job, report jobs.ProgressFunc) (models.JSONB, error) {
+				panic("unexpected")
+			})
+			Expect(queue.Start(context.Background())).To(Succeed())
+
+			job, _ := queue.Enqueue(context.Background(), orgID, "panics", nil)
+			Eventually(func() models.JobStatus { return repo.status(job.ID) }).Should(Equal(models.JobStatusFailed))
+		})
+	})
+
+	Context("Given jobs left running by a previous process", func() {
+		It("should requeue and run them on start", func() {
+			queue.Register("echo", func(ctx context.Context, job *models.Job, report jobs.ProgressFunc) (models.JSONB, error) {
+				return models.JSONB{}, nil
+			})
+			interrupted := &models.Job{OrganizationID: orgID, Type: "echo", Status: models.JobStatusRunning, MaxAttempts: 3}
+			Expect(repo.Create(context.Background(), interrupted)).To(Succeed())
+
+			Expect(queue.Start(context.Background())).To(Succeed())
+			Eventually(func() models.JobStatus { return repo.status(interrupted.ID) }).Should(Equal(models.JobStatusSucceeded))
+		})
+	})
+
+	Context("Given an unknown job type", func() {
+		It("should refuse to enqueue it", func() {
+			_, err := queue.Enqueue(context.Background(), orgID, "missing", nil)
+			Expect(err).To(MatchError(jobs.ErrUnknownJobType))
+		})
+	})
+
+	Context("Given retry backoff", func() {
+		It("should double per attempt up to the cap", func() {
+			Expect(jobs.Backoff(1, time.Second, time.Minute)).To(Equal(time.Second))
+			Expect(jobs.Backoff(3, time.Second, time.Minute)).To(Equal(4 * time.Second))
+			Expect(jobs.Backoff(20, time.Second, time.Minute)).To(Equal(time.Minute))
+		})
+	})
+})
@@ -1,120 +1,212 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/SimpleAjax/Xephyr/internal/auth"
 	"github.com/SimpleAjax/Xephyr/internal/dto"
 )
 
-// AuthMiddleware validates JWT tokens and sets user context
-type AuthMiddleware struct {
-	// In real implementation, this would have a JWT validator
-	jwtSecret string
+// AuthConfig configures token verification
+type AuthConfig struct {
+	// JWTSecret verifies HS256 tokens
+	JWTSecret string
+	// JWKSFile is a JSON Web Key Set whose RSA keys verify RS256 tokens
+	JWKSFile string
+	// ClockSkew is the tolerance applied to token expiry and not-before times
+	ClockSkew time.Duration
+	// DevMode accepts X-User-ID and X-Organization-Id headers in place of a token.
+	// It must never be enabled in front of real users.
+	DevMode bool
 }
 
-// NewAuthMiddleware creates a new auth middleware
-func NewAuthMiddleware(jwtSecret string) *AuthMiddleware {
-	return &AuthMiddleware{jwtSecret: jwtSecret}
+// AuthMiddleware validates JWT tokens and sets user context
+type AuthMiddleware struct {
+	verifier *auth.Verifier
+	devMode  bool
+	now      func() time.Time
 }
 
-// Authenticate validates the JWT token and sets user context
-// For development: if no auth header is provided, generates dummy user/org IDs
-func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		authHeader := ctx.GetHeader("Authorization")
-		
-		// TODO: Implement actual JWT validation
-		// For development/demo: allow requests without auth header
-		userID := ctx.GetHeader("X-User-ID")
-		orgID := ctx.GetHeader("X-Organization-Id")
-
-		// If auth header provided, extract token (but don't validate for now)
-		if authHeader != "" {
-			parts := strings.SplitN(authHeader, " ", 2)
-			if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
-				// Token provided - could validate here in production
-			}
-		}
-
-		if userID == "" {
-			// Generate dummy user ID for demo
-			userID = uuid.New().String()
-		}
-		if orgID == "" {
-			// Generate dummy org ID for demo
-			orgID = uuid.New().String()
+// NewAuthMiddleware creates a new auth middleware. A secret or JWKS file is required
+// unless dev mode is on.
+func NewAuthMiddleware(cfg AuthConfig) (*AuthMiddleware, error) {
+	verifierCfg := auth.VerifierConfig{Secret: []byte(cfg.JWTSecret), ClockSkew: cfg.ClockSkew}
+	if cfg.JWKSFile != "" {
+		keys, err := auth.LoadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
 		}
+		verifierCfg.Keys = keys
+	}
 
-		// Validate UUIDs
-		userUUID, err := uuid.Parse(userID)
+	m := &AuthMiddleware{devMode: cfg.DevMode, now: time.Now}
+	if cfg.JWTSecret != "" || cfg.JWKSFile != "" {
+		verifier, err := auth.NewVerifier(verifierCfg)
 		if err != nil {
-			ctx.JSON(http.StatusUnauthorized, dto.NewErrorResponse(
-				"UNAUTHORIZED",
-				"Invalid user ID",
-				nil,
-				GetRequestID(ctx),
-			))
-			ctx.Abort()
-			return
+			return nil, err
 		}
+		m.verifier = verifier
+	} else if !cfg.DevMode {
+		return nil, errors.New("auth requires a JWT secret or JWKS file outside dev mode")
+	}
+	return m, nil
+}
 
-		orgUUID, err := uuid.Parse(orgID)
-		if err != nil {
-			ctx.JSON(http.StatusForbidden, dto.NewErrorResponse(
-				"FORBIDDEN",
-				"Invalid organization ID",
-				nil,
-				GetRequestID(ctx),
-			))
+// Verifier returns the token verifier, or nil in dev mode without keys
+func (m *AuthMiddleware) Verifier() *auth.Verifier {
+	return m.verifier
+}
+
+// identity is who a request acts as and in which organization
+type identity struct {
+	userID uuid.UUID
+	orgID  string
+	role   string
+	claims *auth.Claims
+}
+
+// authError is a rejected credential together with the status to answer with
+type authError struct {
+	status  int
+	code    string
+	message string
+}
+
+// Authenticate validates the bearer token and sets userId, organizationId and role in the
+// context. The X-Organization-Id header picks one of the token's organizations; without
+// it the token's default organization is used.
+func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, aerr := m.identify(ctx)
+		if aerr == nil && id == nil {
+			aerr = &authError{http.StatusUnauthorized, "UNAUTHORIZED", "Missing bearer token"}
+		}
+		if aerr != nil {
+			ctx.JSON(aerr.status, dto.NewErrorResponse(aerr.code, aerr.message, nil, GetRequestID(ctx)))
 			ctx.Abort()
 			return
 		}
 
-		// Set user context
-		ctx.Set("userId", userUUID.String())
-		ctx.Set("organizationId", orgUUID.String())
-		if authHeader != "" {
-			parts := strings.SplitN(authHeader, " ", 2)
-			if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
-				ctx.Set("token", parts[1])
-			}
-		}
-
+		setIdentity(ctx, id)
 		ctx.Next()
 	}
 }
 
-// OptionalAuth allows requests without authentication but sets user info if available
+// OptionalAuth allows requests without authentication but sets user info if the
+// request carries valid credentials
 func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		authHeader := ctx.GetHeader("Authorization")
-		if authHeader != "" {
-			// Try to extract and validate token
-			parts := strings.SplitN(authHeader, " ", 2)
-			if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
-				userID := ctx.GetHeader("X-User-ID")
-				orgID := ctx.GetHeader("X-Organization-Id")
-
-				if userID != "" && orgID != "" {
-					ctx.Set("userId", userID)
-					ctx.Set("organizationId", orgID)
-				}
-			}
+		if id, aerr := m.identify(ctx); aerr == nil && id != nil {
+			setIdentity(ctx, id)
 		}
 		ctx.Next()
 	}
 }
 
-// RequireRole checks if the user has the required role
+// RequireRole checks if the user has one of the given organization roles. It must run
+// after RequireOrganization, which loads the role from the caller's membership.
 func (m *AuthMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		// TODO: Implement role checking
-		// For now, just pass through
-		ctx.Next()
+		role := GetRole(ctx)
+		for _, r := range roles {
+			if r == role {
+				ctx.Next()
+				return
+			}
+		}
+		Forbidden(ctx, "Your role does not allow this action", map[string]interface{}{
+			"requiredRoles": roles,
+		})
+	}
+}
+
+// identify resolves the caller. It returns nil without error when the request has
+// no credentials at all.
+func (m *AuthMiddleware) identify(ctx *gin.Context) (*identity, *authError) {
+	token, hasToken := bearerToken(ctx.GetHeader("Authorization"))
+	if !hasToken {
+		if m.devMode && ctx.GetHeader("X-User-ID") != "" {
+			return devIdentity(ctx)
+		}
+		return nil, nil
+	}
+	if m.verifier == nil {
+		return nil, &authError{http.StatusUnauthorized, "UNAUTHORIZED", "Token authentication is not configured"}
+	}
+
+	claims, err := m.verifier.Verify(token, m.now())
+	if err != nil {
+		message := "Invalid token"
+		if errors.Is(err, auth.ErrTokenExpired) {
+			message = "Token expired"
+		}
+		return nil, &authError{http.StatusUnauthorized, "UNAUTHORIZED", message}
+	}
+	if claims.Type == auth.TokenTypeRefresh {
+		return nil, &authError{http.StatusUnauthorized, "UNAUTHORIZED", "Refresh tokens cannot be used for API access"}
+	}
+	if claims.Type != "" && claims.Type != auth.TokenTypeAccess {
+		return nil, &authError{http.StatusUnauthorized, "UNAUTHORIZED", "Only access tokens can be used for API access"}
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, &authError{http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token subject"}
+	}
+
+	id := &identity{userID: userID, claims: claims}
+	if requested := ctx.GetHeader("X-Organization-Id"); requested != "" {
+		membership, ok := claims.Membership(requested)
+		if !ok {
+			return nil, &authError{http.StatusForbidden, "FORBIDDEN", fmt.Sprintf("Not a member of organization %s", requested)}
+		}
+		id.orgID, id.role = membership.OrganizationID, membership.Role
+	} else if membership, ok := claims.DefaultMembership(); ok {
+		id.orgID, id.role = membership.OrganizationID, membership.Role
+	}
+	return id, nil
+}
+
+// devIdentity trusts the identity headers; only reachable in dev mode
+func devIdentity(ctx *gin.Context) (*identity, *authError) {
+	userID, err := uuid.Parse(ctx.GetHeader("X-User-ID"))
+	if err != nil {
+		return nil, &authError{http.StatusUnauthorized, "UNAUTHORIZED", "Invalid user ID"}
+	}
+	return &identity{
+		userID: userID,
+		orgID:  ctx.GetHeader("X-Organization-Id"),
+		role:   ctx.GetHeader("X-User-Role"),
+	}, nil
+}
+
+func bearerToken(header string) (string, bool) {
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") || strings.TrimSpace(parts[1]) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(parts[1]), true
+}
+
+func setIdentity(ctx *gin.Context, id *identity) {
+	ctx.Set("userId", id.userID.String())
+	if id.orgID != "" {
+		ctx.Set("organizationId", id.orgID)
+	}
+	if id.role != "" {
+		ctx.Set("role", id.role)
+	}
+	if id.claims != nil {
+		ctx.Set("claims", id.claims)
+		if token, ok := bearerToken(ctx.GetHeader("Authorization")); ok {
+			ctx.Set("token", token)
+		}
 	}
 }
 
@@ -127,3 +219,8 @@ func GetUserID(ctx *gin.Context) string {
 func GetOrganizationID(ctx *gin.Context) string {
 	return ctx.GetString("organizationId")
 }
+
+// GetRole retrieves the caller's role in the current organization from context
+func GetRole(ctx *gin.Context) string {
+	return ctx.GetString("role")
+}
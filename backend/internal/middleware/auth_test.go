@@ -0,0 +1,150 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/SimpleAjax/Xephyr/internal/auth"
+	"github.com/SimpleAjax/Xephyr/internal/middleware"
+)
+
+var _ = Describe("AuthMiddleware", func() {
+	secret := "test-secret"
+
+	var (
+		userID, orgA, orgB string
+		router             *gin.Engine
+	)
+
+	// serve builds a router behind the given middleware that echoes the resolved identity
+	serve := func(cfg middleware.AuthConfig, optional bool) {
+		m, err := middleware.NewAuthMiddleware(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		router = gin.New()
+		handler := m.Authenticate()
+		if optional {
+			handler = m.OptionalAuth()
+		}
+		router.GET("/me", handler, func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{
+				"userId":         ctx.GetString("userId"),
+				"organizationId": ctx.GetString("organizationId"),
+				"role":           ctx.GetString("role"),
+			})
+		})
+	}
+
+	request := func(token string, headers map[string]string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		var body map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+
+	sign := func(mutate func(*auth.Claims)) string {
+		c := auth.Claims{
+			Subject:        userID,
+			ExpiresAt:      time.Now().Add(time.Hour).Unix(),
+			OrganizationID: orgA,
+			Role:           "pm",
+			Organizations:  []auth.Membership{{OrganizationID: orgA, Role: "pm"}, {OrganizationID: orgB, Role: "member"}},
+		}
+		if mutate != nil {
+			mutate(&c)
+		}
+		token, err := auth.SignHS256(c, []byte(secret))
+		Expect(err).NotTo(HaveOccurred())
+		return token
+	}
+
+	BeforeEach(func() {
+		userID, orgA, orgB = uuid.NewString(), uuid.NewString(), uuid.NewString()
+	})
+
+	It("should refuse to start without a secret outside dev mode", func() {
+		_, err := middleware.NewAuthMiddleware(middleware.AuthConfig{})
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("Given a configured secret", func() {
+		BeforeEach(func() {
+			serve(middleware.AuthConfig{JWTSecret: secret, ClockSkew: time.Minute}, false)
+		})
+
+		It("should take identity from the token and its default organization", func() {
+			code, body := request(sign(nil), nil)
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(body).To(HaveKeyWithValue("userId", userID))
+			Expect(body).To(HaveKeyWithValue("organizationId", orgA))
+			Expect(body).To(HaveKeyWithValue("role", "pm"))
+		})
+
+		It("should switch to another organization the token lists", func() {
+			code, body := request(sign(nil), map[string]string{"X-Organization-Id": orgB})
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(body).To(HaveKeyWithValue("organizationId", orgB))
+			Expect(body).To(HaveKeyWithValue("role", "member"))
+		})
+
+		It("should forbid organizations the token does not list", func() {
+			code, _ := request(sign(nil), map[string]string{"X-Organization-Id": uuid.NewString()})
+			Expect(code).To(Equal(http.StatusForbidden))
+		})
+
+		It("should ignore identity headers without a token", func() {
+			code, _ := request("", map[string]string{"X-User-ID": userID, "X-Organization-Id": orgA})
+			Expect(code).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("should reject expired, forged, refresh and invite tokens", func() {
+			expired := sign(func(c *auth.Claims) { c.ExpiresAt = time.Now().Add(-time.Hour).Unix() })
+			forged, _ := auth.SignHS256(auth.Claims{Subject: userID, ExpiresAt: time.Now().Add(time.Hour).Unix()}, []byte("guess"))
+			refresh := sign(func(c *auth.Claims) { c.Type = auth.TokenTypeRefresh })
+			invite := sign(func(c *auth.Claims) { c.Type = auth.TokenTypeInvite })
+
+			for _, token := range []string{expired, forged, refresh, invite} {
+				code, _ := request(token, nil)
+				Expect(code).To(Equal(http.StatusUnauthorized))
+			}
+		})
+	})
+
+	Context("Given optional authentication", func() {
+		BeforeEach(func() {
+			serve(middleware.AuthConfig{JWTSecret: secret}, true)
+		})
+
+		It("should set identity only from a valid token", func() {
+			code, body := request("", map[string]string{"X-User-ID": userID})
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(body).To(HaveKeyWithValue("userId", ""))
+
+			_, body = request(sign(nil), nil)
+			Expect(body).To(HaveKeyWithValue("userId", userID))
+		})
+	})
+
+	Context("Given dev mode", func() {
+		It("should accept identity headers", func() {
+			serve(middleware.AuthConfig{DevMode: true}, false)
+			code, body := request("", map[string]string{"X-User-ID": userID, "X-Organization-Id": orgA})
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(body).To(HaveKeyWithValue("organizationId", orgA))
+		})
+	})
+})
@@ -1,33 +1,40 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
 )
 
 // OrganizationMiddleware validates organization access
 type OrganizationMiddleware struct {
-	// In real implementation, this would check organization membership
+	repos repositories.Repositories
 }
 
 // NewOrganizationMiddleware creates a new organization middleware
-func NewOrganizationMiddleware() *OrganizationMiddleware {
-	return &OrganizationMiddleware{}
+func NewOrganizationMiddleware(repos repositories.Repositories) *OrganizationMiddleware {
+	return &OrganizationMiddleware{repos: repos}
 }
 
-// RequireOrganization ensures an organization ID is provided and valid
+// RequireOrganization ensures an organization ID is provided and that the caller is a
+// member of it. The role stored on the membership replaces any role claimed by the
+// token, so a demotion takes effect on the next request.
 func (m *OrganizationMiddleware) RequireOrganization() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		orgID := ctx.GetHeader("X-Organization-Id")
-		
-		if orgID == "" {
-			// Check if it's in the context (set by auth middleware)
-			orgID = ctx.GetString("organizationId")
-		}
+		// The auth middleware has already checked the organization against the caller's
+		// memberships, so it takes precedence over the raw header
+		orgID := ctx.GetString("organizationId")
 
 		if orgID == "" {
 			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse(
@@ -41,7 +48,8 @@ func (m *OrganizationMiddleware) RequireOrganization() gin.HandlerFunc {
 		}
 
 		// Validate UUID format
-		if _, err := uuid.Parse(orgID); err != nil {
+		orgUUID, err := uuid.Parse(orgID)
+		if err != nil {
 			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse(
 				"VALIDATION_ERROR",
 				"Invalid organization ID format",
@@ -52,11 +60,126 @@ func (m *OrganizationMiddleware) RequireOrganization() gin.HandlerFunc {
 			return
 		}
 
-		// TODO: Verify user has access to this organization
-		// For now, we'll just accept it
+		userID, err := uuid.Parse(GetUserID(ctx))
+		if err != nil {
+			Forbidden(ctx, "Not a member of this organization", nil)
+			return
+		}
+
+		role, err := m.repos.GetOrganization().GetMemberRole(ctx.Request.Context(), orgUUID, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				Forbidden(ctx, "Not a member of this organization", nil)
+				return
+			}
+			log.Printf("[OrganizationMiddleware] Failed to load membership of %s in %s: %v", userID, orgUUID, err)
+			ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse(
+				"INTERNAL_ERROR",
+				"Failed to verify organization membership",
+				nil,
+				GetRequestID(ctx),
+			))
+			ctx.Abort()
+			return
+		}
 
 		ctx.Set("organizationId", orgID)
-		ctx.Next()
+		ctx.Set("role", string(role))
+
+		// Handle the rest of the request in one transaction scoped to the organization, so
+		// the database's row-level security policies back up the repository filters.
+		// Error responses roll back whatever the handler wrote. The response is held back
+		// until the transaction commits, so a failed commit is not reported as a success.
+		headers := ctx.Writer.Header().Clone()
+		buffer := &bufferedWriter{ResponseWriter: ctx.Writer, status: http.StatusOK}
+		ctx.Writer = buffer
+		defer func() { ctx.Writer = buffer.ResponseWriter }() // for the recovery middleware after a panic
+
+		err = m.repos.InOrganization(ctx.Request.Context(), orgUUID, func(txCtx context.Context) error {
+			ctx.Request = ctx.Request.WithContext(txCtx)
+			ctx.Next()
+			if buffer.Status() >= http.StatusBadRequest {
+				return errRequestFailed
+			}
+			return nil
+		})
+		ctx.Writer = buffer.ResponseWriter
+		if err != nil && !errors.Is(err, errRequestFailed) {
+			log.Printf("[OrganizationMiddleware] Failed to complete request transaction for %s: %v", orgUUID, err)
+			// Drop the handler's headers along with its response
+			for k := range ctx.Writer.Header() {
+				delete(ctx.Writer.Header(), k)
+			}
+			for k, v := range headers {
+				ctx.Writer.Header()[k] = v
+			}
+			ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse(
+				"INTERNAL_ERROR",
+				"Failed to complete request",
+				nil,
+				GetRequestID(ctx),
+			))
+			return
+		}
+		buffer.flush()
+	}
+}
+
+// errRequestFailed rolls back the request transaction after an error response
+var errRequestFailed = errors.New("request failed")
+
+// bufferedWriter holds a response back from the client until it is flushed
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// Flush is a no-op: nothing reaches the client before the transaction commits
+func (w *bufferedWriter) Flush() {}
+
+// flush sends the held response to the client
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.written {
+		w.ResponseWriter.WriteHeaderNow()
+		w.ResponseWriter.Write(w.body.Bytes())
 	}
 }
 
@@ -64,7 +187,7 @@ func (m *OrganizationMiddleware) RequireOrganization() gin.HandlerFunc {
 func (m *OrganizationMiddleware) ExtractOrganization() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		orgID := ctx.GetHeader("X-Organization-Id")
-		if orgID != "" {
+		if orgID != "" && ctx.GetString("organizationId") == "" {
 			if _, err := uuid.Parse(orgID); err == nil {
 				ctx.Set("organizationId", orgID)
 			}
@@ -72,3 +195,151 @@ func (m *OrganizationMiddleware) ExtractOrganization() gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// RequireProject rejects requests whose project path parameter names a project outside
+// the caller's organization
+func (m *OrganizationMiddleware) RequireProject(param string) gin.HandlerFunc {
+	return m.requireOwned(param, "Project", func(ctx context.Context, orgID, id uuid.UUID) error {
+		_, err := m.repos.GetProject().GetByID(ctx, orgID, id)
+		return err
+	})
+}
+
+// RequireTask rejects requests whose task path parameter names a task outside the
+// caller's organization
+func (m *OrganizationMiddleware) RequireTask(param string) gin.HandlerFunc {
+	return m.requireOwned(param, "Task", func(ctx context.Context, orgID, id uuid.UUID) error {
+		_, err := m.repos.GetTask().GetByID(ctx, orgID, id)
+		return err
+	})
+}
+
+// RequireDependency rejects requests whose dependency path parameter names a dependency
+// outside the caller's organization
+func (m *OrganizationMiddleware) RequireDependency(param string) gin.HandlerFunc {
+	return m.requireOwned(param, "Dependency", func(ctx context.Context, orgID, id uuid.UUID) error {
+		_, err := m.repos.GetDependency().GetByID(ctx, orgID, id)
+		return err
+	})
+}
+
+// requireOwned parses the named path parameter and checks with load that the record
+// belongs to the caller's organization
+func (m *OrganizationMiddleware) requireOwned(param, resource string, load func(ctx context.Context, orgID, id uuid.UUID) error) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := uuid.Parse(ctx.Param(param))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse(
+				"VALIDATION_ERROR",
+				"Invalid "+strings.ToLower(resource)+" ID format",
+				nil,
+				GetRequestID(ctx),
+			))
+			ctx.Abort()
+			return
+		}
+		orgID, _ := uuid.Parse(GetOrganizationID(ctx))
+
+		if err := load(ctx.Request.Context(), orgID, id); err != nil {
+			m.abortLookup(ctx, resource, id, err)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// TaskAccess is the level of access a route needs on a single task
+type TaskAccess int
+
+const (
+	// TaskAccessUpdate allows the assignee to report status and progress
+	TaskAccessUpdate TaskAccess = iota
+	// TaskAccessManage is reserved for people who run the task's project
+	TaskAccessManage
+)
+
+// RequireTaskAccess guards routes that act on the task named by the given path
+// parameter. Tasks outside the caller's organization are reported as not found.
+// Admins and PMs may act on any task and project leads on tasks in their project;
+// anyone else may only update tasks assigned to them.
+func (m *OrganizationMiddleware) RequireTaskAccess(param string, access TaskAccess) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		taskID, err := uuid.Parse(ctx.Param(param))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse(
+				"VALIDATION_ERROR",
+				"Invalid task ID format",
+				nil,
+				GetRequestID(ctx),
+			))
+			ctx.Abort()
+			return
+		}
+		orgID, _ := uuid.Parse(GetOrganizationID(ctx))
+		userID, _ := uuid.Parse(GetUserID(ctx))
+
+		task, err := m.repos.GetTask().GetByID(ctx.Request.Context(), orgID, taskID)
+		if err != nil {
+			m.abortLookup(ctx, "Task", taskID, err)
+			return
+		}
+
+		role := models.UserRole(GetRole(ctx))
+		permission := PermissionUpdateAnyTask
+		if access == TaskAccessManage {
+			permission = PermissionManageTasks
+		}
+		if HasPermission(role, permission) {
+			ctx.Next()
+			return
+		}
+		if access == TaskAccessUpdate && task.AssigneeID != nil && *task.AssigneeID == userID {
+			ctx.Next()
+			return
+		}
+
+		member, err := m.repos.GetProject().GetMember(ctx.Request.Context(), orgID, task.ProjectID, userID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			m.abortLookup(ctx, "Project member", userID, err)
+			return
+		}
+		if member != nil && member.IsLead() {
+			ctx.Next()
+			return
+		}
+
+		message := "You can only update tasks assigned to you"
+		if access == TaskAccessManage {
+			message = "Only project leads, PMs and admins can manage this task"
+		}
+		Forbidden(ctx, message, map[string]interface{}{
+			"taskId":        taskID.String(),
+			"requiredRoles": RolesFor(permission),
+		})
+	}
+}
+
+func (m *OrganizationMiddleware) abortLookup(ctx *gin.Context, resource string, id uuid.UUID, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		m.abortNotFound(ctx, resource)
+		return
+	}
+	log.Printf("[OrganizationMiddleware] Failed to load %s %s: %v", resource, id, err)
+	ctx.JSON(http.StatusInternalServerError, dto.NewErrorResponse(
+		"INTERNAL_ERROR",
+		"Failed to verify access",
+		nil,
+		GetRequestID(ctx),
+	))
+	ctx.Abort()
+}
+
+func (m *OrganizationMiddleware) abortNotFound(ctx *gin.Context, resource string) {
+	ctx.JSON(http.StatusNotFound, dto.NewErrorResponse(
+		"NOT_FOUND",
+		resource+" not found",
+		nil,
+		GetRequestID(ctx),
+	))
+	ctx.Abort()
+}
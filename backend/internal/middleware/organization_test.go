@@ -0,0 +1,345 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/middleware"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+type fakeOrgRepo struct {
+	repositories.OrganizationRepository
+	roles map[string]models.UserRole
+}
+
+func (r *fakeOrgRepo) GetMemberRole(_ context.Context, orgID, userID uuid.UUID) (models.UserRole, error) {
+	role, ok := r.roles[orgID.String()+"/"+userID.String()]
+	if !ok {
+		return "", gorm.ErrRecordNotFound
+	}
+	return role, nil
+}
+
+type fakeProjectRepo struct {
+	repositories.ProjectRepository
+	projects map[uuid.UUID]*models.Project
+	members  map[string]*models.ProjectMember
+}
+
+func (r *fakeProjectRepo) GetByID(_ context.Context, orgID, id uuid.UUID) (*models.Project, error) {
+	if p, ok := r.projects[id]; ok && p.OrganizationID == orgID {
+		return p, nil
+	}
+	return nil, fmt.Errorf("project not found: %w", gorm.ErrRecordNotFound)
+}
+
+func (r *fakeProjectRepo) GetMember(_ context.Context, orgID, projectID, userID uuid.UUID) (*models.ProjectMember, error) {
+	if p, ok := r.projects[projectID]; !ok || p.OrganizationID != orgID {
+		return nil, fmt.Errorf("project not found: %w", gorm.ErrRecordNotFound)
+	}
+	if m, ok := r.members[projectID.String()+"/"+userID.String()]; ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("project member not found: %w", gorm.ErrRecordNotFound)
+}
+
+type fakeTaskRepo struct {
+	repositories.TaskRepository
+	projects *fakeProjectRepo
+	tasks    map[uuid.UUID]*models.Task
+}
+
+func (r *fakeTaskRepo) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.Task, error) {
+	if t, ok := r.tasks[id]; ok {
+		if _, err := r.projects.GetByID(ctx, orgID, t.ProjectID); err != nil {
+			return nil, fmt.Errorf("task not found: %w", gorm.ErrRecordNotFound)
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("task not found: %w", gorm.ErrRecordNotFound)
+}
+
+// commitRepos fails the commit of every request transaction while commitErr is set
+type commitRepos struct {
+	*repositories.Provider
+	commitErr *error
+}
+
+func (r commitRepos) InOrganization(ctx context.Context, orgID uuid.UUID, fn func(ctx context.Context) error) error {
+	if err := r.Provider.InOrganization(ctx, orgID, fn); err != nil {
+		return err
+	}
+	return *r.commitErr
+}
+
+var _ = Describe("Access control", func() {
+	var (
+		orgID, otherOrgID           uuid.UUID
+		admin, pm, member, lead     uuid.UUID
+		outsider                    uuid.UUID
+		project, otherProject       *models.Project
+		ownTask, otherTask, foreign *models.Task
+		orgs                        *fakeOrgRepo
+		router                      *gin.Engine
+		commitErr                   error
+	)
+
+	BeforeEach(func() {
+		orgID, otherOrgID = uuid.New(), uuid.New()
+		admin, pm, member, lead, outsider = uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+		orgs = &fakeOrgRepo{roles: map[string]models.UserRole{
+			orgID.String() + "/" + admin.String():  models.RoleAdmin,
+			orgID.String() + "/" + pm.String():     models.RolePM,
+			orgID.String() + "/" + member.String(): models.RoleMember,
+			orgID.String() + "/" + lead.String():   models.RoleMember,
+		}}
+		project = &models.Project{BaseModel: models.BaseModel{ID: uuid.New()}, OrganizationID: orgID}
+		otherProject = &models.Project{BaseModel: models.BaseModel{ID: uuid.New()}, OrganizationID: otherOrgID}
+		projects := &fakeProjectRepo{
+			projects: map[uuid.UUID]*models.Project{project.ID: project, otherProject.ID: otherProject},
+			members: map[string]*models.ProjectMember{
+				project.ID.String() + "/" + lead.String():   {ProjectID: project.ID, UserID: lead, Role: "Tech Lead"},
+				project.ID.String() + "/" + member.String(): {ProjectID: project.ID, UserID: member, Role: "Frontend Dev"},
+			},
+		}
+		ownTask = &models.Task{BaseModel: models.BaseModel{ID: uuid.New()}, ProjectID: project.ID, AssigneeID: &member}
+		otherTask = &models.Task{BaseModel: models.BaseModel{ID: uuid.New()}, ProjectID: project.ID, AssigneeID: &pm}
+		foreign = &models.Task{BaseModel: models.BaseModel{ID: uuid.New()}, ProjectID: otherProject.ID}
+		tasks := &fakeTaskRepo{projects: projects, tasks: map[uuid.UUID]*models.Task{
+			ownTask.ID: ownTask, otherTask.ID: otherTask, foreign.ID: foreign,
+		}}
+
+		authMiddleware, err := middleware.NewAuthMiddleware(middleware.AuthConfig{DevMode: true})
+		Expect(err).NotTo(HaveOccurred())
+		commitErr = nil
+		orgMiddleware := middleware.NewOrganizationMiddleware(commitRepos{Provider: &repositories.Provider{
+			Organization: orgs,
+			Project:      projects,
+			Task:         tasks,
+		}, commitErr: &commitErr})
+
+		ok := func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{"role": ctx.GetString("role")})
+		}
+		router = gin.New()
+		api := router.Group("/api", authMiddleware.Authenticate(), orgMiddleware.RequireOrganization())
+		api.GET("/me", ok)
+		api.POST("/created", func(ctx *gin.Context) {
+			ctx.Header("Location", "/api/created/1")
+			ctx.JSON(http.StatusCreated, gin.H{"id": "1"})
+		})
+		api.GET("/scope", func(ctx *gin.Context) {
+			scoped, _ := repositories.OrganizationFromContext(ctx.Request.Context())
+			ctx.JSON(http.StatusOK, gin.H{"organizationId": scoped.String()})
+		})
+		api.GET("/admin", authMiddleware.RequireRole(string(models.RoleAdmin)), ok)
+		api.POST("/scenarios/apply", authMiddleware.RequirePermission(middleware.PermissionApplyScenarios), ok)
+		api.POST("/tasks/:taskId/progress", orgMiddleware.RequireTaskAccess("taskId", middleware.TaskAccessUpdate), ok)
+		api.POST("/tasks/:taskId/assign", orgMiddleware.RequireTaskAccess("taskId", middleware.TaskAccessManage), ok)
+		api.POST("/nudges/:nudgeId/actions", authMiddleware.RequireNudgeActionPermission(), func(ctx *gin.Context) {
+			var req map[string]interface{}
+			ctx.ShouldBindJSON(&req)
+			ctx.JSON(http.StatusOK, req)
+		})
+	})
+
+	request := func(method, path string, userID uuid.UUID, headers map[string]string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-User-ID", userID.String())
+		req.Header.Set("X-Organization-Id", orgID.String())
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		var body map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+
+	errorCode := func(body map[string]interface{}) string {
+		e, _ := body["error"].(map[string]interface{})
+		code, _ := e["code"].(string)
+		return code
+	}
+
+	Describe("RequireOrganization", func() {
+		It("should reject callers who are not members of the organization", func() {
+			code, body := request(http.MethodGet, "/api/me", outsider, nil)
+			Expect(code).To(Equal(http.StatusForbidden))
+			Expect(errorCode(body)).To(Equal("FORBIDDEN"))
+		})
+
+		It("should take the role from the membership rather than the caller", func() {
+			code, body := request(http.MethodGet, "/api/me", member, map[string]string{"X-User-Role": "admin"})
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(body["role"]).To(Equal("member"))
+		})
+
+		It("should scope the request context to the caller's organization", func() {
+			code, body := request(http.MethodGet, "/api/scope", member, nil)
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(body["organizationId"]).To(Equal(orgID.String()))
+		})
+
+		It("should hold the response back until the request transaction commits", func() {
+			code, body := request(http.MethodPost, "/api/created", member, nil)
+			Expect(code).To(Equal(http.StatusCreated))
+			Expect(body["id"]).To(Equal("1"))
+
+			commitErr = errors.New("could not serialize access")
+			req := httptest.NewRequest(http.MethodPost, "/api/created", nil)
+			req.Header.Set("X-User-ID", member.String())
+			req.Header.Set("X-Organization-Id", orgID.String())
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+			Expect(rec.Header().Get("Location")).To(BeEmpty())
+			Expect(rec.Body.String()).To(ContainSubstring("INTERNAL_ERROR"))
+			Expect(rec.Body.String()).NotTo(ContainSubstring(`"id"`))
+		})
+
+		It("should see a changed role on the next request", func() {
+			orgs.roles[orgID.String()+"/"+member.String()] = models.RolePM
+			_, body := request(http.MethodGet, "/api/me", member, nil)
+			Expect(body["role"]).To(Equal("pm"))
+		})
+	})
+
+	Describe("RequireRole and RequirePermission", func() {
+		It("should only let listed roles through", func() {
+			code, _ := request(http.MethodGet, "/api/admin", admin, nil)
+			Expect(code).To(Equal(http.StatusOK))
+
+			code, body := request(http.MethodGet, "/api/admin", pm, nil)
+			Expect(code).To(Equal(http.StatusForbidden))
+			Expect(errorCode(body)).To(Equal("FORBIDDEN"))
+		})
+
+		It("should only let PMs and admins apply scenarios", func() {
+			code, _ := request(http.MethodPost, "/api/scenarios/apply", pm, nil)
+			Expect(code).To(Equal(http.StatusOK))
+
+			code, body := request(http.MethodPost, "/api/scenarios/apply", member, nil)
+			Expect(code).To(Equal(http.StatusForbidden))
+			details := body["error"].(map[string]interface{})["details"].(map[string]interface{})
+			Expect(details["permission"]).To(Equal(string(middleware.PermissionApplyScenarios)))
+			Expect(details["requiredRoles"]).To(ConsistOf("admin", "pm"))
+		})
+
+		It("should hold nudge actions that change tasks to the permissions they need", func() {
+			act := func(userID uuid.UUID, body string) (int, map[string]interface{}) {
+				req := httptest.NewRequest(http.MethodPost, "/api/nudges/"+uuid.NewString()+"/actions", strings.NewReader(body))
+				req.Header.Set("X-User-ID", userID.String())
+				req.Header.Set("X-Organization-Id", orgID.String())
+				req.Header.Set("Content-Type", "application/json")
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+				var resp map[string]interface{}
+				json.Unmarshal(rec.Body.Bytes(), &resp)
+				return rec.Code, resp
+			}
+
+			code, body := act(member, `{"actionType":"reassign"}`)
+			Expect(code).To(Equal(http.StatusForbidden))
+			details := body["error"].(map[string]interface{})["details"].(map[string]interface{})
+			Expect(details["permission"]).To(Equal(string(middleware.PermissionManageTasks)))
+
+			code, body = act(member, `{"actionType":"add_dependency"}`)
+			Expect(code).To(Equal(http.StatusForbidden))
+			details = body["error"].(map[string]interface{})["details"].(map[string]interface{})
+			Expect(details["permission"]).To(Equal(string(middleware.PermissionManageDependencies)))
+
+			// The handler still reads the whole body
+			code, body = act(member, `{"actionType":"snooze","parameters":{"hours":4}}`)
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(body).To(HaveKey("parameters"))
+
+			code, _ = act(pm, `{"actionType":"reassign"}`)
+			Expect(code).To(Equal(http.StatusOK))
+		})
+
+		It("should read the nudge action the way the handler binds it", func() {
+			act := func(body string) int {
+				req := httptest.NewRequest(http.MethodPost, "/api/nudges/"+uuid.NewString()+"/actions", strings.NewReader(body))
+				req.Header.Set("X-User-ID", member.String())
+				req.Header.Set("X-Organization-Id", orgID.String())
+				req.Header.Set("Content-Type", "application/json")
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+				return rec.Code
+			}
+
+			Expect(act(`{"ActionType":"reassign","parameters":{}}`)).To(Equal(http.StatusForbidden))
+			Expect(act(`{"actiontype":"split_task"}`)).To(Equal(http.StatusForbidden))
+			Expect(act(`{"actionType":"snooze","ACTIONTYPE":"add_dependency"}`)).To(Equal(http.StatusForbidden))
+			Expect(act(`{"parameters":{}}`)).To(Equal(http.StatusBadRequest))
+			Expect(act(`not json`)).To(Equal(http.StatusBadRequest))
+			Expect(act(`{"actionType":"archive_task"}`)).To(Equal(http.StatusForbidden))
+		})
+
+		It("should list a permission for every nudge action", func() {
+			for _, actionType := range services.NudgeActionTypes() {
+				Expect(middleware.NudgeActionPermissions).To(HaveKey(actionType))
+			}
+		})
+
+		It("should leave unlisted permissions open to every member", func() {
+			Expect(middleware.HasPermission(models.RoleMember, middleware.Permission("reports:read"))).To(BeTrue())
+		})
+	})
+
+	Describe("RequireTaskAccess", func() {
+		It("should let members update their own tasks only", func() {
+			code, _ := request(http.MethodPost, "/api/tasks/"+ownTask.ID.String()+"/progress", member, nil)
+			Expect(code).To(Equal(http.StatusOK))
+
+			code, body := request(http.MethodPost, "/api/tasks/"+otherTask.ID.String()+"/progress", member, nil)
+			Expect(code).To(Equal(http.StatusForbidden))
+			Expect(errorCode(body)).To(Equal("FORBIDDEN"))
+		})
+
+		It("should not let assignees manage their own tasks", func() {
+			code, _ := request(http.MethodPost, "/api/tasks/"+ownTask.ID.String()+"/assign", member, nil)
+			Expect(code).To(Equal(http.StatusForbidden))
+		})
+
+		It("should let project leads update and manage tasks in their project", func() {
+			code, _ := request(http.MethodPost, "/api/tasks/"+otherTask.ID.String()+"/progress", lead, nil)
+			Expect(code).To(Equal(http.StatusOK))
+			code, _ = request(http.MethodPost, "/api/tasks/"+otherTask.ID.String()+"/assign", lead, nil)
+			Expect(code).To(Equal(http.StatusOK))
+		})
+
+		It("should let PMs act on any task", func() {
+			code, _ := request(http.MethodPost, "/api/tasks/"+ownTask.ID.String()+"/assign", pm, nil)
+			Expect(code).To(Equal(http.StatusOK))
+		})
+
+		It("should hide tasks from other organizations even from admins", func() {
+			code, body := request(http.MethodPost, "/api/tasks/"+foreign.ID.String()+"/progress", admin, nil)
+			Expect(code).To(Equal(http.StatusNotFound))
+			Expect(errorCode(body)).To(Equal("NOT_FOUND"))
+		})
+
+		It("should reject malformed task IDs", func() {
+			code, _ := request(http.MethodPost, "/api/tasks/not-a-uuid/progress", admin, nil)
+			Expect(code).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// Permission is an action guarded by the organization role of the caller
+type Permission string
+
+const (
+	// PermissionConfigureOrganization changes organization-wide settings such as priority scoring
+	PermissionConfigureOrganization Permission = "organization:configure"
+	// PermissionManageMembers invites people, changes roles, removes members and reads the audit trail
+	PermissionManageMembers Permission = "members:manage"
+	// PermissionManageProjects creates, updates and deletes projects
+	PermissionManageProjects Permission = "projects:manage"
+	// PermissionManageTasks creates, deletes and assigns any task in the organization
+	PermissionManageTasks Permission = "tasks:manage"
+	// PermissionUpdateAnyTask updates status and progress of tasks assigned to others
+	PermissionUpdateAnyTask Permission = "tasks:update-any"
+	// PermissionManageDependencies creates and deletes task dependencies
+	PermissionManageDependencies Permission = "dependencies:manage"
+	// PermissionApplyScenarios applies or rejects what-if scenarios
+	PermissionApplyScenarios Permission = "scenarios:apply"
+	// PermissionBulkReassign moves work between people in bulk
+	PermissionBulkReassign Permission = "assignments:bulk-reassign"
+	// PermissionRunAnalysis triggers priority recalculation and nudge generation
+	PermissionRunAnalysis Permission = "analysis:run"
+	// PermissionRespondToNudges snoozes, escalates and answers nudges; every member has it
+	PermissionRespondToNudges Permission = "nudges:respond"
+)
+
+// permissionRoles is the permission matrix. Anything not listed is open to every
+// member of the organization.
+var permissionRoles = map[Permission][]models.UserRole{
+	PermissionConfigureOrganization: {models.RoleAdmin},
+	PermissionManageMembers:         {models.RoleAdmin},
+	PermissionManageProjects:        {models.RoleAdmin, models.RolePM},
+	PermissionManageTasks:           {models.RoleAdmin, models.RolePM},
+	PermissionUpdateAnyTask:         {models.RoleAdmin, models.RolePM},
+	PermissionManageDependencies:    {models.RoleAdmin, models.RolePM},
+	PermissionApplyScenarios:        {models.RoleAdmin, models.RolePM},
+	PermissionBulkReassign:          {models.RoleAdmin, models.RolePM},
+	PermissionRunAnalysis:           {models.RoleAdmin, models.RolePM},
+}
+
+// HasPermission reports whether an organization role grants a permission
+func HasPermission(role models.UserRole, permission Permission) bool {
+	roles, ok := permissionRoles[permission]
+	if !ok {
+		return true
+	}
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RolesFor returns the roles that grant a permission
+func RolesFor(permission Permission) []models.UserRole {
+	return permissionRoles[permission]
+}
+
+// RequirePermission rejects callers whose organization role does not grant the permission
+func (m *AuthMiddleware) RequirePermission(permission Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !HasPermission(models.UserRole(GetRole(ctx)), permission) {
+			Forbidden(ctx, fmt.Sprintf("Your role does not allow %s", permission), map[string]interface{}{
+				"permission":    permission,
+				"requiredRoles": RolesFor(permission),
+			})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// NudgeActionPermissions lists the permission every nudge action needs. Actions that
+// change tasks need the permission the same change needs when made directly; the others
+// are open to every member. Actions missing here are refused.
+var NudgeActionPermissions = map[string]Permission{
+	"reassign":          PermissionManageTasks,
+	"extend_due_date":   PermissionManageTasks,
+	"split_task":        PermissionManageTasks,
+	"add_dependency":    PermissionManageDependencies,
+	"snooze":            PermissionRespondToNudges,
+	"escalate":          PermissionRespondToNudges,
+	"dismiss":           PermissionRespondToNudges,
+	"accept_suggestion": PermissionRespondToNudges,
+	"custom_action":     PermissionRespondToNudges,
+	"ask_alternatives":  PermissionRespondToNudges,
+}
+
+// maxActionBody is how much of a request body RequireNudgeActionPermission reads
+const maxActionBody = 1 << 20
+
+// RequireNudgeActionPermission rejects callers whose role does not grant the permission
+// the nudge action in the body needs. The body is decoded as the handler binds it, so
+// the action checked is the action run, and is left for the handler to read again.
+// Bodies whose action cannot be read, and actions with no listed permission, are rejected.
+func (m *AuthMiddleware) RequireNudgeActionPermission() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxActionBody))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "Failed to read request body", nil, GetRequestID(ctx)))
+			ctx.Abort()
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var req dto.NudgeActionRequest
+		if err := json.Unmarshal(body, &req); err != nil || req.ActionType == "" {
+			ctx.JSON(http.StatusBadRequest, dto.NewErrorResponse("VALIDATION_ERROR", "actionType is required", nil, GetRequestID(ctx)))
+			ctx.Abort()
+			return
+		}
+		permission, ok := NudgeActionPermissions[req.ActionType]
+		if !ok {
+			Forbidden(ctx, fmt.Sprintf("No permission allows the nudge action %s", req.ActionType), map[string]interface{}{
+				"action": req.ActionType,
+			})
+			return
+		}
+		if !HasPermission(models.UserRole(GetRole(ctx)), permission) {
+			Forbidden(ctx, fmt.Sprintf("Your role does not allow %s", permission), map[string]interface{}{
+				"action":        req.ActionType,
+				"permission":    permission,
+				"requiredRoles": RolesFor(permission),
+			})
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// Forbidden answers 403 in the shape every denied request uses and aborts the chain
+func Forbidden(ctx *gin.Context, message string, details map[string]interface{}) {
+	ctx.JSON(http.StatusForbidden, dto.NewErrorResponse("FORBIDDEN", message, details, GetRequestID(ctx)))
+	ctx.Abort()
+}
@@ -0,0 +1,15 @@
+package middleware_test
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Middleware Test Suite")
+}
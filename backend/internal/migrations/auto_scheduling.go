@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// autoScheduling adds the per-project auto-scheduling switch and the flag that pins a
+// task's dates against it
+var autoScheduling = Migration{
+	Version: 9,
+	Name:    "auto_scheduling",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.Project{}, &models.Task{})
+	},
+}
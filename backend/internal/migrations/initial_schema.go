@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// initialSchema creates the tables the server previously auto-migrated on every start.
+// It is idempotent, so databases created that way adopt versioned migrations cleanly.
+var initialSchema = Migration{
+	Version: 1,
+	Name:    "initial_schema",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(
+			&models.User{},
+			&models.Organization{},
+			&models.OrganizationMember{},
+			&models.Skill{},
+			&models.UserSkill{},
+			&models.TaskSkill{},
+			&models.Project{},
+			&models.ProjectMember{},
+			&models.Task{},
+			&models.TaskDependency{},
+			&models.Nudge{},
+			&models.NudgeAction{},
+			&models.AssignmentSuggestion{},
+			&models.WorkloadEntry{},
+			&models.Scenario{},
+			&models.ScenarioImpactAnalysis{},
+			&models.Job{},
+			&models.NotificationPreference{},
+			&models.NotificationDelivery{},
+			&models.RefreshToken{},
+			&models.PasswordResetToken{},
+		)
+	},
+}
@@ -0,0 +1,108 @@
+// Package migrations holds the versioned database schema changes. Each migration runs
+// once, in its own transaction, and is recorded in the schema_migrations table.
+package migrations
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+}
+
+// appliedMigration records a migration that has run
+type appliedMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"not null"`
+	AppliedAt time.Time
+}
+
+func (appliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// lockKey serializes migration runs across server instances starting together
+const lockKey = 7_214_009_371
+
+// All returns every migration in version order
+func All() []Migration {
+	return []Migration{
+		initialSchema,
+		rowLevelSecurity,
+		organizationMembership,
+		projectTemplates,
+		taskArchiving,
+		taskProgress,
+		taskWorkflows,
+		taskEvents,
+		autoScheduling,
+	}
+}
+
+// Run applies the pending migrations in version order. It must connect as the owner of
+// the schema, since migrations create and alter tables.
+func Run(db *gorm.DB, migrations []Migration) error {
+	if err := Validate(migrations); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&appliedMigration{}); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	for _, m := range sorted {
+		applied := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+				return err
+			}
+			var count int64
+			if err := tx.Model(&appliedMigration{}).Where("version = ?", m.Version).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return nil
+			}
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			applied = true
+			return tx.Create(&appliedMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		if applied {
+			log.Printf("[Migrations] Applied %d (%s)", m.Version, m.Name)
+		}
+	}
+	return nil
+}
+
+// Validate checks that every migration has a positive, unique version and an Up step
+func Validate(migrations []Migration) error {
+	seen := make(map[int]string, len(migrations))
+	for _, m := range migrations {
+		if m.Version <= 0 {
+			return fmt.Errorf("migration %q has invalid version %d", m.Name, m.Version)
+		}
+		if m.Up == nil {
+			return fmt.Errorf("migration %d (%s) has no Up step", m.Version, m.Name)
+		}
+		if other, ok := seen[m.Version]; ok {
+			return fmt.Errorf("migrations %q and %q share version %d", other, m.Name, m.Version)
+		}
+		seen[m.Version] = m.Name
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package migrations_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/migrations"
+)
+
+var _ = Describe("Migrations", func() {
+	noop := func(tx *gorm.DB) error { return nil }
+
+	Context("Given the registered migrations", func() {
+		It("should be valid and listed in ascending version order", func() {
+			all := migrations.All()
+			Expect(migrations.Validate(all)).To(Succeed())
+			for i := 1; i < len(all); i++ {
+				Expect(all[i].Version).To(BeNumerically(">", all[i-1].Version))
+			}
+		})
+	})
+
+	Context("Given migrations that share a version", func() {
+		It("should reject them", func() {
+			err := migrations.Validate([]migrations.Migration{
+				{Version: 1, Name: "first", Up: noop},
+				{Version: 1, Name: "second", Up: noop},
+			})
+			Expect(err).To(MatchError(ContainSubstring("share version 1")))
+		})
+	})
+
+	Context("Given a migration without an Up step or version", func() {
+		It("should reject it", func() {
+			Expect(migrations.Validate([]migrations.Migration{{Version: 1, Name: "empty"}})).NotTo(Succeed())
+			Expect(migrations.Validate([]migrations.Migration{{Name: "unversioned", Up: noop}})).NotTo(Succeed())
+		})
+	})
+})
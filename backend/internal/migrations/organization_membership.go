@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// organizationMembership adds organization owners, invitations and the audit trail.
+// Invitations and audit events belong to one organization and get tenant policies.
+var organizationMembership = Migration{
+	Version: 3,
+	Name:    "organization_membership",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.AutoMigrate(&models.Organization{}, &models.Invitation{}, &models.AuditEvent{}); err != nil {
+			return err
+		}
+		// Organizations that predate owners are owned by their earliest admin
+		if err := tx.Exec(`UPDATE organizations o SET owner_id = (
+			SELECT m.user_id FROM organization_members m
+			WHERE m.organization_id = o.id AND m.role = ? AND m.deleted_at IS NULL
+			ORDER BY m.joined_at ASC LIMIT 1
+		) WHERE o.owner_id IS NULL`, models.RoleAdmin).Error; err != nil {
+			return err
+		}
+		if err := tenantPolicy(tx, "invitations", "organization_id = "+currentOrganization, ""); err != nil {
+			return err
+		}
+		return tenantPolicy(tx, "audit_events", "organization_id = "+currentOrganization, "")
+	},
+}
@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// organizationTemplates selects the templates of the current organization
+var organizationTemplates = "SELECT id FROM project_templates WHERE organization_id = " + currentOrganization
+
+// projectTemplates adds the project template library. Templates belong to one
+// organization and their tasks, skills and dependencies are scoped through them.
+var projectTemplates = Migration{
+	Version: 4,
+	Name:    "project_templates",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.AutoMigrate(
+			&models.ProjectTemplate{},
+			&models.TemplateTask{},
+			&models.TemplateTaskSkill{},
+			&models.TemplateDependency{},
+		); err != nil {
+			return err
+		}
+		policies := []struct{ table, using string }{
+			{"project_templates", "organization_id = " + currentOrganization},
+			{"template_tasks", "template_id IN (" + organizationTemplates + ")"},
+			{"template_task_skills", "template_task_id IN (SELECT id FROM template_tasks WHERE template_id IN (" + organizationTemplates + "))"},
+			{"template_dependencies", "template_id IN (" + organizationTemplates + ")"},
+		}
+		for _, p := range policies {
+			if err := tenantPolicy(tx, p.table, p.using, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,113 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+// currentOrganization reads the organization the request transaction is scoped to. It is
+// NULL outside such a transaction, which matches no rows.
+const currentOrganization = "xephyr_current_organization()"
+
+// Subqueries for the rows owned through a parent record
+var (
+	organizationProjects  = "SELECT id FROM projects WHERE organization_id = " + currentOrganization
+	organizationTasks     = "SELECT t.id FROM tasks t JOIN projects p ON p.id = t.project_id WHERE p.organization_id = " + currentOrganization
+	organizationNudges    = "SELECT id FROM nudges WHERE organization_id = " + currentOrganization
+	organizationScenarios = "SELECT id FROM scenarios WHERE organization_id = " + currentOrganization
+)
+
+// tenantPolicies lists each tenant table with the condition its rows must meet to be
+// visible, and to be written, within the current organization. An empty check reuses
+// the visibility condition.
+var tenantPolicies = []struct {
+	table string
+	using string
+	check string
+}{
+	{table: "projects", using: "organization_id = " + currentOrganization},
+	{table: "project_members", using: "project_id IN (" + organizationProjects + ")"},
+	{table: "tasks", using: "project_id IN (" + organizationProjects + ")"},
+	{table: "task_dependencies", using: "task_id IN (" + organizationTasks + ") AND depends_on_task_id IN (" + organizationTasks + ")"},
+	{table: "task_skills", using: "task_id IN (" + organizationTasks + ")"},
+	{table: "assignment_suggestions", using: "task_id IN (" + organizationTasks + ")"},
+	{table: "nudges", using: "organization_id = " + currentOrganization},
+	{table: "nudge_actions", using: "nudge_id IN (" + organizationNudges + ")"},
+	{table: "workload_entries", using: "organization_id = " + currentOrganization},
+	{table: "scenarios", using: "organization_id = " + currentOrganization},
+	{table: "scenario_impact_analyses", using: "scenario_id IN (" + organizationScenarios + ")"},
+	{table: "jobs", using: "organization_id = " + currentOrganization},
+	{table: "notification_deliveries", using: "organization_id = " + currentOrganization},
+	// Global skills (no organization) stay readable by everyone but only an
+	// organization's own skills can be written
+	{
+		table: "skills",
+		using: "organization_id IS NULL OR organization_id = " + currentOrganization,
+		check: "organization_id = " + currentOrganization,
+	},
+}
+
+// rowLevelSecurity makes Postgres enforce tenant isolation underneath the repository
+// filters. It creates the role request handlers assume (repositories.TenantRole) and a
+// BYPASSRLS role for background workers (repositories.BypassRole), grants both to the
+// migrating user so its connections can assume them, and adds a policy to every tenant
+// table. Policies are not forced on the table owner, so migrations running as the owner
+// still see every row. Creating a BYPASSRLS role requires a superuser.
+var rowLevelSecurity = Migration{
+	Version: 2,
+	Name:    "row_level_security",
+	Up: func(tx *gorm.DB) error {
+		statements := []string{
+			fmt.Sprintf(`DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = '%[1]s') THEN
+		CREATE ROLE %[1]s NOLOGIN;
+	END IF;
+	IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = '%[2]s') THEN
+		CREATE ROLE %[2]s NOLOGIN BYPASSRLS;
+	END IF;
+END
+$$`, repositories.TenantRole, repositories.BypassRole),
+			fmt.Sprintf("GRANT %s, %s TO CURRENT_USER", repositories.TenantRole, repositories.BypassRole),
+			fmt.Sprintf("GRANT USAGE ON SCHEMA public TO %s, %s", repositories.TenantRole, repositories.BypassRole),
+			fmt.Sprintf("GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO %s, %s", repositories.TenantRole, repositories.BypassRole),
+			fmt.Sprintf("ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO %s, %s", repositories.TenantRole, repositories.BypassRole),
+			fmt.Sprintf(`CREATE OR REPLACE FUNCTION %s RETURNS uuid
+	LANGUAGE sql STABLE
+	AS $$ SELECT NULLIF(current_setting('%s', true), '')::uuid $$`, currentOrganization, repositories.OrganizationSetting),
+		}
+
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		for _, p := range tenantPolicies {
+			if err := tenantPolicy(tx, p.table, p.using, p.check); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// tenantPolicy enables row-level security on table with a policy that shows rows meeting
+// using and accepts writes meeting check, or using when check is empty
+func tenantPolicy(tx *gorm.DB, table, using, check string) error {
+	if check == "" {
+		check = using
+	}
+	for _, stmt := range []string{
+		fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY", table),
+		fmt.Sprintf("DROP POLICY IF EXISTS tenant_isolation ON %s", table),
+		fmt.Sprintf("CREATE POLICY tenant_isolation ON %s USING (%s) WITH CHECK (%s)", table, using, check),
+	} {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// taskArchiving adds archived_at to tasks so a subtree can be archived on its own. The
+// tasks table already has its tenant policy.
+var taskArchiving = Migration{
+	Version: 5,
+	Name:    "task_archiving",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.Task{})
+	},
+}
@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// taskEvents adds the log of status, assignee, estimate and due date changes to tasks
+var taskEvents = Migration{
+	Version: 8,
+	Name:    "task_events",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.AutoMigrate(&models.TaskEvent{}); err != nil {
+			return err
+		}
+		return tenantPolicy(tx, "task_events", "organization_id = "+currentOrganization, "")
+	},
+}
@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// taskProgress adds the progress percentage assignees report on their tasks, which the
+// task repository already writes
+var taskProgress = Migration{
+	Version: 6,
+	Name:    "task_progress",
+	Up: func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&models.Task{})
+	},
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// taskWorkflows adds per-project task workflows and the workflow status of each task.
+// Statuses and transitions are scoped through their workflow.
+var taskWorkflows = Migration{
+	Version: 7,
+	Name:    "task_workflows",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.AutoMigrate(
+			&models.Task{},
+			&models.Workflow{},
+			&models.WorkflowStatus{},
+			&models.WorkflowTransition{},
+		); err != nil {
+			return err
+		}
+		organizationWorkflows := "SELECT id FROM workflows WHERE organization_id = " + currentOrganization
+		policies := []struct{ table, using string }{
+			{"workflows", "organization_id = " + currentOrganization},
+			{"workflow_statuses", "workflow_id IN (" + organizationWorkflows + ")"},
+			{"workflow_transitions", "workflow_id IN (" + organizationWorkflows + ")"},
+		}
+		for _, p := range policies {
+			if err := tenantPolicy(tx, p.table, p.using, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -42,6 +45,10 @@ type User struct {
 	HourlyRate  float64   `json:"hourlyRate"`
 	Timezone    string    `json:"timezone"`
 	IsActive    bool      `json:"isActive" gorm:"default:true"`
+
+	// Login lockout
+	FailedLoginAttempts int        `json:"-" gorm:"default:0"`
+	LockedUntil         *time.Time `json:"-"`
 	
 	// Relationships
 	OrganizationMemberships []OrganizationMember `json:"organizationMemberships,omitempty" gorm:"foreignKey:UserID"`
@@ -54,6 +61,7 @@ type Organization struct {
 	Slug    string `json:"slug" gorm:"uniqueIndex"`
 	Plan    string `json:"plan" gorm:"default:'free'"`
 	Settings JSONB `json:"settings,omitempty" gorm:"type:jsonb"`
+	OwnerID *uuid.UUID `json:"ownerId,omitempty"` // the admin who can transfer ownership; nil for organizations created before owners
 	
 	// Relationships
 	Members  []OrganizationMember `json:"members,omitempty" gorm:"foreignKey:OrganizationID"`
@@ -129,6 +137,7 @@ type Project struct {
 	StartDate      *time.Time    `json:"startDate"`
 	TargetEndDate  *time.Time    `json:"targetEndDate"`
 	Budget         float64       `json:"budget"`
+	AutoSchedule   bool          `json:"autoSchedule" gorm:"default:false"` // dependents move when their predecessors' dates do
 	
 	// Relationships
 	Organization Organization `json:"-" gorm:"foreignKey:OrganizationID"`
@@ -146,6 +155,12 @@ type ProjectMember struct {
 	User    User    `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// IsLead reports whether the member leads the project. Project roles are free-text
+// titles such as "Tech Lead" or "Frontend Dev"; any title naming a lead counts.
+func (m *ProjectMember) IsLead() bool {
+	return strings.Contains(strings.ToLower(m.Role), "lead")
+}
+
 // ===== Task Models =====
 
 type TaskStatus string
@@ -167,6 +182,9 @@ const (
 	TaskPriorityCritical TaskPriority = "critical"
 )
 
+// MaxHierarchyLevel is the deepest a task can be nested: 1=task, 2=subtask, 3=grandchild
+const MaxHierarchyLevel = 3
+
 type Task struct {
 	BaseModel
 	ProjectID       uuid.UUID    `json:"projectId" gorm:"not null"`
@@ -175,18 +193,22 @@ type Task struct {
 	Title           string       `json:"title"`
 	Description     string       `json:"description"`
 	Status          TaskStatus   `json:"status" gorm:"default:'backlog'"`
+	WorkflowStatus  string       `json:"workflowStatus,omitempty"` // key of the project workflow status; Status is its category
 	Priority        TaskPriority `json:"priority" gorm:"default:'medium'"`
 	PriorityScore   int          `json:"priorityScore" gorm:"default:0"` // 0-100 AI-calculated
 	BusinessValue   int          `json:"businessValue" gorm:"default:50"` // 0-100
 	EstimatedHours  float64      `json:"estimatedHours"`
 	ActualHours     float64      `json:"actualHours"`
+	ProgressPercentage int       `json:"progressPercentage" gorm:"default:0"` // 0-100 as reported by the assignee
 	StartDate       *time.Time   `json:"startDate"`
 	DueDate         *time.Time   `json:"dueDate"`
 	CompletedAt     *time.Time   `json:"completedAt"`
 	AssigneeID      *uuid.UUID   `json:"assigneeId,omitempty"`
 	IsMilestone     bool         `json:"isMilestone" gorm:"default:false"`
 	IsCriticalPath  bool         `json:"isCriticalPath" gorm:"default:false"`
+	DatesPinned     bool         `json:"datesPinned" gorm:"default:false"` // set by hand; auto-scheduling never moves them
 	RiskScore       int          `json:"riskScore" gorm:"default:0"`
+	ArchivedAt      *time.Time   `json:"archivedAt,omitempty"` // archived tasks are read-only
 	
 	// Relationships
 	Project      Project       `json:"-" gorm:"foreignKey:ProjectID"`
@@ -198,6 +220,88 @@ type Task struct {
 	BlockedBy    []TaskDependency `json:"blockedBy,omitempty" gorm:"foreignKey:DependsOnTaskID"`
 }
 
+// TaskEventType is the kind of change a task event records
+type TaskEventType string
+
+const (
+	TaskEventStatusChanged   TaskEventType = "status_changed"
+	TaskEventAssigneeChanged TaskEventType = "assignee_changed"
+	TaskEventEstimateChanged TaskEventType = "estimate_changed"
+	TaskEventDueDateChanged  TaskEventType = "due_date_changed"
+)
+
+// TaskEvent records one change to a task's status, assignee, estimate or due date.
+// Values are stored as text: the workflow status key, the assignee's ID, the estimate in
+// hours or the RFC 3339 due date, empty when unset. Events are append-only.
+type TaskEvent struct {
+	BaseModel
+	OrganizationID uuid.UUID     `json:"organizationId" gorm:"not null;index"`
+	ProjectID      uuid.UUID     `json:"projectId" gorm:"not null;index"`
+	TaskID         uuid.UUID     `json:"taskId" gorm:"not null;index"`
+	ActorID        *uuid.UUID    `json:"actorId,omitempty"`
+	Type           TaskEventType `json:"type" gorm:"not null;index"`
+	FromValue      string        `json:"fromValue"`
+	ToValue        string        `json:"toValue"`
+	OccurredAt     time.Time     `json:"occurredAt" gorm:"not null;index"`
+}
+
+// ===== Workflow Models =====
+
+// WorkflowGuard is a condition a task must meet before a transition is allowed
+type WorkflowGuard string
+
+const (
+	// WorkflowGuardAssignee requires the task to be assigned
+	WorkflowGuardAssignee WorkflowGuard = "assignee_required"
+	// WorkflowGuardSubtasksDone requires every subtask to be done
+	WorkflowGuardSubtasksDone WorkflowGuard = "subtasks_done"
+	// WorkflowGuardPredecessorsDone requires every finish_to_start predecessor to be done
+	WorkflowGuardPredecessorsDone WorkflowGuard = "predecessors_done"
+)
+
+// Workflow is a project's task workflow. Its statuses are mapped onto the canonical
+// TaskStatus categories, which is what health and progress read. Projects without a
+// workflow use the default one over the canonical statuses.
+type Workflow struct {
+	BaseModel
+	OrganizationID uuid.UUID `json:"organizationId" gorm:"not null;index"`
+	ProjectID      uuid.UUID `json:"projectId" gorm:"not null;index"`
+
+	Statuses    []WorkflowStatus     `json:"statuses" gorm:"foreignKey:WorkflowID"`
+	Transitions []WorkflowTransition `json:"transitions" gorm:"foreignKey:WorkflowID"`
+}
+
+// WorkflowStatus is a custom task status. Tasks and transitions refer to it by key.
+type WorkflowStatus struct {
+	BaseModel
+	WorkflowID uuid.UUID  `json:"workflowId" gorm:"not null;index"`
+	Key        string     `json:"key" gorm:"not null"`
+	Name       string     `json:"name"`
+	Category   TaskStatus `json:"category" gorm:"not null"`
+	Position   int        `json:"position"`
+}
+
+// WorkflowTransition allows tasks to move from one status to another once its guards
+// pass. Guards are stored comma-separated.
+type WorkflowTransition struct {
+	BaseModel
+	WorkflowID uuid.UUID `json:"workflowId" gorm:"not null;index"`
+	FromStatus string    `json:"fromStatus" gorm:"not null"`
+	ToStatus   string    `json:"toStatus" gorm:"not null"`
+	Guards     string    `json:"guards"`
+}
+
+// GuardList splits the transition's guards
+func (t *WorkflowTransition) GuardList() []WorkflowGuard {
+	var guards []WorkflowGuard
+	for _, g := range strings.Split(t.Guards, ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			guards = append(guards, WorkflowGuard(g))
+		}
+	}
+	return guards
+}
+
 // ===== Dependency Models =====
 
 type DependencyType string
@@ -220,6 +324,64 @@ type TaskDependency struct {
 	DependsOnTask Task `json:"-" gorm:"foreignKey:DependsOnTaskID"`
 }
 
+// ===== Template Models =====
+
+// ProjectTemplate is a reusable project outline. Task dates are stored as day offsets
+// from the project start so a template can be instantiated on any date.
+type ProjectTemplate struct {
+	BaseModel
+	OrganizationID  uuid.UUID  `json:"organizationId" gorm:"not null;index"`
+	Name            string     `json:"name" gorm:"not null"`
+	Description     string     `json:"description"`
+	DurationDays    int        `json:"durationDays"` // target end, in days after the start
+	SourceProjectID *uuid.UUID `json:"sourceProjectId,omitempty"`
+	CreatedByID     uuid.UUID  `json:"createdById"`
+
+	Tasks        []TemplateTask       `json:"tasks,omitempty" gorm:"foreignKey:TemplateID"`
+	Dependencies []TemplateDependency `json:"dependencies,omitempty" gorm:"foreignKey:TemplateID"`
+}
+
+// TemplateTask is a task in a template. Offsets are days from the project start; a nil
+// offset leaves the date unset.
+type TemplateTask struct {
+	BaseModel
+	TemplateID      uuid.UUID    `json:"templateId" gorm:"not null;index"`
+	ParentID        *uuid.UUID   `json:"parentId,omitempty"` // another task in the same template
+	Position        int          `json:"position"`           // parents come before their subtasks
+	HierarchyLevel  int          `json:"hierarchyLevel" gorm:"default:1"`
+	Title           string       `json:"title"`
+	Description     string       `json:"description"`
+	Priority        TaskPriority `json:"priority" gorm:"default:'medium'"`
+	BusinessValue   int          `json:"businessValue" gorm:"default:50"`
+	EstimatedHours  float64      `json:"estimatedHours"`
+	StartOffsetDays *int         `json:"startOffsetDays,omitempty"`
+	DueOffsetDays   *int         `json:"dueOffsetDays,omitempty"`
+	IsMilestone     bool         `json:"isMilestone" gorm:"default:false"`
+
+	Skills []TemplateTaskSkill `json:"skills,omitempty" gorm:"foreignKey:TemplateTaskID"`
+}
+
+// TemplateTaskSkill is a skill requirement of a template task
+type TemplateTaskSkill struct {
+	BaseModel
+	TemplateTaskID      uuid.UUID `json:"templateTaskId" gorm:"not null;index"`
+	SkillID             uuid.UUID `json:"skillId" gorm:"not null"`
+	ProficiencyRequired int       `json:"proficiencyRequired" gorm:"default:3"`
+	IsRequired          bool      `json:"isRequired" gorm:"default:true"`
+
+	Skill Skill `json:"skill,omitempty" gorm:"foreignKey:SkillID"`
+}
+
+// TemplateDependency links two tasks of a template
+type TemplateDependency struct {
+	BaseModel
+	TemplateID      uuid.UUID      `json:"templateId" gorm:"not null;index"`
+	TaskID          uuid.UUID      `json:"taskId" gorm:"not null"`
+	DependsOnTaskID uuid.UUID      `json:"dependsOnTaskId" gorm:"not null"`
+	DependencyType  DependencyType `json:"dependencyType" gorm:"default:'finish_to_start'"`
+	LagHours        int            `json:"lagHours" gorm:"default:0"`
+}
+
 // ===== Nudge Models =====
 
 type NudgeType string
@@ -249,6 +411,9 @@ const (
 	NudgeStatusRead      NudgeStatus = "read"
 	NudgeStatusDismissed NudgeStatus = "dismissed"
 	NudgeStatusActed     NudgeStatus = "acted"
+	NudgeStatusResolved  NudgeStatus = "resolved" // condition cleared on regeneration
+	NudgeStatusSnoozed   NudgeStatus = "snoozed"  // hidden until SnoozedUntil
+	NudgeStatusExpired   NudgeStatus = "expired"  // passed ExpiresAt without being handled
 )
 
 type Nudge struct {
@@ -265,6 +430,13 @@ type Nudge struct {
 	CriticalityScore  int           `json:"criticalityScore"`
 	ExpiresAt         *time.Time    `json:"expiresAt"`
 	
+	// Fingerprint identifies the condition (type + related entities) so regeneration refreshes instead of duplicating
+	Fingerprint       string        `json:"fingerprint" gorm:"index"`
+	LastDetectedAt    *time.Time    `json:"lastDetectedAt,omitempty"`
+	ResolvedAt        *time.Time    `json:"resolvedAt,omitempty"`
+	SnoozedUntil      *time.Time    `json:"snoozedUntil,omitempty"`
+	EscalatedAt       *time.Time    `json:"escalatedAt,omitempty"`
+	
 	// Related entities
 	RelatedProjectID *uuid.UUID `json:"relatedProjectId,omitempty"`
 	RelatedTaskID    *uuid.UUID `json:"relatedTaskId,omitempty"`
@@ -284,12 +456,12 @@ type Nudge struct {
 type NudgeAction struct {
 	BaseModel
 	NudgeID    uuid.UUID `json:"nudgeId" gorm:"not null"`
-	UserID     uuid.UUID `json:"userId" gorm:"not null"`
-	ActionType string    `json:"actionType"` // accept_suggestion, dismiss, custom_action, etc.
+	UserID     *uuid.UUID `json:"userId,omitempty"` // nil for system actions such as auto_resolved
+	ActionType string    `json:"actionType"` // accept_suggestion, dismiss, custom_action, auto_resolved, etc.
 	Parameters JSONB    `json:"parameters,omitempty" gorm:"type:jsonb"`
 	
 	Nudge Nudge `json:"-" gorm:"foreignKey:NudgeID"`
-	User  User  `json:"-" gorm:"foreignKey:UserID"`
+	User  *User `json:"-" gorm:"foreignKey:UserID"`
 }
 
 // ===== Assignment Models =====
@@ -379,6 +551,156 @@ type ScenarioImpactAnalysis struct {
 	Scenario Scenario `json:"-" gorm:"foreignKey:ScenarioID"`
 }
 
+// ===== Job Models =====
+
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+type Job struct {
+	BaseModel
+	OrganizationID uuid.UUID  `json:"organizationId" gorm:"not null;index"`
+	Type           string     `json:"type" gorm:"not null;index"`
+	Status         JobStatus  `json:"status" gorm:"default:'queued';index"`
+	Payload        JSONB      `json:"payload,omitempty" gorm:"type:jsonb"`
+	Result         JSONB      `json:"result,omitempty" gorm:"type:jsonb"`
+	Progress       int        `json:"progress" gorm:"default:0"` // 0-100
+	Attempts       int        `json:"attempts" gorm:"default:0"`
+	MaxAttempts    int        `json:"maxAttempts" gorm:"default:5"`
+	LastError      string     `json:"lastError,omitempty"`
+	RunAt          time.Time  `json:"runAt" gorm:"index"` // earliest time the job may be picked up
+	StartedAt      *time.Time `json:"startedAt,omitempty"`
+	CompletedAt    *time.Time `json:"completedAt,omitempty"`
+}
+
+// ===== Auth Models =====
+
+// RefreshToken is a server-side record of an issued refresh token. Only a hash of the
+// token is stored. Each refresh rotates the token within its family; presenting a
+// revoked token again revokes the whole family.
+type RefreshToken struct {
+	BaseModel
+	UserID       uuid.UUID  `json:"userId" gorm:"not null;index"`
+	FamilyID     uuid.UUID  `json:"familyId" gorm:"not null;index"`
+	TokenHash    string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt    time.Time  `json:"expiresAt"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+	ReplacedByID *uuid.UUID `json:"replacedById,omitempty"`
+	UserAgent    string     `json:"userAgent"`
+
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// PasswordResetToken is a single-use password reset token; only its hash is stored
+type PasswordResetToken struct {
+	BaseModel
+	UserID    uuid.UUID  `json:"userId" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// ===== Membership Models =====
+
+type InvitationStatus string
+
+const (
+	InvitationStatusPending  InvitationStatus = "pending"
+	InvitationStatusAccepted InvitationStatus = "accepted"
+	InvitationStatusDeclined InvitationStatus = "declined"
+	InvitationStatusRevoked  InvitationStatus = "revoked"
+)
+
+// Invitation asks the owner of an email address to join an organization. The invitee
+// receives a signed token naming the invitation; it is only honoured while the
+// invitation is pending and unexpired.
+type Invitation struct {
+	BaseModel
+	OrganizationID uuid.UUID        `json:"organizationId" gorm:"not null;index"`
+	Email          string           `json:"email" gorm:"not null;index"`
+	Role           UserRole         `json:"role" gorm:"not null"`
+	Status         InvitationStatus `json:"status" gorm:"not null;default:'pending';index"`
+	InvitedByID    uuid.UUID        `json:"invitedById" gorm:"not null"`
+	ExpiresAt      time.Time        `json:"expiresAt"`
+	RespondedAt    *time.Time       `json:"respondedAt,omitempty"`
+	RespondedByID  *uuid.UUID       `json:"respondedById,omitempty"`
+}
+
+// Audit actions recorded for membership changes
+const (
+	AuditInvitationCreated  = "invitation.created"
+	AuditInvitationRevoked  = "invitation.revoked"
+	AuditInvitationAccepted = "invitation.accepted"
+	AuditInvitationDeclined = "invitation.declined"
+	AuditMemberRoleChanged  = "member.role_changed"
+	AuditMemberRemoved      = "member.removed"
+	AuditOwnershipTransfer  = "ownership.transferred"
+)
+
+// AuditEvent records who changed what in an organization
+type AuditEvent struct {
+	BaseModel
+	OrganizationID uuid.UUID  `json:"organizationId" gorm:"not null;index"`
+	ActorID        uuid.UUID  `json:"actorId" gorm:"not null"`
+	Action         string     `json:"action" gorm:"not null;index"`
+	SubjectUserID  *uuid.UUID `json:"subjectUserId,omitempty"`
+	SubjectEmail   string     `json:"subjectEmail,omitempty"`
+	Details        JSONB      `json:"details,omitempty" gorm:"type:jsonb"`
+}
+
+// ===== Notification Models =====
+
+type NotificationChannel string
+
+const (
+	NotificationChannelEmail   NotificationChannel = "email"
+	NotificationChannelWebhook NotificationChannel = "webhook"
+	NotificationChannelSlack   NotificationChannel = "slack"
+)
+
+// NotificationPreference routes a user's nudges of given types and severities to a channel
+type NotificationPreference struct {
+	BaseModel
+	UserID      uuid.UUID           `json:"userId" gorm:"not null;index"`
+	Channel     NotificationChannel `json:"channel" gorm:"not null"`
+	Target      string              `json:"target"`     // email address or webhook URL; email defaults to the user's address
+	NudgeTypes  string              `json:"nudgeTypes"` // comma-separated; empty matches every type
+	MinSeverity NudgeSeverity       `json:"minSeverity" gorm:"default:'low'"`
+	DailyDigest bool                `json:"dailyDigest"` // batch low-severity nudges into one daily message
+
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+type NotificationStatus string
+
+const (
+	NotificationStatusDigest NotificationStatus = "digest" // waiting for the next daily digest
+	NotificationStatusSent   NotificationStatus = "sent"
+	NotificationStatusFailed NotificationStatus = "failed"
+)
+
+// NotificationDelivery records one attempt to deliver a notification
+type NotificationDelivery struct {
+	BaseModel
+	OrganizationID uuid.UUID           `json:"organizationId" gorm:"not null;index"`
+	UserID         uuid.UUID           `json:"userId" gorm:"not null;index"`
+	NudgeID        *uuid.UUID          `json:"nudgeId,omitempty" gorm:"index"`
+	Channel        NotificationChannel `json:"channel"`
+	Target         string              `json:"target"`
+	Subject        string              `json:"subject"`
+	Body           string              `json:"body"`
+	Status         NotificationStatus  `json:"status" gorm:"index"`
+	Error          string              `json:"error,omitempty"`
+	SentAt         *time.Time          `json:"sentAt,omitempty"`
+}
+
 // ===== JSONB Type Helper =====
 
 type JSONB map[string]interface{}
@@ -388,7 +710,11 @@ func (j JSONB) Value() (interface{}, error) {
 	if j == nil {
 		return nil, nil
 	}
-	return j, nil
+	data, err := json.Marshal(j)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
 }
 
 // Scan implements the sql.Scanner interface
@@ -397,6 +723,18 @@ func (j *JSONB) Scan(value interface{}) error {
 		*j = nil
 		return nil
 	}
-	// GORM/PostgreSQL will handle JSONB automatically
-	return nil
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported JSONB source type %T", value)
+	}
+	if len(data) == 0 {
+		*j = nil
+		return nil
+	}
+	return json.Unmarshal(data, j)
 }
@@ -0,0 +1,268 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// Message is a rendered notification
+type Message struct {
+	Subject  string    `json:"subject"`
+	Body     string    `json:"body"`
+	Severity string    `json:"severity,omitempty"`
+	SentAt   time.Time `json:"sentAt"`
+}
+
+// Notifier delivers messages over one channel. The target is an email
+// address or a webhook URL depending on the channel.
+type Notifier interface {
+	Channel() models.NotificationChannel
+	Send(ctx context.Context, target string, msg Message) error
+}
+
+// ErrNoTarget is returned when a message has nowhere to go
+var ErrNoTarget = errors.New("notification target is empty")
+
+// ErrInvalidTarget is returned for webhook URLs the server will not post to: anything
+// but https, Slack webhooks off hooks.slack.com, and hosts on internal networks
+var ErrInvalidTarget = errors.New("invalid notification target")
+
+// slackWebhookHost is the only host Slack incoming webhooks are served from
+const slackWebhookHost = "hooks.slack.com"
+
+// ValidateTarget checks a webhook or Slack target, both when it is saved and before a
+// message is posted to it. Hosts resolving to internal networks are refused when
+// connecting.
+func ValidateTarget(channel models.NotificationChannel, target string) error {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return fmt.Errorf("%w: %s needs an https URL", ErrInvalidTarget, channel)
+	}
+	if channel == models.NotificationChannelSlack && !strings.EqualFold(u.Hostname(), slackWebhookHost) {
+		return fmt.Errorf("%w: Slack webhooks must be on %s", ErrInvalidTarget, slackWebhookHost)
+	}
+	if ip, err := netip.ParseAddr(u.Hostname()); err == nil && !publicAddr(ip) {
+		return fmt.Errorf("%w: %s is not a public address", ErrInvalidTarget, ip)
+	}
+	return nil
+}
+
+// publicAddr reports whether the server may connect to an address: loopback, private,
+// link-local (cloud metadata among them), multicast and unspecified addresses are out
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() && !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// SMTPConfig holds mail server settings
+type SMTPConfig struct {
+	Addr     string // host:port
+	From     string
+	Username string // optional; enables PLAIN auth
+	Password string
+}
+
+// SMTPNotifier sends email through an SMTP server
+type SMTPNotifier struct {
+	config SMTPConfig
+}
+
+// NewSMTPNotifier creates a new email notifier
+func NewSMTPNotifier(config SMTPConfig) *SMTPNotifier {
+	return &SMTPNotifier{config: config}
+}
+
+// Channel returns the email channel
+func (n *SMTPNotifier) Channel() models.NotificationChannel {
+	return models.NotificationChannelEmail
+}
+
+// Send delivers a plain-text email
+func (n *SMTPNotifier) Send(ctx context.Context, target string, msg Message) error {
+	if target == "" {
+		return ErrNoTarget
+	}
+	if strings.ContainsAny(target, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return errors.New("email headers must not contain line breaks")
+	}
+
+	var auth smtp.Auth
+	if n.config.Username != "" {
+		host := n.config.Addr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", n.config.Username, n.config.Password, host)
+	}
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %s\r\n", n.config.From)
+	fmt.Fprintf(&body, "To: %s\r\n", target)
+	fmt.Fprintf(&body, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&body, "Date: %s\r\n", msg.SentAt.Format(time.RFC1123Z))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	body.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	body.WriteString("\r\n")
+
+	// net/smtp has no context support; run it so cancellation is still honoured
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(n.config.Addr, auth, n.config.From, []string{target}, body.Bytes())
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WebhookNotifier posts the message as JSON to a URL
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a new outbound webhook notifier
+func NewWebhookNotifier(client *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{client: defaultClient(client)}
+}
+
+// Channel returns the webhook channel
+func (n *WebhookNotifier) Channel() models.NotificationChannel {
+	return models.NotificationChannelWebhook
+}
+
+// Send posts the message to the target URL
+func (n *WebhookNotifier) Send(ctx context.Context, target string, msg Message) error {
+	return postJSON(ctx, n.client, n.Channel(), target, msg)
+}
+
+// SlackNotifier posts to a Slack-compatible incoming webhook
+type SlackNotifier struct {
+	client *http.Client
+}
+
+// NewSlackNotifier creates a new Slack incoming-webhook notifier
+func NewSlackNotifier(client *http.Client) *SlackNotifier {
+	return &SlackNotifier{client: defaultClient(client)}
+}
+
+// Channel returns the Slack channel
+func (n *SlackNotifier) Channel() models.NotificationChannel {
+	return models.NotificationChannelSlack
+}
+
+// Send posts the message as Slack text to the target webhook URL
+func (n *SlackNotifier) Send(ctx context.Context, target string, msg Message) error {
+	payload := map[string]string{"text": fmt.Sprintf("*%s*\n%s", msg.Subject, msg.Body)}
+	return postJSON(ctx, n.client, n.Channel(), target, payload)
+}
+
+func defaultClient(client *http.Client) *http.Client {
+	if client == nil {
+		return NewPublicClient()
+	}
+	return client
+}
+
+// NewPublicClient is an HTTP client that only connects to public addresses over https.
+// The address is checked once resolved, right before connecting, so neither redirects
+// nor DNS answers that change between checks reach internal networks.
+func NewPublicClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil || !publicAddr(addr.Addr()) {
+				return fmt.Errorf("%w: %s is not a public address", ErrInvalidTarget, address)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return fmt.Errorf("%w: redirect to %s", ErrInvalidTarget, req.URL.Scheme)
+			}
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			return nil
+		},
+	}
+}
+
+func postJSON(ctx context.Context, client *http.Client, channel models.NotificationChannel, target string, payload interface{}) error {
+	if target == "" {
+		return ErrNoTarget
+	}
+	if err := ValidateTarget(channel, target); err != nil {
+		return err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// The response is not echoed back: delivery errors are shown to users
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// LogNotifier writes email to the server log instead of sending it. It stands in
+// for SMTP during local development so flows like password reset still work.
+type LogNotifier struct{}
+
+// NewLogNotifier creates a new logging email notifier
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// Channel returns the email channel
+func (n *LogNotifier) Channel() models.NotificationChannel {
+	return models.NotificationChannelEmail
+}
+
+// Send logs the message
+func (n *LogNotifier) Send(ctx context.Context, target string, msg Message) error {
+	if target == "" {
+		return ErrNoTarget
+	}
+	log.Printf("[Mail] To: %s\nSubject: %s\n\n%s", target, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,197 @@
+package notify_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/notify"
+)
+
+// smtpSink is a minimal local SMTP server that captures the DATA of each message
+type smtpSink struct {
+	listener net.Listener
+	messages chan string
+}
+
+func newSMTPSink() *smtpSink {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	sink := &smtpSink{listener: l, messages: make(chan string, 10)}
+	go sink.serve()
+	return sink
+}
+
+func (s *smtpSink) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpSink) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 sink ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.messages <- data.String()
+			reply("250 queued")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+var _ = Describe("Notifiers", func() {
+	ctx := context.Background()
+	msg := notify.Message{
+		Subject:  "Emma is overallocated",
+		Body:     "Emma is at 130% this week.\nConsider reassigning work.",
+		Severity: "high",
+		SentAt:   time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC),
+	}
+
+	Context("Given a local SMTP sink", func() {
+		It("should deliver a plain-text email", func() {
+			sink := newSMTPSink()
+			defer sink.listener.Close()
+			n := notify.NewSMTPNotifier(notify.SMTPConfig{Addr: sink.listener.Addr().String(), From: "xephyr@example.com"})
+
+			Expect(n.Send(ctx, "emma@example.com", msg)).To(Succeed())
+
+			var data string
+			Eventually(sink.messages).Should(Receive(&data))
+			Expect(data).To(ContainSubstring("To: emma@example.com"))
+			Expect(data).To(ContainSubstring("Subject: Emma is overallocated"))
+			Expect(data).To(ContainSubstring("Consider reassigning work."))
+		})
+
+		It("should refuse header injection", func() {
+			n := notify.NewSMTPNotifier(notify.SMTPConfig{Addr: "127.0.0.1:1", From: "xephyr@example.com"})
+			Expect(n.Send(ctx, "emma@example.com\r\nBcc: x@example.com", msg)).NotTo(Succeed())
+		})
+	})
+
+	Context("Given an HTTPS test server", func() {
+		var (
+			server   *httptest.Server
+			client   *http.Client
+			received map[string]interface{}
+			status   int
+		)
+
+		BeforeEach(func() {
+			status, received = http.StatusOK, nil
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = nil
+				json.NewDecoder(r.Body).Decode(&received)
+				w.WriteHeader(status)
+				w.Write([]byte("internal details"))
+			}))
+			// Every host resolves to the test server, standing in for the real webhooks
+			client = &http.Client{Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+				DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+				},
+			}}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should post the message to a generic webhook", func() {
+			Expect(notify.NewWebhookNotifier(client).Send(ctx, "https://hooks.example.com/xephyr", msg)).To(Succeed())
+			Expect(received).To(HaveKeyWithValue("subject", msg.Subject))
+			Expect(received).To(HaveKeyWithValue("severity", "high"))
+		})
+
+		It("should post Slack-formatted text", func() {
+			Expect(notify.NewSlackNotifier(client).Send(ctx, "https://hooks.slack.com/services/T0/B0/x", msg)).To(Succeed())
+			Expect(received).To(HaveKey("text"))
+			Expect(received["text"]).To(HavePrefix("*Emma is overallocated*"))
+		})
+
+		It("should report non-2xx responses as failures without their body", func() {
+			status = http.StatusInternalServerError
+			err := notify.NewWebhookNotifier(client).Send(ctx, "https://hooks.example.com/xephyr", msg)
+			Expect(err).To(MatchError(ContainSubstring("500")))
+			Expect(err.Error()).NotTo(ContainSubstring("internal details"))
+		})
+
+		It("should reject an empty target", func() {
+			Expect(notify.NewSlackNotifier(client).Send(ctx, "", msg)).To(MatchError(notify.ErrNoTarget))
+		})
+
+		It("should only post Slack messages to Slack", func() {
+			Expect(notify.NewSlackNotifier(client).Send(ctx, "https://hooks.example.com/xephyr", msg)).To(MatchError(notify.ErrInvalidTarget))
+			Expect(received).To(BeNil())
+		})
+
+		It("should refuse to connect to internal addresses", func() {
+			// The default client connects to the loopback test server itself
+			err := notify.NewWebhookNotifier(nil).Send(ctx, "https://localhost:"+strings.Split(server.Listener.Addr().String(), ":")[1], msg)
+			Expect(err).To(MatchError(notify.ErrInvalidTarget))
+			Expect(received).To(BeNil())
+		})
+	})
+
+	DescribeTable("validating webhook targets",
+		func(channel models.NotificationChannel, target string, valid bool) {
+			err := notify.ValidateTarget(channel, target)
+			if valid {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(notify.ErrInvalidTarget))
+			}
+		},
+		Entry("https webhook", models.NotificationChannelWebhook, "https://hooks.example.com/x", true),
+		Entry("plain http", models.NotificationChannelWebhook, "http://hooks.example.com/x", false),
+		Entry("loopback", models.NotificationChannelWebhook, "https://127.0.0.1/x", false),
+		Entry("private network", models.NotificationChannelWebhook, "https://10.0.0.5/x", false),
+		Entry("cloud metadata", models.NotificationChannelWebhook, "https://169.254.169.254/latest", false),
+		Entry("mapped IPv6 loopback", models.NotificationChannelWebhook, "https://[::ffff:127.0.0.1]/x", false),
+		Entry("Slack webhook", models.NotificationChannelSlack, "https://hooks.slack.com/services/T0/B0/x", true),
+		Entry("Slack channel elsewhere", models.NotificationChannelSlack, "https://hooks.example.com/x", false),
+	)
+})
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// Archived projects
+//
+// An archived project is read-only. Writes to the project, its team, its tasks and
+// their dependencies fail with ErrProjectArchived until the project is unarchived.
+// Derived values the system maintains, such as priority scores, health and progress,
+// are still refreshed.
+//
+// A task subtree can also be archived on its own. Its tasks are read-only in the same
+// way, failing with ErrTaskArchived, until the subtree is unarchived.
+
+// ErrProjectArchived is returned for writes to an archived project or anything in it
+var ErrProjectArchived = errors.New("project is archived and read-only")
+
+// ErrTaskArchived is returned for writes to an archived task
+var ErrTaskArchived = errors.New("task is archived and read-only")
+
+// archivedProjectIDs selects the IDs of the organization's archived projects
+func archivedProjectIDs(db *gorm.DB, orgID uuid.UUID) *gorm.DB {
+	return organizationProjectIDs(db, orgID).Where("status = ?", models.ProjectArchived)
+}
+
+// archivedTaskIDs selects the IDs of the tasks in the organization's archived projects
+func archivedTaskIDs(db *gorm.DB, orgID uuid.UUID) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.Task{}).
+		Select("id").
+		Where("project_id IN (?)", archivedProjectIDs(db, orgID))
+}
+
+// requireWritableProject fails with ErrProjectArchived when the project is archived
+func requireWritableProject(db *gorm.DB, orgID, projectID uuid.UUID) error {
+	var count int64
+	if err := db.Model(&models.Project{}).
+		Where("id IN (?)", archivedProjectIDs(db, orgID)).
+		Where("id = ?", projectID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrProjectArchived
+	}
+	return nil
+}
+
+// requireWritableTasks fails with ErrProjectArchived when any of the tasks is in an
+// archived project and with ErrTaskArchived when any of them is archived itself
+func requireWritableTasks(db *gorm.DB, orgID uuid.UUID, taskIDs ...uuid.UUID) error {
+	if err := requireTasksInWritableProjects(db, orgID, taskIDs...); err != nil {
+		return err
+	}
+	var count int64
+	if err := db.Model(&models.Task{}).
+		Where("id IN ? AND archived_at IS NOT NULL", taskIDs).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrTaskArchived
+	}
+	return nil
+}
+
+// requireTasksInWritableProjects fails with ErrProjectArchived when any of the tasks is
+// in an archived project
+func requireTasksInWritableProjects(db *gorm.DB, orgID uuid.UUID, taskIDs ...uuid.UUID) error {
+	var count int64
+	if err := db.Model(&models.Task{}).
+		Where("id IN (?)", archivedTaskIDs(db, orgID)).
+		Where("id IN ?", taskIDs).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrProjectArchived
+	}
+	return nil
+}
+
+// requireWritableDependency fails with ErrProjectArchived when either end of the
+// dependency is in an archived project
+func requireWritableDependency(db *gorm.DB, orgID, dependencyID uuid.UUID) error {
+	var count int64
+	if err := db.Model(&models.TaskDependency{}).
+		Where("id = ?", dependencyID).
+		Where("task_id IN (?) OR depends_on_task_id IN (?)", archivedTaskIDs(db, orgID), archivedTaskIDs(db, orgID)).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrProjectArchived
+	}
+	return nil
+}
@@ -57,12 +57,12 @@ func NewAssignmentRepository(db *gorm.DB) AssignmentRepository {
 }
 
 func (r *assignmentRepository) CreateSuggestion(ctx context.Context, suggestion *models.AssignmentSuggestion) error {
-	return r.db.WithContext(ctx).Create(suggestion).Error
+	return conn(ctx, r.db).Create(suggestion).Error
 }
 
 func (r *assignmentRepository) GetSuggestionByID(ctx context.Context, id uuid.UUID) (*models.AssignmentSuggestion, error) {
 	var suggestion models.AssignmentSuggestion
-	if err := r.db.WithContext(ctx).
+	if err := conn(ctx, r.db).
 		Preload("SuggestedUser").
 		Preload("Task").
 		First(&suggestion, "id = ?", id).Error; err != nil {
@@ -76,7 +76,7 @@ func (r *assignmentRepository) GetSuggestionByID(ctx context.Context, id uuid.UU
 
 func (r *assignmentRepository) GetSuggestionsByTask(ctx context.Context, taskID uuid.UUID) ([]models.AssignmentSuggestion, error) {
 	var suggestions []models.AssignmentSuggestion
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Preload("SuggestedUser").
 		Where("task_id = ?", taskID).
 		Order("total_score DESC").
@@ -85,7 +85,7 @@ func (r *assignmentRepository) GetSuggestionsByTask(ctx context.Context, taskID
 }
 
 func (r *assignmentRepository) UpdateSuggestionStatus(ctx context.Context, suggestionID uuid.UUID, status string) error {
-	return r.db.WithContext(ctx).
+	return conn(ctx, r.db).
 		Model(&models.AssignmentSuggestion{}).
 		Where("id = ?", suggestionID).
 		Update("status", status).Error
@@ -93,7 +93,7 @@ func (r *assignmentRepository) UpdateSuggestionStatus(ctx context.Context, sugge
 
 func (r *assignmentRepository) DeleteOldSuggestions(ctx context.Context, olderThan time.Duration) error {
 	cutoff := time.Now().Add(-olderThan)
-	return r.db.WithContext(ctx).
+	return conn(ctx, r.db).
 		Where("status = ? AND created_at < ?", "pending", cutoff).
 		Delete(&models.AssignmentSuggestion{}).Error
 }
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// AuditRepository defines audit trail data access operations. Events are append-only.
+type AuditRepository interface {
+	// Record appends an event to the organization's audit trail
+	Record(ctx context.Context, orgID uuid.UUID, event *models.AuditEvent) error
+
+	// List retrieves the organization's events, newest first
+	List(ctx context.Context, orgID uuid.UUID, params ListParams) ([]models.AuditEvent, int64, error)
+}
+
+// auditRepository implements AuditRepository
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Record(ctx context.Context, orgID uuid.UUID, event *models.AuditEvent) error {
+	event.OrganizationID = orgID
+	return conn(ctx, r.db).Create(event).Error
+}
+
+func (r *auditRepository) List(ctx context.Context, orgID uuid.UUID, params ListParams) ([]models.AuditEvent, int64, error) {
+	var events []models.AuditEvent
+	var total int64
+
+	query := conn(ctx, r.db).Model(&models.AuditEvent{}).Where("organization_id = ?", orgID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	params.SortBy, params.SortOrder = "created_at", "desc"
+	if err := query.Scopes(Paginate(params), Sort(params)).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
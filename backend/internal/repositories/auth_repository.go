@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// AuthRepository defines refresh token and password reset data access operations
+type AuthRepository interface {
+	// CreateRefreshToken stores a newly issued refresh token
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+
+	// GetRefreshTokenByHash retrieves a refresh token by the hash of its value
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+
+	// RevokeRefreshToken revokes one refresh token, recording the token that replaced it
+	RevokeRefreshToken(ctx context.Context, id uuid.UUID, replacedBy *uuid.UUID, now time.Time) error
+
+	// RevokeRefreshTokenFamily revokes every active token descended from the same login
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID, now time.Time) error
+
+	// RevokeUserRefreshTokens revokes all of a user's active refresh tokens
+	RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID, now time.Time) error
+
+	// CreatePasswordReset stores a password reset token
+	CreatePasswordReset(ctx context.Context, reset *models.PasswordResetToken) error
+
+	// GetPasswordResetByHash retrieves a password reset token by the hash of its value
+	GetPasswordResetByHash(ctx context.Context, hash string) (*models.PasswordResetToken, error)
+
+	// MarkPasswordResetUsed marks a reset token as used; it fails if the token was already used
+	MarkPasswordResetUsed(ctx context.Context, id uuid.UUID, now time.Time) error
+}
+
+// authRepository implements AuthRepository
+type authRepository struct {
+	db *gorm.DB
+}
+
+// NewAuthRepository creates a new auth repository
+func NewAuthRepository(db *gorm.DB) AuthRepository {
+	return &authRepository{db: db}
+}
+
+func (r *authRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return conn(ctx, r.db).Create(token).Error
+}
+
+func (r *authRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := conn(ctx, r.db).First(&token, "token_hash = ?", hash).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("refresh token not found: %w", err)
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *authRepository) RevokeRefreshToken(ctx context.Context, id uuid.UUID, replacedBy *uuid.UUID, now time.Time) error {
+	result := conn(ctx, r.db).
+		Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at":     now,
+			"replaced_by_id": replacedBy,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("refresh token already revoked: %w", gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+func (r *authRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID, now time.Time) error {
+	return conn(ctx, r.db).
+		Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now).Error
+}
+
+func (r *authRepository) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	return conn(ctx, r.db).
+		Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
+
+func (r *authRepository) CreatePasswordReset(ctx context.Context, reset *models.PasswordResetToken) error {
+	return conn(ctx, r.db).Create(reset).Error
+}
+
+func (r *authRepository) GetPasswordResetByHash(ctx context.Context, hash string) (*models.PasswordResetToken, error) {
+	var reset models.PasswordResetToken
+	if err := conn(ctx, r.db).First(&reset, "token_hash = ?", hash).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("password reset not found: %w", err)
+		}
+		return nil, err
+	}
+	return &reset, nil
+}
+
+func (r *authRepository) MarkPasswordResetUsed(ctx context.Context, id uuid.UUID, now time.Time) error {
+	result := conn(ctx, r.db).
+		Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("password reset already used: %w", gorm.ErrRecordNotFound)
+	}
+	return nil
+}
@@ -11,43 +11,48 @@ import (
 	"github.com/SimpleAjax/Xephyr/internal/models"
 )
 
-// DependencyRepository defines dependency data access operations
+// DependencyRepository defines dependency data access operations. Every method is
+// scoped to one organization through the tasks on both ends of the dependency.
 type DependencyRepository interface {
-	// Create creates a new dependency
-	Create(ctx context.Context, dep *models.TaskDependency) error
+	// Create creates a new dependency; both tasks must belong to the organization
+	Create(ctx context.Context, orgID uuid.UUID, dep *models.TaskDependency) error
 
 	// GetByID retrieves a dependency by ID
-	GetByID(ctx context.Context, id uuid.UUID) (*models.TaskDependency, error)
+	GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.TaskDependency, error)
 
 	// Delete removes a dependency
-	Delete(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, orgID uuid.UUID, id uuid.UUID) error
 
 	// ListByTask retrieves dependencies for a task
-	ListByTask(ctx context.Context, taskID uuid.UUID) ([]models.TaskDependency, error)
+	ListByTask(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID) ([]models.TaskDependency, error)
 
 	// ListByDependsOn retrieves tasks that depend on a given task
-	ListByDependsOn(ctx context.Context, dependsOnTaskID uuid.UUID) ([]models.TaskDependency, error)
+	ListByDependsOn(ctx context.Context, orgID uuid.UUID, dependsOnTaskID uuid.UUID) ([]models.TaskDependency, error)
 
 	// ListByProject retrieves all dependencies in a project
-	ListByProject(ctx context.Context, projectID uuid.UUID) ([]models.TaskDependency, error)
+	ListByProject(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID) ([]models.TaskDependency, error)
+
+	// ListDependentsOfProject retrieves the dependencies on a project's tasks, including
+	// those of tasks in other projects
+	ListDependentsOfProject(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID) ([]models.TaskDependency, error)
 
 	// HasDependency checks if a dependency exists
-	HasDependency(ctx context.Context, taskID, dependsOnTaskID uuid.UUID) (bool, error)
+	HasDependency(ctx context.Context, orgID uuid.UUID, taskID, dependsOnTaskID uuid.UUID) (bool, error)
 
 	// WouldCreateCycle checks if adding a dependency would create a cycle
-	WouldCreateCycle(ctx context.Context, taskID, dependsOnTaskID uuid.UUID) (bool, error)
+	WouldCreateCycle(ctx context.Context, orgID uuid.UUID, taskID, dependsOnTaskID uuid.UUID) (bool, error)
 
 	// GetDependentCount counts how many tasks depend on this task
-	GetDependentCount(ctx context.Context, taskID uuid.UUID) (int64, error)
+	GetDependentCount(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID) (int64, error)
 
 	// GetDependencyCount counts how many tasks this task depends on
-	GetDependencyCount(ctx context.Context, taskID uuid.UUID) (int64, error)
+	GetDependencyCount(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID) (int64, error)
 
 	// DeleteByTask removes all dependencies for a task
-	DeleteByTask(ctx context.Context, taskID uuid.UUID) error
+	DeleteByTask(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID) error
 
 	// UpdateLag updates the lag hours for a dependency
-	UpdateLag(ctx context.Context, dependencyID uuid.UUID, lagHours int) error
+	UpdateLag(ctx context.Context, orgID uuid.UUID, dependencyID uuid.UUID, lagHours int) error
 }
 
 // dependencyRepository implements DependencyRepository
@@ -60,13 +65,38 @@ func NewDependencyRepository(db *gorm.DB) DependencyRepository {
 	return &dependencyRepository{db: db}
 }
 
-func (r *dependencyRepository) Create(ctx context.Context, dep *models.TaskDependency) error {
-	return r.db.WithContext(ctx).Create(dep).Error
+// scoped starts a dependency query limited to the organization
+func (r *dependencyRepository) scoped(ctx context.Context, orgID uuid.UUID) *gorm.DB {
+	return conn(ctx, r.db).Model(&models.TaskDependency{}).Scopes(dependenciesInOrganization(r.db, orgID))
+}
+
+// requireTasks fails with not found unless every task belongs to the organization
+func (r *dependencyRepository) requireTasks(ctx context.Context, orgID uuid.UUID, taskIDs ...uuid.UUID) error {
+	for _, id := range taskIDs {
+		ok, err := taskInOrganization(conn(ctx, r.db), orgID, id)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("task not found: %w", gorm.ErrRecordNotFound)
+		}
+	}
+	return nil
 }
 
-func (r *dependencyRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.TaskDependency, error) {
+func (r *dependencyRepository) Create(ctx context.Context, orgID uuid.UUID, dep *models.TaskDependency) error {
+	if err := r.requireTasks(ctx, orgID, dep.TaskID, dep.DependsOnTaskID); err != nil {
+		return err
+	}
+	if err := requireWritableTasks(conn(ctx, r.db), orgID, dep.TaskID, dep.DependsOnTaskID); err != nil {
+		return err
+	}
+	return conn(ctx, r.db).Create(dep).Error
+}
+
+func (r *dependencyRepository) GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.TaskDependency, error) {
 	var dep models.TaskDependency
-	if err := r.db.WithContext(ctx).
+	if err := r.scoped(ctx, orgID).
 		Preload("Task").
 		Preload("DependsOnTask").
 		First(&dep, "id = ?", id).Error; err != nil {
@@ -78,48 +108,63 @@ func (r *dependencyRepository) GetByID(ctx context.Context, id uuid.UUID) (*mode
 	return &dep, nil
 }
 
-func (r *dependencyRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.TaskDependency{}, "id = ?", id).Error
+func (r *dependencyRepository) Delete(ctx context.Context, orgID uuid.UUID, id uuid.UUID) error {
+	if err := requireWritableDependency(conn(ctx, r.db), orgID, id); err != nil {
+		return err
+	}
+	return affectedOne(r.scoped(ctx, orgID).Where("id = ?", id).Delete(&models.TaskDependency{}), "dependency")
 }
 
-func (r *dependencyRepository) ListByTask(ctx context.Context, taskID uuid.UUID) ([]models.TaskDependency, error) {
+func (r *dependencyRepository) ListByTask(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID) ([]models.TaskDependency, error) {
 	var deps []models.TaskDependency
-	err := r.db.WithContext(ctx).
+	err := r.scoped(ctx, orgID).
 		Preload("DependsOnTask").
 		Where("task_id = ?", taskID).
 		Find(&deps).Error
 	return deps, err
 }
 
-func (r *dependencyRepository) ListByDependsOn(ctx context.Context, dependsOnTaskID uuid.UUID) ([]models.TaskDependency, error) {
+func (r *dependencyRepository) ListByDependsOn(ctx context.Context, orgID uuid.UUID, dependsOnTaskID uuid.UUID) ([]models.TaskDependency, error) {
 	var deps []models.TaskDependency
-	err := r.db.WithContext(ctx).
+	err := r.scoped(ctx, orgID).
 		Preload("Task").
 		Where("depends_on_task_id = ?", dependsOnTaskID).
 		Find(&deps).Error
 	return deps, err
 }
 
-func (r *dependencyRepository) ListByProject(ctx context.Context, projectID uuid.UUID) ([]models.TaskDependency, error) {
+func (r *dependencyRepository) ListByProject(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID) ([]models.TaskDependency, error) {
 	var deps []models.TaskDependency
-	err := r.db.WithContext(ctx).
+	err := r.scoped(ctx, orgID).
 		Joins("JOIN tasks ON task_dependencies.task_id = tasks.id").
 		Where("tasks.project_id = ?", projectID).
 		Find(&deps).Error
 	return deps, err
 }
 
-func (r *dependencyRepository) HasDependency(ctx context.Context, taskID, dependsOnTaskID uuid.UUID) (bool, error) {
+func (r *dependencyRepository) ListDependentsOfProject(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID) ([]models.TaskDependency, error) {
+	var deps []models.TaskDependency
+	err := r.scoped(ctx, orgID).
+		Joins("JOIN tasks ON task_dependencies.depends_on_task_id = tasks.id").
+		Where("tasks.project_id = ?", projectID).
+		Find(&deps).Error
+	return deps, err
+}
+
+func (r *dependencyRepository) HasDependency(ctx context.Context, orgID uuid.UUID, taskID, dependsOnTaskID uuid.UUID) (bool, error) {
 	var count int64
-	err := r.db.WithContext(ctx).
-		Model(&models.TaskDependency{}).
+	err := r.scoped(ctx, orgID).
 		Where("task_id = ? AND depends_on_task_id = ?", taskID, dependsOnTaskID).
 		Count(&count).Error
 	return count > 0, err
 }
 
 // WouldCreateCycle checks if adding a dependency would create a cycle using CTE
-func (r *dependencyRepository) WouldCreateCycle(ctx context.Context, taskID, dependsOnTaskID uuid.UUID) (bool, error) {
+func (r *dependencyRepository) WouldCreateCycle(ctx context.Context, orgID uuid.UUID, taskID, dependsOnTaskID uuid.UUID) (bool, error) {
+	if err := r.requireTasks(ctx, orgID, taskID, dependsOnTaskID); err != nil {
+		return false, err
+	}
+
 	// Direct cycle check
 	if taskID == dependsOnTaskID {
 		return true, nil
@@ -128,7 +173,7 @@ func (r *dependencyRepository) WouldCreateCycle(ctx context.Context, taskID, dep
 	// Check if dependsOnTaskID already depends on taskID (would create cycle)
 	var count int64
 	// This is a simplified check - for a full check you'd need a recursive CTE
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Raw(`
 			WITH RECURSIVE dependency_chain AS (
 				SELECT task_id, depends_on_task_id
@@ -150,34 +195,37 @@ func (r *dependencyRepository) WouldCreateCycle(ctx context.Context, taskID, dep
 	return count > 0, nil
 }
 
-func (r *dependencyRepository) GetDependentCount(ctx context.Context, taskID uuid.UUID) (int64, error) {
+func (r *dependencyRepository) GetDependentCount(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID) (int64, error) {
 	var count int64
-	err := r.db.WithContext(ctx).
-		Model(&models.TaskDependency{}).
+	err := r.scoped(ctx, orgID).
 		Where("depends_on_task_id = ?", taskID).
 		Count(&count).Error
 	return count, err
 }
 
-func (r *dependencyRepository) GetDependencyCount(ctx context.Context, taskID uuid.UUID) (int64, error) {
+func (r *dependencyRepository) GetDependencyCount(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID) (int64, error) {
 	var count int64
-	err := r.db.WithContext(ctx).
-		Model(&models.TaskDependency{}).
+	err := r.scoped(ctx, orgID).
 		Where("task_id = ?", taskID).
 		Count(&count).Error
 	return count, err
 }
 
-func (r *dependencyRepository) DeleteByTask(ctx context.Context, taskID uuid.UUID) error {
+func (r *dependencyRepository) DeleteByTask(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID) error {
+	if err := requireWritableTasks(conn(ctx, r.db), orgID, taskID); err != nil {
+		return err
+	}
 	// Delete where task is either the dependent or the dependency
-	return r.db.WithContext(ctx).
+	return r.scoped(ctx, orgID).
 		Where("task_id = ? OR depends_on_task_id = ?", taskID, taskID).
 		Delete(&models.TaskDependency{}).Error
 }
 
-func (r *dependencyRepository) UpdateLag(ctx context.Context, dependencyID uuid.UUID, lagHours int) error {
-	return r.db.WithContext(ctx).
-		Model(&models.TaskDependency{}).
+func (r *dependencyRepository) UpdateLag(ctx context.Context, orgID uuid.UUID, dependencyID uuid.UUID, lagHours int) error {
+	if err := requireWritableDependency(conn(ctx, r.db), orgID, dependencyID); err != nil {
+		return err
+	}
+	return affectedOne(r.scoped(ctx, orgID).
 		Where("id = ?", dependencyID).
-		Update("lag_hours", lagHours).Error
+		Update("lag_hours", lagHours), "dependency")
 }
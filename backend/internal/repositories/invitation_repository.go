@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// InvitationRepository defines organization invitation data access operations. Every
+// method is scoped to one organization.
+type InvitationRepository interface {
+	// Create creates a new invitation in the organization
+	Create(ctx context.Context, orgID uuid.UUID, invitation *models.Invitation) error
+
+	// GetByID retrieves an invitation by ID
+	GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.Invitation, error)
+
+	// ListPending retrieves the invitations still awaiting an answer at now
+	ListPending(ctx context.Context, orgID uuid.UUID, now time.Time) ([]models.Invitation, error)
+
+	// GetPendingByEmail retrieves the unexpired pending invitation for an email, if any
+	GetPendingByEmail(ctx context.Context, orgID uuid.UUID, email string, now time.Time) (*models.Invitation, error)
+
+	// Respond moves a pending invitation to status. It fails with not found when the
+	// invitation is no longer pending, so it can only be answered once.
+	Respond(ctx context.Context, orgID uuid.UUID, id uuid.UUID, status models.InvitationStatus, by uuid.UUID, at time.Time) error
+}
+
+// invitationRepository implements InvitationRepository
+type invitationRepository struct {
+	db *gorm.DB
+}
+
+// NewInvitationRepository creates a new invitation repository
+func NewInvitationRepository(db *gorm.DB) InvitationRepository {
+	return &invitationRepository{db: db}
+}
+
+func (r *invitationRepository) scoped(ctx context.Context, orgID uuid.UUID) *gorm.DB {
+	return conn(ctx, r.db).Model(&models.Invitation{}).Where("organization_id = ?", orgID)
+}
+
+func (r *invitationRepository) Create(ctx context.Context, orgID uuid.UUID, invitation *models.Invitation) error {
+	invitation.OrganizationID = orgID
+	return conn(ctx, r.db).Create(invitation).Error
+}
+
+func (r *invitationRepository) GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.Invitation, error) {
+	var invitation models.Invitation
+	if err := r.scoped(ctx, orgID).First(&invitation, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("invitation not found: %w", err)
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepository) ListPending(ctx context.Context, orgID uuid.UUID, now time.Time) ([]models.Invitation, error) {
+	var invitations []models.Invitation
+	err := r.scoped(ctx, orgID).
+		Where("status = ? AND expires_at > ?", models.InvitationStatusPending, now).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+func (r *invitationRepository) GetPendingByEmail(ctx context.Context, orgID uuid.UUID, email string, now time.Time) (*models.Invitation, error) {
+	var invitation models.Invitation
+	if err := r.scoped(ctx, orgID).
+		Where("email = ? AND status = ? AND expires_at > ?", email, models.InvitationStatusPending, now).
+		First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("invitation not found: %w", err)
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepository) Respond(ctx context.Context, orgID uuid.UUID, id uuid.UUID, status models.InvitationStatus, by uuid.UUID, at time.Time) error {
+	return affectedOne(r.scoped(ctx, orgID).
+		Where("id = ? AND status = ?", id, models.InvitationStatusPending).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"responded_at":    at,
+			"responded_by_id": by,
+		}), "invitation")
+}
@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// JobRepository defines background job data access operations
+type JobRepository interface {
+	// Create enqueues a new job
+	Create(ctx context.Context, job *models.Job) error
+
+	// GetByID retrieves a job of the organization by ID
+	GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.Job, error)
+
+	// ClaimNext atomically picks the next due job of one of the given types and marks it running.
+	// Returns nil when no job is ready.
+	ClaimNext(ctx context.Context, types []string) (*models.Job, error)
+
+	// UpdateProgress records the progress (0-100) of a running job
+	UpdateProgress(ctx context.Context, id uuid.UUID, progress int) error
+
+	// MarkSucceeded completes a job with its result payload
+	MarkSucceeded(ctx context.Context, id uuid.UUID, result models.JSONB) error
+
+	// MarkFailed permanently fails a job
+	MarkFailed(ctx context.Context, id uuid.UUID, errMsg string) error
+
+	// Reschedule puts a failed attempt back on the queue to run again at runAt
+	Reschedule(ctx context.Context, id uuid.UUID, runAt time.Time, errMsg string) error
+
+	// RequeueRunning returns jobs left running by a previous process to the queue
+	RequeueRunning(ctx context.Context) (int64, error)
+}
+
+// jobRepository implements JobRepository
+type jobRepository struct {
+	db *gorm.DB
+}
+
+// NewJobRepository creates a new job repository
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+func (r *jobRepository) Create(ctx context.Context, job *models.Job) error {
+	return conn(ctx, r.db).Create(job).Error
+}
+
+func (r *jobRepository) GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.Job, error) {
+	var job models.Job
+	if err := conn(ctx, r.db).First(&job, "id = ? AND organization_id = ?", id, orgID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("job not found: %w", err)
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *jobRepository) ClaimNext(ctx context.Context, types []string) (*models.Job, error) {
+	var claimed *models.Job
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var job models.Job
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ?", models.JobStatusQueued, time.Now())
+		if len(types) > 0 {
+			query = query.Where("type IN ?", types)
+		}
+		if err := query.Order("run_at ASC").Limit(1).Find(&job).Error; err != nil {
+			return err
+		}
+		if job.ID == uuid.Nil {
+			return nil
+		}
+
+		now := time.Now()
+		job.Status = models.JobStatusRunning
+		job.Attempts++
+		job.StartedAt = &now
+		if err := tx.Model(&models.Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"status":     job.Status,
+			"attempts":   job.Attempts,
+			"started_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		claimed = &job
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+func (r *jobRepository) UpdateProgress(ctx context.Context, id uuid.UUID, progress int) error {
+	return conn(ctx, r.db).
+		Model(&models.Job{}).
+		Where("id = ?", id).
+		Update("progress", progress).Error
+}
+
+func (r *jobRepository) MarkSucceeded(ctx context.Context, id uuid.UUID, result models.JSONB) error {
+	now := time.Now()
+	return conn(ctx, r.db).
+		Model(&models.Job{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       models.JobStatusSucceeded,
+			"progress":     100,
+			"result":       result,
+			"last_error":   "",
+			"completed_at": now,
+		}).Error
+}
+
+func (r *jobRepository) MarkFailed(ctx context.Context, id uuid.UUID, errMsg string) error {
+	now := time.Now()
+	return conn(ctx, r.db).
+		Model(&models.Job{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       models.JobStatusFailed,
+			"last_error":   errMsg,
+			"completed_at": now,
+		}).Error
+}
+
+func (r *jobRepository) Reschedule(ctx context.Context, id uuid.UUID, runAt time.Time, errMsg string) error {
+	return conn(ctx, r.db).
+		Model(&models.Job{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     models.JobStatusQueued,
+			"run_at":     runAt,
+			"last_error": errMsg,
+		}).Error
+}
+
+func (r *jobRepository) RequeueRunning(ctx context.Context) (int64, error) {
+	result := conn(ctx, r.db).
+		Model(&models.Job{}).
+		Where("status = ?", models.JobStatusRunning).
+		Updates(map[string]interface{}{
+			"status": models.JobStatusQueued,
+			"run_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// NotificationRepository defines notification preference and delivery data access operations
+type NotificationRepository interface {
+	// ListPreferences retrieves a user's notification preferences
+	ListPreferences(ctx context.Context, userID uuid.UUID) ([]models.NotificationPreference, error)
+
+	// ReplacePreferences replaces all of a user's notification preferences
+	ReplacePreferences(ctx context.Context, userID uuid.UUID, prefs []models.NotificationPreference) error
+
+	// CreateDelivery records a delivery attempt
+	CreateDelivery(ctx context.Context, delivery *models.NotificationDelivery) error
+
+	// ListPendingDigests retrieves deliveries waiting for the daily digest
+	ListPendingDigests(ctx context.Context) ([]models.NotificationDelivery, error)
+
+	// MarkDeliveries sets the outcome of several deliveries at once
+	MarkDeliveries(ctx context.Context, ids []uuid.UUID, status models.NotificationStatus, errMsg string, sentAt *time.Time) error
+}
+
+// notificationRepository implements NotificationRepository
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) ListPreferences(ctx context.Context, userID uuid.UUID) ([]models.NotificationPreference, error) {
+	var prefs []models.NotificationPreference
+	err := conn(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&prefs).Error
+	return prefs, err
+}
+
+func (r *notificationRepository) ReplacePreferences(ctx context.Context, userID uuid.UUID, prefs []models.NotificationPreference) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.NotificationPreference{}).Error; err != nil {
+			return err
+		}
+		if len(prefs) == 0 {
+			return nil
+		}
+		for i := range prefs {
+			prefs[i].UserID = userID
+		}
+		return tx.Create(&prefs).Error
+	})
+}
+
+func (r *notificationRepository) CreateDelivery(ctx context.Context, delivery *models.NotificationDelivery) error {
+	return conn(ctx, r.db).Create(delivery).Error
+}
+
+func (r *notificationRepository) ListPendingDigests(ctx context.Context) ([]models.NotificationDelivery, error) {
+	var deliveries []models.NotificationDelivery
+	err := conn(ctx, r.db).
+		Where("status = ?", models.NotificationStatusDigest).
+		Order("created_at ASC").
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *notificationRepository) MarkDeliveries(ctx context.Context, ids []uuid.UUID, status models.NotificationStatus, errMsg string, sentAt *time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).
+		Model(&models.NotificationDelivery{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"status":  status,
+			"error":   errMsg,
+			"sent_at": sentAt,
+		}).Error
+}
@@ -12,28 +12,30 @@ import (
 	"github.com/SimpleAjax/Xephyr/internal/models"
 )
 
-// NudgeRepository defines nudge data access operations
+// NudgeRepository defines nudge data access operations. Lookups and updates are scoped
+// to one organization; the expiry, clean-up, wake-up and escalation sweeps run across
+// all organizations for the maintenance scheduler.
 type NudgeRepository interface {
-	// Create creates a new nudge
-	Create(ctx context.Context, nudge *models.Nudge) error
+	// Create creates a new nudge in the organization
+	Create(ctx context.Context, orgID uuid.UUID, nudge *models.Nudge) error
 
 	// GetByID retrieves a nudge by ID
-	GetByID(ctx context.Context, id uuid.UUID) (*models.Nudge, error)
+	GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.Nudge, error)
 
 	// Update updates a nudge
-	Update(ctx context.Context, nudge *models.Nudge) error
+	Update(ctx context.Context, orgID uuid.UUID, nudge *models.Nudge) error
 
 	// Delete soft-deletes a nudge
-	Delete(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, orgID uuid.UUID, id uuid.UUID) error
 
 	// List retrieves nudges with filters
 	List(ctx context.Context, orgID uuid.UUID, filters NudgeFilters, params ListParams) ([]models.Nudge, int64, error)
 
 	// ListByUser retrieves nudges for a specific user
-	ListByUser(ctx context.Context, userID uuid.UUID, params ListParams) ([]models.Nudge, int64, error)
+	ListByUser(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, params ListParams) ([]models.Nudge, int64, error)
 
 	// UpdateStatus updates nudge status
-	UpdateStatus(ctx context.Context, nudgeID uuid.UUID, status models.NudgeStatus) error
+	UpdateStatus(ctx context.Context, orgID uuid.UUID, nudgeID uuid.UUID, status models.NudgeStatus) error
 
 	// CountByStatus counts nudges by status
 	CountByStatus(ctx context.Context, orgID uuid.UUID, status models.NudgeStatus) (int64, error)
@@ -41,11 +43,26 @@ type NudgeRepository interface {
 	// GetStats retrieves nudge statistics
 	GetStats(ctx context.Context, orgID uuid.UUID, period time.Duration) (*NudgeStats, error)
 
-	// ExpireOldNudges marks expired nudges
-	ExpireOldNudges(ctx context.Context) error
+	// ExpireOldNudges marks open nudges past their expiry as expired
+	ExpireOldNudges(ctx context.Context) (int64, error)
 
-	// DeleteOldNudges permanently deletes old dismissed/acted nudges
-	DeleteOldNudges(ctx context.Context, olderThan time.Duration) error
+	// DeleteOldNudges permanently deletes old closed nudges
+	DeleteOldNudges(ctx context.Context, olderThan time.Duration) (int64, error)
+
+	// WakeSnoozedNudges returns snoozed nudges whose snooze has ended to unread
+	WakeSnoozedNudges(ctx context.Context, now time.Time) (int64, error)
+
+	// ListEscalationCandidates retrieves unread, unescalated nudges of a severity created before a cutoff
+	ListEscalationCandidates(ctx context.Context, severity models.NudgeSeverity, createdBefore time.Time) ([]models.Nudge, error)
+
+	// ListOpen retrieves unread, read and snoozed nudges of the given types
+	ListOpen(ctx context.Context, orgID uuid.UUID, types []models.NudgeType) ([]models.Nudge, error)
+
+	// Resolve marks a nudge as resolved and records the resolution action
+	Resolve(ctx context.Context, orgID uuid.UUID, nudgeID uuid.UUID, action *models.NudgeAction) error
+
+	// CreateAction records an action taken on a nudge
+	CreateAction(ctx context.Context, action *models.NudgeAction) error
 }
 
 // NudgeFilters provides filtering options for nudges
@@ -65,6 +82,10 @@ type NudgeStats struct {
 	Read       int64
 	Acted      int64
 	Dismissed  int64
+	Resolved   int64
+	Expired    int64
+	// AvgTimeToAction is the mean time from creation to the first user action
+	AvgTimeToAction time.Duration
 	BySeverity map[string]int64
 	ByType     map[string]int64
 }
@@ -79,13 +100,15 @@ func NewNudgeRepository(db *gorm.DB) NudgeRepository {
 	return &nudgeRepository{db: db}
 }
 
-func (r *nudgeRepository) Create(ctx context.Context, nudge *models.Nudge) error {
-	return r.db.WithContext(ctx).Create(nudge).Error
+func (r *nudgeRepository) Create(ctx context.Context, orgID uuid.UUID, nudge *models.Nudge) error {
+	nudge.OrganizationID = orgID
+	return conn(ctx, r.db).Create(nudge).Error
 }
 
-func (r *nudgeRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Nudge, error) {
+func (r *nudgeRepository) GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.Nudge, error) {
 	var nudge models.Nudge
-	if err := r.db.WithContext(ctx).
+	if err := conn(ctx, r.db).
+		Where("organization_id = ?", orgID).
 		Preload("RelatedUser").
 		Preload("RelatedTask").
 		Preload("RelatedProject").
@@ -99,19 +122,34 @@ func (r *nudgeRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Nu
 	return &nudge, nil
 }
 
-func (r *nudgeRepository) Update(ctx context.Context, nudge *models.Nudge) error {
-	return r.db.WithContext(ctx).Save(nudge).Error
+func (r *nudgeRepository) Update(ctx context.Context, orgID uuid.UUID, nudge *models.Nudge) error {
+	if nudge.OrganizationID != orgID {
+		return fmt.Errorf("nudge not found: %w", gorm.ErrRecordNotFound)
+	}
+	var count int64
+	if err := conn(ctx, r.db).
+		Model(&models.Nudge{}).
+		Where("id = ? AND organization_id = ?", nudge.ID, orgID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("nudge not found: %w", gorm.ErrRecordNotFound)
+	}
+	return conn(ctx, r.db).Save(nudge).Error
 }
 
-func (r *nudgeRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.Nudge{}, "id = ?", id).Error
+func (r *nudgeRepository) Delete(ctx context.Context, orgID uuid.UUID, id uuid.UUID) error {
+	return affectedOne(conn(ctx, r.db).
+		Where("id = ? AND organization_id = ?", id, orgID).
+		Delete(&models.Nudge{}), "nudge")
 }
 
 func (r *nudgeRepository) List(ctx context.Context, orgID uuid.UUID, filters NudgeFilters, params ListParams) ([]models.Nudge, int64, error) {
 	var nudges []models.Nudge
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&models.Nudge{}).Where("organization_id = ?", orgID)
+	query := conn(ctx, r.db).Model(&models.Nudge{}).Where("organization_id = ?", orgID)
 
 	// Apply filters
 	if filters.Status != nil {
@@ -143,11 +181,11 @@ func (r *nudgeRepository) List(ctx context.Context, orgID uuid.UUID, filters Nud
 	return nudges, total, nil
 }
 
-func (r *nudgeRepository) ListByUser(ctx context.Context, userID uuid.UUID, params ListParams) ([]models.Nudge, int64, error) {
+func (r *nudgeRepository) ListByUser(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, params ListParams) ([]models.Nudge, int64, error) {
 	var nudges []models.Nudge
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&models.Nudge{}).Where("related_user_id = ?", userID)
+	query := conn(ctx, r.db).Model(&models.Nudge{}).Where("organization_id = ? AND related_user_id = ?", orgID, userID)
 	query.Count(&total)
 
 	err := query.Scopes(Paginate(params), Sort(params)).Find(&nudges).Error
@@ -158,16 +196,16 @@ func (r *nudgeRepository) ListByUser(ctx context.Context, userID uuid.UUID, para
 	return nudges, total, nil
 }
 
-func (r *nudgeRepository) UpdateStatus(ctx context.Context, nudgeID uuid.UUID, status models.NudgeStatus) error {
-	return r.db.WithContext(ctx).
+func (r *nudgeRepository) UpdateStatus(ctx context.Context, orgID uuid.UUID, nudgeID uuid.UUID, status models.NudgeStatus) error {
+	return affectedOne(conn(ctx, r.db).
 		Model(&models.Nudge{}).
-		Where("id = ?", nudgeID).
-		Update("status", status).Error
+		Where("id = ? AND organization_id = ?", nudgeID, orgID).
+		Update("status", status), "nudge")
 }
 
 func (r *nudgeRepository) CountByStatus(ctx context.Context, orgID uuid.UUID, status models.NudgeStatus) (int64, error) {
 	var count int64
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Model(&models.Nudge{}).
 		Where("organization_id = ? AND status = ?", orgID, status).
 		Count(&count).Error
@@ -187,7 +225,7 @@ func (r *nudgeRepository) GetStats(ctx context.Context, orgID uuid.UUID, period
 	}
 	
 	since := time.Now().Add(-period)
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Model(&models.Nudge{}).
 		Select("status, COUNT(*) as count").
 		Where("organization_id = ? AND created_at > ?", orgID, since).
@@ -208,6 +246,10 @@ func (r *nudgeRepository) GetStats(ctx context.Context, orgID uuid.UUID, period
 			stats.Acted = r.Count
 		case models.NudgeStatusDismissed:
 			stats.Dismissed = r.Count
+		case models.NudgeStatusResolved:
+			stats.Resolved = r.Count
+		case models.NudgeStatusExpired:
+			stats.Expired = r.Count
 		}
 		stats.Total += r.Count
 	}
@@ -218,7 +260,7 @@ func (r *nudgeRepository) GetStats(ctx context.Context, orgID uuid.UUID, period
 		Count    int64
 	}
 	
-	err = r.db.WithContext(ctx).
+	err = conn(ctx, r.db).
 		Model(&models.Nudge{}).
 		Select("severity, COUNT(*) as count").
 		Where("organization_id = ? AND created_at > ?", orgID, since).
@@ -239,7 +281,7 @@ func (r *nudgeRepository) GetStats(ctx context.Context, orgID uuid.UUID, period
 		Count int64
 	}
 	
-	err = r.db.WithContext(ctx).
+	err = conn(ctx, r.db).
 		Model(&models.Nudge{}).
 		Select("type, COUNT(*) as count").
 		Where("organization_id = ? AND created_at > ?", orgID, since).
@@ -254,21 +296,98 @@ func (r *nudgeRepository) GetStats(ctx context.Context, orgID uuid.UUID, period
 		stats.ByType[r.Type] = r.Count
 	}
 
+	// Average time from creation to the first action a person took
+	var avgSeconds *float64
+	err = conn(ctx, r.db).
+		Raw(`
+			SELECT AVG(EXTRACT(EPOCH FROM (a.first_action_at - n.created_at)))
+			FROM nudges n
+			JOIN (
+				SELECT nudge_id, MIN(created_at) AS first_action_at
+				FROM nudge_actions
+				WHERE user_id IS NOT NULL AND deleted_at IS NULL
+				GROUP BY nudge_id
+			) a ON a.nudge_id = n.id
+			WHERE n.organization_id = ? AND n.created_at > ? AND n.deleted_at IS NULL
+		`, orgID, since).
+		Scan(&avgSeconds).Error
+
+	if err != nil {
+		return nil, err
+	}
+	if avgSeconds != nil {
+		stats.AvgTimeToAction = time.Duration(*avgSeconds * float64(time.Second))
+	}
+
 	return stats, nil
 }
 
-func (r *nudgeRepository) ExpireOldNudges(ctx context.Context) error {
+func (r *nudgeRepository) ExpireOldNudges(ctx context.Context) (int64, error) {
 	now := time.Now()
-	return r.db.WithContext(ctx).
+	result := conn(ctx, r.db).
 		Model(&models.Nudge{}).
-		Where("expires_at < ? AND status = ?", now, models.NudgeStatusUnread).
-		Update("status", models.NudgeStatusDismissed).Error
+		Where("expires_at < ? AND status IN ?", now, []models.NudgeStatus{models.NudgeStatusUnread, models.NudgeStatusRead}).
+		Update("status", models.NudgeStatusExpired)
+	return result.RowsAffected, result.Error
 }
 
-func (r *nudgeRepository) DeleteOldNudges(ctx context.Context, olderThan time.Duration) error {
+func (r *nudgeRepository) DeleteOldNudges(ctx context.Context, olderThan time.Duration) (int64, error) {
 	cutoff := time.Now().Add(-olderThan)
-	return r.db.WithContext(ctx).
-		Where("(status = ? OR status = ?) AND updated_at < ?", 
-			models.NudgeStatusDismissed, models.NudgeStatusActed, cutoff).
-		Delete(&models.Nudge{}).Error
+	result := conn(ctx, r.db).
+		Where("status IN ? AND updated_at < ?",
+			[]models.NudgeStatus{models.NudgeStatusDismissed, models.NudgeStatusActed, models.NudgeStatusResolved, models.NudgeStatusExpired}, cutoff).
+		Delete(&models.Nudge{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *nudgeRepository) WakeSnoozedNudges(ctx context.Context, now time.Time) (int64, error) {
+	result := conn(ctx, r.db).
+		Model(&models.Nudge{}).
+		Where("status = ? AND snoozed_until <= ?", models.NudgeStatusSnoozed, now).
+		Updates(map[string]interface{}{
+			"status":        models.NudgeStatusUnread,
+			"snoozed_until": nil,
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *nudgeRepository) ListEscalationCandidates(ctx context.Context, severity models.NudgeSeverity, createdBefore time.Time) ([]models.Nudge, error) {
+	var nudges []models.Nudge
+	err := conn(ctx, r.db).
+		Where("status = ? AND severity = ? AND escalated_at IS NULL AND created_at < ?",
+			models.NudgeStatusUnread, severity, createdBefore).
+		Order("created_at ASC").
+		Find(&nudges).Error
+	return nudges, err
+}
+
+func (r *nudgeRepository) ListOpen(ctx context.Context, orgID uuid.UUID, types []models.NudgeType) ([]models.Nudge, error) {
+	var nudges []models.Nudge
+	query := conn(ctx, r.db).
+		Where("organization_id = ? AND status IN ?", orgID, []models.NudgeStatus{models.NudgeStatusUnread, models.NudgeStatusRead, models.NudgeStatusSnoozed})
+	if len(types) > 0 {
+		query = query.Where("type IN ?", types)
+	}
+	err := query.Order("created_at DESC").Find(&nudges).Error
+	return nudges, err
+}
+
+func (r *nudgeRepository) Resolve(ctx context.Context, orgID uuid.UUID, nudgeID uuid.UUID, action *models.NudgeAction) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := affectedOne(tx.Model(&models.Nudge{}).
+			Where("id = ? AND organization_id = ?", nudgeID, orgID).
+			Updates(map[string]interface{}{
+				"status":      models.NudgeStatusResolved,
+				"resolved_at": now,
+			}), "nudge"); err != nil {
+			return err
+		}
+		action.NudgeID = nudgeID
+		return tx.Create(action).Error
+	})
+}
+
+func (r *nudgeRepository) CreateAction(ctx context.Context, action *models.NudgeAction) error {
+	return conn(ctx, r.db).Create(action).Error
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/SimpleAjax/Xephyr/internal/models"
 )
@@ -45,6 +46,19 @@ type OrganizationRepository interface {
 
 	// GetMemberRole gets a user's role in an organization
 	GetMemberRole(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) (models.UserRole, error)
+
+	// ListMemberships retrieves every organization membership of a user
+	ListMemberships(ctx context.Context, userID uuid.UUID) ([]models.OrganizationMember, error)
+
+	// ListMembers retrieves an organization's members with their users
+	ListMembers(ctx context.Context, orgID uuid.UUID) ([]models.OrganizationMember, error)
+
+	// LockMembers retrieves an organization's members and locks them until the surrounding
+	// transaction ends, so concurrent role changes see each other's results
+	LockMembers(ctx context.Context, orgID uuid.UUID) ([]models.OrganizationMember, error)
+
+	// SetOwner records a member as the organization's owner
+	SetOwner(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) error
 }
 
 // organizationRepository implements OrganizationRepository
@@ -58,12 +72,12 @@ func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
 }
 
 func (r *organizationRepository) Create(ctx context.Context, org *models.Organization) error {
-	return r.db.WithContext(ctx).Create(org).Error
+	return conn(ctx, r.db).Create(org).Error
 }
 
 func (r *organizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
 	var org models.Organization
-	if err := r.db.WithContext(ctx).
+	if err := conn(ctx, r.db).
 		Preload("Members.User").
 		Preload("Projects").
 		First(&org, "id = ?", id).Error; err != nil {
@@ -77,7 +91,7 @@ func (r *organizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*mo
 
 func (r *organizationRepository) GetBySlug(ctx context.Context, slug string) (*models.Organization, error) {
 	var org models.Organization
-	if err := r.db.WithContext(ctx).First(&org, "slug = ?", slug).Error; err != nil {
+	if err := conn(ctx, r.db).First(&org, "slug = ?", slug).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("organization not found: %w", err)
 		}
@@ -87,18 +101,18 @@ func (r *organizationRepository) GetBySlug(ctx context.Context, slug string) (*m
 }
 
 func (r *organizationRepository) Update(ctx context.Context, org *models.Organization) error {
-	return r.db.WithContext(ctx).Save(org).Error
+	return conn(ctx, r.db).Save(org).Error
 }
 
 func (r *organizationRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.Organization{}, "id = ?", id).Error
+	return conn(ctx, r.db).Delete(&models.Organization{}, "id = ?", id).Error
 }
 
 func (r *organizationRepository) List(ctx context.Context, params ListParams) ([]models.Organization, int64, error) {
 	var orgs []models.Organization
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&models.Organization{})
+	query := conn(ctx, r.db).Model(&models.Organization{})
 	query.Count(&total)
 
 	err := query.Scopes(Paginate(params), Sort(params)).Find(&orgs).Error
@@ -115,17 +129,17 @@ func (r *organizationRepository) AddMember(ctx context.Context, orgID uuid.UUID,
 		UserID:         userID,
 		Role:           role,
 	}
-	return r.db.WithContext(ctx).Create(&member).Error
+	return conn(ctx, r.db).Create(&member).Error
 }
 
 func (r *organizationRepository) RemoveMember(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) error {
-	return r.db.WithContext(ctx).
+	return conn(ctx, r.db).
 		Where("organization_id = ? AND user_id = ?", orgID, userID).
 		Delete(&models.OrganizationMember{}).Error
 }
 
 func (r *organizationRepository) UpdateMemberRole(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, role models.UserRole) error {
-	return r.db.WithContext(ctx).
+	return conn(ctx, r.db).
 		Model(&models.OrganizationMember{}).
 		Where("organization_id = ? AND user_id = ?", orgID, userID).
 		Update("role", role).Error
@@ -133,7 +147,7 @@ func (r *organizationRepository) UpdateMemberRole(ctx context.Context, orgID uui
 
 func (r *organizationRepository) IsMember(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) (bool, error) {
 	var count int64
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Model(&models.OrganizationMember{}).
 		Where("organization_id = ? AND user_id = ?", orgID, userID).
 		Count(&count).Error
@@ -142,7 +156,7 @@ func (r *organizationRepository) IsMember(ctx context.Context, orgID uuid.UUID,
 
 func (r *organizationRepository) GetMemberRole(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) (models.UserRole, error) {
 	var member models.OrganizationMember
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Where("organization_id = ? AND user_id = ?", orgID, userID).
 		First(&member).Error
 	if err != nil {
@@ -150,3 +164,39 @@ func (r *organizationRepository) GetMemberRole(ctx context.Context, orgID uuid.U
 	}
 	return member.Role, nil
 }
+
+func (r *organizationRepository) ListMemberships(ctx context.Context, userID uuid.UUID) ([]models.OrganizationMember, error) {
+	var memberships []models.OrganizationMember
+	err := conn(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("joined_at ASC").
+		Find(&memberships).Error
+	return memberships, err
+}
+
+func (r *organizationRepository) ListMembers(ctx context.Context, orgID uuid.UUID) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	err := conn(ctx, r.db).
+		Preload("User").
+		Where("organization_id = ?", orgID).
+		Order("joined_at ASC").
+		Find(&members).Error
+	return members, err
+}
+
+func (r *organizationRepository) LockMembers(ctx context.Context, orgID uuid.UUID) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	err := conn(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ?", orgID).
+		Order("joined_at ASC").
+		Find(&members).Error
+	return members, err
+}
+
+func (r *organizationRepository) SetOwner(ctx context.Context, orgID uuid.UUID, userID uuid.UUID) error {
+	return affectedOne(conn(ctx, r.db).
+		Model(&models.Organization{}).
+		Where("id = ?", orgID).
+		Update("owner_id", userID), "organization")
+}
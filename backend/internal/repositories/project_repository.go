@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -12,51 +13,63 @@ import (
 	"github.com/SimpleAjax/Xephyr/internal/models"
 )
 
-// ProjectRepository defines project data access operations
+// ProjectRepository defines project data access operations. Every method is scoped
+// to one organization.
 type ProjectRepository interface {
-	// Create creates a new project
-	Create(ctx context.Context, project *models.Project) error
+	// Create creates a new project in the organization
+	Create(ctx context.Context, orgID uuid.UUID, project *models.Project) error
 
 	// GetByID retrieves a project by ID
-	GetByID(ctx context.Context, id uuid.UUID) (*models.Project, error)
+	GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.Project, error)
 
-	// Update updates a project
-	Update(ctx context.Context, project *models.Project) error
+	// Update updates a project; archived projects cannot be updated
+	Update(ctx context.Context, orgID uuid.UUID, project *models.Project) error
 
-	// Delete soft-deletes a project
-	Delete(ctx context.Context, id uuid.UUID) error
+	// UpdateStatus moves a project to a new lifecycle status
+	UpdateStatus(ctx context.Context, orgID uuid.UUID, id uuid.UUID, status models.ProjectStatus) error
 
-	// List retrieves projects with pagination
-	List(ctx context.Context, params ListParams) ([]models.Project, int64, error)
+	// Delete soft-deletes a project along with its team, tasks, their skill requirements,
+	// dependencies and assignment suggestions, and the nudges about any of them
+	Delete(ctx context.Context, orgID uuid.UUID, id uuid.UUID) error
+
+	// Restore undeletes a project and everything deleted along with it. It fails with
+	// ErrProjectNotDeleted when the project was never deleted.
+	Restore(ctx context.Context, orgID uuid.UUID, id uuid.UUID) error
 
 	// ListByOrganization retrieves projects in an organization
 	ListByOrganization(ctx context.Context, orgID uuid.UUID, params ListParams) ([]models.Project, int64, error)
 
 	// ListByStatus retrieves projects by status
-	ListByStatus(ctx context.Context, status models.ProjectStatus, params ListParams) ([]models.Project, int64, error)
+	ListByStatus(ctx context.Context, orgID uuid.UUID, status models.ProjectStatus, params ListParams) ([]models.Project, int64, error)
 
 	// UpdateHealthScore updates project health score
-	UpdateHealthScore(ctx context.Context, projectID uuid.UUID, score int) error
+	UpdateHealthScore(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, score int) error
 
 	// UpdateProgress updates project progress percentage
-	UpdateProgress(ctx context.Context, projectID uuid.UUID, progress int) error
+	UpdateProgress(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, progress int) error
 
 	// UpdatePriority updates project priority
-	UpdatePriority(ctx context.Context, projectID uuid.UUID, priority int) error
+	UpdatePriority(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, priority int) error
 
 	// AddMember adds a user to a project
-	AddMember(ctx context.Context, projectID uuid.UUID, userID uuid.UUID, role string) error
+	AddMember(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, userID uuid.UUID, role string) error
 
 	// RemoveMember removes a user from a project
-	RemoveMember(ctx context.Context, projectID uuid.UUID, userID uuid.UUID) error
+	RemoveMember(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, userID uuid.UUID) error
 
 	// IsMember checks if a user is a member of a project
-	IsMember(ctx context.Context, projectID uuid.UUID, userID uuid.UUID) (bool, error)
+	IsMember(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, userID uuid.UUID) (bool, error)
+
+	// GetMember retrieves a user's membership of a project
+	GetMember(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, userID uuid.UUID) (*models.ProjectMember, error)
 
 	// CountTasks counts tasks in a project
-	CountTasks(ctx context.Context, projectID uuid.UUID) (int64, error)
+	CountTasks(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID) (int64, error)
 }
 
+// ErrProjectNotDeleted is returned when restoring a project that is not deleted
+var ErrProjectNotDeleted = errors.New("project is not deleted")
+
 // projectRepository implements ProjectRepository
 type projectRepository struct {
 	db *gorm.DB
@@ -67,13 +80,31 @@ func NewProjectRepository(db *gorm.DB) ProjectRepository {
 	return &projectRepository{db: db}
 }
 
-func (r *projectRepository) Create(ctx context.Context, project *models.Project) error {
-	return r.db.WithContext(ctx).Create(project).Error
+// scoped starts a project query limited to the organization
+func (r *projectRepository) scoped(ctx context.Context, orgID uuid.UUID) *gorm.DB {
+	return conn(ctx, r.db).Model(&models.Project{}).Where("organization_id = ?", orgID)
 }
 
-func (r *projectRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Project, error) {
+// requireProject fails with not found unless the project belongs to the organization
+func (r *projectRepository) requireProject(ctx context.Context, orgID, projectID uuid.UUID) error {
+	ok, err := projectInOrganization(conn(ctx, r.db), orgID, projectID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("project not found: %w", gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+func (r *projectRepository) Create(ctx context.Context, orgID uuid.UUID, project *models.Project) error {
+	project.OrganizationID = orgID
+	return conn(ctx, r.db).Create(project).Error
+}
+
+func (r *projectRepository) GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.Project, error) {
 	var project models.Project
-	if err := r.db.WithContext(ctx).
+	if err := r.scoped(ctx, orgID).
 		Preload("Members.User").
 		Preload("Tasks", func(db *gorm.DB) *gorm.DB {
 			return db.Where("parent_task_id IS NULL") // Only root tasks
@@ -87,27 +118,87 @@ func (r *projectRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.
 	return &project, nil
 }
 
-func (r *projectRepository) Update(ctx context.Context, project *models.Project) error {
-	return r.db.WithContext(ctx).Save(project).Error
+func (r *projectRepository) Update(ctx context.Context, orgID uuid.UUID, project *models.Project) error {
+	if err := r.requireProject(ctx, orgID, project.ID); err != nil {
+		return err
+	}
+	if err := requireWritableProject(conn(ctx, r.db), orgID, project.ID); err != nil {
+		return err
+	}
+	// A project never moves between organizations
+	project.OrganizationID = orgID
+	return conn(ctx, r.db).Save(project).Error
 }
 
-func (r *projectRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.Project{}, "id = ?", id).Error
+func (r *projectRepository) UpdateStatus(ctx context.Context, orgID uuid.UUID, id uuid.UUID, status models.ProjectStatus) error {
+	return affectedOne(r.scoped(ctx, orgID).
+		Where("id = ?", id).
+		Update("status", status), "project")
 }
 
-func (r *projectRepository) List(ctx context.Context, params ListParams) ([]models.Project, int64, error) {
-	var projects []models.Project
-	var total int64
+// Delete stamps the project and its children with one deletion time, so Restore can
+// bring back exactly the rows this delete removed and not those deleted earlier
+func (r *projectRepository) Delete(ctx context.Context, orgID uuid.UUID, id uuid.UUID) error {
+	// Postgres keeps microseconds; truncating lets Restore match the stored value exactly
+	deletedAt := time.Now().UTC().Truncate(time.Microsecond)
 
-	query := r.db.WithContext(ctx).Model(&models.Project{})
-	query.Count(&total)
+	db := conn(ctx, r.db)
+	if err := affectedOne(r.scoped(ctx, orgID).Where("id = ?", id).Update("deleted_at", deletedAt), "project"); err != nil {
+		return err
+	}
+	// Tasks are selected unscoped because the project row above is already deleted
+	tasks := db.Session(&gorm.Session{NewDB: true}).Unscoped().
+		Model(&models.Task{}).Select("id").Where("project_id = ? AND deleted_at IS NULL", id)
+	return r.stampChildren(db, id, tasks, "deleted_at IS NULL", deletedAt)
+}
 
-	err := query.Scopes(Paginate(params), Sort(params)).Find(&projects).Error
-	if err != nil {
-		return nil, 0, err
+func (r *projectRepository) Restore(ctx context.Context, orgID uuid.UUID, id uuid.UUID) error {
+	db := conn(ctx, r.db)
+
+	var project models.Project
+	if err := db.Unscoped().Where("organization_id = ?", orgID).First(&project, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("project not found: %w", err)
+		}
+		return err
 	}
+	if !project.DeletedAt.Valid {
+		return ErrProjectNotDeleted
+	}
+	deletedAt := project.DeletedAt.Time
 
-	return projects, total, nil
+	if err := db.Unscoped().Model(&models.Project{}).Where("id = ?", id).Update("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	tasks := db.Session(&gorm.Session{NewDB: true}).Unscoped().
+		Model(&models.Task{}).Select("id").Where("project_id = ? AND deleted_at = ?", id, deletedAt)
+	return r.stampChildren(db, id, tasks, "deleted_at = ?", nil, deletedAt)
+}
+
+// stampChildren sets deleted_at to value on the project's children matching the
+// condition. Tasks are updated last since the other children are selected through them.
+func (r *projectRepository) stampChildren(db *gorm.DB, projectID uuid.UUID, tasks *gorm.DB, condition string, value interface{}, args ...interface{}) error {
+	children := []struct {
+		model interface{}
+		where string
+		args  []interface{}
+	}{
+		{&models.TaskSkill{}, "task_id IN (?)", []interface{}{tasks}},
+		{&models.TaskDependency{}, "task_id IN (?) OR depends_on_task_id IN (?)", []interface{}{tasks, tasks}},
+		{&models.AssignmentSuggestion{}, "task_id IN (?)", []interface{}{tasks}},
+		{&models.Nudge{}, "related_project_id = ? OR related_task_id IN (?)", []interface{}{projectID, tasks}},
+		{&models.ProjectMember{}, "project_id = ?", []interface{}{projectID}},
+		{&models.Task{}, "project_id = ?", []interface{}{projectID}},
+	}
+	for _, c := range children {
+		if err := db.Unscoped().Model(c.model).
+			Where(c.where, c.args...).
+			Where(condition, args...).
+			Update("deleted_at", value).Error; err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (r *projectRepository) ListByOrganization(ctx context.Context, orgID uuid.UUID, params ListParams) ([]models.Project, int64, error) {
@@ -117,11 +208,11 @@ func (r *projectRepository) ListByOrganization(ctx context.Context, orgID uuid.U
 
 	// Try raw SQL to debug
 	var rawCount int64
-	r.db.WithContext(ctx).Raw("SELECT COUNT(*) FROM projects WHERE organization_id = ?", orgID).Scan(&rawCount)
+	conn(ctx, r.db).Raw("SELECT COUNT(*) FROM projects WHERE organization_id = ?", orgID).Scan(&rawCount)
 	log.Printf("[ProjectRepository] Raw SQL count: %d", rawCount)
 
 	// Use GORM query
-	scopedQuery := r.db.WithContext(ctx).Model(&models.Project{}).Where("organization_id = ?::uuid", orgID)
+	scopedQuery := conn(ctx, r.db).Model(&models.Project{}).Where("organization_id = ?::uuid", orgID)
 	var scopedTotal int64
 	scopedQuery.Count(&scopedTotal)
 	log.Printf("[ProjectRepository] GORM count: %d", scopedTotal)
@@ -139,11 +230,11 @@ func (r *projectRepository) ListByOrganization(ctx context.Context, orgID uuid.U
 	return projects, scopedTotal, nil
 }
 
-func (r *projectRepository) ListByStatus(ctx context.Context, status models.ProjectStatus, params ListParams) ([]models.Project, int64, error) {
+func (r *projectRepository) ListByStatus(ctx context.Context, orgID uuid.UUID, status models.ProjectStatus, params ListParams) ([]models.Project, int64, error) {
 	var projects []models.Project
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&models.Project{}).Where("status = ?", status)
+	query := r.scoped(ctx, orgID).Where("status = ?", status)
 	query.Count(&total)
 
 	err := query.Scopes(Paginate(params), Sort(params)).Find(&projects).Error
@@ -154,55 +245,77 @@ func (r *projectRepository) ListByStatus(ctx context.Context, status models.Proj
 	return projects, total, nil
 }
 
-func (r *projectRepository) UpdateHealthScore(ctx context.Context, projectID uuid.UUID, score int) error {
-	return r.db.WithContext(ctx).
-		Model(&models.Project{}).
+func (r *projectRepository) UpdateHealthScore(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, score int) error {
+	return affectedOne(r.scoped(ctx, orgID).
 		Where("id = ?", projectID).
-		Update("health_score", score).Error
+		Update("health_score", score), "project")
 }
 
-func (r *projectRepository) UpdateProgress(ctx context.Context, projectID uuid.UUID, progress int) error {
-	return r.db.WithContext(ctx).
-		Model(&models.Project{}).
+func (r *projectRepository) UpdateProgress(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, progress int) error {
+	return affectedOne(r.scoped(ctx, orgID).
 		Where("id = ?", projectID).
-		Update("progress", progress).Error
+		Update("progress", progress), "project")
 }
 
-func (r *projectRepository) UpdatePriority(ctx context.Context, projectID uuid.UUID, priority int) error {
-	return r.db.WithContext(ctx).
-		Model(&models.Project{}).
+func (r *projectRepository) UpdatePriority(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, priority int) error {
+	return affectedOne(r.scoped(ctx, orgID).
 		Where("id = ?", projectID).
-		Update("priority", priority).Error
+		Update("priority", priority), "project")
 }
 
-func (r *projectRepository) AddMember(ctx context.Context, projectID uuid.UUID, userID uuid.UUID, role string) error {
+func (r *projectRepository) AddMember(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, userID uuid.UUID, role string) error {
+	if err := r.requireProject(ctx, orgID, projectID); err != nil {
+		return err
+	}
+	if err := requireWritableProject(conn(ctx, r.db), orgID, projectID); err != nil {
+		return err
+	}
 	member := models.ProjectMember{
 		ProjectID: projectID,
 		UserID:    userID,
 		Role:      role,
 	}
-	return r.db.WithContext(ctx).Create(&member).Error
+	return conn(ctx, r.db).Create(&member).Error
 }
 
-func (r *projectRepository) RemoveMember(ctx context.Context, projectID uuid.UUID, userID uuid.UUID) error {
-	return r.db.WithContext(ctx).
+func (r *projectRepository) RemoveMember(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, userID uuid.UUID) error {
+	if err := requireWritableProject(conn(ctx, r.db), orgID, projectID); err != nil {
+		return err
+	}
+	return conn(ctx, r.db).
 		Where("project_id = ? AND user_id = ?", projectID, userID).
+		Where("project_id IN (?)", organizationProjectIDs(r.db, orgID)).
 		Delete(&models.ProjectMember{}).Error
 }
 
-func (r *projectRepository) IsMember(ctx context.Context, projectID uuid.UUID, userID uuid.UUID) (bool, error) {
+func (r *projectRepository) IsMember(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, userID uuid.UUID) (bool, error) {
 	var count int64
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Model(&models.ProjectMember{}).
 		Where("project_id = ? AND user_id = ?", projectID, userID).
+		Where("project_id IN (?)", organizationProjectIDs(r.db, orgID)).
 		Count(&count).Error
 	return count > 0, err
 }
 
-func (r *projectRepository) CountTasks(ctx context.Context, projectID uuid.UUID) (int64, error) {
+func (r *projectRepository) GetMember(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, userID uuid.UUID) (*models.ProjectMember, error) {
+	var member models.ProjectMember
+	if err := conn(ctx, r.db).
+		Where("project_id IN (?)", organizationProjectIDs(r.db, orgID)).
+		First(&member, "project_id = ? AND user_id = ?", projectID, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("project member not found: %w", err)
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *projectRepository) CountTasks(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID) (int64, error) {
 	var count int64
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Model(&models.Task{}).
+		Scopes(tasksInOrganization(r.db, orgID)).
 		Where("project_id = ?", projectID).
 		Count(&count).Error
 	return count, err
@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// ProjectTemplateRepository defines project template data access operations. Every
+// method is scoped to one organization.
+type ProjectTemplateRepository interface {
+	// Create saves a template with its tasks, their skill requirements and its
+	// dependencies. Tasks must be ordered so parents come before their subtasks.
+	Create(ctx context.Context, orgID uuid.UUID, template *models.ProjectTemplate) error
+
+	// GetByID retrieves a template with its tasks, their skills and its dependencies
+	GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.ProjectTemplate, error)
+
+	// List retrieves the organization's templates without their contents
+	List(ctx context.Context, orgID uuid.UUID, params ListParams) ([]models.ProjectTemplate, int64, error)
+
+	// CountTasks counts the tasks of each template
+	CountTasks(ctx context.Context, orgID uuid.UUID, templateIDs []uuid.UUID) (map[uuid.UUID]int, error)
+
+	// Delete soft-deletes a template with its contents
+	Delete(ctx context.Context, orgID uuid.UUID, id uuid.UUID) error
+}
+
+// projectTemplateRepository implements ProjectTemplateRepository
+type projectTemplateRepository struct {
+	db *gorm.DB
+}
+
+// NewProjectTemplateRepository creates a new project template repository
+func NewProjectTemplateRepository(db *gorm.DB) ProjectTemplateRepository {
+	return &projectTemplateRepository{db: db}
+}
+
+func (r *projectTemplateRepository) scoped(ctx context.Context, orgID uuid.UUID) *gorm.DB {
+	return conn(ctx, r.db).Model(&models.ProjectTemplate{}).Where("organization_id = ?", orgID)
+}
+
+// organizationTemplateIDs selects the IDs of an organization's templates
+func organizationTemplateIDs(db *gorm.DB, orgID uuid.UUID) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.ProjectTemplate{}).
+		Select("id").
+		Where("organization_id = ?", orgID)
+}
+
+func (r *projectTemplateRepository) Create(ctx context.Context, orgID uuid.UUID, template *models.ProjectTemplate) error {
+	template.OrganizationID = orgID
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(template).Error; err != nil {
+			return err
+		}
+		for i := range template.Tasks {
+			task := &template.Tasks[i]
+			task.TemplateID = template.ID
+			if err := tx.Omit(clause.Associations).Create(task).Error; err != nil {
+				return err
+			}
+			for j := range task.Skills {
+				task.Skills[j].TemplateTaskID = task.ID
+				if err := tx.Omit(clause.Associations).Create(&task.Skills[j]).Error; err != nil {
+					return err
+				}
+			}
+		}
+		for i := range template.Dependencies {
+			template.Dependencies[i].TemplateID = template.ID
+			if err := tx.Create(&template.Dependencies[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *projectTemplateRepository) GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.ProjectTemplate, error) {
+	var template models.ProjectTemplate
+	err := r.scoped(ctx, orgID).
+		Preload("Tasks", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Preload("Tasks.Skills.Skill").
+		Preload("Dependencies").
+		First(&template, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("project template not found: %w", err)
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *projectTemplateRepository) List(ctx context.Context, orgID uuid.UUID, params ListParams) ([]models.ProjectTemplate, int64, error) {
+	var templates []models.ProjectTemplate
+	var total int64
+
+	query := r.scoped(ctx, orgID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Scopes(Paginate(params), Sort(params)).Find(&templates).Error; err != nil {
+		return nil, 0, err
+	}
+	return templates, total, nil
+}
+
+func (r *projectTemplateRepository) CountTasks(ctx context.Context, orgID uuid.UUID, templateIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	var rows []struct {
+		TemplateID uuid.UUID
+		Count      int
+	}
+	db := conn(ctx, r.db)
+	err := db.Model(&models.TemplateTask{}).
+		Select("template_id, COUNT(*) AS count").
+		Where("template_id IN ?", templateIDs).
+		Where("template_id IN (?)", organizationTemplateIDs(db, orgID)).
+		Group("template_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		counts[row.TemplateID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *projectTemplateRepository) Delete(ctx context.Context, orgID uuid.UUID, id uuid.UUID) error {
+	deletedAt := time.Now().UTC()
+	db := conn(ctx, r.db)
+	if err := affectedOne(r.scoped(ctx, orgID).Where("id = ?", id).Update("deleted_at", deletedAt), "project template"); err != nil {
+		return err
+	}
+	tasks := db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.TemplateTask{}).Select("id").Where("template_id = ?", id)
+	if err := db.Model(&models.TemplateTaskSkill{}).Where("template_task_id IN (?)", tasks).Update("deleted_at", deletedAt).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&models.TemplateDependency{}).Where("template_id = ?", id).Update("deleted_at", deletedAt).Error; err != nil {
+		return err
+	}
+	return db.Model(&models.TemplateTask{}).Where("template_id = ?", id).Update("deleted_at", deletedAt).Error
+}
@@ -1,6 +1,9 @@
 package repositories
 
 import (
+	"context"
+
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -15,6 +18,17 @@ type Provider struct {
 	Workload     WorkloadRepository
 	Scenario     ScenarioRepository
 	Dependency   DependencyRepository
+	Job          JobRepository
+	Notification NotificationRepository
+	Auth         AuthRepository
+	Invitation   InvitationRepository
+	Audit        AuditRepository
+	Skill        SkillRepository
+	Template     ProjectTemplateRepository
+	Workflow     WorkflowRepository
+	TaskEvent    TaskEventRepository
+
+	db *gorm.DB
 }
 
 // NewProvider creates a new repository provider with all repositories
@@ -29,7 +43,33 @@ func NewProvider(db *gorm.DB) *Provider {
 		Workload:     NewWorkloadRepository(db),
 		Scenario:     NewScenarioRepository(db),
 		Dependency:   NewDependencyRepository(db),
+		Job:          NewJobRepository(db),
+		Notification: NewNotificationRepository(db),
+		Auth:         NewAuthRepository(db),
+		Invitation:   NewInvitationRepository(db),
+		Audit:        NewAuditRepository(db),
+		Skill:        NewSkillRepository(db),
+		Template:     NewProjectTemplateRepository(db),
+		Workflow:     NewWorkflowRepository(db),
+		TaskEvent:    NewTaskEventRepository(db),
+		db:           db,
+	}
+}
+
+// WithTransaction runs fn with a provider whose repositories share one database transaction.
+// The transaction is scoped to the organization carried by ctx, if any, and nests inside
+// the request's transaction as a savepoint.
+// Providers assembled without a database (e.g. from in-memory repositories in tests) run fn directly.
+func (p *Provider) WithTransaction(ctx context.Context, fn func(tx *Provider) error) error {
+	if p.db == nil {
+		return fn(p)
 	}
+	return conn(ctx, p.db).Transaction(func(tx *gorm.DB) error {
+		if err := scopeTransaction(ctx, tx); err != nil {
+			return err
+		}
+		return fn(NewProvider(tx))
+	})
 }
 
 // Repositories interface for easy mocking in tests
@@ -43,6 +83,16 @@ type Repositories interface {
 	GetWorkload() WorkloadRepository
 	GetScenario() ScenarioRepository
 	GetDependency() DependencyRepository
+	GetJob() JobRepository
+	GetNotification() NotificationRepository
+	GetAuth() AuthRepository
+	GetInvitation() InvitationRepository
+	GetAudit() AuditRepository
+	GetSkill() SkillRepository
+	GetTemplate() ProjectTemplateRepository
+	GetWorkflow() WorkflowRepository
+	GetTaskEvent() TaskEventRepository
+	InOrganization(ctx context.Context, orgID uuid.UUID, fn func(ctx context.Context) error) error
 }
 
 // Ensure Provider implements Repositories
@@ -92,3 +142,48 @@ func (p *Provider) GetScenario() ScenarioRepository {
 func (p *Provider) GetDependency() DependencyRepository {
 	return p.Dependency
 }
+
+// GetJob returns the job repository
+func (p *Provider) GetJob() JobRepository {
+	return p.Job
+}
+
+// GetNotification returns the notification repository
+func (p *Provider) GetNotification() NotificationRepository {
+	return p.Notification
+}
+
+// GetAuth returns the auth repository
+func (p *Provider) GetAuth() AuthRepository {
+	return p.Auth
+}
+
+// GetInvitation returns the invitation repository
+func (p *Provider) GetInvitation() InvitationRepository {
+	return p.Invitation
+}
+
+// GetAudit returns the audit repository
+func (p *Provider) GetAudit() AuditRepository {
+	return p.Audit
+}
+
+// GetSkill returns the skill repository
+func (p *Provider) GetSkill() SkillRepository {
+	return p.Skill
+}
+
+// GetTemplate returns the project template repository
+func (p *Provider) GetTemplate() ProjectTemplateRepository {
+	return p.Template
+}
+
+// GetWorkflow returns the task workflow repository
+func (p *Provider) GetWorkflow() WorkflowRepository {
+	return p.Workflow
+}
+
+// GetTaskEvent returns the task event repository
+func (p *Provider) GetTaskEvent() TaskEventRepository {
+	return p.TaskEvent
+}
@@ -19,6 +19,8 @@ type DBConfig struct {
 	Password string
 	Database string
 	SSLMode  string
+	// Role, when set, is assumed on every connection in place of User's own privileges
+	Role string
 }
 
 // DefaultDBConfig returns default configuration
@@ -40,6 +42,9 @@ type Repository struct {
 func NewRepository(config DBConfig) (*Repository, error) {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		config.Host, config.Port, config.User, config.Password, config.Database, config.SSLMode)
+	if config.Role != "" {
+		dsn += fmt.Sprintf(" options='-c role=%s'", config.Role)
+	}
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
@@ -66,9 +71,14 @@ func (r *Repository) DB() *gorm.DB {
 	return r.db
 }
 
-// WithTransaction executes a function within a database transaction
+// WithTransaction executes a function within a database transaction. When ctx carries an
+// organization (see ContextWithOrganization) the transaction's row-level security scope
+// is set to it before fn runs.
 func (r *Repository) WithTransaction(ctx context.Context, fn func(*Repository) error) error {
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := scopeTransaction(ctx, tx); err != nil {
+			return err
+		}
 		return fn(&Repository{db: tx})
 	})
 }
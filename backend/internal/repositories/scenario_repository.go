@@ -11,34 +11,35 @@ import (
 	"github.com/SimpleAjax/Xephyr/internal/models"
 )
 
-// ScenarioRepository defines scenario data access operations
+// ScenarioRepository defines scenario data access operations. Every method is scoped
+// to one organization.
 type ScenarioRepository interface {
-	// Create creates a new scenario
-	Create(ctx context.Context, scenario *models.Scenario) error
+	// Create creates a new scenario in the organization
+	Create(ctx context.Context, orgID uuid.UUID, scenario *models.Scenario) error
 
 	// GetByID retrieves a scenario by ID
-	GetByID(ctx context.Context, id uuid.UUID) (*models.Scenario, error)
+	GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.Scenario, error)
 
 	// Update updates a scenario
-	Update(ctx context.Context, scenario *models.Scenario) error
+	Update(ctx context.Context, orgID uuid.UUID, scenario *models.Scenario) error
 
 	// Delete soft-deletes a scenario
-	Delete(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, orgID uuid.UUID, id uuid.UUID) error
 
 	// List retrieves scenarios with filters
 	List(ctx context.Context, orgID uuid.UUID, filters ScenarioFilters, params ListParams) ([]models.Scenario, int64, error)
 
 	// UpdateStatus updates scenario status
-	UpdateStatus(ctx context.Context, scenarioID uuid.UUID, status models.ScenarioStatus) error
+	UpdateStatus(ctx context.Context, orgID uuid.UUID, scenarioID uuid.UUID, status models.ScenarioStatus) error
 
 	// CreateImpactAnalysis creates impact analysis for a scenario
-	CreateImpactAnalysis(ctx context.Context, analysis *models.ScenarioImpactAnalysis) error
+	CreateImpactAnalysis(ctx context.Context, orgID uuid.UUID, analysis *models.ScenarioImpactAnalysis) error
 
 	// GetImpactAnalysis retrieves impact analysis for a scenario
-	GetImpactAnalysis(ctx context.Context, scenarioID uuid.UUID) (*models.ScenarioImpactAnalysis, error)
+	GetImpactAnalysis(ctx context.Context, orgID uuid.UUID, scenarioID uuid.UUID) (*models.ScenarioImpactAnalysis, error)
 
 	// UpdateImpactAnalysis updates impact analysis
-	UpdateImpactAnalysis(ctx context.Context, analysis *models.ScenarioImpactAnalysis) error
+	UpdateImpactAnalysis(ctx context.Context, orgID uuid.UUID, analysis *models.ScenarioImpactAnalysis) error
 
 	// GetByStatus retrieves scenarios by status
 	GetByStatus(ctx context.Context, orgID uuid.UUID, status models.ScenarioStatus) ([]models.Scenario, error)
@@ -61,13 +62,30 @@ func NewScenarioRepository(db *gorm.DB) ScenarioRepository {
 	return &scenarioRepository{db: db}
 }
 
-func (r *scenarioRepository) Create(ctx context.Context, scenario *models.Scenario) error {
-	return r.db.WithContext(ctx).Create(scenario).Error
+// requireScenario fails with not found unless the scenario belongs to the organization
+func (r *scenarioRepository) requireScenario(ctx context.Context, orgID, scenarioID uuid.UUID) error {
+	var count int64
+	if err := conn(ctx, r.db).
+		Model(&models.Scenario{}).
+		Where("id = ? AND organization_id = ?", scenarioID, orgID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("scenario not found: %w", gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+func (r *scenarioRepository) Create(ctx context.Context, orgID uuid.UUID, scenario *models.Scenario) error {
+	scenario.OrganizationID = orgID
+	return conn(ctx, r.db).Create(scenario).Error
 }
 
-func (r *scenarioRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Scenario, error) {
+func (r *scenarioRepository) GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.Scenario, error) {
 	var scenario models.Scenario
-	if err := r.db.WithContext(ctx).
+	if err := conn(ctx, r.db).
+		Where("organization_id = ?", orgID).
 		Preload("ImpactAnalysis").
 		Preload("CreatedBy").
 		First(&scenario, "id = ?", id).Error; err != nil {
@@ -79,19 +97,25 @@ func (r *scenarioRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 	return &scenario, nil
 }
 
-func (r *scenarioRepository) Update(ctx context.Context, scenario *models.Scenario) error {
-	return r.db.WithContext(ctx).Save(scenario).Error
+func (r *scenarioRepository) Update(ctx context.Context, orgID uuid.UUID, scenario *models.Scenario) error {
+	if err := r.requireScenario(ctx, orgID, scenario.ID); err != nil {
+		return err
+	}
+	scenario.OrganizationID = orgID
+	return conn(ctx, r.db).Save(scenario).Error
 }
 
-func (r *scenarioRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.Scenario{}, "id = ?", id).Error
+func (r *scenarioRepository) Delete(ctx context.Context, orgID uuid.UUID, id uuid.UUID) error {
+	return affectedOne(conn(ctx, r.db).
+		Where("id = ? AND organization_id = ?", id, orgID).
+		Delete(&models.Scenario{}), "scenario")
 }
 
 func (r *scenarioRepository) List(ctx context.Context, orgID uuid.UUID, filters ScenarioFilters, params ListParams) ([]models.Scenario, int64, error) {
 	var scenarios []models.Scenario
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&models.Scenario{}).Where("organization_id = ?", orgID)
+	query := conn(ctx, r.db).Model(&models.Scenario{}).Where("organization_id = ?", orgID)
 
 	// Apply filters
 	if filters.Status != nil {
@@ -114,21 +138,26 @@ func (r *scenarioRepository) List(ctx context.Context, orgID uuid.UUID, filters
 	return scenarios, total, nil
 }
 
-func (r *scenarioRepository) UpdateStatus(ctx context.Context, scenarioID uuid.UUID, status models.ScenarioStatus) error {
-	return r.db.WithContext(ctx).
+func (r *scenarioRepository) UpdateStatus(ctx context.Context, orgID uuid.UUID, scenarioID uuid.UUID, status models.ScenarioStatus) error {
+	return affectedOne(conn(ctx, r.db).
 		Model(&models.Scenario{}).
-		Where("id = ?", scenarioID).
-		Update("status", status).Error
+		Where("id = ? AND organization_id = ?", scenarioID, orgID).
+		Update("status", status), "scenario")
 }
 
-func (r *scenarioRepository) CreateImpactAnalysis(ctx context.Context, analysis *models.ScenarioImpactAnalysis) error {
-	return r.db.WithContext(ctx).Create(analysis).Error
+func (r *scenarioRepository) CreateImpactAnalysis(ctx context.Context, orgID uuid.UUID, analysis *models.ScenarioImpactAnalysis) error {
+	if err := r.requireScenario(ctx, orgID, analysis.ScenarioID); err != nil {
+		return err
+	}
+	return conn(ctx, r.db).Create(analysis).Error
 }
 
-func (r *scenarioRepository) GetImpactAnalysis(ctx context.Context, scenarioID uuid.UUID) (*models.ScenarioImpactAnalysis, error) {
+func (r *scenarioRepository) GetImpactAnalysis(ctx context.Context, orgID uuid.UUID, scenarioID uuid.UUID) (*models.ScenarioImpactAnalysis, error) {
 	var analysis models.ScenarioImpactAnalysis
-	if err := r.db.WithContext(ctx).
+	if err := conn(ctx, r.db).
 		Where("scenario_id = ?", scenarioID).
+		Where("scenario_id IN (?)", r.db.Session(&gorm.Session{NewDB: true}).
+			Model(&models.Scenario{}).Select("id").Where("organization_id = ?", orgID)).
 		First(&analysis).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("impact analysis not found: %w", err)
@@ -138,13 +167,16 @@ func (r *scenarioRepository) GetImpactAnalysis(ctx context.Context, scenarioID u
 	return &analysis, nil
 }
 
-func (r *scenarioRepository) UpdateImpactAnalysis(ctx context.Context, analysis *models.ScenarioImpactAnalysis) error {
-	return r.db.WithContext(ctx).Save(analysis).Error
+func (r *scenarioRepository) UpdateImpactAnalysis(ctx context.Context, orgID uuid.UUID, analysis *models.ScenarioImpactAnalysis) error {
+	if err := r.requireScenario(ctx, orgID, analysis.ScenarioID); err != nil {
+		return err
+	}
+	return conn(ctx, r.db).Save(analysis).Error
 }
 
 func (r *scenarioRepository) GetByStatus(ctx context.Context, orgID uuid.UUID, status models.ScenarioStatus) ([]models.Scenario, error) {
 	var scenarios []models.Scenario
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Where("organization_id = ? AND status = ?", orgID, status).
 		Find(&scenarios).Error
 	return scenarios, err
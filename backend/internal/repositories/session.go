@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Database roles created by the row-level security migration. Request handlers connect
+// as TenantRole, which owns no tables, so the policies on tenant tables always apply to
+// it. Background workers connect as BypassRole, which the policies do not filter.
+const (
+	TenantRole = "xephyr_app"
+	BypassRole = "xephyr_rls_bypass"
+)
+
+// OrganizationSetting is the session variable the row-level security policies compare
+// each row's organization against
+const OrganizationSetting = "app.organization_id"
+
+type organizationKey struct{}
+
+type txKey struct{}
+
+// ContextWithOrganization returns a context whose transactions are scoped to orgID
+func ContextWithOrganization(ctx context.Context, orgID uuid.UUID) context.Context {
+	return context.WithValue(ctx, organizationKey{}, orgID)
+}
+
+// OrganizationFromContext returns the organization set by ContextWithOrganization
+func OrganizationFromContext(ctx context.Context) (uuid.UUID, bool) {
+	orgID, ok := ctx.Value(organizationKey{}).(uuid.UUID)
+	return orgID, ok
+}
+
+// conn returns the transaction InOrganization bound to ctx, so every repository call
+// made while handling a request shares its row-level security scope. Outside such a
+// transaction it returns db.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// scopeTransaction sets the organization session variable for the rest of tx when ctx
+// names an organization. The setting is transaction-local, so it never leaks to the
+// next user of the pooled connection.
+func scopeTransaction(ctx context.Context, tx *gorm.DB) error {
+	orgID, ok := OrganizationFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return tx.Exec("SELECT set_config(?, ?, true)", OrganizationSetting, orgID.String()).Error
+}
+
+// InOrganization runs fn in a transaction scoped to orgID. Repository calls made with
+// the context passed to fn join that transaction; returning an error rolls it back.
+// Providers assembled without a database run fn directly.
+func (p *Provider) InOrganization(ctx context.Context, orgID uuid.UUID, fn func(ctx context.Context) error) error {
+	ctx = ContextWithOrganization(ctx, orgID)
+	if p.db == nil {
+		return fn(ctx)
+	}
+	return conn(ctx, p.db).Transaction(func(tx *gorm.DB) error {
+		if err := scopeTransaction(ctx, tx); err != nil {
+			return err
+		}
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
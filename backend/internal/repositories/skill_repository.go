@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// SkillRepository defines skill catalogue data access operations. An organization sees
+// the global skills and its own.
+type SkillRepository interface {
+	// ListByNames retrieves the skills visible to the organization whose names match,
+	// ignoring case
+	ListByNames(ctx context.Context, orgID uuid.UUID, names []string) ([]models.Skill, error)
+
+	// Create creates a skill owned by the organization
+	Create(ctx context.Context, orgID uuid.UUID, skill *models.Skill) error
+}
+
+// skillRepository implements SkillRepository
+type skillRepository struct {
+	db *gorm.DB
+}
+
+// NewSkillRepository creates a new skill repository
+func NewSkillRepository(db *gorm.DB) SkillRepository {
+	return &skillRepository{db: db}
+}
+
+func (r *skillRepository) ListByNames(ctx context.Context, orgID uuid.UUID, names []string) ([]models.Skill, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	lowered := make([]string, len(names))
+	for i, name := range names {
+		lowered[i] = strings.ToLower(name)
+	}
+	var skills []models.Skill
+	err := conn(ctx, r.db).
+		Where("organization_id IS NULL OR organization_id = ?", orgID).
+		Where("LOWER(name) IN ?", lowered).
+		Find(&skills).Error
+	return skills, err
+}
+
+func (r *skillRepository) Create(ctx context.Context, orgID uuid.UUID, skill *models.Skill) error {
+	skill.OrganizationID = &orgID
+	return conn(ctx, r.db).Create(skill).Error
+}
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// TaskEventRepository defines task change history data access operations. Events are
+// append-only.
+type TaskEventRepository interface {
+	// Record appends an event to a task's history
+	Record(ctx context.Context, orgID uuid.UUID, event *models.TaskEvent) error
+
+	// ListByTask retrieves a task's events, oldest first
+	ListByTask(ctx context.Context, orgID, taskID uuid.UUID) ([]models.TaskEvent, error)
+
+	// ListByTasks retrieves the events of the given tasks, oldest first, optionally only
+	// those of the given types
+	ListByTasks(ctx context.Context, orgID uuid.UUID, taskIDs []uuid.UUID, types ...models.TaskEventType) ([]models.TaskEvent, error)
+}
+
+// taskEventRepository implements TaskEventRepository
+type taskEventRepository struct {
+	db *gorm.DB
+}
+
+// NewTaskEventRepository creates a new task event repository
+func NewTaskEventRepository(db *gorm.DB) TaskEventRepository {
+	return &taskEventRepository{db: db}
+}
+
+// scoped restricts queries to the organization's events
+func (r *taskEventRepository) scoped(ctx context.Context, orgID uuid.UUID) *gorm.DB {
+	return conn(ctx, r.db).Model(&models.TaskEvent{}).Where("organization_id = ?", orgID)
+}
+
+func (r *taskEventRepository) Record(ctx context.Context, orgID uuid.UUID, event *models.TaskEvent) error {
+	event.OrganizationID = orgID
+	return conn(ctx, r.db).Create(event).Error
+}
+
+func (r *taskEventRepository) ListByTask(ctx context.Context, orgID, taskID uuid.UUID) ([]models.TaskEvent, error) {
+	var events []models.TaskEvent
+	err := r.scoped(ctx, orgID).
+		Where("task_id = ?", taskID).
+		Order("occurred_at ASC, created_at ASC").
+		Find(&events).Error
+	return events, err
+}
+
+func (r *taskEventRepository) ListByTasks(ctx context.Context, orgID uuid.UUID, taskIDs []uuid.UUID, types ...models.TaskEventType) ([]models.TaskEvent, error) {
+	if len(taskIDs) == 0 {
+		return nil, nil
+	}
+	query := r.scoped(ctx, orgID).Where("task_id IN ?", taskIDs)
+	if len(types) > 0 {
+		query = query.Where("type IN ?", types)
+	}
+	var events []models.TaskEvent
+	err := query.Order("occurred_at ASC, created_at ASC").Find(&events).Error
+	return events, err
+}
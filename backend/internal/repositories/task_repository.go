@@ -12,64 +12,95 @@ import (
 	"github.com/SimpleAjax/Xephyr/internal/models"
 )
 
-// TaskRepository defines task data access operations
+// TaskRepository defines task data access operations. Every method is scoped to one
+// organization through the task's project.
 type TaskRepository interface {
-	// Create creates a new task
-	Create(ctx context.Context, task *models.Task) error
+	// Create creates a new task; the project must belong to the organization
+	Create(ctx context.Context, orgID uuid.UUID, task *models.Task) error
 
 	// GetByID retrieves a task by ID
-	GetByID(ctx context.Context, id uuid.UUID) (*models.Task, error)
+	GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.Task, error)
 
 	// Update updates a task
-	Update(ctx context.Context, task *models.Task) error
+	Update(ctx context.Context, orgID uuid.UUID, task *models.Task) error
 
 	// Delete soft-deletes a task
-	Delete(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, orgID uuid.UUID, id uuid.UUID) error
 
 	// List retrieves tasks with pagination
-	List(ctx context.Context, params ListParams) ([]models.Task, int64, error)
+	List(ctx context.Context, orgID uuid.UUID, params ListParams) ([]models.Task, int64, error)
 
 	// ListByProject retrieves tasks in a project
-	ListByProject(ctx context.Context, projectID uuid.UUID, params ListParams) ([]models.Task, int64, error)
+	ListByProject(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, params ListParams) ([]models.Task, int64, error)
 
 	// ListByAssignee retrieves tasks assigned to a user
-	ListByAssignee(ctx context.Context, assigneeID uuid.UUID, params ListParams) ([]models.Task, int64, error)
+	ListByAssignee(ctx context.Context, orgID uuid.UUID, assigneeID uuid.UUID, params ListParams) ([]models.Task, int64, error)
 
 	// ListByStatus retrieves tasks by status
-	ListByStatus(ctx context.Context, status models.TaskStatus, params ListParams) ([]models.Task, int64, error)
+	ListByStatus(ctx context.Context, orgID uuid.UUID, status models.TaskStatus, params ListParams) ([]models.Task, int64, error)
 
 	// ListSubtasks retrieves subtasks of a task
-	ListSubtasks(ctx context.Context, parentTaskID uuid.UUID) ([]models.Task, error)
+	ListSubtasks(ctx context.Context, orgID uuid.UUID, parentTaskID uuid.UUID) ([]models.Task, error)
+
+	// ListSubtree retrieves a task and all of its descendants, parents before children
+	ListSubtree(ctx context.Context, orgID uuid.UUID, rootID uuid.UUID) ([]models.Task, error)
+
+	// UpdateHierarchy sets a task's project, parent and hierarchy level
+	UpdateHierarchy(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID, projectID uuid.UUID, parentTaskID *uuid.UUID, level int) error
+
+	// DeleteTasks soft-deletes tasks together with their skill requirements,
+	// dependencies, assignment suggestions and nudges
+	DeleteTasks(ctx context.Context, orgID uuid.UUID, taskIDs []uuid.UUID) error
+
+	// SetArchived archives tasks, or unarchives them when archivedAt is nil
+	SetArchived(ctx context.Context, orgID uuid.UUID, taskIDs []uuid.UUID, archivedAt *time.Time) error
 
 	// UpdateStatus updates task status
-	UpdateStatus(ctx context.Context, taskID uuid.UUID, status models.TaskStatus) error
+	UpdateStatus(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID, status models.TaskStatus) error
+
+	// UpdateWorkflowStatus moves a task to a workflow status and its category, writing
+	// the start and completion dates the transition sets
+	UpdateWorkflowStatus(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID, status models.TaskStatus, workflowStatus string, startDate, completedAt *time.Time) error
+
+	// UpdateDates sets a task's start and due dates
+	UpdateDates(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID, startDate, dueDate *time.Time) error
 
 	// UpdateAssignee updates task assignee
-	UpdateAssignee(ctx context.Context, taskID uuid.UUID, assigneeID *uuid.UUID) error
+	UpdateAssignee(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID, assigneeID *uuid.UUID) error
 
 	// UpdateProgress updates task progress
-	UpdateProgress(ctx context.Context, taskID uuid.UUID, progress int, actualHours float64) error
+	UpdateProgress(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID, progress int, actualHours float64) error
 
 	// UpdatePriorityScore updates the calculated priority score
-	UpdatePriorityScore(ctx context.Context, taskID uuid.UUID, score int) error
+	UpdatePriorityScore(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID, score int) error
+
+	// UpdateCriticalPath flags the given tasks of a project as on its critical path and
+	// clears the flag on the rest
+	UpdateCriticalPath(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, criticalTaskIDs []uuid.UUID) error
 
 	// MarkAsCompleted marks a task as completed
-	MarkAsCompleted(ctx context.Context, taskID uuid.UUID) error
+	MarkAsCompleted(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID) error
 
 	// ListByProjectAndStatus retrieves tasks in a project by status
-	ListByProjectAndStatus(ctx context.Context, projectID uuid.UUID, status models.TaskStatus) ([]models.Task, error)
+	ListByProjectAndStatus(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, status models.TaskStatus) ([]models.Task, error)
 
 	// CountByProject counts tasks in a project
-	CountByProject(ctx context.Context, projectID uuid.UUID) (int64, error)
+	CountByProject(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID) (int64, error)
 
 	// CountByProjectAndStatus counts tasks by project and status
-	CountByProjectAndStatus(ctx context.Context, projectID uuid.UUID, status models.TaskStatus) (int64, error)
+	CountByProjectAndStatus(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, status models.TaskStatus) (int64, error)
 
 	// GetUnassigned retrieves unassigned tasks in a project
-	GetUnassigned(ctx context.Context, projectID uuid.UUID) ([]models.Task, error)
+	GetUnassigned(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID) ([]models.Task, error)
 
 	// GetOverdue retrieves overdue tasks
 	GetOverdue(ctx context.Context, orgID uuid.UUID) ([]models.Task, error)
+
+	// ListSkillsByProject retrieves the skill requirements of all tasks in a project
+	ListSkillsByProject(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID) ([]models.TaskSkill, error)
+
+	// AddSkill adds a skill requirement to a task
+	AddSkill(ctx context.Context, orgID uuid.UUID, skill *models.TaskSkill) error
 }
 
 // taskRepository implements TaskRepository
@@ -82,13 +113,28 @@ func NewTaskRepository(db *gorm.DB) TaskRepository {
 	return &taskRepository{db: db}
 }
 
-func (r *taskRepository) Create(ctx context.Context, task *models.Task) error {
-	return r.db.WithContext(ctx).Create(task).Error
+// scoped starts a task query limited to the organization
+func (r *taskRepository) scoped(ctx context.Context, orgID uuid.UUID) *gorm.DB {
+	return conn(ctx, r.db).Model(&models.Task{}).Scopes(tasksInOrganization(r.db, orgID))
 }
 
-func (r *taskRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+func (r *taskRepository) Create(ctx context.Context, orgID uuid.UUID, task *models.Task) error {
+	ok, err := projectInOrganization(conn(ctx, r.db), orgID, task.ProjectID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("project not found: %w", gorm.ErrRecordNotFound)
+	}
+	if err := requireWritableProject(conn(ctx, r.db), orgID, task.ProjectID); err != nil {
+		return err
+	}
+	return conn(ctx, r.db).Create(task).Error
+}
+
+func (r *taskRepository) GetByID(ctx context.Context, orgID uuid.UUID, id uuid.UUID) (*models.Task, error) {
 	var task models.Task
-	if err := r.db.WithContext(ctx).
+	if err := r.scoped(ctx, orgID).
 		Preload("Assignee").
 		Preload("Skills.Skill").
 		Preload("Subtasks").
@@ -101,19 +147,39 @@ func (r *taskRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Tas
 	return &task, nil
 }
 
-func (r *taskRepository) Update(ctx context.Context, task *models.Task) error {
-	return r.db.WithContext(ctx).Save(task).Error
+func (r *taskRepository) Update(ctx context.Context, orgID uuid.UUID, task *models.Task) error {
+	// Both the stored task and the project it is moved to must be in the organization
+	ok, err := taskInOrganization(conn(ctx, r.db), orgID, task.ID)
+	if err == nil && ok {
+		ok, err = projectInOrganization(conn(ctx, r.db), orgID, task.ProjectID)
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("task not found: %w", gorm.ErrRecordNotFound)
+	}
+	if err := requireWritableTasks(conn(ctx, r.db), orgID, task.ID); err != nil {
+		return err
+	}
+	if err := requireWritableProject(conn(ctx, r.db), orgID, task.ProjectID); err != nil {
+		return err
+	}
+	return conn(ctx, r.db).Save(task).Error
 }
 
-func (r *taskRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.Task{}, "id = ?", id).Error
+func (r *taskRepository) Delete(ctx context.Context, orgID uuid.UUID, id uuid.UUID) error {
+	if err := requireWritableTasks(conn(ctx, r.db), orgID, id); err != nil {
+		return err
+	}
+	return affectedOne(r.scoped(ctx, orgID).Where("id = ?", id).Delete(&models.Task{}), "task")
 }
 
-func (r *taskRepository) List(ctx context.Context, params ListParams) ([]models.Task, int64, error) {
+func (r *taskRepository) List(ctx context.Context, orgID uuid.UUID, params ListParams) ([]models.Task, int64, error) {
 	var tasks []models.Task
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&models.Task{})
+	query := r.scoped(ctx, orgID)
 	query.Count(&total)
 
 	err := query.Scopes(Paginate(params), Sort(params)).Find(&tasks).Error
@@ -124,11 +190,11 @@ func (r *taskRepository) List(ctx context.Context, params ListParams) ([]models.
 	return tasks, total, nil
 }
 
-func (r *taskRepository) ListByProject(ctx context.Context, projectID uuid.UUID, params ListParams) ([]models.Task, int64, error) {
+func (r *taskRepository) ListByProject(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, params ListParams) ([]models.Task, int64, error) {
 	var tasks []models.Task
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&models.Task{}).Where("project_id = ?", projectID)
+	query := r.scoped(ctx, orgID).Where("project_id = ?", projectID)
 	query.Count(&total)
 
 	err := query.Scopes(Paginate(params), Sort(params)).Find(&tasks).Error
@@ -139,11 +205,11 @@ func (r *taskRepository) ListByProject(ctx context.Context, projectID uuid.UUID,
 	return tasks, total, nil
 }
 
-func (r *taskRepository) ListByAssignee(ctx context.Context, assigneeID uuid.UUID, params ListParams) ([]models.Task, int64, error) {
+func (r *taskRepository) ListByAssignee(ctx context.Context, orgID uuid.UUID, assigneeID uuid.UUID, params ListParams) ([]models.Task, int64, error) {
 	var tasks []models.Task
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&models.Task{}).Where("assignee_id = ?", assigneeID)
+	query := r.scoped(ctx, orgID).Where("assignee_id = ?", assigneeID)
 	query.Count(&total)
 
 	err := query.Scopes(Paginate(params), Sort(params)).Find(&tasks).Error
@@ -154,11 +220,11 @@ func (r *taskRepository) ListByAssignee(ctx context.Context, assigneeID uuid.UUI
 	return tasks, total, nil
 }
 
-func (r *taskRepository) ListByStatus(ctx context.Context, status models.TaskStatus, params ListParams) ([]models.Task, int64, error) {
+func (r *taskRepository) ListByStatus(ctx context.Context, orgID uuid.UUID, status models.TaskStatus, params ListParams) ([]models.Task, int64, error) {
 	var tasks []models.Task
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&models.Task{}).Where("status = ?", status)
+	query := r.scoped(ctx, orgID).Where("status = ?", status)
 	query.Count(&total)
 
 	err := query.Scopes(Paginate(params), Sort(params)).Find(&tasks).Error
@@ -169,15 +235,132 @@ func (r *taskRepository) ListByStatus(ctx context.Context, status models.TaskSta
 	return tasks, total, nil
 }
 
-func (r *taskRepository) ListSubtasks(ctx context.Context, parentTaskID uuid.UUID) ([]models.Task, error) {
+func (r *taskRepository) ListSubtasks(ctx context.Context, orgID uuid.UUID, parentTaskID uuid.UUID) ([]models.Task, error) {
 	var tasks []models.Task
-	err := r.db.WithContext(ctx).
+	err := r.scoped(ctx, orgID).
 		Where("parent_task_id = ?", parentTaskID).
 		Find(&tasks).Error
 	return tasks, err
 }
 
-func (r *taskRepository) UpdateStatus(ctx context.Context, taskID uuid.UUID, status models.TaskStatus) error {
+func (r *taskRepository) ListSubtree(ctx context.Context, orgID uuid.UUID, rootID uuid.UUID) ([]models.Task, error) {
+	var root models.Task
+	if err := r.scoped(ctx, orgID).First(&root, "id = ?", rootID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("task not found: %w", err)
+		}
+		return nil, err
+	}
+
+	// One query per level; the hierarchy is at most models.MaxHierarchyLevel deep
+	tasks := []models.Task{root}
+	seen := map[uuid.UUID]bool{root.ID: true}
+	level := []uuid.UUID{root.ID}
+	for len(level) > 0 {
+		var children []models.Task
+		if err := r.scoped(ctx, orgID).
+			Where("parent_task_id IN ?", level).
+			Order("created_at ASC").
+			Find(&children).Error; err != nil {
+			return nil, err
+		}
+		level = nil
+		for _, child := range children {
+			// Hierarchies written before parents were validated may loop
+			if seen[child.ID] {
+				continue
+			}
+			seen[child.ID] = true
+			tasks = append(tasks, child)
+			level = append(level, child.ID)
+		}
+	}
+	return tasks, nil
+}
+
+func (r *taskRepository) UpdateHierarchy(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID, projectID uuid.UUID, parentTaskID *uuid.UUID, level int) error {
+	// The task, its new project and its new parent must all be in the organization
+	ok, err := projectInOrganization(conn(ctx, r.db), orgID, projectID)
+	if err == nil && ok && parentTaskID != nil {
+		ok, err = taskInOrganization(conn(ctx, r.db), orgID, *parentTaskID)
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("task not found: %w", gorm.ErrRecordNotFound)
+	}
+	if err := requireWritableTasks(conn(ctx, r.db), orgID, taskID); err != nil {
+		return err
+	}
+	if err := requireWritableProject(conn(ctx, r.db), orgID, projectID); err != nil {
+		return err
+	}
+	return affectedOne(r.scoped(ctx, orgID).
+		Where("id = ?", taskID).
+		Updates(map[string]interface{}{
+			"project_id":      projectID,
+			"parent_task_id":  parentTaskID,
+			"hierarchy_level": level,
+		}), "task")
+}
+
+func (r *taskRepository) DeleteTasks(ctx context.Context, orgID uuid.UUID, taskIDs []uuid.UUID) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	db := conn(ctx, r.db)
+	var count int64
+	if err := r.scoped(ctx, orgID).Where("id IN ?", taskIDs).Count(&count).Error; err != nil {
+		return err
+	}
+	if count != int64(len(taskIDs)) {
+		return fmt.Errorf("task not found: %w", gorm.ErrRecordNotFound)
+	}
+	if err := requireWritableTasks(db, orgID, taskIDs...); err != nil {
+		return err
+	}
+
+	deletedAt := time.Now()
+	children := []struct {
+		model interface{}
+		where string
+		args  []interface{}
+	}{
+		{&models.TaskSkill{}, "task_id IN ?", []interface{}{taskIDs}},
+		{&models.TaskDependency{}, "task_id IN ? OR depends_on_task_id IN ?", []interface{}{taskIDs, taskIDs}},
+		{&models.AssignmentSuggestion{}, "task_id IN ?", []interface{}{taskIDs}},
+		{&models.Nudge{}, "related_task_id IN ?", []interface{}{taskIDs}},
+		{&models.Task{}, "id IN ?", []interface{}{taskIDs}},
+	}
+	for _, c := range children {
+		if err := db.Model(c.model).
+			Where(c.where, c.args...).
+			Update("deleted_at", deletedAt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *taskRepository) SetArchived(ctx context.Context, orgID uuid.UUID, taskIDs []uuid.UUID, archivedAt *time.Time) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	if err := requireTasksInWritableProjects(conn(ctx, r.db), orgID, taskIDs...); err != nil {
+		return err
+	}
+	result := r.scoped(ctx, orgID).Where("id IN ?", taskIDs).Update("archived_at", archivedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != int64(len(taskIDs)) {
+		return fmt.Errorf("task not found: %w", gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+func (r *taskRepository) UpdateStatus(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID, status models.TaskStatus) error {
 	updates := map[string]interface{}{
 		"status": status,
 	}
@@ -185,80 +368,126 @@ func (r *taskRepository) UpdateStatus(ctx context.Context, taskID uuid.UUID, sta
 	if status == models.TaskStatusDone {
 		now := time.Now()
 		updates["completed_at"] = &now
-		updates["progress"] = 100
+		updates["progress_percentage"] = 100
 	}
 	
-	return r.db.WithContext(ctx).
-		Model(&models.Task{}).
+	if err := requireWritableTasks(conn(ctx, r.db), orgID, taskID); err != nil {
+		return err
+	}
+	return affectedOne(r.scoped(ctx, orgID).
 		Where("id = ?", taskID).
-		Updates(updates).Error
+		Updates(updates), "task")
 }
 
-func (r *taskRepository) UpdateAssignee(ctx context.Context, taskID uuid.UUID, assigneeID *uuid.UUID) error {
-	return r.db.WithContext(ctx).
-		Model(&models.Task{}).
+func (r *taskRepository) UpdateWorkflowStatus(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID, status models.TaskStatus, workflowStatus string, startDate, completedAt *time.Time) error {
+	updates := map[string]interface{}{
+		"status":          status,
+		"workflow_status": workflowStatus,
+		"start_date":      startDate,
+		"completed_at":    completedAt,
+	}
+	if status == models.TaskStatusDone {
+		updates["progress_percentage"] = 100
+	}
+
+	if err := requireWritableTasks(conn(ctx, r.db), orgID, taskID); err != nil {
+		return err
+	}
+	return affectedOne(r.scoped(ctx, orgID).
 		Where("id = ?", taskID).
-		Update("assignee_id", assigneeID).Error
+		Updates(updates), "task")
 }
 
-func (r *taskRepository) UpdateProgress(ctx context.Context, taskID uuid.UUID, progress int, actualHours float64) error {
-	return r.db.WithContext(ctx).
-		Model(&models.Task{}).
+func (r *taskRepository) UpdateAssignee(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID, assigneeID *uuid.UUID) error {
+	if err := requireWritableTasks(conn(ctx, r.db), orgID, taskID); err != nil {
+		return err
+	}
+	return affectedOne(r.scoped(ctx, orgID).
+		Where("id = ?", taskID).
+		Update("assignee_id", assigneeID), "task")
+}
+
+func (r *taskRepository) UpdateProgress(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID, progress int, actualHours float64) error {
+	if err := requireWritableTasks(conn(ctx, r.db), orgID, taskID); err != nil {
+		return err
+	}
+	return affectedOne(r.scoped(ctx, orgID).
 		Where("id = ?", taskID).
 		Updates(map[string]interface{}{
 			"progress_percentage": progress,
 			"actual_hours":        actualHours,
-		}).Error
+		}), "task")
 }
 
-func (r *taskRepository) UpdatePriorityScore(ctx context.Context, taskID uuid.UUID, score int) error {
-	return r.db.WithContext(ctx).
-		Model(&models.Task{}).
+func (r *taskRepository) UpdateDates(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID, startDate, dueDate *time.Time) error {
+	if err := requireWritableTasks(conn(ctx, r.db), orgID, taskID); err != nil {
+		return err
+	}
+	return affectedOne(r.scoped(ctx, orgID).
 		Where("id = ?", taskID).
-		Update("priority_score", score).Error
+		Updates(map[string]interface{}{
+			"start_date": startDate,
+			"due_date":   dueDate,
+		}), "task")
+}
+
+func (r *taskRepository) UpdatePriorityScore(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID, score int) error {
+	return affectedOne(r.scoped(ctx, orgID).
+		Where("id = ?", taskID).
+		Update("priority_score", score), "task")
+}
+
+func (r *taskRepository) UpdateCriticalPath(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, criticalTaskIDs []uuid.UUID) error {
+	var critical interface{} = false
+	if len(criticalTaskIDs) > 0 {
+		critical = gorm.Expr("id IN ?", criticalTaskIDs)
+	}
+	return r.scoped(ctx, orgID).
+		Where("project_id = ?", projectID).
+		Update("is_critical_path", critical).Error
 }
 
-func (r *taskRepository) MarkAsCompleted(ctx context.Context, taskID uuid.UUID) error {
+func (r *taskRepository) MarkAsCompleted(ctx context.Context, orgID uuid.UUID, taskID uuid.UUID) error {
+	if err := requireWritableTasks(conn(ctx, r.db), orgID, taskID); err != nil {
+		return err
+	}
 	now := time.Now()
-	return r.db.WithContext(ctx).
-		Model(&models.Task{}).
+	return affectedOne(r.scoped(ctx, orgID).
 		Where("id = ?", taskID).
 		Updates(map[string]interface{}{
-			"status":       models.TaskStatusDone,
-			"completed_at": &now,
-			"progress":     100,
-		}).Error
+			"status":              models.TaskStatusDone,
+			"completed_at":        &now,
+			"progress_percentage": 100,
+		}), "task")
 }
 
-func (r *taskRepository) ListByProjectAndStatus(ctx context.Context, projectID uuid.UUID, status models.TaskStatus) ([]models.Task, error) {
+func (r *taskRepository) ListByProjectAndStatus(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, status models.TaskStatus) ([]models.Task, error) {
 	var tasks []models.Task
-	err := r.db.WithContext(ctx).
+	err := r.scoped(ctx, orgID).
 		Where("project_id = ? AND status = ?", projectID, status).
 		Find(&tasks).Error
 	return tasks, err
 }
 
-func (r *taskRepository) CountByProject(ctx context.Context, projectID uuid.UUID) (int64, error) {
+func (r *taskRepository) CountByProject(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID) (int64, error) {
 	var count int64
-	err := r.db.WithContext(ctx).
-		Model(&models.Task{}).
+	err := r.scoped(ctx, orgID).
 		Where("project_id = ?", projectID).
 		Count(&count).Error
 	return count, err
 }
 
-func (r *taskRepository) CountByProjectAndStatus(ctx context.Context, projectID uuid.UUID, status models.TaskStatus) (int64, error) {
+func (r *taskRepository) CountByProjectAndStatus(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID, status models.TaskStatus) (int64, error) {
 	var count int64
-	err := r.db.WithContext(ctx).
-		Model(&models.Task{}).
+	err := r.scoped(ctx, orgID).
 		Where("project_id = ? AND status = ?", projectID, status).
 		Count(&count).Error
 	return count, err
 }
 
-func (r *taskRepository) GetUnassigned(ctx context.Context, projectID uuid.UUID) ([]models.Task, error) {
+func (r *taskRepository) GetUnassigned(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID) ([]models.Task, error) {
 	var tasks []models.Task
-	err := r.db.WithContext(ctx).
+	err := r.scoped(ctx, orgID).
 		Where("project_id = ? AND assignee_id IS NULL", projectID).
 		Find(&tasks).Error
 	return tasks, err
@@ -267,10 +496,35 @@ func (r *taskRepository) GetUnassigned(ctx context.Context, projectID uuid.UUID)
 func (r *taskRepository) GetOverdue(ctx context.Context, orgID uuid.UUID) ([]models.Task, error) {
 	var tasks []models.Task
 	now := time.Now()
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Joins("JOIN projects ON tasks.project_id = projects.id").
 		Where("projects.organization_id = ?", orgID).
 		Where("tasks.due_date < ? AND tasks.status != ?", now, models.TaskStatusDone).
 		Find(&tasks).Error
 	return tasks, err
 }
+
+func (r *taskRepository) ListSkillsByProject(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID) ([]models.TaskSkill, error) {
+	var skills []models.TaskSkill
+	err := conn(ctx, r.db).
+		Preload("Skill").
+		Joins("JOIN tasks ON task_skills.task_id = tasks.id").
+		Where("tasks.project_id = ? AND tasks.deleted_at IS NULL", projectID).
+		Scopes(tasksInOrganization(r.db, orgID)).
+		Find(&skills).Error
+	return skills, err
+}
+
+func (r *taskRepository) AddSkill(ctx context.Context, orgID uuid.UUID, skill *models.TaskSkill) error {
+	ok, err := taskInOrganization(conn(ctx, r.db), orgID, skill.TaskID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("task not found: %w", gorm.ErrRecordNotFound)
+	}
+	if err := requireWritableTasks(conn(ctx, r.db), orgID, skill.TaskID); err != nil {
+		return err
+	}
+	return conn(ctx, r.db).Create(skill).Error
+}
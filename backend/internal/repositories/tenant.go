@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// Tenant scoping
+//
+// Every query on organization-owned data takes the caller's organization ID.
+// Projects, nudges and scenarios carry organization_id themselves; tasks are scoped
+// through their project and dependencies through their task. A record in another
+// organization behaves exactly like a missing one, so handlers answer 404 without
+// revealing that the ID exists.
+
+// organizationProjectIDs selects the IDs of the projects an organization owns
+func organizationProjectIDs(db *gorm.DB, orgID uuid.UUID) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.Project{}).
+		Select("id").
+		Where("organization_id = ?", orgID)
+}
+
+// organizationTaskIDs selects the IDs of the tasks in an organization's projects
+func organizationTaskIDs(db *gorm.DB, orgID uuid.UUID) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.Task{}).
+		Select("id").
+		Where("project_id IN (?)", organizationProjectIDs(db, orgID))
+}
+
+// tasksInOrganization limits a task query to the organization's projects
+func tasksInOrganization(db *gorm.DB, orgID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(query *gorm.DB) *gorm.DB {
+		return query.Where("tasks.project_id IN (?)", organizationProjectIDs(db, orgID))
+	}
+}
+
+// dependenciesInOrganization limits a dependency query to dependencies whose tasks
+// both belong to the organization
+func dependenciesInOrganization(db *gorm.DB, orgID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(query *gorm.DB) *gorm.DB {
+		return query.
+			Where("task_dependencies.task_id IN (?)", organizationTaskIDs(db, orgID)).
+			Where("task_dependencies.depends_on_task_id IN (?)", organizationTaskIDs(db, orgID))
+	}
+}
+
+// projectInOrganization reports whether a project belongs to the organization
+func projectInOrganization(db *gorm.DB, orgID, projectID uuid.UUID) (bool, error) {
+	var count int64
+	err := db.Model(&models.Project{}).
+		Where("id = ? AND organization_id = ?", projectID, orgID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// taskInOrganization reports whether a task belongs to one of the organization's projects
+func taskInOrganization(db *gorm.DB, orgID, taskID uuid.UUID) (bool, error) {
+	var count int64
+	err := db.Model(&models.Task{}).
+		Scopes(tasksInOrganization(db, orgID)).
+		Where("id = ?", taskID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// affectedOne turns an update or delete that matched nothing into a not-found error,
+// which is what a write against another organization's record looks like
+func affectedOne(result *gorm.DB, resource string) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s not found: %w", resource, gorm.ErrRecordNotFound)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -40,8 +41,18 @@ type UserRepository interface {
 	// UpdatePassword updates user password
 	UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string) error
 
+	// RecordFailedLogin counts a failed login and locks the account until lockUntil once
+	// maxAttempts consecutive failures are reached. It returns the new lock, if any.
+	RecordFailedLogin(ctx context.Context, userID uuid.UUID, maxAttempts int, lockUntil time.Time) (*time.Time, error)
+
+	// ClearFailedLogins resets the failed login count and any lock
+	ClearFailedLogins(ctx context.Context, userID uuid.UUID) error
+
 	// Exists checks if a user exists
 	Exists(ctx context.Context, id uuid.UUID) (bool, error)
+
+	// ListSkillsByOrganization retrieves the skills of all members of an organization
+	ListSkillsByOrganization(ctx context.Context, orgID uuid.UUID) ([]models.UserSkill, error)
 }
 
 // userRepository implements UserRepository
@@ -55,12 +66,12 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 }
 
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	return conn(ctx, r.db).Create(user).Error
 }
 
 func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	var user models.User
-	if err := r.db.WithContext(ctx).Preload("Skills.Skill").First(&user, "id = ?", id).Error; err != nil {
+	if err := conn(ctx, r.db).Preload("Skills.Skill").First(&user, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("user not found: %w", err)
 		}
@@ -71,7 +82,7 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	if err := r.db.WithContext(ctx).First(&user, "email = ?", email).Error; err != nil {
+	if err := conn(ctx, r.db).First(&user, "email = ?", email).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("user not found: %w", err)
 		}
@@ -81,18 +92,18 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 }
 
 func (r *userRepository) Update(ctx context.Context, user *models.User) error {
-	return r.db.WithContext(ctx).Save(user).Error
+	return conn(ctx, r.db).Save(user).Error
 }
 
 func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.User{}, "id = ?", id).Error
+	return conn(ctx, r.db).Delete(&models.User{}, "id = ?", id).Error
 }
 
 func (r *userRepository) List(ctx context.Context, params ListParams) ([]models.User, int64, error) {
 	var users []models.User
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&models.User{})
+	query := conn(ctx, r.db).Model(&models.User{})
 	query.Count(&total)
 
 	err := query.Scopes(Paginate(params), Sort(params)).Find(&users).Error
@@ -107,7 +118,7 @@ func (r *userRepository) ListByOrganization(ctx context.Context, orgID uuid.UUID
 	var users []models.User
 	var total int64
 
-	query := r.db.WithContext(ctx).
+	query := conn(ctx, r.db).
 		Model(&models.User{}).
 		Joins("JOIN organization_members ON users.id = organization_members.user_id").
 		Where("organization_members.organization_id = ?", orgID)
@@ -124,7 +135,7 @@ func (r *userRepository) ListByOrganization(ctx context.Context, orgID uuid.UUID
 
 func (r *userRepository) GetByOrganizationAndRole(ctx context.Context, orgID uuid.UUID, role models.UserRole) ([]models.User, error) {
 	var users []models.User
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Model(&models.User{}).
 		Joins("JOIN organization_members ON users.id = organization_members.user_id").
 		Where("organization_members.organization_id = ? AND organization_members.role = ?", orgID, role).
@@ -133,14 +144,55 @@ func (r *userRepository) GetByOrganizationAndRole(ctx context.Context, orgID uui
 }
 
 func (r *userRepository) UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string) error {
-	return r.db.WithContext(ctx).
+	return conn(ctx, r.db).
 		Model(&models.User{}).
 		Where("id = ?", userID).
 		Update("password_hash", passwordHash).Error
 }
 
+func (r *userRepository) RecordFailedLogin(ctx context.Context, userID uuid.UUID, maxAttempts int, lockUntil time.Time) (*time.Time, error) {
+	var user models.User
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).
+			Where("id = ?", userID).
+			Update("failed_login_attempts", gorm.Expr("failed_login_attempts + 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.Select("id", "failed_login_attempts").First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		if user.FailedLoginAttempts < maxAttempts {
+			return nil
+		}
+		user.LockedUntil = &lockUntil
+		return tx.Model(&models.User{}).
+			Where("id = ?", userID).
+			Updates(map[string]interface{}{"failed_login_attempts": 0, "locked_until": lockUntil}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user.LockedUntil, nil
+}
+
+func (r *userRepository) ClearFailedLogins(ctx context.Context, userID uuid.UUID) error {
+	return conn(ctx, r.db).
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{"failed_login_attempts": 0, "locked_until": nil}).Error
+}
+
 func (r *userRepository) Exists(ctx context.Context, id uuid.UUID) (bool, error) {
 	var count int64
-	err := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Count(&count).Error
+	err := conn(ctx, r.db).Model(&models.User{}).Where("id = ?", id).Count(&count).Error
 	return count > 0, err
 }
+
+func (r *userRepository) ListSkillsByOrganization(ctx context.Context, orgID uuid.UUID) ([]models.UserSkill, error) {
+	var skills []models.UserSkill
+	err := conn(ctx, r.db).
+		Joins("JOIN organization_members ON user_skills.user_id = organization_members.user_id").
+		Where("organization_members.organization_id = ? AND organization_members.deleted_at IS NULL", orgID).
+		Find(&skills).Error
+	return skills, err
+}
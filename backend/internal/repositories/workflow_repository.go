@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// WorkflowRepository defines task workflow data access operations. Every method is
+// scoped to one organization.
+type WorkflowRepository interface {
+	// GetByProject retrieves a project's workflow with its statuses and transitions
+	GetByProject(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID) (*models.Workflow, error)
+
+	// Replace saves a project's workflow with its statuses and transitions, soft-deleting
+	// the one it replaces
+	Replace(ctx context.Context, orgID uuid.UUID, workflow *models.Workflow) error
+
+	// DeleteByProject soft-deletes a project's workflow, returning it to the default
+	DeleteByProject(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID) error
+}
+
+// workflowRepository implements WorkflowRepository
+type workflowRepository struct {
+	db *gorm.DB
+}
+
+// NewWorkflowRepository creates a new workflow repository
+func NewWorkflowRepository(db *gorm.DB) WorkflowRepository {
+	return &workflowRepository{db: db}
+}
+
+func (r *workflowRepository) scoped(ctx context.Context, orgID uuid.UUID) *gorm.DB {
+	return conn(ctx, r.db).Model(&models.Workflow{}).Where("organization_id = ?", orgID)
+}
+
+func (r *workflowRepository) GetByProject(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID) (*models.Workflow, error) {
+	var workflow models.Workflow
+	err := r.scoped(ctx, orgID).
+		Preload("Statuses", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Preload("Transitions").
+		First(&workflow, "project_id = ?", projectID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("workflow not found: %w", err)
+		}
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+func (r *workflowRepository) Replace(ctx context.Context, orgID uuid.UUID, workflow *models.Workflow) error {
+	ok, err := projectInOrganization(conn(ctx, r.db), orgID, workflow.ProjectID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("project not found: %w", gorm.ErrRecordNotFound)
+	}
+	if err := requireWritableProject(conn(ctx, r.db), orgID, workflow.ProjectID); err != nil {
+		return err
+	}
+	workflow.OrganizationID = orgID
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := r.deleteByProject(tx, orgID, workflow.ProjectID); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(workflow).Error; err != nil {
+			return err
+		}
+		for i := range workflow.Statuses {
+			workflow.Statuses[i].WorkflowID = workflow.ID
+			if err := tx.Create(&workflow.Statuses[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range workflow.Transitions {
+			workflow.Transitions[i].WorkflowID = workflow.ID
+			if err := tx.Create(&workflow.Transitions[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *workflowRepository) DeleteByProject(ctx context.Context, orgID uuid.UUID, projectID uuid.UUID) error {
+	if err := requireWritableProject(conn(ctx, r.db), orgID, projectID); err != nil {
+		return err
+	}
+	return r.deleteByProject(conn(ctx, r.db), orgID, projectID)
+}
+
+// deleteByProject soft-deletes the project's workflow, if it has one, with its
+// statuses and transitions
+func (r *workflowRepository) deleteByProject(db *gorm.DB, orgID, projectID uuid.UUID) error {
+	deletedAt := time.Now().UTC()
+	workflows := db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.Workflow{}).
+		Select("id").
+		Where("organization_id = ? AND project_id = ?", orgID, projectID)
+	if err := db.Model(&models.WorkflowStatus{}).Where("workflow_id IN (?)", workflows).Update("deleted_at", deletedAt).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&models.WorkflowTransition{}).Where("workflow_id IN (?)", workflows).Update("deleted_at", deletedAt).Error; err != nil {
+		return err
+	}
+	return db.Model(&models.Workflow{}).
+		Where("organization_id = ? AND project_id = ?", orgID, projectID).
+		Update("deleted_at", deletedAt).Error
+}
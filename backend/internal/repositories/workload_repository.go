@@ -16,19 +16,19 @@ type WorkloadRepository interface {
 	CreateOrUpdate(ctx context.Context, entry *models.WorkloadEntry) error
 
 	// GetByUserAndWeek retrieves workload for a user in a specific week
-	GetByUserAndWeek(ctx context.Context, userID uuid.UUID, weekStart time.Time) (*models.WorkloadEntry, error)
+	GetByUserAndWeek(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, weekStart time.Time) (*models.WorkloadEntry, error)
 
 	// ListByOrganization retrieves workload entries for an organization
 	ListByOrganization(ctx context.Context, orgID uuid.UUID, weekStart time.Time) ([]models.WorkloadEntry, error)
 
 	// ListByUser retrieves workload history for a user
-	ListByUser(ctx context.Context, userID uuid.UUID, fromDate, toDate time.Time) ([]models.WorkloadEntry, error)
+	ListByUser(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, fromDate, toDate time.Time) ([]models.WorkloadEntry, error)
 
 	// GetTeamWorkload retrieves aggregated team workload
 	GetTeamWorkload(ctx context.Context, orgID uuid.UUID, weekStart time.Time) (*TeamWorkload, error)
 
 	// UpdateAllocation updates user's allocation percentage
-	UpdateAllocation(ctx context.Context, userID uuid.UUID, weekStart time.Time, allocation int) error
+	UpdateAllocation(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, weekStart time.Time, allocation int) error
 
 	// DeleteOldEntries removes workload entries older than threshold
 	DeleteOldEntries(ctx context.Context, olderThan time.Duration) error
@@ -65,9 +65,9 @@ func NewWorkloadRepository(db *gorm.DB) WorkloadRepository {
 
 func (r *workloadRepository) CreateOrUpdate(ctx context.Context, entry *models.WorkloadEntry) error {
 	// Try to update existing entry
-	result := r.db.WithContext(ctx).
+	result := conn(ctx, r.db).
 		Model(&models.WorkloadEntry{}).
-		Where("user_id = ? AND week_start = ?", entry.UserID, entry.WeekStart).
+		Where("organization_id = ? AND user_id = ? AND week_start = ?", entry.OrganizationID, entry.UserID, entry.WeekStart).
 		Updates(map[string]interface{}{
 			"allocation_percentage": entry.AllocationPercentage,
 			"assigned_tasks":        entry.AssignedTasks,
@@ -81,17 +81,17 @@ func (r *workloadRepository) CreateOrUpdate(ctx context.Context, entry *models.W
 
 	// If no rows updated, create new
 	if result.RowsAffected == 0 {
-		return r.db.WithContext(ctx).Create(entry).Error
+		return conn(ctx, r.db).Create(entry).Error
 	}
 
 	return nil
 }
 
-func (r *workloadRepository) GetByUserAndWeek(ctx context.Context, userID uuid.UUID, weekStart time.Time) (*models.WorkloadEntry, error) {
+func (r *workloadRepository) GetByUserAndWeek(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, weekStart time.Time) (*models.WorkloadEntry, error) {
 	var entry models.WorkloadEntry
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Preload("User").
-		Where("user_id = ? AND week_start = ?", userID, weekStart).
+		Where("organization_id = ? AND user_id = ? AND week_start = ?", orgID, userID, weekStart).
 		First(&entry).Error
 	if err != nil {
 		return nil, err
@@ -101,17 +101,17 @@ func (r *workloadRepository) GetByUserAndWeek(ctx context.Context, userID uuid.U
 
 func (r *workloadRepository) ListByOrganization(ctx context.Context, orgID uuid.UUID, weekStart time.Time) ([]models.WorkloadEntry, error) {
 	var entries []models.WorkloadEntry
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Preload("User").
 		Where("organization_id = ? AND week_start = ?", orgID, weekStart).
 		Find(&entries).Error
 	return entries, err
 }
 
-func (r *workloadRepository) ListByUser(ctx context.Context, userID uuid.UUID, fromDate, toDate time.Time) ([]models.WorkloadEntry, error) {
+func (r *workloadRepository) ListByUser(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, fromDate, toDate time.Time) ([]models.WorkloadEntry, error) {
 	var entries []models.WorkloadEntry
-	err := r.db.WithContext(ctx).
-		Where("user_id = ? AND week_start BETWEEN ? AND ?", userID, fromDate, toDate).
+	err := conn(ctx, r.db).
+		Where("organization_id = ? AND user_id = ? AND week_start BETWEEN ? AND ?", orgID, userID, fromDate, toDate).
 		Order("week_start ASC").
 		Find(&entries).Error
 	return entries, err
@@ -119,7 +119,7 @@ func (r *workloadRepository) ListByUser(ctx context.Context, userID uuid.UUID, f
 
 func (r *workloadRepository) GetTeamWorkload(ctx context.Context, orgID uuid.UUID, weekStart time.Time) (*TeamWorkload, error) {
 	var entries []models.WorkloadEntry
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Preload("User").
 		Where("organization_id = ? AND week_start = ?", orgID, weekStart).
 		Find(&entries).Error
@@ -155,16 +155,16 @@ func (r *workloadRepository) GetTeamWorkload(ctx context.Context, orgID uuid.UUI
 	return tw, nil
 }
 
-func (r *workloadRepository) UpdateAllocation(ctx context.Context, userID uuid.UUID, weekStart time.Time, allocation int) error {
-	return r.db.WithContext(ctx).
+func (r *workloadRepository) UpdateAllocation(ctx context.Context, orgID uuid.UUID, userID uuid.UUID, weekStart time.Time, allocation int) error {
+	return conn(ctx, r.db).
 		Model(&models.WorkloadEntry{}).
-		Where("user_id = ? AND week_start = ?", userID, weekStart).
+		Where("organization_id = ? AND user_id = ? AND week_start = ?", orgID, userID, weekStart).
 		Update("allocation_percentage", allocation).Error
 }
 
 func (r *workloadRepository) DeleteOldEntries(ctx context.Context, olderThan time.Duration) error {
 	cutoff := time.Now().Add(-olderThan)
-	return r.db.WithContext(ctx).
+	return conn(ctx, r.db).
 		Where("week_start < ?", cutoff).
 		Delete(&models.WorkloadEntry{}).Error
 }
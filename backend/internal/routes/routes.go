@@ -6,6 +6,7 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 
 	"github.com/SimpleAjax/Xephyr/internal/controllers"
+	"github.com/SimpleAjax/Xephyr/internal/jobs"
 	"github.com/SimpleAjax/Xephyr/internal/middleware"
 	"github.com/SimpleAjax/Xephyr/internal/repositories"
 	"github.com/SimpleAjax/Xephyr/internal/services"
@@ -27,9 +28,14 @@ func NewRouter(
 	scenarioCtrl *controllers.ScenarioController,
 	workloadCtrl *controllers.WorkloadController,
 	projectCtrl *controllers.ProjectController,
+	templateCtrl *controllers.ProjectTemplateController,
 	taskCtrl *controllers.TaskController,
 	userCtrl *controllers.UserController,
 	skillCtrl *controllers.SkillController,
+	jobCtrl *controllers.JobController,
+	authCtrl *controllers.AuthController,
+	membershipCtrl *controllers.MembershipController,
+	analyticsCtrl *controllers.AnalyticsController,
 	authMiddleware *middleware.AuthMiddleware,
 	orgMiddleware *middleware.OrganizationMiddleware,
 ) *Router {
@@ -49,6 +55,12 @@ func NewRouter(
 	// Swagger documentation (no auth required)
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Auth endpoints sit outside the authenticated API group
+	registerAuthRoutes(router.Group("/api/v1/auth"), authCtrl, authMiddleware)
+
+	// Invitees answer invitations before they belong to the organization
+	registerInvitationRoutes(router.Group("/api/v1/invitations"), membershipCtrl, authMiddleware)
+
 	// API v1 group
 	v1 := router.Group("/api/v1")
 	{
@@ -57,18 +69,22 @@ func NewRouter(
 		v1.Use(orgMiddleware.RequireOrganization())
 
 		// Register all module routes
-		registerPriorityRoutes(v1, priorityCtrl)
+		registerPriorityRoutes(v1, priorityCtrl, authMiddleware)
 		registerHealthRoutes(v1, healthCtrl)
-		registerNudgeRoutes(v1, nudgeCtrl)
-		registerProgressRoutes(v1, progressCtrl)
-		registerDependencyRoutes(v1, dependencyCtrl)
-		registerAssignmentRoutes(v1, assignmentCtrl)
-		registerScenarioRoutes(v1, scenarioCtrl)
+		registerNudgeRoutes(v1, nudgeCtrl, authMiddleware)
+		registerProgressRoutes(v1, progressCtrl, orgMiddleware)
+		registerDependencyRoutes(v1, dependencyCtrl, authMiddleware, orgMiddleware)
+		registerAssignmentRoutes(v1, assignmentCtrl, authMiddleware, orgMiddleware)
+		registerScenarioRoutes(v1, scenarioCtrl, authMiddleware)
 		registerWorkloadRoutes(v1, workloadCtrl)
-		registerProjectRoutes(v1, projectCtrl)
-		registerTaskRoutes(v1, taskCtrl)
+		registerProjectRoutes(v1, projectCtrl, authMiddleware)
+		registerProjectTemplateRoutes(v1, templateCtrl, authMiddleware)
+		registerTaskRoutes(v1, taskCtrl, authMiddleware, orgMiddleware)
 		registerUserRoutes(v1, userCtrl)
+		registerMembershipRoutes(v1, membershipCtrl, authMiddleware)
 		registerSkillRoutes(v1, skillCtrl)
+		registerJobRoutes(v1, jobCtrl)
+		registerAnalyticsRoutes(v1, analyticsCtrl, orgMiddleware)
 	}
 
 	// Handle 404s
@@ -84,7 +100,7 @@ func (r *Router) GetEngine() *gin.Engine {
 }
 
 // registerPriorityRoutes registers priority module routes
-func registerPriorityRoutes(rg *gin.RouterGroup, ctrl *controllers.PriorityController) {
+func registerPriorityRoutes(rg *gin.RouterGroup, ctrl *controllers.PriorityController, authMiddleware *middleware.AuthMiddleware) {
 	priorities := rg.Group("/priorities")
 	{
 		// Task priorities
@@ -95,7 +111,12 @@ func registerPriorityRoutes(rg *gin.RouterGroup, ctrl *controllers.PriorityContr
 		priorities.GET("/projects/:projectId/ranking", ctrl.GetProjectTaskRanking)
 
 		// Recalculation
-		priorities.POST("/recalculate", ctrl.RecalculatePriorities)
+		priorities.POST("/recalculate", authMiddleware.RequirePermission(middleware.PermissionRunAnalysis), ctrl.RecalculatePriorities)
+
+		// Scoring configuration
+		priorities.GET("/config", ctrl.GetPriorityConfig)
+		priorities.PUT("/config", authMiddleware.RequirePermission(middleware.PermissionConfigureOrganization), ctrl.UpdatePriorityConfig)
+		priorities.POST("/config/preview", ctrl.PreviewPriorityConfig)
 	}
 }
 
@@ -116,7 +137,7 @@ func registerHealthRoutes(rg *gin.RouterGroup, ctrl *controllers.HealthControlle
 }
 
 // registerNudgeRoutes registers nudge module routes
-func registerNudgeRoutes(rg *gin.RouterGroup, ctrl *controllers.NudgeController) {
+func registerNudgeRoutes(rg *gin.RouterGroup, ctrl *controllers.NudgeController, authMiddleware *middleware.AuthMiddleware) {
 	nudges := rg.Group("/nudges")
 	{
 		// CRUD operations
@@ -125,10 +146,10 @@ func registerNudgeRoutes(rg *gin.RouterGroup, ctrl *controllers.NudgeController)
 		nudges.PATCH("/:nudgeId/status", ctrl.UpdateNudgeStatus)
 
 		// Actions
-		nudges.POST("/:nudgeId/actions", ctrl.TakeNudgeAction)
+		nudges.POST("/:nudgeId/actions", authMiddleware.RequireNudgeActionPermission(), ctrl.TakeNudgeAction)
 
 		// Generation
-		nudges.POST("/generate", ctrl.GenerateNudges)
+		nudges.POST("/generate", authMiddleware.RequirePermission(middleware.PermissionRunAnalysis), ctrl.GenerateNudges)
 
 		// Stats
 		nudges.GET("/stats", ctrl.GetNudgeStats)
@@ -136,64 +157,65 @@ func registerNudgeRoutes(rg *gin.RouterGroup, ctrl *controllers.NudgeController)
 }
 
 // registerProgressRoutes registers progress module routes
-func registerProgressRoutes(rg *gin.RouterGroup, ctrl *controllers.ProgressController) {
+func registerProgressRoutes(rg *gin.RouterGroup, ctrl *controllers.ProgressController, orgMiddleware *middleware.OrganizationMiddleware) {
 	progress := rg.Group("/progress")
 	{
 		// Projects
-		progress.GET("/projects/:projectId", ctrl.GetProjectProgress)
+		progress.GET("/projects/:projectId", orgMiddleware.RequireProject("projectId"), ctrl.GetProjectProgress)
 
 		// Tasks
-		progress.GET("/tasks/:taskId", ctrl.GetTaskProgress)
-		progress.POST("/tasks/:taskId/update", ctrl.UpdateTaskProgress)
+		progress.GET("/tasks/:taskId", orgMiddleware.RequireTask("taskId"), ctrl.GetTaskProgress)
+		progress.POST("/tasks/:taskId/update", orgMiddleware.RequireTaskAccess("taskId", middleware.TaskAccessUpdate), ctrl.UpdateTaskProgress)
 
 		// Rollups
-		progress.GET("/rollups/:projectId", ctrl.GetProjectRollup)
+		progress.GET("/rollups/:projectId", orgMiddleware.RequireProject("projectId"), ctrl.GetProjectRollup)
 	}
 }
 
 // registerDependencyRoutes registers dependency module routes
-func registerDependencyRoutes(rg *gin.RouterGroup, ctrl *controllers.DependencyController) {
+func registerDependencyRoutes(rg *gin.RouterGroup, ctrl *controllers.DependencyController, authMiddleware *middleware.AuthMiddleware, orgMiddleware *middleware.OrganizationMiddleware) {
 	dependencies := rg.Group("/dependencies")
 	{
 		// Task dependencies
-		dependencies.GET("/tasks/:taskId", ctrl.GetTaskDependencies)
+		dependencies.GET("/tasks/:taskId", orgMiddleware.RequireTask("taskId"), ctrl.GetTaskDependencies)
 
 		// CRUD
-		dependencies.POST("", ctrl.CreateDependency)
-		dependencies.DELETE("/:dependencyId", ctrl.DeleteDependency)
+		dependencies.POST("", authMiddleware.RequirePermission(middleware.PermissionManageDependencies), ctrl.CreateDependency)
+		dependencies.DELETE("/:dependencyId", authMiddleware.RequirePermission(middleware.PermissionManageDependencies), orgMiddleware.RequireDependency("dependencyId"), ctrl.DeleteDependency)
 
 		// Critical path
-		dependencies.GET("/critical-path/:projectId", ctrl.GetCriticalPath)
+		dependencies.GET("/critical-path/:projectId", orgMiddleware.RequireProject("projectId"), ctrl.GetCriticalPath)
 
 		// Validation
 		dependencies.POST("/validate", ctrl.ValidateDependency)
 
 		// Graph
-		dependencies.GET("/graph/:projectId", ctrl.GetDependencyGraph)
+		dependencies.GET("/graph/:projectId", orgMiddleware.RequireProject("projectId"), ctrl.GetDependencyGraph)
+		dependencies.GET("/portfolio", ctrl.GetPortfolioGraph)
 	}
 }
 
 // registerAssignmentRoutes registers assignment module routes
-func registerAssignmentRoutes(rg *gin.RouterGroup, ctrl *controllers.AssignmentController) {
+func registerAssignmentRoutes(rg *gin.RouterGroup, ctrl *controllers.AssignmentController, authMiddleware *middleware.AuthMiddleware, orgMiddleware *middleware.OrganizationMiddleware) {
 	assignments := rg.Group("/assignments")
 	{
 		// Suggestions
 		assignments.GET("/suggestions", ctrl.GetAssignmentSuggestions)
 
 		// Assignment operations
-		assignments.POST("/tasks/:taskId/assign", ctrl.AssignTask)
-		assignments.POST("/tasks/:taskId/auto-assign", ctrl.AutoAssignTask)
+		assignments.POST("/tasks/:taskId/assign", orgMiddleware.RequireTaskAccess("taskId", middleware.TaskAccessManage), ctrl.AssignTask)
+		assignments.POST("/tasks/:taskId/auto-assign", orgMiddleware.RequireTaskAccess("taskId", middleware.TaskAccessManage), ctrl.AutoAssignTask)
 
 		// Compatibility
 		assignments.GET("/compatibility", ctrl.CheckCompatibility)
 
 		// Bulk operations
-		assignments.POST("/bulk-reassign", ctrl.BulkReassign)
+		assignments.POST("/bulk-reassign", authMiddleware.RequirePermission(middleware.PermissionBulkReassign), ctrl.BulkReassign)
 	}
 }
 
 // registerScenarioRoutes registers scenario module routes
-func registerScenarioRoutes(rg *gin.RouterGroup, ctrl *controllers.ScenarioController) {
+func registerScenarioRoutes(rg *gin.RouterGroup, ctrl *controllers.ScenarioController, authMiddleware *middleware.AuthMiddleware) {
 	scenarios := rg.Group("/scenarios")
 	{
 		// CRUD
@@ -205,8 +227,8 @@ func registerScenarioRoutes(rg *gin.RouterGroup, ctrl *controllers.ScenarioContr
 		scenarios.POST("/:scenarioId/simulate", ctrl.SimulateScenario)
 
 		// Actions
-		scenarios.POST("/:scenarioId/apply", ctrl.ApplyScenario)
-		scenarios.POST("/:scenarioId/reject", ctrl.RejectScenario)
+		scenarios.POST("/:scenarioId/apply", authMiddleware.RequirePermission(middleware.PermissionApplyScenarios), ctrl.ApplyScenario)
+		scenarios.POST("/:scenarioId/reject", authMiddleware.RequirePermission(middleware.PermissionApplyScenarios), ctrl.RejectScenario)
 		scenarios.PATCH("/:scenarioId/modify", ctrl.ModifyScenario)
 	}
 }
@@ -233,7 +255,7 @@ func registerWorkloadRoutes(rg *gin.RouterGroup, ctrl *controllers.WorkloadContr
 }
 
 // registerProjectRoutes registers project module routes
-func registerProjectRoutes(rg *gin.RouterGroup, ctrl *controllers.ProjectController) {
+func registerProjectRoutes(rg *gin.RouterGroup, ctrl *controllers.ProjectController, authMiddleware *middleware.AuthMiddleware) {
 	if ctrl == nil {
 		return
 	}
@@ -241,18 +263,45 @@ func registerProjectRoutes(rg *gin.RouterGroup, ctrl *controllers.ProjectControl
 	{
 		// CRUD
 		projects.GET("", ctrl.ListProjects)
-		projects.POST("", ctrl.CreateProject)
+		projects.POST("", authMiddleware.RequirePermission(middleware.PermissionManageProjects), ctrl.CreateProject)
 		projects.GET("/:projectId", ctrl.GetProject)
-		projects.PATCH("/:projectId", ctrl.UpdateProject)
-		projects.DELETE("/:projectId", ctrl.DeleteProject)
+		projects.PATCH("/:projectId", authMiddleware.RequirePermission(middleware.PermissionManageProjects), ctrl.UpdateProject)
+		projects.DELETE("/:projectId", authMiddleware.RequirePermission(middleware.PermissionManageProjects), ctrl.DeleteProject)
+
+		// Lifecycle
+		projects.POST("/:projectId/status", authMiddleware.RequirePermission(middleware.PermissionManageProjects), ctrl.UpdateProjectStatus)
+		projects.POST("/:projectId/restore", authMiddleware.RequirePermission(middleware.PermissionManageProjects), ctrl.RestoreProject)
+		projects.POST("/:projectId/clone", authMiddleware.RequirePermission(middleware.PermissionManageProjects), ctrl.CloneProject)
+
+		// Task workflow
+		projects.GET("/:projectId/workflow", ctrl.GetProjectWorkflow)
+		projects.PUT("/:projectId/workflow", authMiddleware.RequirePermission(middleware.PermissionManageProjects), ctrl.UpdateProjectWorkflow)
+		projects.DELETE("/:projectId/workflow", authMiddleware.RequirePermission(middleware.PermissionManageProjects), ctrl.ResetProjectWorkflow)
 
 		// Team
 		projects.GET("/:projectId/team", ctrl.GetProjectTeam)
 	}
 }
 
+// registerProjectTemplateRoutes registers project template library routes
+func registerProjectTemplateRoutes(rg *gin.RouterGroup, ctrl *controllers.ProjectTemplateController, authMiddleware *middleware.AuthMiddleware) {
+	if ctrl == nil {
+		return
+	}
+	templates := rg.Group("/project-templates")
+	{
+		templates.GET("", ctrl.ListTemplates)
+		templates.POST("", authMiddleware.RequirePermission(middleware.PermissionManageProjects), ctrl.CreateTemplate)
+		templates.POST("/import", authMiddleware.RequirePermission(middleware.PermissionManageProjects), ctrl.ImportTemplate)
+		templates.GET("/:templateId", ctrl.GetTemplate)
+		templates.DELETE("/:templateId", authMiddleware.RequirePermission(middleware.PermissionManageProjects), ctrl.DeleteTemplate)
+		templates.GET("/:templateId/export", ctrl.ExportTemplate)
+		templates.POST("/:templateId/instantiate", authMiddleware.RequirePermission(middleware.PermissionManageProjects), ctrl.InstantiateTemplate)
+	}
+}
+
 // registerTaskRoutes registers task module routes
-func registerTaskRoutes(rg *gin.RouterGroup, ctrl *controllers.TaskController) {
+func registerTaskRoutes(rg *gin.RouterGroup, ctrl *controllers.TaskController, authMiddleware *middleware.AuthMiddleware, orgMiddleware *middleware.OrganizationMiddleware) {
 	if ctrl == nil {
 		return
 	}
@@ -260,16 +309,31 @@ func registerTaskRoutes(rg *gin.RouterGroup, ctrl *controllers.TaskController) {
 	{
 		// CRUD
 		tasks.GET("", ctrl.ListTasks)
-		tasks.POST("", ctrl.CreateTask)
+		tasks.POST("", authMiddleware.RequirePermission(middleware.PermissionManageTasks), ctrl.CreateTask)
 		tasks.GET("/:taskId", ctrl.GetTask)
-		tasks.PATCH("/:taskId", ctrl.UpdateTask)
-		tasks.DELETE("/:taskId", ctrl.DeleteTask)
+		// Editing reassigns and reschedules, so it is for managers; assignees use /status and progress
+		tasks.PATCH("/:taskId", orgMiddleware.RequireTaskAccess("taskId", middleware.TaskAccessManage), ctrl.UpdateTask)
+		tasks.DELETE("/:taskId", orgMiddleware.RequireTaskAccess("taskId", middleware.TaskAccessManage), ctrl.DeleteTask)
+
+		// Hierarchy
+		tasks.GET("/tree", ctrl.GetProjectTaskTree)
+		tasks.GET("/:taskId/tree", ctrl.GetTaskTree)
+		// Moving can take a task into another project, so it needs the same permission as creating one
+		tasks.POST("/:taskId/move", authMiddleware.RequirePermission(middleware.PermissionManageTasks), ctrl.MoveTask)
+		tasks.POST("/:taskId/archive", orgMiddleware.RequireTaskAccess("taskId", middleware.TaskAccessManage), ctrl.ArchiveTask)
+		tasks.POST("/:taskId/unarchive", orgMiddleware.RequireTaskAccess("taskId", middleware.TaskAccessManage), ctrl.UnarchiveTask)
 
 		// Status updates
-		tasks.POST("/:taskId/status", ctrl.UpdateTaskStatus)
+		tasks.POST("/:taskId/status", orgMiddleware.RequireTaskAccess("taskId", middleware.TaskAccessUpdate), ctrl.UpdateTaskStatus)
+
+		// Scheduling
+		tasks.POST("/:taskId/schedule/preview", orgMiddleware.RequireTaskAccess("taskId", middleware.TaskAccessUpdate), ctrl.PreviewSchedule)
 
 		// Assignment
-		tasks.POST("/:taskId/assign", ctrl.AssignTask)
+		tasks.POST("/:taskId/assign", orgMiddleware.RequireTaskAccess("taskId", middleware.TaskAccessManage), ctrl.AssignTask)
+
+		// History
+		tasks.GET("/:taskId/history", ctrl.GetTaskHistory)
 	}
 }
 
@@ -289,6 +353,10 @@ func registerUserRoutes(rg *gin.RouterGroup, ctrl *controllers.UserController) {
 
 		// Workload
 		users.GET("/:userId/workload", ctrl.GetUserWorkload)
+
+		// Notification preferences
+		users.GET("/:userId/notification-preferences", ctrl.GetNotificationPreferences)
+		users.PUT("/:userId/notification-preferences", ctrl.UpdateNotificationPreferences)
 	}
 }
 
@@ -304,17 +372,100 @@ func registerSkillRoutes(rg *gin.RouterGroup, ctrl *controllers.SkillController)
 	}
 }
 
-// SetupRoutesWithRepos creates all services, controllers and routes using real repositories
-func SetupRoutesWithRepos(repos *repositories.Provider) *Router {
+// registerAuthRoutes registers account and session routes. Only changing a password
+// needs an authenticated caller.
+func registerAuthRoutes(rg *gin.RouterGroup, ctrl *controllers.AuthController, authMiddleware *middleware.AuthMiddleware) {
+	if ctrl == nil {
+		return
+	}
+	rg.POST("/register", ctrl.Register)
+	rg.POST("/login", ctrl.Login)
+	rg.POST("/refresh", ctrl.Refresh)
+	rg.POST("/logout", ctrl.Logout)
+
+	password := rg.Group("/password")
+	{
+		password.POST("/forgot", ctrl.ForgotPassword)
+		password.POST("/reset", ctrl.ResetPassword)
+		password.POST("/change", authMiddleware.Authenticate(), ctrl.ChangePassword)
+	}
+}
+
+// registerInvitationRoutes registers the routes invitees use to answer an invitation.
+// They need an authenticated user but no organization membership.
+func registerInvitationRoutes(rg *gin.RouterGroup, ctrl *controllers.MembershipController, authMiddleware *middleware.AuthMiddleware) {
+	if ctrl == nil {
+		return
+	}
+	rg.Use(authMiddleware.Authenticate())
+	rg.POST("/accept", ctrl.AcceptInvitation)
+	rg.POST("/decline", ctrl.DeclineInvitation)
+}
+
+// registerMembershipRoutes registers organization membership routes
+func registerMembershipRoutes(rg *gin.RouterGroup, ctrl *controllers.MembershipController, authMiddleware *middleware.AuthMiddleware) {
+	if ctrl == nil {
+		return
+	}
+	manage := authMiddleware.RequirePermission(middleware.PermissionManageMembers)
+	org := rg.Group("/organization")
+	{
+		// Members
+		org.GET("/members", ctrl.ListMembers)
+		org.PATCH("/members/:userId/role", manage, ctrl.UpdateMemberRole)
+		org.DELETE("/members/:userId", manage, ctrl.RemoveMember)
+		org.POST("/ownership/transfer", manage, ctrl.TransferOwnership)
+
+		// Invitations
+		org.GET("/invitations", manage, ctrl.ListInvitations)
+		org.POST("/invitations", manage, ctrl.CreateInvitation)
+		org.DELETE("/invitations/:invitationId", manage, ctrl.RevokeInvitation)
+
+		// Audit trail
+		org.GET("/audit-events", manage, ctrl.ListAuditEvents)
+	}
+}
+
+// registerJobRoutes registers background job routes
+func registerJobRoutes(rg *gin.RouterGroup, ctrl *controllers.JobController) {
+	jobs := rg.Group("/jobs")
+	{
+		jobs.GET("/:jobId", ctrl.GetJob)
+	}
+}
+
+// registerAnalyticsRoutes registers flow analytics routes
+func registerAnalyticsRoutes(rg *gin.RouterGroup, ctrl *controllers.AnalyticsController, orgMiddleware *middleware.OrganizationMiddleware) {
+	if ctrl == nil {
+		return
+	}
+	analytics := rg.Group("/analytics")
+	{
+		analytics.GET("/projects/:projectId/flow", orgMiddleware.RequireProject("projectId"), ctrl.GetProjectFlow)
+		analytics.GET("/people/:personId/flow", ctrl.GetPersonFlow)
+	}
+}
+
+// SetupRoutesWithRepos creates all services, controllers and routes using real repositories.
+// Services register their background job handlers on the given queue and send
+// notifications through the given dispatcher; API routes authenticate with authMiddleware.
+// Login and registration are only served when an auth service is given.
+func SetupRoutesWithRepos(repos *repositories.Provider, queue *jobs.Queue, notifier services.NotificationDispatcher, authMiddleware *middleware.AuthMiddleware, authService services.AuthService, membershipService services.MembershipService) *Router {
 	// Create real services using repositories
-	priorityService := services.NewDummyPriorityService() // Keep dummy for now
+	priorityService := services.NewRealPriorityService(repos, queue)
 	healthService := services.NewRealHealthService(repos)
-	nudgeService := services.NewRealNudgeService(repos)
-	progressService := services.NewDummyProgressService() // Keep dummy for now
-	dependencyService := services.NewDummyDependencyService() // Keep dummy for now
-	assignmentService := services.NewDummyAssignmentService() // Keep dummy for now
-	scenarioService := services.NewRealScenarioService(repos)
+	nudgeService := services.NewRealNudgeService(repos, queue, notifier)
+	progressService := services.NewRealProgressService(repos)
+	dependencyService := services.NewRealDependencyService(repos)
+	assignmentService := services.NewNotifyingAssignmentService(repos, notifier)
+	scenarioService := services.NewRealScenarioService(repos, notifier)
 	workloadService := services.NewRealWorkloadService(repos)
+	projectLifecycleService := services.NewProjectLifecycleService(repos)
+	projectTemplateService := services.NewProjectTemplateService(repos)
+	taskHierarchyService := services.NewTaskHierarchyService(repos)
+	taskWorkflowService := services.NewTaskWorkflowService(repos)
+	taskHistoryService := services.NewTaskHistoryService(repos)
+	taskScheduleService := services.NewTaskScheduleService(repos, notifier)
 
 	// Create controllers
 	priorityCtrl := controllers.NewPriorityController(priorityService)
@@ -325,14 +476,24 @@ func SetupRoutesWithRepos(repos *repositories.Provider) *Router {
 	assignmentCtrl := controllers.NewAssignmentController(assignmentService)
 	scenarioCtrl := controllers.NewScenarioController(scenarioService)
 	workloadCtrl := controllers.NewWorkloadController(workloadService)
-	projectCtrl := controllers.NewProjectController(repos)
-	taskCtrl := controllers.NewTaskController(repos)
+	projectCtrl := controllers.NewProjectController(repos, projectLifecycleService, taskWorkflowService)
+	templateCtrl := controllers.NewProjectTemplateController(projectTemplateService)
+	taskCtrl := controllers.NewTaskController(repos, taskHierarchyService, taskWorkflowService, progressService, taskHistoryService, dependencyService, taskScheduleService)
 	userCtrl := controllers.NewUserController(repos)
 	skillCtrl := controllers.NewSkillController(repos)
+	jobCtrl := controllers.NewJobController(repos)
+	analyticsCtrl := controllers.NewAnalyticsController(taskHistoryService)
+	var authCtrl *controllers.AuthController
+	if authService != nil {
+		authCtrl = controllers.NewAuthController(authService)
+	}
+	var membershipCtrl *controllers.MembershipController
+	if membershipService != nil {
+		membershipCtrl = controllers.NewMembershipController(membershipService)
+	}
 
 	// Create middleware
-	authMiddleware := middleware.NewAuthMiddleware("dummy-secret")
-	orgMiddleware := middleware.NewOrganizationMiddleware()
+	orgMiddleware := middleware.NewOrganizationMiddleware(repos)
 
 	// Create and return router
 	return NewRouter(
@@ -345,9 +506,14 @@ func SetupRoutesWithRepos(repos *repositories.Provider) *Router {
 		scenarioCtrl,
 		workloadCtrl,
 		projectCtrl,
+		templateCtrl,
 		taskCtrl,
 		userCtrl,
 		skillCtrl,
+		jobCtrl,
+		authCtrl,
+		membershipCtrl,
+		analyticsCtrl,
 		authMiddleware,
 		orgMiddleware,
 	)
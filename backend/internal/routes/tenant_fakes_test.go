@@ -0,0 +1,953 @@
+package routes_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+// tenantStore backs in-memory repositories that scope every lookup the same way the
+// database repositories do: projects, nudges, scenarios and jobs by their own
+// organization, tasks through their project and dependencies through their tasks.
+type tenantStore struct {
+	orgs      map[uuid.UUID]*models.Organization
+	roles     map[uuid.UUID]map[uuid.UUID]models.UserRole
+	users     map[uuid.UUID]*models.User
+	projects  map[uuid.UUID]*models.Project
+	tasks     map[uuid.UUID]*models.Task
+	deps      map[uuid.UUID]*models.TaskDependency
+	nudges    map[uuid.UUID]*models.Nudge
+	scenarios map[uuid.UUID]*models.Scenario
+	jobs      map[uuid.UUID]*models.Job
+	workloads []models.WorkloadEntry
+	prefs     map[uuid.UUID][]models.NotificationPreference
+	invites   map[uuid.UUID]*models.Invitation
+	templates map[uuid.UUID]*models.ProjectTemplate
+	workflows map[uuid.UUID]*models.Workflow // by project
+	audit     []models.AuditEvent
+	events    []models.TaskEvent
+}
+
+func newTenantStore() *tenantStore {
+	return &tenantStore{
+		orgs:      map[uuid.UUID]*models.Organization{},
+		roles:     map[uuid.UUID]map[uuid.UUID]models.UserRole{},
+		users:     map[uuid.UUID]*models.User{},
+		projects:  map[uuid.UUID]*models.Project{},
+		tasks:     map[uuid.UUID]*models.Task{},
+		deps:      map[uuid.UUID]*models.TaskDependency{},
+		nudges:    map[uuid.UUID]*models.Nudge{},
+		scenarios: map[uuid.UUID]*models.Scenario{},
+		jobs:      map[uuid.UUID]*models.Job{},
+		prefs:     map[uuid.UUID][]models.NotificationPreference{},
+		invites:   map[uuid.UUID]*models.Invitation{},
+		templates: map[uuid.UUID]*models.ProjectTemplate{},
+		workflows: map[uuid.UUID]*models.Workflow{},
+	}
+}
+
+func (s *tenantStore) provider() *repositories.Provider {
+	return &repositories.Provider{
+		User:         &fakeUserRepo{s: s},
+		Organization: &fakeOrgRepo{s: s},
+		Project:      &fakeProjectRepo{s: s},
+		Task:         &fakeTaskRepo{s: s},
+		Nudge:        &fakeNudgeRepo{s: s},
+		Workload:     &fakeWorkloadRepo{s: s},
+		Scenario:     &fakeScenarioRepo{s: s},
+		Dependency:   &fakeDependencyRepo{s: s},
+		Job:          &fakeJobRepo{s: s},
+		Notification: &fakeNotificationRepo{s: s},
+		Invitation:   &fakeInvitationRepo{s: s},
+		Audit:        &fakeAuditRepo{s: s},
+		Template:     &fakeTemplateRepo{s: s},
+		Workflow:     &fakeWorkflowRepo{s: s},
+		TaskEvent:    &fakeTaskEventRepo{s: s},
+	}
+}
+
+func notFound(resource string) error {
+	return fmt.Errorf("%s not found: %w", resource, gorm.ErrRecordNotFound)
+}
+
+func (s *tenantStore) project(orgID, id uuid.UUID) (*models.Project, bool) {
+	p, ok := s.projects[id]
+	return p, ok && p.OrganizationID == orgID
+}
+
+func (s *tenantStore) task(orgID, id uuid.UUID) (*models.Task, bool) {
+	t, ok := s.tasks[id]
+	if !ok {
+		return nil, false
+	}
+	_, ok = s.project(orgID, t.ProjectID)
+	return t, ok
+}
+
+func (s *tenantStore) orgTasks(orgID uuid.UUID, keep func(*models.Task) bool) []models.Task {
+	out := []models.Task{}
+	for _, t := range s.tasks {
+		if _, ok := s.project(orgID, t.ProjectID); ok && keep(t) {
+			out = append(out, *t)
+		}
+	}
+	return out
+}
+
+func (s *tenantStore) members(orgID uuid.UUID) []models.User {
+	out := []models.User{}
+	for userID := range s.roles[orgID] {
+		out = append(out, *s.users[userID])
+	}
+	return out
+}
+
+type fakeOrgRepo struct {
+	repositories.OrganizationRepository
+	s *tenantStore
+}
+
+func (r *fakeOrgRepo) GetByID(_ context.Context, id uuid.UUID) (*models.Organization, error) {
+	if o, ok := r.s.orgs[id]; ok {
+		clone := *o
+		return &clone, nil
+	}
+	return nil, notFound("organization")
+}
+
+func (r *fakeOrgRepo) Update(_ context.Context, org *models.Organization) error {
+	clone := *org
+	r.s.orgs[org.ID] = &clone
+	return nil
+}
+
+func (r *fakeOrgRepo) IsMember(_ context.Context, orgID, userID uuid.UUID) (bool, error) {
+	_, ok := r.s.roles[orgID][userID]
+	return ok, nil
+}
+
+func (r *fakeOrgRepo) GetMemberRole(_ context.Context, orgID, userID uuid.UUID) (models.UserRole, error) {
+	if role, ok := r.s.roles[orgID][userID]; ok {
+		return role, nil
+	}
+	return "", gorm.ErrRecordNotFound
+}
+
+func (r *fakeOrgRepo) AddMember(_ context.Context, orgID, userID uuid.UUID, role models.UserRole) error {
+	r.s.roles[orgID][userID] = role
+	return nil
+}
+
+func (r *fakeOrgRepo) RemoveMember(_ context.Context, orgID, userID uuid.UUID) error {
+	delete(r.s.roles[orgID], userID)
+	return nil
+}
+
+func (r *fakeOrgRepo) UpdateMemberRole(_ context.Context, orgID, userID uuid.UUID, role models.UserRole) error {
+	if _, ok := r.s.roles[orgID][userID]; !ok {
+		return notFound("organization member")
+	}
+	r.s.roles[orgID][userID] = role
+	return nil
+}
+
+func (r *fakeOrgRepo) ListMembers(_ context.Context, orgID uuid.UUID) ([]models.OrganizationMember, error) {
+	out := []models.OrganizationMember{}
+	for userID, role := range r.s.roles[orgID] {
+		out = append(out, models.OrganizationMember{OrganizationID: orgID, UserID: userID, Role: role, User: *r.s.users[userID]})
+	}
+	return out, nil
+}
+
+func (r *fakeOrgRepo) LockMembers(ctx context.Context, orgID uuid.UUID) ([]models.OrganizationMember, error) {
+	return r.ListMembers(ctx, orgID)
+}
+
+func (r *fakeOrgRepo) SetOwner(_ context.Context, orgID, userID uuid.UUID) error {
+	r.s.orgs[orgID].OwnerID = &userID
+	return nil
+}
+
+type fakeUserRepo struct {
+	repositories.UserRepository
+	s *tenantStore
+}
+
+func (r *fakeUserRepo) GetByID(_ context.Context, id uuid.UUID) (*models.User, error) {
+	if u, ok := r.s.users[id]; ok {
+		clone := *u
+		return &clone, nil
+	}
+	return nil, notFound("user")
+}
+
+func (r *fakeUserRepo) GetByEmail(_ context.Context, email string) (*models.User, error) {
+	for _, u := range r.s.users {
+		if u.Email == email {
+			clone := *u
+			return &clone, nil
+		}
+	}
+	return nil, notFound("user")
+}
+
+func (r *fakeUserRepo) ListByOrganization(_ context.Context, orgID uuid.UUID, _ repositories.ListParams) ([]models.User, int64, error) {
+	users := r.s.members(orgID)
+	return users, int64(len(users)), nil
+}
+
+func (r *fakeUserRepo) GetByOrganizationAndRole(_ context.Context, orgID uuid.UUID, role models.UserRole) ([]models.User, error) {
+	out := []models.User{}
+	for userID, r2 := range r.s.roles[orgID] {
+		if r2 == role {
+			out = append(out, *r.s.users[userID])
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeUserRepo) ListSkillsByOrganization(_ context.Context, _ uuid.UUID) ([]models.UserSkill, error) {
+	return []models.UserSkill{}, nil
+}
+
+type fakeProjectRepo struct {
+	repositories.ProjectRepository
+	s *tenantStore
+}
+
+func (r *fakeProjectRepo) Create(_ context.Context, orgID uuid.UUID, p *models.Project) error {
+	p.ID, p.OrganizationID = uuid.New(), orgID
+	clone := *p
+	r.s.projects[p.ID] = &clone
+	return nil
+}
+
+func (r *fakeProjectRepo) GetByID(_ context.Context, orgID, id uuid.UUID) (*models.Project, error) {
+	if p, ok := r.s.project(orgID, id); ok {
+		clone := *p
+		return &clone, nil
+	}
+	return nil, notFound("project")
+}
+
+func (r *fakeProjectRepo) Update(_ context.Context, orgID uuid.UUID, p *models.Project) error {
+	if _, ok := r.s.project(orgID, p.ID); !ok {
+		return notFound("project")
+	}
+	clone := *p
+	r.s.projects[p.ID] = &clone
+	return nil
+}
+
+func (r *fakeProjectRepo) Delete(_ context.Context, orgID, id uuid.UUID) error {
+	if _, ok := r.s.project(orgID, id); !ok {
+		return notFound("project")
+	}
+	delete(r.s.projects, id)
+	return nil
+}
+
+func (r *fakeProjectRepo) UpdateStatus(_ context.Context, orgID, id uuid.UUID, status models.ProjectStatus) error {
+	p, ok := r.s.project(orgID, id)
+	if !ok {
+		return notFound("project")
+	}
+	p.Status = status
+	return nil
+}
+
+// Restore never finds a deleted project because Delete drops it from the store
+func (r *fakeProjectRepo) Restore(_ context.Context, orgID, id uuid.UUID) error {
+	if _, ok := r.s.project(orgID, id); ok {
+		return repositories.ErrProjectNotDeleted
+	}
+	return notFound("project")
+}
+
+func (r *fakeProjectRepo) ListByOrganization(_ context.Context, orgID uuid.UUID, _ repositories.ListParams) ([]models.Project, int64, error) {
+	return r.list(orgID, func(*models.Project) bool { return true })
+}
+
+func (r *fakeProjectRepo) ListByStatus(_ context.Context, orgID uuid.UUID, status models.ProjectStatus, _ repositories.ListParams) ([]models.Project, int64, error) {
+	return r.list(orgID, func(p *models.Project) bool { return p.Status == status })
+}
+
+func (r *fakeProjectRepo) list(orgID uuid.UUID, keep func(*models.Project) bool) ([]models.Project, int64, error) {
+	out := []models.Project{}
+	for _, p := range r.s.projects {
+		if p.OrganizationID == orgID && keep(p) {
+			out = append(out, *p)
+		}
+	}
+	return out, int64(len(out)), nil
+}
+
+func (r *fakeProjectRepo) GetMember(_ context.Context, orgID, projectID, userID uuid.UUID) (*models.ProjectMember, error) {
+	return nil, notFound("project member")
+}
+
+type fakeTaskRepo struct {
+	repositories.TaskRepository
+	s *tenantStore
+}
+
+func (r *fakeTaskRepo) Create(_ context.Context, orgID uuid.UUID, t *models.Task) error {
+	if _, ok := r.s.project(orgID, t.ProjectID); !ok {
+		return notFound("project")
+	}
+	t.ID = uuid.New()
+	clone := *t
+	r.s.tasks[t.ID] = &clone
+	return nil
+}
+
+func (r *fakeTaskRepo) GetByID(_ context.Context, orgID, id uuid.UUID) (*models.Task, error) {
+	if t, ok := r.s.task(orgID, id); ok {
+		clone := *t
+		return &clone, nil
+	}
+	return nil, notFound("task")
+}
+
+func (r *fakeTaskRepo) Update(_ context.Context, orgID uuid.UUID, t *models.Task) error {
+	if _, ok := r.s.task(orgID, t.ID); !ok {
+		return notFound("task")
+	}
+	clone := *t
+	r.s.tasks[t.ID] = &clone
+	return nil
+}
+
+func (r *fakeTaskRepo) Delete(_ context.Context, orgID, id uuid.UUID) error {
+	if _, ok := r.s.task(orgID, id); !ok {
+		return notFound("task")
+	}
+	delete(r.s.tasks, id)
+	return nil
+}
+
+func (r *fakeTaskRepo) List(_ context.Context, orgID uuid.UUID, _ repositories.ListParams) ([]models.Task, int64, error) {
+	tasks := r.s.orgTasks(orgID, func(*models.Task) bool { return true })
+	return tasks, int64(len(tasks)), nil
+}
+
+func (r *fakeTaskRepo) ListByProject(_ context.Context, orgID, projectID uuid.UUID, _ repositories.ListParams) ([]models.Task, int64, error) {
+	tasks := r.s.orgTasks(orgID, func(t *models.Task) bool { return t.ProjectID == projectID })
+	return tasks, int64(len(tasks)), nil
+}
+
+func (r *fakeTaskRepo) ListByAssignee(_ context.Context, orgID, assigneeID uuid.UUID, _ repositories.ListParams) ([]models.Task, int64, error) {
+	tasks := r.s.orgTasks(orgID, func(t *models.Task) bool { return t.AssigneeID != nil && *t.AssigneeID == assigneeID })
+	return tasks, int64(len(tasks)), nil
+}
+
+func (r *fakeTaskRepo) ListByStatus(_ context.Context, orgID uuid.UUID, status models.TaskStatus, _ repositories.ListParams) ([]models.Task, int64, error) {
+	tasks := r.s.orgTasks(orgID, func(t *models.Task) bool { return t.Status == status })
+	return tasks, int64(len(tasks)), nil
+}
+
+func (r *fakeTaskRepo) ListSkillsByProject(_ context.Context, _, _ uuid.UUID) ([]models.TaskSkill, error) {
+	return []models.TaskSkill{}, nil
+}
+
+func (r *fakeTaskRepo) UpdateStatus(_ context.Context, orgID, id uuid.UUID, status models.TaskStatus) error {
+	t, ok := r.s.task(orgID, id)
+	if !ok {
+		return notFound("task")
+	}
+	t.Status = status
+	return nil
+}
+
+func (r *fakeTaskRepo) UpdateDates(_ context.Context, orgID, id uuid.UUID, startDate, dueDate *time.Time) error {
+	t, ok := r.s.task(orgID, id)
+	if !ok {
+		return notFound("task")
+	}
+	t.StartDate, t.DueDate = startDate, dueDate
+	return nil
+}
+
+func (r *fakeTaskRepo) UpdateAssignee(_ context.Context, orgID, id uuid.UUID, assigneeID *uuid.UUID) error {
+	t, ok := r.s.task(orgID, id)
+	if !ok {
+		return notFound("task")
+	}
+	t.AssigneeID = assigneeID
+	return nil
+}
+
+func (r *fakeTaskRepo) ListSubtree(_ context.Context, orgID, rootID uuid.UUID) ([]models.Task, error) {
+	root, ok := r.s.task(orgID, rootID)
+	if !ok {
+		return nil, notFound("task")
+	}
+	subtree := []models.Task{*root}
+	for i := 0; i < len(subtree); i++ {
+		parentID := subtree[i].ID
+		subtree = append(subtree, r.s.orgTasks(orgID, func(t *models.Task) bool {
+			return t.ParentTaskID != nil && *t.ParentTaskID == parentID
+		})...)
+	}
+	return subtree, nil
+}
+
+func (r *fakeTaskRepo) UpdateHierarchy(_ context.Context, orgID, id, projectID uuid.UUID, parentID *uuid.UUID, level int) error {
+	t, ok := r.s.task(orgID, id)
+	if !ok {
+		return notFound("task")
+	}
+	if _, ok := r.s.project(orgID, projectID); !ok {
+		return notFound("project")
+	}
+	t.ProjectID, t.ParentTaskID, t.HierarchyLevel = projectID, parentID, level
+	return nil
+}
+
+func (r *fakeTaskRepo) DeleteTasks(_ context.Context, orgID uuid.UUID, ids []uuid.UUID) error {
+	for _, id := range ids {
+		if _, ok := r.s.task(orgID, id); !ok {
+			return notFound("task")
+		}
+	}
+	for _, id := range ids {
+		delete(r.s.tasks, id)
+	}
+	return nil
+}
+
+func (r *fakeTaskRepo) SetArchived(_ context.Context, orgID uuid.UUID, ids []uuid.UUID, archivedAt *time.Time) error {
+	for _, id := range ids {
+		t, ok := r.s.task(orgID, id)
+		if !ok {
+			return notFound("task")
+		}
+		t.ArchivedAt = archivedAt
+	}
+	return nil
+}
+
+func (r *fakeTaskRepo) UpdatePriorityScore(_ context.Context, orgID, id uuid.UUID, score int) error {
+	t, ok := r.s.task(orgID, id)
+	if !ok {
+		return notFound("task")
+	}
+	t.PriorityScore = score
+	return nil
+}
+
+func (r *fakeTaskRepo) UpdateCriticalPath(_ context.Context, orgID, projectID uuid.UUID, criticalIDs []uuid.UUID) error {
+	for _, t := range r.s.orgTasks(orgID, func(t *models.Task) bool { return t.ProjectID == projectID }) {
+		r.s.tasks[t.ID].IsCriticalPath = slices.Contains(criticalIDs, t.ID)
+	}
+	return nil
+}
+
+type fakeDependencyRepo struct {
+	repositories.DependencyRepository
+	s *tenantStore
+}
+
+func (r *fakeDependencyRepo) inOrg(orgID uuid.UUID, d *models.TaskDependency) bool {
+	_, a := r.s.task(orgID, d.TaskID)
+	_, b := r.s.task(orgID, d.DependsOnTaskID)
+	return a && b
+}
+
+func (r *fakeDependencyRepo) Create(_ context.Context, orgID uuid.UUID, d *models.TaskDependency) error {
+	if !r.inOrg(orgID, d) {
+		return notFound("task")
+	}
+	d.ID = uuid.New()
+	clone := *d
+	r.s.deps[d.ID] = &clone
+	return nil
+}
+
+func (r *fakeDependencyRepo) GetByID(_ context.Context, orgID, id uuid.UUID) (*models.TaskDependency, error) {
+	if d, ok := r.s.deps[id]; ok && r.inOrg(orgID, d) {
+		clone := *d
+		return &clone, nil
+	}
+	return nil, notFound("dependency")
+}
+
+func (r *fakeDependencyRepo) Delete(_ context.Context, orgID, id uuid.UUID) error {
+	if d, ok := r.s.deps[id]; ok && r.inOrg(orgID, d) {
+		delete(r.s.deps, id)
+		return nil
+	}
+	return notFound("dependency")
+}
+
+func (r *fakeDependencyRepo) HasDependency(_ context.Context, orgID, taskID, dependsOnTaskID uuid.UUID) (bool, error) {
+	for _, d := range r.s.deps {
+		if d.TaskID == taskID && d.DependsOnTaskID == dependsOnTaskID && r.inOrg(orgID, d) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeDependencyRepo) ListByProject(_ context.Context, orgID, projectID uuid.UUID) ([]models.TaskDependency, error) {
+	out := []models.TaskDependency{}
+	for _, d := range r.s.deps {
+		if t, ok := r.s.task(orgID, d.TaskID); ok && t.ProjectID == projectID && r.inOrg(orgID, d) {
+			out = append(out, *d)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeDependencyRepo) ListDependentsOfProject(_ context.Context, orgID, projectID uuid.UUID) ([]models.TaskDependency, error) {
+	out := []models.TaskDependency{}
+	for _, d := range r.s.deps {
+		if t, ok := r.s.task(orgID, d.DependsOnTaskID); ok && t.ProjectID == projectID && r.inOrg(orgID, d) {
+			out = append(out, *d)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeDependencyRepo) ListByTask(_ context.Context, orgID, taskID uuid.UUID) ([]models.TaskDependency, error) {
+	out := []models.TaskDependency{}
+	for _, d := range r.s.deps {
+		if d.TaskID == taskID && r.inOrg(orgID, d) {
+			clone := *d
+			clone.DependsOnTask = *r.s.tasks[d.DependsOnTaskID]
+			out = append(out, clone)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeDependencyRepo) ListByDependsOn(_ context.Context, orgID, dependsOnTaskID uuid.UUID) ([]models.TaskDependency, error) {
+	out := []models.TaskDependency{}
+	for _, d := range r.s.deps {
+		if d.DependsOnTaskID == dependsOnTaskID && r.inOrg(orgID, d) {
+			clone := *d
+			clone.Task = *r.s.tasks[d.TaskID]
+			out = append(out, clone)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeDependencyRepo) GetDependentCount(_ context.Context, orgID, taskID uuid.UUID) (int64, error) {
+	var count int64
+	for _, d := range r.s.deps {
+		if d.DependsOnTaskID == taskID && r.inOrg(orgID, d) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+type fakeNudgeRepo struct {
+	repositories.NudgeRepository
+	s *tenantStore
+}
+
+func (r *fakeNudgeRepo) get(orgID, id uuid.UUID) (*models.Nudge, bool) {
+	n, ok := r.s.nudges[id]
+	return n, ok && n.OrganizationID == orgID
+}
+
+func (r *fakeNudgeRepo) Create(_ context.Context, orgID uuid.UUID, n *models.Nudge) error {
+	n.ID, n.OrganizationID = uuid.New(), orgID
+	clone := *n
+	r.s.nudges[n.ID] = &clone
+	return nil
+}
+
+func (r *fakeNudgeRepo) GetByID(_ context.Context, orgID, id uuid.UUID) (*models.Nudge, error) {
+	if n, ok := r.get(orgID, id); ok {
+		clone := *n
+		return &clone, nil
+	}
+	return nil, notFound("nudge")
+}
+
+func (r *fakeNudgeRepo) Update(_ context.Context, orgID uuid.UUID, n *models.Nudge) error {
+	if _, ok := r.get(orgID, n.ID); !ok {
+		return notFound("nudge")
+	}
+	clone := *n
+	r.s.nudges[n.ID] = &clone
+	return nil
+}
+
+func (r *fakeNudgeRepo) UpdateStatus(_ context.Context, orgID, id uuid.UUID, status models.NudgeStatus) error {
+	n, ok := r.get(orgID, id)
+	if !ok {
+		return notFound("nudge")
+	}
+	n.Status = status
+	return nil
+}
+
+func (r *fakeNudgeRepo) List(_ context.Context, orgID uuid.UUID, _ repositories.NudgeFilters, _ repositories.ListParams) ([]models.Nudge, int64, error) {
+	out := []models.Nudge{}
+	for _, n := range r.s.nudges {
+		if n.OrganizationID == orgID {
+			out = append(out, *n)
+		}
+	}
+	return out, int64(len(out)), nil
+}
+
+func (r *fakeNudgeRepo) ListOpen(ctx context.Context, orgID uuid.UUID, _ []models.NudgeType) ([]models.Nudge, error) {
+	nudges, _, err := r.List(ctx, orgID, repositories.NudgeFilters{}, repositories.ListParams{})
+	return nudges, err
+}
+
+func (r *fakeNudgeRepo) Resolve(_ context.Context, orgID, id uuid.UUID, _ *models.NudgeAction) error {
+	n, ok := r.get(orgID, id)
+	if !ok {
+		return notFound("nudge")
+	}
+	n.Status = models.NudgeStatusResolved
+	return nil
+}
+
+func (r *fakeNudgeRepo) CreateAction(_ context.Context, _ *models.NudgeAction) error {
+	return nil
+}
+
+func (r *fakeNudgeRepo) GetStats(_ context.Context, orgID uuid.UUID, _ time.Duration) (*repositories.NudgeStats, error) {
+	stats := &repositories.NudgeStats{BySeverity: map[string]int64{}, ByType: map[string]int64{}}
+	for _, n := range r.s.nudges {
+		if n.OrganizationID == orgID {
+			stats.Total++
+		}
+	}
+	return stats, nil
+}
+
+type fakeScenarioRepo struct {
+	repositories.ScenarioRepository
+	s *tenantStore
+}
+
+func (r *fakeScenarioRepo) get(orgID, id uuid.UUID) (*models.Scenario, bool) {
+	sc, ok := r.s.scenarios[id]
+	return sc, ok && sc.OrganizationID == orgID
+}
+
+func (r *fakeScenarioRepo) Create(_ context.Context, orgID uuid.UUID, sc *models.Scenario) error {
+	sc.ID, sc.OrganizationID = uuid.New(), orgID
+	clone := *sc
+	r.s.scenarios[sc.ID] = &clone
+	return nil
+}
+
+func (r *fakeScenarioRepo) GetByID(_ context.Context, orgID, id uuid.UUID) (*models.Scenario, error) {
+	if sc, ok := r.get(orgID, id); ok {
+		clone := *sc
+		return &clone, nil
+	}
+	return nil, notFound("scenario")
+}
+
+func (r *fakeScenarioRepo) Update(_ context.Context, orgID uuid.UUID, sc *models.Scenario) error {
+	if _, ok := r.get(orgID, sc.ID); !ok {
+		return notFound("scenario")
+	}
+	clone := *sc
+	r.s.scenarios[sc.ID] = &clone
+	return nil
+}
+
+func (r *fakeScenarioRepo) UpdateStatus(_ context.Context, orgID, id uuid.UUID, status models.ScenarioStatus) error {
+	sc, ok := r.get(orgID, id)
+	if !ok {
+		return notFound("scenario")
+	}
+	sc.Status = status
+	return nil
+}
+
+func (r *fakeScenarioRepo) List(_ context.Context, orgID uuid.UUID, _ repositories.ScenarioFilters, _ repositories.ListParams) ([]models.Scenario, int64, error) {
+	out := []models.Scenario{}
+	for _, sc := range r.s.scenarios {
+		if sc.OrganizationID == orgID {
+			out = append(out, *sc)
+		}
+	}
+	return out, int64(len(out)), nil
+}
+
+type fakeWorkloadRepo struct {
+	repositories.WorkloadRepository
+	s *tenantStore
+}
+
+func (r *fakeWorkloadRepo) entries(keep func(models.WorkloadEntry) bool) []models.WorkloadEntry {
+	out := []models.WorkloadEntry{}
+	for _, e := range r.s.workloads {
+		if keep(e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func (r *fakeWorkloadRepo) GetByUserAndWeek(_ context.Context, orgID, userID uuid.UUID, _ time.Time) (*models.WorkloadEntry, error) {
+	found := r.entries(func(e models.WorkloadEntry) bool { return e.OrganizationID == orgID && e.UserID == userID })
+	if len(found) == 0 {
+		return nil, notFound("workload entry")
+	}
+	return &found[0], nil
+}
+
+func (r *fakeWorkloadRepo) CreateOrUpdate(_ context.Context, entry *models.WorkloadEntry) error {
+	for i, e := range r.s.workloads {
+		if e.ID == entry.ID {
+			r.s.workloads[i] = *entry
+			return nil
+		}
+	}
+	r.s.workloads = append(r.s.workloads, *entry)
+	return nil
+}
+
+func (r *fakeWorkloadRepo) ListByOrganization(_ context.Context, orgID uuid.UUID, _ time.Time) ([]models.WorkloadEntry, error) {
+	return r.entries(func(e models.WorkloadEntry) bool { return e.OrganizationID == orgID }), nil
+}
+
+func (r *fakeWorkloadRepo) ListByUser(_ context.Context, orgID, userID uuid.UUID, _, _ time.Time) ([]models.WorkloadEntry, error) {
+	return r.entries(func(e models.WorkloadEntry) bool { return e.OrganizationID == orgID && e.UserID == userID }), nil
+}
+
+func (r *fakeWorkloadRepo) GetTeamWorkload(_ context.Context, orgID uuid.UUID, weekStart time.Time) (*repositories.TeamWorkload, error) {
+	tw := &repositories.TeamWorkload{WeekStarting: weekStart}
+	for _, e := range r.entries(func(e models.WorkloadEntry) bool { return e.OrganizationID == orgID }) {
+		tw.MemberWorkloads = append(tw.MemberWorkloads, repositories.MemberWorkloadDetail{
+			UserID:               e.UserID,
+			UserName:             r.s.users[e.UserID].Name,
+			AllocationPercentage: e.AllocationPercentage,
+		})
+	}
+	return tw, nil
+}
+
+type fakeJobRepo struct {
+	repositories.JobRepository
+	s *tenantStore
+}
+
+func (r *fakeJobRepo) Create(_ context.Context, job *models.Job) error {
+	job.ID = uuid.New()
+	clone := *job
+	r.s.jobs[job.ID] = &clone
+	return nil
+}
+
+func (r *fakeJobRepo) GetByID(_ context.Context, orgID, id uuid.UUID) (*models.Job, error) {
+	if j, ok := r.s.jobs[id]; ok && j.OrganizationID == orgID {
+		clone := *j
+		return &clone, nil
+	}
+	return nil, notFound("job")
+}
+
+type fakeNotificationRepo struct {
+	repositories.NotificationRepository
+	s *tenantStore
+}
+
+func (r *fakeNotificationRepo) ListPreferences(_ context.Context, userID uuid.UUID) ([]models.NotificationPreference, error) {
+	return r.s.prefs[userID], nil
+}
+
+func (r *fakeNotificationRepo) ReplacePreferences(_ context.Context, userID uuid.UUID, prefs []models.NotificationPreference) error {
+	r.s.prefs[userID] = prefs
+	return nil
+}
+
+type fakeInvitationRepo struct {
+	repositories.InvitationRepository
+	s *tenantStore
+}
+
+func (r *fakeInvitationRepo) Create(_ context.Context, orgID uuid.UUID, inv *models.Invitation) error {
+	inv.ID = uuid.New()
+	inv.OrganizationID = orgID
+	clone := *inv
+	r.s.invites[inv.ID] = &clone
+	return nil
+}
+
+func (r *fakeInvitationRepo) GetByID(_ context.Context, orgID, id uuid.UUID) (*models.Invitation, error) {
+	if inv, ok := r.s.invites[id]; ok && inv.OrganizationID == orgID {
+		clone := *inv
+		return &clone, nil
+	}
+	return nil, notFound("invitation")
+}
+
+func (r *fakeInvitationRepo) ListPending(_ context.Context, orgID uuid.UUID, now time.Time) ([]models.Invitation, error) {
+	out := []models.Invitation{}
+	for _, inv := range r.s.invites {
+		if inv.OrganizationID == orgID && inv.Status == models.InvitationStatusPending && inv.ExpiresAt.After(now) {
+			out = append(out, *inv)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeInvitationRepo) GetPendingByEmail(ctx context.Context, orgID uuid.UUID, email string, now time.Time) (*models.Invitation, error) {
+	pending, _ := r.ListPending(ctx, orgID, now)
+	for i := range pending {
+		if pending[i].Email == email {
+			return &pending[i], nil
+		}
+	}
+	return nil, notFound("invitation")
+}
+
+func (r *fakeInvitationRepo) Respond(_ context.Context, orgID, id uuid.UUID, status models.InvitationStatus, by uuid.UUID, at time.Time) error {
+	inv, ok := r.s.invites[id]
+	if !ok || inv.OrganizationID != orgID || inv.Status != models.InvitationStatusPending {
+		return notFound("invitation")
+	}
+	inv.Status, inv.RespondedByID, inv.RespondedAt = status, &by, &at
+	return nil
+}
+
+type fakeAuditRepo struct {
+	repositories.AuditRepository
+	s *tenantStore
+}
+
+func (r *fakeAuditRepo) Record(_ context.Context, orgID uuid.UUID, event *models.AuditEvent) error {
+	event.ID = uuid.New()
+	event.OrganizationID = orgID
+	r.s.audit = append(r.s.audit, *event)
+	return nil
+}
+
+func (r *fakeAuditRepo) List(_ context.Context, orgID uuid.UUID, _ repositories.ListParams) ([]models.AuditEvent, int64, error) {
+	out := []models.AuditEvent{}
+	for _, e := range r.s.audit {
+		if e.OrganizationID == orgID {
+			out = append(out, e)
+		}
+	}
+	return out, int64(len(out)), nil
+}
+
+type fakeTemplateRepo struct {
+	repositories.ProjectTemplateRepository
+	s *tenantStore
+}
+
+func (r *fakeTemplateRepo) Create(_ context.Context, orgID uuid.UUID, t *models.ProjectTemplate) error {
+	t.ID, t.OrganizationID = uuid.New(), orgID
+	clone := *t
+	r.s.templates[t.ID] = &clone
+	return nil
+}
+
+func (r *fakeTemplateRepo) GetByID(_ context.Context, orgID, id uuid.UUID) (*models.ProjectTemplate, error) {
+	if t, ok := r.s.templates[id]; ok && t.OrganizationID == orgID {
+		clone := *t
+		return &clone, nil
+	}
+	return nil, notFound("project template")
+}
+
+func (r *fakeTemplateRepo) List(_ context.Context, orgID uuid.UUID, _ repositories.ListParams) ([]models.ProjectTemplate, int64, error) {
+	out := []models.ProjectTemplate{}
+	for _, t := range r.s.templates {
+		if t.OrganizationID == orgID {
+			out = append(out, *t)
+		}
+	}
+	return out, int64(len(out)), nil
+}
+
+func (r *fakeTemplateRepo) CountTasks(_ context.Context, orgID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]int, error) {
+	counts := map[uuid.UUID]int{}
+	for _, id := range ids {
+		if t, ok := r.s.templates[id]; ok && t.OrganizationID == orgID {
+			counts[id] = len(t.Tasks)
+		}
+	}
+	return counts, nil
+}
+
+func (r *fakeTemplateRepo) Delete(_ context.Context, orgID, id uuid.UUID) error {
+	if t, ok := r.s.templates[id]; !ok || t.OrganizationID != orgID {
+		return notFound("project template")
+	}
+	delete(r.s.templates, id)
+	return nil
+}
+
+type fakeWorkflowRepo struct {
+	repositories.WorkflowRepository
+	s *tenantStore
+}
+
+func (r *fakeWorkflowRepo) GetByProject(_ context.Context, orgID, projectID uuid.UUID) (*models.Workflow, error) {
+	if w, ok := r.s.workflows[projectID]; ok && w.OrganizationID == orgID {
+		clone := *w
+		return &clone, nil
+	}
+	return nil, notFound("workflow")
+}
+
+func (r *fakeWorkflowRepo) Replace(_ context.Context, orgID uuid.UUID, w *models.Workflow) error {
+	if _, ok := r.s.project(orgID, w.ProjectID); !ok {
+		return notFound("project")
+	}
+	w.ID, w.OrganizationID = uuid.New(), orgID
+	clone := *w
+	r.s.workflows[w.ProjectID] = &clone
+	return nil
+}
+
+func (r *fakeWorkflowRepo) DeleteByProject(_ context.Context, orgID, projectID uuid.UUID) error {
+	if w, ok := r.s.workflows[projectID]; ok && w.OrganizationID == orgID {
+		delete(r.s.workflows, projectID)
+	}
+	return nil
+}
+
+type fakeTaskEventRepo struct {
+	repositories.TaskEventRepository
+	s *tenantStore
+}
+
+func (r *fakeTaskEventRepo) Record(_ context.Context, orgID uuid.UUID, e *models.TaskEvent) error {
+	e.ID, e.OrganizationID = uuid.New(), orgID
+	r.s.events = append(r.s.events, *e)
+	return nil
+}
+
+func (r *fakeTaskEventRepo) ListByTask(ctx context.Context, orgID, taskID uuid.UUID) ([]models.TaskEvent, error) {
+	return r.ListByTasks(ctx, orgID, []uuid.UUID{taskID})
+}
+
+func (r *fakeTaskEventRepo) ListByTasks(_ context.Context, orgID uuid.UUID, taskIDs []uuid.UUID, types ...models.TaskEventType) ([]models.TaskEvent, error) {
+	var out []models.TaskEvent
+	for _, e := range r.s.events {
+		if e.OrganizationID != orgID {
+			continue
+		}
+		for _, id := range taskIDs {
+			if e.TaskID == id && (len(types) == 0 || e.Type == types[0]) {
+				out = append(out, e)
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,576 @@
+package routes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/SimpleAjax/Xephyr/internal/auth"
+	"github.com/SimpleAjax/Xephyr/internal/jobs"
+	"github.com/SimpleAjax/Xephyr/internal/middleware"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/routes"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// tenant holds the IDs of one seeded organization's records
+type tenant struct {
+	org, admin, member           uuid.UUID
+	project, task, dependentTask uuid.UUID
+	dependency, nudge, scenario  uuid.UUID
+	job, invitation, template    uuid.UUID
+	marker                       string
+}
+
+// seedTenant adds an organization with one record of every kind. Every name carries the
+// marker so responses can be searched for leaked data.
+func seedTenant(s *tenantStore, marker string) tenant {
+	t := tenant{
+		org: uuid.New(), admin: uuid.New(), member: uuid.New(),
+		project: uuid.New(), task: uuid.New(), dependentTask: uuid.New(),
+		dependency: uuid.New(), nudge: uuid.New(), scenario: uuid.New(), job: uuid.New(),
+		invitation: uuid.New(), template: uuid.New(), marker: marker,
+	}
+
+	s.orgs[t.org] = &models.Organization{BaseModel: models.BaseModel{ID: t.org}, Name: marker + " org"}
+	s.roles[t.org] = map[uuid.UUID]models.UserRole{t.admin: models.RoleAdmin, t.member: models.RoleMember}
+	s.users[t.admin] = &models.User{BaseModel: models.BaseModel{ID: t.admin}, Name: marker + " admin", Email: marker + "-admin@example.com"}
+	s.users[t.member] = &models.User{BaseModel: models.BaseModel{ID: t.member}, Name: marker + " member", Email: marker + "-member@example.com"}
+
+	s.projects[t.project] = &models.Project{BaseModel: models.BaseModel{ID: t.project}, OrganizationID: t.org,
+		Name: marker + " project", Status: models.ProjectActive, Priority: 50, HealthScore: 100}
+	s.tasks[t.task] = &models.Task{BaseModel: models.BaseModel{ID: t.task}, ProjectID: t.project,
+		Title: marker + " task", Status: models.TaskStatusInProgress, AssigneeID: &t.member, EstimatedHours: 8}
+	s.tasks[t.dependentTask] = &models.Task{BaseModel: models.BaseModel{ID: t.dependentTask}, ProjectID: t.project,
+		Title: marker + " dependent task", Status: models.TaskStatusBacklog, EstimatedHours: 4}
+	s.deps[t.dependency] = &models.TaskDependency{BaseModel: models.BaseModel{ID: t.dependency},
+		TaskID: t.dependentTask, DependsOnTaskID: t.task, DependencyType: models.DependencyFinishToStart}
+
+	s.nudges[t.nudge] = &models.Nudge{BaseModel: models.BaseModel{ID: t.nudge}, OrganizationID: t.org,
+		Type: models.NudgeTypeOverload, Severity: models.NudgeSeverityMedium, Status: models.NudgeStatusUnread,
+		Title: marker + " nudge", RelatedTaskID: &t.task, RelatedUserID: &t.member}
+	s.scenarios[t.scenario] = &models.Scenario{BaseModel: models.BaseModel{ID: t.scenario}, OrganizationID: t.org,
+		Title: marker + " scenario", ChangeType: models.ScenarioChangeType("reallocation"),
+		Status: models.ScenarioStatusPending, CreatedByID: t.admin}
+	s.jobs[t.job] = &models.Job{BaseModel: models.BaseModel{ID: t.job}, OrganizationID: t.org,
+		Type: services.JobTypePriorityRecalculation, Status: models.JobStatus("queued"), LastError: marker}
+
+	// Addressed to the admin so the admin can answer it in the control requests
+	s.invites[t.invitation] = &models.Invitation{BaseModel: models.BaseModel{ID: t.invitation}, OrganizationID: t.org,
+		Email: marker + "-admin@example.com", Role: models.RolePM, Status: models.InvitationStatusPending,
+		InvitedByID: t.admin, ExpiresAt: time.Now().Add(24 * time.Hour)}
+
+	s.templates[t.template] = &models.ProjectTemplate{BaseModel: models.BaseModel{ID: t.template}, OrganizationID: t.org,
+		Name: marker + " template", DurationDays: 14, CreatedByID: t.admin,
+		Tasks: []models.TemplateTask{{BaseModel: models.BaseModel{ID: uuid.New()}, Title: marker + " template task", EstimatedHours: 6}}}
+
+	s.events = append(s.events, models.TaskEvent{BaseModel: models.BaseModel{ID: uuid.New()}, OrganizationID: t.org,
+		ProjectID: t.project, TaskID: t.task, Type: models.TaskEventStatusChanged,
+		FromValue: marker + " ready", ToValue: string(models.TaskStatusInProgress), OccurredAt: time.Now()})
+
+	s.workloads = append(s.workloads, models.WorkloadEntry{OrganizationID: t.org, UserID: t.member,
+		AllocationPercentage: 80, TotalEstimatedHours: 32, AvailableHours: 40})
+	return t
+}
+
+// expectation is what a request from organization A naming organization B's records
+// must produce
+type expectation int
+
+const (
+	// rejected routes name a record in the path, query or body and must answer 403 or 404
+	rejected expectation = iota
+	// unleaked routes list, aggregate or create data in the caller's own organization,
+	// or are still served by placeholder services; their responses must not contain
+	// anything from organization B
+	unleaked
+	// exempt routes are not organization scoped at all
+	exempt
+)
+
+// crossTenantCase builds a request from the caller's organization (a) against the other
+// organization's records (b)
+type crossTenantCase struct {
+	expect  expectation
+	request func(a, b tenant) (path string, body interface{})
+}
+
+// invitationSecret signs the invitation tokens in these specs
+var invitationSecret = []byte("tenant-isolation-secret")
+
+// invitationToken signs a token for the tenant's seeded invitation
+func invitationToken(t tenant) string {
+	token, err := auth.SignHS256(auth.Claims{
+		Subject: t.invitation.String(), ID: t.invitation.String(), Type: auth.TokenTypeInvite,
+		OrganizationID: t.org.String(), ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}, invitationSecret)
+	Expect(err).NotTo(HaveOccurred())
+	return token
+}
+
+func path(p string) func(a, b tenant) (string, interface{}) {
+	return func(a, b tenant) (string, interface{}) { return p, nil }
+}
+
+// crossTenantRoutes lists every route registered by SetupRoutesWithRepos. The spec
+// below fails when a route is added without an entry here.
+var crossTenantRoutes = map[string]crossTenantCase{
+	// Unauthenticated
+	"GET /health":       {exempt, path("/health")},
+	"GET /swagger/*any": {exempt, path("/swagger/index.html")},
+
+	// Account and session routes identify the caller, not an organization
+	"POST /api/v1/auth/register":        {exempt, nil},
+	"POST /api/v1/auth/login":           {exempt, nil},
+	"POST /api/v1/auth/refresh":         {exempt, nil},
+	"POST /api/v1/auth/logout":          {exempt, nil},
+	"POST /api/v1/auth/password/forgot": {exempt, nil},
+	"POST /api/v1/auth/password/reset":  {exempt, nil},
+	"POST /api/v1/auth/password/change": {exempt, nil},
+
+	// Priorities
+	"GET /api/v1/priorities/tasks/:taskId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/priorities/tasks/" + b.task.String(), nil
+	}},
+	"POST /api/v1/priorities/tasks/bulk": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/priorities/tasks/bulk", map[string]interface{}{"taskIds": []string{b.task.String()}}
+	}},
+	"GET /api/v1/priorities/projects/:projectId/ranking": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/priorities/projects/" + b.project.String() + "/ranking", nil
+	}},
+	"POST /api/v1/priorities/recalculate": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/priorities/recalculate", map[string]interface{}{"scope": "project", "projectId": b.project.String()}
+	}},
+	"GET /api/v1/priorities/config": {unleaked, path("/api/v1/priorities/config")},
+	"PUT /api/v1/priorities/config": {unleaked, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/priorities/config", services.DefaultPriorityConfig()
+	}},
+	"POST /api/v1/priorities/config/preview": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/priorities/config/preview", map[string]interface{}{
+			"projectId": b.project.String(), "config": services.DefaultPriorityConfig(),
+		}
+	}},
+
+	// Health
+	"GET /api/v1/health/portfolio": {unleaked, path("/api/v1/health/portfolio")},
+	"GET /api/v1/health/projects":  {unleaked, path("/api/v1/health/projects")},
+	"GET /api/v1/health/projects/:projectId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/health/projects/" + b.project.String(), nil
+	}},
+	"GET /api/v1/health/trends": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/health/trends?projectId=" + b.project.String(), nil
+	}},
+
+	// Nudges
+	"GET /api/v1/nudges": {unleaked, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/nudges?projectId=" + b.project.String(), nil
+	}},
+	"GET /api/v1/nudges/stats": {unleaked, path("/api/v1/nudges/stats")},
+	"GET /api/v1/nudges/:nudgeId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/nudges/" + b.nudge.String(), nil
+	}},
+	"PATCH /api/v1/nudges/:nudgeId/status": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/nudges/" + b.nudge.String() + "/status", map[string]interface{}{"status": "dismissed"}
+	}},
+	"POST /api/v1/nudges/:nudgeId/actions": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/nudges/" + b.nudge.String() + "/actions", map[string]interface{}{"actionType": "dismiss"}
+	}},
+	"POST /api/v1/nudges/generate": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/nudges/generate", map[string]interface{}{"scope": "project", "projectId": b.project.String()}
+	}},
+
+	// Progress
+	"GET /api/v1/progress/projects/:projectId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/progress/projects/" + b.project.String(), nil
+	}},
+	"GET /api/v1/progress/tasks/:taskId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/progress/tasks/" + b.task.String(), nil
+	}},
+	"POST /api/v1/progress/tasks/:taskId/update": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/progress/tasks/" + b.task.String() + "/update", map[string]interface{}{"progressPercentage": 90}
+	}},
+	"GET /api/v1/progress/rollups/:projectId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/progress/rollups/" + b.project.String(), nil
+	}},
+
+	// Dependencies
+	"GET /api/v1/dependencies/tasks/:taskId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/dependencies/tasks/" + b.task.String(), nil
+	}},
+	"POST /api/v1/dependencies": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/dependencies", map[string]interface{}{
+			"taskId": b.dependentTask.String(), "dependsOnTaskId": b.task.String(), "dependencyType": "finish_to_start",
+		}
+	}},
+	"DELETE /api/v1/dependencies/:dependencyId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/dependencies/" + b.dependency.String(), nil
+	}},
+	"GET /api/v1/dependencies/critical-path/:projectId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/dependencies/critical-path/" + b.project.String(), nil
+	}},
+	"POST /api/v1/dependencies/validate": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/dependencies/validate", map[string]interface{}{
+			"taskId": b.dependentTask.String(), "dependsOnTaskId": b.task.String(), "dependencyType": "finish_to_start",
+		}
+	}},
+	"GET /api/v1/dependencies/graph/:projectId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/dependencies/graph/" + b.project.String(), nil
+	}},
+	"GET /api/v1/dependencies/portfolio": {unleaked, path("/api/v1/dependencies/portfolio")},
+
+	// Assignments
+	"GET /api/v1/assignments/suggestions": {unleaked, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/assignments/suggestions?taskId=" + b.task.String(), nil
+	}},
+	"POST /api/v1/assignments/tasks/:taskId/assign": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/assignments/tasks/" + b.task.String() + "/assign", map[string]interface{}{"personId": a.member.String()}
+	}},
+	"POST /api/v1/assignments/tasks/:taskId/auto-assign": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/assignments/tasks/" + b.task.String() + "/auto-assign", map[string]interface{}{}
+	}},
+	"GET /api/v1/assignments/compatibility": {unleaked, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/assignments/compatibility?taskId=" + b.task.String() + "&personId=" + b.member.String(), nil
+	}},
+	"POST /api/v1/assignments/bulk-reassign": {unleaked, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/assignments/bulk-reassign", map[string]interface{}{"reassignments": []map[string]string{{
+			"taskId": b.task.String(), "fromPersonId": b.member.String(), "toPersonId": a.member.String(),
+		}}}
+	}},
+
+	// Scenarios
+	"POST /api/v1/scenarios": {unleaked, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/scenarios", map[string]interface{}{
+			"title": "Leave", "changeType": "employee_leave",
+			"proposedChanges": map[string]interface{}{"personId": a.member.String()},
+		}
+	}},
+	"GET /api/v1/scenarios": {unleaked, path("/api/v1/scenarios")},
+	"GET /api/v1/scenarios/:scenarioId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/scenarios/" + b.scenario.String(), nil
+	}},
+	"POST /api/v1/scenarios/:scenarioId/simulate": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/scenarios/" + b.scenario.String() + "/simulate", map[string]interface{}{}
+	}},
+	"POST /api/v1/scenarios/:scenarioId/apply": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/scenarios/" + b.scenario.String() + "/apply", map[string]interface{}{}
+	}},
+	"POST /api/v1/scenarios/:scenarioId/reject": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/scenarios/" + b.scenario.String() + "/reject", map[string]interface{}{}
+	}},
+	"PATCH /api/v1/scenarios/:scenarioId/modify": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/scenarios/" + b.scenario.String() + "/modify", map[string]interface{}{"title": "Taken over"}
+	}},
+
+	// Workload
+	"GET /api/v1/workload/team": {unleaked, path("/api/v1/workload/team")},
+	"GET /api/v1/workload/people/:personId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/workload/people/" + b.member.String(), nil
+	}},
+	"GET /api/v1/workload/forecast": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/workload/forecast?personId=" + b.member.String(), nil
+	}},
+	"GET /api/v1/workload/analytics": {unleaked, path("/api/v1/workload/analytics")},
+	"POST /api/v1/workload/rebalance": {unleaked, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/workload/rebalance", map[string]interface{}{"personId": b.member.String(), "maxUtilization": 100}
+	}},
+
+	// Projects
+	"GET /api/v1/projects": {unleaked, path("/api/v1/projects")},
+	"POST /api/v1/projects": {unleaked, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/projects", map[string]interface{}{"name": "New project"}
+	}},
+	"GET /api/v1/projects/:projectId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/projects/" + b.project.String(), nil
+	}},
+	"PATCH /api/v1/projects/:projectId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/projects/" + b.project.String(), map[string]interface{}{"name": "Taken over"}
+	}},
+	"DELETE /api/v1/projects/:projectId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/projects/" + b.project.String(), nil
+	}},
+	"GET /api/v1/projects/:projectId/team": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/projects/" + b.project.String() + "/team", nil
+	}},
+	"POST /api/v1/projects/:projectId/status": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/projects/" + b.project.String() + "/status", map[string]interface{}{"status": "paused"}
+	}},
+	"POST /api/v1/projects/:projectId/restore": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/projects/" + b.project.String() + "/restore", nil
+	}},
+	"POST /api/v1/projects/:projectId/clone": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/projects/" + b.project.String() + "/clone", map[string]interface{}{"startDate": "2026-01-05T00:00:00Z"}
+	}},
+	"GET /api/v1/projects/:projectId/workflow": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/projects/" + b.project.String() + "/workflow", nil
+	}},
+	"PUT /api/v1/projects/:projectId/workflow": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/projects/" + b.project.String() + "/workflow", map[string]interface{}{
+			"statuses": []map[string]string{{"key": "done", "category": "done"}},
+		}
+	}},
+	"DELETE /api/v1/projects/:projectId/workflow": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/projects/" + b.project.String() + "/workflow", nil
+	}},
+
+	// Project templates
+	"GET /api/v1/project-templates": {unleaked, path("/api/v1/project-templates")},
+	"POST /api/v1/project-templates": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/project-templates", map[string]interface{}{"projectId": b.project.String(), "name": "Taken over"}
+	}},
+	"POST /api/v1/project-templates/import": {unleaked, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/project-templates/import", map[string]interface{}{
+			"format": "xephyr.project-template", "version": 1, "name": "Imported",
+			"tasks": []map[string]interface{}{{"key": "kickoff", "title": "Kickoff"}},
+		}
+	}},
+	"GET /api/v1/project-templates/:templateId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/project-templates/" + b.template.String(), nil
+	}},
+	"DELETE /api/v1/project-templates/:templateId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/project-templates/" + b.template.String(), nil
+	}},
+	"GET /api/v1/project-templates/:templateId/export": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/project-templates/" + b.template.String() + "/export", nil
+	}},
+	"POST /api/v1/project-templates/:templateId/instantiate": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/project-templates/" + b.template.String() + "/instantiate", map[string]interface{}{"name": "Taken over", "startDate": "2026-01-05T00:00:00Z"}
+	}},
+
+	// Tasks
+	"GET /api/v1/tasks": {unleaked, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/tasks?projectId=" + b.project.String(), nil
+	}},
+	"POST /api/v1/tasks": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/tasks", map[string]interface{}{"projectId": b.project.String(), "title": "Planted task"}
+	}},
+	"GET /api/v1/tasks/:taskId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/tasks/" + b.task.String(), nil
+	}},
+	"PATCH /api/v1/tasks/:taskId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/tasks/" + b.task.String(), map[string]interface{}{"title": "Taken over"}
+	}},
+	"DELETE /api/v1/tasks/:taskId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/tasks/" + b.task.String(), nil
+	}},
+	"GET /api/v1/tasks/tree": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/tasks/tree?projectId=" + b.project.String(), nil
+	}},
+	"GET /api/v1/tasks/:taskId/tree": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/tasks/" + b.task.String() + "/tree", nil
+	}},
+	"POST /api/v1/tasks/:taskId/move": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/tasks/" + b.dependentTask.String() + "/move", map[string]interface{}{"parentTaskId": b.task.String()}
+	}},
+	"POST /api/v1/tasks/:taskId/archive": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/tasks/" + b.task.String() + "/archive", nil
+	}},
+	"POST /api/v1/tasks/:taskId/unarchive": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/tasks/" + b.task.String() + "/unarchive", nil
+	}},
+	"POST /api/v1/tasks/:taskId/status": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/tasks/" + b.task.String() + "/status", map[string]interface{}{"status": "done"}
+	}},
+	"POST /api/v1/tasks/:taskId/schedule/preview": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/tasks/" + b.task.String() + "/schedule/preview", map[string]interface{}{"status": "done"}
+	}},
+	"POST /api/v1/tasks/:taskId/assign": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/tasks/" + b.task.String() + "/assign", map[string]interface{}{"personId": a.member.String()}
+	}},
+	"GET /api/v1/tasks/:taskId/history": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/tasks/" + b.task.String() + "/history", nil
+	}},
+
+	// Users
+	"GET /api/v1/users": {unleaked, path("/api/v1/users")},
+	"GET /api/v1/users/:userId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/users/" + b.member.String(), nil
+	}},
+	"GET /api/v1/users/:userId/skills": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/users/" + b.member.String() + "/skills", nil
+	}},
+	"GET /api/v1/users/:userId/workload": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/users/" + b.member.String() + "/workload", nil
+	}},
+	"GET /api/v1/users/:userId/notification-preferences": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/users/" + b.admin.String() + "/notification-preferences", nil
+	}},
+	"PUT /api/v1/users/:userId/notification-preferences": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/users/" + b.admin.String() + "/notification-preferences", map[string]interface{}{"preferences": []interface{}{}}
+	}},
+
+	// Skills
+	"GET /api/v1/skills":      {unleaked, path("/api/v1/skills")},
+	"GET /api/v1/skills/gaps": {unleaked, path("/api/v1/skills/gaps")},
+
+	// Jobs
+	"GET /api/v1/jobs/:jobId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/jobs/" + b.job.String(), nil
+	}},
+
+	// Analytics
+	"GET /api/v1/analytics/projects/:projectId/flow": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/analytics/projects/" + b.project.String() + "/flow", nil
+	}},
+	"GET /api/v1/analytics/people/:personId/flow": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/analytics/people/" + b.member.String() + "/flow", nil
+	}},
+
+	// Organization membership
+	"GET /api/v1/organization/members": {unleaked, path("/api/v1/organization/members")},
+	"PATCH /api/v1/organization/members/:userId/role": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/organization/members/" + b.member.String() + "/role", map[string]interface{}{"role": "pm"}
+	}},
+	"DELETE /api/v1/organization/members/:userId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/organization/members/" + b.member.String(), nil
+	}},
+	"POST /api/v1/organization/ownership/transfer": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/organization/ownership/transfer", map[string]interface{}{"userId": b.member.String()}
+	}},
+	"GET /api/v1/organization/invitations": {unleaked, path("/api/v1/organization/invitations")},
+	"POST /api/v1/organization/invitations": {unleaked, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/organization/invitations", map[string]interface{}{"email": "newcomer@example.com", "role": "member"}
+	}},
+	"DELETE /api/v1/organization/invitations/:invitationId": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/organization/invitations/" + b.invitation.String(), nil
+	}},
+	"GET /api/v1/organization/audit-events": {unleaked, path("/api/v1/organization/audit-events")},
+
+	// Invitations name their organization in a signed token rather than a header
+	"POST /api/v1/invitations/accept": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/invitations/accept", map[string]interface{}{"token": invitationToken(b)}
+	}},
+	"POST /api/v1/invitations/decline": {rejected, func(a, b tenant) (string, interface{}) {
+		return "/api/v1/invitations/decline", map[string]interface{}{"token": invitationToken(b)}
+	}},
+}
+
+var _ = Describe("Tenant isolation", func() {
+	var (
+		store  *tenantStore
+		a, b   tenant
+		router *gin.Engine
+	)
+
+	setup := func() {
+		store = newTenantStore()
+		a = seedTenant(store, "tenant-a-data")
+		b = seedTenant(store, "tenant-b-secret")
+
+		repos := store.provider()
+		authMiddleware, err := middleware.NewAuthMiddleware(middleware.AuthConfig{DevMode: true})
+		Expect(err).NotTo(HaveOccurred())
+		queue := jobs.NewQueue(repos.Job, jobs.DefaultConfig())
+		membershipConfig := services.DefaultMembershipServiceConfig()
+		membershipConfig.Secret = invitationSecret
+		membershipService := services.NewMembershipService(repos, membershipConfig, nil)
+		router = routes.SetupRoutesWithRepos(repos, queue, nil, authMiddleware, nil, membershipService).GetEngine()
+	}
+
+	BeforeEach(func() {
+		setup()
+	})
+
+	send := func(method, target string, body interface{}, caller uuid.UUID, orgID uuid.UUID) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			var err error
+			payload, err = json.Marshal(body)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		req := httptest.NewRequest(method, target, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", caller.String())
+		req.Header.Set("X-Organization-Id", orgID.String())
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// snapshot captures organization B's records so writes that slipped through show up
+	snapshot := func() string {
+		encoded, err := json.Marshal([]interface{}{
+			store.projects[b.project], store.tasks[b.task], store.tasks[b.dependentTask],
+			store.deps[b.dependency], store.nudges[b.nudge], store.scenarios[b.scenario], store.prefs[b.member],
+			store.orgs[b.org], store.roles[b.org], store.invites[b.invitation], store.templates[b.template], store.workflows[b.project],
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return string(encoded)
+	}
+
+	It("should cover every registered route", func() {
+		// The auth routes are only served with an auth service, so they are not
+		// registered here; everything else must have an entry.
+		for _, route := range router.Routes() {
+			key := route.Method + " " + route.Path
+			Expect(crossTenantRoutes).To(HaveKey(key), "route %s has no cross-tenant case", key)
+		}
+	})
+
+	It("should reject callers naming an organization they do not belong to", func() {
+		rec := send(http.MethodGet, "/api/v1/projects", nil, a.admin, b.org)
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		Expect(rec.Body.String()).NotTo(ContainSubstring(b.marker))
+	})
+
+	It("should keep every route from reading or changing another organization's records", func() {
+		before := snapshot()
+
+		for _, route := range router.Routes() {
+			key := route.Method + " " + route.Path
+			c, ok := crossTenantRoutes[key]
+			if !ok || c.expect == exempt {
+				continue
+			}
+
+			target, body := c.request(a, b)
+			rec := send(route.Method, target, body, a.admin, a.org)
+
+			Expect(rec.Code).To(BeNumerically("<", http.StatusInternalServerError), "%s: %s", key, rec.Body.String())
+			Expect(rec.Body.String()).NotTo(ContainSubstring(b.marker), "%s leaked organization B data", key)
+			if c.expect == rejected {
+				Expect(rec.Code).To(BeElementOf(http.StatusForbidden, http.StatusNotFound), "%s: %s", key, rec.Body.String())
+			} else {
+				for _, id := range []uuid.UUID{b.project, b.task, b.nudge, b.scenario, b.dependency} {
+					if !strings.Contains(target, id.String()) && !containsJSON(body, id.String()) {
+						Expect(rec.Body.String()).NotTo(ContainSubstring(id.String()), "%s leaked organization B ID", key)
+					}
+				}
+			}
+		}
+
+		Expect(snapshot()).To(Equal(before))
+	})
+
+	It("should still serve the caller's own records on the rejected routes", func() {
+		for _, route := range router.Routes() {
+			key := route.Method + " " + route.Path
+			c, ok := crossTenantRoutes[key]
+			if !ok || c.expect != rejected {
+				continue
+			}
+			// Start from fresh records so earlier deletes do not hide later routes
+			setup()
+
+			target, body := c.request(b, a)
+			rec := send(route.Method, target, body, a.admin, a.org)
+
+			Expect(rec.Code).NotTo(BeElementOf(http.StatusForbidden, http.StatusNotFound), "%s: %s", key, rec.Body.String())
+		}
+	})
+})
+
+func containsJSON(body interface{}, s string) bool {
+	if body == nil {
+		return false
+	}
+	encoded, _ := json.Marshal(body)
+	return strings.Contains(string(encoded), s)
+}
@@ -0,0 +1,478 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/auth"
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/notify"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+var (
+	// ErrEmailTaken is returned when registering an email that already has an account
+	ErrEmailTaken = errors.New("email is already registered")
+
+	// ErrInvalidCredentials is returned for a wrong email or password
+	ErrInvalidCredentials = errors.New("invalid email or password")
+
+	// ErrAccountLocked is returned while an account is locked after repeated failed logins
+	ErrAccountLocked = errors.New("account is temporarily locked")
+
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+	// ErrInvalidResetToken is returned for unknown, expired or used password reset tokens
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+	// ErrPasswordTooLong is returned for passwords longer than the 72 bytes bcrypt hashes
+	ErrPasswordTooLong = errors.New("password must be at most 72 bytes")
+)
+
+// AuthService defines the interface for account and session operations
+type AuthService interface {
+	// Register creates an account, optionally with a new organization, and logs it in
+	Register(ctx context.Context, req dto.RegisterRequest, userAgent string) (*dto.AuthTokensResponse, error)
+
+	// Login checks a password and issues a token pair
+	Login(ctx context.Context, req dto.LoginRequest, userAgent string) (*dto.AuthTokensResponse, error)
+
+	// Refresh rotates a refresh token and issues a new token pair
+	Refresh(ctx context.Context, refreshToken string, userAgent string) (*dto.AuthTokensResponse, error)
+
+	// Logout revokes a refresh token and its family
+	Logout(ctx context.Context, refreshToken string) error
+
+	// ChangePassword changes a password and revokes every session of the user
+	ChangePassword(ctx context.Context, userID uuid.UUID, req dto.ChangePasswordRequest) error
+
+	// RequestPasswordReset mails a reset token if the email has an account
+	RequestPasswordReset(ctx context.Context, email string) error
+
+	// ResetPassword sets a new password with a reset token and revokes every session
+	ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error
+}
+
+// AuthServiceConfig controls token lifetimes and login lockout
+type AuthServiceConfig struct {
+	// Secret signs HS256 access tokens; it must match the auth middleware's secret
+	Secret []byte
+	// Issuer is set as the iss claim when not empty
+	Issuer          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	ResetTokenTTL   time.Duration
+	// MaxFailedLogins consecutive failures lock the account for LockoutDuration
+	MaxFailedLogins int
+	LockoutDuration time.Duration
+	// ResetURL is the page that accepts reset tokens; the token is appended as ?token=
+	ResetURL string
+}
+
+// DefaultAuthServiceConfig returns the default lifetimes and lockout policy
+func DefaultAuthServiceConfig() AuthServiceConfig {
+	return AuthServiceConfig{
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 30 * 24 * time.Hour,
+		ResetTokenTTL:   time.Hour,
+		MaxFailedLogins: 5,
+		LockoutDuration: 15 * time.Minute,
+		ResetURL:        "http://localhost:3000/reset-password",
+	}
+}
+
+// RealAuthService implements AuthService with bcrypt password hashes and
+// server-side rotating refresh tokens
+type RealAuthService struct {
+	repos  *repositories.Provider
+	config AuthServiceConfig
+	mailer notify.Notifier
+	now    func() time.Time
+}
+
+// NewAuthService creates a new auth service. Password reset emails go through mailer.
+func NewAuthService(repos *repositories.Provider, config AuthServiceConfig, mailer notify.Notifier) (*RealAuthService, error) {
+	if len(config.Secret) == 0 {
+		return nil, errors.New("auth service requires a JWT secret to sign access tokens")
+	}
+	return &RealAuthService{repos: repos, config: config, mailer: mailer, now: time.Now}, nil
+}
+
+// dummyPasswordHash is compared against when the email is unknown, so that
+// responses take the same time whether or not an account exists
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("xephyr-dummy-password"), bcrypt.DefaultCost)
+
+// Register creates an account, optionally with a new organization, and logs it in
+func (s *RealAuthService) Register(ctx context.Context, req dto.RegisterRequest, userAgent string) (*dto.AuthTokensResponse, error) {
+	email := normalizeEmail(req.Email)
+	if _, err := s.repos.GetUser().GetByEmail(ctx, email); err == nil {
+		return nil, ErrEmailTaken
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Email:        email,
+		Name:         strings.TrimSpace(req.Name),
+		PasswordHash: string(hash),
+		Timezone:     "UTC",
+		IsActive:     true,
+	}
+	err = s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		if err := tx.GetUser().Create(ctx, user); err != nil {
+			return err
+		}
+		if req.OrganizationName == "" {
+			return nil
+		}
+		org := &models.Organization{
+			Name:    strings.TrimSpace(req.OrganizationName),
+			Slug:    organizationSlug(req.OrganizationName),
+			OwnerID: &user.ID,
+		}
+		if err := tx.GetOrganization().Create(ctx, org); err != nil {
+			return err
+		}
+		return tx.GetOrganization().AddMember(ctx, org.ID, user.ID, models.RoleAdmin)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, user, uuid.New(), userAgent)
+}
+
+// Login checks a password and issues a token pair
+func (s *RealAuthService) Login(ctx context.Context, req dto.LoginRequest, userAgent string) (*dto.AuthTokensResponse, error) {
+	now := s.now()
+	user, err := s.repos.GetUser().GetByEmail(ctx, normalizeEmail(req.Email))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		return nil, ErrAccountLocked
+	}
+
+	if user.PasswordHash == "" || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		lockedUntil, err := s.repos.GetUser().RecordFailedLogin(ctx, user.ID, s.config.MaxFailedLogins, now.Add(s.config.LockoutDuration))
+		if err != nil {
+			return nil, err
+		}
+		if lockedUntil != nil {
+			log.Printf("[AuthService] Locked user %s until %s after repeated failed logins", user.ID, lockedUntil.Format(time.RFC3339))
+			return nil, ErrAccountLocked
+		}
+		return nil, ErrInvalidCredentials
+	}
+	if !user.IsActive {
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		if err := s.repos.GetUser().ClearFailedLogins(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	return s.issueTokens(ctx, user, uuid.New(), userAgent)
+}
+
+// Refresh rotates a refresh token and issues a new token pair. Presenting a token
+// that was already rotated means it leaked, so its whole family is revoked.
+func (s *RealAuthService) Refresh(ctx context.Context, refreshToken string, userAgent string) (*dto.AuthTokensResponse, error) {
+	now := s.now()
+	stored, err := s.repos.GetAuth().GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if stored.RevokedAt != nil {
+		if stored.ReplacedByID != nil {
+			log.Printf("[AuthService] Refresh token reuse detected for user %s, revoking family %s", stored.UserID, stored.FamilyID)
+			if err := s.repos.GetAuth().RevokeRefreshTokenFamily(ctx, stored.FamilyID, now); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if now.After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.repos.GetUser().GetByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	var tokens *dto.AuthTokensResponse
+	err = s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		var err error
+		tokens, err = s.withRepos(tx).issueTokens(ctx, user, stored.FamilyID, userAgent)
+		if err != nil {
+			return err
+		}
+		replacement, err := tx.GetAuth().GetRefreshTokenByHash(ctx, hashToken(tokens.RefreshToken))
+		if err != nil {
+			return err
+		}
+		return tx.GetAuth().RevokeRefreshToken(ctx, stored.ID, &replacement.ID, now)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Another request rotated the token first
+		return nil, ErrInvalidRefreshToken
+	}
+	return tokens, err
+}
+
+// Logout revokes a refresh token and its family
+func (s *RealAuthService) Logout(ctx context.Context, refreshToken string) error {
+	stored, err := s.repos.GetAuth().GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return s.repos.GetAuth().RevokeRefreshTokenFamily(ctx, stored.FamilyID, s.now())
+}
+
+// ChangePassword changes a password and revokes every session of the user
+func (s *RealAuthService) ChangePassword(ctx context.Context, userID uuid.UUID, req dto.ChangePasswordRequest) error {
+	user, err := s.repos.GetUser().GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)) != nil {
+		return ErrInvalidCredentials
+	}
+	return s.setPassword(ctx, s.repos, user.ID, req.NewPassword)
+}
+
+// RequestPasswordReset mails a reset token if the email has an account. It reports
+// success either way so that it cannot be used to discover accounts.
+func (s *RealAuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.repos.GetUser().GetByEmail(ctx, normalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	reset := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: s.now().Add(s.config.ResetTokenTTL),
+	}
+	if err := s.repos.GetAuth().CreatePasswordReset(ctx, reset); err != nil {
+		return err
+	}
+
+	if s.mailer == nil {
+		log.Printf("[AuthService] No mailer configured; password reset for user %s not sent", user.ID)
+		return nil
+	}
+	msg := notify.Message{
+		Subject: "Reset your Xephyr password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %s.\n\n%s?token=%s\n\nIf you did not ask for this, you can ignore this email.",
+			user.Name, s.config.ResetTokenTTL, s.config.ResetURL, token),
+		SentAt: s.now().UTC(),
+	}
+	if err := s.mailer.Send(ctx, user.Email, msg); err != nil {
+		log.Printf("[AuthService] Failed to send password reset to user %s: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with a reset token and revokes every session
+func (s *RealAuthService) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error {
+	now := s.now()
+	reset, err := s.repos.GetAuth().GetPasswordResetByHash(ctx, hashToken(req.Token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+	if reset.UsedAt != nil || now.After(reset.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	err = s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		if err := tx.GetAuth().MarkPasswordResetUsed(ctx, reset.ID, now); err != nil {
+			return err
+		}
+		if err := tx.GetUser().ClearFailedLogins(ctx, reset.UserID); err != nil {
+			return err
+		}
+		return s.setPassword(ctx, tx, reset.UserID, req.NewPassword)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidResetToken
+	}
+	return err
+}
+
+// setPassword stores a new password hash and revokes all refresh tokens
+func (s *RealAuthService) setPassword(ctx context.Context, repos *repositories.Provider, userID uuid.UUID, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := repos.GetUser().UpdatePassword(ctx, userID, string(hash)); err != nil {
+		return err
+	}
+	return repos.GetAuth().RevokeUserRefreshTokens(ctx, userID, s.now())
+}
+
+// hashPassword hashes a password with bcrypt. Request validation bounds passwords in
+// characters, so multi-byte passwords can still exceed the 72 bytes bcrypt accepts.
+func hashPassword(password string) ([]byte, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		return nil, ErrPasswordTooLong
+	}
+	return hash, err
+}
+
+// issueTokens signs an access token carrying the user's memberships and stores a new
+// refresh token in the given family
+func (s *RealAuthService) issueTokens(ctx context.Context, user *models.User, familyID uuid.UUID, userAgent string) (*dto.AuthTokensResponse, error) {
+	now := s.now()
+	memberships, err := s.repos.GetOrganization().ListMemberships(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := auth.Claims{
+		Subject:   user.ID.String(),
+		Issuer:    s.config.Issuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.config.AccessTokenTTL).Unix(),
+		ID:        uuid.NewString(),
+		Type:      auth.TokenTypeAccess,
+	}
+	authUser := dto.AuthUser{ID: user.ID.String(), Email: user.Email, Name: user.Name, Memberships: []dto.AuthMembership{}}
+	for _, m := range memberships {
+		claims.Organizations = append(claims.Organizations, auth.Membership{OrganizationID: m.OrganizationID.String(), Role: string(m.Role)})
+		authUser.Memberships = append(authUser.Memberships, dto.AuthMembership{OrganizationID: m.OrganizationID.String(), Role: string(m.Role)})
+	}
+	if len(memberships) > 0 {
+		claims.OrganizationID = memberships[0].OrganizationID.String()
+		claims.Role = string(memberships[0].Role)
+	}
+
+	accessToken, err := auth.SignHS256(claims, s.config.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	stored := &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: now.Add(s.config.RefreshTokenTTL),
+		UserAgent: truncate(userAgent, 255),
+	}
+	if err := s.repos.GetAuth().CreateRefreshToken(ctx, stored); err != nil {
+		return nil, err
+	}
+
+	return &dto.AuthTokensResponse{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresAt:        time.Unix(claims.ExpiresAt, 0).UTC(),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: stored.ExpiresAt.UTC(),
+		User:             authUser,
+	}, nil
+}
+
+// withRepos returns a copy of the service that uses the given repositories
+func (s *RealAuthService) withRepos(repos *repositories.Provider) *RealAuthService {
+	clone := *s
+	clone.repos = repos
+	return &clone
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// randomToken returns 32 random bytes, URL-safe encoded
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is how opaque tokens are stored; they are random enough that a fast hash suffices
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+var slugInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+
+// organizationSlug derives a unique slug from an organization name
+func organizationSlug(name string) string {
+	slug := strings.Trim(slugInvalid.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(slug) > 40 {
+		slug = strings.TrimRight(slug[:40], "-")
+	}
+	if slug == "" {
+		slug = "org"
+	}
+	return slug + "-" + uuid.NewString()[:8]
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,330 @@
+package services_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/auth"
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// memAccountRepo stores users by ID for the auth flows
+type memAccountRepo struct {
+	repositories.UserRepository
+	users map[uuid.UUID]*models.User
+}
+
+func (r *memAccountRepo) Create(ctx context.Context, user *models.User) error {
+	user.ID = uuid.New()
+	clone := *user
+	r.users[user.ID] = &clone
+	return nil
+}
+
+func (r *memAccountRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	u, ok := r.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	clone := *u
+	return &clone, nil
+}
+
+func (r *memAccountRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			clone := *u
+			return &clone, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memAccountRepo) UpdatePassword(ctx context.Context, userID uuid.UUID, hash string) error {
+	r.users[userID].PasswordHash = hash
+	return nil
+}
+
+func (r *memAccountRepo) RecordFailedLogin(ctx context.Context, userID uuid.UUID, maxAttempts int, lockUntil time.Time) (*time.Time, error) {
+	u := r.users[userID]
+	u.FailedLoginAttempts++
+	if u.FailedLoginAttempts < maxAttempts {
+		return nil, nil
+	}
+	u.FailedLoginAttempts = 0
+	u.LockedUntil = &lockUntil
+	return &lockUntil, nil
+}
+
+func (r *memAccountRepo) ClearFailedLogins(ctx context.Context, userID uuid.UUID) error {
+	r.users[userID].FailedLoginAttempts = 0
+	r.users[userID].LockedUntil = nil
+	return nil
+}
+
+type memMembershipRepo struct {
+	repositories.OrganizationRepository
+	orgs        []models.Organization
+	memberships []models.OrganizationMember
+}
+
+func (r *memMembershipRepo) Create(ctx context.Context, org *models.Organization) error {
+	org.ID = uuid.New()
+	r.orgs = append(r.orgs, *org)
+	return nil
+}
+
+func (r *memMembershipRepo) AddMember(ctx context.Context, orgID, userID uuid.UUID, role models.UserRole) error {
+	r.memberships = append(r.memberships, models.OrganizationMember{OrganizationID: orgID, UserID: userID, Role: role})
+	return nil
+}
+
+func (r *memMembershipRepo) ListMemberships(ctx context.Context, userID uuid.UUID) ([]models.OrganizationMember, error) {
+	var found []models.OrganizationMember
+	for _, m := range r.memberships {
+		if m.UserID == userID {
+			found = append(found, m)
+		}
+	}
+	return found, nil
+}
+
+type memAuthRepo struct {
+	repositories.AuthRepository
+	refresh []*models.RefreshToken
+	resets  []*models.PasswordResetToken
+}
+
+func (r *memAuthRepo) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	token.ID = uuid.New()
+	clone := *token
+	r.refresh = append(r.refresh, &clone)
+	return nil
+}
+
+func (r *memAuthRepo) GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	for _, t := range r.refresh {
+		if t.TokenHash == hash {
+			clone := *t
+			return &clone, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memAuthRepo) RevokeRefreshToken(ctx context.Context, id uuid.UUID, replacedBy *uuid.UUID, now time.Time) error {
+	for _, t := range r.refresh {
+		if t.ID == id && t.RevokedAt == nil {
+			t.RevokedAt, t.ReplacedByID = &now, replacedBy
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *memAuthRepo) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID, now time.Time) error {
+	for _, t := range r.refresh {
+		if t.FamilyID == familyID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *memAuthRepo) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	for _, t := range r.refresh {
+		if t.UserID == userID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *memAuthRepo) CreatePasswordReset(ctx context.Context, reset *models.PasswordResetToken) error {
+	reset.ID = uuid.New()
+	clone := *reset
+	r.resets = append(r.resets, &clone)
+	return nil
+}
+
+func (r *memAuthRepo) GetPasswordResetByHash(ctx context.Context, hash string) (*models.PasswordResetToken, error) {
+	for _, t := range r.resets {
+		if t.TokenHash == hash {
+			clone := *t
+			return &clone, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memAuthRepo) MarkPasswordResetUsed(ctx context.Context, id uuid.UUID, now time.Time) error {
+	for _, t := range r.resets {
+		if t.ID == id && t.UsedAt == nil {
+			t.UsedAt = &now
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+var _ = Describe("Auth Service", func() {
+	secret := []byte("test-secret")
+
+	var (
+		ctx     context.Context
+		users   *memAccountRepo
+		orgs    *memMembershipRepo
+		tokens  *memAuthRepo
+		mailer  *fakeNotifier
+		service services.AuthService
+	)
+
+	register := func() *dto.AuthTokensResponse {
+		result, err := service.Register(ctx, dto.RegisterRequest{
+			Email: "Emma@Example.com", Password: "correct-horse", Name: "Emma", OrganizationName: "Acme Corp",
+		}, "test")
+		Expect(err).NotTo(HaveOccurred())
+		return result
+	}
+
+	login := func(password string) (*dto.AuthTokensResponse, error) {
+		return service.Login(ctx, dto.LoginRequest{Email: "emma@example.com", Password: password}, "test")
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		users = &memAccountRepo{users: map[uuid.UUID]*models.User{}}
+		orgs = &memMembershipRepo{}
+		tokens = &memAuthRepo{}
+		mailer = &fakeNotifier{channel: models.NotificationChannelEmail}
+		repos := &repositories.Provider{User: users, Organization: orgs, Auth: tokens}
+
+		cfg := services.DefaultAuthServiceConfig()
+		cfg.Secret = secret
+		var err error
+		service, err = services.NewAuthService(repos, cfg, mailer)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("Given a new registration", func() {
+		It("should create the account as admin of its organization and issue valid tokens", func() {
+			result := register()
+
+			Expect(result.User.Email).To(Equal("emma@example.com"))
+			Expect(result.User.Memberships).To(HaveLen(1))
+			Expect(result.User.Memberships[0].Role).To(Equal("admin"))
+			Expect(orgs.orgs[0].OwnerID).To(HaveValue(Equal(uuid.MustParse(result.User.ID))))
+			Expect(users.users[uuid.MustParse(result.User.ID)].PasswordHash).To(HavePrefix("$2"))
+
+			verifier, _ := auth.NewVerifier(auth.VerifierConfig{Secret: secret})
+			claims, err := verifier.Verify(result.AccessToken, time.Now())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claims.OrganizationID).To(Equal(result.User.Memberships[0].OrganizationID))
+			Expect(claims.Role).To(Equal("admin"))
+		})
+
+		It("should refuse a second account with the same email", func() {
+			register()
+			_, err := service.Register(ctx, dto.RegisterRequest{Email: "emma@example.com", Password: "another-pass", Name: "E"}, "")
+			Expect(err).To(MatchError(services.ErrEmailTaken))
+		})
+
+		It("should refuse a password of more than 72 bytes", func() {
+			// 40 characters fit the request's limit but take 80 bytes
+			_, err := service.Register(ctx, dto.RegisterRequest{Email: "emma@example.com", Password: strings.Repeat("é", 40), Name: "E"}, "")
+			Expect(err).To(MatchError(services.ErrPasswordTooLong))
+		})
+	})
+
+	Context("Given repeated failed logins", func() {
+		It("should lock the account after the limit", func() {
+			register()
+			for i := 0; i < 4; i++ {
+				_, err := login("wrong")
+				Expect(err).To(MatchError(services.ErrInvalidCredentials))
+			}
+			_, err := login("wrong")
+			Expect(err).To(MatchError(services.ErrAccountLocked))
+
+			_, err = login("correct-horse")
+			Expect(err).To(MatchError(services.ErrAccountLocked))
+		})
+
+		It("should reset the count after a successful login", func() {
+			register()
+			login("wrong")
+			_, err := login("correct-horse")
+			Expect(err).NotTo(HaveOccurred())
+			for _, u := range users.users {
+				Expect(u.FailedLoginAttempts).To(BeZero())
+			}
+		})
+	})
+
+	Context("Given a refresh token", func() {
+		It("should rotate it and reject the old one", func() {
+			first := register()
+
+			second, err := service.Refresh(ctx, first.RefreshToken, "test")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second.RefreshToken).NotTo(Equal(first.RefreshToken))
+
+			_, err = service.Refresh(ctx, first.RefreshToken, "test")
+			Expect(err).To(MatchError(services.ErrInvalidRefreshToken))
+		})
+
+		It("should revoke the whole family when a rotated token is reused", func() {
+			first := register()
+			second, _ := service.Refresh(ctx, first.RefreshToken, "test")
+
+			service.Refresh(ctx, first.RefreshToken, "test")
+
+			_, err := service.Refresh(ctx, second.RefreshToken, "test")
+			Expect(err).To(MatchError(services.ErrInvalidRefreshToken))
+		})
+
+		It("should stop working after logout", func() {
+			first := register()
+			Expect(service.Logout(ctx, first.RefreshToken)).To(Succeed())
+			_, err := service.Refresh(ctx, first.RefreshToken, "test")
+			Expect(err).To(MatchError(services.ErrInvalidRefreshToken))
+		})
+	})
+
+	Context("Given a password reset", func() {
+		It("should mail a single-use token that sets a new password and ends sessions", func() {
+			session := register()
+			Expect(service.RequestPasswordReset(ctx, "emma@example.com")).To(Succeed())
+			Expect(mailer.targets).To(ConsistOf("emma@example.com"))
+
+			link := mailer.sent[0].Body[strings.Index(mailer.sent[0].Body, "http"):]
+			link = strings.Fields(link)[0]
+			parsed, err := url.Parse(link)
+			Expect(err).NotTo(HaveOccurred())
+			token := parsed.Query().Get("token")
+
+			Expect(service.ResetPassword(ctx, dto.ResetPasswordRequest{Token: token, NewPassword: "new-password"})).To(Succeed())
+			Expect(service.ResetPassword(ctx, dto.ResetPasswordRequest{Token: token, NewPassword: "again-password"})).To(MatchError(services.ErrInvalidResetToken))
+
+			_, err = login("new-password")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = service.Refresh(ctx, session.RefreshToken, "test")
+			Expect(err).To(MatchError(services.ErrInvalidRefreshToken))
+		})
+
+		It("should not reveal unknown emails", func() {
+			Expect(service.RequestPasswordReset(ctx, "nobody@example.com")).To(Succeed())
+			Expect(mailer.sent).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// errDependencyCycle is returned when a graph that must be acyclic has a cycle
+var errDependencyCycle = errors.New("dependency graph has a cycle")
+
+// dependencyGraph is an in-memory view of tasks and the dependencies between them.
+// Dependencies naming a task outside the graph are left out.
+type dependencyGraph struct {
+	tasks map[uuid.UUID]*models.Task
+	order []uuid.UUID // tasks in the order they were loaded, for stable output
+
+	// projects are the projects the graph was loaded for, when it was loaded by
+	// project. Tasks of other projects are in it because these projects depend on them.
+	projects map[uuid.UUID]bool
+
+	// start is when work on the tasks can begin, the origin of their schedule
+	start time.Time
+
+	// predecessors are the dependencies of a task, successors the dependencies on it
+	predecessors map[uuid.UUID][]models.TaskDependency
+	successors   map[uuid.UUID][]models.TaskDependency
+}
+
+// dependencyChain is a path from a task through its dependencies or its dependents. The
+// path starts at the task it was searched from.
+type dependencyChain struct {
+	path []uuid.UUID
+}
+
+// newDependencyGraph builds the graph of the given tasks and dependencies
+func newDependencyGraph(tasks []models.Task, deps []models.TaskDependency) *dependencyGraph {
+	g := &dependencyGraph{
+		tasks:        make(map[uuid.UUID]*models.Task, len(tasks)),
+		predecessors: map[uuid.UUID][]models.TaskDependency{},
+		successors:   map[uuid.UUID][]models.TaskDependency{},
+	}
+	for i := range tasks {
+		if _, ok := g.tasks[tasks[i].ID]; ok {
+			continue
+		}
+		g.tasks[tasks[i].ID] = &tasks[i]
+		g.order = append(g.order, tasks[i].ID)
+	}
+	for _, d := range deps {
+		g.addDependency(d)
+	}
+	return g
+}
+
+// addDependency adds an edge between two tasks of the graph
+func (g *dependencyGraph) addDependency(d models.TaskDependency) {
+	if g.tasks[d.TaskID] == nil || g.tasks[d.DependsOnTaskID] == nil {
+		return
+	}
+	for _, existing := range g.predecessors[d.TaskID] {
+		if existing.DependsOnTaskID == d.DependsOnTaskID {
+			return
+		}
+	}
+	g.predecessors[d.TaskID] = append(g.predecessors[d.TaskID], d)
+	g.successors[d.DependsOnTaskID] = append(g.successors[d.DependsOnTaskID], d)
+}
+
+// dependencyPath finds the shortest chain of dependencies leading from one task to
+// another, as the tasks along it from first to last, or nil when from does not depend
+// on to
+func (g *dependencyGraph) dependencyPath(from, to uuid.UUID) []uuid.UUID {
+	for _, chain := range g.upstream(from) {
+		if chain.path[len(chain.path)-1] == to {
+			return chain.path
+		}
+	}
+	return nil
+}
+
+// upstream lists every task the task depends on, directly or transitively, with the
+// shortest chain leading to it, nearest first
+func (g *dependencyGraph) upstream(id uuid.UUID) []dependencyChain {
+	return g.walk(id, func(t uuid.UUID) []uuid.UUID {
+		next := make([]uuid.UUID, 0, len(g.predecessors[t]))
+		for _, d := range g.predecessors[t] {
+			next = append(next, d.DependsOnTaskID)
+		}
+		return next
+	})
+}
+
+// downstream lists every task depending on the task, directly or transitively, with
+// the shortest chain leading to it, nearest first
+func (g *dependencyGraph) downstream(id uuid.UUID) []dependencyChain {
+	return g.walk(id, func(t uuid.UUID) []uuid.UUID {
+		next := make([]uuid.UUID, 0, len(g.successors[t]))
+		for _, d := range g.successors[t] {
+			next = append(next, d.TaskID)
+		}
+		return next
+	})
+}
+
+// walk searches breadth first from a task, so each task is reached by a shortest chain
+func (g *dependencyGraph) walk(start uuid.UUID, next func(uuid.UUID) []uuid.UUID) []dependencyChain {
+	var chains []dependencyChain
+	seen := map[uuid.UUID]bool{start: true}
+	queue := [][]uuid.UUID{{start}}
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		for _, id := range next(path[len(path)-1]) {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			extended := append(append([]uuid.UUID(nil), path...), id)
+			chains = append(chains, dependencyChain{path: extended})
+			queue = append(queue, extended)
+		}
+	}
+	return chains
+}
+
+// topologicalOrder orders tasks so each comes after everything it depends on, keeping
+// the load order among tasks that are free to go first
+func (g *dependencyGraph) topologicalOrder() ([]uuid.UUID, error) {
+	remaining := make(map[uuid.UUID]int, len(g.order))
+	for _, id := range g.order {
+		remaining[id] = len(g.predecessors[id])
+	}
+	var ready, order []uuid.UUID
+	for _, id := range g.order {
+		if remaining[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+		for _, d := range g.successors[id] {
+			remaining[d.TaskID]--
+			if remaining[d.TaskID] == 0 {
+				ready = append(ready, d.TaskID)
+			}
+		}
+	}
+	if len(order) != len(g.order) {
+		return nil, errDependencyCycle
+	}
+	return order, nil
+}
+
+// levels is how many dependencies deep each task sits: tasks without predecessors are
+// at level 0 and every other task one level below its deepest predecessor
+func (g *dependencyGraph) levels(order []uuid.UUID) map[uuid.UUID]int {
+	level := make(map[uuid.UUID]int, len(order))
+	for _, id := range order {
+		for _, d := range g.predecessors[id] {
+			if l := level[d.DependsOnTaskID] + 1; l > level[id] {
+				level[id] = l
+			}
+		}
+	}
+	return level
+}
+
+// external reports whether a task belongs to a project the graph was not loaded for
+func (g *dependencyGraph) external(id uuid.UUID) bool {
+	return g.projects != nil && !g.projects[g.tasks[id].ProjectID]
+}
+
+// crossProject reports whether a dependency links tasks of two projects
+func (g *dependencyGraph) crossProject(d models.TaskDependency) bool {
+	return g.tasks[d.TaskID].ProjectID != g.tasks[d.DependsOnTaskID].ProjectID
+}
+
+// duration is the working hours a task takes. Archived tasks take no time.
+func (g *dependencyGraph) duration(id uuid.UUID) float64 {
+	t := g.tasks[id]
+	if t.ArchivedAt != nil || t.EstimatedHours < 0 {
+		return 0
+	}
+	return t.EstimatedHours
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// Formats a dependency graph can be rendered in besides JSON
+const (
+	GraphFormatDOT     = "dot"
+	GraphFormatMermaid = "mermaid"
+	GraphFormatGraphML = "graphml"
+)
+
+// ErrUnsupportedGraphFormat is returned when a dependency graph is asked for in a format
+// it cannot be rendered in
+var ErrUnsupportedGraphFormat = errors.New("unsupported graph format")
+
+// graphRenderer turns a dependency graph into diagram source of one format
+type graphRenderer struct {
+	contentType string
+	render      func(graph *dto.DependencyGraphResponse) (string, error)
+}
+
+var graphRenderers = map[string]graphRenderer{
+	GraphFormatDOT:     {contentType: "text/vnd.graphviz; charset=utf-8", render: renderDOT},
+	GraphFormatMermaid: {contentType: "text/plain; charset=utf-8", render: renderMermaid},
+	GraphFormatGraphML: {contentType: "application/graphml+xml; charset=utf-8", render: renderGraphML},
+}
+
+// Diagram colours: critical tasks and dependencies in red, finished tasks greyed out
+const (
+	graphCriticalColor = "#d62728"
+	graphDoneFill      = "#eeeeee"
+	graphDoneText      = "#777777"
+)
+
+// SupportsGraphFormat reports whether a dependency graph can be rendered in a format
+func SupportsGraphFormat(format string) bool {
+	_, ok := graphRenderers[format]
+	return ok
+}
+
+// RenderDependencyGraph renders a dependency graph as Graphviz DOT, a Mermaid flowchart
+// or GraphML, returning the diagram source and its content type. Critical tasks and
+// dependencies are highlighted, subtasks are clustered under their top-level task and
+// dependencies are labelled with their type and lag.
+func RenderDependencyGraph(graph *dto.DependencyGraphResponse, format string) (string, string, error) {
+	renderer, ok := graphRenderers[format]
+	if !ok {
+		return "", "", fmt.Errorf("%w: %q", ErrUnsupportedGraphFormat, format)
+	}
+	content, err := renderer.render(graph)
+	if err != nil {
+		return "", "", err
+	}
+	return content, renderer.contentType, nil
+}
+
+// graphTitle names a diagram after its project
+func graphTitle(graph *dto.DependencyGraphResponse) string {
+	if graph.ProjectName != "" {
+		return graph.ProjectName
+	}
+	return "Project " + graph.ProjectID
+}
+
+// edgeLabel is the short form of a dependency's type, followed by its lag or lead,
+// such as "FS" or "SS +4h"
+func edgeLabel(edge dto.DependencyGraphEdge) string {
+	label := map[string]string{
+		string(models.DependencyFinishToStart):  "FS",
+		string(models.DependencyStartToStart):   "SS",
+		string(models.DependencyFinishToFinish): "FF",
+		string(models.DependencyStartToFinish):  "SF",
+	}[edge.Type]
+	if label == "" {
+		label = edge.Type
+	}
+	if edge.LagHours != 0 {
+		label += fmt.Sprintf(" %+dh", edge.LagHours)
+	}
+	return label
+}
+
+// clusterMembers lists the nodes of each cluster, and the nodes in none, in graph order
+func clusterMembers(graph *dto.DependencyGraphResponse) (map[string][]dto.DependencyGraphNode, []dto.DependencyGraphNode) {
+	members := map[string][]dto.DependencyGraphNode{}
+	var loose []dto.DependencyGraphNode
+	for _, n := range graph.Nodes {
+		if n.ClusterID == "" {
+			loose = append(loose, n)
+			continue
+		}
+		members[n.ClusterID] = append(members[n.ClusterID], n)
+	}
+	return members, loose
+}
+
+// renderDOT writes the graph for Graphviz, each cluster a subgraph
+func renderDOT(graph *dto.DependencyGraphResponse) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(graphTitle(graph)))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded, fontname=Helvetica];\n")
+	b.WriteString("  edge [fontname=Helvetica, fontsize=10];\n")
+
+	node := func(indent string, n dto.DependencyGraphNode) {
+		attrs := []string{"label=" + dotQuote(n.Title)}
+		styles := []string{"rounded"}
+		if n.Status == string(models.TaskStatusDone) {
+			styles = append(styles, "filled")
+			attrs = append(attrs, "fillcolor="+dotQuote(graphDoneFill), "fontcolor="+dotQuote(graphDoneText))
+		}
+		if n.External {
+			styles = append(styles, "dashed")
+		}
+		if n.Critical {
+			styles = append(styles, "bold")
+			attrs = append(attrs, "color="+dotQuote(graphCriticalColor), "penwidth=2")
+		}
+		attrs = append(attrs, "style="+dotQuote(strings.Join(styles, ",")))
+		fmt.Fprintf(&b, "%s%s [%s];\n", indent, dotQuote(n.ID), strings.Join(attrs, ", "))
+	}
+
+	members, loose := clusterMembers(graph)
+	for _, c := range graph.Clusters {
+		fmt.Fprintf(&b, "  subgraph %s {\n", dotQuote("cluster_"+c.ID))
+		fmt.Fprintf(&b, "    label=%s;\n", dotQuote(c.Title))
+		b.WriteString("    style=\"rounded,dashed\";\n")
+		for _, n := range members[c.ID] {
+			node("    ", n)
+		}
+		b.WriteString("  }\n")
+	}
+	for _, n := range loose {
+		node("  ", n)
+	}
+
+	for _, e := range graph.Edges {
+		attrs := []string{"label=" + dotQuote(edgeLabel(e))}
+		if e.Critical {
+			attrs = append(attrs, "color="+dotQuote(graphCriticalColor), "fontcolor="+dotQuote(graphCriticalColor), "penwidth=2")
+		}
+		if e.CrossProject {
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotQuote(e.Source), dotQuote(e.Target), strings.Join(attrs, ", "))
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// dotQuote makes a string a quoted DOT identifier
+func dotQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r", "", "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
+
+// renderMermaid writes the graph as a Mermaid flowchart, each cluster a subgraph.
+// Mermaid ids cannot hold every character, so tasks are numbered in graph order.
+func renderMermaid(graph *dto.DependencyGraphResponse) (string, error) {
+	ids := make(map[string]string, len(graph.Nodes))
+	for i, n := range graph.Nodes {
+		ids[n.ID] = "t" + strconv.Itoa(i)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "---\ntitle: %s\n---\n", strconv.Quote(graphTitle(graph)))
+	b.WriteString("flowchart LR\n")
+
+	var critical, done, external []string
+	node := func(indent string, n dto.DependencyGraphNode) {
+		fmt.Fprintf(&b, "%s%s[\"%s\"]\n", indent, ids[n.ID], mermaidText(n.Title))
+		if n.Critical {
+			critical = append(critical, ids[n.ID])
+		}
+		if n.Status == string(models.TaskStatusDone) {
+			done = append(done, ids[n.ID])
+		}
+		if n.External {
+			external = append(external, ids[n.ID])
+		}
+	}
+
+	members, loose := clusterMembers(graph)
+	for i, c := range graph.Clusters {
+		fmt.Fprintf(&b, "  subgraph c%d[\"%s\"]\n", i, mermaidText(c.Title))
+		for _, n := range members[c.ID] {
+			node("    ", n)
+		}
+		b.WriteString("  end\n")
+	}
+	for _, n := range loose {
+		node("  ", n)
+	}
+
+	// Critical dependencies are drawn thick; link styles refer to edges by position
+	var criticalEdges []string
+	for i, e := range graph.Edges {
+		arrow := "-->"
+		switch {
+		case e.Critical:
+			arrow = "==>"
+			criticalEdges = append(criticalEdges, strconv.Itoa(i))
+		case e.CrossProject:
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s|\"%s\"| %s\n", ids[e.Source], arrow, mermaidText(edgeLabel(e)), ids[e.Target])
+	}
+
+	fmt.Fprintf(&b, "  classDef critical stroke:%s,stroke-width:3px\n", graphCriticalColor)
+	fmt.Fprintf(&b, "  classDef done fill:%s,color:%s\n", graphDoneFill, graphDoneText)
+	b.WriteString("  classDef external stroke-dasharray:5 5\n")
+	for _, class := range []struct {
+		name  string
+		nodes []string
+	}{{"done", done}, {"external", external}, {"critical", critical}} {
+		if len(class.nodes) > 0 {
+			fmt.Fprintf(&b, "  class %s %s\n", strings.Join(class.nodes, ","), class.name)
+		}
+	}
+	if len(criticalEdges) > 0 {
+		fmt.Fprintf(&b, "  linkStyle %s stroke:%s,stroke-width:3px\n", strings.Join(criticalEdges, ","), graphCriticalColor)
+	}
+	return b.String(), nil
+}
+
+// mermaidText escapes text for a quoted Mermaid label
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\r", "", "\n", " ").Replace(s)
+}
+
+// GraphML document, with each cluster a task node holding a nested graph of its subtasks
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Data        []graphMLData `xml:"data"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID    string        `xml:"id,attr"`
+	Data  []graphMLData `xml:"data"`
+	Graph *graphMLGraph `xml:"graph,omitempty"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// renderGraphML writes the graph as GraphML for yEd and other graph tools
+func renderGraphML(graph *dto.DependencyGraphResponse) (string, error) {
+	doc := graphMLDocument{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "name", For: "graph", AttrName: "name", AttrType: "string"},
+			{ID: "label", For: "all", AttrName: "label", AttrType: "string"},
+			{ID: "critical", For: "all", AttrName: "critical", AttrType: "boolean"},
+			{ID: "status", For: "node", AttrName: "status", AttrType: "string"},
+			{ID: "project", For: "node", AttrName: "projectId", AttrType: "string"},
+			{ID: "external", For: "node", AttrName: "external", AttrType: "boolean"},
+			{ID: "type", For: "edge", AttrName: "dependencyType", AttrType: "string"},
+			{ID: "lag", For: "edge", AttrName: "lagHours", AttrType: "int"},
+			{ID: "crossProject", For: "edge", AttrName: "crossProject", AttrType: "boolean"},
+		},
+		Graph: graphMLGraph{
+			ID:          graph.ProjectID,
+			EdgeDefault: "directed",
+			Data:        []graphMLData{{Key: "name", Value: graphTitle(graph)}},
+		},
+	}
+
+	node := func(n dto.DependencyGraphNode) graphMLNode {
+		return graphMLNode{ID: n.ID, Data: []graphMLData{
+			{Key: "label", Value: n.Title},
+			{Key: "critical", Value: strconv.FormatBool(n.Critical)},
+			{Key: "status", Value: n.Status},
+			{Key: "project", Value: n.ProjectID},
+			{Key: "external", Value: strconv.FormatBool(n.External)},
+		}}
+	}
+	members, loose := clusterMembers(graph)
+	for _, c := range graph.Clusters {
+		var top graphMLNode
+		nested := &graphMLGraph{ID: c.ID + ":", EdgeDefault: "directed"}
+		for _, n := range members[c.ID] {
+			if n.ID == c.ID {
+				top = node(n)
+				continue
+			}
+			nested.Nodes = append(nested.Nodes, node(n))
+		}
+		top.Graph = nested
+		doc.Graph.Nodes = append(doc.Graph.Nodes, top)
+	}
+	for _, n := range loose {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node(n))
+	}
+
+	// Edges sit in the top-level graph, which holds both ends of every one of them
+	for _, e := range graph.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{ID: e.ID, Source: e.Source, Target: e.Target, Data: []graphMLData{
+			{Key: "label", Value: edgeLabel(e)},
+			{Key: "critical", Value: strconv.FormatBool(e.Critical)},
+			{Key: "type", Value: e.Type},
+			{Key: "lag", Value: strconv.Itoa(e.LagHours)},
+			{Key: "crossProject", Value: strconv.FormatBool(e.CrossProject)},
+		}})
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(out) + "\n", nil
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// Working time: tasks are scheduled in working hours, eight a day from 09:00 UTC,
+// Monday to Friday
+const (
+	workdayStartHour = 9
+	workdayHours     = 8
+)
+
+// workCalendar turns working hours counted from the start of a schedule into dates
+type workCalendar struct {
+	start time.Time // the start of the first working day
+}
+
+// newWorkCalendar starts a calendar on the first working day on or after start
+func newWorkCalendar(start time.Time) workCalendar {
+	start = start.UTC()
+	day := time.Date(start.Year(), start.Month(), start.Day(), workdayStartHour, 0, 0, 0, time.UTC)
+	for !isWorkday(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return workCalendar{start: day}
+}
+
+// startAt is the date work begins after the given working hours
+func (c workCalendar) startAt(hours float64) time.Time {
+	return c.at(hours, false)
+}
+
+// finishAt is the date work ends after the given working hours. Work ending with a
+// working day ends that evening rather than the next morning.
+func (c workCalendar) finishAt(hours float64) time.Time {
+	return c.at(hours, true)
+}
+
+// at is the date the given working hours after the start
+func (c workCalendar) at(hours float64, finish bool) time.Time {
+	minutes := int(math.Round(math.Max(hours, 0) * 60))
+	days, within := minutes/(workdayHours*60), minutes%(workdayHours*60)
+	if finish && within == 0 && days > 0 {
+		days, within = days-1, workdayHours*60
+	}
+	day := c.start
+	for days > 0 {
+		day = day.AddDate(0, 0, 1)
+		if isWorkday(day) {
+			days--
+		}
+	}
+	return day.Add(time.Duration(within) * time.Minute)
+}
+
+// addWorkingHours is the date the given working hours after t. Time outside working
+// hours does not count. Leads, as negative hours, are taken as plain hours.
+func addWorkingHours(t time.Time, hours float64) time.Time {
+	remaining := time.Duration(math.Round(hours*60)) * time.Minute
+	if remaining <= 0 {
+		return t.Add(remaining)
+	}
+	for {
+		t = nextWorkingTime(t)
+		closing := time.Date(t.Year(), t.Month(), t.Day(), workdayStartHour+workdayHours, 0, 0, 0, time.UTC)
+		if left := closing.Sub(t); remaining <= left {
+			return t.Add(remaining)
+		}
+		remaining -= closing.Sub(t)
+		t = closing
+	}
+}
+
+// workingHoursBetween counts the working hours from one date to a later one
+func workingHoursBetween(from, to time.Time) float64 {
+	from, to = from.UTC(), to.UTC()
+	var worked time.Duration
+	day := time.Date(from.Year(), from.Month(), from.Day(), workdayStartHour, 0, 0, 0, time.UTC)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !isWorkday(day) {
+			continue
+		}
+		start, end := day, day.Add(workdayHours*time.Hour)
+		if from.After(start) {
+			start = from
+		}
+		if to.Before(end) {
+			end = to
+		}
+		if end.After(start) {
+			worked += end.Sub(start)
+		}
+	}
+	return worked.Hours()
+}
+
+// nextWorkingTime is t when it falls within working hours, or else the start of the next
+// working day
+func nextWorkingTime(t time.Time) time.Time {
+	t = t.UTC()
+	for {
+		open := time.Date(t.Year(), t.Month(), t.Day(), workdayStartHour, 0, 0, 0, time.UTC)
+		if !isWorkday(t) || !t.Before(open.Add(workdayHours*time.Hour)) {
+			t = open.AddDate(0, 0, 1)
+			continue
+		}
+		if t.Before(open) {
+			return open
+		}
+		return t
+	}
+}
+
+// isWorkday reports whether a date falls on a weekday
+func isWorkday(t time.Time) bool {
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+}
+
+// dependencySchedule is the critical path schedule of a dependency graph. Times are
+// working hours from the start of the graph's calendar.
+type dependencySchedule struct {
+	calendar workCalendar
+	order    []uuid.UUID
+
+	// earlyStart and earlyFinish are as soon as the dependencies let a task run;
+	// lateStart and lateFinish as late as it can run without delaying the finish
+	earlyStart, earlyFinish map[uuid.UUID]float64
+	lateStart, lateFinish   map[uuid.UUID]float64
+
+	// tasksTo and tasksFrom count the tasks of the longest chains of dependencies
+	// ending and starting at a task, both including the task itself
+	tasksTo, tasksFrom map[uuid.UUID]int
+
+	// finish is when the last task finishes
+	finish float64
+}
+
+// schedule runs the critical path method over the graph: a forward pass finds when
+// each task can start at the earliest and a backward pass when it must finish at the
+// latest. Dependency types and lag hours decide how far apart two tasks must be.
+func (g *dependencyGraph) schedule() (*dependencySchedule, error) {
+	order, err := g.topologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+	s := &dependencySchedule{
+		calendar:    newWorkCalendar(g.start),
+		order:       order,
+		earlyStart:  make(map[uuid.UUID]float64, len(order)),
+		earlyFinish: make(map[uuid.UUID]float64, len(order)),
+		lateStart:   make(map[uuid.UUID]float64, len(order)),
+		lateFinish:  make(map[uuid.UUID]float64, len(order)),
+		tasksTo:     make(map[uuid.UUID]int, len(order)),
+		tasksFrom:   make(map[uuid.UUID]int, len(order)),
+	}
+	for _, id := range order {
+		start, tasks := 0.0, 0
+		for _, d := range g.predecessors[id] {
+			start = math.Max(start, s.earliestStart(g, d))
+			tasks = max(tasks, s.tasksTo[d.DependsOnTaskID])
+		}
+		s.earlyStart[id], s.earlyFinish[id] = start, start+g.duration(id)
+		s.tasksTo[id] = tasks + 1
+		s.finish = math.Max(s.finish, s.earlyFinish[id])
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		id := order[i]
+		finish, tasks := s.finish, 0
+		for _, d := range g.successors[id] {
+			finish = math.Min(finish, s.latestFinish(g, d))
+			tasks = max(tasks, s.tasksFrom[d.TaskID])
+		}
+		s.lateFinish[id], s.lateStart[id] = finish, finish-g.duration(id)
+		s.tasksFrom[id] = tasks + 1
+	}
+	return s, nil
+}
+
+// earliestStart is the earliest a dependency lets its task start
+func (s *dependencySchedule) earliestStart(g *dependencyGraph, d models.TaskDependency) float64 {
+	lag, p := float64(d.LagHours), d.DependsOnTaskID
+	switch d.DependencyType {
+	case models.DependencyStartToStart:
+		return s.earlyStart[p] + lag
+	case models.DependencyFinishToFinish:
+		return s.earlyFinish[p] + lag - g.duration(d.TaskID)
+	case models.DependencyStartToFinish:
+		return s.earlyStart[p] + lag - g.duration(d.TaskID)
+	}
+	return s.earlyFinish[p] + lag
+}
+
+// latestFinish is the latest a dependency lets its predecessor finish
+func (s *dependencySchedule) latestFinish(g *dependencyGraph, d models.TaskDependency) float64 {
+	lag, t := float64(d.LagHours), d.TaskID
+	switch d.DependencyType {
+	case models.DependencyStartToStart:
+		return s.lateStart[t] - lag + g.duration(d.DependsOnTaskID)
+	case models.DependencyFinishToFinish:
+		return s.lateFinish[t] - lag
+	case models.DependencyStartToFinish:
+		return s.lateFinish[t] - lag + g.duration(d.DependsOnTaskID)
+	}
+	return s.lateStart[t] - lag
+}
+
+// totalFloat is how many hours a task can slip without delaying the finish, rounded to
+// a hundredth of an hour so critical tasks have none
+func (s *dependencySchedule) totalFloat(id uuid.UUID) float64 {
+	return math.Max(roundHours(s.lateStart[id]-s.earlyStart[id]), 0)
+}
+
+// freeFloat is how many hours a task can slip without delaying any task depending on it
+func (s *dependencySchedule) freeFloat(g *dependencyGraph, id uuid.UUID) float64 {
+	float := s.finish - s.earlyFinish[id]
+	for _, d := range g.successors[id] {
+		float = math.Min(float, s.earlyStart[d.TaskID]-s.earliestStart(g, d))
+	}
+	return math.Max(roundHours(float), 0)
+}
+
+// critical reports whether a task is on the critical path: it has no float, and the
+// schedule takes some time. Archived tasks are never critical.
+func (s *dependencySchedule) critical(g *dependencyGraph, id uuid.UUID) bool {
+	return s.finish > 0 && g.tasks[id].ArchivedAt == nil && s.totalFloat(id) == 0
+}
+
+// criticalDependency reports whether a dependency runs between two critical tasks and is
+// the one deciding when the later of them can start
+func (s *dependencySchedule) criticalDependency(g *dependencyGraph, d models.TaskDependency) bool {
+	return s.critical(g, d.DependsOnTaskID) && s.critical(g, d.TaskID) &&
+		roundHours(s.earliestStart(g, d)) >= roundHours(s.earlyStart[d.TaskID])
+}
+
+// criticalPath lists the critical tasks by their early start
+func (s *dependencySchedule) criticalPath(g *dependencyGraph) []uuid.UUID {
+	var path []uuid.UUID
+	for _, id := range s.order {
+		if s.critical(g, id) {
+			path = append(path, id)
+		}
+	}
+	slices.SortStableFunc(path, func(a, b uuid.UUID) int {
+		return cmp.Compare(s.earlyStart[a], s.earlyStart[b])
+	})
+	return path
+}
+
+// tasksThrough counts the tasks in the longest chain of dependencies through a task
+func (s *dependencySchedule) tasksThrough(id uuid.UUID) int {
+	return s.tasksTo[id] + s.tasksFrom[id] - 1
+}
+
+// projectFinish is when the last task of a project finishes
+func (s *dependencySchedule) projectFinish(g *dependencyGraph, projectID uuid.UUID) float64 {
+	finish := 0.0
+	for _, id := range s.order {
+		if g.tasks[id].ProjectID == projectID {
+			finish = math.Max(finish, s.earlyFinish[id])
+		}
+	}
+	return finish
+}
@@ -21,11 +21,17 @@ type DependencyService interface {
 	// GetCriticalPath returns the critical path for a project
 	GetCriticalPath(ctx context.Context, projectID string, orgID string) (*dto.CriticalPathResponse, error)
 
+	// RecalculateCriticalPath updates which of a project's tasks are on its critical path
+	RecalculateCriticalPath(ctx context.Context, projectID string, orgID string) error
+
 	// ValidateDependency validates a potential dependency
 	ValidateDependency(ctx context.Context, req dto.ValidateDependencyRequest, orgID string) (*dto.ValidateDependencyResponse, error)
 
 	// GetDependencyGraph returns the dependency graph for a project
 	GetDependencyGraph(ctx context.Context, projectID string, orgID string) (*dto.DependencyGraphResponse, error)
+
+	// GetPortfolioGraph returns the dependencies between the organization's projects
+	GetPortfolioGraph(ctx context.Context, orgID string) (*dto.PortfolioDependencyGraphResponse, error)
 }
 
 // DummyDependencyService is a placeholder implementation of DependencyService
@@ -176,6 +182,11 @@ func (s *DummyDependencyService) GetCriticalPath(ctx context.Context, projectID
 	}, nil
 }
 
+// RecalculateCriticalPath does nothing
+func (s *DummyDependencyService) RecalculateCriticalPath(ctx context.Context, projectID string, orgID string) error {
+	return nil
+}
+
 // ValidateDependency returns dummy validation
 func (s *DummyDependencyService) ValidateDependency(ctx context.Context, req dto.ValidateDependencyRequest, orgID string) (*dto.ValidateDependencyResponse, error) {
 	return &dto.ValidateDependencyResponse{
@@ -209,3 +220,25 @@ func (s *DummyDependencyService) GetDependencyGraph(ctx context.Context, project
 		},
 	}, nil
 }
+
+// GetPortfolioGraph returns a dummy portfolio graph of two linked projects
+func (s *DummyDependencyService) GetPortfolioGraph(ctx context.Context, orgID string) (*dto.PortfolioDependencyGraphResponse, error) {
+	return &dto.PortfolioDependencyGraphResponse{
+		Projects: []dto.PortfolioProject{
+			{ProjectID: "proj-platform", Name: "Platform", Status: "active", DependedOnBy: 1, Blocking: 1, OnCriticalPath: true},
+			{ProjectID: "proj-checkout", Name: "Checkout", Status: "active", DependsOn: 1, BlockedBy: 1, OnCriticalPath: true},
+		},
+		Links: []dto.PortfolioProjectLink{
+			{Source: "proj-platform", Target: "proj-checkout", Dependencies: 1, Blocking: 1, Critical: true},
+		},
+		Nodes: []dto.DependencyGraphNode{
+			{ID: "task-1", ProjectID: "proj-platform", Title: "Auth API", Status: "in_progress", X: 0, Y: 0},
+			{ID: "task-2", ProjectID: "proj-checkout", Title: "Checkout login", Status: "backlog", X: 200, Y: 0},
+		},
+		Edges: []dto.DependencyGraphEdge{
+			{ID: "dep-1", Source: "task-1", Target: "task-2", Type: "finish_to_start", CrossProject: true},
+		},
+		CriticalPath: dto.CriticalPathInfo{TaskIDs: []string{"task-1", "task-2"}, Tasks: []dto.CriticalPathTask{}},
+		CalculatedAt: time.Now().UTC(),
+	}, nil
+}
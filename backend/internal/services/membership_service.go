@@ -0,0 +1,564 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/auth"
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/notify"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+var (
+	// ErrInvitationsDisabled is returned for invitation operations when no signing secret is configured
+	ErrInvitationsDisabled = errors.New("invitations are not configured")
+
+	// ErrInvalidInvitation is returned for forged, expired or already answered invitation tokens
+	ErrInvalidInvitation = errors.New("invalid or expired invitation")
+
+	// ErrInvitationNotFound is returned when an invitation is not pending in the organization
+	ErrInvitationNotFound = errors.New("invitation not found")
+
+	// ErrInvitationEmailMismatch is returned when an invitation is answered from another account
+	ErrInvitationEmailMismatch = errors.New("invitation was sent to a different email address")
+
+	// ErrInvitationPending is returned when inviting an email that already has a pending invitation
+	ErrInvitationPending = errors.New("an invitation is already pending for this email")
+
+	// ErrAlreadyMember is returned when inviting or adding someone who is already a member
+	ErrAlreadyMember = errors.New("user is already a member of this organization")
+
+	// ErrMemberNotFound is returned when a user is not a member of the organization
+	ErrMemberNotFound = errors.New("member not found")
+
+	// ErrLastAdmin is returned when a change would leave the organization without an admin
+	ErrLastAdmin = errors.New("organization must keep at least one admin")
+
+	// ErrOwnerRequired is returned when someone other than the owner transfers ownership
+	ErrOwnerRequired = errors.New("only the organization owner can transfer ownership")
+
+	// ErrOwnerChange is returned when demoting or removing the owner
+	ErrOwnerChange = errors.New("the owner must stay an admin; transfer ownership first")
+)
+
+// MembershipService defines the interface for organization invitations and membership
+type MembershipService interface {
+	// Invite emails a signed invitation to join the organization
+	Invite(ctx context.Context, orgID, actorID uuid.UUID, req dto.CreateInvitationRequest) (*dto.InvitationResponse, error)
+
+	// ListInvitations lists the invitations still awaiting an answer
+	ListInvitations(ctx context.Context, orgID uuid.UUID) ([]dto.InvitationResponse, error)
+
+	// RevokeInvitation withdraws a pending invitation
+	RevokeInvitation(ctx context.Context, orgID, actorID, invitationID uuid.UUID) error
+
+	// AcceptInvitation adds the caller to the organization named by the invitation token
+	AcceptInvitation(ctx context.Context, userID uuid.UUID, token string) (*dto.MemberResponse, error)
+
+	// DeclineInvitation turns down the invitation named by the token
+	DeclineInvitation(ctx context.Context, userID uuid.UUID, token string) error
+
+	// ListMembers lists the organization's members
+	ListMembers(ctx context.Context, orgID uuid.UUID) ([]dto.MemberResponse, error)
+
+	// ChangeRole changes a member's role
+	ChangeRole(ctx context.Context, orgID, actorID, userID uuid.UUID, role models.UserRole) (*dto.MemberResponse, error)
+
+	// RemoveMember removes a member from the organization
+	RemoveMember(ctx context.Context, orgID, actorID, userID uuid.UUID) error
+
+	// TransferOwnership makes another member the owner, promoting them to admin
+	TransferOwnership(ctx context.Context, orgID, actorID, newOwnerID uuid.UUID) error
+
+	// ListAuditEvents lists the organization's audit trail, newest first
+	ListAuditEvents(ctx context.Context, orgID uuid.UUID, params repositories.ListParams) (*dto.AuditEventListResponse, error)
+}
+
+// MembershipServiceConfig controls invitation tokens
+type MembershipServiceConfig struct {
+	// Secret signs HS256 invitation tokens; without it invitations are disabled
+	Secret []byte
+	// Issuer is set as the iss claim when not empty
+	Issuer        string
+	InvitationTTL time.Duration
+	// AcceptURL is the page that accepts invitations; the token is appended as ?token=
+	AcceptURL string
+}
+
+// DefaultMembershipServiceConfig returns the default invitation lifetime and link
+func DefaultMembershipServiceConfig() MembershipServiceConfig {
+	return MembershipServiceConfig{
+		InvitationTTL: 7 * 24 * time.Hour,
+		AcceptURL:     "http://localhost:3000/invitations/accept",
+	}
+}
+
+// RealMembershipService implements MembershipService. Every change is recorded in the
+// organization's audit trail in the same transaction as the change itself.
+type RealMembershipService struct {
+	repos    *repositories.Provider
+	config   MembershipServiceConfig
+	mailer   notify.Notifier
+	verifier *auth.Verifier
+	now      func() time.Time
+}
+
+// NewMembershipService creates a new membership service. Invitation emails go through mailer.
+func NewMembershipService(repos *repositories.Provider, config MembershipServiceConfig, mailer notify.Notifier) *RealMembershipService {
+	s := &RealMembershipService{repos: repos, config: config, mailer: mailer, now: time.Now}
+	if len(config.Secret) > 0 {
+		s.verifier, _ = auth.NewVerifier(auth.VerifierConfig{Secret: config.Secret, Issuer: config.Issuer})
+	}
+	return s
+}
+
+// Invite emails a signed invitation to join the organization
+func (s *RealMembershipService) Invite(ctx context.Context, orgID, actorID uuid.UUID, req dto.CreateInvitationRequest) (*dto.InvitationResponse, error) {
+	if s.verifier == nil {
+		return nil, ErrInvitationsDisabled
+	}
+	now := s.now()
+	email := normalizeEmail(req.Email)
+
+	if user, err := s.repos.GetUser().GetByEmail(ctx, email); err == nil {
+		member, err := s.repos.GetOrganization().IsMember(ctx, orgID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if member {
+			return nil, ErrAlreadyMember
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if _, err := s.repos.GetInvitation().GetPendingByEmail(ctx, orgID, email, now); err == nil {
+		return nil, ErrInvitationPending
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	invitation := &models.Invitation{
+		Email:       email,
+		Role:        models.UserRole(req.Role),
+		Status:      models.InvitationStatusPending,
+		InvitedByID: actorID,
+		ExpiresAt:   now.Add(s.config.InvitationTTL),
+	}
+	err := s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		if err := tx.GetInvitation().Create(ctx, orgID, invitation); err != nil {
+			return err
+		}
+		return tx.GetAudit().Record(ctx, orgID, &models.AuditEvent{
+			ActorID:      actorID,
+			Action:       models.AuditInvitationCreated,
+			SubjectEmail: email,
+			Details:      models.JSONB{"invitationId": invitation.ID.String(), "role": string(invitation.Role)},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.signInvitation(invitation)
+	if err != nil {
+		return nil, err
+	}
+	s.sendInvitation(ctx, orgID, invitation, token)
+
+	response := toInvitationResponse(invitation)
+	return &response, nil
+}
+
+// ListInvitations lists the invitations still awaiting an answer
+func (s *RealMembershipService) ListInvitations(ctx context.Context, orgID uuid.UUID) ([]dto.InvitationResponse, error) {
+	invitations, err := s.repos.GetInvitation().ListPending(ctx, orgID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]dto.InvitationResponse, 0, len(invitations))
+	for i := range invitations {
+		responses = append(responses, toInvitationResponse(&invitations[i]))
+	}
+	return responses, nil
+}
+
+// RevokeInvitation withdraws a pending invitation
+func (s *RealMembershipService) RevokeInvitation(ctx context.Context, orgID, actorID, invitationID uuid.UUID) error {
+	err := s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		invitation, err := tx.GetInvitation().GetByID(ctx, orgID, invitationID)
+		if err != nil {
+			return err
+		}
+		if err := tx.GetInvitation().Respond(ctx, orgID, invitationID, models.InvitationStatusRevoked, actorID, s.now()); err != nil {
+			return err
+		}
+		return tx.GetAudit().Record(ctx, orgID, &models.AuditEvent{
+			ActorID:      actorID,
+			Action:       models.AuditInvitationRevoked,
+			SubjectEmail: invitation.Email,
+			Details:      models.JSONB{"invitationId": invitationID.String()},
+		})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvitationNotFound
+	}
+	return err
+}
+
+// AcceptInvitation adds the caller to the organization named by the invitation token.
+// The caller is not a member yet, so the work runs in a transaction scoped to the
+// organization the verified token names.
+func (s *RealMembershipService) AcceptInvitation(ctx context.Context, userID uuid.UUID, token string) (*dto.MemberResponse, error) {
+	var response *dto.MemberResponse
+	err := s.respond(ctx, userID, token, func(ctx context.Context, user *models.User, invitation *models.Invitation) error {
+		member, err := s.repos.GetOrganization().IsMember(ctx, invitation.OrganizationID, user.ID)
+		if err != nil {
+			return err
+		}
+		if member {
+			return ErrAlreadyMember
+		}
+		if err := s.repos.GetInvitation().Respond(ctx, invitation.OrganizationID, invitation.ID, models.InvitationStatusAccepted, user.ID, s.now()); err != nil {
+			return err
+		}
+		if err := s.repos.GetOrganization().AddMember(ctx, invitation.OrganizationID, user.ID, invitation.Role); err != nil {
+			return err
+		}
+		if err := s.repos.GetAudit().Record(ctx, invitation.OrganizationID, &models.AuditEvent{
+			ActorID:       user.ID,
+			Action:        models.AuditInvitationAccepted,
+			SubjectUserID: &user.ID,
+			SubjectEmail:  invitation.Email,
+			Details:       models.JSONB{"invitationId": invitation.ID.String(), "role": string(invitation.Role)},
+		}); err != nil {
+			return err
+		}
+		response = &dto.MemberResponse{
+			UserID:   user.ID,
+			Email:    user.Email,
+			Name:     user.Name,
+			Role:     string(invitation.Role),
+			JoinedAt: s.now(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// DeclineInvitation turns down the invitation named by the token
+func (s *RealMembershipService) DeclineInvitation(ctx context.Context, userID uuid.UUID, token string) error {
+	return s.respond(ctx, userID, token, func(ctx context.Context, user *models.User, invitation *models.Invitation) error {
+		if err := s.repos.GetInvitation().Respond(ctx, invitation.OrganizationID, invitation.ID, models.InvitationStatusDeclined, user.ID, s.now()); err != nil {
+			return err
+		}
+		return s.repos.GetAudit().Record(ctx, invitation.OrganizationID, &models.AuditEvent{
+			ActorID:       user.ID,
+			Action:        models.AuditInvitationDeclined,
+			SubjectUserID: &user.ID,
+			SubjectEmail:  invitation.Email,
+			Details:       models.JSONB{"invitationId": invitation.ID.String()},
+		})
+	})
+}
+
+// respond verifies an invitation token and runs fn, scoped to the invitation's
+// organization, once the invitation is known to be pending and addressed to the caller
+func (s *RealMembershipService) respond(ctx context.Context, userID uuid.UUID, token string, fn func(ctx context.Context, user *models.User, invitation *models.Invitation) error) error {
+	if s.verifier == nil {
+		return ErrInvitationsDisabled
+	}
+	orgID, invitationID, err := s.verifyInvitation(token)
+	if err != nil {
+		return err
+	}
+	user, err := s.repos.GetUser().GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.repos.InOrganization(ctx, orgID, func(ctx context.Context) error {
+		invitation, err := s.repos.GetInvitation().GetByID(ctx, orgID, invitationID)
+		if err != nil {
+			return err
+		}
+		if invitation.Status != models.InvitationStatusPending || s.now().After(invitation.ExpiresAt) {
+			return ErrInvalidInvitation
+		}
+		if normalizeEmail(user.Email) != invitation.Email {
+			return ErrInvitationEmailMismatch
+		}
+		return fn(ctx, user, invitation)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The invitation was revoked, or answered by a concurrent request
+		return ErrInvalidInvitation
+	}
+	return err
+}
+
+// ListMembers lists the organization's members
+func (s *RealMembershipService) ListMembers(ctx context.Context, orgID uuid.UUID) ([]dto.MemberResponse, error) {
+	org, err := s.repos.GetOrganization().GetByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.repos.GetOrganization().ListMembers(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]dto.MemberResponse, 0, len(members))
+	for i := range members {
+		responses = append(responses, toMemberResponse(&members[i], org.OwnerID))
+	}
+	return responses, nil
+}
+
+// ChangeRole changes a member's role. The owner stays an admin, and the last admin
+// cannot be demoted.
+func (s *RealMembershipService) ChangeRole(ctx context.Context, orgID, actorID, userID uuid.UUID, role models.UserRole) (*dto.MemberResponse, error) {
+	var response dto.MemberResponse
+	err := s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		org, members, target, err := s.lockMember(ctx, tx, orgID, userID)
+		if err != nil {
+			return err
+		}
+		previous := target.Role
+		if previous != role {
+			if role != models.RoleAdmin && isOwner(org, userID) {
+				return ErrOwnerChange
+			}
+			if previous == models.RoleAdmin && countAdmins(members) == 1 {
+				return ErrLastAdmin
+			}
+			if err := tx.GetOrganization().UpdateMemberRole(ctx, orgID, userID, role); err != nil {
+				return err
+			}
+			if err := tx.GetAudit().Record(ctx, orgID, &models.AuditEvent{
+				ActorID:       actorID,
+				Action:        models.AuditMemberRoleChanged,
+				SubjectUserID: &userID,
+				Details:       models.JSONB{"from": string(previous), "to": string(role)},
+			}); err != nil {
+				return err
+			}
+			target.Role = role
+		}
+		response = toMemberResponse(target, org.OwnerID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// RemoveMember removes a member from the organization. The owner and the last admin
+// cannot be removed.
+func (s *RealMembershipService) RemoveMember(ctx context.Context, orgID, actorID, userID uuid.UUID) error {
+	return s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		org, members, target, err := s.lockMember(ctx, tx, orgID, userID)
+		if err != nil {
+			return err
+		}
+		if isOwner(org, userID) {
+			return ErrOwnerChange
+		}
+		if target.Role == models.RoleAdmin && countAdmins(members) == 1 {
+			return ErrLastAdmin
+		}
+		if err := tx.GetOrganization().RemoveMember(ctx, orgID, userID); err != nil {
+			return err
+		}
+		return tx.GetAudit().Record(ctx, orgID, &models.AuditEvent{
+			ActorID:       actorID,
+			Action:        models.AuditMemberRemoved,
+			SubjectUserID: &userID,
+			Details:       models.JSONB{"role": string(target.Role)},
+		})
+	})
+}
+
+// TransferOwnership makes another member the owner, promoting them to admin. Only the
+// owner may transfer; organizations created before owners existed can be claimed by
+// any admin.
+func (s *RealMembershipService) TransferOwnership(ctx context.Context, orgID, actorID, newOwnerID uuid.UUID) error {
+	return s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		org, _, target, err := s.lockMember(ctx, tx, orgID, newOwnerID)
+		if err != nil {
+			return err
+		}
+		if org.OwnerID != nil && *org.OwnerID != actorID {
+			return ErrOwnerRequired
+		}
+		if isOwner(org, newOwnerID) {
+			return nil
+		}
+
+		promoted := target.Role != models.RoleAdmin
+		if promoted {
+			if err := tx.GetOrganization().UpdateMemberRole(ctx, orgID, newOwnerID, models.RoleAdmin); err != nil {
+				return err
+			}
+		}
+		if err := tx.GetOrganization().SetOwner(ctx, orgID, newOwnerID); err != nil {
+			return err
+		}
+
+		details := models.JSONB{"promoted": promoted}
+		if org.OwnerID != nil {
+			details["previousOwnerId"] = org.OwnerID.String()
+		}
+		return tx.GetAudit().Record(ctx, orgID, &models.AuditEvent{
+			ActorID:       actorID,
+			Action:        models.AuditOwnershipTransfer,
+			SubjectUserID: &newOwnerID,
+			Details:       details,
+		})
+	})
+}
+
+// ListAuditEvents lists the organization's audit trail, newest first
+func (s *RealMembershipService) ListAuditEvents(ctx context.Context, orgID uuid.UUID, params repositories.ListParams) (*dto.AuditEventListResponse, error) {
+	events, total, err := s.repos.GetAudit().List(ctx, orgID, params)
+	if err != nil {
+		return nil, err
+	}
+	response := &dto.AuditEventListResponse{Events: make([]dto.AuditEventResponse, 0, len(events)), Total: int(total)}
+	for _, e := range events {
+		response.Events = append(response.Events, dto.AuditEventResponse{
+			ID:            e.ID,
+			Action:        e.Action,
+			ActorID:       e.ActorID,
+			SubjectUserID: e.SubjectUserID,
+			SubjectEmail:  e.SubjectEmail,
+			Details:       e.Details,
+			CreatedAt:     e.CreatedAt,
+		})
+	}
+	return response, nil
+}
+
+// lockMember locks the organization's memberships and returns them along with the
+// organization and the target's membership
+func (s *RealMembershipService) lockMember(ctx context.Context, tx *repositories.Provider, orgID, userID uuid.UUID) (*models.Organization, []models.OrganizationMember, *models.OrganizationMember, error) {
+	org, err := tx.GetOrganization().GetByID(ctx, orgID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	members, err := tx.GetOrganization().LockMembers(ctx, orgID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for i := range members {
+		if members[i].UserID == userID {
+			return org, members, &members[i], nil
+		}
+	}
+	return nil, nil, nil, ErrMemberNotFound
+}
+
+// signInvitation issues the token emailed to the invitee. It names the invitation and
+// its organization and expires with it.
+func (s *RealMembershipService) signInvitation(invitation *models.Invitation) (string, error) {
+	return auth.SignHS256(auth.Claims{
+		Subject:        invitation.ID.String(),
+		Issuer:         s.config.Issuer,
+		IssuedAt:       s.now().Unix(),
+		ExpiresAt:      invitation.ExpiresAt.Unix(),
+		ID:             invitation.ID.String(),
+		Type:           auth.TokenTypeInvite,
+		OrganizationID: invitation.OrganizationID.String(),
+		Role:           string(invitation.Role),
+	}, s.config.Secret)
+}
+
+// verifyInvitation checks an invitation token and returns the organization and
+// invitation it names
+func (s *RealMembershipService) verifyInvitation(token string) (uuid.UUID, uuid.UUID, error) {
+	claims, err := s.verifier.Verify(token, s.now())
+	if err != nil || claims.Type != auth.TokenTypeInvite {
+		return uuid.Nil, uuid.Nil, ErrInvalidInvitation
+	}
+	orgID, err := uuid.Parse(claims.OrganizationID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, ErrInvalidInvitation
+	}
+	invitationID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, ErrInvalidInvitation
+	}
+	return orgID, invitationID, nil
+}
+
+// sendInvitation mails the invitation link. A failed send is logged rather than
+// returned; the invitation stays pending and can be revoked and sent again.
+func (s *RealMembershipService) sendInvitation(ctx context.Context, orgID uuid.UUID, invitation *models.Invitation, token string) {
+	if s.mailer == nil {
+		log.Printf("[MembershipService] No mailer configured; invitation %s not sent", invitation.ID)
+		return
+	}
+	name := "an organization"
+	if org, err := s.repos.GetOrganization().GetByID(ctx, orgID); err == nil && org.Name != "" {
+		name = org.Name
+	}
+	msg := notify.Message{
+		Subject: fmt.Sprintf("You're invited to join %s on Xephyr", name),
+		Body: fmt.Sprintf("Hi,\n\nYou have been invited to join %s on Xephyr as %s. The invitation expires on %s.\n\n%s?token=%s\n\nIf you were not expecting this, you can ignore this email.",
+			name, invitation.Role, invitation.ExpiresAt.UTC().Format("January 2, 2006"), s.config.AcceptURL, token),
+		SentAt: s.now().UTC(),
+	}
+	if err := s.mailer.Send(ctx, invitation.Email, msg); err != nil {
+		log.Printf("[MembershipService] Failed to send invitation %s: %v", invitation.ID, err)
+	}
+}
+
+func isOwner(org *models.Organization, userID uuid.UUID) bool {
+	return org.OwnerID != nil && *org.OwnerID == userID
+}
+
+func countAdmins(members []models.OrganizationMember) int {
+	n := 0
+	for _, m := range members {
+		if m.Role == models.RoleAdmin {
+			n++
+		}
+	}
+	return n
+}
+
+func toInvitationResponse(invitation *models.Invitation) dto.InvitationResponse {
+	return dto.InvitationResponse{
+		ID:             invitation.ID,
+		OrganizationID: invitation.OrganizationID,
+		Email:          invitation.Email,
+		Role:           string(invitation.Role),
+		Status:         string(invitation.Status),
+		InvitedByID:    invitation.InvitedByID,
+		ExpiresAt:      invitation.ExpiresAt,
+		RespondedAt:    invitation.RespondedAt,
+		CreatedAt:      invitation.CreatedAt,
+	}
+}
+
+func toMemberResponse(member *models.OrganizationMember, ownerID *uuid.UUID) dto.MemberResponse {
+	return dto.MemberResponse{
+		UserID:   member.UserID,
+		Email:    member.User.Email,
+		Name:     member.User.Name,
+		Role:     string(member.Role),
+		IsOwner:  ownerID != nil && *ownerID == member.UserID,
+		JoinedAt: member.JoinedAt,
+	}
+}
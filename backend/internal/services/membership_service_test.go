@@ -0,0 +1,392 @@
+package services_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// memOrgMembers stores one organization and its members
+type memOrgMembers struct {
+	repositories.OrganizationRepository
+	org     models.Organization
+	users   *memAccountRepo
+	members []models.OrganizationMember
+}
+
+func (r *memOrgMembers) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	if id != r.org.ID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	clone := r.org
+	return &clone, nil
+}
+
+func (r *memOrgMembers) IsMember(ctx context.Context, orgID, userID uuid.UUID) (bool, error) {
+	return r.find(orgID, userID) >= 0, nil
+}
+
+func (r *memOrgMembers) AddMember(ctx context.Context, orgID, userID uuid.UUID, role models.UserRole) error {
+	r.members = append(r.members, models.OrganizationMember{OrganizationID: orgID, UserID: userID, Role: role})
+	return nil
+}
+
+func (r *memOrgMembers) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
+	i := r.find(orgID, userID)
+	if i < 0 {
+		return gorm.ErrRecordNotFound
+	}
+	r.members = append(r.members[:i], r.members[i+1:]...)
+	return nil
+}
+
+func (r *memOrgMembers) UpdateMemberRole(ctx context.Context, orgID, userID uuid.UUID, role models.UserRole) error {
+	i := r.find(orgID, userID)
+	if i < 0 {
+		return gorm.ErrRecordNotFound
+	}
+	r.members[i].Role = role
+	return nil
+}
+
+func (r *memOrgMembers) ListMembers(ctx context.Context, orgID uuid.UUID) ([]models.OrganizationMember, error) {
+	var found []models.OrganizationMember
+	for _, m := range r.members {
+		if m.OrganizationID == orgID {
+			m.User = *r.users.users[m.UserID]
+			found = append(found, m)
+		}
+	}
+	return found, nil
+}
+
+func (r *memOrgMembers) LockMembers(ctx context.Context, orgID uuid.UUID) ([]models.OrganizationMember, error) {
+	return r.ListMembers(ctx, orgID)
+}
+
+func (r *memOrgMembers) SetOwner(ctx context.Context, orgID, userID uuid.UUID) error {
+	r.org.OwnerID = &userID
+	return nil
+}
+
+func (r *memOrgMembers) role(userID uuid.UUID) models.UserRole {
+	if i := r.find(r.org.ID, userID); i >= 0 {
+		return r.members[i].Role
+	}
+	return ""
+}
+
+func (r *memOrgMembers) find(orgID, userID uuid.UUID) int {
+	for i, m := range r.members {
+		if m.OrganizationID == orgID && m.UserID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+type memInvitationRepo struct {
+	invitations []*models.Invitation
+}
+
+func (r *memInvitationRepo) Create(ctx context.Context, orgID uuid.UUID, invitation *models.Invitation) error {
+	invitation.ID = uuid.New()
+	invitation.OrganizationID = orgID
+	clone := *invitation
+	r.invitations = append(r.invitations, &clone)
+	return nil
+}
+
+func (r *memInvitationRepo) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.Invitation, error) {
+	for _, inv := range r.invitations {
+		if inv.ID == id && inv.OrganizationID == orgID {
+			clone := *inv
+			return &clone, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memInvitationRepo) ListPending(ctx context.Context, orgID uuid.UUID, now time.Time) ([]models.Invitation, error) {
+	var found []models.Invitation
+	for _, inv := range r.invitations {
+		if inv.OrganizationID == orgID && inv.Status == models.InvitationStatusPending && inv.ExpiresAt.After(now) {
+			found = append(found, *inv)
+		}
+	}
+	return found, nil
+}
+
+func (r *memInvitationRepo) GetPendingByEmail(ctx context.Context, orgID uuid.UUID, email string, now time.Time) (*models.Invitation, error) {
+	pending, _ := r.ListPending(ctx, orgID, now)
+	for _, inv := range pending {
+		if inv.Email == email {
+			return &inv, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memInvitationRepo) Respond(ctx context.Context, orgID, id uuid.UUID, status models.InvitationStatus, by uuid.UUID, at time.Time) error {
+	for _, inv := range r.invitations {
+		if inv.ID == id && inv.OrganizationID == orgID && inv.Status == models.InvitationStatusPending {
+			inv.Status, inv.RespondedByID, inv.RespondedAt = status, &by, &at
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+type memAuditRepo struct {
+	events []models.AuditEvent
+}
+
+func (r *memAuditRepo) Record(ctx context.Context, orgID uuid.UUID, event *models.AuditEvent) error {
+	event.ID = uuid.New()
+	event.OrganizationID = orgID
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *memAuditRepo) List(ctx context.Context, orgID uuid.UUID, params repositories.ListParams) ([]models.AuditEvent, int64, error) {
+	var found []models.AuditEvent
+	for i := len(r.events) - 1; i >= 0; i-- {
+		if r.events[i].OrganizationID == orgID {
+			found = append(found, r.events[i])
+		}
+	}
+	return found, int64(len(found)), nil
+}
+
+func (r *memAuditRepo) actions() []string {
+	var actions []string
+	for _, e := range r.events {
+		actions = append(actions, e.Action)
+	}
+	return actions
+}
+
+var _ = Describe("Membership Service", func() {
+	var (
+		ctx         context.Context
+		users       *memAccountRepo
+		orgs        *memOrgMembers
+		invitations *memInvitationRepo
+		audit       *memAuditRepo
+		mailer      *fakeNotifier
+		service     services.MembershipService
+		orgID       uuid.UUID
+		owner       uuid.UUID
+	)
+
+	addUser := func(email string, role models.UserRole) uuid.UUID {
+		id := uuid.New()
+		users.users[id] = &models.User{BaseModel: models.BaseModel{ID: id}, Email: email, Name: email}
+		if role != "" {
+			Expect(orgs.AddMember(ctx, orgID, id, role)).To(Succeed())
+		}
+		return id
+	}
+
+	// invite sends an invitation and returns the token from its email
+	invite := func(email string, role string) string {
+		_, err := service.Invite(ctx, orgID, owner, dto.CreateInvitationRequest{Email: email, Role: role})
+		Expect(err).NotTo(HaveOccurred())
+		body := mailer.sent[len(mailer.sent)-1].Body
+		link := body[strings.Index(body, "http"):]
+		parsed, err := url.Parse(strings.Fields(link)[0])
+		Expect(err).NotTo(HaveOccurred())
+		return parsed.Query().Get("token")
+	}
+
+	newService := func(secret []byte) services.MembershipService {
+		cfg := services.DefaultMembershipServiceConfig()
+		cfg.Secret = secret
+		repos := &repositories.Provider{User: users, Organization: orgs, Invitation: invitations, Audit: audit}
+		return services.NewMembershipService(repos, cfg, mailer)
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		orgID = uuid.New()
+		users = &memAccountRepo{users: map[uuid.UUID]*models.User{}}
+		orgs = &memOrgMembers{org: models.Organization{BaseModel: models.BaseModel{ID: orgID}, Name: "Acme"}, users: users}
+		invitations = &memInvitationRepo{}
+		audit = &memAuditRepo{}
+		mailer = &fakeNotifier{channel: models.NotificationChannelEmail}
+		service = newService([]byte("test-secret"))
+
+		owner = addUser("owner@example.com", models.RoleAdmin)
+		orgs.org.OwnerID = &owner
+	})
+
+	Context("Given an invitation", func() {
+		It("should email a token that lets the invitee join with the invited role", func() {
+			invitee := addUser("newcomer@example.com", "")
+			token := invite("Newcomer@Example.com", "pm")
+
+			Expect(mailer.targets).To(Equal([]string{"newcomer@example.com"}))
+			pending, err := service.ListInvitations(ctx, orgID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pending).To(HaveLen(1))
+
+			member, err := service.AcceptInvitation(ctx, invitee, token)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(member.Role).To(Equal("pm"))
+			Expect(orgs.role(invitee)).To(Equal(models.RolePM))
+			Expect(audit.actions()).To(Equal([]string{models.AuditInvitationCreated, models.AuditInvitationAccepted}))
+
+			pending, _ = service.ListInvitations(ctx, orgID)
+			Expect(pending).To(BeEmpty())
+		})
+
+		It("should only accept a token once", func() {
+			invitee := addUser("newcomer@example.com", "")
+			token := invite("newcomer@example.com", "member")
+
+			_, err := service.AcceptInvitation(ctx, invitee, token)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = service.AcceptInvitation(ctx, invitee, token)
+			Expect(err).To(MatchError(services.ErrInvalidInvitation))
+		})
+
+		It("should reject the token from an account with another email", func() {
+			addUser("newcomer@example.com", "")
+			intruder := addUser("intruder@example.com", "")
+			token := invite("newcomer@example.com", "admin")
+
+			_, err := service.AcceptInvitation(ctx, intruder, token)
+			Expect(err).To(MatchError(services.ErrInvitationEmailMismatch))
+			Expect(orgs.role(intruder)).To(BeEmpty())
+		})
+
+		It("should reject a declined invitation and record the decline", func() {
+			invitee := addUser("newcomer@example.com", "")
+			token := invite("newcomer@example.com", "member")
+
+			Expect(service.DeclineInvitation(ctx, invitee, token)).To(Succeed())
+			_, err := service.AcceptInvitation(ctx, invitee, token)
+			Expect(err).To(MatchError(services.ErrInvalidInvitation))
+			Expect(audit.actions()).To(ContainElement(models.AuditInvitationDeclined))
+		})
+
+		It("should reject a revoked invitation", func() {
+			invitee := addUser("newcomer@example.com", "")
+			token := invite("newcomer@example.com", "member")
+
+			Expect(service.RevokeInvitation(ctx, orgID, owner, invitations.invitations[0].ID)).To(Succeed())
+			_, err := service.AcceptInvitation(ctx, invitee, token)
+			Expect(err).To(MatchError(services.ErrInvalidInvitation))
+		})
+
+		It("should reject an expired invitation", func() {
+			invitee := addUser("newcomer@example.com", "")
+			token := invite("newcomer@example.com", "member")
+			invitations.invitations[0].ExpiresAt = time.Now().Add(-time.Minute)
+
+			_, err := service.AcceptInvitation(ctx, invitee, token)
+			Expect(err).To(MatchError(services.ErrInvalidInvitation))
+		})
+
+		It("should reject a token signed with another secret", func() {
+			invitee := addUser("newcomer@example.com", "")
+			token := invite("newcomer@example.com", "member")
+
+			_, err := newService([]byte("other-secret")).AcceptInvitation(ctx, invitee, token)
+			Expect(err).To(MatchError(services.ErrInvalidInvitation))
+		})
+
+		It("should not invite existing members or invite the same email twice", func() {
+			member := addUser("member@example.com", models.RoleMember)
+			_, err := service.Invite(ctx, orgID, owner, dto.CreateInvitationRequest{Email: users.users[member].Email, Role: "pm"})
+			Expect(err).To(MatchError(services.ErrAlreadyMember))
+
+			invite("newcomer@example.com", "member")
+			_, err = service.Invite(ctx, orgID, owner, dto.CreateInvitationRequest{Email: "newcomer@example.com", Role: "pm"})
+			Expect(err).To(MatchError(services.ErrInvitationPending))
+		})
+
+		It("should be disabled without a signing secret", func() {
+			_, err := newService(nil).Invite(ctx, orgID, owner, dto.CreateInvitationRequest{Email: "newcomer@example.com", Role: "member"})
+			Expect(err).To(MatchError(services.ErrInvitationsDisabled))
+		})
+	})
+
+	Context("Given the last admin", func() {
+		BeforeEach(func() {
+			orgs.org.OwnerID = nil
+		})
+
+		It("should not demote them", func() {
+			_, err := service.ChangeRole(ctx, orgID, owner, owner, models.RoleMember)
+			Expect(err).To(MatchError(services.ErrLastAdmin))
+			Expect(orgs.role(owner)).To(Equal(models.RoleAdmin))
+		})
+
+		It("should not remove them", func() {
+			Expect(service.RemoveMember(ctx, orgID, owner, owner)).To(MatchError(services.ErrLastAdmin))
+		})
+
+		It("should allow the change once another admin exists", func() {
+			other := addUser("other@example.com", models.RoleAdmin)
+			Expect(service.RemoveMember(ctx, orgID, other, owner)).To(Succeed())
+			Expect(orgs.role(owner)).To(BeEmpty())
+			Expect(audit.actions()).To(Equal([]string{models.AuditMemberRemoved}))
+		})
+	})
+
+	Context("Given an owner", func() {
+		It("should keep the owner an admin until ownership is transferred", func() {
+			addUser("other@example.com", models.RoleAdmin)
+			_, err := service.ChangeRole(ctx, orgID, owner, owner, models.RolePM)
+			Expect(err).To(MatchError(services.ErrOwnerChange))
+			Expect(service.RemoveMember(ctx, orgID, owner, owner)).To(MatchError(services.ErrOwnerChange))
+		})
+
+		It("should transfer ownership to a member and promote them", func() {
+			member := addUser("member@example.com", models.RoleMember)
+
+			Expect(service.TransferOwnership(ctx, orgID, owner, member)).To(Succeed())
+			Expect(orgs.org.OwnerID).To(HaveValue(Equal(member)))
+			Expect(orgs.role(member)).To(Equal(models.RoleAdmin))
+
+			// The previous owner is now an ordinary admin
+			_, err := service.ChangeRole(ctx, orgID, member, owner, models.RoleMember)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(audit.actions()).To(Equal([]string{models.AuditOwnershipTransfer, models.AuditMemberRoleChanged}))
+		})
+
+		It("should only let the owner transfer ownership", func() {
+			admin := addUser("admin@example.com", models.RoleAdmin)
+			member := addUser("member@example.com", models.RoleMember)
+
+			Expect(service.TransferOwnership(ctx, orgID, admin, member)).To(MatchError(services.ErrOwnerRequired))
+		})
+
+		It("should not transfer ownership outside the organization", func() {
+			outsider := addUser("outsider@example.com", "")
+			Expect(service.TransferOwnership(ctx, orgID, owner, outsider)).To(MatchError(services.ErrMemberNotFound))
+		})
+	})
+
+	It("should list members with the owner marked", func() {
+		addUser("member@example.com", models.RoleMember)
+
+		members, err := service.ListMembers(ctx, orgID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(members).To(HaveLen(2))
+		Expect(members[0].IsOwner).To(BeTrue())
+		Expect(members[1].IsOwner).To(BeFalse())
+	})
+})
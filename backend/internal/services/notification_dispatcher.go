@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/notify"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+// NotificationDispatcher routes nudges and events to the channels each user prefers
+type NotificationDispatcher interface {
+	// NotifyNudge delivers a nudge to the recipients and returns those it reached
+	NotifyNudge(ctx context.Context, nudge *models.Nudge, recipients []uuid.UUID) ([]uuid.UUID, error)
+
+	// NotifyEvent delivers a non-nudge event to the recipients and returns those it reached
+	NotifyEvent(ctx context.Context, orgID uuid.UUID, recipients []uuid.UUID, severity models.NudgeSeverity, subject, body string) ([]uuid.UUID, error)
+
+	// SendDigests sends one message per user and channel for notifications held for the daily digest
+	SendDigests(ctx context.Context, now time.Time) (int, error)
+}
+
+// DefaultNotificationPreference applies to users who have not set any preferences:
+// email to their own address, with low-severity nudges held for the daily digest
+func DefaultNotificationPreference() models.NotificationPreference {
+	return models.NotificationPreference{
+		Channel:     models.NotificationChannelEmail,
+		MinSeverity: models.NudgeSeverityLow,
+		DailyDigest: true,
+	}
+}
+
+// MatchNotificationPreference reports whether a preference wants a notification of the
+// given nudge type and severity, and whether it should wait for the daily digest.
+// Events that are not nudges have an empty type and ignore the type filter.
+func MatchNotificationPreference(pref models.NotificationPreference, nudgeType string, severity models.NudgeSeverity) (match, digest bool) {
+	minSeverity := pref.MinSeverity
+	if minSeverity == "" {
+		minSeverity = models.NudgeSeverityLow
+	}
+	if nudgeSeverityRank[severity] < nudgeSeverityRank[minSeverity] {
+		return false, false
+	}
+	if nudgeType != "" && strings.TrimSpace(pref.NudgeTypes) != "" {
+		found := false
+		for _, t := range strings.Split(pref.NudgeTypes, ",") {
+			if strings.TrimSpace(t) == nudgeType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, false
+		}
+	}
+	return true, pref.DailyDigest && severity == models.NudgeSeverityLow
+}
+
+// notificationDispatcher implements NotificationDispatcher
+type notificationDispatcher struct {
+	repos     *repositories.Provider
+	notifiers map[models.NotificationChannel]notify.Notifier
+}
+
+// NewNotificationDispatcher creates a dispatcher that delivers through the given notifiers.
+// Preferences for a channel without a notifier are recorded as failed deliveries.
+func NewNotificationDispatcher(repos *repositories.Provider, notifiers ...notify.Notifier) NotificationDispatcher {
+	d := &notificationDispatcher{
+		repos:     repos,
+		notifiers: make(map[models.NotificationChannel]notify.Notifier),
+	}
+	for _, n := range notifiers {
+		d.notifiers[n.Channel()] = n
+	}
+	return d
+}
+
+// notification is one message addressed to one user
+type notification struct {
+	orgID     uuid.UUID
+	userID    uuid.UUID
+	nudgeID   *uuid.UUID
+	nudgeType string
+	severity  models.NudgeSeverity
+	subject   string
+	body      string
+}
+
+func (d *notificationDispatcher) NotifyNudge(ctx context.Context, nudge *models.Nudge, recipients []uuid.UUID) ([]uuid.UUID, error) {
+	body := nudge.Description
+	if nudge.SuggestedAction != "" {
+		body += "\n\nSuggested action: " + nudge.SuggestedAction
+	}
+	nudgeID := nudge.ID
+
+	var reached []uuid.UUID
+	for _, userID := range recipients {
+		ok, err := d.deliver(ctx, notification{
+			orgID:     nudge.OrganizationID,
+			userID:    userID,
+			nudgeID:   &nudgeID,
+			nudgeType: string(nudge.Type),
+			severity:  nudge.Severity,
+			subject:   nudge.Title,
+			body:      body,
+		})
+		if err != nil {
+			return reached, err
+		}
+		if ok {
+			reached = append(reached, userID)
+		}
+	}
+	return reached, nil
+}
+
+func (d *notificationDispatcher) NotifyEvent(ctx context.Context, orgID uuid.UUID, recipients []uuid.UUID, severity models.NudgeSeverity, subject, body string) ([]uuid.UUID, error) {
+	var reached []uuid.UUID
+	for _, userID := range recipients {
+		ok, err := d.deliver(ctx, notification{
+			orgID:    orgID,
+			userID:   userID,
+			severity: severity,
+			subject:  subject,
+			body:     body,
+		})
+		if err != nil {
+			return reached, err
+		}
+		if ok {
+			reached = append(reached, userID)
+		}
+	}
+	return reached, nil
+}
+
+// deliver sends a notification over every matching preference of its user and records
+// each attempt. It reports whether at least one channel accepted it or held it for the digest.
+func (d *notificationDispatcher) deliver(ctx context.Context, n notification) (bool, error) {
+	prefs, err := d.repos.GetNotification().ListPreferences(ctx, n.userID)
+	if err != nil {
+		return false, err
+	}
+	if len(prefs) == 0 {
+		prefs = []models.NotificationPreference{DefaultNotificationPreference()}
+	}
+
+	var user *models.User
+	reached := false
+	seen := make(map[string]bool)
+	for _, pref := range prefs {
+		match, digest := MatchNotificationPreference(pref, n.nudgeType, n.severity)
+		if !match {
+			continue
+		}
+
+		target := pref.Target
+		if target == "" && pref.Channel == models.NotificationChannelEmail {
+			if user == nil {
+				if user, err = d.repos.GetUser().GetByID(ctx, n.userID); err != nil {
+					return reached, err
+				}
+			}
+			target = user.Email
+		}
+		key := string(pref.Channel) + "|" + target
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		delivery := &models.NotificationDelivery{
+			OrganizationID: n.orgID,
+			UserID:         n.userID,
+			NudgeID:        n.nudgeID,
+			Channel:        pref.Channel,
+			Target:         target,
+			Subject:        n.subject,
+			Body:           n.body,
+			Status:         models.NotificationStatusDigest,
+		}
+		if !digest {
+			d.send(ctx, delivery, notify.Message{
+				Subject:  n.subject,
+				Body:     n.body,
+				Severity: string(n.severity),
+				SentAt:   time.Now().UTC(),
+			})
+		}
+		if err := d.repos.GetNotification().CreateDelivery(ctx, delivery); err != nil {
+			return reached, err
+		}
+		if delivery.Status != models.NotificationStatusFailed {
+			reached = true
+		}
+	}
+	return reached, nil
+}
+
+// send attempts delivery and records the outcome on the delivery
+func (d *notificationDispatcher) send(ctx context.Context, delivery *models.NotificationDelivery, msg notify.Message) {
+	notifier, ok := d.notifiers[delivery.Channel]
+	if !ok {
+		delivery.Status = models.NotificationStatusFailed
+		delivery.Error = fmt.Sprintf("%s notifications are not configured", delivery.Channel)
+		return
+	}
+	if err := notifier.Send(ctx, delivery.Target, msg); err != nil {
+		log.Printf("[Notifications] Failed to send %s notification to user %s: %v", delivery.Channel, delivery.UserID, err)
+		delivery.Status = models.NotificationStatusFailed
+		delivery.Error = err.Error()
+		return
+	}
+	delivery.Status = models.NotificationStatusSent
+	delivery.SentAt = &msg.SentAt
+}
+
+func (d *notificationDispatcher) SendDigests(ctx context.Context, now time.Time) (int, error) {
+	pending, err := d.repos.GetNotification().ListPendingDigests(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Group by recipient address, keeping first-seen order
+	type batch struct {
+		sample models.NotificationDelivery
+		ids    []uuid.UUID
+		lines  []string
+	}
+	var order []string
+	batches := make(map[string]*batch)
+	for _, p := range pending {
+		key := p.UserID.String() + "|" + string(p.Channel) + "|" + p.Target
+		b, ok := batches[key]
+		if !ok {
+			b = &batch{sample: p}
+			batches[key] = b
+			order = append(order, key)
+		}
+		b.ids = append(b.ids, p.ID)
+		b.lines = append(b.lines, "- "+p.Subject)
+	}
+
+	sent := 0
+	for _, key := range order {
+		b := batches[key]
+		delivery := b.sample
+		d.send(ctx, &delivery, notify.Message{
+			Subject:  fmt.Sprintf("Xephyr daily digest: %d %s", len(b.ids), pluralize(len(b.ids), "nudge", "nudges")),
+			Body:     strings.Join(b.lines, "\n"),
+			Severity: string(models.NudgeSeverityLow),
+			SentAt:   now,
+		})
+		if err := d.repos.GetNotification().MarkDeliveries(ctx, b.ids, delivery.Status, delivery.Error, delivery.SentAt); err != nil {
+			return sent, err
+		}
+		if delivery.Status == models.NotificationStatusSent {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// nudgeRecipients returns who should hear about a nudge: the person it concerns,
+// or the organization's admins and project managers when it concerns no one in particular
+func nudgeRecipients(ctx context.Context, repos *repositories.Provider, nudge *models.Nudge) ([]uuid.UUID, error) {
+	if nudge.RelatedUserID != nil {
+		return []uuid.UUID{*nudge.RelatedUserID}, nil
+	}
+	return escalationRecipients(ctx, repos, nudge.OrganizationID)
+}
+
+// appendUniqueID appends id unless it is already present
+func appendUniqueID(ids []uuid.UUID, id uuid.UUID) []uuid.UUID {
+	for _, existing := range ids {
+		if existing == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
@@ -0,0 +1,179 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/notify"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+type memNotificationRepo struct {
+	repositories.NotificationRepository
+	prefs      map[uuid.UUID][]models.NotificationPreference
+	deliveries []*models.NotificationDelivery
+}
+
+func (r *memNotificationRepo) ListPreferences(ctx context.Context, userID uuid.UUID) ([]models.NotificationPreference, error) {
+	return r.prefs[userID], nil
+}
+
+func (r *memNotificationRepo) CreateDelivery(ctx context.Context, delivery *models.NotificationDelivery) error {
+	delivery.ID = uuid.New()
+	clone := *delivery
+	r.deliveries = append(r.deliveries, &clone)
+	return nil
+}
+
+func (r *memNotificationRepo) ListPendingDigests(ctx context.Context) ([]models.NotificationDelivery, error) {
+	var pending []models.NotificationDelivery
+	for _, d := range r.deliveries {
+		if d.Status == models.NotificationStatusDigest {
+			pending = append(pending, *d)
+		}
+	}
+	return pending, nil
+}
+
+func (r *memNotificationRepo) MarkDeliveries(ctx context.Context, ids []uuid.UUID, status models.NotificationStatus, errMsg string, sentAt *time.Time) error {
+	for _, d := range r.deliveries {
+		for _, id := range ids {
+			if d.ID == id {
+				d.Status, d.Error, d.SentAt = status, errMsg, sentAt
+			}
+		}
+	}
+	return nil
+}
+
+// fakeNotifier records what it sends and fails when err is set
+type fakeNotifier struct {
+	channel models.NotificationChannel
+	err     error
+	sent    []notify.Message
+	targets []string
+}
+
+func (n *fakeNotifier) Channel() models.NotificationChannel { return n.channel }
+
+func (n *fakeNotifier) Send(ctx context.Context, target string, msg notify.Message) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.targets = append(n.targets, target)
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+var _ = Describe("Notification Dispatcher", func() {
+	now := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	var (
+		ctx           context.Context
+		notifications *memNotificationRepo
+		email         *fakeNotifier
+		slack         *fakeNotifier
+		dispatcher    services.NotificationDispatcher
+		orgID         uuid.UUID
+		emma          models.User
+	)
+
+	newNudge := func(nudgeType models.NudgeType, severity models.NudgeSeverity) *models.Nudge {
+		n := &models.Nudge{OrganizationID: orgID, Type: nudgeType, Severity: severity, Title: string(nudgeType) + " nudge"}
+		n.ID = uuid.New()
+		return n
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		orgID = uuid.New()
+		emma = models.User{Name: "Emma", Email: "emma@example.com"}
+		emma.ID = uuid.New()
+
+		notifications = &memNotificationRepo{prefs: map[uuid.UUID][]models.NotificationPreference{}}
+		users := &memUserRepo{byID: map[uuid.UUID]*models.User{emma.ID: &emma}}
+		repos := &repositories.Provider{User: users, Notification: notifications}
+
+		email = &fakeNotifier{channel: models.NotificationChannelEmail}
+		slack = &fakeNotifier{channel: models.NotificationChannelSlack}
+		dispatcher = services.NewNotificationDispatcher(repos, email, slack)
+	})
+
+	Context("Given a user without preferences", func() {
+		It("should email a high-severity nudge to their own address", func() {
+			reached, err := dispatcher.NotifyNudge(ctx, newNudge(models.NudgeTypeOverload, models.NudgeSeverityHigh), []uuid.UUID{emma.ID})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reached).To(Equal([]uuid.UUID{emma.ID}))
+			Expect(email.targets).To(ConsistOf("emma@example.com"))
+			Expect(notifications.deliveries).To(HaveLen(1))
+			Expect(notifications.deliveries[0].Status).To(Equal(models.NotificationStatusSent))
+		})
+
+		It("should hold low-severity nudges for the daily digest", func() {
+			dispatcher.NotifyNudge(ctx, newNudge(models.NudgeTypeOverload, models.NudgeSeverityLow), []uuid.UUID{emma.ID})
+			dispatcher.NotifyNudge(ctx, newNudge(models.NudgeTypeUnassigned, models.NudgeSeverityLow), []uuid.UUID{emma.ID})
+			Expect(email.sent).To(BeEmpty())
+
+			sent, err := dispatcher.SendDigests(ctx, now)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sent).To(Equal(1))
+			Expect(email.sent).To(HaveLen(1))
+			Expect(email.sent[0].Subject).To(ContainSubstring("2 nudges"))
+			for _, d := range notifications.deliveries {
+				Expect(d.Status).To(Equal(models.NotificationStatusSent))
+			}
+		})
+	})
+
+	Context("Given per-channel preferences", func() {
+		BeforeEach(func() {
+			notifications.prefs[emma.ID] = []models.NotificationPreference{
+				{Channel: models.NotificationChannelSlack, Target: "https://hooks.example.com/x", NudgeTypes: "overload", MinSeverity: models.NudgeSeverityMedium},
+				{Channel: models.NotificationChannelWebhook, Target: "https://example.com/hook", MinSeverity: models.NudgeSeverityHigh},
+			}
+		})
+
+		It("should route by nudge type and severity", func() {
+			dispatcher.NotifyNudge(ctx, newNudge(models.NudgeTypeOverload, models.NudgeSeverityMedium), []uuid.UUID{emma.ID})
+			reached, err := dispatcher.NotifyNudge(ctx, newNudge(models.NudgeTypeUnassigned, models.NudgeSeverityMedium), []uuid.UUID{emma.ID})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reached).To(BeEmpty())
+			Expect(slack.sent).To(HaveLen(1))
+			Expect(email.sent).To(BeEmpty())
+		})
+
+		It("should record channels without a notifier as failed deliveries", func() {
+			reached, err := dispatcher.NotifyNudge(ctx, newNudge(models.NudgeTypeOverload, models.NudgeSeverityHigh), []uuid.UUID{emma.ID})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reached).To(Equal([]uuid.UUID{emma.ID}))
+			Expect(notifications.deliveries).To(HaveLen(2))
+			Expect(notifications.deliveries[1].Channel).To(Equal(models.NotificationChannelWebhook))
+			Expect(notifications.deliveries[1].Status).To(Equal(models.NotificationStatusFailed))
+		})
+	})
+
+	Context("Given a failing channel", func() {
+		It("should record the failure and not count the user as reached", func() {
+			email.err = errors.New("connection refused")
+
+			reached, err := dispatcher.NotifyEvent(ctx, orgID, []uuid.UUID{emma.ID}, models.NudgeSeverityMedium, "New assignment", "body")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reached).To(BeEmpty())
+			Expect(notifications.deliveries).To(HaveLen(1))
+			Expect(notifications.deliveries[0].Status).To(Equal(models.NotificationStatusFailed))
+			Expect(notifications.deliveries[0].Error).To(ContainSubstring("connection refused"))
+		})
+	})
+})
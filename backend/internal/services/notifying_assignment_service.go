@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+var (
+	// ErrAssignmentTaskNotFound is returned when the task does not exist in the organization
+	ErrAssignmentTaskNotFound = errors.New("task not found")
+
+	// ErrInvalidAssignment is returned when the assignee cannot take the task
+	ErrInvalidAssignment = errors.New("invalid assignment")
+)
+
+// NotifyingAssignmentService implements AssignmentService with only AssignTask backed
+// by the database: it stores the assignee, logs the change and notifies them.
+// Suggestions, auto-assignment, compatibility checks and bulk reassignment are still
+// the canned data of DummyAssignmentService.
+type NotifyingAssignmentService struct {
+	*DummyAssignmentService
+	repos    *repositories.Provider
+	notifier NotificationDispatcher
+}
+
+// NewNotifyingAssignmentService creates an assignment service that assigns tasks and
+// notifies assignees
+func NewNotifyingAssignmentService(repos *repositories.Provider, notifier NotificationDispatcher) AssignmentService {
+	return &NotifyingAssignmentService{
+		DummyAssignmentService: &DummyAssignmentService{},
+		repos:                  repos,
+		notifier:               notifier,
+	}
+}
+
+// AssignTask assigns a task to an organization member and notifies them
+func (s *NotifyingAssignmentService) AssignTask(ctx context.Context, taskID string, req dto.AssignTaskRequest, orgID string, assignedBy uuid.UUID) (*dto.AssignTaskResponse, error) {
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return nil, err
+	}
+	taskUUID, err := uuid.Parse(taskID)
+	if err != nil {
+		return nil, ErrAssignmentTaskNotFound
+	}
+	personID, err := uuid.Parse(req.PersonID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid person ID %q", ErrInvalidAssignment, req.PersonID)
+	}
+
+	task, err := s.repos.GetTask().GetByID(ctx, orgUUID, taskUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAssignmentTaskNotFound
+		}
+		return nil, err
+	}
+
+	member, err := s.repos.GetOrganization().IsMember(ctx, orgUUID, personID)
+	if err != nil {
+		return nil, err
+	}
+	if !member {
+		return nil, fmt.Errorf("%w: user %s is not a member of the organization", ErrInvalidAssignment, personID)
+	}
+	assignee, err := s.repos.GetUser().GetByID(ctx, personID)
+	if err != nil {
+		return nil, err
+	}
+
+	var previous *dto.AssigneeInfo
+	if task.AssigneeID != nil {
+		previous = &dto.AssigneeInfo{PersonID: task.AssigneeID.String()}
+		if prev, err := s.repos.GetUser().GetByID(ctx, *task.AssigneeID); err == nil {
+			previous.Name = prev.Name
+		}
+	}
+
+	if err := s.repos.GetTask().UpdateAssignee(ctx, orgUUID, task.ID, &personID); err != nil {
+		return nil, err
+	}
+	assigned := *task
+	assigned.AssigneeID = &personID
+	if err := recordTaskChanges(ctx, s.repos, orgUUID, assignedBy, task, &assigned, time.Now()); err != nil {
+		return nil, err
+	}
+
+	allocation := 0
+	if entry, err := s.repos.GetWorkload().GetByUserAndWeek(ctx, orgUUID, personID, getCurrentWeekStart()); err == nil {
+		allocation = entry.AllocationPercentage
+	}
+
+	return &dto.AssignTaskResponse{
+		TaskID: task.ID.String(),
+		AssignedTo: dto.AssigneeInfo{
+			PersonID: personID.String(),
+			Name:     assignee.Name,
+		},
+		PreviousAssignee: previous,
+		Assignment: dto.AssignmentInfo{
+			AssignedAt: time.Now().UTC(),
+			AssignedBy: assignedBy.String(),
+		},
+		Impact: dto.AssignmentImpact{
+			WorkloadUpdated:   false,
+			NewAllocation:     allocation,
+			NudgesGenerated:   []string{},
+			NotificationsSent: s.notifyAssignee(ctx, orgUUID, task, personID, req.Note),
+		},
+	}, nil
+}
+
+// notifyAssignee tells the new assignee about the task and returns the IDs of those reached
+func (s *NotifyingAssignmentService) notifyAssignee(ctx context.Context, orgID uuid.UUID, task *models.Task, personID uuid.UUID, note string) []string {
+	sent := []string{}
+	if s.notifier == nil {
+		return sent
+	}
+	body := fmt.Sprintf("You have been assigned %q.", task.Title)
+	if note != "" {
+		body += "\n\n" + note
+	}
+	reached, err := s.notifier.NotifyEvent(ctx, orgID, []uuid.UUID{personID}, models.NudgeSeverityMedium, "New assignment: "+task.Title, body)
+	if err != nil {
+		log.Printf("[AssignmentService] Error notifying assignee of task %s: %v", task.ID, err)
+	}
+	for _, id := range reached {
+		sent = append(sent, id.String())
+	}
+	return sent
+}
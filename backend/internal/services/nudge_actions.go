@@ -0,0 +1,558 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+var (
+	// ErrNudgeNotFound is returned when a nudge does not exist in the organization
+	ErrNudgeNotFound = errors.New("nudge not found")
+
+	// ErrInvalidNudgeAction is returned when an action type or its parameters are invalid
+	ErrInvalidNudgeAction = errors.New("invalid nudge action")
+)
+
+// Limits on action parameters
+const (
+	maxSnooze     = 30 * 24 * time.Hour
+	maxSplitParts = 10
+)
+
+// NudgeActionContext carries the nudge and parameters a handler executes against
+type NudgeActionContext struct {
+	Nudge  *models.Nudge
+	OrgID  uuid.UUID
+	UserID uuid.UUID
+	Params map[string]interface{}
+	Now    time.Time
+
+	// changes are the tasks the handler created or changed, logged in their history
+	// once the handler succeeds
+	changes []taskVersions
+	// projects are the projects whose progress and critical path the action changed
+	projects []uuid.UUID
+}
+
+// taskVersions is a task before and after an action; before is nil for a new task
+type taskVersions struct {
+	before, after *models.Task
+}
+
+// changedTask notes a task the action created or changed for its history
+func (act *NudgeActionContext) changedTask(before, after *models.Task) {
+	act.changes = append(act.changes, taskVersions{before: before, after: after})
+	act.changedProjects(after.ProjectID)
+}
+
+// changedProjects notes projects whose progress or critical path the action changed
+func (act *NudgeActionContext) changedProjects(projectIDs ...uuid.UUID) {
+	act.projects = append(act.projects, projectIDs...)
+}
+
+// NudgeActionHandler validates an action's parameters and applies it through repositories
+// that share one transaction. Handlers set the nudge's new status; the caller persists it.
+type NudgeActionHandler func(ctx context.Context, repos *repositories.Provider, act *NudgeActionContext) (*dto.NudgeActionResult, error)
+
+// nudgeActionHandlers maps every action type to its handler
+var nudgeActionHandlers = map[string]NudgeActionHandler{
+	"reassign":          reassignTask,
+	"extend_due_date":   extendDueDate,
+	"split_task":        splitTask,
+	"add_dependency":    addDependency,
+	"snooze":            snoozeNudge,
+	"escalate":          escalateNudge,
+	"dismiss":           closeNudge(models.NudgeStatusDismissed, "Nudge dismissed"),
+	"accept_suggestion": closeNudge(models.NudgeStatusActed, "Suggestion accepted"),
+	"custom_action":     closeNudge(models.NudgeStatusActed, "Handled outside Xephyr"),
+	"ask_alternatives":  closeNudge(models.NudgeStatusActed, "Alternatives requested"),
+}
+
+// NudgeActionTypes returns the action types a nudge can be acted on with, sorted
+func NudgeActionTypes() []string {
+	types := make([]string, 0, len(nudgeActionHandlers))
+	for actionType := range nudgeActionHandlers {
+		types = append(types, actionType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// reassignTask moves the nudge's task (or parameter taskId) to toUserId
+func reassignTask(ctx context.Context, repos *repositories.Provider, act *NudgeActionContext) (*dto.NudgeActionResult, error) {
+	task, err := act.loadTask(ctx, repos, "taskId")
+	if err != nil {
+		return nil, err
+	}
+	toUserID, err := act.requireMember(ctx, repos, "toUserId")
+	if err != nil {
+		return nil, err
+	}
+	if task.AssigneeID != nil && *task.AssigneeID == toUserID {
+		return nil, invalidAction("task is already assigned to %s", toUserID)
+	}
+
+	if err := repos.GetTask().UpdateAssignee(ctx, act.OrgID, task.ID, &toUserID); err != nil {
+		return nil, err
+	}
+	reassigned := *task
+	reassigned.AssigneeID = &toUserID
+	act.changedTask(task, &reassigned)
+	act.Nudge.Status = models.NudgeStatusActed
+
+	from := ""
+	if task.AssigneeID != nil {
+		from = task.AssigneeID.String()
+	}
+	return &dto.NudgeActionResult{
+		TaskReassigned: true,
+		FromUserID:     from,
+		ToUserID:       toUserID.String(),
+		TaskID:         task.ID.String(),
+		Summary:        fmt.Sprintf("Reassigned %q", task.Title),
+		Changes:        []dto.NudgeChange{taskChange(task, "assigneeId", optionalID(task.AssigneeID), toUserID.String())},
+	}, nil
+}
+
+// extendDueDate moves a task's due date to newDueDate, or later by a number of days
+func extendDueDate(ctx context.Context, repos *repositories.Provider, act *NudgeActionContext) (*dto.NudgeActionResult, error) {
+	task, err := act.loadTask(ctx, repos, "taskId")
+	if err != nil {
+		return nil, err
+	}
+
+	newDue, err := paramTime(act.Params, "newDueDate")
+	if err != nil {
+		return nil, err
+	}
+	if newDue == nil {
+		days, ok, err := paramNumber(act.Params, "days")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, invalidAction("newDueDate or days is required")
+		}
+		if days < 1 || days != math.Trunc(days) {
+			return nil, invalidAction("days must be a whole number of at least 1")
+		}
+		if task.DueDate == nil {
+			return nil, invalidAction("task has no due date to extend; give newDueDate instead")
+		}
+		extended := task.DueDate.AddDate(0, 0, int(days))
+		newDue = &extended
+	}
+	if task.DueDate != nil && !newDue.After(*task.DueDate) {
+		return nil, invalidAction("newDueDate must be after the current due date %s", task.DueDate.Format(time.RFC3339))
+	}
+
+	before := *task
+	previous := task.DueDate
+	task.DueDate = newDue
+	if err := repos.GetTask().Update(ctx, act.OrgID, task); err != nil {
+		return nil, err
+	}
+	act.changedTask(&before, task)
+	act.Nudge.Status = models.NudgeStatusActed
+
+	var from interface{}
+	if previous != nil {
+		from = *previous
+	}
+	return &dto.NudgeActionResult{
+		TaskID:  task.ID.String(),
+		Summary: fmt.Sprintf("Moved the due date of %q to %s", task.Title, newDue.Format("2006-01-02")),
+		Changes: []dto.NudgeChange{taskChange(task, "dueDate", from, *newDue)},
+	}, nil
+}
+
+// splitTask breaks a task into subtasks described by the parts parameter. The subtasks
+// start in the first status of the project's workflow.
+func splitTask(ctx context.Context, repos *repositories.Provider, act *NudgeActionContext) (*dto.NudgeActionResult, error) {
+	task, err := act.loadTask(ctx, repos, "taskId")
+	if err != nil {
+		return nil, err
+	}
+	if task.Status == models.TaskStatusDone {
+		return nil, invalidAction("cannot split a completed task")
+	}
+	if task.HierarchyLevel >= models.MaxHierarchyLevel {
+		return nil, invalidAction("task is already at the deepest hierarchy level")
+	}
+
+	rawParts, ok := act.Params["parts"].([]interface{})
+	if !ok || len(rawParts) < 2 {
+		return nil, invalidAction("parts must list at least two subtasks")
+	}
+	if len(rawParts) > maxSplitParts {
+		return nil, invalidAction("parts may list at most %d subtasks", maxSplitParts)
+	}
+
+	subtasks := make([]models.Task, 0, len(rawParts))
+	for i, raw := range rawParts {
+		part, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, invalidAction("parts[%d] must be an object", i)
+		}
+		title, _ := part["title"].(string)
+		if strings.TrimSpace(title) == "" {
+			return nil, invalidAction("parts[%d].title is required", i)
+		}
+		hours, _, err := paramNumber(part, "estimatedHours")
+		if err != nil || hours < 0 {
+			return nil, invalidAction("parts[%d].estimatedHours must be a non-negative number", i)
+		}
+		assignee := task.AssigneeID
+		if _, given := part["assigneeId"]; given {
+			id, err := paramUUID(part, "assigneeId", nil)
+			if err != nil {
+				return nil, err
+			}
+			if err := act.checkMember(ctx, repos, id); err != nil {
+				return nil, err
+			}
+			assignee = &id
+		}
+
+		parentID := task.ID
+		subtasks = append(subtasks, models.Task{
+			ProjectID:      task.ProjectID,
+			ParentTaskID:   &parentID,
+			HierarchyLevel: task.HierarchyLevel + 1,
+			Title:          strings.TrimSpace(title),
+			Priority:       task.Priority,
+			BusinessValue:  task.BusinessValue,
+			EstimatedHours: hours,
+			DueDate:        task.DueDate,
+			AssigneeID:     assignee,
+		})
+	}
+
+	result := &dto.NudgeActionResult{
+		TaskID:  task.ID.String(),
+		Summary: fmt.Sprintf("Split %q into %d subtasks", task.Title, len(subtasks)),
+		Changes: []dto.NudgeChange{},
+	}
+	for i := range subtasks {
+		if err := initialStatus(ctx, repos, act.OrgID, &subtasks[i], "", act.Now); err != nil {
+			return nil, err
+		}
+		if err := repos.GetTask().Create(ctx, act.OrgID, &subtasks[i]); err != nil {
+			return nil, err
+		}
+		act.changedTask(nil, &subtasks[i])
+		result.CreatedTaskIDs = append(result.CreatedTaskIDs, subtasks[i].ID.String())
+		result.Changes = append(result.Changes, taskChange(&subtasks[i], "created", nil, subtasks[i].Title))
+	}
+	act.Nudge.Status = models.NudgeStatusActed
+	return result, nil
+}
+
+// addDependency makes the task wait on dependsOnTaskId
+func addDependency(ctx context.Context, repos *repositories.Provider, act *NudgeActionContext) (*dto.NudgeActionResult, error) {
+	task, err := act.loadTask(ctx, repos, "taskId")
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := act.Params["dependsOnTaskId"]; !ok {
+		return nil, invalidAction("dependsOnTaskId is required")
+	}
+	predecessor, err := act.loadTask(ctx, repos, "dependsOnTaskId")
+	if err != nil {
+		return nil, err
+	}
+	if predecessor.ID == task.ID {
+		return nil, invalidAction("a task cannot depend on itself")
+	}
+
+	depType := models.DependencyFinishToStart
+	if v, ok := act.Params["dependencyType"]; ok {
+		s, _ := v.(string)
+		switch models.DependencyType(s) {
+		case models.DependencyFinishToStart, models.DependencyStartToStart,
+			models.DependencyFinishToFinish, models.DependencyStartToFinish:
+			depType = models.DependencyType(s)
+		default:
+			return nil, invalidAction("unknown dependencyType %q", s)
+		}
+	}
+	lag, _, err := paramNumber(act.Params, "lagHours")
+	if err != nil || lag < 0 {
+		return nil, invalidAction("lagHours must be a non-negative number")
+	}
+
+	exists, err := repos.GetDependency().HasDependency(ctx, act.OrgID, task.ID, predecessor.ID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, invalidAction("%q already depends on %q", task.Title, predecessor.Title)
+	}
+	cycle, err := repos.GetDependency().WouldCreateCycle(ctx, act.OrgID, task.ID, predecessor.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cycle {
+		return nil, invalidAction("dependency would create a cycle")
+	}
+
+	dep := &models.TaskDependency{
+		TaskID:          task.ID,
+		DependsOnTaskID: predecessor.ID,
+		DependencyType:  depType,
+		LagHours:        int(lag),
+	}
+	if err := repos.GetDependency().Create(ctx, act.OrgID, dep); err != nil {
+		return nil, err
+	}
+	act.changedProjects(task.ProjectID, predecessor.ProjectID)
+	act.Nudge.Status = models.NudgeStatusActed
+
+	return &dto.NudgeActionResult{
+		TaskID:       task.ID.String(),
+		DependencyID: dep.ID.String(),
+		Summary:      fmt.Sprintf("%q now depends on %q", task.Title, predecessor.Title),
+		Changes: []dto.NudgeChange{{
+			Entity:   "dependency",
+			EntityID: dep.ID.String(),
+			Field:    "created",
+			To:       fmt.Sprintf("%s -> %s (%s)", predecessor.ID, task.ID, depType),
+		}},
+	}, nil
+}
+
+// snoozeNudge hides the nudge until a time, or for a number of hours
+func snoozeNudge(ctx context.Context, repos *repositories.Provider, act *NudgeActionContext) (*dto.NudgeActionResult, error) {
+	until, err := paramTime(act.Params, "until")
+	if err != nil {
+		return nil, err
+	}
+	if until == nil {
+		hours, ok, err := paramNumber(act.Params, "hours")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, invalidAction("until or hours is required")
+		}
+		t := act.Now.Add(time.Duration(hours * float64(time.Hour)))
+		until = &t
+	}
+	if !until.After(act.Now) {
+		return nil, invalidAction("snooze must end in the future")
+	}
+	if until.Sub(act.Now) > maxSnooze {
+		return nil, invalidAction("snooze may last at most %d days", int(maxSnooze.Hours()/24))
+	}
+
+	from := act.Nudge.Status
+	act.Nudge.Status = models.NudgeStatusSnoozed
+	act.Nudge.SnoozedUntil = until
+
+	return &dto.NudgeActionResult{
+		SnoozedUntil: until,
+		Summary:      fmt.Sprintf("Snoozed until %s", until.Format(time.RFC3339)),
+		Changes:      []dto.NudgeChange{nudgeChange(act.Nudge, "status", from, act.Nudge.Status)},
+	}, nil
+}
+
+// escalateNudge raises the nudge to high severity and flags it for the given users,
+// defaulting to the organization's admins and project managers
+func escalateNudge(ctx context.Context, repos *repositories.Provider, act *NudgeActionContext) (*dto.NudgeActionResult, error) {
+	var recipients []uuid.UUID
+	if raw, ok := act.Params["userIds"]; ok {
+		ids, ok := raw.([]interface{})
+		if !ok || len(ids) == 0 {
+			return nil, invalidAction("userIds must be a non-empty list")
+		}
+		for _, raw := range ids {
+			s, _ := raw.(string)
+			id, err := uuid.Parse(s)
+			if err != nil {
+				return nil, invalidAction("userIds must contain UUIDs")
+			}
+			if err := act.checkMember(ctx, repos, id); err != nil {
+				return nil, err
+			}
+			recipients = append(recipients, id)
+		}
+	} else {
+		var err error
+		recipients, err = escalationRecipients(ctx, repos, act.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		if len(recipients) == 0 {
+			return nil, invalidAction("organization has no admins or project managers to escalate to")
+		}
+	}
+
+	changes := []dto.NudgeChange{}
+	if act.Nudge.Severity != models.NudgeSeverityHigh {
+		changes = append(changes, nudgeChange(act.Nudge, "severity", act.Nudge.Severity, models.NudgeSeverityHigh))
+		act.Nudge.Severity = models.NudgeSeverityHigh
+	}
+	onCriticalPath, _ := act.Nudge.Metrics["isCriticalPath"].(bool)
+	act.Nudge.CriticalityScore = NudgeCriticality(act.Nudge.Type, act.Nudge.Severity, onCriticalPath)
+	act.Nudge.Status = models.NudgeStatusUnread
+	act.Nudge.SnoozedUntil = nil
+	act.Nudge.EscalatedAt = &act.Now
+	changes = append(changes, nudgeChange(act.Nudge, "escalatedAt", nil, act.Now))
+
+	escalatedTo := make([]string, 0, len(recipients))
+	for _, id := range recipients {
+		escalatedTo = append(escalatedTo, id.String())
+	}
+	return &dto.NudgeActionResult{
+		EscalatedTo: escalatedTo,
+		Summary:     fmt.Sprintf("Escalated to %d %s", len(recipients), pluralize(len(recipients), "person", "people")),
+		Changes:     changes,
+	}, nil
+}
+
+// closeNudge returns a handler that only records a final status for the nudge
+func closeNudge(status models.NudgeStatus, summary string) NudgeActionHandler {
+	return func(ctx context.Context, repos *repositories.Provider, act *NudgeActionContext) (*dto.NudgeActionResult, error) {
+		from := act.Nudge.Status
+		act.Nudge.Status = status
+		return &dto.NudgeActionResult{
+			Summary: summary,
+			Changes: []dto.NudgeChange{nudgeChange(act.Nudge, "status", from, status)},
+		}, nil
+	}
+}
+
+// escalationRecipients returns the organization's admins and project managers
+func escalationRecipients(ctx context.Context, repos *repositories.Provider, orgID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, role := range []models.UserRole{models.RoleAdmin, models.RolePM} {
+		users, err := repos.GetUser().GetByOrganizationAndRole(ctx, orgID, role)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			if !seen[u.ID] {
+				seen[u.ID] = true
+				ids = append(ids, u.ID)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// loadTask loads the task named by a parameter, defaulting to the nudge's related task,
+// and checks that it belongs to the organization
+func (act *NudgeActionContext) loadTask(ctx context.Context, repos *repositories.Provider, key string) (*models.Task, error) {
+	taskID, err := paramUUID(act.Params, key, act.Nudge.RelatedTaskID)
+	if err != nil {
+		return nil, err
+	}
+	task, err := repos.GetTask().GetByID(ctx, act.OrgID, taskID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, invalidAction("task %s not found", taskID)
+		}
+		return nil, err
+	}
+	return task, nil
+}
+
+// requireMember reads a required user ID parameter and checks organization membership
+func (act *NudgeActionContext) requireMember(ctx context.Context, repos *repositories.Provider, key string) (uuid.UUID, error) {
+	userID, err := paramUUID(act.Params, key, nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return userID, act.checkMember(ctx, repos, userID)
+}
+
+func (act *NudgeActionContext) checkMember(ctx context.Context, repos *repositories.Provider, userID uuid.UUID) error {
+	member, err := repos.GetOrganization().IsMember(ctx, act.OrgID, userID)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return invalidAction("user %s is not a member of the organization", userID)
+	}
+	return nil
+}
+
+func invalidAction(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidNudgeAction, fmt.Sprintf(format, args...))
+}
+
+func taskChange(task *models.Task, field string, from, to interface{}) dto.NudgeChange {
+	return dto.NudgeChange{Entity: "task", EntityID: task.ID.String(), Field: field, From: from, To: to}
+}
+
+func nudgeChange(nudge *models.Nudge, field string, from, to interface{}) dto.NudgeChange {
+	return dto.NudgeChange{Entity: "nudge", EntityID: nudge.ID.String(), Field: field, From: from, To: to}
+}
+
+func optionalID(id *uuid.UUID) interface{} {
+	if id == nil {
+		return nil
+	}
+	return id.String()
+}
+
+// paramUUID reads a UUID parameter, falling back to def when absent
+func paramUUID(params map[string]interface{}, key string, def *uuid.UUID) (uuid.UUID, error) {
+	raw, ok := params[key]
+	if !ok {
+		if def == nil {
+			return uuid.Nil, invalidAction("%s is required", key)
+		}
+		return *def, nil
+	}
+	s, _ := raw.(string)
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, invalidAction("%s must be a UUID", key)
+	}
+	return id, nil
+}
+
+// paramTime reads an RFC 3339 or YYYY-MM-DD parameter; nil means absent
+func paramTime(params map[string]interface{}, key string) (*time.Time, error) {
+	raw, ok := params[key]
+	if !ok {
+		return nil, nil
+	}
+	s, _ := raw.(string)
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			t = t.UTC()
+			return &t, nil
+		}
+	}
+	return nil, invalidAction("%s must be an RFC 3339 timestamp or YYYY-MM-DD date", key)
+}
+
+// paramNumber reads a numeric parameter, reporting whether it was present
+func paramNumber(params map[string]interface{}, key string) (float64, bool, error) {
+	raw, ok := params[key]
+	if !ok {
+		return 0, false, nil
+	}
+	switch v := raw.(type) {
+	case float64:
+		return v, true, nil
+	case int:
+		return float64(v), true, nil
+	}
+	return 0, true, invalidAction("%s must be a number", key)
+}
@@ -0,0 +1,519 @@
+package services_test
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/jobs"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// In-memory repositories implementing only what the action handlers use
+
+type memNudgeRepo struct {
+	repositories.NudgeRepository
+	nudges  map[uuid.UUID]*models.Nudge
+	actions []models.NudgeAction
+}
+
+func (r *memNudgeRepo) Create(ctx context.Context, orgID uuid.UUID, n *models.Nudge) error {
+	n.ID, n.OrganizationID = uuid.New(), orgID
+	clone := *n
+	r.nudges[n.ID] = &clone
+	return nil
+}
+
+func (r *memNudgeRepo) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.Nudge, error) {
+	n, ok := r.nudges[id]
+	if !ok || n.OrganizationID != orgID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	clone := *n
+	return &clone, nil
+}
+
+func (r *memNudgeRepo) Update(ctx context.Context, orgID uuid.UUID, n *models.Nudge) error {
+	clone := *n
+	r.nudges[n.ID] = &clone
+	return nil
+}
+
+func (r *memNudgeRepo) CreateAction(ctx context.Context, a *models.NudgeAction) error {
+	r.actions = append(r.actions, *a)
+	return nil
+}
+
+func (r *memNudgeRepo) WakeSnoozedNudges(ctx context.Context, now time.Time) (int64, error) {
+	var woken int64
+	for _, n := range r.nudges {
+		if n.Status == models.NudgeStatusSnoozed && !n.SnoozedUntil.After(now) {
+			n.Status, n.SnoozedUntil = models.NudgeStatusUnread, nil
+			woken++
+		}
+	}
+	return woken, nil
+}
+
+func (r *memNudgeRepo) ListEscalationCandidates(ctx context.Context, severity models.NudgeSeverity, createdBefore time.Time) ([]models.Nudge, error) {
+	var out []models.Nudge
+	for _, n := range r.nudges {
+		if n.Status == models.NudgeStatusUnread && n.Severity == severity && n.EscalatedAt == nil && n.CreatedAt.Before(createdBefore) {
+			out = append(out, *n)
+		}
+	}
+	return out, nil
+}
+
+func (r *memNudgeRepo) ListOpen(ctx context.Context, orgID uuid.UUID, types []models.NudgeType) ([]models.Nudge, error) {
+	var out []models.Nudge
+	for _, n := range r.nudges {
+		open := n.Status == models.NudgeStatusUnread || n.Status == models.NudgeStatusRead || n.Status == models.NudgeStatusSnoozed
+		if n.OrganizationID == orgID && open && slices.Contains(types, n.Type) {
+			out = append(out, *n)
+		}
+	}
+	return out, nil
+}
+
+func (r *memNudgeRepo) ExpireOldNudges(ctx context.Context) (int64, error) { return 0, nil }
+
+func (r *memNudgeRepo) DeleteOldNudges(ctx context.Context, olderThan time.Duration) (int64, error) {
+	return 0, nil
+}
+
+type memUserRepo struct {
+	repositories.UserRepository
+	byRole map[models.UserRole][]models.User
+	byID   map[uuid.UUID]*models.User
+}
+
+func (r *memUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	u, ok := r.byID[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return u, nil
+}
+
+func (r *memUserRepo) GetByOrganizationAndRole(ctx context.Context, orgID uuid.UUID, role models.UserRole) ([]models.User, error) {
+	return r.byRole[role], nil
+}
+
+type memTaskRepo struct {
+	repositories.TaskRepository
+	tasks map[uuid.UUID]*models.Task
+}
+
+func (r *memTaskRepo) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.Task, error) {
+	clone := *r.tasks[id]
+	return &clone, nil
+}
+
+func (r *memTaskRepo) UpdateAssignee(ctx context.Context, orgID, id uuid.UUID, assignee *uuid.UUID) error {
+	r.tasks[id].AssigneeID = assignee
+	return nil
+}
+
+func (r *memTaskRepo) Create(ctx context.Context, orgID uuid.UUID, t *models.Task) error {
+	t.ID = uuid.New()
+	clone := *t
+	r.tasks[t.ID] = &clone
+	return nil
+}
+
+func (r *memTaskRepo) Update(ctx context.Context, orgID uuid.UUID, t *models.Task) error {
+	clone := *t
+	r.tasks[t.ID] = &clone
+	return nil
+}
+
+func (r *memTaskRepo) ListByProject(ctx context.Context, orgID, projectID uuid.UUID, params repositories.ListParams) ([]models.Task, int64, error) {
+	var out []models.Task
+	for _, t := range r.tasks {
+		if t.ProjectID == projectID {
+			out = append(out, *t)
+		}
+	}
+	return out, int64(len(out)), nil
+}
+
+func (r *memTaskRepo) UpdateCriticalPath(ctx context.Context, orgID, projectID uuid.UUID, criticalTaskIDs []uuid.UUID) error {
+	for _, t := range r.tasks {
+		if t.ProjectID == projectID {
+			t.IsCriticalPath = slices.Contains(criticalTaskIDs, t.ID)
+		}
+	}
+	return nil
+}
+
+type memProjectRepo struct {
+	repositories.ProjectRepository
+	project models.Project
+}
+
+func (r *memProjectRepo) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.Project, error) {
+	clone := r.project
+	return &clone, nil
+}
+
+func (r *memProjectRepo) UpdateProgress(ctx context.Context, orgID, projectID uuid.UUID, progress int) error {
+	r.project.Progress = progress
+	return nil
+}
+
+type memOrgRepo struct {
+	repositories.OrganizationRepository
+	members map[uuid.UUID]bool
+}
+
+func (r *memOrgRepo) IsMember(ctx context.Context, orgID, userID uuid.UUID) (bool, error) {
+	return r.members[userID], nil
+}
+
+type memDependencyRepo struct {
+	repositories.DependencyRepository
+	cycle bool
+	deps  []models.TaskDependency
+}
+
+func (r *memDependencyRepo) Create(ctx context.Context, orgID uuid.UUID, d *models.TaskDependency) error {
+	d.ID = uuid.New()
+	r.deps = append(r.deps, *d)
+	return nil
+}
+
+func (r *memDependencyRepo) ListByProject(ctx context.Context, orgID, projectID uuid.UUID) ([]models.TaskDependency, error) {
+	return r.deps, nil
+}
+
+func (r *memDependencyRepo) HasDependency(ctx context.Context, orgID, taskID, dependsOnTaskID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+func (r *memDependencyRepo) WouldCreateCycle(ctx context.Context, orgID, taskID, dependsOnTaskID uuid.UUID) (bool, error) {
+	return r.cycle, nil
+}
+
+// memJobRepo runs the jobs it is given once, in the order they were queued
+type memJobRepo struct {
+	repositories.JobRepository
+	mu   sync.Mutex
+	jobs []*models.Job
+}
+
+func (r *memJobRepo) Create(ctx context.Context, job *models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.ID = uuid.New()
+	clone := *job
+	r.jobs = append(r.jobs, &clone)
+	return nil
+}
+
+func (r *memJobRepo) ClaimNext(ctx context.Context, types []string) (*models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, job := range r.jobs {
+		if job.Status == models.JobStatusQueued && slices.Contains(types, job.Type) {
+			job.Status = models.JobStatusRunning
+			job.Attempts++
+			clone := *job
+			return &clone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memJobRepo) finish(id uuid.UUID, status models.JobStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, job := range r.jobs {
+		if job.ID == id {
+			job.Status = status
+		}
+	}
+	return nil
+}
+
+func (r *memJobRepo) MarkSucceeded(ctx context.Context, id uuid.UUID, result models.JSONB) error {
+	return r.finish(id, models.JobStatusSucceeded)
+}
+
+func (r *memJobRepo) MarkFailed(ctx context.Context, id uuid.UUID, errMsg string) error {
+	return r.finish(id, models.JobStatusFailed)
+}
+
+func (r *memJobRepo) RequeueRunning(ctx context.Context) (int64, error) { return 0, nil }
+
+// sentNudges records who each nudge was sent to
+type sentNudges struct {
+	services.NotificationDispatcher
+	mu   sync.Mutex
+	sent map[uuid.UUID][]uuid.UUID
+}
+
+func (n *sentNudges) NotifyNudge(ctx context.Context, nudge *models.Nudge, recipients []uuid.UUID) ([]uuid.UUID, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent[nudge.ID] = append(n.sent[nudge.ID], recipients...)
+	return recipients, nil
+}
+
+func (n *sentNudges) recipients(nudgeID uuid.UUID) []uuid.UUID {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.sent[nudgeID]
+}
+
+var _ = Describe("Nudge Actions", func() {
+	var (
+		ctx      context.Context
+		service  services.NudgeService
+		nudges   *memNudgeRepo
+		tasks    *memTaskRepo
+		deps     *memDependencyRepo
+		flows    *workflowRepo
+		projects *memProjectRepo
+		repos    *repositories.Provider
+		events   *taskEventRepo
+		orgID    uuid.UUID
+		actorID  uuid.UUID
+		emma     uuid.UUID
+		rachel   uuid.UUID
+		task     models.Task
+		nudge    models.Nudge
+		outsider uuid.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		orgID, actorID, emma, rachel, outsider = uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+		project := models.Project{OrganizationID: orgID, Name: "Apollo"}
+		project.ID = uuid.New()
+		due := time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)
+		task = models.Task{ProjectID: project.ID, Title: "Consultation", AssigneeID: &emma, DueDate: &due}
+		task.ID = uuid.New()
+		nudge = models.Nudge{OrganizationID: orgID, Type: models.NudgeTypeOverload, Severity: models.NudgeSeverityMedium,
+			Status: models.NudgeStatusUnread, RelatedTaskID: &task.ID, RelatedUserID: &emma}
+		nudge.ID = uuid.New()
+
+		nudges = &memNudgeRepo{nudges: map[uuid.UUID]*models.Nudge{nudge.ID: &nudge}}
+		tasks = &memTaskRepo{tasks: map[uuid.UUID]*models.Task{task.ID: &task}}
+		deps = &memDependencyRepo{}
+		flows = &workflowRepo{workflows: map[uuid.UUID]*models.Workflow{}}
+		events = &taskEventRepo{}
+		projects = &memProjectRepo{project: project}
+		repos = &repositories.Provider{
+			Nudge:        nudges,
+			Task:         tasks,
+			Project:      projects,
+			Organization: &memOrgRepo{members: map[uuid.UUID]bool{emma: true, rachel: true, actorID: true}},
+			Dependency:   deps,
+			TaskEvent:    events,
+			Workflow:     flows,
+		}
+		service = services.NewRealNudgeService(repos, jobs.NewQueue(nil, jobs.DefaultConfig()), nil)
+	})
+
+	take := func(actionType string, params map[string]interface{}) (*dto.NudgeActionResponse, error) {
+		req := dto.NudgeActionRequest{ActionType: actionType, Parameters: params}
+		return service.TakeNudgeAction(ctx, nudge.ID.String(), req, orgID.String(), actorID)
+	}
+
+	Context("Given a reassign action", func() {
+		It("should move the task and record an audit row", func() {
+			resp, err := take("reassign", map[string]interface{}{"toUserId": rachel.String()})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*tasks.tasks[task.ID].AssigneeID).To(Equal(rachel))
+			Expect(resp.NudgeStatus).To(Equal("acted"))
+			Expect(resp.Result.FromUserID).To(Equal(emma.String()))
+			Expect(resp.Result.ToUserID).To(Equal(rachel.String()))
+			Expect(resp.Result.Changes).To(HaveLen(1))
+			Expect(resp.Result.Changes[0].Field).To(Equal("assigneeId"))
+
+			Expect(nudges.actions).To(HaveLen(1))
+			Expect(nudges.actions[0].ActionType).To(Equal("reassign"))
+			Expect(*nudges.actions[0].UserID).To(Equal(actorID))
+			Expect(nudges.actions[0].Parameters).To(HaveKey("changes"))
+			Expect(nudges.nudges[nudge.ID].Status).To(Equal(models.NudgeStatusActed))
+
+			Expect(events.events).To(HaveLen(1))
+			Expect(events.events[0].TaskID).To(Equal(task.ID))
+			Expect(events.events[0].Type).To(Equal(models.TaskEventAssigneeChanged))
+			Expect(events.events[0].FromValue).To(Equal(emma.String()))
+			Expect(events.events[0].ToValue).To(Equal(rachel.String()))
+			Expect(*events.events[0].ActorID).To(Equal(actorID))
+		})
+
+		It("should reject a user outside the organization without changing anything", func() {
+			_, err := take("reassign", map[string]interface{}{"toUserId": outsider.String()})
+
+			Expect(err).To(MatchError(services.ErrInvalidNudgeAction))
+			Expect(*tasks.tasks[task.ID].AssigneeID).To(Equal(emma))
+			Expect(nudges.actions).To(BeEmpty())
+			Expect(events.events).To(BeEmpty())
+		})
+	})
+
+	Context("Given an extend due date action", func() {
+		It("should push the due date out by the given days", func() {
+			resp, err := take("extend_due_date", map[string]interface{}{"days": float64(3)})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*tasks.tasks[task.ID].DueDate).To(Equal(time.Date(2026, 3, 23, 0, 0, 0, 0, time.UTC)))
+			Expect(resp.Result.Changes[0].Field).To(Equal("dueDate"))
+
+			Expect(events.events).To(HaveLen(1))
+			Expect(events.events[0].Type).To(Equal(models.TaskEventDueDateChanged))
+		})
+
+		It("should refuse a part of a day", func() {
+			_, err := take("extend_due_date", map[string]interface{}{"days": 1.5})
+
+			Expect(err).To(MatchError(services.ErrInvalidNudgeAction))
+			Expect(*tasks.tasks[task.ID].DueDate).To(Equal(time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)))
+		})
+
+		It("should refuse to move the due date earlier", func() {
+			_, err := take("extend_due_date", map[string]interface{}{"newDueDate": "2026-03-01"})
+			Expect(err).To(MatchError(services.ErrInvalidNudgeAction))
+		})
+	})
+
+	Context("Given a split task action", func() {
+		It("should start the subtasks in the first status of the project's workflow", func() {
+			flows.workflows[task.ProjectID] = &models.Workflow{ProjectID: task.ProjectID, Statuses: []models.WorkflowStatus{
+				{Key: "todo", Category: models.TaskStatusReady},
+				{Key: "shipped", Category: models.TaskStatusDone},
+			}}
+			resp, err := take("split_task", map[string]interface{}{"parts": []interface{}{
+				map[string]interface{}{"title": "Intake form", "estimatedHours": float64(3)},
+				map[string]interface{}{"title": "Follow-up call", "estimatedHours": float64(2)},
+			}})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Result.CreatedTaskIDs).To(HaveLen(2))
+			for _, id := range resp.Result.CreatedTaskIDs {
+				subtask := tasks.tasks[uuid.MustParse(id)]
+				Expect(subtask.Status).To(Equal(models.TaskStatusReady))
+				Expect(subtask.WorkflowStatus).To(Equal("todo"))
+				Expect(*subtask.ParentTaskID).To(Equal(task.ID))
+			}
+
+			var created []string
+			for _, e := range events.events {
+				if e.Type == models.TaskEventStatusChanged {
+					Expect(e.FromValue).To(BeEmpty())
+					Expect(e.ToValue).To(Equal("todo"))
+					created = append(created, e.TaskID.String())
+				}
+			}
+			Expect(created).To(ConsistOf(resp.Result.CreatedTaskIDs))
+		})
+
+		It("should refresh the project's stored progress", func() {
+			projects.project.Progress = 100
+			_, err := take("split_task", map[string]interface{}{"parts": []interface{}{
+				map[string]interface{}{"title": "Intake form", "estimatedHours": float64(3)},
+				map[string]interface{}{"title": "Follow-up call", "estimatedHours": float64(2)},
+			}})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(projects.project.Progress).To(Equal(0))
+		})
+	})
+
+	Context("Given an add dependency action", func() {
+		It("should refresh the project's critical path", func() {
+			task.EstimatedHours = 8
+			other := models.Task{ProjectID: task.ProjectID, Title: "API", EstimatedHours: 8}
+			other.ID = uuid.New()
+			tasks.tasks[other.ID] = &other
+			_, err := take("add_dependency", map[string]interface{}{"dependsOnTaskId": other.ID.String()})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deps.deps).To(HaveLen(1))
+			Expect(tasks.tasks[task.ID].IsCriticalPath).To(BeTrue())
+			Expect(tasks.tasks[other.ID].IsCriticalPath).To(BeTrue())
+		})
+	})
+
+	Context("Given an add dependency action that would form a cycle", func() {
+		It("should be rejected", func() {
+			other := models.Task{ProjectID: task.ProjectID, Title: "API"}
+			other.ID = uuid.New()
+			tasks.tasks[other.ID] = &other
+			deps.cycle = true
+
+			_, err := take("add_dependency", map[string]interface{}{"dependsOnTaskId": other.ID.String()})
+			Expect(err).To(MatchError(services.ErrInvalidNudgeAction))
+		})
+	})
+
+	Context("Given a snooze action", func() {
+		It("should hide the nudge until the requested time", func() {
+			resp, err := take("snooze", map[string]interface{}{"hours": float64(24)})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.NudgeStatus).To(Equal("snoozed"))
+			Expect(resp.Result.SnoozedUntil).NotTo(BeNil())
+			Expect(nudges.nudges[nudge.ID].SnoozedUntil).NotTo(BeNil())
+		})
+
+		It("should require a duration", func() {
+			_, err := take("snooze", nil)
+			Expect(err).To(MatchError(services.ErrInvalidNudgeAction))
+		})
+	})
+
+	Context("Given an escalate action", func() {
+		It("should queue the notice instead of sending it before the escalation commits", func() {
+			jobRepo := &memJobRepo{}
+			queue := jobs.NewQueue(jobRepo, jobs.Config{Workers: 1, PollInterval: 10 * time.Millisecond})
+			notifier := &sentNudges{sent: map[uuid.UUID][]uuid.UUID{}}
+			service = services.NewRealNudgeService(repos, queue, notifier)
+
+			resp, err := take("escalate", map[string]interface{}{"userIds": []interface{}{rachel.String()}})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Result.EscalatedTo).To(ConsistOf(rachel.String()))
+			Expect(notifier.recipients(nudge.ID)).To(BeEmpty())
+			Expect(jobRepo.jobs).To(HaveLen(1))
+			Expect(jobRepo.jobs[0].Type).To(Equal(services.JobTypeNudgeEscalationNotice))
+
+			Expect(queue.Start(ctx)).To(Succeed())
+			defer queue.Stop()
+			Eventually(func() []uuid.UUID { return notifier.recipients(nudge.ID) }).Should(Equal([]uuid.UUID{rachel}))
+		})
+	})
+
+	Context("Given a nudge from another organization", func() {
+		It("should report it as not found", func() {
+			req := dto.NudgeActionRequest{ActionType: "dismiss"}
+			_, err := service.TakeNudgeAction(ctx, nudge.ID.String(), req, uuid.New().String(), actorID)
+			Expect(err).To(MatchError(services.ErrNudgeNotFound))
+		})
+	})
+
+	Context("Given a nudge that is already closed", func() {
+		It("should reject further actions", func() {
+			_, err := take("dismiss", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = take("accept_suggestion", nil)
+			Expect(err).To(MatchError(services.ErrInvalidNudgeAction))
+		})
+	})
+})
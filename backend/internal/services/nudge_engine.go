@@ -0,0 +1,672 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+)
+
+// NudgeSnapshot holds the organization data the nudge rules are evaluated against
+type NudgeSnapshot struct {
+	Now          time.Time
+	Projects     []models.Project
+	Tasks        []models.Task
+	Dependencies []models.TaskDependency
+	TaskSkills   []models.TaskSkill
+	UserSkills   []models.UserSkill
+	Workloads    []models.WorkloadEntry
+	Users        map[uuid.UUID]models.User
+}
+
+// NudgeRule detects the conditions for one nudge type
+type NudgeRule func(snap *NudgeSnapshot) []models.Nudge
+
+// nudgeRules maps every nudge type to the rule that detects it
+var nudgeRules = map[models.NudgeType]NudgeRule{
+	models.NudgeTypeOverload:        detectOverload,
+	models.NudgeTypeDelayRisk:       detectDelayRisk,
+	models.NudgeTypeSkillGap:        detectSkillGap,
+	models.NudgeTypeUnassigned:      detectUnassigned,
+	models.NudgeTypeBlocked:         detectBlocked,
+	models.NudgeTypeConflict:        detectConflict,
+	models.NudgeTypeDependencyBlock: detectDependencyBlock,
+}
+
+// nudgeTypeOrder is the order rules are evaluated in, so output is deterministic
+var nudgeTypeOrder = []models.NudgeType{
+	models.NudgeTypeOverload,
+	models.NudgeTypeDelayRisk,
+	models.NudgeTypeSkillGap,
+	models.NudgeTypeUnassigned,
+	models.NudgeTypeBlocked,
+	models.NudgeTypeConflict,
+	models.NudgeTypeDependencyBlock,
+}
+
+// nudgeBaseCriticality is the starting criticality of each nudge type
+var nudgeBaseCriticality = map[models.NudgeType]int{
+	models.NudgeTypeOverload:        85,
+	models.NudgeTypeDelayRisk:       80,
+	models.NudgeTypeSkillGap:        60,
+	models.NudgeTypeUnassigned:      70,
+	models.NudgeTypeBlocked:         75,
+	models.NudgeTypeConflict:        55,
+	models.NudgeTypeDependencyBlock: 65,
+}
+
+// nudgeTTL is how long a nudge stays relevant before it expires
+var nudgeTTL = map[models.NudgeType]time.Duration{
+	models.NudgeTypeOverload:        7 * 24 * time.Hour,
+	models.NudgeTypeDelayRisk:       7 * 24 * time.Hour,
+	models.NudgeTypeSkillGap:        14 * 24 * time.Hour,
+	models.NudgeTypeUnassigned:      3 * 24 * time.Hour,
+	models.NudgeTypeBlocked:         2 * 24 * time.Hour,
+	models.NudgeTypeConflict:        7 * 24 * time.Hour,
+	models.NudgeTypeDependencyBlock: 3 * 24 * time.Hour,
+}
+
+// ParseNudgeTypes validates requested nudge types; an empty list selects every type
+func ParseNudgeTypes(types []string) ([]models.NudgeType, error) {
+	if len(types) == 0 {
+		return nudgeTypeOrder, nil
+	}
+
+	requested := make(map[models.NudgeType]bool, len(types))
+	for _, t := range types {
+		nt := models.NudgeType(t)
+		if _, ok := nudgeRules[nt]; !ok {
+			return nil, fmt.Errorf("unknown nudge type %q", t)
+		}
+		requested[nt] = true
+	}
+
+	selected := make([]models.NudgeType, 0, len(requested))
+	for _, nt := range nudgeTypeOrder {
+		if requested[nt] {
+			selected = append(selected, nt)
+		}
+	}
+	return selected, nil
+}
+
+// EvaluateNudgeRules runs the rules for the given types and fills in scoring and expiry
+func EvaluateNudgeRules(snap *NudgeSnapshot, types []models.NudgeType) []models.Nudge {
+	var nudges []models.Nudge
+	for _, nt := range types {
+		rule, ok := nudgeRules[nt]
+		if !ok {
+			continue
+		}
+		for _, n := range rule(snap) {
+			completeNudge(&n, nt, snap.Now)
+			nudges = append(nudges, n)
+		}
+	}
+
+	sort.SliceStable(nudges, func(i, j int) bool {
+		return nudges[i].CriticalityScore > nudges[j].CriticalityScore
+	})
+	return nudges
+}
+
+// completeNudge fills in the type, status, scoring, expiry and fingerprint of a
+// detected nudge
+func completeNudge(n *models.Nudge, nudgeType models.NudgeType, now time.Time) {
+	n.Type = nudgeType
+	n.Status = models.NudgeStatusUnread
+	onCriticalPath, _ := n.Metrics["isCriticalPath"].(bool)
+	n.CriticalityScore = NudgeCriticality(nudgeType, n.Severity, onCriticalPath)
+	expiresAt := now.Add(nudgeTTL[nudgeType])
+	n.ExpiresAt = &expiresAt
+	n.Fingerprint = NudgeFingerprint(n)
+}
+
+// NudgeFingerprint identifies the condition a nudge reports from its type and related entities,
+// so the same overloaded person or blocked task maps to the same nudge across runs
+func NudgeFingerprint(n *models.Nudge) string {
+	ref := func(id *uuid.UUID) string {
+		if id == nil {
+			return "-"
+		}
+		return id.String()
+	}
+	key := strings.Join([]string{
+		string(n.Type),
+		ref(n.RelatedProjectID),
+		ref(n.RelatedTaskID),
+		ref(n.RelatedUserID),
+	}, "|")
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// NudgeReconciliation is the outcome of matching detected conditions against open nudges
+type NudgeReconciliation struct {
+	Create     []models.Nudge // conditions with no open nudge
+	Refresh    []models.Nudge // open nudges updated from their re-detected condition
+	Resolve    []models.Nudge // open nudges whose condition has cleared
+	Superseded []models.Nudge // older duplicate nudges for the same condition
+}
+
+// ReconcileNudges matches freshly detected nudges to open ones by fingerprint. Open nudges
+// that were not re-detected are resolved when inScope reports they were evaluated this run;
+// duplicate open nudges for the same condition are superseded by the newest.
+func ReconcileNudges(open, detected []models.Nudge, inScope func(models.Nudge) bool, now time.Time) NudgeReconciliation {
+	var result NudgeReconciliation
+
+	existing := make(map[string]models.Nudge, len(open))
+	for _, n := range open {
+		if n.Fingerprint == "" {
+			n.Fingerprint = NudgeFingerprint(&n)
+		}
+		if current, ok := existing[n.Fingerprint]; ok {
+			if current.CreatedAt.After(n.CreatedAt) {
+				result.Superseded = append(result.Superseded, n)
+				continue
+			}
+			result.Superseded = append(result.Superseded, current)
+		}
+		existing[n.Fingerprint] = n
+	}
+
+	seen := make(map[string]bool, len(detected))
+	for _, d := range detected {
+		if seen[d.Fingerprint] {
+			continue
+		}
+		seen[d.Fingerprint] = true
+
+		current, ok := existing[d.Fingerprint]
+		if !ok {
+			d.LastDetectedAt = &now
+			result.Create = append(result.Create, d)
+			continue
+		}
+		// An escalated condition should be seen again even if the nudge was read or snoozed
+		if nudgeSeverityRank[d.Severity] > nudgeSeverityRank[current.Severity] {
+			current.Status = models.NudgeStatusUnread
+			current.SnoozedUntil = nil
+		}
+		current.Severity = d.Severity
+		current.Title = d.Title
+		current.Description = d.Description
+		current.AIExplanation = d.AIExplanation
+		current.SuggestedAction = d.SuggestedAction
+		current.ConfidenceScore = d.ConfidenceScore
+		current.CriticalityScore = d.CriticalityScore
+		current.Metrics = d.Metrics
+		current.ExpiresAt = d.ExpiresAt
+		current.LastDetectedAt = &now
+		result.Refresh = append(result.Refresh, current)
+	}
+
+	for fp, n := range existing {
+		if !seen[fp] && inScope(n) {
+			result.Resolve = append(result.Resolve, n)
+		}
+	}
+	sort.SliceStable(result.Resolve, func(i, j int) bool {
+		return result.Resolve[i].CreatedAt.Before(result.Resolve[j].CreatedAt)
+	})
+	return result
+}
+
+// nudgeSeverityRank orders severities from least to most urgent
+var nudgeSeverityRank = map[models.NudgeSeverity]int{
+	models.NudgeSeverityLow:    1,
+	models.NudgeSeverityMedium: 2,
+	models.NudgeSeverityHigh:   3,
+}
+
+// NudgeCriticality scores a nudge 0-100 from its type, severity and critical path involvement
+func NudgeCriticality(nudgeType models.NudgeType, severity models.NudgeSeverity, onCriticalPath bool) int {
+	score := float64(nudgeBaseCriticality[nudgeType])
+
+	switch severity {
+	case models.NudgeSeverityHigh:
+		score *= 1.2
+	case models.NudgeSeverityLow:
+		score *= 0.8
+	}
+	if onCriticalPath {
+		score *= 1.15
+	}
+
+	if score > 100 {
+		return 100
+	}
+	return int(score)
+}
+
+// detectOverload flags people allocated above 100% this week
+func detectOverload(snap *NudgeSnapshot) []models.Nudge {
+	var nudges []models.Nudge
+	for _, w := range snap.Workloads {
+		if w.AllocationPercentage <= 100 {
+			continue
+		}
+
+		severity := models.NudgeSeverityLow
+		switch {
+		case w.AllocationPercentage >= 125:
+			severity = models.NudgeSeverityHigh
+		case w.AllocationPercentage >= 110:
+			severity = models.NudgeSeverityMedium
+		}
+
+		name := snap.userName(w.UserID)
+		if w.User.Name != "" {
+			name = w.User.Name
+		}
+		userID := w.UserID
+		nudges = append(nudges, models.Nudge{
+			Severity:        severity,
+			Title:           fmt.Sprintf("%s is overallocated", name),
+			Description:     fmt.Sprintf("%s is at %d%% allocation with %d tasks this week.", name, w.AllocationPercentage, w.AssignedTasks),
+			AIExplanation:   "Sustained allocation above 100% increases the risk of missed deadlines and burnout.",
+			SuggestedAction: "Reassign lower priority work or extend due dates",
+			ConfidenceScore: 0.9,
+			RelatedUserID:   &userID,
+			Metrics: models.JSONB{
+				"allocationPercentage": w.AllocationPercentage,
+				"assignedTasks":        w.AssignedTasks,
+				"totalHours":           w.TotalEstimatedHours,
+				"availableHours":       w.AvailableHours,
+			},
+		})
+	}
+	return nudges
+}
+
+// detectDelayRisk compares each project's progress with the time elapsed towards its TargetEndDate
+func detectDelayRisk(snap *NudgeSnapshot) []models.Nudge {
+	var nudges []models.Nudge
+	for _, p := range snap.Projects {
+		if p.Status != models.ProjectActive || p.StartDate == nil || p.TargetEndDate == nil {
+			continue
+		}
+		total := p.TargetEndDate.Sub(*p.StartDate).Hours()
+		if total <= 0 || snap.Now.Before(*p.StartDate) {
+			continue
+		}
+
+		expected := math.Min(snap.Now.Sub(*p.StartDate).Hours()/total, 1)
+		actual := snap.projectCompletion(p)
+		variance := expected - actual
+		overdue := snap.Now.After(*p.TargetEndDate) && actual < 1
+		if variance <= 0.10 && !overdue {
+			continue
+		}
+
+		severity := models.NudgeSeverityLow
+		switch {
+		case overdue || variance > 0.25:
+			severity = models.NudgeSeverityHigh
+		case variance > 0.15:
+			severity = models.NudgeSeverityMedium
+		}
+
+		// More elapsed schedule makes the variance a more reliable signal
+		confidence := math.Round((0.6+0.3*expected)*100) / 100
+
+		projectID := p.ID
+		daysRemaining := int(math.Floor(p.TargetEndDate.Sub(snap.Now).Hours() / 24))
+		nudges = append(nudges, models.Nudge{
+			Severity:         severity,
+			Title:            fmt.Sprintf("%s is behind schedule", p.Name),
+			Description:      fmt.Sprintf("%s is %.0f%% complete with %.0f%% of its schedule elapsed.", p.Name, actual*100, expected*100),
+			AIExplanation:    "Progress is trailing the time elapsed towards the target end date.",
+			SuggestedAction:  "Review scope or add capacity to critical path tasks",
+			ConfidenceScore:  confidence,
+			RelatedProjectID: &projectID,
+			Metrics: models.JSONB{
+				"expectedProgress": math.Round(expected * 100),
+				"actualProgress":   math.Round(actual * 100),
+				"scheduleVariance": math.Round(variance * 100),
+				"daysRemaining":    daysRemaining,
+			},
+		})
+	}
+	return nudges
+}
+
+// detectSkillGap flags open tasks with a required skill nobody in the organization has at the needed level
+func detectSkillGap(snap *NudgeSnapshot) []models.Nudge {
+	bestProficiency := make(map[uuid.UUID]int)
+	for _, us := range snap.UserSkills {
+		if us.Proficiency > bestProficiency[us.SkillID] {
+			bestProficiency[us.SkillID] = us.Proficiency
+		}
+	}
+
+	missingByTask := make(map[uuid.UUID][]models.TaskSkill)
+	for _, ts := range snap.TaskSkills {
+		if ts.IsRequired && bestProficiency[ts.SkillID] < ts.ProficiencyRequired {
+			missingByTask[ts.TaskID] = append(missingByTask[ts.TaskID], ts)
+		}
+	}
+
+	var nudges []models.Nudge
+	for _, t := range snap.Tasks {
+		missing := missingByTask[t.ID]
+		if t.Status == models.TaskStatusDone || len(missing) == 0 {
+			continue
+		}
+
+		severity := models.NudgeSeverityMedium
+		if t.Priority == models.TaskPriorityCritical || t.IsCriticalPath {
+			severity = models.NudgeSeverityHigh
+		}
+
+		names := make([]string, 0, len(missing))
+		skillIDs := make([]string, 0, len(missing))
+		for _, ts := range missing {
+			name := ts.Skill.Name
+			if name == "" {
+				name = ts.SkillID.String()
+			}
+			names = append(names, name)
+			skillIDs = append(skillIDs, ts.SkillID.String())
+		}
+
+		nudges = append(nudges, snap.taskNudge(t, models.Nudge{
+			Severity:        severity,
+			Title:           fmt.Sprintf("No one has the skills for %s", t.Title),
+			Description:     fmt.Sprintf("%s requires %s, which no team member has at the required level.", t.Title, strings.Join(names, ", ")),
+			AIExplanation:   "Required skills are unmatched by any team member's proficiency.",
+			SuggestedAction: "Train a team member, hire, or bring in a contractor",
+			ConfidenceScore: 0.8,
+			Metrics: models.JSONB{
+				"missingSkills":   names,
+				"missingSkillIds": skillIDs,
+			},
+		}))
+	}
+	return nudges
+}
+
+// detectUnassigned flags open tasks of medium priority or above with nobody assigned
+func detectUnassigned(snap *NudgeSnapshot) []models.Nudge {
+	var nudges []models.Nudge
+	for _, t := range snap.Tasks {
+		if t.Status == models.TaskStatusDone || t.AssigneeID != nil {
+			continue
+		}
+
+		var severity models.NudgeSeverity
+		switch t.Priority {
+		case models.TaskPriorityCritical:
+			severity = models.NudgeSeverityHigh
+		case models.TaskPriorityHigh:
+			severity = models.NudgeSeverityMedium
+		case models.TaskPriorityMedium:
+			severity = models.NudgeSeverityLow
+		default:
+			continue
+		}
+
+		metrics := models.JSONB{"priority": string(t.Priority)}
+		if t.DueDate != nil {
+			daysUntilDue := int(t.DueDate.Sub(snap.Now).Hours() / 24)
+			metrics["daysUntilDue"] = daysUntilDue
+			if daysUntilDue <= 3 || (t.Priority == models.TaskPriorityCritical && daysUntilDue <= 7) {
+				severity = models.NudgeSeverityHigh
+			}
+		}
+
+		nudges = append(nudges, snap.taskNudge(t, models.Nudge{
+			Severity:        severity,
+			Title:           fmt.Sprintf("%s is unassigned", t.Title),
+			Description:     fmt.Sprintf("%s has %s priority and no assignee.", t.Title, t.Priority),
+			AIExplanation:   "Unassigned work cannot progress and is easy to overlook.",
+			SuggestedAction: "Assign the task to an available team member",
+			ConfidenceScore: 0.95,
+			Metrics:         metrics,
+		}))
+	}
+	return nudges
+}
+
+// detectBlocked flags tasks that are due to be worked on but wait on an unfinished predecessor
+func detectBlocked(snap *NudgeSnapshot) []models.Nudge {
+	var nudges []models.Nudge
+	for _, t := range snap.Tasks {
+		if t.Status == models.TaskStatusDone || !snap.isStartable(t) {
+			continue
+		}
+
+		blockers := snap.openPredecessors(t.ID)
+		if len(blockers) == 0 {
+			continue
+		}
+
+		severity := models.NudgeSeverityMedium
+		if t.IsCriticalPath || t.Status == models.TaskStatusInProgress {
+			severity = models.NudgeSeverityHigh
+		}
+
+		nudges = append(nudges, snap.taskNudge(t, models.Nudge{
+			Severity:        severity,
+			Title:           fmt.Sprintf("%s is blocked", t.Title),
+			Description:     fmt.Sprintf("%s is waiting on %d unfinished %s.", t.Title, len(blockers), pluralize(len(blockers), "task", "tasks")),
+			AIExplanation:   "The task is ready to be worked on but its predecessors are not complete.",
+			SuggestedAction: "Unblock the predecessor tasks or re-plan the dependency",
+			ConfidenceScore: 0.9,
+			Metrics: models.JSONB{
+				"blockingTaskIds": taskIDs(blockers),
+				"blockingCount":   len(blockers),
+			},
+		}))
+	}
+	return nudges
+}
+
+// detectConflict flags people holding several critical or high priority tasks at once
+func detectConflict(snap *NudgeSnapshot) []models.Nudge {
+	byAssignee := make(map[uuid.UUID][]models.Task)
+	var assignees []uuid.UUID
+	for _, t := range snap.Tasks {
+		if t.AssigneeID == nil || t.Status == models.TaskStatusDone {
+			continue
+		}
+		if _, seen := byAssignee[*t.AssigneeID]; !seen {
+			assignees = append(assignees, *t.AssigneeID)
+		}
+		byAssignee[*t.AssigneeID] = append(byAssignee[*t.AssigneeID], t)
+	}
+
+	var nudges []models.Nudge
+	for _, userID := range assignees {
+		var critical, high []models.Task
+		for _, t := range byAssignee[userID] {
+			if t.IsCriticalPath || t.Priority == models.TaskPriorityCritical {
+				critical = append(critical, t)
+			} else if t.Priority == models.TaskPriorityHigh {
+				high = append(high, t)
+			}
+		}
+		if len(critical) < 2 && (len(critical) < 1 || len(high) < 1) {
+			continue
+		}
+
+		conflicting := append(critical, high...)
+		totalHours := 0.0
+		for _, t := range conflicting {
+			totalHours += t.EstimatedHours
+		}
+		if totalHours <= 30 {
+			continue
+		}
+
+		severity := models.NudgeSeverityMedium
+		if len(critical) >= 3 {
+			severity = models.NudgeSeverityHigh
+		}
+
+		id := userID
+		name := snap.userName(userID)
+		nudges = append(nudges, models.Nudge{
+			Severity:        severity,
+			Title:           fmt.Sprintf("%s has competing priorities", name),
+			Description:     fmt.Sprintf("%s holds %d critical or high priority tasks totalling %.0fh.", name, len(conflicting), totalHours),
+			AIExplanation:   "Several urgent tasks assigned to one person will compete for the same time.",
+			SuggestedAction: "Sequence the tasks explicitly or move one to another team member",
+			ConfidenceScore: 0.7,
+			RelatedUserID:   &id,
+			Metrics: models.JSONB{
+				"conflictingTaskIds": taskIDs(conflicting),
+				"criticalTasks":      len(critical),
+				"highPriorityTasks":  len(high),
+				"totalHours":         totalHours,
+				"isCriticalPath":     len(critical) > 0,
+			},
+		})
+	}
+	return nudges
+}
+
+// detectDependencyBlock flags tasks whose predecessors are at risk of finishing late
+func detectDependencyBlock(snap *NudgeSnapshot) []models.Nudge {
+	var nudges []models.Nudge
+	for _, t := range snap.Tasks {
+		// Startable tasks with open predecessors are already reported as blocked
+		if t.Status == models.TaskStatusDone || snap.isStartable(t) {
+			continue
+		}
+
+		var atRisk []models.Task
+		for _, p := range snap.openPredecessors(t.ID) {
+			if p.Status == models.TaskStatusInProgress || p.DueDate == nil {
+				continue
+			}
+			if int(p.DueDate.Sub(snap.Now).Hours()/24) <= 7 {
+				atRisk = append(atRisk, p)
+			}
+		}
+		if len(atRisk) == 0 {
+			continue
+		}
+
+		severity := models.NudgeSeverityMedium
+		if t.IsCriticalPath {
+			severity = models.NudgeSeverityHigh
+		}
+
+		nudges = append(nudges, snap.taskNudge(t, models.Nudge{
+			Severity:        severity,
+			Title:           fmt.Sprintf("%s depends on at-risk work", t.Title),
+			Description:     fmt.Sprintf("%d %s that %s depends on %s due within a week and not yet started.", len(atRisk), pluralize(len(atRisk), "task", "tasks"), t.Title, pluralize(len(atRisk), "is", "are")),
+			AIExplanation:   "Predecessors close to their due date without work in progress are likely to slip.",
+			SuggestedAction: "Start or reassign the predecessor tasks",
+			ConfidenceScore: 0.75,
+			Metrics: models.JSONB{
+				"atRiskTaskIds": taskIDs(atRisk),
+				"atRiskCount":   len(atRisk),
+			},
+		}))
+	}
+	return nudges
+}
+
+// Helper functions
+
+// taskNudge fills in the task and project references shared by task-level nudges
+func (snap *NudgeSnapshot) taskNudge(t models.Task, n models.Nudge) models.Nudge {
+	taskID := t.ID
+	projectID := t.ProjectID
+	n.RelatedTaskID = &taskID
+	n.RelatedProjectID = &projectID
+	if t.AssigneeID != nil {
+		assignee := *t.AssigneeID
+		n.RelatedUserID = &assignee
+	}
+	if n.Metrics == nil {
+		n.Metrics = models.JSONB{}
+	}
+	n.Metrics["isCriticalPath"] = t.IsCriticalPath
+	return n
+}
+
+// isStartable reports whether work on the task is expected to have begun
+func (snap *NudgeSnapshot) isStartable(t models.Task) bool {
+	switch t.Status {
+	case models.TaskStatusReady, models.TaskStatusInProgress, models.TaskStatusReview:
+		return true
+	}
+	return t.StartDate != nil && !t.StartDate.After(snap.Now)
+}
+
+// openPredecessors returns the unfinished tasks the given task depends on
+func (snap *NudgeSnapshot) openPredecessors(taskID uuid.UUID) []models.Task {
+	byID := make(map[uuid.UUID]models.Task, len(snap.Tasks))
+	for _, t := range snap.Tasks {
+		byID[t.ID] = t
+	}
+
+	var open []models.Task
+	for _, d := range snap.Dependencies {
+		if d.TaskID != taskID {
+			continue
+		}
+		if p, ok := byID[d.DependsOnTaskID]; ok && p.Status != models.TaskStatusDone {
+			open = append(open, p)
+		}
+	}
+	return open
+}
+
+// projectCompletion returns the share (0-1) of a project's estimated work that is done
+func (snap *NudgeSnapshot) projectCompletion(p models.Project) float64 {
+	var total, done float64
+	var count, doneCount int
+	for _, t := range snap.Tasks {
+		if t.ProjectID != p.ID {
+			continue
+		}
+		count++
+		total += t.EstimatedHours
+		if t.Status == models.TaskStatusDone {
+			doneCount++
+			done += t.EstimatedHours
+		}
+	}
+
+	switch {
+	case total > 0:
+		return done / total
+	case count > 0:
+		return float64(doneCount) / float64(count)
+	default:
+		return float64(p.Progress) / 100
+	}
+}
+
+func (snap *NudgeSnapshot) userName(id uuid.UUID) string {
+	if u, ok := snap.Users[id]; ok && u.Name != "" {
+		return u.Name
+	}
+	return "A team member"
+}
+
+func taskIDs(tasks []models.Task) []string {
+	ids := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		ids = append(ids, t.ID.String())
+	}
+	return ids
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
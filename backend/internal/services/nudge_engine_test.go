@@ -0,0 +1,174 @@
+package services_test
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+var _ = Describe("Nudge Rule Engine", func() {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	var snap *services.NudgeSnapshot
+	var project models.Project
+
+	newTask := func(title string, status models.TaskStatus, priority models.TaskPriority) models.Task {
+		t := models.Task{ProjectID: project.ID, Title: title, Status: status, Priority: priority}
+		t.ID = uuid.New()
+		return t
+	}
+
+	BeforeEach(func() {
+		project = models.Project{Name: "Apollo", Status: models.ProjectActive}
+		project.ID = uuid.New()
+		snap = &services.NudgeSnapshot{
+			Now:      now,
+			Projects: []models.Project{project},
+			Users:    map[uuid.UUID]models.User{},
+		}
+	})
+
+	evaluate := func(types ...models.NudgeType) []models.Nudge {
+		return services.EvaluateNudgeRules(snap, types)
+	}
+
+	Context("Given a team member above 100% allocation", func() {
+		It("should emit a scored overload nudge", func() {
+			snap.Workloads = []models.WorkloadEntry{
+				{UserID: uuid.New(), AllocationPercentage: 130, AssignedTasks: 6},
+				{UserID: uuid.New(), AllocationPercentage: 100},
+			}
+
+			nudges := evaluate(models.NudgeTypeOverload)
+
+			Expect(nudges).To(HaveLen(1))
+			Expect(nudges[0].Severity).To(Equal(models.NudgeSeverityHigh))
+			Expect(nudges[0].CriticalityScore).To(Equal(100))
+			Expect(nudges[0].ConfidenceScore).To(BeNumerically(">", 0))
+			Expect(nudges[0].ExpiresAt).NotTo(BeNil())
+			Expect(nudges[0].Metrics).To(HaveKeyWithValue("allocationPercentage", 130))
+		})
+	})
+
+	Context("Given a project behind its target end date schedule", func() {
+		It("should emit a delay risk nudge for the project", func() {
+			start := now.Add(-30 * 24 * time.Hour)
+			end := now.Add(10 * 24 * time.Hour)
+			snap.Projects[0].StartDate = &start
+			snap.Projects[0].TargetEndDate = &end
+			done := newTask("Design", models.TaskStatusDone, models.TaskPriorityMedium)
+			done.EstimatedHours = 10
+			open := newTask("Build", models.TaskStatusInProgress, models.TaskPriorityMedium)
+			open.EstimatedHours = 90
+			snap.Tasks = []models.Task{done, open}
+
+			nudges := evaluate(models.NudgeTypeDelayRisk)
+
+			Expect(nudges).To(HaveLen(1))
+			Expect(*nudges[0].RelatedProjectID).To(Equal(project.ID))
+			Expect(nudges[0].Severity).To(Equal(models.NudgeSeverityHigh))
+			Expect(nudges[0].Metrics).To(HaveKey("scheduleVariance"))
+		})
+	})
+
+	Context("Given a task requiring a skill nobody has", func() {
+		It("should emit a skill gap nudge", func() {
+			skillID := uuid.New()
+			task := newTask("ML pipeline", models.TaskStatusReady, models.TaskPriorityHigh)
+			snap.Tasks = []models.Task{task}
+			snap.TaskSkills = []models.TaskSkill{{TaskID: task.ID, SkillID: skillID, ProficiencyRequired: 3, IsRequired: true}}
+			snap.UserSkills = []models.UserSkill{{UserID: uuid.New(), SkillID: skillID, Proficiency: 2}}
+
+			nudges := evaluate(models.NudgeTypeSkillGap)
+
+			Expect(nudges).To(HaveLen(1))
+			Expect(*nudges[0].RelatedTaskID).To(Equal(task.ID))
+		})
+
+		It("should not fire when a member is proficient enough", func() {
+			skillID := uuid.New()
+			task := newTask("ML pipeline", models.TaskStatusReady, models.TaskPriorityHigh)
+			snap.Tasks = []models.Task{task}
+			snap.TaskSkills = []models.TaskSkill{{TaskID: task.ID, SkillID: skillID, ProficiencyRequired: 3, IsRequired: true}}
+			snap.UserSkills = []models.UserSkill{{UserID: uuid.New(), SkillID: skillID, Proficiency: 4}}
+
+			Expect(evaluate(models.NudgeTypeSkillGap)).To(BeEmpty())
+		})
+	})
+
+	Context("Given unassigned tasks", func() {
+		It("should flag medium priority and above but not low priority", func() {
+			critical := newTask("Launch", models.TaskStatusReady, models.TaskPriorityCritical)
+			low := newTask("Polish", models.TaskStatusReady, models.TaskPriorityLow)
+			snap.Tasks = []models.Task{critical, low}
+
+			nudges := evaluate(models.NudgeTypeUnassigned)
+
+			Expect(nudges).To(HaveLen(1))
+			Expect(*nudges[0].RelatedTaskID).To(Equal(critical.ID))
+			Expect(nudges[0].Severity).To(Equal(models.NudgeSeverityHigh))
+		})
+	})
+
+	Context("Given dependencies on unfinished work", func() {
+		var predecessor, started, future models.Task
+
+		BeforeEach(func() {
+			due := now.Add(2 * 24 * time.Hour)
+			predecessor = newTask("API", models.TaskStatusReady, models.TaskPriorityHigh)
+			predecessor.DueDate = &due
+			started = newTask("Client", models.TaskStatusInProgress, models.TaskPriorityHigh)
+			future = newTask("Docs", models.TaskStatusBacklog, models.TaskPriorityMedium)
+			snap.Tasks = []models.Task{predecessor, started, future}
+			snap.Dependencies = []models.TaskDependency{
+				{TaskID: started.ID, DependsOnTaskID: predecessor.ID},
+				{TaskID: future.ID, DependsOnTaskID: predecessor.ID},
+			}
+		})
+
+		It("should flag startable tasks as blocked", func() {
+			nudges := evaluate(models.NudgeTypeBlocked)
+			Expect(nudges).To(HaveLen(1))
+			Expect(*nudges[0].RelatedTaskID).To(Equal(started.ID))
+		})
+
+		It("should flag future tasks whose predecessors are at risk", func() {
+			nudges := evaluate(models.NudgeTypeDependencyBlock)
+			Expect(nudges).To(HaveLen(1))
+			Expect(*nudges[0].RelatedTaskID).To(Equal(future.ID))
+		})
+	})
+
+	Context("Given a person with competing critical work", func() {
+		It("should emit a conflict nudge", func() {
+			userID := uuid.New()
+			a := newTask("Migration", models.TaskStatusInProgress, models.TaskPriorityCritical)
+			b := newTask("Audit", models.TaskStatusReady, models.TaskPriorityHigh)
+			a.AssigneeID, b.AssigneeID = &userID, &userID
+			a.EstimatedHours, b.EstimatedHours = 24, 16
+			snap.Tasks = []models.Task{a, b}
+
+			nudges := evaluate(models.NudgeTypeConflict)
+
+			Expect(nudges).To(HaveLen(1))
+			Expect(*nudges[0].RelatedUserID).To(Equal(userID))
+		})
+	})
+
+	Context("Given a type filter", func() {
+		It("should reject unknown types", func() {
+			_, err := services.ParseNudgeTypes([]string{"overload", "bogus"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should select every type when none are given", func() {
+			types, err := services.ParseNudgeTypes(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(types).To(HaveLen(7))
+		})
+	})
+})
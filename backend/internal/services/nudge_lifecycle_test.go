@@ -0,0 +1,116 @@
+package services_test
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+var _ = Describe("Nudge Lifecycle", func() {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	everywhere := func(models.Nudge) bool { return true }
+
+	newNudge := func(nudgeType models.NudgeType, userID uuid.UUID, severity models.NudgeSeverity) models.Nudge {
+		n := models.Nudge{Type: nudgeType, Severity: severity, Status: models.NudgeStatusUnread, RelatedUserID: &userID}
+		n.Fingerprint = services.NudgeFingerprint(&n)
+		return n
+	}
+
+	stored := func(n models.Nudge, createdAt time.Time) models.Nudge {
+		n.ID = uuid.New()
+		n.CreatedAt = createdAt
+		return n
+	}
+
+	Context("Given the same condition detected twice", func() {
+		It("should produce the same fingerprint", func() {
+			userID := uuid.New()
+			a := newNudge(models.NudgeTypeOverload, userID, models.NudgeSeverityHigh)
+			b := newNudge(models.NudgeTypeOverload, userID, models.NudgeSeverityMedium)
+			b.Title = "different text"
+
+			Expect(services.NudgeFingerprint(&b)).To(Equal(a.Fingerprint))
+		})
+
+		It("should distinguish types and related entities", func() {
+			userID := uuid.New()
+			a := newNudge(models.NudgeTypeOverload, userID, models.NudgeSeverityHigh)
+			b := newNudge(models.NudgeTypeConflict, userID, models.NudgeSeverityHigh)
+			c := newNudge(models.NudgeTypeOverload, uuid.New(), models.NudgeSeverityHigh)
+
+			Expect(a.Fingerprint).NotTo(Equal(b.Fingerprint))
+			Expect(a.Fingerprint).NotTo(Equal(c.Fingerprint))
+		})
+	})
+
+	Context("Given an open nudge that is detected again", func() {
+		It("should refresh it instead of creating a duplicate", func() {
+			userID := uuid.New()
+			existing := stored(newNudge(models.NudgeTypeOverload, userID, models.NudgeSeverityMedium), now.Add(-48*time.Hour))
+			existing.Status = models.NudgeStatusRead
+			detected := newNudge(models.NudgeTypeOverload, userID, models.NudgeSeverityHigh)
+			detected.Title = "Now at 140%"
+
+			plan := services.ReconcileNudges([]models.Nudge{existing}, []models.Nudge{detected}, everywhere, now)
+
+			Expect(plan.Create).To(BeEmpty())
+			Expect(plan.Resolve).To(BeEmpty())
+			Expect(plan.Refresh).To(HaveLen(1))
+			Expect(plan.Refresh[0].ID).To(Equal(existing.ID))
+			Expect(plan.Refresh[0].Title).To(Equal("Now at 140%"))
+			Expect(*plan.Refresh[0].LastDetectedAt).To(Equal(now))
+			// Severity rose, so the read nudge is surfaced again
+			Expect(plan.Refresh[0].Status).To(Equal(models.NudgeStatusUnread))
+		})
+
+		It("should create nudges for new conditions", func() {
+			detected := newNudge(models.NudgeTypeOverload, uuid.New(), models.NudgeSeverityHigh)
+
+			plan := services.ReconcileNudges(nil, []models.Nudge{detected}, everywhere, now)
+
+			Expect(plan.Create).To(HaveLen(1))
+			Expect(plan.Create[0].LastDetectedAt).NotTo(BeNil())
+		})
+	})
+
+	Context("Given an open nudge whose condition cleared", func() {
+		It("should resolve it when it was in scope", func() {
+			existing := stored(newNudge(models.NudgeTypeOverload, uuid.New(), models.NudgeSeverityHigh), now.Add(-time.Hour))
+
+			plan := services.ReconcileNudges([]models.Nudge{existing}, nil, everywhere, now)
+
+			Expect(plan.Resolve).To(HaveLen(1))
+			Expect(plan.Resolve[0].ID).To(Equal(existing.ID))
+		})
+
+		It("should leave it open when it was outside the evaluated scope", func() {
+			existing := stored(newNudge(models.NudgeTypeOverload, uuid.New(), models.NudgeSeverityHigh), now.Add(-time.Hour))
+
+			plan := services.ReconcileNudges([]models.Nudge{existing}, nil, func(models.Nudge) bool { return false }, now)
+
+			Expect(plan.Resolve).To(BeEmpty())
+		})
+	})
+
+	Context("Given duplicate open nudges from before fingerprinting", func() {
+		It("should keep the newest and supersede the rest", func() {
+			userID := uuid.New()
+			older := stored(newNudge(models.NudgeTypeOverload, userID, models.NudgeSeverityHigh), now.Add(-72*time.Hour))
+			newer := stored(newNudge(models.NudgeTypeOverload, userID, models.NudgeSeverityHigh), now.Add(-24*time.Hour))
+			older.Fingerprint, newer.Fingerprint = "", ""
+			detected := newNudge(models.NudgeTypeOverload, userID, models.NudgeSeverityHigh)
+
+			plan := services.ReconcileNudges([]models.Nudge{older, newer}, []models.Nudge{detected}, everywhere, now)
+
+			Expect(plan.Refresh).To(HaveLen(1))
+			Expect(plan.Refresh[0].ID).To(Equal(newer.ID))
+			Expect(plan.Superseded).To(HaveLen(1))
+			Expect(plan.Superseded[0].ID).To(Equal(older.ID))
+		})
+	})
+})
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+// NudgeMaintenanceConfig controls the periodic nudge lifecycle sweep
+type NudgeMaintenanceConfig struct {
+	// EscalationDelay is how long a high-severity nudge may stay unread before it is escalated
+	EscalationDelay time.Duration
+	// Retention is how long closed nudges are kept before they are deleted
+	Retention time.Duration
+}
+
+// DefaultNudgeMaintenanceConfig returns the default sweep settings
+func DefaultNudgeMaintenanceConfig() NudgeMaintenanceConfig {
+	return NudgeMaintenanceConfig{
+		EscalationDelay: 24 * time.Hour,
+		Retention:       90 * 24 * time.Hour,
+	}
+}
+
+// NudgeMaintenanceResult counts what one sweep changed
+type NudgeMaintenanceResult struct {
+	Woken     int64
+	Escalated int64
+	Expired   int64
+	Deleted   int64
+}
+
+// RunNudgeMaintenance wakes nudges whose snooze has ended, escalates unread high-severity
+// nudges to the organization's admins and project managers, expires nudges past their
+// expiry and deletes closed nudges older than the retention period
+func RunNudgeMaintenance(ctx context.Context, repos *repositories.Provider, notifier NotificationDispatcher, cfg NudgeMaintenanceConfig, now time.Time) (NudgeMaintenanceResult, error) {
+	var result NudgeMaintenanceResult
+	var err error
+
+	if result.Woken, err = repos.GetNudge().WakeSnoozedNudges(ctx, now); err != nil {
+		return result, fmt.Errorf("failed to wake snoozed nudges: %w", err)
+	}
+
+	if result.Escalated, err = escalateUnreadNudges(ctx, repos, notifier, cfg.EscalationDelay, now); err != nil {
+		return result, fmt.Errorf("failed to escalate nudges: %w", err)
+	}
+
+	if result.Expired, err = repos.GetNudge().ExpireOldNudges(ctx); err != nil {
+		return result, fmt.Errorf("failed to expire nudges: %w", err)
+	}
+
+	if result.Deleted, err = repos.GetNudge().DeleteOldNudges(ctx, cfg.Retention); err != nil {
+		return result, fmt.Errorf("failed to delete old nudges: %w", err)
+	}
+
+	return result, nil
+}
+
+// escalateUnreadNudges escalates high-severity nudges left unread longer than delay,
+// recording a system escalate action on each
+func escalateUnreadNudges(ctx context.Context, repos *repositories.Provider, notifier NotificationDispatcher, delay time.Duration, now time.Time) (int64, error) {
+	candidates, err := repos.GetNudge().ListEscalationCandidates(ctx, models.NudgeSeverityHigh, now.Add(-delay))
+	if err != nil {
+		return 0, err
+	}
+
+	var escalated, skipped int64
+	for i := range candidates {
+		act := &NudgeActionContext{
+			Nudge:  &candidates[i],
+			OrgID:  candidates[i].OrganizationID,
+			Params: map[string]interface{}{"reason": fmt.Sprintf("unread for more than %s", delay)},
+			Now:    now,
+		}
+		result, err := applyNudgeAction(ctx, repos, "escalate", escalateNudge, act, nil)
+		if err != nil {
+			if errors.Is(err, ErrInvalidNudgeAction) {
+				skipped++
+				continue
+			}
+			return escalated, err
+		}
+		escalated++
+		notifyEscalation(ctx, notifier, &candidates[i], result.EscalatedTo)
+	}
+	if skipped > 0 {
+		log.Printf("[NudgeMaintenance] Skipped escalating %d nudges in organizations without admins or PMs", skipped)
+	}
+	return escalated, nil
+}
+
+// notifyEscalation tells the escalation recipients about a nudge; failures are only logged
+func notifyEscalation(ctx context.Context, notifier NotificationDispatcher, nudge *models.Nudge, userIDs []string) {
+	if notifier == nil {
+		return
+	}
+	recipients := make([]uuid.UUID, 0, len(userIDs))
+	for _, id := range userIDs {
+		if parsed, err := uuid.Parse(id); err == nil {
+			recipients = append(recipients, parsed)
+		}
+	}
+	if _, err := notifier.NotifyNudge(ctx, nudge, recipients); err != nil {
+		log.Printf("[NudgeMaintenance] Error notifying escalation of nudge %s: %v", nudge.ID, err)
+	}
+}
@@ -0,0 +1,101 @@
+package services_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+var _ = Describe("Nudge Maintenance", func() {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	cfg := services.DefaultNudgeMaintenanceConfig()
+
+	var (
+		ctx    context.Context
+		nudges *memNudgeRepo
+		users  *memUserRepo
+		repos  *repositories.Provider
+		orgID  uuid.UUID
+	)
+
+	newNudge := func(severity models.NudgeSeverity, status models.NudgeStatus, age time.Duration) *models.Nudge {
+		n := &models.Nudge{OrganizationID: orgID, Type: models.NudgeTypeOverload, Severity: severity, Status: status}
+		n.ID = uuid.New()
+		n.CreatedAt = now.Add(-age)
+		nudges.nudges[n.ID] = n
+		return n
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		orgID = uuid.New()
+		admin := models.User{Name: "Alex"}
+		admin.ID = uuid.New()
+		nudges = &memNudgeRepo{nudges: map[uuid.UUID]*models.Nudge{}}
+		users = &memUserRepo{byRole: map[models.UserRole][]models.User{models.RoleAdmin: {admin}}}
+		repos = &repositories.Provider{Nudge: nudges, User: users}
+	})
+
+	Context("Given an unread high-severity nudge past the escalation delay", func() {
+		It("should escalate it with a system action", func() {
+			stale := newNudge(models.NudgeSeverityHigh, models.NudgeStatusUnread, 30*time.Hour)
+			fresh := newNudge(models.NudgeSeverityHigh, models.NudgeStatusUnread, time.Hour)
+			medium := newNudge(models.NudgeSeverityMedium, models.NudgeStatusUnread, 30*time.Hour)
+
+			result, err := services.RunNudgeMaintenance(ctx, repos, nil, cfg, now)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Escalated).To(Equal(int64(1)))
+			Expect(nudges.nudges[stale.ID].EscalatedAt).NotTo(BeNil())
+			Expect(nudges.nudges[fresh.ID].EscalatedAt).To(BeNil())
+			Expect(nudges.nudges[medium.ID].EscalatedAt).To(BeNil())
+
+			Expect(nudges.actions).To(HaveLen(1))
+			Expect(nudges.actions[0].ActionType).To(Equal("escalate"))
+			Expect(nudges.actions[0].UserID).To(BeNil())
+		})
+
+		It("should not escalate the same nudge twice", func() {
+			newNudge(models.NudgeSeverityHigh, models.NudgeStatusUnread, 30*time.Hour)
+
+			_, err := services.RunNudgeMaintenance(ctx, repos, nil, cfg, now)
+			Expect(err).NotTo(HaveOccurred())
+			result, err := services.RunNudgeMaintenance(ctx, repos, nil, cfg, now)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Escalated).To(BeZero())
+			Expect(nudges.actions).To(HaveLen(1))
+		})
+
+		It("should skip organizations with nobody to escalate to", func() {
+			users.byRole = nil
+			newNudge(models.NudgeSeverityHigh, models.NudgeStatusUnread, 30*time.Hour)
+
+			result, err := services.RunNudgeMaintenance(ctx, repos, nil, cfg, now)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Escalated).To(BeZero())
+		})
+	})
+
+	Context("Given a snoozed nudge whose snooze has ended", func() {
+		It("should reappear as unread", func() {
+			n := newNudge(models.NudgeSeverityLow, models.NudgeStatusSnoozed, 48*time.Hour)
+			until := now.Add(-time.Minute)
+			n.SnoozedUntil = &until
+
+			result, err := services.RunNudgeMaintenance(ctx, repos, nil, cfg, now)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Woken).To(Equal(int64(1)))
+			Expect(nudges.nudges[n.ID].Status).To(Equal(models.NudgeStatusUnread))
+		})
+	})
+})
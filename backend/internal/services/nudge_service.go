@@ -24,7 +24,7 @@ type NudgeService interface {
 	UpdateNudgeStatus(ctx context.Context, nudgeID string, status string, orgID string) (*dto.NudgeResponse, error)
 
 	// GenerateNudges triggers manual nudge generation
-	GenerateNudges(ctx context.Context, req dto.GenerateNudgesRequest, orgID string) (string, error)
+	GenerateNudges(ctx context.Context, req dto.GenerateNudgesRequest, orgID string) (*dto.GenerateNudgesResponse, error)
 
 	// GetNudgeStats returns nudge statistics
 	GetNudgeStats(ctx context.Context, period string, orgID string) (*dto.NudgeStatsResponse, error)
@@ -90,6 +90,7 @@ func (s *DummyNudgeService) ListNudges(ctx context.Context, params dto.NudgeList
 
 // GetNudge returns a dummy nudge
 func (s *DummyNudgeService) GetNudge(ctx context.Context, nudgeID string, orgID string) (*dto.NudgeDetailResponse, error) {
+	dummyUserID := uuid.New()
 	return &dto.NudgeDetailResponse{
 		NudgeResponse: dto.NudgeResponse{
 			ID:              nudgeID,
@@ -112,7 +113,7 @@ func (s *DummyNudgeService) GetNudge(ctx context.Context, nudgeID string, orgID
 			{
 				Action:    "created",
 				Timestamp: time.Now().UTC().Add(-24 * time.Hour),
-				UserID:    uuid.New(),
+				UserID:    &dummyUserID,
 			},
 		},
 	}, nil
@@ -132,6 +133,10 @@ func (s *DummyNudgeService) TakeNudgeAction(ctx context.Context, nudgeID string,
 			FromUserID:     "user-emma",
 			ToUserID:       "user-rachel",
 			TaskID:         "task-web-3",
+			Summary:        "Reassigned \"Marketing Website consultation\"",
+			Changes: []dto.NudgeChange{
+				{Entity: "task", EntityID: "task-web-3", Field: "assigneeId", From: "user-emma", To: "user-rachel"},
+			},
 		},
 		NudgeStatus:    status,
 		FollowUpNudges: []string{},
@@ -150,8 +155,19 @@ func (s *DummyNudgeService) UpdateNudgeStatus(ctx context.Context, nudgeID strin
 }
 
 // GenerateNudges triggers dummy nudge generation
-func (s *DummyNudgeService) GenerateNudges(ctx context.Context, req dto.GenerateNudgesRequest, orgID string) (string, error) {
-	return "job_nudge_gen_abc123", nil
+func (s *DummyNudgeService) GenerateNudges(ctx context.Context, req dto.GenerateNudgesRequest, orgID string) (*dto.GenerateNudgesResponse, error) {
+	if req.Async {
+		return &dto.GenerateNudgesResponse{
+			JobID:    "job_nudge_gen_abc123",
+			Status:   "queued",
+			NudgeIDs: []string{},
+		}, nil
+	}
+	return &dto.GenerateNudgesResponse{
+		Status:    "succeeded",
+		Generated: 2,
+		NudgeIDs:  []string{"nudge-1", "nudge-2"},
+	}, nil
 }
 
 // GetNudgeStats returns dummy stats
@@ -161,6 +177,7 @@ func (s *DummyNudgeService) GetNudgeStats(ctx context.Context, period string, or
 		Generated:       45,
 		Acted:           23,
 		Dismissed:       12,
+		AutoResolved:    4,
 		Expired:         5,
 		ActionRate:      0.51,
 		AvgTimeToAction: "4.2h",
@@ -22,6 +22,15 @@ type PriorityService interface {
 
 	// GetTaskRank returns just the rank for a task
 	GetTaskRank(ctx context.Context, taskID string, orgID string) (int, error)
+
+	// GetPriorityConfig returns the organization's priority scoring configuration
+	GetPriorityConfig(ctx context.Context, orgID string) (*dto.PriorityConfigResponse, error)
+
+	// UpdatePriorityConfig validates and saves the organization's priority scoring configuration
+	UpdatePriorityConfig(ctx context.Context, cfg dto.PriorityConfig, orgID string) (*dto.PriorityConfigResponse, error)
+
+	// PreviewPriorityConfig shows how a project's ranking would change under a candidate configuration
+	PreviewPriorityConfig(ctx context.Context, req dto.PriorityConfigPreviewRequest, orgID string) (*dto.PriorityConfigPreviewResponse, error)
 }
 
 // DummyPriorityService is a placeholder implementation of PriorityService
@@ -118,6 +127,43 @@ func (s *DummyPriorityService) GetTaskRank(ctx context.Context, taskID string, o
 	return 1, nil
 }
 
+// GetPriorityConfig returns the default configuration
+func (s *DummyPriorityService) GetPriorityConfig(ctx context.Context, orgID string) (*dto.PriorityConfigResponse, error) {
+	return &dto.PriorityConfigResponse{
+		OrganizationID: orgID,
+		Config:         DefaultPriorityConfig(),
+		IsDefault:      true,
+	}, nil
+}
+
+// UpdatePriorityConfig validates and echoes the configuration
+func (s *DummyPriorityService) UpdatePriorityConfig(ctx context.Context, cfg dto.PriorityConfig, orgID string) (*dto.PriorityConfigResponse, error) {
+	if err := ValidatePriorityConfig(cfg); err != nil {
+		return nil, err
+	}
+	return &dto.PriorityConfigResponse{
+		OrganizationID: orgID,
+		Config:         cfg,
+		IsDefault:      false,
+	}, nil
+}
+
+// PreviewPriorityConfig returns a dummy ranking preview
+func (s *DummyPriorityService) PreviewPriorityConfig(ctx context.Context, req dto.PriorityConfigPreviewRequest, orgID string) (*dto.PriorityConfigPreviewResponse, error) {
+	if err := ValidatePriorityConfig(req.Config); err != nil {
+		return nil, err
+	}
+	return &dto.PriorityConfigPreviewResponse{
+		ProjectID: req.ProjectID,
+		Changes: []dto.RankingChange{
+			{TaskID: "task-1", Title: "Payment Integration", CurrentRank: 2, PreviewRank: 1, CurrentScore: 84, PreviewScore: 91, RankDelta: 1},
+			{TaskID: "task-2", Title: "API Documentation", CurrentRank: 1, PreviewRank: 2, CurrentScore: 87, PreviewScore: 79, RankDelta: -1},
+		},
+		MovedTasks: 2,
+		Total:      2,
+	}, nil
+}
+
 func strPtr(s string) *string {
 	return &s
 }
@@ -2,15 +2,47 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/SimpleAjax/Xephyr/internal/dto"
 )
 
+// ErrInvalidProgressMethod is returned for an unknown progress calculation method
+var ErrInvalidProgressMethod = errors.New("invalid progress calculation method")
+
+// ProgressMethod is how tasks are weighted when their progress is rolled up
+type ProgressMethod string
+
+const (
+	// ProgressByTaskCount weighs every task equally
+	ProgressByTaskCount ProgressMethod = "task_count"
+	// ProgressByHours weighs tasks by their estimated hours
+	ProgressByHours ProgressMethod = "weighted_hours"
+	// ProgressByBusinessValue weighs tasks by their business value
+	ProgressByBusinessValue ProgressMethod = "business_value"
+)
+
+// DefaultProgressMethod is used when no method is requested and for the progress
+// stored on projects
+const DefaultProgressMethod = ProgressByHours
+
+// ParseProgressMethod validates a requested method; an empty string is the default
+func ParseProgressMethod(method string) (ProgressMethod, error) {
+	switch m := ProgressMethod(method); m {
+	case "":
+		return DefaultProgressMethod, nil
+	case ProgressByTaskCount, ProgressByHours, ProgressByBusinessValue:
+		return m, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidProgressMethod, method)
+}
+
 // ProgressService defines the interface for progress-related operations
 type ProgressService interface {
 	// GetProjectProgress returns progress for a project
-	GetProjectProgress(ctx context.Context, projectID string, orgID string) (*dto.ProjectProgressResponse, error)
+	GetProjectProgress(ctx context.Context, projectID string, method ProgressMethod, orgID string) (*dto.ProjectProgressResponse, error)
 
 	// GetTaskProgress returns detailed progress for a task
 	GetTaskProgress(ctx context.Context, taskID string, orgID string) (*dto.TaskProgressResponse, error)
@@ -19,7 +51,7 @@ type ProgressService interface {
 	UpdateTaskProgress(ctx context.Context, taskID string, req dto.UpdateTaskProgressRequest, orgID string, userID string) (*dto.TaskProgressUpdateResponse, error)
 
 	// GetProjectRollup returns hierarchical progress rollup
-	GetProjectRollup(ctx context.Context, projectID string, orgID string) (*dto.ProjectRollupResponse, error)
+	GetProjectRollup(ctx context.Context, projectID string, method ProgressMethod, orgID string) (*dto.ProjectRollupResponse, error)
 
 	// RecalculateProjectProgress recalculates project progress
 	RecalculateProjectProgress(ctx context.Context, projectID string, orgID string) error
@@ -34,7 +66,7 @@ func NewDummyProgressService() ProgressService {
 }
 
 // GetProjectProgress returns dummy project progress
-func (s *DummyProgressService) GetProjectProgress(ctx context.Context, projectID string, orgID string) (*dto.ProjectProgressResponse, error) {
+func (s *DummyProgressService) GetProjectProgress(ctx context.Context, projectID string, method ProgressMethod, orgID string) (*dto.ProjectProgressResponse, error) {
 	return &dto.ProjectProgressResponse{
 		ProjectID:          projectID,
 		ProgressPercentage: 45,
@@ -106,7 +138,7 @@ func (s *DummyProgressService) UpdateTaskProgress(ctx context.Context, taskID st
 }
 
 // GetProjectRollup returns dummy rollup
-func (s *DummyProgressService) GetProjectRollup(ctx context.Context, projectID string, orgID string) (*dto.ProjectRollupResponse, error) {
+func (s *DummyProgressService) GetProjectRollup(ctx context.Context, projectID string, method ProgressMethod, orgID string) (*dto.ProjectRollupResponse, error) {
 	return &dto.ProjectRollupResponse{
 		ProjectID:          projectID,
 		ProgressPercentage: 45,
@@ -0,0 +1,360 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+// ErrInvalidProjectTransition is returned when a project cannot move to the requested status
+var ErrInvalidProjectTransition = errors.New("invalid project status transition")
+
+// projectTransitions lists the statuses each project status may move to. Completed
+// projects can be reopened and archived projects unarchived.
+var projectTransitions = map[models.ProjectStatus][]models.ProjectStatus{
+	models.ProjectActive:    {models.ProjectPaused, models.ProjectCompleted, models.ProjectArchived},
+	models.ProjectPaused:    {models.ProjectActive, models.ProjectCompleted, models.ProjectArchived},
+	models.ProjectCompleted: {models.ProjectActive, models.ProjectArchived},
+	models.ProjectArchived:  {models.ProjectActive, models.ProjectCompleted},
+}
+
+// CanTransitionProject reports whether a project may move from one status to another
+func CanTransitionProject(from, to models.ProjectStatus) bool {
+	for _, allowed := range projectTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectCloneResult is a project created from another project or a template, with
+// counts of what was copied into it
+type ProjectCloneResult struct {
+	Project      *models.Project
+	Tasks        int
+	Skills       int
+	Dependencies int
+}
+
+// ProjectLifecycleService defines the interface for project status changes, deletion and cloning
+type ProjectLifecycleService interface {
+	// Transition moves a project to a new status
+	Transition(ctx context.Context, orgID, projectID uuid.UUID, status models.ProjectStatus) (*models.Project, error)
+
+	// Delete soft-deletes a project with everything in it
+	Delete(ctx context.Context, orgID, projectID uuid.UUID) error
+
+	// Restore undeletes a project with everything deleted along with it
+	Restore(ctx context.Context, orgID, projectID uuid.UUID) (*models.Project, error)
+
+	// Clone copies a project's task hierarchy, skill requirements and internal
+	// dependencies into a new project, shifting dates to start on startDate
+	Clone(ctx context.Context, orgID, projectID uuid.UUID, name string, startDate time.Time) (*ProjectCloneResult, error)
+}
+
+// RealProjectLifecycleService implements ProjectLifecycleService
+type RealProjectLifecycleService struct {
+	repos *repositories.Provider
+	now   func() time.Time
+}
+
+// NewProjectLifecycleService creates a new project lifecycle service
+func NewProjectLifecycleService(repos *repositories.Provider) *RealProjectLifecycleService {
+	return &RealProjectLifecycleService{repos: repos, now: time.Now}
+}
+
+// Transition moves a project to a new status. Moving to the current status is a no-op.
+func (s *RealProjectLifecycleService) Transition(ctx context.Context, orgID, projectID uuid.UUID, status models.ProjectStatus) (*models.Project, error) {
+	var project *models.Project
+	err := s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		var err error
+		project, err = tx.GetProject().GetByID(ctx, orgID, projectID)
+		if err != nil {
+			return err
+		}
+		if project.Status == status {
+			return nil
+		}
+		if !CanTransitionProject(project.Status, status) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidProjectTransition, project.Status, status)
+		}
+		if err := tx.GetProject().UpdateStatus(ctx, orgID, projectID, status); err != nil {
+			return err
+		}
+		project.Status = status
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// Delete soft-deletes a project with everything in it and takes its open tasks out of
+// this week's workload
+func (s *RealProjectLifecycleService) Delete(ctx context.Context, orgID, projectID uuid.UUID) error {
+	return s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		tasks, err := listProjectTasks(ctx, tx, orgID, projectID)
+		if err != nil {
+			return err
+		}
+		if err := tx.GetProject().Delete(ctx, orgID, projectID); err != nil {
+			return err
+		}
+		return adjustWorkload(ctx, tx, orgID, startOfWeek(s.now()), tasks, -1)
+	})
+}
+
+// Restore undeletes a project with everything deleted along with it and puts its open
+// tasks back into this week's workload
+func (s *RealProjectLifecycleService) Restore(ctx context.Context, orgID, projectID uuid.UUID) (*models.Project, error) {
+	var project *models.Project
+	err := s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		if err := tx.GetProject().Restore(ctx, orgID, projectID); err != nil {
+			return err
+		}
+		var err error
+		if project, err = tx.GetProject().GetByID(ctx, orgID, projectID); err != nil {
+			return err
+		}
+		tasks, err := listProjectTasks(ctx, tx, orgID, projectID)
+		if err != nil {
+			return err
+		}
+		return adjustWorkload(ctx, tx, orgID, startOfWeek(s.now()), tasks, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// adjustWorkload adds (sign 1) or removes (sign -1) the remaining hours of open
+// assigned tasks from their assignees' workload entry for the given week. Earlier
+// weeks are history and are left as recorded; weeks without an entry are skipped.
+func adjustWorkload(ctx context.Context, tx *repositories.Provider, orgID uuid.UUID, week time.Time, tasks []models.Task, sign int) error {
+	type load struct {
+		tasks int
+		hours float64
+	}
+	loads := map[uuid.UUID]*load{}
+	for _, t := range tasks {
+		if t.AssigneeID == nil || t.Status == models.TaskStatusDone {
+			continue
+		}
+		l, ok := loads[*t.AssigneeID]
+		if !ok {
+			l = &load{}
+			loads[*t.AssigneeID] = l
+		}
+		l.tasks++
+		l.hours += math.Max(t.EstimatedHours-t.ActualHours, 0)
+	}
+
+	for userID, l := range loads {
+		entry, err := tx.GetWorkload().GetByUserAndWeek(ctx, orgID, userID, week)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		entry.AssignedTasks = max(entry.AssignedTasks+sign*l.tasks, 0)
+		entry.TotalEstimatedHours = math.Max(entry.TotalEstimatedHours+float64(sign)*l.hours, 0)
+		if entry.AvailableHours > 0 {
+			entry.AllocationPercentage = int(math.Round(entry.TotalEstimatedHours / entry.AvailableHours * 100))
+		}
+		if err := tx.GetWorkload().CreateOrUpdate(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clone copies a project's task hierarchy, skill requirements and the dependencies
+// between its own tasks into a new active project. Dates move by the distance between
+// the source's start and startDate. Tasks start over in the backlog, unassigned and
+// with no recorded effort.
+func (s *RealProjectLifecycleService) Clone(ctx context.Context, orgID, projectID uuid.UUID, name string, startDate time.Time) (*ProjectCloneResult, error) {
+	source, err := s.repos.GetProject().GetByID(ctx, orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := listProjectTasks(ctx, s.repos, orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	skills, err := s.repos.GetTask().ListSkillsByProject(ctx, orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := s.repos.GetDependency().ListByProject(ctx, orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if name = strings.TrimSpace(name); name == "" {
+		name = "Copy of " + source.Name
+	}
+	shift := time.Duration(0)
+	if origin := projectOrigin(source, tasks); origin != nil {
+		shift = startDate.Sub(*origin)
+	}
+
+	clone := &models.Project{
+		Name:          name,
+		Description:   source.Description,
+		Status:        models.ProjectActive,
+		Priority:      source.Priority,
+		HealthScore:   100,
+		StartDate:     &startDate,
+		TargetEndDate: shiftTime(source.TargetEndDate, shift),
+		Budget:        source.Budget,
+	}
+	result := &ProjectCloneResult{Project: clone}
+
+	err = s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		if err := tx.GetProject().Create(ctx, orgID, clone); err != nil {
+			return err
+		}
+
+		// Parents are created before their subtasks so parent links resolve
+		ids := make(map[uuid.UUID]uuid.UUID, len(tasks))
+		for _, t := range hierarchyOrder(tasks) {
+			copied := &models.Task{
+				ProjectID:      clone.ID,
+				HierarchyLevel: t.HierarchyLevel,
+				Title:          t.Title,
+				Description:    t.Description,
+				Status:         models.TaskStatusBacklog,
+				Priority:       t.Priority,
+				BusinessValue:  t.BusinessValue,
+				EstimatedHours: t.EstimatedHours,
+				StartDate:      shiftTime(t.StartDate, shift),
+				DueDate:        shiftTime(t.DueDate, shift),
+				IsMilestone:    t.IsMilestone,
+			}
+			if t.ParentTaskID != nil {
+				if parent, ok := ids[*t.ParentTaskID]; ok {
+					copied.ParentTaskID = &parent
+				}
+			}
+			if err := tx.GetTask().Create(ctx, orgID, copied); err != nil {
+				return err
+			}
+			ids[t.ID] = copied.ID
+			result.Tasks++
+		}
+
+		for _, sk := range skills {
+			taskID, ok := ids[sk.TaskID]
+			if !ok {
+				continue
+			}
+			if err := tx.GetTask().AddSkill(ctx, orgID, &models.TaskSkill{
+				TaskID:              taskID,
+				SkillID:             sk.SkillID,
+				ProficiencyRequired: sk.ProficiencyRequired,
+				IsRequired:          sk.IsRequired,
+			}); err != nil {
+				return err
+			}
+			result.Skills++
+		}
+
+		// Dependencies on tasks in other projects are not copied
+		for _, d := range deps {
+			taskID, ok := ids[d.TaskID]
+			dependsOnID, ok2 := ids[d.DependsOnTaskID]
+			if !ok || !ok2 {
+				continue
+			}
+			if err := tx.GetDependency().Create(ctx, orgID, &models.TaskDependency{
+				TaskID:          taskID,
+				DependsOnTaskID: dependsOnID,
+				DependencyType:  d.DependencyType,
+				LagHours:        d.LagHours,
+			}); err != nil {
+				return err
+			}
+			result.Dependencies++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// projectOrigin is the date a project's schedule is measured from: its start date, or
+// else the earliest task start or due date
+func projectOrigin(project *models.Project, tasks []models.Task) *time.Time {
+	if project.StartDate != nil {
+		return project.StartDate
+	}
+	var origin *time.Time
+	for _, t := range tasks {
+		for _, d := range []*time.Time{t.StartDate, t.DueDate} {
+			if d != nil && (origin == nil || d.Before(*origin)) {
+				origin = d
+			}
+		}
+	}
+	return origin
+}
+
+func shiftTime(t *time.Time, shift time.Duration) *time.Time {
+	if t == nil {
+		return nil
+	}
+	shifted := t.Add(shift)
+	return &shifted
+}
+
+// hierarchyOrder orders tasks so every parent precedes its subtasks. Tasks whose parent
+// is outside the list are treated as roots.
+func hierarchyOrder(tasks []models.Task) []models.Task {
+	present := make(map[uuid.UUID]bool, len(tasks))
+	for _, t := range tasks {
+		present[t.ID] = true
+	}
+	children := map[uuid.UUID][]models.Task{}
+	var queue []models.Task
+	for _, t := range tasks {
+		if t.ParentTaskID != nil && present[*t.ParentTaskID] && *t.ParentTaskID != t.ID {
+			children[*t.ParentTaskID] = append(children[*t.ParentTaskID], t)
+		} else {
+			queue = append(queue, t)
+		}
+	}
+
+	ordered := make([]models.Task, 0, len(tasks))
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		ordered = append(ordered, t)
+		queue = append(queue, children[t.ID]...)
+	}
+	return ordered
+}
+
+// startOfWeek returns the Monday of t's week, truncated to the day, which is how
+// workload entries are keyed
+func startOfWeek(t time.Time) time.Time {
+	weekday := int(t.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	return t.AddDate(0, 0, -weekday+1).Truncate(24 * time.Hour)
+}
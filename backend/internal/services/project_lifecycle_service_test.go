@@ -0,0 +1,330 @@
+package services_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// In-memory repositories implementing only what the lifecycle service uses
+
+type lifecycleProjectRepo struct {
+	repositories.ProjectRepository
+	projects map[uuid.UUID]*models.Project
+	deleted  map[uuid.UUID]bool
+}
+
+func (r *lifecycleProjectRepo) Create(ctx context.Context, orgID uuid.UUID, p *models.Project) error {
+	p.ID, p.OrganizationID = uuid.New(), orgID
+	clone := *p
+	r.projects[p.ID] = &clone
+	return nil
+}
+
+func (r *lifecycleProjectRepo) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.Project, error) {
+	p, ok := r.projects[id]
+	if !ok || r.deleted[id] {
+		return nil, gorm.ErrRecordNotFound
+	}
+	clone := *p
+	return &clone, nil
+}
+
+func (r *lifecycleProjectRepo) ListByOrganization(ctx context.Context, orgID uuid.UUID, params repositories.ListParams) ([]models.Project, int64, error) {
+	var out []models.Project
+	for id, p := range r.projects {
+		if p.OrganizationID == orgID && !r.deleted[id] {
+			out = append(out, *p)
+		}
+	}
+	slices.SortFunc(out, func(a, b models.Project) int { return strings.Compare(a.Name, b.Name) })
+	return out, int64(len(out)), nil
+}
+
+func (r *lifecycleProjectRepo) UpdateStatus(ctx context.Context, orgID, id uuid.UUID, status models.ProjectStatus) error {
+	r.projects[id].Status = status
+	return nil
+}
+
+func (r *lifecycleProjectRepo) Delete(ctx context.Context, orgID, id uuid.UUID) error {
+	r.deleted[id] = true
+	return nil
+}
+
+func (r *lifecycleProjectRepo) Restore(ctx context.Context, orgID, id uuid.UUID) error {
+	if !r.deleted[id] {
+		return repositories.ErrProjectNotDeleted
+	}
+	delete(r.deleted, id)
+	return nil
+}
+
+type lifecycleTaskRepo struct {
+	repositories.TaskRepository
+	tasks  []models.Task
+	skills []models.TaskSkill
+}
+
+func (r *lifecycleTaskRepo) Create(ctx context.Context, orgID uuid.UUID, t *models.Task) error {
+	t.ID = uuid.New()
+	r.tasks = append(r.tasks, *t)
+	return nil
+}
+
+func (r *lifecycleTaskRepo) ListByProject(ctx context.Context, orgID, projectID uuid.UUID, params repositories.ListParams) ([]models.Task, int64, error) {
+	var out []models.Task
+	for _, t := range r.tasks {
+		if t.ProjectID == projectID {
+			out = append(out, t)
+		}
+	}
+	return out, int64(len(out)), nil
+}
+
+func (r *lifecycleTaskRepo) ListSkillsByProject(ctx context.Context, orgID, projectID uuid.UUID) ([]models.TaskSkill, error) {
+	var out []models.TaskSkill
+	for _, sk := range r.skills {
+		for _, t := range r.tasks {
+			if t.ID == sk.TaskID && t.ProjectID == projectID {
+				out = append(out, sk)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (r *lifecycleTaskRepo) AddSkill(ctx context.Context, orgID uuid.UUID, skill *models.TaskSkill) error {
+	r.skills = append(r.skills, *skill)
+	return nil
+}
+
+func (r *lifecycleTaskRepo) byTitle(projectID uuid.UUID, title string) models.Task {
+	for _, t := range r.tasks {
+		if t.ProjectID == projectID && t.Title == title {
+			return t
+		}
+	}
+	Fail("no task titled " + title)
+	return models.Task{}
+}
+
+type lifecycleDependencyRepo struct {
+	repositories.DependencyRepository
+	tasks *lifecycleTaskRepo
+	deps  []models.TaskDependency
+}
+
+func (r *lifecycleDependencyRepo) Create(ctx context.Context, orgID uuid.UUID, d *models.TaskDependency) error {
+	d.ID = uuid.New()
+	r.deps = append(r.deps, *d)
+	return nil
+}
+
+func (r *lifecycleDependencyRepo) ListByProject(ctx context.Context, orgID, projectID uuid.UUID) ([]models.TaskDependency, error) {
+	inProject := map[uuid.UUID]bool{}
+	for _, t := range r.tasks.tasks {
+		inProject[t.ID] = t.ProjectID == projectID
+	}
+	var out []models.TaskDependency
+	for _, d := range r.deps {
+		if inProject[d.TaskID] || inProject[d.DependsOnTaskID] {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+type lifecycleWorkloadRepo struct {
+	repositories.WorkloadRepository
+	entries map[uuid.UUID]*models.WorkloadEntry
+}
+
+func (r *lifecycleWorkloadRepo) GetByUserAndWeek(ctx context.Context, orgID, userID uuid.UUID, weekStart time.Time) (*models.WorkloadEntry, error) {
+	e, ok := r.entries[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	clone := *e
+	return &clone, nil
+}
+
+func (r *lifecycleWorkloadRepo) CreateOrUpdate(ctx context.Context, entry *models.WorkloadEntry) error {
+	clone := *entry
+	r.entries[entry.UserID] = &clone
+	return nil
+}
+
+var _ = Describe("Project Lifecycle Service", func() {
+	var (
+		ctx       context.Context
+		service   services.ProjectLifecycleService
+		projects  *lifecycleProjectRepo
+		tasks     *lifecycleTaskRepo
+		deps      *lifecycleDependencyRepo
+		workloads *lifecycleWorkloadRepo
+		orgID     uuid.UUID
+		projectID uuid.UUID
+		emma      uuid.UUID
+		start     time.Time
+	)
+
+	date := func(year int, month time.Month, day int) *time.Time {
+		d := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		orgID, projectID, emma = uuid.New(), uuid.New(), uuid.New()
+		start = *date(2026, 3, 2)
+
+		projects = &lifecycleProjectRepo{
+			projects: map[uuid.UUID]*models.Project{projectID: {
+				BaseModel:      models.BaseModel{ID: projectID},
+				OrganizationID: orgID,
+				Name:           "Launch",
+				Status:         models.ProjectActive,
+				StartDate:      &start,
+				TargetEndDate:  date(2026, 3, 30),
+			}},
+			deleted: map[uuid.UUID]bool{},
+		}
+
+		epicID, designID, buildID, outsideID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+		tasks = &lifecycleTaskRepo{
+			// Subtasks are listed before their parent to exercise hierarchy ordering
+			tasks: []models.Task{
+				{BaseModel: models.BaseModel{ID: buildID}, ProjectID: projectID, ParentTaskID: &epicID, Title: "Build",
+					Status: models.TaskStatusInProgress, AssigneeID: &emma, EstimatedHours: 10, ActualHours: 4, DueDate: date(2026, 3, 20)},
+				{BaseModel: models.BaseModel{ID: designID}, ProjectID: projectID, ParentTaskID: &epicID, Title: "Design",
+					Status: models.TaskStatusDone, AssigneeID: &emma, EstimatedHours: 8, ActualHours: 8, StartDate: date(2026, 3, 2), DueDate: date(2026, 3, 6)},
+				{BaseModel: models.BaseModel{ID: epicID}, ProjectID: projectID, Title: "Epic", Status: models.TaskStatusReady},
+				{BaseModel: models.BaseModel{ID: outsideID}, ProjectID: uuid.New(), Title: "Elsewhere"},
+			},
+			skills: []models.TaskSkill{{TaskID: buildID, SkillID: uuid.New(), ProficiencyRequired: 3, IsRequired: true}},
+		}
+		deps = &lifecycleDependencyRepo{tasks: tasks, deps: []models.TaskDependency{
+			{TaskID: buildID, DependsOnTaskID: designID, DependencyType: models.DependencyFinishToStart, LagHours: 4},
+			{TaskID: buildID, DependsOnTaskID: outsideID, DependencyType: models.DependencyFinishToStart},
+		}}
+		workloads = &lifecycleWorkloadRepo{entries: map[uuid.UUID]*models.WorkloadEntry{emma: {
+			OrganizationID:      orgID,
+			UserID:              emma,
+			AssignedTasks:       3,
+			TotalEstimatedHours: 20,
+			AvailableHours:      40,
+		}}}
+
+		service = services.NewProjectLifecycleService(&repositories.Provider{
+			Project:    projects,
+			Task:       tasks,
+			Dependency: deps,
+			Workload:   workloads,
+		})
+	})
+
+	Describe("Transition", func() {
+		It("moves a project along an allowed transition", func() {
+			project, err := service.Transition(ctx, orgID, projectID, models.ProjectArchived)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(project.Status).To(Equal(models.ProjectArchived))
+			Expect(projects.projects[projectID].Status).To(Equal(models.ProjectArchived))
+		})
+
+		It("rejects a transition the lifecycle does not allow", func() {
+			projects.projects[projectID].Status = models.ProjectArchived
+
+			_, err := service.Transition(ctx, orgID, projectID, models.ProjectPaused)
+			Expect(err).To(MatchError(services.ErrInvalidProjectTransition))
+			Expect(projects.projects[projectID].Status).To(Equal(models.ProjectArchived))
+		})
+
+		It("treats moving to the current status as a no-op", func() {
+			project, err := service.Transition(ctx, orgID, projectID, models.ProjectActive)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(project.Status).To(Equal(models.ProjectActive))
+		})
+
+		It("reports a missing project as not found", func() {
+			_, err := service.Transition(ctx, orgID, uuid.New(), models.ProjectPaused)
+			Expect(err).To(MatchError(gorm.ErrRecordNotFound))
+		})
+	})
+
+	Describe("Delete and Restore", func() {
+		It("takes open tasks out of the assignee's workload and puts them back on restore", func() {
+			Expect(service.Delete(ctx, orgID, projectID)).To(Succeed())
+			Expect(projects.deleted[projectID]).To(BeTrue())
+			// Only Build is open: one task and its 6 remaining hours
+			Expect(workloads.entries[emma].AssignedTasks).To(Equal(2))
+			Expect(workloads.entries[emma].TotalEstimatedHours).To(Equal(14.0))
+			Expect(workloads.entries[emma].AllocationPercentage).To(Equal(35))
+
+			project, err := service.Restore(ctx, orgID, projectID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(project.ID).To(Equal(projectID))
+			Expect(workloads.entries[emma].AssignedTasks).To(Equal(3))
+			Expect(workloads.entries[emma].TotalEstimatedHours).To(Equal(20.0))
+		})
+
+		It("refuses to restore a project that is not deleted", func() {
+			_, err := service.Restore(ctx, orgID, projectID)
+			Expect(err).To(MatchError(repositories.ErrProjectNotDeleted))
+			Expect(workloads.entries[emma].AssignedTasks).To(Equal(3))
+		})
+	})
+
+	Describe("Clone", func() {
+		It("copies the hierarchy, skills and internal dependencies with shifted dates", func() {
+			result, err := service.Clone(ctx, orgID, projectID, "", *date(2026, 4, 6))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Tasks).To(Equal(3))
+			Expect(result.Skills).To(Equal(1))
+			Expect(result.Dependencies).To(Equal(1))
+
+			clone := result.Project
+			Expect(clone.Name).To(Equal("Copy of Launch"))
+			Expect(clone.Status).To(Equal(models.ProjectActive))
+			Expect(*clone.StartDate).To(Equal(*date(2026, 4, 6)))
+			Expect(*clone.TargetEndDate).To(Equal(*date(2026, 5, 4)))
+
+			epic := tasks.byTitle(clone.ID, "Epic")
+			design := tasks.byTitle(clone.ID, "Design")
+			build := tasks.byTitle(clone.ID, "Build")
+			Expect(epic.ParentTaskID).To(BeNil())
+			Expect(*build.ParentTaskID).To(Equal(epic.ID))
+			Expect(*design.ParentTaskID).To(Equal(epic.ID))
+
+			Expect(build.Status).To(Equal(models.TaskStatusBacklog))
+			Expect(build.AssigneeID).To(BeNil())
+			Expect(build.ActualHours).To(BeZero())
+			Expect(build.EstimatedHours).To(Equal(10.0))
+			Expect(*build.DueDate).To(Equal(*date(2026, 4, 24)))
+			Expect(*design.StartDate).To(Equal(*date(2026, 4, 6)))
+
+			Expect(tasks.skills).To(ContainElement(models.TaskSkill{
+				TaskID: build.ID, SkillID: tasks.skills[0].SkillID, ProficiencyRequired: 3, IsRequired: true,
+			}))
+			copied := deps.deps[len(deps.deps)-1]
+			Expect(copied.TaskID).To(Equal(build.ID))
+			Expect(copied.DependsOnTaskID).To(Equal(design.ID))
+			Expect(copied.LagHours).To(Equal(4))
+		})
+
+		It("keeps the requested name", func() {
+			result, err := service.Clone(ctx, orgID, projectID, "Launch v2", start)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Project.Name).To(Equal("Launch v2"))
+		})
+	})
+})
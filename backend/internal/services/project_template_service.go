@@ -0,0 +1,644 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+// ErrInvalidTemplate is returned when an imported template document is malformed
+var ErrInvalidTemplate = errors.New("invalid project template")
+
+// maxTemplateTasks bounds the size of an imported template
+const maxTemplateTasks = 1000
+
+var validTaskPriorities = map[models.TaskPriority]bool{
+	models.TaskPriorityLow:      true,
+	models.TaskPriorityMedium:   true,
+	models.TaskPriorityHigh:     true,
+	models.TaskPriorityCritical: true,
+}
+
+var validDependencyTypes = map[models.DependencyType]bool{
+	models.DependencyFinishToStart:  true,
+	models.DependencyStartToStart:   true,
+	models.DependencyFinishToFinish: true,
+	models.DependencyStartToFinish:  true,
+}
+
+// ProjectTemplateService defines the interface for the project template library
+type ProjectTemplateService interface {
+	// CreateFromProject saves a project's task tree, skill requirements and internal
+	// dependencies as a template
+	CreateFromProject(ctx context.Context, orgID, actorID uuid.UUID, req dto.CreateTemplateRequest) (*dto.ProjectTemplateResponse, error)
+
+	// List returns the organization's templates
+	List(ctx context.Context, orgID uuid.UUID, params repositories.ListParams) (*dto.ProjectTemplateListResponse, error)
+
+	// Get returns a template with its contents
+	Get(ctx context.Context, orgID, templateID uuid.UUID) (*dto.ProjectTemplateResponse, error)
+
+	// Delete removes a template. Projects created from it are unaffected.
+	Delete(ctx context.Context, orgID, templateID uuid.UUID) error
+
+	// Instantiate creates a new project from a template, starting on req.StartDate
+	Instantiate(ctx context.Context, orgID, templateID uuid.UUID, req dto.InstantiateTemplateRequest) (*ProjectCloneResult, error)
+
+	// Export returns a template as a portable document
+	Export(ctx context.Context, orgID, templateID uuid.UUID) (*dto.ProjectTemplateDocument, error)
+
+	// Import saves a template document in the organization. Skills are matched by name;
+	// skills the organization does not know are created for it.
+	Import(ctx context.Context, orgID, actorID uuid.UUID, doc dto.ProjectTemplateDocument) (*dto.ProjectTemplateResponse, error)
+}
+
+// RealProjectTemplateService implements ProjectTemplateService
+type RealProjectTemplateService struct {
+	repos *repositories.Provider
+}
+
+// NewProjectTemplateService creates a new project template service
+func NewProjectTemplateService(repos *repositories.Provider) *RealProjectTemplateService {
+	return &RealProjectTemplateService{repos: repos}
+}
+
+// CreateFromProject saves a project as a template. Dates become day offsets from the
+// project's start, or from its earliest task date when it has none. Dependencies on
+// tasks in other projects are left out.
+func (s *RealProjectTemplateService) CreateFromProject(ctx context.Context, orgID, actorID uuid.UUID, req dto.CreateTemplateRequest) (*dto.ProjectTemplateResponse, error) {
+	project, err := s.repos.GetProject().GetByID(ctx, orgID, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := listProjectTasks(ctx, s.repos, orgID, project.ID)
+	if err != nil {
+		return nil, err
+	}
+	skills, err := s.repos.GetTask().ListSkillsByProject(ctx, orgID, project.ID)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := s.repos.GetDependency().ListByProject(ctx, orgID, project.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	origin := projectOrigin(project, tasks)
+	template := &models.ProjectTemplate{
+		Name:            strings.TrimSpace(req.Name),
+		Description:     req.Description,
+		SourceProjectID: &project.ID,
+		CreatedByID:     actorID,
+	}
+	if end := dayOffset(origin, project.TargetEndDate); end != nil {
+		template.DurationDays = max(*end, 0)
+	}
+
+	ids := make(map[uuid.UUID]uuid.UUID, len(tasks))
+	index := make(map[uuid.UUID]int, len(tasks))
+	for i, t := range hierarchyOrder(tasks) {
+		ids[t.ID] = uuid.New()
+		index[t.ID] = i
+		task := models.TemplateTask{
+			BaseModel:       models.BaseModel{ID: ids[t.ID]},
+			Position:        i,
+			HierarchyLevel:  t.HierarchyLevel,
+			Title:           t.Title,
+			Description:     t.Description,
+			Priority:        t.Priority,
+			BusinessValue:   t.BusinessValue,
+			EstimatedHours:  t.EstimatedHours,
+			StartOffsetDays: dayOffset(origin, t.StartDate),
+			DueOffsetDays:   dayOffset(origin, t.DueDate),
+			IsMilestone:     t.IsMilestone,
+		}
+		if t.ParentTaskID != nil {
+			if parent, ok := ids[*t.ParentTaskID]; ok {
+				task.ParentID = &parent
+			}
+		}
+		// Without a target end date the template lasts until its last due date
+		if project.TargetEndDate == nil && task.DueOffsetDays != nil {
+			template.DurationDays = max(template.DurationDays, *task.DueOffsetDays)
+		}
+		template.Tasks = append(template.Tasks, task)
+	}
+	for _, sk := range skills {
+		if i, ok := index[sk.TaskID]; ok {
+			template.Tasks[i].Skills = append(template.Tasks[i].Skills, models.TemplateTaskSkill{
+				SkillID:             sk.SkillID,
+				ProficiencyRequired: sk.ProficiencyRequired,
+				IsRequired:          sk.IsRequired,
+			})
+		}
+	}
+	for _, d := range deps {
+		taskID, ok := ids[d.TaskID]
+		dependsOnID, ok2 := ids[d.DependsOnTaskID]
+		if !ok || !ok2 {
+			continue
+		}
+		template.Dependencies = append(template.Dependencies, models.TemplateDependency{
+			TaskID:          taskID,
+			DependsOnTaskID: dependsOnID,
+			DependencyType:  d.DependencyType,
+			LagHours:        d.LagHours,
+		})
+	}
+
+	if err := s.repos.GetTemplate().Create(ctx, orgID, template); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, orgID, template.ID)
+}
+
+// List returns the organization's templates with their task counts
+func (s *RealProjectTemplateService) List(ctx context.Context, orgID uuid.UUID, params repositories.ListParams) (*dto.ProjectTemplateListResponse, error) {
+	templates, total, err := s.repos.GetTemplate().List(ctx, orgID, params)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, len(templates))
+	for i, t := range templates {
+		ids[i] = t.ID
+	}
+	counts, err := s.repos.GetTemplate().CountTasks(ctx, orgID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.ProjectTemplateListResponse{Templates: make([]dto.ProjectTemplateSummary, len(templates)), Total: total}
+	for i := range templates {
+		resp.Templates[i] = toTemplateSummary(&templates[i], counts[templates[i].ID])
+	}
+	return resp, nil
+}
+
+// Get returns a template with its contents
+func (s *RealProjectTemplateService) Get(ctx context.Context, orgID, templateID uuid.UUID) (*dto.ProjectTemplateResponse, error) {
+	template, err := s.repos.GetTemplate().GetByID(ctx, orgID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, deps := toTemplateDocuments(template)
+	return &dto.ProjectTemplateResponse{
+		ProjectTemplateSummary: toTemplateSummary(template, len(template.Tasks)),
+		Tasks:                  tasks,
+		Dependencies:           deps,
+	}, nil
+}
+
+// Delete removes a template
+func (s *RealProjectTemplateService) Delete(ctx context.Context, orgID, templateID uuid.UUID) error {
+	return s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		return tx.GetTemplate().Delete(ctx, orgID, templateID)
+	})
+}
+
+// Instantiate creates an active project from a template. Tasks start in the backlog,
+// unassigned, with dates placed relative to startDate.
+func (s *RealProjectTemplateService) Instantiate(ctx context.Context, orgID, templateID uuid.UUID, req dto.InstantiateTemplateRequest) (*ProjectCloneResult, error) {
+	template, err := s.repos.GetTemplate().GetByID(ctx, orgID, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := req.StartDate
+	project := &models.Project{
+		Name:        strings.TrimSpace(req.Name),
+		Description: template.Description,
+		Status:      models.ProjectActive,
+		HealthScore: 100,
+		StartDate:   &start,
+	}
+	if template.DurationDays > 0 {
+		project.TargetEndDate = offsetDate(start, &template.DurationDays)
+	}
+	result := &ProjectCloneResult{Project: project}
+
+	err = s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		if err := tx.GetProject().Create(ctx, orgID, project); err != nil {
+			return err
+		}
+
+		// Template tasks are stored parents first
+		ids := make(map[uuid.UUID]uuid.UUID, len(template.Tasks))
+		for _, t := range template.Tasks {
+			task := &models.Task{
+				ProjectID:      project.ID,
+				HierarchyLevel: t.HierarchyLevel,
+				Title:          t.Title,
+				Description:    t.Description,
+				Status:         models.TaskStatusBacklog,
+				Priority:       t.Priority,
+				BusinessValue:  t.BusinessValue,
+				EstimatedHours: t.EstimatedHours,
+				StartDate:      offsetDate(start, t.StartOffsetDays),
+				DueDate:        offsetDate(start, t.DueOffsetDays),
+				IsMilestone:    t.IsMilestone,
+			}
+			if t.ParentID != nil {
+				if parent, ok := ids[*t.ParentID]; ok {
+					task.ParentTaskID = &parent
+				}
+			}
+			if err := tx.GetTask().Create(ctx, orgID, task); err != nil {
+				return err
+			}
+			ids[t.ID] = task.ID
+			result.Tasks++
+
+			for _, sk := range t.Skills {
+				if err := tx.GetTask().AddSkill(ctx, orgID, &models.TaskSkill{
+					TaskID:              task.ID,
+					SkillID:             sk.SkillID,
+					ProficiencyRequired: sk.ProficiencyRequired,
+					IsRequired:          sk.IsRequired,
+				}); err != nil {
+					return err
+				}
+				result.Skills++
+			}
+		}
+
+		for _, d := range template.Dependencies {
+			taskID, ok := ids[d.TaskID]
+			dependsOnID, ok2 := ids[d.DependsOnTaskID]
+			if !ok || !ok2 {
+				continue
+			}
+			if err := tx.GetDependency().Create(ctx, orgID, &models.TaskDependency{
+				TaskID:          taskID,
+				DependsOnTaskID: dependsOnID,
+				DependencyType:  d.DependencyType,
+				LagHours:        d.LagHours,
+			}); err != nil {
+				return err
+			}
+			result.Dependencies++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Export returns a template as a portable document
+func (s *RealProjectTemplateService) Export(ctx context.Context, orgID, templateID uuid.UUID) (*dto.ProjectTemplateDocument, error) {
+	template, err := s.repos.GetTemplate().GetByID(ctx, orgID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, deps := toTemplateDocuments(template)
+	return &dto.ProjectTemplateDocument{
+		Format:       dto.TemplateDocumentFormat,
+		Version:      dto.TemplateDocumentVersion,
+		Name:         template.Name,
+		Description:  template.Description,
+		DurationDays: template.DurationDays,
+		Tasks:        tasks,
+		Dependencies: deps,
+	}, nil
+}
+
+// Import validates a template document and saves it in the organization
+func (s *RealProjectTemplateService) Import(ctx context.Context, orgID, actorID uuid.UUID, doc dto.ProjectTemplateDocument) (*dto.ProjectTemplateResponse, error) {
+	ordered, err := validateTemplateDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &models.ProjectTemplate{
+		Name:         strings.TrimSpace(doc.Name),
+		Description:  doc.Description,
+		DurationDays: doc.DurationDays,
+		CreatedByID:  actorID,
+	}
+	err = s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		skills, err := resolveSkills(ctx, tx, orgID, doc.Tasks)
+		if err != nil {
+			return err
+		}
+
+		ids := make(map[string]uuid.UUID, len(ordered))
+		levels := make(map[string]int, len(ordered))
+		for i, t := range ordered {
+			ids[t.Key] = uuid.New()
+			levels[t.Key] = 1
+			task := models.TemplateTask{
+				BaseModel:       models.BaseModel{ID: ids[t.Key]},
+				Position:        i,
+				HierarchyLevel:  1,
+				Title:           strings.TrimSpace(t.Title),
+				Description:     t.Description,
+				Priority:        models.TaskPriority(t.Priority),
+				BusinessValue:   t.BusinessValue,
+				EstimatedHours:  t.EstimatedHours,
+				StartOffsetDays: t.StartOffsetDays,
+				DueOffsetDays:   t.DueOffsetDays,
+				IsMilestone:     t.IsMilestone,
+			}
+			if task.Priority == "" {
+				task.Priority = models.TaskPriorityMedium
+			}
+			if t.Parent != "" {
+				parent := ids[t.Parent]
+				task.ParentID = &parent
+				levels[t.Key] = levels[t.Parent] + 1
+				task.HierarchyLevel = levels[t.Key]
+			}
+			for _, sk := range t.Skills {
+				proficiency := sk.ProficiencyRequired
+				if proficiency == 0 {
+					proficiency = 3
+				}
+				task.Skills = append(task.Skills, models.TemplateTaskSkill{
+					SkillID:             skills[strings.ToLower(strings.TrimSpace(sk.Name))],
+					ProficiencyRequired: proficiency,
+					IsRequired:          sk.IsRequired,
+				})
+			}
+			template.Tasks = append(template.Tasks, task)
+		}
+		for _, d := range doc.Dependencies {
+			depType := models.DependencyType(d.Type)
+			if depType == "" {
+				depType = models.DependencyFinishToStart
+			}
+			template.Dependencies = append(template.Dependencies, models.TemplateDependency{
+				TaskID:          ids[d.Task],
+				DependsOnTaskID: ids[d.DependsOn],
+				DependencyType:  depType,
+				LagHours:        d.LagHours,
+			})
+		}
+		return tx.GetTemplate().Create(ctx, orgID, template)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, orgID, template.ID)
+}
+
+// validateTemplateDocument checks a document and returns its tasks ordered so every
+// parent precedes its subtasks
+func validateTemplateDocument(doc dto.ProjectTemplateDocument) ([]dto.TemplateTaskDocument, error) {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidTemplate, fmt.Sprintf(format, args...))
+	}
+
+	if doc.Format != dto.TemplateDocumentFormat {
+		return nil, invalid("format must be %q", dto.TemplateDocumentFormat)
+	}
+	if doc.Version != dto.TemplateDocumentVersion {
+		return nil, invalid("unsupported version %d", doc.Version)
+	}
+	if strings.TrimSpace(doc.Name) == "" {
+		return nil, invalid("name is required")
+	}
+	if doc.DurationDays < 0 {
+		return nil, invalid("durationDays must not be negative")
+	}
+	if len(doc.Tasks) > maxTemplateTasks {
+		return nil, invalid("at most %d tasks are allowed", maxTemplateTasks)
+	}
+
+	tasks := make(map[string]dto.TemplateTaskDocument, len(doc.Tasks))
+	for _, t := range doc.Tasks {
+		switch {
+		case t.Key == "":
+			return nil, invalid("every task needs a key")
+		case tasks[t.Key].Key != "":
+			return nil, invalid("task key %q is used twice", t.Key)
+		case strings.TrimSpace(t.Title) == "":
+			return nil, invalid("task %q has no title", t.Key)
+		case t.Priority != "" && !validTaskPriorities[models.TaskPriority(t.Priority)]:
+			return nil, invalid("task %q has unknown priority %q", t.Key, t.Priority)
+		case t.BusinessValue < 0 || t.BusinessValue > 100:
+			return nil, invalid("task %q businessValue must be between 0 and 100", t.Key)
+		case t.EstimatedHours < 0:
+			return nil, invalid("task %q estimatedHours must not be negative", t.Key)
+		}
+		for _, sk := range t.Skills {
+			if strings.TrimSpace(sk.Name) == "" {
+				return nil, invalid("task %q has a skill without a name", t.Key)
+			}
+			if sk.ProficiencyRequired < 0 || sk.ProficiencyRequired > 4 {
+				return nil, invalid("task %q skill %q proficiencyRequired must be between 1 and 4", t.Key, sk.Name)
+			}
+		}
+		tasks[t.Key] = t
+	}
+
+	// Order parents first; a task left over belongs to a parent cycle
+	var ordered []dto.TemplateTaskDocument
+	placed := make(map[string]bool, len(doc.Tasks))
+	for len(ordered) < len(doc.Tasks) {
+		progressed := false
+		for _, t := range doc.Tasks {
+			if placed[t.Key] {
+				continue
+			}
+			if t.Parent != "" {
+				if _, ok := tasks[t.Parent]; !ok {
+					return nil, invalid("task %q has unknown parent %q", t.Key, t.Parent)
+				}
+				if !placed[t.Parent] {
+					continue
+				}
+			}
+			ordered = append(ordered, t)
+			placed[t.Key] = true
+			progressed = true
+		}
+		if !progressed {
+			return nil, invalid("task parents form a cycle")
+		}
+	}
+
+	edges := make(map[string][]string)
+	seen := make(map[[2]string]bool)
+	for _, d := range doc.Dependencies {
+		if _, ok := tasks[d.Task]; !ok {
+			return nil, invalid("dependency names unknown task %q", d.Task)
+		}
+		if _, ok := tasks[d.DependsOn]; !ok {
+			return nil, invalid("dependency names unknown task %q", d.DependsOn)
+		}
+		if d.Task == d.DependsOn {
+			return nil, invalid("task %q cannot depend on itself", d.Task)
+		}
+		if d.Type != "" && !validDependencyTypes[models.DependencyType(d.Type)] {
+			return nil, invalid("dependency type %q is unknown", d.Type)
+		}
+		pair := [2]string{d.Task, d.DependsOn}
+		if seen[pair] {
+			return nil, invalid("task %q depends on %q twice", d.Task, d.DependsOn)
+		}
+		seen[pair] = true
+		edges[d.Task] = append(edges[d.Task], d.DependsOn)
+	}
+	if dependencyCycle(edges) {
+		return nil, invalid("dependencies form a cycle")
+	}
+	return ordered, nil
+}
+
+// dependencyCycle reports whether the task -> depends-on edges contain a cycle
+func dependencyCycle(edges map[string][]string) bool {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(edges))
+	var visit func(string) bool
+	visit = func(key string) bool {
+		switch state[key] {
+		case visiting:
+			return true
+		case done:
+			return false
+		}
+		state[key] = visiting
+		for _, next := range edges[key] {
+			if visit(next) {
+				return true
+			}
+		}
+		state[key] = done
+		return false
+	}
+	for key := range edges {
+		if visit(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveSkills maps the lower-cased skill names used by tasks to skills the
+// organization can see, preferring its own over global ones. Unknown skills are created
+// for the organization.
+func resolveSkills(ctx context.Context, tx *repositories.Provider, orgID uuid.UUID, tasks []dto.TemplateTaskDocument) (map[string]uuid.UUID, error) {
+	wanted := map[string]dto.TemplateSkillDocument{}
+	var names []string
+	for _, t := range tasks {
+		for _, sk := range t.Skills {
+			name := strings.ToLower(strings.TrimSpace(sk.Name))
+			if _, ok := wanted[name]; !ok {
+				wanted[name] = sk
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	known, err := tx.GetSkill().ListByNames(ctx, orgID, names)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]uuid.UUID, len(names))
+	for _, sk := range known {
+		name := strings.ToLower(sk.Name)
+		if _, ok := ids[name]; !ok || sk.OrganizationID != nil {
+			ids[name] = sk.ID
+		}
+	}
+	for _, name := range names {
+		if _, ok := ids[name]; ok {
+			continue
+		}
+		skill := &models.Skill{Name: strings.TrimSpace(wanted[name].Name), Category: wanted[name].Category}
+		if err := tx.GetSkill().Create(ctx, orgID, skill); err != nil {
+			return nil, err
+		}
+		ids[name] = skill.ID
+	}
+	return ids, nil
+}
+
+func toTemplateSummary(t *models.ProjectTemplate, taskCount int) dto.ProjectTemplateSummary {
+	return dto.ProjectTemplateSummary{
+		ID:              t.ID,
+		Name:            t.Name,
+		Description:     t.Description,
+		DurationDays:    t.DurationDays,
+		TaskCount:       taskCount,
+		SourceProjectID: t.SourceProjectID,
+		CreatedByID:     t.CreatedByID,
+		CreatedAt:       t.CreatedAt,
+	}
+}
+
+// toTemplateDocuments converts a template's contents to document form, keyed by
+// template task ID
+func toTemplateDocuments(t *models.ProjectTemplate) ([]dto.TemplateTaskDocument, []dto.TemplateDependencyDocument) {
+	tasks := make([]dto.TemplateTaskDocument, 0, len(t.Tasks))
+	for _, task := range t.Tasks {
+		doc := dto.TemplateTaskDocument{
+			Key:             task.ID.String(),
+			Title:           task.Title,
+			Description:     task.Description,
+			Priority:        string(task.Priority),
+			BusinessValue:   task.BusinessValue,
+			EstimatedHours:  task.EstimatedHours,
+			StartOffsetDays: task.StartOffsetDays,
+			DueOffsetDays:   task.DueOffsetDays,
+			IsMilestone:     task.IsMilestone,
+		}
+		if task.ParentID != nil {
+			doc.Parent = task.ParentID.String()
+		}
+		for _, sk := range task.Skills {
+			doc.Skills = append(doc.Skills, dto.TemplateSkillDocument{
+				Name:                sk.Skill.Name,
+				Category:            sk.Skill.Category,
+				ProficiencyRequired: sk.ProficiencyRequired,
+				IsRequired:          sk.IsRequired,
+			})
+		}
+		tasks = append(tasks, doc)
+	}
+
+	deps := make([]dto.TemplateDependencyDocument, 0, len(t.Dependencies))
+	for _, d := range t.Dependencies {
+		deps = append(deps, dto.TemplateDependencyDocument{
+			Task:      d.TaskID.String(),
+			DependsOn: d.DependsOnTaskID.String(),
+			Type:      string(d.DependencyType),
+			LagHours:  d.LagHours,
+		})
+	}
+	return tasks, deps
+}
+
+// dayOffset is the whole number of days from origin to t, or nil when either is unset
+func dayOffset(origin, t *time.Time) *int {
+	if origin == nil || t == nil {
+		return nil
+	}
+	days := int(math.Round(t.Sub(*origin).Hours() / 24))
+	return &days
+}
+
+// offsetDate places a day offset relative to start
+func offsetDate(start time.Time, days *int) *time.Time {
+	if days == nil {
+		return nil
+	}
+	d := start.AddDate(0, 0, *days)
+	return &d
+}
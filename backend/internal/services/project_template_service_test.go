@@ -0,0 +1,275 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+type memSkillRepo struct {
+	repositories.SkillRepository
+	skills []models.Skill
+}
+
+func (r *memSkillRepo) ListByNames(ctx context.Context, orgID uuid.UUID, names []string) ([]models.Skill, error) {
+	var out []models.Skill
+	for _, sk := range r.skills {
+		visible := sk.OrganizationID == nil || *sk.OrganizationID == orgID
+		for _, name := range names {
+			if visible && strings.EqualFold(sk.Name, name) {
+				out = append(out, sk)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (r *memSkillRepo) Create(ctx context.Context, orgID uuid.UUID, skill *models.Skill) error {
+	skill.ID, skill.OrganizationID = uuid.New(), &orgID
+	r.skills = append(r.skills, *skill)
+	return nil
+}
+
+func (r *memSkillRepo) byID(id uuid.UUID) models.Skill {
+	for _, sk := range r.skills {
+		if sk.ID == id {
+			return sk
+		}
+	}
+	return models.Skill{}
+}
+
+type memTemplateRepo struct {
+	repositories.ProjectTemplateRepository
+	skills    *memSkillRepo
+	templates map[uuid.UUID]*models.ProjectTemplate
+}
+
+func (r *memTemplateRepo) Create(ctx context.Context, orgID uuid.UUID, t *models.ProjectTemplate) error {
+	t.ID, t.OrganizationID = uuid.New(), orgID
+	clone := *t
+	r.templates[t.ID] = &clone
+	return nil
+}
+
+func (r *memTemplateRepo) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.ProjectTemplate, error) {
+	t, ok := r.templates[id]
+	if !ok || t.OrganizationID != orgID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	clone := *t
+	clone.Tasks = append([]models.TemplateTask(nil), t.Tasks...)
+	for i := range clone.Tasks {
+		skills := append([]models.TemplateTaskSkill(nil), clone.Tasks[i].Skills...)
+		for j := range skills {
+			skills[j].Skill = r.skills.byID(skills[j].SkillID)
+		}
+		clone.Tasks[i].Skills = skills
+	}
+	return &clone, nil
+}
+
+var _ = Describe("Project Template Service", func() {
+	var (
+		ctx       context.Context
+		service   services.ProjectTemplateService
+		projects  *lifecycleProjectRepo
+		tasks     *lifecycleTaskRepo
+		deps      *lifecycleDependencyRepo
+		skills    *memSkillRepo
+		templates *memTemplateRepo
+		orgID     uuid.UUID
+		projectID uuid.UUID
+		actorID   uuid.UUID
+		goSkill   models.Skill
+	)
+
+	date := func(year int, month time.Month, day int) *time.Time {
+		d := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+	days := func(n int) *int { return &n }
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		orgID, projectID, actorID = uuid.New(), uuid.New(), uuid.New()
+
+		projects = &lifecycleProjectRepo{
+			projects: map[uuid.UUID]*models.Project{projectID: {
+				BaseModel:      models.BaseModel{ID: projectID},
+				OrganizationID: orgID,
+				Name:           "Migration",
+				StartDate:      date(2026, 3, 2),
+				TargetEndDate:  date(2026, 3, 30),
+			}},
+			deleted: map[uuid.UUID]bool{},
+		}
+
+		goSkill = models.Skill{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "Go", Category: "Backend"}
+		skills = &memSkillRepo{skills: []models.Skill{goSkill}}
+
+		phaseID, cutoverID, reviewID := uuid.New(), uuid.New(), uuid.New()
+		tasks = &lifecycleTaskRepo{
+			tasks: []models.Task{
+				{BaseModel: models.BaseModel{ID: cutoverID}, ProjectID: projectID, ParentTaskID: &phaseID, HierarchyLevel: 2,
+					Title: "Cutover", Priority: models.TaskPriorityHigh, EstimatedHours: 12, IsMilestone: true,
+					StartDate: date(2026, 3, 16), DueDate: date(2026, 3, 20)},
+				{BaseModel: models.BaseModel{ID: phaseID}, ProjectID: projectID, HierarchyLevel: 1, Title: "Phase 1",
+					Priority: models.TaskPriorityMedium},
+				{BaseModel: models.BaseModel{ID: reviewID}, ProjectID: projectID, HierarchyLevel: 1, Title: "Review",
+					Priority: models.TaskPriorityLow, EstimatedHours: 3, DueDate: date(2026, 3, 11)},
+			},
+			skills: []models.TaskSkill{{TaskID: cutoverID, SkillID: goSkill.ID, ProficiencyRequired: 4, IsRequired: true}},
+		}
+		deps = &lifecycleDependencyRepo{tasks: tasks, deps: []models.TaskDependency{
+			{TaskID: cutoverID, DependsOnTaskID: reviewID, DependencyType: models.DependencyFinishToStart, LagHours: 24},
+		}}
+		templates = &memTemplateRepo{skills: skills, templates: map[uuid.UUID]*models.ProjectTemplate{}}
+
+		service = services.NewProjectTemplateService(&repositories.Provider{
+			Project:    projects,
+			Task:       tasks,
+			Dependency: deps,
+			Skill:      skills,
+			Template:   templates,
+		})
+	})
+
+	Describe("CreateFromProject", func() {
+		It("captures the task tree with day offsets, skills, milestones and dependencies", func() {
+			template, err := service.CreateFromProject(ctx, orgID, actorID, dto.CreateTemplateRequest{ProjectID: projectID, Name: "Migration playbook"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(template.Name).To(Equal("Migration playbook"))
+			Expect(template.DurationDays).To(Equal(28))
+			Expect(template.TaskCount).To(Equal(3))
+			Expect(*template.SourceProjectID).To(Equal(projectID))
+
+			byTitle := map[string]dto.TemplateTaskDocument{}
+			for _, t := range template.Tasks {
+				byTitle[t.Title] = t
+			}
+			phase, cutover, review := byTitle["Phase 1"], byTitle["Cutover"], byTitle["Review"]
+			Expect(cutover.Parent).To(Equal(phase.Key))
+			Expect(cutover.StartOffsetDays).To(Equal(days(14)))
+			Expect(cutover.DueOffsetDays).To(Equal(days(18)))
+			Expect(cutover.IsMilestone).To(BeTrue())
+			Expect(cutover.Skills).To(ConsistOf(dto.TemplateSkillDocument{Name: "Go", Category: "Backend", ProficiencyRequired: 4, IsRequired: true}))
+			Expect(phase.StartOffsetDays).To(BeNil())
+
+			Expect(template.Dependencies).To(ConsistOf(dto.TemplateDependencyDocument{
+				Task: cutover.Key, DependsOn: review.Key, Type: string(models.DependencyFinishToStart), LagHours: 24,
+			}))
+		})
+
+		It("reports a missing project as not found", func() {
+			_, err := service.CreateFromProject(ctx, orgID, actorID, dto.CreateTemplateRequest{ProjectID: uuid.New(), Name: "x"})
+			Expect(err).To(MatchError(gorm.ErrRecordNotFound))
+		})
+	})
+
+	Describe("Instantiate", func() {
+		It("creates a project with dates placed relative to the new start", func() {
+			template, err := service.CreateFromProject(ctx, orgID, actorID, dto.CreateTemplateRequest{ProjectID: projectID, Name: "Playbook"})
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := service.Instantiate(ctx, orgID, template.ID, dto.InstantiateTemplateRequest{Name: "Migration 2", StartDate: *date(2026, 6, 1)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Tasks).To(Equal(3))
+			Expect(result.Skills).To(Equal(1))
+			Expect(result.Dependencies).To(Equal(1))
+
+			project := result.Project
+			Expect(project.Name).To(Equal("Migration 2"))
+			Expect(*project.TargetEndDate).To(Equal(*date(2026, 6, 29)))
+
+			phase := tasks.byTitle(project.ID, "Phase 1")
+			cutover := tasks.byTitle(project.ID, "Cutover")
+			review := tasks.byTitle(project.ID, "Review")
+			Expect(*cutover.ParentTaskID).To(Equal(phase.ID))
+			Expect(*cutover.StartDate).To(Equal(*date(2026, 6, 15)))
+			Expect(*cutover.DueDate).To(Equal(*date(2026, 6, 19)))
+			Expect(cutover.Status).To(Equal(models.TaskStatusBacklog))
+			Expect(cutover.IsMilestone).To(BeTrue())
+
+			copied := deps.deps[len(deps.deps)-1]
+			Expect(copied.TaskID).To(Equal(cutover.ID))
+			Expect(copied.DependsOnTaskID).To(Equal(review.ID))
+			Expect(copied.LagHours).To(Equal(24))
+		})
+	})
+
+	Describe("Export and Import", func() {
+		It("round-trips a template into another organization, matching skills by name", func() {
+			template, err := service.CreateFromProject(ctx, orgID, actorID, dto.CreateTemplateRequest{ProjectID: projectID, Name: "Playbook"})
+			Expect(err).NotTo(HaveOccurred())
+			doc, err := service.Export(ctx, orgID, template.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(doc.Format).To(Equal(dto.TemplateDocumentFormat))
+
+			// The other organization has its own "go" skill and no Kubernetes skill
+			otherOrg := uuid.New()
+			ownGo := models.Skill{BaseModel: models.BaseModel{ID: uuid.New()}, OrganizationID: &otherOrg, Name: "go"}
+			skills.skills = append(skills.skills, ownGo)
+			doc.Tasks[0].Skills = append(doc.Tasks[0].Skills, dto.TemplateSkillDocument{Name: "Kubernetes", Category: "Ops", ProficiencyRequired: 2})
+
+			imported, err := service.Import(ctx, otherOrg, actorID, *doc)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(imported.ID).NotTo(Equal(template.ID))
+			Expect(imported.TaskCount).To(Equal(3))
+			Expect(imported.Dependencies).To(HaveLen(1))
+
+			stored := templates.templates[imported.ID]
+			var skillIDs []uuid.UUID
+			for _, t := range stored.Tasks {
+				for _, sk := range t.Skills {
+					skillIDs = append(skillIDs, sk.SkillID)
+				}
+			}
+			created := skills.skills[len(skills.skills)-1]
+			Expect(created.Name).To(Equal("Kubernetes"))
+			Expect(*created.OrganizationID).To(Equal(otherOrg))
+			Expect(skillIDs).To(ConsistOf(ownGo.ID, created.ID))
+		})
+
+		DescribeTable("rejects malformed documents",
+			func(mutate func(doc *dto.ProjectTemplateDocument), message string) {
+				doc := dto.ProjectTemplateDocument{
+					Format:  dto.TemplateDocumentFormat,
+					Version: dto.TemplateDocumentVersion,
+					Name:    "Launch",
+					Tasks: []dto.TemplateTaskDocument{
+						{Key: "a", Title: "Plan"},
+						{Key: "b", Title: "Build", Parent: "a"},
+						{Key: "c", Title: "Ship"},
+					},
+					Dependencies: []dto.TemplateDependencyDocument{{Task: "c", DependsOn: "b"}},
+				}
+				mutate(&doc)
+
+				_, err := service.Import(ctx, orgID, actorID, doc)
+				Expect(err).To(MatchError(services.ErrInvalidTemplate))
+				Expect(err.Error()).To(ContainSubstring(message))
+				Expect(templates.templates).To(BeEmpty())
+			},
+			Entry("wrong format", func(doc *dto.ProjectTemplateDocument) { doc.Format = "other" }, "format"),
+			Entry("newer version", func(doc *dto.ProjectTemplateDocument) { doc.Version = 2 }, "unsupported version"),
+			Entry("duplicate key", func(doc *dto.ProjectTemplateDocument) { doc.Tasks[2].Key = "a" }, "used twice"),
+			Entry("unknown parent", func(doc *dto.ProjectTemplateDocument) { doc.Tasks[1].Parent = "z" }, "unknown parent"),
+			Entry("parent cycle", func(doc *dto.ProjectTemplateDocument) { doc.Tasks[0].Parent = "b" }, "parents form a cycle"),
+			Entry("dependency cycle", func(doc *dto.ProjectTemplateDocument) {
+				doc.Dependencies = append(doc.Dependencies, dto.TemplateDependencyDocument{Task: "b", DependsOn: "c"})
+			}, "dependencies form a cycle"),
+			Entry("unknown dependency type", func(doc *dto.ProjectTemplateDocument) { doc.Dependencies[0].Type = "sometime" }, "unknown"),
+		)
+	})
+})
@@ -0,0 +1,835 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+var (
+	// ErrSelfDependency is returned when a task is made to depend on itself
+	ErrSelfDependency = errors.New("a task cannot depend on itself")
+
+	// ErrDuplicateDependency is returned when a task already depends on the other task
+	ErrDuplicateDependency = errors.New("dependency already exists")
+)
+
+// Dependency graph layout, in points between columns and rows of nodes
+const (
+	graphColumnWidth = 200
+	graphRowHeight   = 100
+)
+
+// RealDependencyService implements DependencyService over an in-memory graph of the
+// tasks and dependencies of a project
+type RealDependencyService struct {
+	repos *repositories.Provider
+	now   func() time.Time
+}
+
+// NewRealDependencyService creates a new real dependency service
+func NewRealDependencyService(repos *repositories.Provider) DependencyService {
+	return &RealDependencyService{repos: repos, now: time.Now}
+}
+
+// GetTaskDependencies lists what a task depends on and what depends on it, in its own
+// project or others, and where it sits in the project's schedule
+func (s *RealDependencyService) GetTaskDependencies(ctx context.Context, taskID string, includeIndirect bool, orgID string) (*dto.TaskDependenciesResponse, error) {
+	orgUUID, taskUUID, err := parseDependencyIDs(orgID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	task, err := s.repos.GetTask().GetByID(ctx, orgUUID, taskUUID)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := s.graph(ctx, orgUUID, task.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.TaskDependenciesResponse{
+		TaskID: taskID,
+		Dependencies: dto.DependencySection{
+			Direct:   []dto.DependencyInfo{},
+			Indirect: []dto.IndirectDependency{},
+		},
+		Dependents: dto.DependentsSection{
+			Direct:   []dto.DependentInfo{},
+			Indirect: []dto.IndirectDependency{},
+		},
+	}
+	for _, d := range graph.predecessors[taskUUID] {
+		predecessor := graph.tasks[d.DependsOnTaskID]
+		resp.Dependencies.Direct = append(resp.Dependencies.Direct, dto.DependencyInfo{
+			DependencyID:    d.ID.String(),
+			DependsOnTaskID: d.DependsOnTaskID.String(),
+			DependencyType:  string(d.DependencyType),
+			LagHours:        d.LagHours,
+			Status:          string(predecessor.Status),
+			IsBlocking:      !dependencySatisfied(d.DependencyType, predecessor),
+			ProjectID:       predecessor.ProjectID.String(),
+			CrossProject:    graph.crossProject(d),
+		})
+	}
+
+	// The graph holds the tasks the project depends on but not those of other projects
+	// depending on it
+	dependents, err := s.repos.GetDependency().ListByDependsOn(ctx, orgUUID, taskUUID)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range dependents {
+		resp.Dependents.Direct = append(resp.Dependents.Direct, dto.DependentInfo{
+			TaskID:         d.TaskID.String(),
+			DependencyType: string(d.DependencyType),
+			IsBlocked:      !dependencySatisfied(d.DependencyType, task),
+			ProjectID:      d.Task.ProjectID.String(),
+			CrossProject:   d.Task.ProjectID != task.ProjectID,
+		})
+	}
+	if includeIndirect {
+		resp.Dependencies.Indirect = indirectDependencies(graph.upstream(taskUUID))
+		resp.Dependents.Indirect = indirectDependencies(graph.downstream(taskUUID))
+	}
+
+	// A cycle left over from before cycles were rejected cannot be scheduled
+	resp.ChainAnalysis.CriticalPathPosition = "unknown"
+	if schedule, err := graph.schedule(); err == nil {
+		resp.ChainAnalysis = dto.ChainAnalysis{
+			LongestChain:         schedule.tasksThrough(taskUUID),
+			CriticalPathPosition: "off_path",
+			FloatHours:           int(math.Round(schedule.totalFloat(taskUUID))),
+			FreeFloatHours:       int(math.Round(schedule.freeFloat(graph, taskUUID))),
+		}
+		if schedule.critical(graph, taskUUID) {
+			resp.ChainAnalysis.CriticalPathPosition = "on_path"
+		}
+	}
+	return resp, nil
+}
+
+// CreateDependency makes one task depend on another, unless that would make the task
+// depend on itself, repeat an existing dependency or close a cycle
+func (s *RealDependencyService) CreateDependency(ctx context.Context, req dto.CreateDependencyRequest, orgID string) (*dto.CreateDependencyResponse, error) {
+	check, err := s.checkDependency(ctx, orgID, req.TaskID, req.DependsOnTaskID)
+	if err != nil {
+		return nil, err
+	}
+	if err := check.err(); err != nil {
+		return nil, err
+	}
+
+	dep := &models.TaskDependency{
+		TaskID:          check.task.ID,
+		DependsOnTaskID: check.dependsOn.ID,
+		DependencyType:  models.DependencyType(req.DependencyType),
+		LagHours:        req.LagHours,
+	}
+	if err := s.repos.GetDependency().Create(ctx, check.orgID, dep); err != nil {
+		return nil, err
+	}
+	impact := check.impact(*dep)
+	s.refreshCriticalPath(ctx, check.orgID, check.task.ProjectID, check.dependsOn.ProjectID)
+
+	createdAt := dep.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = s.now()
+	}
+	return &dto.CreateDependencyResponse{
+		DependencyID:    dep.ID.String(),
+		TaskID:          req.TaskID,
+		DependsOnTaskID: req.DependsOnTaskID,
+		DependencyType:  req.DependencyType,
+		LagHours:        req.LagHours,
+		CreatedAt:       createdAt.UTC(),
+		Validation:      dto.DependencyValidation{Valid: true},
+		Impact:          impact,
+	}, nil
+}
+
+// DeleteDependency removes a dependency
+func (s *RealDependencyService) DeleteDependency(ctx context.Context, dependencyID string, orgID string) error {
+	orgUUID, depUUID, err := parseDependencyIDs(orgID, dependencyID)
+	if err != nil {
+		return err
+	}
+	dep, err := s.repos.GetDependency().GetByID(ctx, orgUUID, depUUID)
+	if err != nil {
+		return err
+	}
+	var projectIDs []uuid.UUID
+	for _, taskID := range []uuid.UUID{dep.TaskID, dep.DependsOnTaskID} {
+		task, err := s.repos.GetTask().GetByID(ctx, orgUUID, taskID)
+		if err != nil {
+			return err
+		}
+		projectIDs = append(projectIDs, task.ProjectID)
+	}
+	if err := s.repos.GetDependency().Delete(ctx, orgUUID, depUUID); err != nil {
+		return err
+	}
+	s.refreshCriticalPath(ctx, orgUUID, projectIDs...)
+	return nil
+}
+
+// GetCriticalPath schedules the project's tasks by their dependencies and lists the
+// critical ones, which cannot slip without delaying the project. Tasks of other projects
+// the project waits on are scheduled with it, so the path can run through them. Every
+// other open task of the project can slip by its float. The critical path flags of the
+// project's tasks are updated to match.
+func (s *RealDependencyService) GetCriticalPath(ctx context.Context, projectID string, orgID string) (*dto.CriticalPathResponse, error) {
+	orgUUID, projUUID, err := parseDependencyIDs(orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	graph, schedule, err := s.recalculateCriticalPath(ctx, orgUUID, projUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.CriticalPathResponse{
+		ProjectID: projectID,
+		CriticalPath: dto.CriticalPathInfo{
+			TaskIDs: []string{},
+			Tasks:   []dto.CriticalPathTask{},
+		},
+		NonCriticalTasks: []dto.NonCriticalTask{},
+		ProjectDuration:  int(math.Round(schedule.finish)),
+		CalculatedAt:     s.now().UTC(),
+	}
+	resp.CriticalPath.TotalDuration = resp.ProjectDuration
+	if len(schedule.order) > 0 {
+		end := schedule.calendar.finishAt(schedule.finish)
+		resp.ProjectEndDate = &end
+	}
+
+	resp.CriticalPath.TaskIDs, resp.CriticalPath.Tasks = criticalPathTasks(graph, schedule)
+	for _, id := range schedule.order {
+		task := graph.tasks[id]
+		if schedule.critical(graph, id) || task.ArchivedAt != nil || graph.external(id) {
+			continue
+		}
+		resp.NonCriticalTasks = append(resp.NonCriticalTasks, dto.NonCriticalTask{
+			TaskID:         id.String(),
+			Title:          task.Title,
+			FloatHours:     int(math.Round(schedule.totalFloat(id))),
+			FreeFloatHours: int(math.Round(schedule.freeFloat(graph, id))),
+		})
+	}
+	return resp, nil
+}
+
+// criticalPathTasks lists the critical tasks of a schedule with their dates
+func criticalPathTasks(graph *dependencyGraph, schedule *dependencySchedule) ([]string, []dto.CriticalPathTask) {
+	ids, tasks := []string{}, []dto.CriticalPathTask{}
+	date := func(t time.Time) *time.Time { return &t }
+	for _, id := range schedule.criticalPath(graph) {
+		task := graph.tasks[id]
+		ids = append(ids, id.String())
+		tasks = append(tasks, dto.CriticalPathTask{
+			TaskID:         id.String(),
+			ProjectID:      task.ProjectID.String(),
+			External:       graph.external(id),
+			Title:          task.Title,
+			EstimatedHours: int(math.Round(task.EstimatedHours)),
+			EarliestStart:  date(schedule.calendar.startAt(schedule.earlyStart[id])),
+			EarliestFinish: date(schedule.calendar.finishAt(schedule.earlyFinish[id])),
+			LatestStart:    date(schedule.calendar.startAt(schedule.lateStart[id])),
+			LatestFinish:   date(schedule.calendar.finishAt(schedule.lateFinish[id])),
+		})
+	}
+	return ids, tasks
+}
+
+// RecalculateCriticalPath stores which of the project's tasks are on its critical path
+func (s *RealDependencyService) RecalculateCriticalPath(ctx context.Context, projectID string, orgID string) error {
+	orgUUID, projUUID, err := parseDependencyIDs(orgID, projectID)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.recalculateCriticalPath(ctx, orgUUID, projUUID)
+	return err
+}
+
+// recalculateCriticalPath schedules the project and stores which of its tasks are
+// critical
+func (s *RealDependencyService) recalculateCriticalPath(ctx context.Context, orgID, projectID uuid.UUID) (*dependencyGraph, *dependencySchedule, error) {
+	graph, err := s.graph(ctx, orgID, projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	schedule, err := graph.schedule()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.repos.GetTask().UpdateCriticalPath(ctx, orgID, projectID, schedule.criticalPath(graph)); err != nil {
+		return nil, nil, err
+	}
+	return graph, schedule, nil
+}
+
+// refreshCriticalPath recalculates the critical paths of projects whose dependencies
+// changed. The change has already been saved, so a failure is logged rather than
+// returned.
+func (s *RealDependencyService) refreshCriticalPath(ctx context.Context, orgID uuid.UUID, projectIDs ...uuid.UUID) {
+	for i, projectID := range projectIDs {
+		if slices.Contains(projectIDs[:i], projectID) {
+			continue
+		}
+		if _, _, err := s.recalculateCriticalPath(ctx, orgID, projectID); err != nil {
+			log.Printf("[DependencyService] Failed to recalculate the critical path of project %s: %v", projectID, err)
+		}
+	}
+}
+
+// ValidateDependency reports whether a dependency could be created and what it would
+// change, without creating it
+func (s *RealDependencyService) ValidateDependency(ctx context.Context, req dto.ValidateDependencyRequest, orgID string) (*dto.ValidateDependencyResponse, error) {
+	check, err := s.checkDependency(ctx, orgID, req.TaskID, req.DependsOnTaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.ValidateDependencyResponse{
+		Valid:            true,
+		WouldCreateCycle: len(check.cycle) > 0,
+		Warnings:         []dto.DependencyWarning{},
+	}
+	warn := func(kind, message string) {
+		resp.Warnings = append(resp.Warnings, dto.DependencyWarning{Type: kind, Message: message})
+	}
+	switch {
+	case check.self:
+		resp.Valid = false
+		warn("self_dependency", "A task cannot depend on itself")
+	case check.duplicate:
+		resp.Valid = false
+		warn("duplicate_dependency", fmt.Sprintf("%q already depends on %q", check.task.Title, check.dependsOn.Title))
+	case len(check.cycle) > 0:
+		resp.Valid = false
+		titles := make([]string, len(check.cycle))
+		for i, id := range check.cycle {
+			titles[i] = check.graph.tasks[id].Title
+		}
+		warn("circular_dependency", "This dependency would create a cycle: "+strings.Join(titles, " -> "))
+	}
+	if !resp.Valid {
+		return resp, nil
+	}
+
+	if check.dependsOnProject != nil {
+		warn("cross_project", fmt.Sprintf("%q is in another project, %s; its schedule will hold up this project", check.dependsOn.Title, check.dependsOnProject.Name))
+		if check.dependsOnProject.Status == models.ProjectPaused {
+			warn("project_paused", fmt.Sprintf("%s is paused, so %q may not move until it resumes", check.dependsOnProject.Name, check.dependsOn.Title))
+		}
+	}
+
+	dependencyType := models.DependencyType(req.DependencyType)
+	finishes := dependencyType == models.DependencyFinishToStart || dependencyType == models.DependencyFinishToFinish
+	if finishes && check.task.DueDate != nil && check.dependsOn.DueDate != nil && check.dependsOn.DueDate.After(*check.task.DueDate) {
+		warn("date_constraint", fmt.Sprintf("%q is due after %q; its due date may need to move", check.dependsOn.Title, check.task.Title))
+	}
+	if dependencyType == models.DependencyFinishToStart && check.task.Status != models.TaskStatusBacklog &&
+		check.task.Status != models.TaskStatusReady && check.dependsOn.Status != models.TaskStatusDone {
+		warn("status_constraint", fmt.Sprintf("%q has already started but %q is not done", check.task.Title, check.dependsOn.Title))
+	}
+
+	impact := check.impact(models.TaskDependency{TaskID: check.task.ID, DependsOnTaskID: check.dependsOn.ID, DependencyType: dependencyType})
+	resp.Impact = dto.ValidationImpact{
+		EstimatedDelay: int(math.Round(check.delay)),
+		AffectedTasks:  len(impact.AffectedTasks),
+	}
+	return resp, nil
+}
+
+// GetDependencyGraph lays out the project's tasks in columns by how many dependencies
+// deep they sit, with an edge from each task to the tasks depending on it. Tasks of other
+// projects the project depends on are included and marked external. Critical tasks and
+// the dependencies driving them are flagged, and subtasks are clustered under their
+// top-level task.
+func (s *RealDependencyService) GetDependencyGraph(ctx context.Context, projectID string, orgID string) (*dto.DependencyGraphResponse, error) {
+	orgUUID, projUUID, err := parseDependencyIDs(orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	project, err := s.repos.GetProject().GetByID(ctx, orgUUID, projUUID)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := s.graph(ctx, orgUUID, projUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A cycle left over from before cycles were rejected puts every task in one column,
+	// with nothing on the critical path
+	order, err := graph.topologicalOrder()
+	if err != nil {
+		order = graph.order
+	}
+	levels := graph.levels(order)
+	schedule, _ := graph.schedule()
+
+	resp := &dto.DependencyGraphResponse{
+		ProjectID:   projectID,
+		ProjectName: project.Name,
+		Nodes:       make([]dto.DependencyGraphNode, 0, len(order)),
+		Edges:       []dto.DependencyGraphEdge{},
+		Clusters:    []dto.DependencyGraphCluster{},
+	}
+	clusters := graphClusters(graph)
+	rows := map[int]int{}
+	for _, id := range order {
+		level := levels[id]
+		node := graphNode(graph, schedule, id, level, rows[level])
+		if cluster, ok := clusters[id]; ok {
+			node.ClusterID = cluster.String()
+			if cluster == id {
+				resp.Clusters = append(resp.Clusters, dto.DependencyGraphCluster{ID: node.ID, Title: node.Title})
+			}
+		}
+		resp.Nodes = append(resp.Nodes, node)
+		rows[level]++
+		for _, d := range graph.predecessors[id] {
+			resp.Edges = append(resp.Edges, graphEdge(graph, schedule, d))
+		}
+	}
+	return resp, nil
+}
+
+// graphClusters groups the project's tasks under their top-level task, for the
+// top-level tasks that have subtasks. Tasks of other projects are left out.
+func graphClusters(graph *dependencyGraph) map[uuid.UUID]uuid.UUID {
+	clusters := map[uuid.UUID]uuid.UUID{}
+	for _, id := range graph.order {
+		if graph.external(id) {
+			continue
+		}
+		top := id
+		for range models.MaxHierarchyLevel {
+			parentID := graph.tasks[top].ParentTaskID
+			if parentID == nil || graph.tasks[*parentID] == nil {
+				break
+			}
+			top = *parentID
+		}
+		if top != id {
+			clusters[id], clusters[top] = top, top
+		}
+	}
+	return clusters
+}
+
+// graphNode places a task in the given column and row of a graph layout. Without a
+// schedule no task is critical.
+func graphNode(graph *dependencyGraph, schedule *dependencySchedule, id uuid.UUID, column, row int) dto.DependencyGraphNode {
+	task := graph.tasks[id]
+	return dto.DependencyGraphNode{
+		ID:        id.String(),
+		ProjectID: task.ProjectID.String(),
+		External:  graph.external(id),
+		Title:     task.Title,
+		Status:    string(task.Status),
+		Critical:  schedule != nil && schedule.critical(graph, id),
+		X:         column * graphColumnWidth,
+		Y:         row * graphRowHeight,
+	}
+}
+
+// graphEdge is a dependency as an edge from the task depended on to the waiting task
+func graphEdge(graph *dependencyGraph, schedule *dependencySchedule, d models.TaskDependency) dto.DependencyGraphEdge {
+	return dto.DependencyGraphEdge{
+		ID:           d.ID.String(),
+		Source:       d.DependsOnTaskID.String(),
+		Target:       d.TaskID.String(),
+		Type:         string(d.DependencyType),
+		LagHours:     d.LagHours,
+		CrossProject: graph.crossProject(d),
+		Critical:     schedule != nil && schedule.criticalDependency(graph, d),
+	}
+}
+
+// GetPortfolioGraph schedules every project of the organization together, so a chain of
+// dependencies can run from one project into another, and summarizes how the projects
+// depend on each other. The portfolio's critical path runs through the projects that
+// decide when the last of them finishes.
+func (s *RealDependencyService) GetPortfolioGraph(ctx context.Context, orgID string) (*dto.PortfolioDependencyGraphResponse, error) {
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := listOrganizationProjects(ctx, s.repos, orgUUID)
+	if err != nil {
+		return nil, err
+	}
+	projects = slices.DeleteFunc(projects, func(p models.Project) bool { return p.Status == models.ProjectArchived })
+	projectIDs := make([]uuid.UUID, len(projects))
+	for i, p := range projects {
+		projectIDs[i] = p.ID
+	}
+
+	graph, err := s.graph(ctx, orgUUID, projectIDs...)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := graph.schedule()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.PortfolioDependencyGraphResponse{
+		Projects:     make([]dto.PortfolioProject, 0, len(projects)),
+		Links:        []dto.PortfolioProjectLink{},
+		Nodes:        []dto.DependencyGraphNode{},
+		Edges:        []dto.DependencyGraphEdge{},
+		CalculatedAt: s.now().UTC(),
+	}
+	resp.CriticalPath.TaskIDs, resp.CriticalPath.Tasks = criticalPathTasks(graph, schedule)
+	resp.CriticalPath.TotalDuration = int(math.Round(schedule.finish))
+	if len(schedule.order) > 0 {
+		end := schedule.calendar.finishAt(schedule.finish)
+		resp.PortfolioEndDate = &end
+	}
+
+	summaries := make(map[uuid.UUID]*dto.PortfolioProject, len(projects))
+	for _, p := range projects {
+		resp.Projects = append(resp.Projects, dto.PortfolioProject{
+			ProjectID:     p.ID.String(),
+			Name:          p.Name,
+			Status:        string(p.Status),
+			TargetEndDate: p.TargetEndDate,
+		})
+	}
+	for i := range resp.Projects {
+		summaries[projects[i].ID] = &resp.Projects[i]
+		if finish := schedule.projectFinish(graph, projects[i].ID); finish > 0 {
+			end := schedule.calendar.finishAt(finish)
+			resp.Projects[i].ProjectEndDate = &end
+		}
+	}
+
+	// Only the tasks at either end of a dependency crossing projects are drawn, in
+	// columns by how deep they sit in the whole portfolio
+	order, err := graph.topologicalOrder()
+	if err != nil {
+		order = graph.order
+	}
+	levels := graph.levels(order)
+	links := map[[2]uuid.UUID]int{} // index into resp.Links
+	drawn := map[uuid.UUID]bool{}
+	rows := map[int]int{}
+	draw := func(id uuid.UUID) {
+		if !drawn[id] {
+			drawn[id] = true
+			resp.Nodes = append(resp.Nodes, graphNode(graph, schedule, id, levels[id], rows[levels[id]]))
+			rows[levels[id]]++
+		}
+	}
+	for _, id := range order {
+		if schedule.critical(graph, id) {
+			if summary := summaries[graph.tasks[id].ProjectID]; summary != nil {
+				summary.OnCriticalPath = true
+			}
+		}
+		for _, d := range graph.predecessors[id] {
+			if !graph.crossProject(d) {
+				continue
+			}
+			from, to := graph.tasks[d.DependsOnTaskID].ProjectID, graph.tasks[d.TaskID].ProjectID
+			blocking := !dependencySatisfied(d.DependencyType, graph.tasks[d.DependsOnTaskID]) && graph.tasks[d.TaskID].Status != models.TaskStatusDone
+			draw(d.DependsOnTaskID)
+			draw(d.TaskID)
+			resp.Edges = append(resp.Edges, graphEdge(graph, schedule, d))
+
+			i, ok := links[[2]uuid.UUID{from, to}]
+			if !ok {
+				i = len(resp.Links)
+				links[[2]uuid.UUID{from, to}] = i
+				resp.Links = append(resp.Links, dto.PortfolioProjectLink{Source: from.String(), Target: to.String()})
+			}
+			link := &resp.Links[i]
+			link.Dependencies++
+			link.Critical = link.Critical || schedule.criticalDependency(graph, d)
+			if summary := summaries[to]; summary != nil {
+				summary.DependsOn++
+			}
+			if summary := summaries[from]; summary != nil {
+				summary.DependedOnBy++
+			}
+			if !blocking {
+				continue
+			}
+			link.Blocking++
+			if summary := summaries[to]; summary != nil {
+				summary.BlockedBy++
+			}
+			if summary := summaries[from]; summary != nil {
+				summary.Blocking++
+			}
+		}
+	}
+	return resp, nil
+}
+
+// graph loads the tasks and dependencies of the given projects, together with the tasks
+// of other projects they depend on, directly or through other tasks. Their schedule
+// starts with the earliest project, or the earliest task start when no project has a
+// start date, or else today.
+func (s *RealDependencyService) graph(ctx context.Context, orgID uuid.UUID, projectIDs ...uuid.UUID) (*dependencyGraph, error) {
+	var tasks []models.Task
+	var deps []models.TaskDependency
+	var start *time.Time
+	earliest := func(t *time.Time) {
+		if t != nil && (start == nil || t.Before(*start)) {
+			start = t
+		}
+	}
+	projects := make(map[uuid.UUID]bool, len(projectIDs))
+	for _, projectID := range projectIDs {
+		if projects[projectID] {
+			continue
+		}
+		projects[projectID] = true
+		project, err := s.repos.GetProject().GetByID(ctx, orgID, projectID)
+		if err != nil {
+			return nil, err
+		}
+		earliest(project.StartDate)
+		projectTasks, err := listProjectTasks(ctx, s.repos, orgID, projectID)
+		if err != nil {
+			return nil, err
+		}
+		projectDeps, err := s.repos.GetDependency().ListByProject(ctx, orgID, projectID)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, projectTasks...)
+		deps = append(deps, projectDeps...)
+	}
+	if start == nil {
+		for i := range tasks {
+			earliest(tasks[i].StartDate)
+		}
+	}
+
+	external, err := s.upstreamTasks(ctx, orgID, tasks, deps)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range external {
+		tasks = append(tasks, task.Task)
+		deps = append(deps, task.deps...)
+	}
+
+	graph := newDependencyGraph(tasks, deps)
+	graph.projects = projects
+	graph.start = s.now()
+	if start != nil {
+		graph.start = *start
+	}
+	return graph, nil
+}
+
+// upstreamTask is a task of another project that a loaded task depends on, with its own
+// dependencies
+type upstreamTask struct {
+	models.Task
+	deps []models.TaskDependency
+}
+
+// upstreamTasks follows the dependencies leading out of the loaded tasks to tasks of
+// other projects, and from there on to everything those depend on
+func (s *RealDependencyService) upstreamTasks(ctx context.Context, orgID uuid.UUID, tasks []models.Task, deps []models.TaskDependency) ([]upstreamTask, error) {
+	seen := make(map[uuid.UUID]bool, len(tasks))
+	for _, t := range tasks {
+		seen[t.ID] = true
+	}
+	var queue []uuid.UUID
+	follow := func(deps []models.TaskDependency) {
+		for _, d := range deps {
+			if !seen[d.DependsOnTaskID] {
+				seen[d.DependsOnTaskID] = true
+				queue = append(queue, d.DependsOnTaskID)
+			}
+		}
+	}
+	follow(deps)
+
+	var upstream []upstreamTask
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		task, err := s.repos.GetTask().GetByID(ctx, orgID, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		taskDeps, err := s.repos.GetDependency().ListByTask(ctx, orgID, id)
+		if err != nil {
+			return nil, err
+		}
+		upstream = append(upstream, upstreamTask{Task: *task, deps: taskDeps})
+		follow(taskDeps)
+	}
+	return upstream, nil
+}
+
+// dependencyCheck is a proposed dependency of task on dependsOn, with the graph of
+// their projects and what stands in its way
+type dependencyCheck struct {
+	orgID           uuid.UUID
+	task, dependsOn *models.Task
+	graph           *dependencyGraph
+
+	self, duplicate bool
+	// dependsOnProject is the project of dependsOn when it is not the task's own
+	dependsOnProject *models.Project
+	// cycle lists the tasks of the cycle the dependency would close, starting and
+	// ending with the task
+	cycle []uuid.UUID
+
+	// delay is how many working hours later the task could finish with the dependency
+	delay float64
+}
+
+// checkDependency loads both tasks and their projects' graph and looks for a
+// self-dependency, a duplicate or a cycle. The graph follows dependencies into other
+// projects, so a cycle running through any project of the organization is found.
+func (s *RealDependencyService) checkDependency(ctx context.Context, orgID, taskID, dependsOnID string) (*dependencyCheck, error) {
+	orgUUID, taskUUID, err := parseDependencyIDs(orgID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	_, dependsOnUUID, err := parseDependencyIDs(orgID, dependsOnID)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := s.repos.GetTask().GetByID(ctx, orgUUID, taskUUID)
+	if err != nil {
+		return nil, err
+	}
+	dependsOn, err := s.repos.GetTask().GetByID(ctx, orgUUID, dependsOnUUID)
+	if err != nil {
+		return nil, err
+	}
+	check := &dependencyCheck{orgID: orgUUID, task: task, dependsOn: dependsOn, self: taskUUID == dependsOnUUID}
+	if check.self {
+		return check, nil
+	}
+
+	if check.duplicate, err = s.repos.GetDependency().HasDependency(ctx, orgUUID, taskUUID, dependsOnUUID); err != nil {
+		return nil, err
+	}
+	if dependsOn.ProjectID != task.ProjectID {
+		if check.dependsOnProject, err = s.repos.GetProject().GetByID(ctx, orgUUID, dependsOn.ProjectID); err != nil {
+			return nil, err
+		}
+	}
+	if check.graph, err = s.graph(ctx, orgUUID, task.ProjectID, dependsOn.ProjectID); err != nil {
+		return nil, err
+	}
+	if path := check.graph.dependencyPath(dependsOnUUID, taskUUID); path != nil {
+		check.cycle = append([]uuid.UUID{taskUUID}, path...)
+	}
+	return check, nil
+}
+
+// err is why the dependency cannot be created, or nil
+func (c *dependencyCheck) err() error {
+	switch {
+	case c.self:
+		return ErrSelfDependency
+	case c.duplicate:
+		return fmt.Errorf("%w: %q already depends on %q", ErrDuplicateDependency, c.task.Title, c.dependsOn.Title)
+	case len(c.cycle) > 0:
+		cycle := make([]string, len(c.cycle))
+		for i, id := range c.cycle {
+			cycle[i] = id.String()
+		}
+		return &CircularDependencyError{Message: "Circular dependency detected", Cycle: cycle}
+	}
+	return nil
+}
+
+// impact adds the dependency to the graph and compares its schedule before and after.
+// The task and everything depending on it are affected.
+func (c *dependencyCheck) impact(dep models.TaskDependency) dto.DependencyImpact {
+	impact := dto.DependencyImpact{AffectedTasks: []string{c.task.ID.String()}}
+	before, err := c.graph.schedule()
+	if err != nil {
+		return impact
+	}
+	c.graph.addDependency(dep)
+	after, err := c.graph.schedule()
+	if err != nil {
+		return impact
+	}
+
+	for _, chain := range c.graph.downstream(c.task.ID) {
+		impact.AffectedTasks = append(impact.AffectedTasks, chain.path[len(chain.path)-1].String())
+	}
+	impact.CriticalPathChanged = !slices.Equal(before.criticalPath(c.graph), after.criticalPath(c.graph))
+	end := after.calendar.finishAt(after.projectFinish(c.graph, c.task.ProjectID))
+	impact.NewProjectEndDate = &end
+	c.delay = math.Max(after.earlyFinish[c.task.ID]-before.earlyFinish[c.task.ID], 0)
+	return impact
+}
+
+// dependencySatisfied reports whether a predecessor has got far enough for its
+// dependent: finish dependencies wait for it to be done, start dependencies for it to
+// have started
+func dependencySatisfied(dependencyType models.DependencyType, predecessor *models.Task) bool {
+	switch dependencyType {
+	case models.DependencyStartToStart, models.DependencyStartToFinish:
+		return predecessor.Status == models.TaskStatusInProgress ||
+			predecessor.Status == models.TaskStatusReview ||
+			predecessor.Status == models.TaskStatusDone
+	}
+	return predecessor.Status == models.TaskStatusDone
+}
+
+// indirectDependencies keeps the chains that pass through at least one other task
+func indirectDependencies(chains []dependencyChain) []dto.IndirectDependency {
+	out := []dto.IndirectDependency{}
+	for _, chain := range chains {
+		if len(chain.path) < 3 {
+			continue
+		}
+		path := make([]string, len(chain.path))
+		for i, id := range chain.path {
+			path[i] = id.String()
+		}
+		out = append(out, dto.IndirectDependency{Path: path, Depth: len(path) - 1})
+	}
+	return out
+}
+
+// parseDependencyIDs parses the organization ID and the ID of the task, project or
+// dependency. A malformed ID cannot name a record, so it is reported as not found.
+func parseDependencyIDs(orgID, id string) (uuid.UUID, uuid.UUID, error) {
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("invalid ID %q: %w", id, gorm.ErrRecordNotFound)
+	}
+	return orgUUID, parsed, nil
+}
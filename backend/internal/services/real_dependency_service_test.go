@@ -0,0 +1,530 @@
+package services_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// graphDependencyRepo keeps dependencies between the tasks of the hierarchy fake
+type graphDependencyRepo struct {
+	repositories.DependencyRepository
+	tasks *hierarchyTaskRepo
+	deps  []models.TaskDependency
+}
+
+func (r *graphDependencyRepo) add(taskID, dependsOn uuid.UUID, dependencyType models.DependencyType) {
+	r.deps = append(r.deps, models.TaskDependency{
+		BaseModel: models.BaseModel{ID: uuid.New()}, TaskID: taskID, DependsOnTaskID: dependsOn, DependencyType: dependencyType,
+	})
+}
+
+func (r *graphDependencyRepo) Create(ctx context.Context, orgID uuid.UUID, d *models.TaskDependency) error {
+	d.ID = uuid.New()
+	r.deps = append(r.deps, *d)
+	return nil
+}
+
+func (r *graphDependencyRepo) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.TaskDependency, error) {
+	for _, d := range r.deps {
+		if d.ID == id {
+			return &d, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *graphDependencyRepo) Delete(ctx context.Context, orgID, id uuid.UUID) error {
+	for i, d := range r.deps {
+		if d.ID == id {
+			r.deps = append(r.deps[:i], r.deps[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *graphDependencyRepo) ListByProject(ctx context.Context, orgID, projectID uuid.UUID) ([]models.TaskDependency, error) {
+	var out []models.TaskDependency
+	for _, d := range r.deps {
+		if t, ok := r.tasks.tasks[d.TaskID]; ok && t.ProjectID == projectID {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (r *graphDependencyRepo) ListByTask(ctx context.Context, orgID, taskID uuid.UUID) ([]models.TaskDependency, error) {
+	var out []models.TaskDependency
+	for _, d := range r.deps {
+		if d.TaskID == taskID {
+			d.DependsOnTask = *r.tasks.tasks[d.DependsOnTaskID]
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (r *graphDependencyRepo) ListByDependsOn(ctx context.Context, orgID, dependsOnTaskID uuid.UUID) ([]models.TaskDependency, error) {
+	var out []models.TaskDependency
+	for _, d := range r.deps {
+		if d.DependsOnTaskID == dependsOnTaskID {
+			d.Task = *r.tasks.tasks[d.TaskID]
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (r *graphDependencyRepo) HasDependency(ctx context.Context, orgID, taskID, dependsOn uuid.UUID) (bool, error) {
+	for _, d := range r.deps {
+		if d.TaskID == taskID && d.DependsOnTaskID == dependsOn {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+var _ = Describe("Real Dependency Service", func() {
+	var (
+		ctx       context.Context
+		service   services.DependencyService
+		projects  *lifecycleProjectRepo
+		tasks     *hierarchyTaskRepo
+		deps      *graphDependencyRepo
+		orgID     uuid.UUID
+		projectID uuid.UUID
+
+		design, api, ui, checkout, docs uuid.UUID
+	)
+
+	// The project starts on Monday 7 September; working days run from 09:00 to 17:00
+	at := func(day, hour int) time.Time {
+		return time.Date(2026, time.September, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		orgID, projectID = uuid.New(), uuid.New()
+
+		// design -> api -> checkout is the critical path at 32 hours; ui runs beside api and
+		// only has to start before checkout does
+		tasks = &hierarchyTaskRepo{tasks: map[uuid.UUID]*models.Task{}}
+		design = tasks.add(models.Task{ProjectID: projectID, Title: "Design", Status: models.TaskStatusDone, EstimatedHours: 8})
+		api = tasks.add(models.Task{ProjectID: projectID, Title: "API", Status: models.TaskStatusInProgress, EstimatedHours: 16})
+		ui = tasks.add(models.Task{ProjectID: projectID, Title: "UI", Status: models.TaskStatusInProgress, EstimatedHours: 4})
+		checkout = tasks.add(models.Task{ProjectID: projectID, Title: "Checkout", Status: models.TaskStatusReady, EstimatedHours: 8})
+		docs = tasks.add(models.Task{ProjectID: projectID, Title: "Docs", Status: models.TaskStatusBacklog, EstimatedHours: 2})
+
+		deps = &graphDependencyRepo{tasks: tasks}
+		deps.add(api, design, models.DependencyFinishToStart)
+		deps.add(ui, design, models.DependencyFinishToStart)
+		deps.add(checkout, api, models.DependencyFinishToStart)
+		deps.add(checkout, ui, models.DependencyStartToStart)
+
+		start := at(7, 0)
+		projects = &lifecycleProjectRepo{
+			projects: map[uuid.UUID]*models.Project{projectID: {BaseModel: models.BaseModel{ID: projectID}, OrganizationID: orgID, Name: "Checkout", StartDate: &start}},
+			deleted:  map[uuid.UUID]bool{},
+		}
+		service = services.NewRealDependencyService(&repositories.Provider{
+			Project:    projects,
+			Task:       tasks,
+			Dependency: deps,
+		})
+	})
+
+	Describe("GetTaskDependencies", func() {
+		It("lists direct and indirect predecessors with what still blocks the task", func() {
+			resp, err := service.GetTaskDependencies(ctx, checkout.String(), true, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(resp.Dependencies.Direct).To(HaveLen(2))
+			Expect(resp.Dependencies.Direct[0].DependsOnTaskID).To(Equal(api.String()))
+			Expect(resp.Dependencies.Direct[0].IsBlocking).To(BeTrue())
+			Expect(resp.Dependencies.Direct[1].DependsOnTaskID).To(Equal(ui.String()))
+			Expect(resp.Dependencies.Direct[1].IsBlocking).To(BeFalse())
+
+			Expect(resp.Dependencies.Indirect).To(HaveLen(1))
+			Expect(resp.Dependencies.Indirect[0].Path).To(Equal([]string{checkout.String(), api.String(), design.String()}))
+			Expect(resp.Dependencies.Indirect[0].Depth).To(Equal(2))
+			Expect(resp.Dependents.Direct).To(BeEmpty())
+
+			Expect(resp.ChainAnalysis.LongestChain).To(Equal(3))
+			Expect(resp.ChainAnalysis.CriticalPathPosition).To(Equal("on_path"))
+		})
+
+		It("lists dependents and the float of tasks off the critical path", func() {
+			resp, err := service.GetTaskDependencies(ctx, ui.String(), true, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Dependents.Direct).To(HaveLen(1))
+			Expect(resp.Dependents.Direct[0].TaskID).To(Equal(checkout.String()))
+			Expect(resp.Dependents.Direct[0].IsBlocked).To(BeFalse())
+			Expect(resp.ChainAnalysis.CriticalPathPosition).To(Equal("off_path"))
+			Expect(resp.ChainAnalysis.FloatHours).To(Equal(16))
+			Expect(resp.ChainAnalysis.FreeFloatHours).To(Equal(16))
+		})
+
+		It("leaves out indirect chains unless asked", func() {
+			resp, err := service.GetTaskDependencies(ctx, design.String(), false, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Dependents.Direct).To(HaveLen(2))
+			Expect(resp.Dependents.Indirect).To(BeEmpty())
+		})
+
+		It("reports unknown tasks as not found", func() {
+			_, err := service.GetTaskDependencies(ctx, uuid.NewString(), false, orgID.String())
+			Expect(err).To(MatchError(gorm.ErrRecordNotFound))
+		})
+	})
+
+	Describe("CreateDependency", func() {
+		create := func(taskID, dependsOn uuid.UUID) (*dto.CreateDependencyResponse, error) {
+			return service.CreateDependency(ctx, dto.CreateDependencyRequest{
+				TaskID: taskID.String(), DependsOnTaskID: dependsOn.String(), DependencyType: "finish_to_start",
+			}, orgID.String())
+		}
+
+		It("rejects a cycle with the path it would close", func() {
+			_, err := create(design, checkout)
+			var circular *services.CircularDependencyError
+			Expect(errors.As(err, &circular)).To(BeTrue())
+			Expect(circular.Cycle).To(Equal([]string{design.String(), checkout.String(), api.String(), design.String()}))
+			Expect(deps.deps).To(HaveLen(4))
+		})
+
+		It("rejects self-dependencies and duplicates", func() {
+			_, err := create(api, api)
+			Expect(err).To(MatchError(services.ErrSelfDependency))
+
+			_, err = create(api, design)
+			Expect(err).To(MatchError(services.ErrDuplicateDependency))
+		})
+
+		It("creates the dependency and reports the chains it changes", func() {
+			resp, err := create(docs, checkout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deps.deps).To(HaveLen(5))
+			Expect(resp.DependencyID).To(Equal(deps.deps[4].ID.String()))
+			Expect(resp.Validation.Valid).To(BeTrue())
+			Expect(resp.Impact.CriticalPathChanged).To(BeTrue())
+			Expect(resp.Impact.AffectedTasks).To(Equal([]string{docs.String()}))
+			Expect(*resp.Impact.NewProjectEndDate).To(Equal(at(11, 11)))
+			Expect(tasks.tasks[docs].IsCriticalPath).To(BeTrue())
+		})
+	})
+
+	Describe("DeleteDependency", func() {
+		It("takes tasks off the critical path when their dependency goes", func() {
+			Expect(service.RecalculateCriticalPath(ctx, projectID.String(), orgID.String())).To(Succeed())
+			Expect(tasks.tasks[checkout].IsCriticalPath).To(BeTrue())
+
+			Expect(service.DeleteDependency(ctx, deps.deps[2].ID.String(), orgID.String())).To(Succeed())
+			Expect(deps.deps).To(HaveLen(3))
+			Expect(tasks.tasks[api].IsCriticalPath).To(BeTrue())
+			Expect(tasks.tasks[checkout].IsCriticalPath).To(BeFalse())
+		})
+	})
+
+	Describe("ValidateDependency", func() {
+		It("flags cycles without creating anything", func() {
+			resp, err := service.ValidateDependency(ctx, dto.ValidateDependencyRequest{
+				TaskID: design.String(), DependsOnTaskID: checkout.String(), DependencyType: "finish_to_start",
+			}, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Valid).To(BeFalse())
+			Expect(resp.WouldCreateCycle).To(BeTrue())
+			Expect(resp.Warnings[0].Message).To(ContainSubstring("Design -> Checkout -> API -> Design"))
+		})
+
+		It("estimates how much later the task could finish", func() {
+			resp, err := service.ValidateDependency(ctx, dto.ValidateDependencyRequest{
+				TaskID: docs.String(), DependsOnTaskID: checkout.String(), DependencyType: "finish_to_start",
+			}, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Valid).To(BeTrue())
+			Expect(resp.Impact.EstimatedDelay).To(Equal(32))
+			Expect(resp.Impact.AffectedTasks).To(Equal(1))
+			Expect(deps.deps).To(HaveLen(4))
+		})
+	})
+
+	Describe("GetCriticalPath", func() {
+		It("schedules the tasks without float in working hours", func() {
+			resp, err := service.GetCriticalPath(ctx, projectID.String(), orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.CriticalPath.TaskIDs).To(Equal([]string{design.String(), api.String(), checkout.String()}))
+			Expect(resp.ProjectDuration).To(Equal(32))
+			Expect(*resp.ProjectEndDate).To(Equal(at(10, 17)))
+
+			// API runs from Tuesday morning to Wednesday evening and cannot move
+			apiTask := resp.CriticalPath.Tasks[1]
+			Expect(*apiTask.EarliestStart).To(Equal(at(8, 9)))
+			Expect(*apiTask.EarliestFinish).To(Equal(at(9, 17)))
+			Expect(*apiTask.LatestStart).To(Equal(at(8, 9)))
+			Expect(*apiTask.LatestFinish).To(Equal(at(9, 17)))
+
+			floats := map[string]int{}
+			for _, t := range resp.NonCriticalTasks {
+				floats[t.TaskID] = t.FloatHours
+			}
+			Expect(floats).To(Equal(map[string]int{ui.String(): 16, docs.String(): 30}))
+		})
+
+		It("flags the critical tasks", func() {
+			tasks.tasks[docs].IsCriticalPath = true
+			_, err := service.GetCriticalPath(ctx, projectID.String(), orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			for id, critical := range map[uuid.UUID]bool{design: true, api: true, ui: false, checkout: true, docs: false} {
+				Expect(tasks.tasks[id].IsCriticalPath).To(Equal(critical))
+			}
+		})
+
+		DescribeTable("spaces tasks by dependency type and lag",
+			func(dependencyType models.DependencyType, dependsOn func() uuid.UUID, lag, duration, docsFloat int) {
+				deps.add(docs, dependsOn(), dependencyType)
+				deps.deps[len(deps.deps)-1].LagHours = lag
+
+				resp, err := service.GetCriticalPath(ctx, projectID.String(), orgID.String())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.ProjectDuration).To(Equal(duration))
+				floats := map[string]int{}
+				for _, t := range resp.NonCriticalTasks {
+					floats[t.TaskID] = t.FloatHours
+				}
+				if docsFloat == 0 {
+					Expect(resp.CriticalPath.TaskIDs).To(ContainElement(docs.String()))
+				} else {
+					Expect(floats[docs.String()]).To(Equal(docsFloat))
+				}
+			},
+			Entry("finish to start after checkout", models.DependencyFinishToStart, func() uuid.UUID { return checkout }, 0, 34, 0),
+			Entry("finish to start with lag", models.DependencyFinishToStart, func() uuid.UUID { return checkout }, 4, 38, 0),
+			Entry("start to start with API", models.DependencyStartToStart, func() uuid.UUID { return api }, 2, 32, 20),
+			Entry("finish to finish with checkout", models.DependencyFinishToFinish, func() uuid.UUID { return checkout }, 0, 32, 0),
+			Entry("start to finish with API", models.DependencyStartToFinish, func() uuid.UUID { return api }, 0, 32, 24),
+		)
+	})
+
+	Describe("GetDependencyGraph", func() {
+		It("lays tasks out in columns by dependency depth", func() {
+			resp, err := service.GetDependencyGraph(ctx, projectID.String(), orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Nodes).To(HaveLen(5))
+			Expect(resp.Edges).To(HaveLen(4))
+
+			x := map[string]int{}
+			for _, n := range resp.Nodes {
+				x[n.ID] = n.X
+			}
+			Expect(x[design.String()]).To(Equal(0))
+			Expect(x[ui.String()]).To(Equal(200))
+			Expect(x[checkout.String()]).To(Equal(400))
+		})
+
+		It("flags the critical path and clusters subtasks under their top-level task", func() {
+			tasks.tasks[api].ParentTaskID = &design
+			tasks.tasks[ui].ParentTaskID = &api
+
+			resp, err := service.GetDependencyGraph(ctx, projectID.String(), orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.ProjectName).To(Equal("Checkout"))
+			Expect(resp.Clusters).To(Equal([]dto.DependencyGraphCluster{{ID: design.String(), Title: "Design"}}))
+
+			nodes := map[string]dto.DependencyGraphNode{}
+			for _, n := range resp.Nodes {
+				nodes[n.ID] = n
+			}
+			Expect(nodes[ui.String()].ClusterID).To(Equal(design.String()))
+			Expect(nodes[design.String()].ClusterID).To(Equal(design.String()))
+			Expect(nodes[checkout.String()].ClusterID).To(BeEmpty())
+			Expect(nodes[checkout.String()].Critical).To(BeTrue())
+			Expect(nodes[ui.String()].Critical).To(BeFalse())
+
+			critical := map[[2]string]bool{}
+			for _, e := range resp.Edges {
+				critical[[2]string{e.Source, e.Target}] = e.Critical
+			}
+			Expect(critical).To(Equal(map[[2]string]bool{
+				{design.String(), api.String()}:   true,
+				{design.String(), ui.String()}:    false,
+				{api.String(), checkout.String()}: true,
+				{ui.String(), checkout.String()}:  false,
+			}))
+		})
+
+		Describe("rendered as diagram source", func() {
+			var graph *dto.DependencyGraphResponse
+
+			BeforeEach(func() {
+				tasks.tasks[api].ParentTaskID = &design
+				deps.deps[2].LagHours = 4 // checkout starts four hours after the API is done
+
+				var err error
+				graph, err = service.GetDependencyGraph(ctx, projectID.String(), orgID.String())
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("writes Graphviz DOT", func() {
+				out, contentType, err := services.RenderDependencyGraph(graph, services.GraphFormatDOT)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(contentType).To(HavePrefix("text/vnd.graphviz"))
+				Expect(out).To(HavePrefix(`digraph "Checkout" {`))
+				Expect(out).To(ContainSubstring(`subgraph "cluster_` + design.String() + `" {`))
+				Expect(out).To(ContainSubstring(`"` + api.String() + `" -> "` + checkout.String() + `" [label="FS +4h", color="#d62728"`))
+				Expect(out).To(ContainSubstring(`"` + ui.String() + `" -> "` + checkout.String() + `" [label="SS"];`))
+			})
+
+			It("writes a Mermaid flowchart", func() {
+				out, _, err := services.RenderDependencyGraph(graph, services.GraphFormatMermaid)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(out).To(ContainSubstring("flowchart LR\n"))
+				Expect(out).To(ContainSubstring(`subgraph c0["Design"]`))
+				Expect(out).To(ContainSubstring(`==>|"FS +4h"|`))
+				Expect(out).To(ContainSubstring(`-->|"SS"|`))
+				Expect(out).To(MatchRegexp(`class t\d+,t\d+,t\d+ critical`))
+				Expect(out).To(ContainSubstring("linkStyle "))
+			})
+
+			It("writes GraphML with each cluster as a nested graph", func() {
+				out, contentType, err := services.RenderDependencyGraph(graph, services.GraphFormatGraphML)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(contentType).To(HavePrefix("application/graphml+xml"))
+				Expect(xml.Unmarshal([]byte(out), &struct{}{})).To(Succeed())
+				Expect(out).To(ContainSubstring(`<graph id="` + design.String() + `:" edgedefault="directed">`))
+				Expect(out).To(ContainSubstring(`<data key="label">FS +4h</data>`))
+			})
+
+			It("rejects other formats", func() {
+				_, _, err := services.RenderDependencyGraph(graph, "svg")
+				Expect(err).To(MatchError(services.ErrUnsupportedGraphFormat))
+			})
+		})
+	})
+
+	Describe("Cross-project dependencies", func() {
+		var platformID, auth uuid.UUID
+
+		// The API waits on an auth service another team builds in the platform project,
+		// which makes auth -> api -> checkout the critical path at 64 hours
+		BeforeEach(func() {
+			platformID = uuid.New()
+			start := at(7, 0)
+			projects.projects[platformID] = &models.Project{
+				BaseModel: models.BaseModel{ID: platformID}, OrganizationID: orgID, Name: "Platform", Status: models.ProjectActive, StartDate: &start,
+			}
+			auth = tasks.add(models.Task{ProjectID: platformID, Title: "Auth service", Status: models.TaskStatusInProgress, EstimatedHours: 40})
+			deps.add(api, auth, models.DependencyFinishToStart)
+		})
+
+		It("runs the critical path through the other project", func() {
+			resp, err := service.GetCriticalPath(ctx, projectID.String(), orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.CriticalPath.TaskIDs).To(Equal([]string{auth.String(), api.String(), checkout.String()}))
+			Expect(resp.CriticalPath.Tasks[0].External).To(BeTrue())
+			Expect(resp.CriticalPath.Tasks[0].ProjectID).To(Equal(platformID.String()))
+			Expect(resp.ProjectDuration).To(Equal(64))
+			Expect(*resp.ProjectEndDate).To(Equal(at(16, 17)))
+
+			// Only this project's tasks are flagged
+			Expect(tasks.tasks[api].IsCriticalPath).To(BeTrue())
+			Expect(tasks.tasks[auth].IsCriticalPath).To(BeFalse())
+		})
+
+		It("shows dependencies and dependents across projects", func() {
+			resp, err := service.GetTaskDependencies(ctx, api.String(), false, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Dependencies.Direct).To(HaveLen(2))
+			external := resp.Dependencies.Direct[1]
+			Expect(external.DependsOnTaskID).To(Equal(auth.String()))
+			Expect(external.ProjectID).To(Equal(platformID.String()))
+			Expect(external.CrossProject).To(BeTrue())
+			Expect(external.IsBlocking).To(BeTrue())
+
+			resp, err = service.GetTaskDependencies(ctx, auth.String(), false, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Dependents.Direct).To(HaveLen(1))
+			Expect(resp.Dependents.Direct[0].TaskID).To(Equal(api.String()))
+			Expect(resp.Dependents.Direct[0].ProjectID).To(Equal(projectID.String()))
+			Expect(resp.Dependents.Direct[0].CrossProject).To(BeTrue())
+		})
+
+		It("finds cycles that run through other projects", func() {
+			mobileID := uuid.New()
+			projects.projects[mobileID] = &models.Project{BaseModel: models.BaseModel{ID: mobileID}, OrganizationID: orgID, Name: "Mobile"}
+			sdk := tasks.add(models.Task{ProjectID: mobileID, Title: "SDK", Status: models.TaskStatusReady, EstimatedHours: 8})
+			deps.add(sdk, checkout, models.DependencyFinishToStart)
+
+			resp, err := service.ValidateDependency(ctx, dto.ValidateDependencyRequest{
+				TaskID: auth.String(), DependsOnTaskID: sdk.String(), DependencyType: "finish_to_start",
+			}, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.WouldCreateCycle).To(BeTrue())
+			Expect(resp.Warnings[0].Message).To(ContainSubstring("Auth service -> SDK -> Checkout -> API -> Auth service"))
+		})
+
+		It("warns when a task would wait on another project, more so a paused one", func() {
+			request := dto.ValidateDependencyRequest{TaskID: docs.String(), DependsOnTaskID: auth.String(), DependencyType: "finish_to_start"}
+			resp, err := service.ValidateDependency(ctx, request, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Valid).To(BeTrue())
+			Expect(warningTypes(resp.Warnings)).To(ConsistOf("cross_project"))
+			Expect(resp.Warnings[0].Message).To(ContainSubstring("Platform"))
+
+			projects.projects[platformID].Status = models.ProjectPaused
+			resp, err = service.ValidateDependency(ctx, request, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warningTypes(resp.Warnings)).To(ConsistOf("cross_project", "project_paused"))
+		})
+
+		It("draws the portfolio as projects linked by the dependencies between them", func() {
+			resp, err := service.GetPortfolioGraph(ctx, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(resp.Links).To(HaveLen(1))
+			link := resp.Links[0]
+			Expect(link.Source).To(Equal(platformID.String()))
+			Expect(link.Target).To(Equal(projectID.String()))
+			Expect(link.Dependencies).To(Equal(1))
+			Expect(link.Blocking).To(Equal(1))
+			Expect(link.Critical).To(BeTrue())
+
+			summaries := map[string]dto.PortfolioProject{}
+			for _, p := range resp.Projects {
+				summaries[p.ProjectID] = p
+			}
+			Expect(summaries[projectID.String()].DependsOn).To(Equal(1))
+			Expect(summaries[projectID.String()].BlockedBy).To(Equal(1))
+			Expect(summaries[platformID.String()].DependedOnBy).To(Equal(1))
+			Expect(summaries[platformID.String()].Blocking).To(Equal(1))
+			Expect(summaries[platformID.String()].OnCriticalPath).To(BeTrue())
+
+			// Only the tasks either side of a cross-project dependency are drawn
+			Expect(resp.Nodes).To(HaveLen(2))
+			Expect(resp.Edges).To(HaveLen(1))
+			Expect(resp.Edges[0].CrossProject).To(BeTrue())
+			Expect(resp.CriticalPath.TaskIDs).To(Equal([]string{auth.String(), api.String(), checkout.String()}))
+			Expect(*resp.PortfolioEndDate).To(Equal(at(16, 17)))
+		})
+	})
+})
+
+// warningTypes lists the types of validation warnings
+func warningTypes(warnings []dto.DependencyWarning) []string {
+	var types []string
+	for _, w := range warnings {
+		types = append(types, w.Type)
+	}
+	return types
+}
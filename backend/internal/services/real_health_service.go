@@ -2,10 +2,12 @@ package services
 
 import (
 	"context"
+	"errors"
 	"log"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"github.com/SimpleAjax/Xephyr/internal/dto"
 	"github.com/SimpleAjax/Xephyr/internal/models"
@@ -111,13 +113,13 @@ func (s *RealHealthService) GetProjectHealth(ctx context.Context, projectID stri
 	}
 
 	// Get project details
-	project, err := s.repos.GetProject().GetByID(ctx, projUUID)
+	project, err := s.repos.GetProject().GetByID(ctx, orgUUID, projUUID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get tasks for this project
-	tasks, _, err := s.repos.GetTask().ListByProject(ctx, projUUID, repositories.ListParams{Limit: 1000})
+	tasks, _, err := s.repos.GetTask().ListByProject(ctx, orgUUID, projUUID, repositories.ListParams{Limit: 1000})
 	if err != nil {
 		tasks = []models.Task{}
 	}
@@ -125,7 +127,6 @@ func (s *RealHealthService) GetProjectHealth(ctx context.Context, projectID stri
 	// Calculate completion metrics
 	completedTasks := 0
 	inProgressTasks := 0
-	blockedTasks := 0
 	atRiskTasks := 0
 	for _, task := range tasks {
 		switch task.Status {
@@ -139,6 +140,13 @@ func (s *RealHealthService) GetProjectHealth(ctx context.Context, projectID stri
 		}
 	}
 
+	// Dependencies, including those on tasks of other projects
+	dependencies, err := s.dependencyDetails(ctx, orgUUID, projUUID, tasks)
+	if err != nil {
+		return nil, err
+	}
+	dependencies.AtRisk = atRiskTasks
+
 	// Get team members count
 	memberCount := len(project.Members)
 
@@ -178,11 +186,7 @@ func (s *RealHealthService) GetProjectHealth(ctx context.Context, projectID stri
 				InProgress:     inProgressTasks,
 				CompletionRate: completionRate,
 			},
-			Dependencies: dto.DependencyDetails{
-				Total:   totalTasks, // Simplified
-				Blocked: blockedTasks,
-				AtRisk:  atRiskTasks,
-			},
+			Dependencies: *dependencies,
 			Resources: dto.ResourceDetails{
 				TeamSize:      memberCount,
 				AvgAllocation: s.calculateAvgAllocation(workloadEntries),
@@ -202,7 +206,7 @@ func (s *RealHealthService) GetProjectHealth(ctx context.Context, projectID stri
 		resp.Breakdown = dto.HealthBreakdown{
 			ScheduleHealth:     s.calculateScheduleHealth(project),
 			CompletionHealth:   s.calculateCompletionHealth(completionRate),
-			DependencyHealth:   100 - (blockedTasks * 10), // Simplified
+			DependencyHealth:   s.calculateDependencyHealth(dependencies),
 			ResourceHealth:     100 - (overallocatedCount * 20),
 			CriticalPathHealth: project.HealthScore,
 		}
@@ -237,8 +241,12 @@ func (s *RealHealthService) GetHealthTrends(ctx context.Context, projectID strin
 	if err != nil {
 		return nil, err
 	}
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return nil, err
+	}
 
-	project, err := s.repos.GetProject().GetByID(ctx, projUUID)
+	project, err := s.repos.GetProject().GetByID(ctx, orgUUID, projUUID)
 	if err != nil {
 		return nil, err
 	}
@@ -372,3 +380,74 @@ func (s *RealHealthService) calculateScheduleHealth(project *models.Project) int
 func (s *RealHealthService) calculateCompletionHealth(completionRate int) int {
 	return completionRate
 }
+
+// dependencyDetails counts the project's dependencies and the unfinished tasks still
+// waiting on a predecessor, and lists the predecessors in other projects holding them up
+func (s *RealHealthService) dependencyDetails(ctx context.Context, orgID, projectID uuid.UUID, tasks []models.Task) (*dto.DependencyDetails, error) {
+	deps, err := s.repos.GetDependency().ListByProject(ctx, orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.Task, len(tasks))
+	for i := range tasks {
+		byID[tasks[i].ID] = &tasks[i]
+	}
+
+	details := &dto.DependencyDetails{Total: len(deps), ExternalBlockers: []dto.ExternalBlocker{}}
+	blocked := map[uuid.UUID]bool{}
+	now := time.Now()
+	for _, d := range deps {
+		predecessor, external := byID[d.DependsOnTaskID], false
+		if predecessor == nil {
+			predecessor, err = s.repos.GetTask().GetByID(ctx, orgID, d.DependsOnTaskID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			external = predecessor.ProjectID != projectID
+		}
+		if external {
+			details.External++
+		}
+
+		task := byID[d.TaskID]
+		if task == nil || task.Status == models.TaskStatusDone || task.ArchivedAt != nil || dependencySatisfied(d.DependencyType, predecessor) {
+			continue
+		}
+		blocked[task.ID] = true
+		if !external {
+			continue
+		}
+		details.ExternalBlocked++
+		details.ExternalBlockers = append(details.ExternalBlockers, dto.ExternalBlocker{
+			TaskID:             task.ID.String(),
+			TaskTitle:          task.Title,
+			BlockedByTaskID:    predecessor.ID.String(),
+			BlockedByTitle:     predecessor.Title,
+			BlockedByProjectID: predecessor.ProjectID.String(),
+			DependencyType:     string(d.DependencyType),
+			DueDate:            predecessor.DueDate,
+			Overdue:            predecessor.DueDate != nil && predecessor.DueDate.Before(now),
+		})
+	}
+	details.Blocked = len(blocked)
+	return details, nil
+}
+
+// calculateDependencyHealth takes points off for every blocked task, more for each
+// dependency on another project still waiting, since the team cannot clear it itself,
+// and more again when that other project's task is overdue
+func (s *RealHealthService) calculateDependencyHealth(details *dto.DependencyDetails) int {
+	score := 100 - details.Blocked*10 - details.ExternalBlocked*10
+	for _, b := range details.ExternalBlockers {
+		if b.Overdue {
+			score -= 10
+		}
+	}
+	if score < 0 {
+		return 0
+	}
+	return score
+}
@@ -0,0 +1,87 @@
+package services_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// healthWorkloadRepo has no workload entries
+type healthWorkloadRepo struct {
+	repositories.WorkloadRepository
+}
+
+func (r *healthWorkloadRepo) ListByOrganization(ctx context.Context, orgID uuid.UUID, weekStart time.Time) ([]models.WorkloadEntry, error) {
+	return nil, nil
+}
+
+var _ = Describe("Real Health Service", func() {
+	var (
+		ctx       context.Context
+		service   services.HealthService
+		orgID     uuid.UUID
+		projectID uuid.UUID
+
+		api, checkout, auth uuid.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		orgID, projectID = uuid.New(), uuid.New()
+		platformID := uuid.New()
+
+		// Checkout waits on the API, which waits on an overdue auth service in the
+		// platform project; the finished docs no longer wait on anything
+		overdue := time.Now().AddDate(0, 0, -2)
+		tasks := &hierarchyTaskRepo{tasks: map[uuid.UUID]*models.Task{}}
+		api = tasks.add(models.Task{ProjectID: projectID, Title: "API", Status: models.TaskStatusReady, EstimatedHours: 16})
+		checkout = tasks.add(models.Task{ProjectID: projectID, Title: "Checkout", Status: models.TaskStatusBacklog, EstimatedHours: 8})
+		docs := tasks.add(models.Task{ProjectID: projectID, Title: "Docs", Status: models.TaskStatusDone, EstimatedHours: 2})
+		auth = tasks.add(models.Task{ProjectID: platformID, Title: "Auth service", Status: models.TaskStatusInProgress, DueDate: &overdue})
+
+		deps := &graphDependencyRepo{tasks: tasks}
+		deps.add(api, auth, models.DependencyFinishToStart)
+		deps.add(checkout, api, models.DependencyFinishToStart)
+		deps.add(docs, checkout, models.DependencyFinishToStart)
+
+		service = services.NewRealHealthService(&repositories.Provider{
+			Project: &lifecycleProjectRepo{
+				projects: map[uuid.UUID]*models.Project{projectID: {BaseModel: models.BaseModel{ID: projectID}, OrganizationID: orgID, Name: "Checkout"}},
+				deleted:  map[uuid.UUID]bool{},
+			},
+			Task:       tasks,
+			Dependency: deps,
+			Workload:   &healthWorkloadRepo{},
+		})
+	})
+
+	Describe("GetProjectHealth", func() {
+		It("lists the tasks of other projects holding this one up", func() {
+			resp, err := service.GetProjectHealth(ctx, projectID.String(), true, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+
+			details := resp.Details.Dependencies
+			Expect(details.Total).To(Equal(3))
+			Expect(details.Blocked).To(Equal(2))
+			Expect(details.External).To(Equal(1))
+			Expect(details.ExternalBlocked).To(Equal(1))
+			Expect(details.ExternalBlockers).To(HaveLen(1))
+
+			blocker := details.ExternalBlockers[0]
+			Expect(blocker.TaskID).To(Equal(api.String()))
+			Expect(blocker.BlockedByTaskID).To(Equal(auth.String()))
+			Expect(blocker.BlockedByTitle).To(Equal("Auth service"))
+			Expect(blocker.Overdue).To(BeTrue())
+
+			// Two blocked tasks, one waiting on another project, which is overdue
+			Expect(resp.Breakdown.DependencyHealth).To(Equal(60))
+		})
+	})
+})
@@ -2,23 +2,55 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/jobs"
 	"github.com/SimpleAjax/Xephyr/internal/models"
 	"github.com/SimpleAjax/Xephyr/internal/repositories"
 )
 
+const (
+	// JobTypeNudgeGeneration is the job type for asynchronous nudge generation
+	JobTypeNudgeGeneration = "nudge_generation"
+	// JobTypeNudgeEscalationNotice is the job type that tells escalation recipients
+	// about a nudge once the escalation has committed
+	JobTypeNudgeEscalationNotice = "nudge_escalation_notice"
+)
+
+// ErrInvalidNudgeGeneration is returned for unknown nudge types or a project scope
+// without a valid project ID
+var ErrInvalidNudgeGeneration = errors.New("invalid nudge generation request")
+
 // RealNudgeService implements NudgeService using database queries
 type RealNudgeService struct {
-	repos *repositories.Provider
+	repos    *repositories.Provider
+	queue    *jobs.Queue
+	notifier NotificationDispatcher
+}
+
+// escalationNotice is the payload of a nudge escalation notice job
+type escalationNotice struct {
+	NudgeID uuid.UUID `json:"nudgeId"`
+	UserIDs []string  `json:"userIds"`
 }
 
-// NewRealNudgeService creates a new real nudge service
-func NewRealNudgeService(repos *repositories.Provider) NudgeService {
-	return &RealNudgeService{repos: repos}
+// NewRealNudgeService creates a new real nudge service and registers its job handlers.
+// New and escalated nudges are sent through the notifier when one is given.
+func NewRealNudgeService(repos *repositories.Provider, queue *jobs.Queue, notifier NotificationDispatcher) NudgeService {
+	s := &RealNudgeService{repos: repos, queue: queue, notifier: notifier}
+	queue.Register(JobTypeNudgeGeneration, s.handleGenerationJob)
+	if notifier != nil {
+		queue.Register(JobTypeNudgeEscalationNotice, s.handleEscalationNoticeJob)
+	}
+	return s
 }
 
 // ListNudges returns nudges from database
@@ -112,13 +144,20 @@ func (s *RealNudgeService) ListNudges(ctx context.Context, params dto.NudgeListQ
 
 // GetNudge returns a single nudge
 func (s *RealNudgeService) GetNudge(ctx context.Context, nudgeID string, orgID string) (*dto.NudgeDetailResponse, error) {
-	nudgeUUID, err := uuid.Parse(nudgeID)
+	orgUUID, err := uuid.Parse(orgID)
 	if err != nil {
 		return nil, err
 	}
+	nudgeUUID, err := uuid.Parse(nudgeID)
+	if err != nil {
+		return nil, ErrNudgeNotFound
+	}
 
-	nudge, err := s.repos.GetNudge().GetByID(ctx, nudgeUUID)
+	nudge, err := s.repos.GetNudge().GetByID(ctx, orgUUID, nudgeUUID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNudgeNotFound
+		}
 		return nil, err
 	}
 
@@ -129,6 +168,7 @@ func (s *RealNudgeService) GetNudge(ctx context.Context, nudgeID string, orgID s
 			Action:    action.ActionType,
 			Timestamp: action.CreatedAt,
 			UserID:    action.UserID,
+			Details:   action.Parameters,
 		})
 	}
 
@@ -138,57 +178,149 @@ func (s *RealNudgeService) GetNudge(ctx context.Context, nudgeID string, orgID s
 	}, nil
 }
 
-// TakeNudgeAction processes an action on a nudge
+// TakeNudgeAction executes an action on a nudge and records it in the nudge's history
 func (s *RealNudgeService) TakeNudgeAction(ctx context.Context, nudgeID string, req dto.NudgeActionRequest, orgID string, userID uuid.UUID) (*dto.NudgeActionResponse, error) {
-	nudgeUUID, err := uuid.Parse(nudgeID)
+	handler, ok := nudgeActionHandlers[req.ActionType]
+	if !ok {
+		return nil, invalidAction("unknown action type %q", req.ActionType)
+	}
+
+	orgUUID, err := uuid.Parse(orgID)
 	if err != nil {
 		return nil, err
 	}
+	nudgeUUID, err := uuid.Parse(nudgeID)
+	if err != nil {
+		return nil, ErrNudgeNotFound
+	}
 
-	// Get current nudge
-	nudge, err := s.repos.GetNudge().GetByID(ctx, nudgeUUID)
+	nudge, err := s.repos.GetNudge().GetByID(ctx, orgUUID, nudgeUUID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNudgeNotFound
+		}
 		return nil, err
 	}
+	switch nudge.Status {
+	case models.NudgeStatusActed, models.NudgeStatusDismissed, models.NudgeStatusResolved:
+		return nil, invalidAction("nudge is already %s", nudge.Status)
+	}
 
-	// Update status based on action
-	newStatus := models.NudgeStatusActed
-	if req.ActionType == "dismiss" {
-		newStatus = models.NudgeStatusDismissed
-	} else if req.ActionType == "read" {
-		newStatus = models.NudgeStatusRead
+	params := req.Parameters
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	act := &NudgeActionContext{
+		Nudge:  nudge,
+		OrgID:  orgUUID,
+		UserID: userID,
+		Params: params,
+		Now:    time.Now().UTC(),
 	}
 
-	nudge.Status = newStatus
-	if err := s.repos.GetNudge().Update(ctx, nudge); err != nil {
+	var actor *uuid.UUID
+	if userID != uuid.Nil {
+		actor = &userID
+	}
+	// The escalation notice is queued in the action's transaction, so it goes out only
+	// once the escalation has committed
+	var result *dto.NudgeActionResult
+	err = s.repos.InOrganization(ctx, orgUUID, func(ctx context.Context) error {
+		var err error
+		result, err = applyNudgeAction(ctx, s.repos, req.ActionType, handler, act, actor)
+		if err != nil || len(result.EscalatedTo) == 0 || s.notifier == nil {
+			return err
+		}
+		notice := escalationNotice{NudgeID: nudge.ID, UserIDs: result.EscalatedTo}
+		_, err = s.queue.Enqueue(ctx, orgUUID, JobTypeNudgeEscalationNotice, notice)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
+	refreshProjects(ctx, s.repos, act.OrgID, act.projects...)
 
 	return &dto.NudgeActionResponse{
-		NudgeID:     nudgeID,
-		ActionTaken: req.ActionType,
-		Result: dto.NudgeActionResult{
-			TaskReassigned: req.ActionType == "reassign",
-		},
-		NudgeStatus: string(newStatus),
-		CompletedAt: time.Now().UTC(),
+		NudgeID:        nudgeID,
+		ActionTaken:    req.ActionType,
+		Result:         *result,
+		NudgeStatus:    string(nudge.Status),
+		FollowUpNudges: []string{},
+		CompletedAt:    act.Now,
 	}, nil
 }
 
+// refreshProjects recalculates the stored progress and critical path of projects whose
+// tasks or dependencies changed. The change has already succeeded, so a failure is
+// logged rather than returned.
+func refreshProjects(ctx context.Context, repos *repositories.Provider, orgID uuid.UUID, projectIDs ...uuid.UUID) {
+	for i, projectID := range projectIDs {
+		if slices.Contains(projectIDs[:i], projectID) {
+			continue
+		}
+		if _, err := recalculateProjectProgress(ctx, repos, orgID, projectID); err != nil {
+			log.Printf("[NudgeService] Failed to recalculate progress for project %s: %v", projectID, err)
+		}
+	}
+	(&RealDependencyService{repos: repos, now: time.Now}).refreshCriticalPath(ctx, orgID, projectIDs...)
+}
+
+// applyNudgeAction runs a handler and persists the nudge, its audit row and the history of
+// the tasks it changed in one transaction. A nil actor records a system action.
+func applyNudgeAction(ctx context.Context, repos *repositories.Provider, actionType string, handler NudgeActionHandler, act *NudgeActionContext, actor *uuid.UUID) (*dto.NudgeActionResult, error) {
+	var result *dto.NudgeActionResult
+	err := repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		var err error
+		result, err = handler(ctx, tx, act)
+		if err != nil {
+			return err
+		}
+		for _, change := range act.changes {
+			if err := recordTaskChanges(ctx, tx, act.OrgID, act.UserID, change.before, change.after, act.Now); err != nil {
+				return err
+			}
+		}
+		if err := tx.GetNudge().Update(ctx, act.OrgID, act.Nudge); err != nil {
+			return err
+		}
+
+		audit := models.JSONB{}
+		for k, v := range act.Params {
+			audit[k] = v
+		}
+		audit["summary"] = result.Summary
+		audit["changes"] = result.Changes
+		return tx.GetNudge().CreateAction(ctx, &models.NudgeAction{
+			NudgeID:    act.Nudge.ID,
+			UserID:     actor,
+			ActionType: actionType,
+			Parameters: audit,
+		})
+	})
+	return result, err
+}
+
 // UpdateNudgeStatus updates nudge status
 func (s *RealNudgeService) UpdateNudgeStatus(ctx context.Context, nudgeID string, status string, orgID string) (*dto.NudgeResponse, error) {
-	nudgeUUID, err := uuid.Parse(nudgeID)
+	orgUUID, err := uuid.Parse(orgID)
 	if err != nil {
 		return nil, err
 	}
+	nudgeUUID, err := uuid.Parse(nudgeID)
+	if err != nil {
+		return nil, ErrNudgeNotFound
+	}
 
 	nudgeStatus := models.NudgeStatus(status)
-	if err := s.repos.GetNudge().UpdateStatus(ctx, nudgeUUID, nudgeStatus); err != nil {
+	if err := s.repos.GetNudge().UpdateStatus(ctx, orgUUID, nudgeUUID, nudgeStatus); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNudgeNotFound
+		}
 		return nil, err
 	}
 
 	// Fetch updated nudge
-	nudge, err := s.repos.GetNudge().GetByID(ctx, nudgeUUID)
+	nudge, err := s.repos.GetNudge().GetByID(ctx, orgUUID, nudgeUUID)
 	if err != nil {
 		return nil, err
 	}
@@ -198,10 +330,247 @@ func (s *RealNudgeService) UpdateNudgeStatus(ctx context.Context, nudgeID string
 }
 
 // GenerateNudges triggers manual nudge generation
-func (s *RealNudgeService) GenerateNudges(ctx context.Context, req dto.GenerateNudgesRequest, orgID string) (string, error) {
-	// This would trigger async nudge generation
-	// For now, return a job ID
-	return "job_nudge_gen_" + uuid.New().String()[:8], nil
+func (s *RealNudgeService) GenerateNudges(ctx context.Context, req dto.GenerateNudgesRequest, orgID string) (*dto.GenerateNudgesResponse, error) {
+	if req.Async {
+		orgUUID, err := uuid.Parse(orgID)
+		if err != nil {
+			return nil, err
+		}
+		req.Async = false
+		job, err := s.queue.Enqueue(ctx, orgUUID, JobTypeNudgeGeneration, req)
+		if err != nil {
+			return nil, err
+		}
+		return &dto.GenerateNudgesResponse{
+			JobID:    job.ID.String(),
+			Status:   string(job.Status),
+			NudgeIDs: []string{},
+		}, nil
+	}
+
+	return s.generate(ctx, req, orgID, func(int) {})
+}
+
+// handleGenerationJob runs a queued nudge generation. Invalid requests and projects that
+// no longer exist fail the job without retrying.
+func (s *RealNudgeService) handleGenerationJob(ctx context.Context, job *models.Job, report jobs.ProgressFunc) (models.JSONB, error) {
+	var req dto.GenerateNudgesRequest
+	if err := jobs.DecodePayload(job, &req); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.generate(ctx, req, job.OrganizationID.String(), report)
+	if errors.Is(err, ErrInvalidNudgeGeneration) || errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, jobs.Permanent(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp.JobID = job.ID.String()
+	return jobs.EncodePayload(resp)
+}
+
+// handleEscalationNoticeJob tells the recipients of a committed escalation about the nudge
+func (s *RealNudgeService) handleEscalationNoticeJob(ctx context.Context, job *models.Job, report jobs.ProgressFunc) (models.JSONB, error) {
+	var notice escalationNotice
+	if err := jobs.DecodePayload(job, &notice); err != nil {
+		return nil, err
+	}
+	nudge, err := s.repos.GetNudge().GetByID(ctx, job.OrganizationID, notice.NudgeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, jobs.Permanent(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	notifyEscalation(ctx, s.notifier, nudge, notice.UserIDs)
+	return nil, nil
+}
+
+// generate evaluates the nudge rules for the requested scope and stores the resulting nudges
+func (s *RealNudgeService) generate(ctx context.Context, req dto.GenerateNudgesRequest, orgID string, report jobs.ProgressFunc) (*dto.GenerateNudgesResponse, error) {
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := ParseNudgeTypes(req.Types)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNudgeGeneration, err)
+	}
+
+	snap, err := s.buildSnapshot(ctx, req, orgUUID)
+	if err != nil {
+		return nil, err
+	}
+	report(50)
+
+	open, err := s.repos.GetNudge().ListOpen(ctx, orgUUID, types)
+	if err != nil {
+		return nil, err
+	}
+	inScope := func(n models.Nudge) bool { return true }
+	if req.Scope == "project" {
+		projectID := snap.Projects[0].ID
+		inScope = func(n models.Nudge) bool {
+			return n.RelatedProjectID != nil && *n.RelatedProjectID == projectID
+		}
+	}
+
+	detected := EvaluateNudgeRules(snap, types)
+	plan := ReconcileNudges(open, detected, inScope, snap.Now)
+	report(75)
+
+	nudgeIDs := make([]string, 0, len(plan.Create)+len(plan.Refresh))
+	for i := range plan.Create {
+		if err := s.repos.GetNudge().Create(ctx, orgUUID, &plan.Create[i]); err != nil {
+			return nil, err
+		}
+		nudgeIDs = append(nudgeIDs, plan.Create[i].ID.String())
+		s.notifyNew(ctx, &plan.Create[i])
+	}
+	for i := range plan.Refresh {
+		if err := s.repos.GetNudge().Update(ctx, orgUUID, &plan.Refresh[i]); err != nil {
+			return nil, err
+		}
+		nudgeIDs = append(nudgeIDs, plan.Refresh[i].ID.String())
+	}
+	for _, n := range plan.Resolve {
+		if err := s.resolveNudge(ctx, n, "condition_cleared"); err != nil {
+			return nil, err
+		}
+	}
+	for _, n := range plan.Superseded {
+		if err := s.resolveNudge(ctx, n, "duplicate"); err != nil {
+			return nil, err
+		}
+	}
+	report(100)
+
+	return &dto.GenerateNudgesResponse{
+		Status:    string(models.JobStatusSucceeded),
+		Generated: len(plan.Create),
+		Refreshed: len(plan.Refresh),
+		Resolved:  len(plan.Resolve),
+		NudgeIDs:  nudgeIDs,
+	}, nil
+}
+
+// notifyNew sends a newly created nudge to the people it concerns. Delivery problems
+// are recorded by the dispatcher and never fail generation.
+func (s *RealNudgeService) notifyNew(ctx context.Context, nudge *models.Nudge) {
+	if s.notifier == nil {
+		return
+	}
+	recipients, err := nudgeRecipients(ctx, s.repos, nudge)
+	if err == nil {
+		_, err = s.notifier.NotifyNudge(ctx, nudge, recipients)
+	}
+	if err != nil {
+		log.Printf("[NudgeService] Error notifying about nudge %s: %v", nudge.ID, err)
+	}
+}
+
+// resolveNudge auto-resolves an open nudge and records why in its action history
+func (s *RealNudgeService) resolveNudge(ctx context.Context, n models.Nudge, reason string) error {
+	action := &models.NudgeAction{
+		ActionType: "auto_resolved",
+		Parameters: models.JSONB{
+			"reason":      reason,
+			"fingerprint": n.Fingerprint,
+		},
+	}
+	if err := s.repos.GetNudge().Resolve(ctx, n.OrganizationID, n.ID, action); err != nil {
+		return fmt.Errorf("failed to resolve nudge %s: %w", n.ID, err)
+	}
+	return nil
+}
+
+// buildSnapshot loads the projects, tasks, dependencies, skills and workload the nudge rules need
+func (s *RealNudgeService) buildSnapshot(ctx context.Context, req dto.GenerateNudgesRequest, orgUUID uuid.UUID) (*NudgeSnapshot, error) {
+	snap := &NudgeSnapshot{
+		Now:   time.Now().UTC(),
+		Users: make(map[uuid.UUID]models.User),
+	}
+
+	if req.Scope == "project" {
+		if req.ProjectID == nil {
+			return nil, fmt.Errorf("%w: projectId is required for project scope", ErrInvalidNudgeGeneration)
+		}
+		projUUID, err := uuid.Parse(*req.ProjectID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid projectId %q", ErrInvalidNudgeGeneration, *req.ProjectID)
+		}
+		project, err := s.repos.GetProject().GetByID(ctx, orgUUID, projUUID)
+		if err != nil {
+			return nil, err
+		}
+		snap.Projects = []models.Project{*project}
+	} else {
+		projects, err := listOrganizationProjects(ctx, s.repos, orgUUID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range projects {
+			if p.Status == models.ProjectActive || p.Status == models.ProjectPaused {
+				snap.Projects = append(snap.Projects, p)
+			}
+		}
+	}
+
+	assignees := make(map[uuid.UUID]bool)
+	for _, p := range snap.Projects {
+		tasks, err := listProjectTasks(ctx, s.repos, orgUUID, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			if t.AssigneeID != nil {
+				assignees[*t.AssigneeID] = true
+			}
+		}
+		snap.Tasks = append(snap.Tasks, tasks...)
+
+		deps, err := s.repos.GetDependency().ListByProject(ctx, orgUUID, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		snap.Dependencies = append(snap.Dependencies, deps...)
+
+		skills, err := s.repos.GetTask().ListSkillsByProject(ctx, orgUUID, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		snap.TaskSkills = append(snap.TaskSkills, skills...)
+	}
+
+	userSkills, err := s.repos.GetUser().ListSkillsByOrganization(ctx, orgUUID)
+	if err != nil {
+		return nil, err
+	}
+	snap.UserSkills = userSkills
+
+	users, err := listOrganizationUsers(ctx, s.repos, orgUUID)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		snap.Users[u.ID] = u
+	}
+
+	workloads, err := s.repos.GetWorkload().ListByOrganization(ctx, orgUUID, getCurrentWeekStart())
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range workloads {
+		// Project scope only reports on people working in that project
+		if req.Scope == "project" && !assignees[w.UserID] {
+			continue
+		}
+		snap.Workloads = append(snap.Workloads, w)
+	}
+
+	return snap, nil
 }
 
 // GetNudgeStats returns nudge statistics
@@ -245,9 +614,10 @@ func (s *RealNudgeService) GetNudgeStats(ctx context.Context, period string, org
 		Generated:       int(stats.Total),
 		Acted:           int(stats.Acted),
 		Dismissed:       int(stats.Dismissed),
-		Expired:         0,
+		AutoResolved:    int(stats.Resolved),
+		Expired:         int(stats.Expired),
 		ActionRate:      actionRate,
-		AvgTimeToAction: "4.2h",
+		AvgTimeToAction: fmt.Sprintf("%.1fh", stats.AvgTimeToAction.Hours()),
 		ByType:          byType,
 	}, nil
 }
@@ -0,0 +1,894 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/jobs"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+const (
+	// priorityConfigSettingsKey is the Organization.Settings key holding the priority configuration
+	priorityConfigSettingsKey = "priority"
+
+	// JobTypePriorityRecalculation is the job type for asynchronous priority recalculation
+	JobTypePriorityRecalculation = "priority_recalculation"
+)
+
+var (
+	// ErrTaskNotInOrganization is returned when a task or project belongs to another organization
+	ErrTaskNotInOrganization = errors.New("task not found in organization")
+
+	// ErrInvalidPriorityConfig is returned when a priority configuration fails validation
+	ErrInvalidPriorityConfig = errors.New("invalid priority config")
+
+	// ErrInvalidRecalculation is returned for a recalculation scope missing what it needs
+	ErrInvalidRecalculation = errors.New("invalid recalculation request")
+)
+
+// DefaultPriorityConfig returns the configuration used by organizations that have not customised scoring
+func DefaultPriorityConfig() dto.PriorityConfig {
+	return dto.PriorityConfig{
+		Weights: dto.PriorityWeights{
+			ProjectPriority:    25,
+			BusinessValue:      25,
+			DeadlineUrgency:    25,
+			CriticalPathWeight: 15,
+			DependencyImpact:   10,
+		},
+		UrgencyCurve: []dto.UrgencyThreshold{
+			{MaxDays: 0, Urgency: 95},
+			{MaxDays: 1, Urgency: 90},
+			{MaxDays: 3, Urgency: 80},
+			{MaxDays: 7, Urgency: 65},
+			{MaxDays: 14, Urgency: 45},
+			{MaxDays: 30, Urgency: 25},
+		},
+		OverdueUrgency:          100,
+		DistantUrgency:          10,
+		NoDueDateUrgency:        50,
+		DependencyImpactPerTask: 15,
+	}
+}
+
+// ValidatePriorityConfig checks that weights sum to 100 and the urgency curve is well formed
+func ValidatePriorityConfig(cfg dto.PriorityConfig) error {
+	w := cfg.Weights
+	for name, v := range map[string]int{
+		"projectPriority":    w.ProjectPriority,
+		"businessValue":      w.BusinessValue,
+		"deadlineUrgency":    w.DeadlineUrgency,
+		"criticalPathWeight": w.CriticalPathWeight,
+		"dependencyImpact":   w.DependencyImpact,
+	} {
+		if v < 0 || v > 100 {
+			return fmt.Errorf("%w: weight %s must be between 0 and 100", ErrInvalidPriorityConfig, name)
+		}
+	}
+	if sum := w.ProjectPriority + w.BusinessValue + w.DeadlineUrgency + w.CriticalPathWeight + w.DependencyImpact; sum != 100 {
+		return fmt.Errorf("%w: weights must sum to 100, got %d", ErrInvalidPriorityConfig, sum)
+	}
+
+	if len(cfg.UrgencyCurve) == 0 {
+		return fmt.Errorf("%w: urgency curve must have at least one threshold", ErrInvalidPriorityConfig)
+	}
+	for i, t := range cfg.UrgencyCurve {
+		if t.MaxDays < 0 {
+			return fmt.Errorf("%w: urgency threshold %d has negative maxDays", ErrInvalidPriorityConfig, i)
+		}
+		if t.Urgency < 0 || t.Urgency > 100 {
+			return fmt.Errorf("%w: urgency threshold %d must be between 0 and 100", ErrInvalidPriorityConfig, i)
+		}
+		if i > 0 {
+			prev := cfg.UrgencyCurve[i-1]
+			if t.MaxDays <= prev.MaxDays {
+				return fmt.Errorf("%w: urgency thresholds must be in ascending maxDays order", ErrInvalidPriorityConfig)
+			}
+			if t.Urgency > prev.Urgency {
+				return fmt.Errorf("%w: urgency must not increase as the due date gets further away", ErrInvalidPriorityConfig)
+			}
+		}
+	}
+
+	for name, v := range map[string]int{
+		"overdueUrgency":   cfg.OverdueUrgency,
+		"distantUrgency":   cfg.DistantUrgency,
+		"noDueDateUrgency": cfg.NoDueDateUrgency,
+	} {
+		if v < 0 || v > 100 {
+			return fmt.Errorf("%w: %s must be between 0 and 100", ErrInvalidPriorityConfig, name)
+		}
+	}
+	if cfg.DependencyImpactPerTask < 0 || cfg.DependencyImpactPerTask > 100 {
+		return fmt.Errorf("%w: dependencyImpactPerTask must be between 0 and 100", ErrInvalidPriorityConfig)
+	}
+	return nil
+}
+
+// PriorityInputs holds the raw data a task's priority score is derived from
+type PriorityInputs struct {
+	ProjectPriority int
+	BusinessValue   int
+	DueDate         *time.Time
+	IsCriticalPath  bool
+	DependentCount  int
+}
+
+// PriorityResult holds a computed priority score with its breakdown and factors
+type PriorityResult struct {
+	Score     int
+	Breakdown dto.PriorityBreakdown
+	Factors   dto.PriorityFactors
+}
+
+// ComputePriority calculates the universal priority score for a task. Each
+// component is a 0-100 factor scaled by its configured weight.
+func ComputePriority(in PriorityInputs, cfg dto.PriorityConfig, now time.Time) PriorityResult {
+	urgency, daysUntilDue := deadlineUrgency(in.DueDate, cfg, now)
+
+	criticalFactor := 0
+	if in.IsCriticalPath {
+		criticalFactor = 100
+	}
+	dependencyFactor := clampScore(in.DependentCount * cfg.DependencyImpactPerTask)
+
+	breakdown := dto.PriorityBreakdown{
+		ProjectPriority:    weighted(clampScore(in.ProjectPriority), cfg.Weights.ProjectPriority),
+		BusinessValue:      weighted(clampScore(in.BusinessValue), cfg.Weights.BusinessValue),
+		DeadlineUrgency:    weighted(urgency, cfg.Weights.DeadlineUrgency),
+		CriticalPathWeight: weighted(criticalFactor, cfg.Weights.CriticalPathWeight),
+		DependencyImpact:   weighted(dependencyFactor, cfg.Weights.DependencyImpact),
+	}
+
+	score := breakdown.ProjectPriority + breakdown.BusinessValue + breakdown.DeadlineUrgency +
+		breakdown.CriticalPathWeight + breakdown.DependencyImpact
+
+	return PriorityResult{
+		Score:     clampScore(score),
+		Breakdown: breakdown,
+		Factors: dto.PriorityFactors{
+			ProjectPriority:   in.ProjectPriority,
+			BusinessValue:     in.BusinessValue,
+			DeadlineUrgency:   urgency,
+			IsOnCriticalPath:  in.IsCriticalPath,
+			BlockedTasksCount: in.DependentCount,
+			DaysUntilDue:      daysUntilDue,
+		},
+	}
+}
+
+// deadlineUrgency maps a due date to a 0-100 urgency factor and the whole days remaining
+func deadlineUrgency(dueDate *time.Time, cfg dto.PriorityConfig, now time.Time) (int, int) {
+	if dueDate == nil {
+		return cfg.NoDueDateUrgency, 0
+	}
+
+	remaining := dueDate.Sub(now)
+	if remaining < 0 {
+		return cfg.OverdueUrgency, int(math.Floor(remaining.Hours() / 24))
+	}
+
+	days := int(remaining.Hours() / 24)
+	for _, t := range cfg.UrgencyCurve {
+		if days <= t.MaxDays {
+			return t.Urgency, days
+		}
+	}
+	return cfg.DistantUrgency, days
+}
+
+func weighted(factor, weight int) int {
+	return int(math.Round(float64(factor) * float64(weight) / 100))
+}
+
+func clampScore(v int) int {
+	if v < 0 {
+		return 0
+	}
+	if v > 100 {
+		return 100
+	}
+	return v
+}
+
+// RealPriorityService implements PriorityService using database queries
+type RealPriorityService struct {
+	repos *repositories.Provider
+	queue *jobs.Queue
+}
+
+// NewRealPriorityService creates a new real priority service and registers its job handler
+func NewRealPriorityService(repos *repositories.Provider, queue *jobs.Queue) PriorityService {
+	s := &RealPriorityService{repos: repos, queue: queue}
+	queue.Register(JobTypePriorityRecalculation, s.handleRecalculationJob)
+	return s
+}
+
+// scoredTask pairs a task with its computed priority
+type scoredTask struct {
+	task   models.Task
+	result PriorityResult
+	rank   int
+}
+
+// GetTaskPriority calculates and persists the priority for a single task
+func (s *RealPriorityService) GetTaskPriority(ctx context.Context, taskID string, orgID string) (*dto.TaskPriorityResponse, error) {
+	task, project, err := s.loadTask(ctx, taskID, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := s.loadConfig(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked, err := s.scoreProject(ctx, project, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// The score is the one the task is ranked by. Tasks the project listing leaves out
+	// are scored on their own, counting dependents the same way.
+	now := time.Now().UTC()
+	var result PriorityResult
+	rank := 0
+	for _, st := range ranked {
+		if st.task.ID == task.ID {
+			result, rank = st.result, st.rank
+			break
+		}
+	}
+	if rank == 0 {
+		dependents, err := s.repos.GetDependency().GetDependentCount(ctx, project.OrganizationID, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		result = ComputePriority(priorityInputs(task, project, int(dependents)), cfg, now)
+	}
+
+	if result.Score != task.PriorityScore {
+		if err := s.repos.GetTask().UpdatePriorityScore(ctx, project.OrganizationID, task.ID, result.Score); err != nil {
+			return nil, err
+		}
+	}
+
+	return &dto.TaskPriorityResponse{
+		TaskID:                 task.ID.String(),
+		UniversalPriorityScore: result.Score,
+		Rank:                   rank,
+		Breakdown:              result.Breakdown,
+		Factors:                result.Factors,
+		CalculatedAt:           now,
+	}, nil
+}
+
+// GetBulkTaskPriorities calculates priorities for a set of tasks and ranks them against each other
+func (s *RealPriorityService) GetBulkTaskPriorities(ctx context.Context, req dto.BulkPriorityRequest, orgID string) (*dto.BulkPriorityResponse, error) {
+	cfg, _, err := s.loadConfig(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	projects := make(map[uuid.UUID]*models.Project)
+	now := time.Now().UTC()
+	scored := make([]scoredTask, 0, len(req.TaskIds))
+
+	for _, id := range req.TaskIds {
+		task, project, err := s.loadTaskWithCache(ctx, id, orgID, projects)
+		if err != nil {
+			return nil, fmt.Errorf("task %s: %w", id, err)
+		}
+
+		dependents, err := s.repos.GetDependency().GetDependentCount(ctx, project.OrganizationID, task.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		result := ComputePriority(priorityInputs(task, project, int(dependents)), cfg, now)
+		if result.Score != task.PriorityScore {
+			if err := s.repos.GetTask().UpdatePriorityScore(ctx, project.OrganizationID, task.ID, result.Score); err != nil {
+				return nil, err
+			}
+		}
+		scored = append(scored, scoredTask{task: *task, result: result})
+	}
+
+	rankScoredTasks(scored)
+
+	priorities := make([]dto.TaskPrioritySummary, 0, len(scored))
+	sortedOrder := make([]string, 0, len(scored))
+	for _, st := range scored {
+		priorities = append(priorities, dto.TaskPrioritySummary{
+			TaskID:                 st.task.ID.String(),
+			UniversalPriorityScore: st.result.Score,
+			Rank:                   st.rank,
+		})
+		sortedOrder = append(sortedOrder, st.task.ID.String())
+	}
+
+	return &dto.BulkPriorityResponse{
+		Priorities:  priorities,
+		SortedOrder: sortedOrder,
+	}, nil
+}
+
+// GetProjectTaskRanking returns the tasks of a project ordered by priority
+func (s *RealPriorityService) GetProjectTaskRanking(ctx context.Context, projectID string, params dto.ProjectRankingQueryParams, orgID string) (*dto.ProjectTaskRankingResponse, error) {
+	project, err := s.loadProject(ctx, projectID, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := s.loadConfig(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked, err := s.scoreProject(ctx, project, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.persistScores(ctx, project.OrganizationID, ranked); err != nil {
+		return nil, err
+	}
+
+	return &dto.ProjectTaskRankingResponse{
+		ProjectID: projectID,
+		Rankings:  filterRankings(ranked, params),
+		Total:     countRankings(ranked, params),
+	}, nil
+}
+
+// RecalculatePriorities recalculates and persists priorities for the requested scope
+func (s *RealPriorityService) RecalculatePriorities(ctx context.Context, req dto.RecalculatePriorityRequest, orgID string) (*dto.RecalculatePrioritySyncResponse, *dto.RecalculatePriorityAsyncResponse, error) {
+	if req.Async {
+		orgUUID, err := uuid.Parse(orgID)
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Async = false
+		job, err := s.queue.Enqueue(ctx, orgUUID, JobTypePriorityRecalculation, req)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, &dto.RecalculatePriorityAsyncResponse{
+			JobID:             job.ID.String(),
+			Status:            string(job.Status),
+			EstimatedDuration: "5s",
+		}, nil
+	}
+
+	resp, err := s.recalculate(ctx, req, orgID, func(int) {})
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, nil, nil
+}
+
+// GetTaskRank returns the rank of a task within its project
+func (s *RealPriorityService) GetTaskRank(ctx context.Context, taskID string, orgID string) (int, error) {
+	task, project, err := s.loadTask(ctx, taskID, orgID)
+	if err != nil {
+		return 0, err
+	}
+
+	cfg, _, err := s.loadConfig(ctx, orgID)
+	if err != nil {
+		return 0, err
+	}
+
+	ranked, err := s.scoreProject(ctx, project, cfg)
+	if err != nil {
+		return 0, err
+	}
+	for _, st := range ranked {
+		if st.task.ID == task.ID {
+			return st.rank, nil
+		}
+	}
+	return 0, fmt.Errorf("task %s not ranked", taskID)
+}
+
+// GetPriorityConfig returns the priority configuration of an organization
+func (s *RealPriorityService) GetPriorityConfig(ctx context.Context, orgID string) (*dto.PriorityConfigResponse, error) {
+	cfg, isDefault, err := s.loadConfig(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.PriorityConfigResponse{
+		OrganizationID: orgID,
+		Config:         cfg,
+		IsDefault:      isDefault,
+	}, nil
+}
+
+// UpdatePriorityConfig validates and stores the priority configuration of an organization
+func (s *RealPriorityService) UpdatePriorityConfig(ctx context.Context, cfg dto.PriorityConfig, orgID string) (*dto.PriorityConfigResponse, error) {
+	if err := ValidatePriorityConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.repos.GetOrganization().GetByID(ctx, orgUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := encodePriorityConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if org.Settings == nil {
+		org.Settings = models.JSONB{}
+	}
+	org.Settings[priorityConfigSettingsKey] = encoded
+	if err := s.repos.GetOrganization().Update(ctx, org); err != nil {
+		return nil, err
+	}
+
+	// Stored scores were computed with the previous weights
+	if _, _, err := s.RecalculatePriorities(ctx, dto.RecalculatePriorityRequest{Scope: "organization", Async: true}, orgID); err != nil {
+		return nil, err
+	}
+
+	return &dto.PriorityConfigResponse{
+		OrganizationID: orgID,
+		Config:         cfg,
+		IsDefault:      false,
+	}, nil
+}
+
+// PreviewPriorityConfig compares a project's current ranking with the ranking under a candidate configuration
+func (s *RealPriorityService) PreviewPriorityConfig(ctx context.Context, req dto.PriorityConfigPreviewRequest, orgID string) (*dto.PriorityConfigPreviewResponse, error) {
+	if err := ValidatePriorityConfig(req.Config); err != nil {
+		return nil, err
+	}
+
+	project, err := s.loadProject(ctx, req.ProjectID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	current, _, err := s.loadConfig(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	currentRanked, err := s.scoreProject(ctx, project, current)
+	if err != nil {
+		return nil, err
+	}
+	previewRanked, err := s.scoreProject(ctx, project, req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, moved := CompareRankings(toRankingItems(currentRanked), toRankingItems(previewRanked))
+	if req.Limit > 0 && len(changes) > req.Limit {
+		changes = changes[:req.Limit]
+	}
+
+	return &dto.PriorityConfigPreviewResponse{
+		ProjectID:  req.ProjectID,
+		Changes:    changes,
+		MovedTasks: moved,
+		Total:      len(previewRanked),
+	}, nil
+}
+
+// CompareRankings pairs two rankings of the same tasks and reports how each task moved.
+// Changes are returned in preview rank order along with the number of tasks whose rank changed.
+func CompareRankings(current, preview []dto.TaskRankingItem) ([]dto.RankingChange, int) {
+	byTask := make(map[string]dto.TaskRankingItem, len(current))
+	for _, item := range current {
+		byTask[item.TaskID] = item
+	}
+
+	changes := make([]dto.RankingChange, 0, len(preview))
+	moved := 0
+	for _, item := range preview {
+		before, ok := byTask[item.TaskID]
+		if !ok {
+			continue
+		}
+		change := dto.RankingChange{
+			TaskID:       item.TaskID,
+			Title:        item.Title,
+			CurrentRank:  before.Rank,
+			PreviewRank:  item.Rank,
+			CurrentScore: before.PriorityScore,
+			PreviewScore: item.PriorityScore,
+			RankDelta:    before.Rank - item.Rank,
+		}
+		if change.RankDelta != 0 {
+			moved++
+		}
+		changes = append(changes, change)
+	}
+	return changes, moved
+}
+
+// loadConfig reads the organization's priority configuration, falling back to the default
+// when none is stored or the stored value no longer validates
+func (s *RealPriorityService) loadConfig(ctx context.Context, orgID string) (dto.PriorityConfig, bool, error) {
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return dto.PriorityConfig{}, false, err
+	}
+	org, err := s.repos.GetOrganization().GetByID(ctx, orgUUID)
+	if err != nil {
+		return dto.PriorityConfig{}, false, err
+	}
+
+	raw, ok := org.Settings[priorityConfigSettingsKey]
+	if !ok {
+		return DefaultPriorityConfig(), true, nil
+	}
+	cfg, err := decodePriorityConfig(raw)
+	if err != nil || ValidatePriorityConfig(cfg) != nil {
+		return DefaultPriorityConfig(), true, nil
+	}
+	return cfg, false, nil
+}
+
+// handleRecalculationJob runs a queued priority recalculation. Invalid requests and
+// projects or tasks that no longer exist fail the job without retrying.
+func (s *RealPriorityService) handleRecalculationJob(ctx context.Context, job *models.Job, report jobs.ProgressFunc) (models.JSONB, error) {
+	var req dto.RecalculatePriorityRequest
+	if err := jobs.DecodePayload(job, &req); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.recalculate(ctx, req, job.OrganizationID.String(), report)
+	if errors.Is(err, ErrInvalidRecalculation) || errors.Is(err, ErrTaskNotInOrganization) || errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, jobs.Permanent(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return jobs.EncodePayload(resp)
+}
+
+// recalculate performs the synchronous recalculation for a scope
+func (s *RealPriorityService) recalculate(ctx context.Context, req dto.RecalculatePriorityRequest, orgID string, report jobs.ProgressFunc) (*dto.RecalculatePrioritySyncResponse, error) {
+	start := time.Now()
+	var projects []*models.Project
+
+	switch req.Scope {
+	case "project":
+		if req.ProjectID == nil {
+			return nil, fmt.Errorf("%w: projectId is required for project scope", ErrInvalidRecalculation)
+		}
+		project, err := s.loadProject(ctx, *req.ProjectID, orgID)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	case "organization":
+		orgUUID, err := uuid.Parse(orgID)
+		if err != nil {
+			return nil, err
+		}
+		orgProjects, err := listOrganizationProjects(ctx, s.repos, orgUUID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range orgProjects {
+			projects = append(projects, &orgProjects[i])
+		}
+	case "task":
+		if len(req.TaskIDs) == 0 {
+			return nil, fmt.Errorf("%w: taskIds are required for task scope", ErrInvalidRecalculation)
+		}
+		bulk, err := s.GetBulkTaskPriorities(ctx, dto.BulkPriorityRequest{TaskIds: req.TaskIDs}, orgID)
+		if err != nil {
+			return nil, err
+		}
+		return &dto.RecalculatePrioritySyncResponse{
+			Recalculated:  len(bulk.Priorities),
+			Duration:      time.Since(start).Round(time.Millisecond).String(),
+			AffectedTasks: bulk.SortedOrder,
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported scope %q", ErrInvalidRecalculation, req.Scope)
+	}
+
+	cfg, _, err := s.loadConfig(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	recalculated := 0
+	affected := []string{}
+	for i, project := range projects {
+		ranked, err := s.scoreProject(ctx, project, cfg)
+		if err != nil {
+			return nil, err
+		}
+		changed, err := s.persistScores(ctx, project.OrganizationID, ranked)
+		if err != nil {
+			return nil, err
+		}
+		recalculated += len(ranked)
+		affected = append(affected, changed...)
+		report((i + 1) * 100 / len(projects))
+	}
+
+	return &dto.RecalculatePrioritySyncResponse{
+		Recalculated:  recalculated,
+		Duration:      time.Since(start).Round(time.Millisecond).String(),
+		AffectedTasks: affected,
+	}, nil
+}
+
+// scoreProject scores and ranks every task in a project. Dependents are counted
+// wherever they are, as GetDependentCount does for single tasks.
+func (s *RealPriorityService) scoreProject(ctx context.Context, project *models.Project, cfg dto.PriorityConfig) ([]scoredTask, error) {
+	tasks, err := listProjectTasks(ctx, s.repos, project.OrganizationID, project.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	deps, err := s.repos.GetDependency().ListDependentsOfProject(ctx, project.OrganizationID, project.ID)
+	if err != nil {
+		return nil, err
+	}
+	dependents := make(map[uuid.UUID]int)
+	for _, dep := range deps {
+		dependents[dep.DependsOnTaskID]++
+	}
+
+	now := time.Now().UTC()
+	scored := make([]scoredTask, 0, len(tasks))
+	for i := range tasks {
+		result := ComputePriority(priorityInputs(&tasks[i], project, dependents[tasks[i].ID]), cfg, now)
+		scored = append(scored, scoredTask{task: tasks[i], result: result})
+	}
+
+	rankScoredTasks(scored)
+	return scored, nil
+}
+
+// persistScores writes changed scores back and returns the IDs of tasks that changed
+func (s *RealPriorityService) persistScores(ctx context.Context, orgID uuid.UUID, scored []scoredTask) ([]string, error) {
+	changed := []string{}
+	for _, st := range scored {
+		if st.result.Score == st.task.PriorityScore {
+			continue
+		}
+		if err := s.repos.GetTask().UpdatePriorityScore(ctx, orgID, st.task.ID, st.result.Score); err != nil {
+			return nil, err
+		}
+		changed = append(changed, st.task.ID.String())
+	}
+	return changed, nil
+}
+
+func (s *RealPriorityService) loadProject(ctx context.Context, projectID string, orgID string) (*models.Project, error) {
+	projUUID, err := uuid.Parse(projectID)
+	if err != nil {
+		return nil, ErrTaskNotInOrganization
+	}
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return nil, ErrTaskNotInOrganization
+	}
+	project, err := s.repos.GetProject().GetByID(ctx, orgUUID, projUUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTaskNotInOrganization
+	}
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+func (s *RealPriorityService) loadTask(ctx context.Context, taskID string, orgID string) (*models.Task, *models.Project, error) {
+	return s.loadTaskWithCache(ctx, taskID, orgID, map[uuid.UUID]*models.Project{})
+}
+
+func (s *RealPriorityService) loadTaskWithCache(ctx context.Context, taskID string, orgID string, projects map[uuid.UUID]*models.Project) (*models.Task, *models.Project, error) {
+	taskUUID, err := uuid.Parse(taskID)
+	if err != nil {
+		return nil, nil, ErrTaskNotInOrganization
+	}
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return nil, nil, ErrTaskNotInOrganization
+	}
+	task, err := s.repos.GetTask().GetByID(ctx, orgUUID, taskUUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrTaskNotInOrganization
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	project, ok := projects[task.ProjectID]
+	if !ok {
+		project, err = s.loadProject(ctx, task.ProjectID.String(), orgID)
+		if err != nil {
+			return nil, nil, err
+		}
+		projects[task.ProjectID] = project
+	}
+	return task, project, nil
+}
+
+// Helper functions
+
+func priorityInputs(task *models.Task, project *models.Project, dependents int) PriorityInputs {
+	return PriorityInputs{
+		ProjectPriority: project.Priority,
+		BusinessValue:   task.BusinessValue,
+		DueDate:         task.DueDate,
+		IsCriticalPath:  task.IsCriticalPath,
+		DependentCount:  dependents,
+	}
+}
+
+func encodePriorityConfig(cfg dto.PriorityConfig) (map[string]interface{}, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func decodePriorityConfig(raw interface{}) (dto.PriorityConfig, error) {
+	var cfg dto.PriorityConfig
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return cfg, err
+	}
+	err = json.Unmarshal(data, &cfg)
+	return cfg, err
+}
+
+func toRankingItems(scored []scoredTask) []dto.TaskRankingItem {
+	return filterRankings(scored, dto.ProjectRankingQueryParams{Limit: len(scored) + 1})
+}
+
+// rankScoredTasks sorts by score (ties broken by earliest due date) and assigns 1-based ranks
+func rankScoredTasks(scored []scoredTask) {
+	sort.SliceStable(scored, func(i, j int) bool {
+		a, b := scored[i], scored[j]
+		if a.result.Score != b.result.Score {
+			return a.result.Score > b.result.Score
+		}
+		if a.task.DueDate != nil && b.task.DueDate != nil && !a.task.DueDate.Equal(*b.task.DueDate) {
+			return a.task.DueDate.Before(*b.task.DueDate)
+		}
+		if (a.task.DueDate == nil) != (b.task.DueDate == nil) {
+			return a.task.DueDate != nil
+		}
+		return a.task.CreatedAt.Before(b.task.CreatedAt)
+	})
+	for i := range scored {
+		scored[i].rank = i + 1
+	}
+}
+
+func rankingMatches(st scoredTask, params dto.ProjectRankingQueryParams) bool {
+	if params.Status != "" && string(st.task.Status) != params.Status {
+		return false
+	}
+	if params.AssigneeID != "" && (st.task.AssigneeID == nil || st.task.AssigneeID.String() != params.AssigneeID) {
+		return false
+	}
+	return st.result.Score >= params.MinScore
+}
+
+func countRankings(scored []scoredTask, params dto.ProjectRankingQueryParams) int {
+	total := 0
+	for _, st := range scored {
+		if rankingMatches(st, params) {
+			total++
+		}
+	}
+	return total
+}
+
+func filterRankings(scored []scoredTask, params dto.ProjectRankingQueryParams) []dto.TaskRankingItem {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	items := []dto.TaskRankingItem{}
+	skipped := 0
+	for _, st := range scored {
+		if !rankingMatches(st, params) {
+			continue
+		}
+		if skipped < params.Offset {
+			skipped++
+			continue
+		}
+		if len(items) >= limit {
+			break
+		}
+
+		item := dto.TaskRankingItem{
+			Rank:          st.rank,
+			TaskID:        st.task.ID.String(),
+			Title:         st.task.Title,
+			PriorityScore: st.result.Score,
+			Status:        string(st.task.Status),
+		}
+		if st.task.AssigneeID != nil {
+			item.AssigneeID = strPtr(st.task.AssigneeID.String())
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// listProjectTasks pages through all tasks in a project, since list queries are capped at 100 rows
+func listProjectTasks(ctx context.Context, repos *repositories.Provider, orgID, projectID uuid.UUID) ([]models.Task, error) {
+	params := repositories.ListParams{Limit: 100, SortBy: "created_at", SortOrder: "asc"}
+	var all []models.Task
+	for {
+		page, total, err := repos.GetTask().ListByProject(ctx, orgID, projectID, params)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) == 0 || int64(len(all)) >= total {
+			return all, nil
+		}
+		params.Offset += len(page)
+	}
+}
+
+// listOrganizationProjects pages through all projects in an organization
+func listOrganizationProjects(ctx context.Context, repos *repositories.Provider, orgID uuid.UUID) ([]models.Project, error) {
+	params := repositories.ListParams{Limit: 100, SortBy: "created_at", SortOrder: "asc"}
+	var all []models.Project
+	for {
+		page, total, err := repos.GetProject().ListByOrganization(ctx, orgID, params)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) == 0 || int64(len(all)) >= total {
+			return all, nil
+		}
+		params.Offset += len(page)
+	}
+}
+
+// listOrganizationUsers pages through all members of an organization
+func listOrganizationUsers(ctx context.Context, repos *repositories.Provider, orgID uuid.UUID) ([]models.User, error) {
+	params := repositories.ListParams{Limit: 100, SortBy: "users.created_at", SortOrder: "asc"}
+	var all []models.User
+	for {
+		page, total, err := repos.GetUser().ListByOrganization(ctx, orgID, params)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) == 0 || int64(len(all)) >= total {
+			return all, nil
+		}
+		params.Offset += len(page)
+	}
+}
@@ -0,0 +1,100 @@
+package services_test
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/SimpleAjax/Xephyr/internal/jobs"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// priorityTaskRepo stores the scores the priority service writes back
+type priorityTaskRepo struct {
+	*hierarchyTaskRepo
+}
+
+func (r *priorityTaskRepo) UpdatePriorityScore(ctx context.Context, orgID, id uuid.UUID, score int) error {
+	r.tasks[id].PriorityScore = score
+	return nil
+}
+
+// priorityDependencyRepo counts dependents wherever they are
+type priorityDependencyRepo struct {
+	*graphDependencyRepo
+}
+
+func (r *priorityDependencyRepo) ListDependentsOfProject(ctx context.Context, orgID, projectID uuid.UUID) ([]models.TaskDependency, error) {
+	var out []models.TaskDependency
+	for _, d := range r.deps {
+		if t, ok := r.tasks.tasks[d.DependsOnTaskID]; ok && t.ProjectID == projectID {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (r *priorityDependencyRepo) GetDependentCount(ctx context.Context, orgID, taskID uuid.UUID) (int64, error) {
+	var count int64
+	for _, d := range r.deps {
+		if d.DependsOnTaskID == taskID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+var _ = Describe("Real Priority Service", func() {
+	var (
+		ctx     context.Context
+		service services.PriorityService
+		orgID   uuid.UUID
+
+		auth, billing uuid.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		orgID = uuid.New()
+		projectID, mobileID := uuid.New(), uuid.New()
+
+		// Auth and billing only differ in that two tasks of the mobile project wait on auth
+		tasks := &hierarchyTaskRepo{tasks: map[uuid.UUID]*models.Task{}}
+		billing = tasks.add(models.Task{ProjectID: projectID, Title: "Billing", BusinessValue: 50})
+		auth = tasks.add(models.Task{ProjectID: projectID, Title: "Auth", BusinessValue: 50})
+		login := tasks.add(models.Task{ProjectID: mobileID, Title: "Login screen"})
+		signup := tasks.add(models.Task{ProjectID: mobileID, Title: "Signup screen"})
+
+		deps := &graphDependencyRepo{tasks: tasks}
+		deps.add(login, auth, models.DependencyFinishToStart)
+		deps.add(signup, auth, models.DependencyFinishToStart)
+
+		service = services.NewRealPriorityService(&repositories.Provider{
+			Organization: &memOrgMembers{org: models.Organization{BaseModel: models.BaseModel{ID: orgID}}},
+			Project: &lifecycleProjectRepo{
+				projects: map[uuid.UUID]*models.Project{projectID: {BaseModel: models.BaseModel{ID: projectID}, OrganizationID: orgID, Priority: 50}},
+				deleted:  map[uuid.UUID]bool{},
+			},
+			Task:       &priorityTaskRepo{tasks},
+			Dependency: &priorityDependencyRepo{deps},
+		}, jobs.NewQueue(nil, jobs.DefaultConfig()))
+	})
+
+	Describe("GetTaskPriority", func() {
+		It("ranks a task by the score it reports, counting dependents in other projects", func() {
+			resp, err := service.GetTaskPriority(ctx, auth.String(), orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Factors.BlockedTasksCount).To(Equal(2))
+			Expect(resp.Rank).To(Equal(1))
+
+			other, err := service.GetTaskPriority(ctx, billing.String(), orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(other.UniversalPriorityScore).To(BeNumerically("<", resp.UniversalPriorityScore))
+			Expect(other.Rank).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,469 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+// statusProgress is how far along a task is taken to be from its status alone
+var statusProgress = map[models.TaskStatus]float64{
+	models.TaskStatusBacklog:    0,
+	models.TaskStatusReady:      0.1,
+	models.TaskStatusInProgress: 0.4,
+	models.TaskStatusReview:     0.8,
+	models.TaskStatusDone:       1,
+}
+
+// maxOpenProgress keeps a task that is not done short of complete, however many hours
+// were logged against it
+const maxOpenProgress = 0.99
+
+// varianceTolerance is how many points progress may trail or lead the schedule and
+// still be on track
+const varianceTolerance = 10
+
+// RealProgressService implements ProgressService from task status, reported progress
+// and hours
+type RealProgressService struct {
+	repos *repositories.Provider
+	now   func() time.Time
+}
+
+// NewRealProgressService creates a new real progress service
+func NewRealProgressService(repos *repositories.Provider) ProgressService {
+	return &RealProgressService{repos: repos, now: time.Now}
+}
+
+// GetProjectProgress rolls up a project's tasks and reports the breakdowns, schedule
+// variance and milestones
+func (s *RealProgressService) GetProjectProgress(ctx context.Context, projectID string, method ProgressMethod, orgID string) (*dto.ProjectProgressResponse, error) {
+	orgUUID, projUUID, err := parseProgressIDs(orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	project, err := s.repos.GetProject().GetByID(ctx, orgUUID, projUUID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := progressTasks(ctx, s.repos, orgUUID, projUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	rollup := rollupProgress(tasks, method)
+	progress := percent(rollup.overall)
+	return &dto.ProjectProgressResponse{
+		ProjectID:          projectID,
+		ProgressPercentage: progress,
+		CalculationMethod:  string(method),
+		Breakdown: dto.ProgressBreakdown{
+			ByStatus:    statusBreakdown(tasks),
+			ByHierarchy: hierarchyBreakdown(tasks),
+		},
+		Variance:     progressVariance(project, progress, now),
+		Milestones:   milestones(tasks, now),
+		CalculatedAt: now.UTC(),
+	}, nil
+}
+
+// GetTaskProgress rolls up a task's subtree and reports it with its direct subtasks
+func (s *RealProgressService) GetTaskProgress(ctx context.Context, taskID string, orgID string) (*dto.TaskProgressResponse, error) {
+	orgUUID, taskUUID, err := parseProgressIDs(orgID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	subtree, err := s.repos.GetTask().ListSubtree(ctx, orgUUID, taskUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The task heads its own rollup even when its parent is in the list
+	subtree[0].ParentTaskID = nil
+	rollup := rollupProgress(subtree, DefaultProgressMethod)
+	task := subtree[0]
+
+	resp := &dto.TaskProgressResponse{
+		TaskID:             taskID,
+		Title:              task.Title,
+		Status:             string(task.Status),
+		ProgressPercentage: percent(rollup.progress[task.ID]),
+		EstimatedHours:     task.EstimatedHours,
+		ActualHours:        task.ActualHours,
+		RemainingHours:     math.Max(task.EstimatedHours-task.ActualHours, 0),
+		StartDate:          task.StartDate,
+		DueDate:            task.DueDate,
+		CompletedAt:        task.CompletedAt,
+	}
+	for _, child := range rollup.roots[0].Subtasks {
+		resp.SubtaskProgress = append(resp.SubtaskProgress, dto.SubtaskProgress{
+			TaskID:             child.Task.ID.String(),
+			Title:              child.Task.Title,
+			Status:             string(child.Task.Status),
+			ProgressPercentage: percent(rollup.progress[child.Task.ID]),
+		})
+	}
+	return resp, nil
+}
+
+// UpdateTaskProgress records a status change, reported progress or logged hours and
+// recalculates the project. Status changes follow the project's workflow. Dependents are listed when the task is completed, since
+// their blocker is then cleared.
+func (s *RealProgressService) UpdateTaskProgress(ctx context.Context, taskID string, req dto.UpdateTaskProgressRequest, orgID string, userID string) (*dto.TaskProgressUpdateResponse, error) {
+	orgUUID, taskUUID, err := parseProgressIDs(orgID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	// The actor is only logged, so a change without a user is recorded as the system's
+	actorID, _ := uuid.Parse(userID)
+
+	var task *models.Task
+	var previous models.TaskStatus
+	err = s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		loaded, err := tx.GetTask().GetByID(ctx, orgUUID, taskUUID)
+		if err != nil {
+			return err
+		}
+		task, previous = loaded, loaded.Status
+
+		if req.Status != "" {
+			if err := transitionTask(ctx, tx, orgUUID, actorID, task, req.Status, s.now()); err != nil {
+				return err
+			}
+		}
+		if req.ProgressPercentage != nil || req.ActualHours != 0 {
+			if req.ProgressPercentage != nil {
+				task.ProgressPercentage = *req.ProgressPercentage
+			}
+			if req.ActualHours != 0 {
+				task.ActualHours = req.ActualHours
+			}
+			if err := tx.GetTask().UpdateProgress(ctx, orgUUID, taskUUID, task.ProgressPercentage, task.ActualHours); err != nil {
+				return err
+			}
+		}
+		_, err = recalculateProjectProgress(ctx, tx, orgUUID, task.ProjectID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dependents := []string{}
+	if task.Status == models.TaskStatusDone && previous != models.TaskStatusDone {
+		deps, err := s.repos.GetDependency().ListByDependsOn(ctx, orgUUID, taskUUID)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range deps {
+			dependents = append(dependents, d.TaskID.String())
+		}
+	}
+
+	return &dto.TaskProgressUpdateResponse{
+		TaskID:             taskID,
+		PreviousStatus:     string(previous),
+		NewStatus:          string(task.Status),
+		ProgressPercentage: task.ProgressPercentage,
+		ActualHours:        task.ActualHours,
+		EstimatedHours:     task.EstimatedHours,
+		RemainingHours:     math.Max(task.EstimatedHours-task.ActualHours, 0),
+		Affected: dto.ProgressUpdateImpact{
+			ParentProgressUpdated:  task.ParentTaskID != nil,
+			ProjectProgressUpdated: true,
+			DependentsNotified:     dependents,
+		},
+	}, nil
+}
+
+// GetProjectRollup nests a project's tasks with each one's rolled-up progress
+func (s *RealProgressService) GetProjectRollup(ctx context.Context, projectID string, method ProgressMethod, orgID string) (*dto.ProjectRollupResponse, error) {
+	orgUUID, projUUID, err := parseProgressIDs(orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repos.GetProject().GetByID(ctx, orgUUID, projUUID); err != nil {
+		return nil, err
+	}
+	tasks, err := progressTasks(ctx, s.repos, orgUUID, projUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	rollup := rollupProgress(tasks, method)
+	items := make([]dto.RollupItem, 0, len(rollup.roots))
+	for _, root := range rollup.roots {
+		items = append(items, rollup.item(root))
+	}
+	return &dto.ProjectRollupResponse{
+		ProjectID:          projectID,
+		ProgressPercentage: percent(rollup.overall),
+		CalculationMethod:  string(method),
+		Tasks:              items,
+		CalculatedAt:       s.now().UTC(),
+	}, nil
+}
+
+// RecalculateProjectProgress stores the project's progress by the default method
+func (s *RealProgressService) RecalculateProjectProgress(ctx context.Context, projectID string, orgID string) error {
+	orgUUID, projUUID, err := parseProgressIDs(orgID, projectID)
+	if err != nil {
+		return err
+	}
+	_, err = recalculateProjectProgress(ctx, s.repos, orgUUID, projUUID)
+	return err
+}
+
+// recalculateProjectProgress rolls up a project's tasks by the default method and
+// stores the result on the project
+func recalculateProjectProgress(ctx context.Context, repos *repositories.Provider, orgID, projectID uuid.UUID) (int, error) {
+	tasks, err := progressTasks(ctx, repos, orgID, projectID)
+	if err != nil {
+		return 0, err
+	}
+	progress := percent(rollupProgress(tasks, DefaultProgressMethod).overall)
+	if err := repos.GetProject().UpdateProgress(ctx, orgID, projectID, progress); err != nil {
+		return 0, err
+	}
+	return progress, nil
+}
+
+// progressTasks lists the project's tasks that count toward progress. Archived
+// subtrees are out of scope.
+func progressTasks(ctx context.Context, repos *repositories.Provider, orgID, projectID uuid.UUID) ([]models.Task, error) {
+	tasks, err := listProjectTasks(ctx, repos, orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	active := tasks[:0]
+	for _, t := range tasks {
+		if t.ArchivedAt == nil {
+			active = append(active, t)
+		}
+	}
+	return active, nil
+}
+
+// parseProgressIDs parses the organization ID and the ID of the project or task
+func parseProgressIDs(orgID, id string) (uuid.UUID, uuid.UUID, error) {
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("invalid ID %q: %w", id, gorm.ErrRecordNotFound)
+	}
+	return orgUUID, parsed, nil
+}
+
+// progressRollup is the rolled-up progress (0-1) and weight of every task in a forest
+type progressRollup struct {
+	roots    []*TaskNode
+	progress map[uuid.UUID]float64
+	weight   map[uuid.UUID]float64
+	overall  float64
+}
+
+// rollupProgress computes every task's progress bottom-up. A task without subtasks
+// gets its own progress and weight; a parent gets the weighted mean of its subtasks
+// and the sum of their weights, unless it is done, which counts as complete.
+func rollupProgress(tasks []models.Task, method ProgressMethod) *progressRollup {
+	r := &progressRollup{
+		roots:    buildTaskForest(tasks),
+		progress: make(map[uuid.UUID]float64, len(tasks)),
+		weight:   make(map[uuid.UUID]float64, len(tasks)),
+	}
+	var visit func(node *TaskNode)
+	visit = func(node *TaskNode) {
+		task := node.Task
+		if len(node.Subtasks) == 0 {
+			r.progress[task.ID] = taskProgress(task)
+			r.weight[task.ID] = taskWeight(task, method)
+			return
+		}
+		for _, child := range node.Subtasks {
+			visit(child)
+		}
+		progress, weight := r.mean(node.Subtasks)
+		if task.Status == models.TaskStatusDone {
+			progress = 1
+		}
+		r.progress[task.ID], r.weight[task.ID] = progress, weight
+	}
+	for _, root := range r.roots {
+		visit(root)
+	}
+	r.overall, _ = r.mean(r.roots)
+	return r
+}
+
+// mean is the weighted mean progress of sibling tasks and their total weight. Siblings
+// that all weigh nothing, such as tasks without estimates under weighted_hours, count
+// equally instead.
+func (r *progressRollup) mean(nodes []*TaskNode) (float64, float64) {
+	if len(nodes) == 0 {
+		return 0, 0
+	}
+	var sum, weight float64
+	for _, n := range nodes {
+		sum += r.progress[n.Task.ID] * r.weight[n.Task.ID]
+		weight += r.weight[n.Task.ID]
+	}
+	if weight > 0 {
+		return sum / weight, weight
+	}
+	for _, n := range nodes {
+		sum += r.progress[n.Task.ID]
+	}
+	return sum / float64(len(nodes)), 0
+}
+
+// item converts a rolled-up task and its subtasks to the response form
+func (r *progressRollup) item(node *TaskNode) dto.RollupItem {
+	item := dto.RollupItem{
+		TaskID:             node.Task.ID.String(),
+		Title:              node.Task.Title,
+		ProgressPercentage: percent(r.progress[node.Task.ID]),
+		Status:             string(node.Task.Status),
+		EstimatedHours:     node.Task.EstimatedHours,
+		ActualHours:        node.Task.ActualHours,
+	}
+	for _, child := range node.Subtasks {
+		item.Children = append(item.Children, r.item(child))
+	}
+	return item
+}
+
+// taskProgress is a task's own progress from 0 to 1. A done task is complete; any
+// other task is as far along as the best of its status, the progress its assignee
+// reported and the share of its estimate already logged, short of complete.
+func taskProgress(task models.Task) float64 {
+	if task.Status == models.TaskStatusDone {
+		return 1
+	}
+	progress := statusProgress[task.Status]
+	progress = math.Max(progress, float64(task.ProgressPercentage)/100)
+	if task.EstimatedHours > 0 {
+		progress = math.Max(progress, task.ActualHours/task.EstimatedHours)
+	}
+	return math.Min(progress, maxOpenProgress)
+}
+
+// taskWeight is how much a task counts toward its parent and project
+func taskWeight(task models.Task, method ProgressMethod) float64 {
+	switch method {
+	case ProgressByHours:
+		return math.Max(task.EstimatedHours, 0)
+	case ProgressByBusinessValue:
+		return float64(max(task.BusinessValue, 0))
+	default:
+		return 1
+	}
+}
+
+// statusBreakdown counts tasks and estimated hours by status. Percentages are shares
+// of the estimated hours, or of the task count when nothing is estimated.
+func statusBreakdown(tasks []models.Task) dto.ByStatusBreakdown {
+	var b dto.ByStatusBreakdown
+	slots := map[models.TaskStatus]*dto.StatusBreakdown{
+		models.TaskStatusBacklog:    &b.Backlog,
+		models.TaskStatusReady:      &b.Ready,
+		models.TaskStatusInProgress: &b.InProgress,
+		models.TaskStatusReview:     &b.Review,
+		models.TaskStatusDone:       &b.Done,
+	}
+	var totalHours float64
+	for _, t := range tasks {
+		if slot, ok := slots[t.Status]; ok {
+			slot.Count++
+			slot.Hours += t.EstimatedHours
+			totalHours += t.EstimatedHours
+		}
+	}
+	for _, slot := range slots {
+		switch {
+		case totalHours > 0:
+			slot.Percentage = percent(slot.Hours / totalHours)
+		case len(tasks) > 0:
+			slot.Percentage = percent(float64(slot.Count) / float64(len(tasks)))
+		}
+	}
+	return b
+}
+
+// hierarchyBreakdown counts top-level tasks and subtasks at any depth
+func hierarchyBreakdown(tasks []models.Task) dto.ByHierarchyBreakdown {
+	var b dto.ByHierarchyBreakdown
+	for _, t := range tasks {
+		item := &b.Tasks
+		if t.ParentTaskID != nil {
+			item = &b.Subtasks
+		}
+		item.Total++
+		if t.Status == models.TaskStatusDone {
+			item.Completed++
+		}
+	}
+	return b
+}
+
+// progressVariance compares progress with the share of the schedule already elapsed.
+// Projects without both a start and a target end date have no schedule to trail.
+func progressVariance(project *models.Project, progress int, now time.Time) dto.ProgressVariance {
+	v := dto.ProgressVariance{ExpectedProgress: progress, ActualProgress: progress, Status: "unscheduled"}
+	if project.StartDate == nil || project.TargetEndDate == nil || !project.TargetEndDate.After(*project.StartDate) {
+		return v
+	}
+	elapsed := now.Sub(*project.StartDate).Hours() / project.TargetEndDate.Sub(*project.StartDate).Hours()
+	v.ExpectedProgress = percent(math.Min(math.Max(elapsed, 0), 1))
+	v.Variance = progress - v.ExpectedProgress
+	switch {
+	case v.Variance < -varianceTolerance:
+		v.Status = "behind_schedule"
+	case v.Variance > varianceTolerance:
+		v.Status = "ahead_of_schedule"
+	default:
+		v.Status = "on_track"
+	}
+	return v
+}
+
+// milestones lists the milestone tasks as completed, overdue or upcoming
+func milestones(tasks []models.Task, now time.Time) []dto.Milestone {
+	out := []dto.Milestone{}
+	for _, t := range tasks {
+		if !t.IsMilestone {
+			continue
+		}
+		status := "upcoming"
+		switch {
+		case t.Status == models.TaskStatusDone:
+			status = "completed"
+		case t.DueDate != nil && t.DueDate.Before(now):
+			status = "overdue"
+		}
+		out = append(out, dto.Milestone{
+			TaskID:      t.ID.String(),
+			Title:       t.Title,
+			Status:      status,
+			DueDate:     t.DueDate,
+			CompletedAt: t.CompletedAt,
+		})
+	}
+	return out
+}
+
+// percent rounds a 0-1 fraction to a whole percentage
+func percent(fraction float64) int {
+	return int(math.Round(fraction * 100))
+}
@@ -0,0 +1,221 @@
+package services_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// progressProjectRepo records the progress written back to projects
+type progressProjectRepo struct {
+	*lifecycleProjectRepo
+}
+
+func (r *progressProjectRepo) UpdateProgress(ctx context.Context, orgID, id uuid.UUID, progress int) error {
+	r.projects[id].Progress = progress
+	return nil
+}
+
+// progressTaskRepo adds progress updates to the workflow fake
+type progressTaskRepo struct {
+	workflowTaskRepo
+}
+
+func (r *progressTaskRepo) UpdateProgress(ctx context.Context, orgID, id uuid.UUID, progress int, actualHours float64) error {
+	r.tasks[id].ProgressPercentage, r.tasks[id].ActualHours = progress, actualHours
+	return nil
+}
+
+var _ = Describe("Real Progress Service", func() {
+	var (
+		ctx       context.Context
+		service   services.ProgressService
+		projects  *progressProjectRepo
+		tasks     *hierarchyTaskRepo
+		orgID     uuid.UUID
+		projectID uuid.UUID
+
+		epic, design, build, docs, launch uuid.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		orgID, projectID = uuid.New(), uuid.New()
+		now := time.Now()
+		start, end, yesterday := now.AddDate(0, 0, -10), now.AddDate(0, 0, 10), now.AddDate(0, 0, -1)
+
+		// Hours: Epic rolls up Design (done, 10h) and Build (in progress, 30h) to 55%;
+		// Docs (backlog, 10h) adds nothing and Launch has no estimate, so 22 of 50 hours
+		tasks = &hierarchyTaskRepo{tasks: map[uuid.UUID]*models.Task{}}
+		epic = tasks.add(models.Task{ProjectID: projectID, Title: "Epic", HierarchyLevel: 1,
+			Status: models.TaskStatusInProgress})
+		design = tasks.add(models.Task{ProjectID: projectID, ParentTaskID: &epic, Title: "Design", HierarchyLevel: 2,
+			Status: models.TaskStatusDone, EstimatedHours: 10, BusinessValue: 60})
+		build = tasks.add(models.Task{ProjectID: projectID, ParentTaskID: &epic, Title: "Build", HierarchyLevel: 2,
+			Status: models.TaskStatusInProgress, EstimatedHours: 30, ActualHours: 6, BusinessValue: 20})
+		docs = tasks.add(models.Task{ProjectID: projectID, Title: "Docs", HierarchyLevel: 1,
+			Status: models.TaskStatusBacklog, EstimatedHours: 10, BusinessValue: 20, IsMilestone: true, DueDate: &yesterday})
+		launch = tasks.add(models.Task{ProjectID: projectID, Title: "Launch", HierarchyLevel: 1,
+			Status: models.TaskStatusDone, IsMilestone: true, CompletedAt: &yesterday})
+		tasks.add(models.Task{ProjectID: projectID, Title: "Shelved", HierarchyLevel: 1,
+			Status: models.TaskStatusBacklog, EstimatedHours: 100, ArchivedAt: &yesterday})
+
+		projects = &progressProjectRepo{&lifecycleProjectRepo{
+			projects: map[uuid.UUID]*models.Project{projectID: {
+				BaseModel:      models.BaseModel{ID: projectID},
+				OrganizationID: orgID,
+				Name:           "Launch",
+				StartDate:      &start,
+				TargetEndDate:  &end,
+			}},
+			deleted: map[uuid.UUID]bool{},
+		}}
+
+		service = services.NewRealProgressService(&repositories.Provider{
+			Project: projects,
+			Task:    &progressTaskRepo{workflowTaskRepo{tasks}},
+			Dependency: &workflowDependencyRepo{tasks: tasks, deps: []models.TaskDependency{
+				{TaskID: docs, DependsOnTaskID: build},
+			}},
+			Workflow:  &workflowRepo{workflows: map[uuid.UUID]*models.Workflow{}},
+			TaskEvent: &taskEventRepo{},
+		})
+	})
+
+	Describe("ParseProgressMethod", func() {
+		It("defaults to weighted hours and rejects unknown methods", func() {
+			Expect(services.ParseProgressMethod("")).To(Equal(services.ProgressByHours))
+			Expect(services.ParseProgressMethod("business_value")).To(Equal(services.ProgressByBusinessValue))
+			_, err := services.ParseProgressMethod("story_points")
+			Expect(err).To(MatchError(services.ErrInvalidProgressMethod))
+		})
+	})
+
+	Describe("GetProjectProgress", func() {
+		DescribeTable("weights tasks by the calculation method",
+			func(method services.ProgressMethod, expected int) {
+				progress, err := service.GetProjectProgress(ctx, projectID.String(), method, orgID.String())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(progress.ProgressPercentage).To(Equal(expected))
+				Expect(progress.CalculationMethod).To(Equal(string(method)))
+			},
+			Entry("by estimated hours", services.ProgressByHours, 44),
+			Entry("by task count", services.ProgressByTaskCount, 60),
+			Entry("by business value", services.ProgressByBusinessValue, 68),
+		)
+
+		It("breaks progress down by status and hierarchy, leaving out archived tasks", func() {
+			progress, err := service.GetProjectProgress(ctx, projectID.String(), services.ProgressByHours, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+
+			byStatus := progress.Breakdown.ByStatus
+			Expect(byStatus.Done).To(Equal(dto.StatusBreakdown{Count: 2, Hours: 10, Percentage: 20}))
+			Expect(byStatus.InProgress).To(Equal(dto.StatusBreakdown{Count: 2, Hours: 30, Percentage: 60}))
+			Expect(byStatus.Backlog).To(Equal(dto.StatusBreakdown{Count: 1, Hours: 10, Percentage: 20}))
+
+			Expect(progress.Breakdown.ByHierarchy).To(Equal(dto.ByHierarchyBreakdown{
+				Tasks:    dto.HierarchyItem{Total: 3, Completed: 1},
+				Subtasks: dto.HierarchyItem{Total: 2, Completed: 1},
+			}))
+		})
+
+		It("reports milestones as completed or overdue", func() {
+			progress, err := service.GetProjectProgress(ctx, projectID.String(), services.ProgressByHours, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+
+			statuses := map[string]string{}
+			for _, m := range progress.Milestones {
+				statuses[m.Title] = m.Status
+			}
+			Expect(statuses).To(Equal(map[string]string{"Docs": "overdue", "Launch": "completed"}))
+		})
+
+		It("compares progress with the elapsed share of the schedule", func() {
+			progress, err := service.GetProjectProgress(ctx, projectID.String(), services.ProgressByHours, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(progress.Variance.ExpectedProgress).To(Equal(50))
+			Expect(progress.Variance.Variance).To(Equal(-6))
+			Expect(progress.Variance.Status).To(Equal("on_track"))
+
+			start := time.Now().AddDate(0, 0, -18)
+			projects.projects[projectID].StartDate = &start
+			progress, err = service.GetProjectProgress(ctx, projectID.String(), services.ProgressByHours, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(progress.Variance.ExpectedProgress).To(Equal(64))
+			Expect(progress.Variance.Status).To(Equal("behind_schedule"))
+		})
+
+		It("reports an unknown project as not found", func() {
+			_, err := service.GetProjectProgress(ctx, uuid.NewString(), services.ProgressByHours, orgID.String())
+			Expect(err).To(MatchError(gorm.ErrRecordNotFound))
+		})
+	})
+
+	Describe("GetProjectRollup", func() {
+		It("rolls subtask progress up into their parents", func() {
+			rollup, err := service.GetProjectRollup(ctx, projectID.String(), services.ProgressByHours, orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rollup.ProgressPercentage).To(Equal(44))
+			Expect(rollup.Tasks).To(HaveLen(3))
+
+			root := rollup.Tasks[0]
+			Expect(root.Title).To(Equal("Epic"))
+			Expect(root.ProgressPercentage).To(Equal(55))
+			Expect(root.Children).To(HaveLen(2))
+			Expect(root.Children[0].ProgressPercentage).To(Equal(100))
+			Expect(root.Children[1].ProgressPercentage).To(Equal(40))
+		})
+	})
+
+	Describe("GetTaskProgress", func() {
+		It("rolls up a task's subtree", func() {
+			progress, err := service.GetTaskProgress(ctx, epic.String(), orgID.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(progress.ProgressPercentage).To(Equal(55))
+			Expect(progress.SubtaskProgress).To(HaveLen(2))
+			Expect(progress.SubtaskProgress[0].TaskID).To(Equal(design.String()))
+		})
+	})
+
+	Describe("UpdateTaskProgress", func() {
+		It("completes a task, writes the project's progress back and lists its dependents", func() {
+			result, err := service.UpdateTaskProgress(ctx, build.String(), dto.UpdateTaskProgressRequest{Status: "done"}, orgID.String(), uuid.NewString())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.PreviousStatus).To(Equal("in_progress"))
+			Expect(result.ProgressPercentage).To(Equal(100))
+			Expect(result.Affected.ParentProgressUpdated).To(BeTrue())
+			Expect(result.Affected.DependentsNotified).To(ConsistOf(docs.String()))
+
+			// Epic is now complete: 40 of 50 hours
+			Expect(projects.projects[projectID].Progress).To(Equal(80))
+		})
+
+		It("counts reported progress and logged hours", func() {
+			reported := 90
+			_, err := service.UpdateTaskProgress(ctx, docs.String(), dto.UpdateTaskProgressRequest{ProgressPercentage: &reported}, orgID.String(), uuid.NewString())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks.tasks[docs].ProgressPercentage).To(Equal(90))
+			Expect(projects.projects[projectID].Progress).To(Equal(62))
+
+			_, err = service.UpdateTaskProgress(ctx, launch.String(), dto.UpdateTaskProgressRequest{ActualHours: 3}, orgID.String(), uuid.NewString())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks.tasks[launch].ActualHours).To(Equal(3.0))
+		})
+	})
+
+	Describe("RecalculateProjectProgress", func() {
+		It("stores the progress by weighted hours", func() {
+			Expect(service.RecalculateProjectProgress(ctx, projectID.String(), orgID.String())).To(Succeed())
+			Expect(projects.projects[projectID].Progress).To(Equal(44))
+		})
+	})
+})
@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,12 +16,13 @@ import (
 
 // RealScenarioService implements ScenarioService using database queries
 type RealScenarioService struct {
-	repos *repositories.Provider
+	repos    *repositories.Provider
+	notifier NotificationDispatcher
 }
 
 // NewRealScenarioService creates a new real scenario service
-func NewRealScenarioService(repos *repositories.Provider) ScenarioService {
-	return &RealScenarioService{repos: repos}
+func NewRealScenarioService(repos *repositories.Provider, notifier NotificationDispatcher) ScenarioService {
+	return &RealScenarioService{repos: repos, notifier: notifier}
 }
 
 // ListScenarios returns scenarios from database
@@ -48,12 +51,16 @@ func (s *RealScenarioService) ListScenarios(ctx context.Context, params dto.Scen
 
 // GetScenario returns a single scenario
 func (s *RealScenarioService) GetScenario(ctx context.Context, scenarioID string, orgID string) (*dto.ScenarioDetailResponse, error) {
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return nil, err
+	}
 	scenarioUUID, err := uuid.Parse(scenarioID)
 	if err != nil {
 		return nil, err
 	}
 
-	scenario, err := s.repos.GetScenario().GetByID(ctx, scenarioUUID)
+	scenario, err := s.repos.GetScenario().GetByID(ctx, orgUUID, scenarioUUID)
 	if err != nil {
 		return nil, err
 	}
@@ -80,7 +87,7 @@ func (s *RealScenarioService) CreateScenario(ctx context.Context, req dto.Create
 		CreatedByID:     userID,
 	}
 
-	if err := s.repos.GetScenario().Create(ctx, scenario); err != nil {
+	if err := s.repos.GetScenario().Create(ctx, orgUUID, scenario); err != nil {
 		return nil, err
 	}
 
@@ -96,12 +103,16 @@ func (s *RealScenarioService) CreateScenario(ctx context.Context, req dto.Create
 
 // SimulateScenario simulates a scenario
 func (s *RealScenarioService) SimulateScenario(ctx context.Context, scenarioID string, req dto.SimulateScenarioRequest, orgID string) (*dto.SimulateScenarioResponse, error) {
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return nil, err
+	}
 	scenarioUUID, err := uuid.Parse(scenarioID)
 	if err != nil {
 		return nil, err
 	}
 
-	scenario, err := s.repos.GetScenario().GetByID(ctx, scenarioUUID)
+	scenario, err := s.repos.GetScenario().GetByID(ctx, orgUUID, scenarioUUID)
 	if err != nil {
 		return nil, err
 	}
@@ -186,15 +197,24 @@ func (s *RealScenarioService) SimulateScenario(ctx context.Context, scenarioID s
 
 // ApplyScenario applies a scenario
 func (s *RealScenarioService) ApplyScenario(ctx context.Context, scenarioID string, req dto.ApplyScenarioRequest, orgID string, userID uuid.UUID) (*dto.ApplyScenarioResponse, error) {
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return nil, err
+	}
 	scenarioUUID, err := uuid.Parse(scenarioID)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := s.repos.GetScenario().UpdateStatus(ctx, scenarioUUID, models.ScenarioStatusApplied); err != nil {
+	if err := s.repos.GetScenario().UpdateStatus(ctx, orgUUID, scenarioUUID, models.ScenarioStatusApplied); err != nil {
 		return nil, err
 	}
 
+	notificationsSent := 0
+	if req.NotifyStakeholders {
+		notificationsSent = s.notifyApplied(ctx, orgUUID, scenarioUUID)
+	}
+
 	now := time.Now().UTC()
 	return &dto.ApplyScenarioResponse{
 		ScenarioID: scenarioID,
@@ -204,7 +224,7 @@ func (s *RealScenarioService) ApplyScenario(ctx context.Context, scenarioID stri
 		Changes: dto.ScenarioChanges{
 			TasksReassigned:   []dto.ReassignmentChange{},
 			DatesAdjusted:     []dto.DateAdjustment{},
-			NotificationsSent: 0,
+			NotificationsSent: notificationsSent,
 		},
 		FollowUp: dto.ScenarioFollowUp{
 			NudgesCreated:         []string{},
@@ -215,12 +235,16 @@ func (s *RealScenarioService) ApplyScenario(ctx context.Context, scenarioID stri
 
 // RejectScenario rejects a scenario
 func (s *RealScenarioService) RejectScenario(ctx context.Context, scenarioID string, orgID string, userID uuid.UUID) (*dto.RejectScenarioResponse, error) {
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return nil, err
+	}
 	scenarioUUID, err := uuid.Parse(scenarioID)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := s.repos.GetScenario().UpdateStatus(ctx, scenarioUUID, models.ScenarioStatusRejected); err != nil {
+	if err := s.repos.GetScenario().UpdateStatus(ctx, orgUUID, scenarioUUID, models.ScenarioStatusRejected); err != nil {
 		return nil, err
 	}
 
@@ -235,12 +259,16 @@ func (s *RealScenarioService) RejectScenario(ctx context.Context, scenarioID str
 
 // ModifyScenario modifies a scenario
 func (s *RealScenarioService) ModifyScenario(ctx context.Context, scenarioID string, req dto.ModifyScenarioRequest, orgID string) (*dto.ScenarioResponse, error) {
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return nil, err
+	}
 	scenarioUUID, err := uuid.Parse(scenarioID)
 	if err != nil {
 		return nil, err
 	}
 
-	scenario, err := s.repos.GetScenario().GetByID(ctx, scenarioUUID)
+	scenario, err := s.repos.GetScenario().GetByID(ctx, orgUUID, scenarioUUID)
 	if err != nil {
 		return nil, err
 	}
@@ -258,7 +286,7 @@ func (s *RealScenarioService) ModifyScenario(ctx context.Context, scenarioID str
 	}
 	scenario.Status = models.ScenarioStatusModified
 
-	if err := s.repos.GetScenario().Update(ctx, scenario); err != nil {
+	if err := s.repos.GetScenario().Update(ctx, orgUUID, scenario); err != nil {
 		return nil, err
 	}
 
@@ -273,6 +301,33 @@ func (s *RealScenarioService) ModifyScenario(ctx context.Context, scenarioID str
 
 // Helper functions
 
+// notifyApplied tells the scenario's author and the organization's admins and project
+// managers that it was applied, returning how many of them were reached
+func (s *RealScenarioService) notifyApplied(ctx context.Context, orgID, scenarioID uuid.UUID) int {
+	if s.notifier == nil {
+		return 0
+	}
+	scenario, err := s.repos.GetScenario().GetByID(ctx, orgID, scenarioID)
+	if err != nil {
+		log.Printf("[ScenarioService] Error loading scenario %s for notification: %v", scenarioID, err)
+		return 0
+	}
+	recipients, err := escalationRecipients(ctx, s.repos, scenario.OrganizationID)
+	if err != nil {
+		log.Printf("[ScenarioService] Error finding stakeholders for scenario %s: %v", scenarioID, err)
+		return 0
+	}
+	recipients = appendUniqueID(recipients, scenario.CreatedByID)
+
+	reached, err := s.notifier.NotifyEvent(ctx, scenario.OrganizationID, recipients, models.NudgeSeverityMedium,
+		"Scenario applied: "+scenario.Title,
+		fmt.Sprintf("The %s scenario \"%s\" has been applied.", scenario.ChangeType, scenario.Title))
+	if err != nil {
+		log.Printf("[ScenarioService] Error notifying stakeholders of scenario %s: %v", scenarioID, err)
+	}
+	return len(reached)
+}
+
 func (s *RealScenarioService) toScenarioItem(scenario *models.Scenario) dto.ScenarioResponse {
 	return dto.ScenarioResponse{
 		ScenarioID:       scenario.ID.String(),
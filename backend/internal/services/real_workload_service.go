@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,6 +12,9 @@ import (
 	"github.com/SimpleAjax/Xephyr/internal/repositories"
 )
 
+// ErrPersonNotFound is returned when a person is not a member of the caller's organization
+var ErrPersonNotFound = errors.New("person not found in organization")
+
 // RealWorkloadService implements WorkloadService using database queries
 type RealWorkloadService struct {
 	repos *repositories.Provider
@@ -84,7 +88,7 @@ func (s *RealWorkloadService) GetTeamWorkload(ctx context.Context, week string,
 			}
 
 			// Get tasks for this user
-			tasks, _, err := s.repos.GetTask().ListByAssignee(ctx, user.ID, repositories.ListParams{Limit: 50})
+			tasks, _, err := s.repos.GetTask().ListByAssignee(ctx, orgUUID, user.ID, repositories.ListParams{Limit: 50})
 			if err != nil {
 				tasks = []models.Task{}
 			}
@@ -157,7 +161,7 @@ func (s *RealWorkloadService) GetTeamWorkload(ctx context.Context, week string,
 
 // GetIndividualWorkload returns workload for a specific person
 func (s *RealWorkloadService) GetIndividualWorkload(ctx context.Context, personID string, orgID string) (*dto.IndividualWorkloadResponse, error) {
-	userUUID, err := uuid.Parse(personID)
+	orgUUID, userUUID, err := s.memberIDs(ctx, orgID, personID)
 	if err != nil {
 		return nil, err
 	}
@@ -170,7 +174,7 @@ func (s *RealWorkloadService) GetIndividualWorkload(ctx context.Context, personI
 
 	// Get current week workload
 	weekStart := getCurrentWeekStart()
-	entry, err := s.repos.GetWorkload().GetByUserAndWeek(ctx, userUUID, weekStart)
+	entry, err := s.repos.GetWorkload().GetByUserAndWeek(ctx, orgUUID, userUUID, weekStart)
 	if err != nil {
 		// No workload entry found, return empty
 		return &dto.IndividualWorkloadResponse{
@@ -188,7 +192,7 @@ func (s *RealWorkloadService) GetIndividualWorkload(ctx context.Context, personI
 	}
 
 	// Get tasks assigned to user
-	tasks, _, err := s.repos.GetTask().ListByAssignee(ctx, userUUID, repositories.ListParams{Limit: 50})
+	tasks, _, err := s.repos.GetTask().ListByAssignee(ctx, orgUUID, userUUID, repositories.ListParams{Limit: 50})
 	if err != nil {
 		tasks = []models.Task{}
 	}
@@ -228,12 +232,7 @@ func (s *RealWorkloadService) GetIndividualWorkload(ctx context.Context, personI
 
 // GetWorkloadForecast returns workload forecast
 func (s *RealWorkloadService) GetWorkloadForecast(ctx context.Context, personID string, weeks int, orgID string) (*dto.WorkloadForecastResponse, error) {
-	userUUID, err := uuid.Parse(personID)
-	if err != nil {
-		return nil, err
-	}
-
-	_, err = s.repos.GetUser().GetByID(ctx, userUUID)
+	orgUUID, userUUID, err := s.memberIDs(ctx, orgID, personID)
 	if err != nil {
 		return nil, err
 	}
@@ -241,7 +240,7 @@ func (s *RealWorkloadService) GetWorkloadForecast(ctx context.Context, personID
 	// Get workload history
 	now := time.Now()
 	fromDate := now.AddDate(0, 0, -weeks*7)
-	entries, err := s.repos.GetWorkload().ListByUser(ctx, userUUID, fromDate, now)
+	entries, err := s.repos.GetWorkload().ListByUser(ctx, orgUUID, userUUID, fromDate, now)
 	if err != nil {
 		entries = []models.WorkloadEntry{}
 	}
@@ -323,13 +322,29 @@ func (s *RealWorkloadService) GetRebalanceSuggestions(ctx context.Context, req d
 
 // Helper functions
 
-func getCurrentWeekStart() time.Time {
-	now := time.Now()
-	weekday := int(now.Weekday())
-	if weekday == 0 {
-		weekday = 7
+// memberIDs parses the organization and person IDs and checks that the person belongs
+// to the organization, so one tenant cannot read another tenant's people
+func (s *RealWorkloadService) memberIDs(ctx context.Context, orgID, personID string) (uuid.UUID, uuid.UUID, error) {
+	orgUUID, err := uuid.Parse(orgID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	userUUID, err := uuid.Parse(personID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, ErrPersonNotFound
+	}
+	member, err := s.repos.GetOrganization().IsMember(ctx, orgUUID, userUUID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	if !member {
+		return uuid.Nil, uuid.Nil, ErrPersonNotFound
 	}
-	return now.AddDate(0, 0, -weekday+1).Truncate(24 * time.Hour)
+	return orgUUID, userUUID, nil
+}
+
+func getCurrentWeekStart() time.Time {
+	return startOfWeek(time.Now())
 }
 
 func (s *RealWorkloadService) getStatus(percentage int) string {
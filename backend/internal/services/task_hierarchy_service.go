@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+// ErrInvalidHierarchy is returned when a task cannot be placed where requested
+var ErrInvalidHierarchy = errors.New("invalid task hierarchy")
+
+// TaskNode is a task with its subtasks nested beneath it
+type TaskNode struct {
+	Task     models.Task
+	Subtasks []*TaskNode
+}
+
+// TaskHierarchyService defines the interface for keeping the task hierarchy valid and
+// acting on whole subtrees
+type TaskHierarchyService interface {
+	// Create creates a task, deriving its hierarchy level from its parent
+	Create(ctx context.Context, orgID uuid.UUID, task *models.Task) error
+
+	// Move moves a task with its subtasks under a new parent, or to the top level of
+	// a project when parentID is nil. The project defaults to the parent's, or to the
+	// task's own when there is no parent.
+	Move(ctx context.Context, orgID, taskID uuid.UUID, parentID, projectID *uuid.UUID) (*TaskNode, error)
+
+	// DeleteSubtree soft-deletes a task with all of its subtasks
+	DeleteSubtree(ctx context.Context, orgID, taskID uuid.UUID) error
+
+	// ArchiveSubtree archives, or unarchives, a task with all of its subtasks
+	ArchiveSubtree(ctx context.Context, orgID, taskID uuid.UUID, archived bool) (*TaskNode, error)
+
+	// Tree returns a task with its subtasks nested to the full depth
+	Tree(ctx context.Context, orgID, taskID uuid.UUID) (*TaskNode, error)
+
+	// ProjectTree returns a project's top-level tasks with their subtasks nested
+	ProjectTree(ctx context.Context, orgID, projectID uuid.UUID) ([]*TaskNode, error)
+}
+
+// RealTaskHierarchyService implements TaskHierarchyService
+type RealTaskHierarchyService struct {
+	repos *repositories.Provider
+	now   func() time.Time
+}
+
+// NewTaskHierarchyService creates a new task hierarchy service
+func NewTaskHierarchyService(repos *repositories.Provider) *RealTaskHierarchyService {
+	return &RealTaskHierarchyService{repos: repos, now: time.Now}
+}
+
+// Create creates a task under its parent, if it has one. The parent must be in the same
+// project, not archived, and above the deepest level; the task's level is one below
+// the parent's whatever the caller set.
+func (s *RealTaskHierarchyService) Create(ctx context.Context, orgID uuid.UUID, task *models.Task) error {
+	task.HierarchyLevel = 1
+	if task.ParentTaskID != nil {
+		parent, err := s.parent(ctx, s.repos, orgID, *task.ParentTaskID)
+		if err != nil {
+			return err
+		}
+		if parent.ProjectID != task.ProjectID {
+			return fmt.Errorf("%w: parent task is in another project", ErrInvalidHierarchy)
+		}
+		if parent.HierarchyLevel >= models.MaxHierarchyLevel {
+			return fmt.Errorf("%w: parent task is already at the deepest level (%d)", ErrInvalidHierarchy, models.MaxHierarchyLevel)
+		}
+		task.HierarchyLevel = parent.HierarchyLevel + 1
+	}
+	return s.repos.GetTask().Create(ctx, orgID, task)
+}
+
+// Move re-parents a task and re-levels its subtree. The task cannot move under itself
+// or one of its own subtasks, and the deepest moved subtask must stay within
+// models.MaxHierarchyLevel. Dependencies move with their tasks.
+func (s *RealTaskHierarchyService) Move(ctx context.Context, orgID, taskID uuid.UUID, parentID, projectID *uuid.UUID) (*TaskNode, error) {
+	err := s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		subtree, err := tx.GetTask().ListSubtree(ctx, orgID, taskID)
+		if err != nil {
+			return err
+		}
+		root := subtree[0]
+		depths := subtreeDepths(subtree)
+
+		level, targetProject := 1, root.ProjectID
+		if projectID != nil {
+			targetProject = *projectID
+		}
+		if parentID != nil {
+			if _, inSubtree := depths[*parentID]; inSubtree {
+				return fmt.Errorf("%w: a task cannot move under itself or its own subtask", ErrInvalidHierarchy)
+			}
+			parent, err := s.parent(ctx, tx, orgID, *parentID)
+			if err != nil {
+				return err
+			}
+			if projectID != nil && *projectID != parent.ProjectID {
+				return fmt.Errorf("%w: parent task is in another project", ErrInvalidHierarchy)
+			}
+			level, targetProject = parent.HierarchyLevel+1, parent.ProjectID
+		} else if targetProject != root.ProjectID {
+			if _, err := tx.GetProject().GetByID(ctx, orgID, targetProject); err != nil {
+				return err
+			}
+		}
+
+		deepest := 0
+		for _, depth := range depths {
+			deepest = max(deepest, depth)
+		}
+		if level+deepest > models.MaxHierarchyLevel {
+			return fmt.Errorf("%w: the move would nest subtasks %d levels deep; the limit is %d",
+				ErrInvalidHierarchy, level+deepest, models.MaxHierarchyLevel)
+		}
+
+		for _, t := range subtree {
+			parent := t.ParentTaskID
+			if t.ID == root.ID {
+				parent = parentID
+			}
+			if err := tx.GetTask().UpdateHierarchy(ctx, orgID, t.ID, targetProject, parent, level+depths[t.ID]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Tree(ctx, orgID, taskID)
+}
+
+// DeleteSubtree soft-deletes a task and its subtasks with their skill requirements,
+// dependencies, suggestions and nudges, and takes the open ones out of this week's
+// workload
+func (s *RealTaskHierarchyService) DeleteSubtree(ctx context.Context, orgID, taskID uuid.UUID) error {
+	return s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		subtree, err := tx.GetTask().ListSubtree(ctx, orgID, taskID)
+		if err != nil {
+			return err
+		}
+		if err := tx.GetTask().DeleteTasks(ctx, orgID, taskUUIDs(subtree)); err != nil {
+			return err
+		}
+		return adjustWorkload(ctx, tx, orgID, startOfWeek(s.now()), subtree, -1)
+	})
+}
+
+// ArchiveSubtree archives or unarchives a task and its subtasks. Archived tasks stay
+// visible but are read-only until unarchived.
+func (s *RealTaskHierarchyService) ArchiveSubtree(ctx context.Context, orgID, taskID uuid.UUID, archived bool) (*TaskNode, error) {
+	err := s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		subtree, err := tx.GetTask().ListSubtree(ctx, orgID, taskID)
+		if err != nil {
+			return err
+		}
+		var archivedAt *time.Time
+		if archived {
+			now := s.now()
+			archivedAt = &now
+		}
+		return tx.GetTask().SetArchived(ctx, orgID, taskUUIDs(subtree), archivedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Tree(ctx, orgID, taskID)
+}
+
+// Tree loads a task's subtree and nests it
+func (s *RealTaskHierarchyService) Tree(ctx context.Context, orgID, taskID uuid.UUID) (*TaskNode, error) {
+	subtree, err := s.repos.GetTask().ListSubtree(ctx, orgID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	// The root heads the tree even when older data loops back to it
+	parentID := subtree[0].ParentTaskID
+	subtree[0].ParentTaskID = nil
+	root := buildTaskForest(subtree)[0]
+	root.Task.ParentTaskID = parentID
+	return root, nil
+}
+
+// ProjectTree loads every task in a project and nests them under their parents. Tasks
+// whose parent is outside the project are treated as top-level.
+func (s *RealTaskHierarchyService) ProjectTree(ctx context.Context, orgID, projectID uuid.UUID) ([]*TaskNode, error) {
+	if _, err := s.repos.GetProject().GetByID(ctx, orgID, projectID); err != nil {
+		return nil, err
+	}
+	tasks, err := listProjectTasks(ctx, s.repos, orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return buildTaskForest(tasks), nil
+}
+
+// parent loads a prospective parent task, which must exist and not be archived
+func (s *RealTaskHierarchyService) parent(ctx context.Context, repos *repositories.Provider, orgID, parentID uuid.UUID) (*models.Task, error) {
+	parent, err := repos.GetTask().GetByID(ctx, orgID, parentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: parent task not found", ErrInvalidHierarchy)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if parent.ArchivedAt != nil {
+		return nil, repositories.ErrTaskArchived
+	}
+	return parent, nil
+}
+
+// subtreeDepths gives each task's depth below the first, which is the subtree root.
+// Tasks are listed parents before children.
+func subtreeDepths(subtree []models.Task) map[uuid.UUID]int {
+	depths := make(map[uuid.UUID]int, len(subtree))
+	for i, t := range subtree {
+		if i == 0 || t.ParentTaskID == nil {
+			depths[t.ID] = 0
+			continue
+		}
+		depths[t.ID] = depths[*t.ParentTaskID] + 1
+	}
+	return depths
+}
+
+// buildTaskForest nests tasks under their parents, keeping their order. Tasks whose
+// parent is not in the list become roots.
+func buildTaskForest(tasks []models.Task) []*TaskNode {
+	nodes := make(map[uuid.UUID]*TaskNode, len(tasks))
+	for _, t := range tasks {
+		nodes[t.ID] = &TaskNode{Task: t}
+	}
+	var roots []*TaskNode
+	for _, t := range tasks {
+		node := nodes[t.ID]
+		if t.ParentTaskID != nil {
+			if parent, ok := nodes[*t.ParentTaskID]; ok && parent != node {
+				parent.Subtasks = append(parent.Subtasks, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
+
+// taskUUIDs lists the IDs of tasks
+func taskUUIDs(tasks []models.Task) []uuid.UUID {
+	ids := make([]uuid.UUID, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+	return ids
+}
@@ -0,0 +1,300 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// hierarchyTaskRepo keeps tasks by ID and lists subtrees in creation order
+type hierarchyTaskRepo struct {
+	repositories.TaskRepository
+	tasks map[uuid.UUID]*models.Task
+	order []uuid.UUID
+}
+
+func (r *hierarchyTaskRepo) add(t models.Task) uuid.UUID {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	r.tasks[t.ID] = &t
+	r.order = append(r.order, t.ID)
+	return t.ID
+}
+
+func (r *hierarchyTaskRepo) Create(ctx context.Context, orgID uuid.UUID, t *models.Task) error {
+	t.ID = r.add(*t)
+	return nil
+}
+
+func (r *hierarchyTaskRepo) GetByID(ctx context.Context, orgID, id uuid.UUID) (*models.Task, error) {
+	t, ok := r.tasks[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	clone := *t
+	return &clone, nil
+}
+
+func (r *hierarchyTaskRepo) ListByProject(ctx context.Context, orgID, projectID uuid.UUID, params repositories.ListParams) ([]models.Task, int64, error) {
+	var out []models.Task
+	for _, id := range r.order {
+		if t, ok := r.tasks[id]; ok && t.ProjectID == projectID {
+			out = append(out, *t)
+		}
+	}
+	return out, int64(len(out)), nil
+}
+
+func (r *hierarchyTaskRepo) ListSubtree(ctx context.Context, orgID, rootID uuid.UUID) ([]models.Task, error) {
+	root, ok := r.tasks[rootID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	subtree := []models.Task{*root}
+	for i := 0; i < len(subtree); i++ {
+		for _, id := range r.order {
+			if t, ok := r.tasks[id]; ok && t.ParentTaskID != nil && *t.ParentTaskID == subtree[i].ID {
+				subtree = append(subtree, *t)
+			}
+		}
+	}
+	return subtree, nil
+}
+
+func (r *hierarchyTaskRepo) UpdateHierarchy(ctx context.Context, orgID, id, projectID uuid.UUID, parentID *uuid.UUID, level int) error {
+	t := r.tasks[id]
+	t.ProjectID, t.ParentTaskID, t.HierarchyLevel = projectID, parentID, level
+	return nil
+}
+
+func (r *hierarchyTaskRepo) DeleteTasks(ctx context.Context, orgID uuid.UUID, ids []uuid.UUID) error {
+	for _, id := range ids {
+		delete(r.tasks, id)
+	}
+	return nil
+}
+
+func (r *hierarchyTaskRepo) SetArchived(ctx context.Context, orgID uuid.UUID, ids []uuid.UUID, archivedAt *time.Time) error {
+	for _, id := range ids {
+		r.tasks[id].ArchivedAt = archivedAt
+	}
+	return nil
+}
+
+func (r *hierarchyTaskRepo) UpdateCriticalPath(ctx context.Context, orgID, projectID uuid.UUID, criticalIDs []uuid.UUID) error {
+	for _, t := range r.tasks {
+		if t.ProjectID == projectID {
+			t.IsCriticalPath = slices.Contains(criticalIDs, t.ID)
+		}
+	}
+	return nil
+}
+
+func (r *hierarchyTaskRepo) UpdateDates(ctx context.Context, orgID, id uuid.UUID, startDate, dueDate *time.Time) error {
+	t, ok := r.tasks[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	t.StartDate, t.DueDate = startDate, dueDate
+	return nil
+}
+
+var _ = Describe("Task Hierarchy Service", func() {
+	var (
+		ctx       context.Context
+		service   services.TaskHierarchyService
+		tasks     *hierarchyTaskRepo
+		workloads *lifecycleWorkloadRepo
+		orgID     uuid.UUID
+		projectID uuid.UUID
+		otherID   uuid.UUID
+		emma      uuid.UUID
+
+		epic, design, wireframes, build, standalone uuid.UUID
+	)
+
+	// titles flattens a tree depth-first into "title@level" entries
+	var titles func(node *services.TaskNode) []string
+	titles = func(node *services.TaskNode) []string {
+		out := []string{fmt.Sprintf("%s@%d", node.Task.Title, node.Task.HierarchyLevel)}
+		for _, child := range node.Subtasks {
+			out = append(out, titles(child)...)
+		}
+		return out
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		orgID, projectID, otherID, emma = uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+		tasks = &hierarchyTaskRepo{tasks: map[uuid.UUID]*models.Task{}}
+		epic = tasks.add(models.Task{ProjectID: projectID, Title: "Epic", HierarchyLevel: 1})
+		design = tasks.add(models.Task{ProjectID: projectID, ParentTaskID: &epic, Title: "Design", HierarchyLevel: 2,
+			Status: models.TaskStatusInProgress, AssigneeID: &emma, EstimatedHours: 10, ActualHours: 4})
+		wireframes = tasks.add(models.Task{ProjectID: projectID, ParentTaskID: &design, Title: "Wireframes", HierarchyLevel: 3})
+		build = tasks.add(models.Task{ProjectID: projectID, ParentTaskID: &epic, Title: "Build", HierarchyLevel: 2})
+		standalone = tasks.add(models.Task{ProjectID: projectID, Title: "Standalone", HierarchyLevel: 1})
+
+		projects := &lifecycleProjectRepo{
+			projects: map[uuid.UUID]*models.Project{
+				projectID: {BaseModel: models.BaseModel{ID: projectID}, OrganizationID: orgID, Name: "Launch"},
+				otherID:   {BaseModel: models.BaseModel{ID: otherID}, OrganizationID: orgID, Name: "Follow-up"},
+			},
+			deleted: map[uuid.UUID]bool{},
+		}
+		workloads = &lifecycleWorkloadRepo{entries: map[uuid.UUID]*models.WorkloadEntry{emma: {
+			OrganizationID:      orgID,
+			UserID:              emma,
+			AssignedTasks:       2,
+			TotalEstimatedHours: 16,
+			AvailableHours:      40,
+		}}}
+
+		service = services.NewTaskHierarchyService(&repositories.Provider{
+			Project:  projects,
+			Task:     tasks,
+			Workload: workloads,
+		})
+	})
+
+	Describe("Create", func() {
+		It("derives the level from the parent whatever the caller asked for", func() {
+			task := &models.Task{ProjectID: projectID, ParentTaskID: &build, Title: "API", HierarchyLevel: 1}
+			Expect(service.Create(ctx, orgID, task)).To(Succeed())
+			Expect(tasks.tasks[task.ID].HierarchyLevel).To(Equal(3))
+
+			top := &models.Task{ProjectID: projectID, Title: "Docs", HierarchyLevel: 3}
+			Expect(service.Create(ctx, orgID, top)).To(Succeed())
+			Expect(tasks.tasks[top.ID].HierarchyLevel).To(Equal(1))
+		})
+
+		It("rejects a parent at the deepest level", func() {
+			task := &models.Task{ProjectID: projectID, ParentTaskID: &wireframes, Title: "Too deep"}
+			Expect(service.Create(ctx, orgID, task)).To(MatchError(services.ErrInvalidHierarchy))
+		})
+
+		It("rejects a parent in another project", func() {
+			task := &models.Task{ProjectID: otherID, ParentTaskID: &epic, Title: "Stray"}
+			Expect(service.Create(ctx, orgID, task)).To(MatchError(services.ErrInvalidHierarchy))
+		})
+
+		It("rejects a parent that does not exist", func() {
+			missing := uuid.New()
+			task := &models.Task{ProjectID: projectID, ParentTaskID: &missing, Title: "Orphan"}
+			Expect(service.Create(ctx, orgID, task)).To(MatchError(services.ErrInvalidHierarchy))
+		})
+
+		It("rejects an archived parent", func() {
+			now := time.Now()
+			tasks.tasks[epic].ArchivedAt = &now
+
+			task := &models.Task{ProjectID: projectID, ParentTaskID: &epic, Title: "Late"}
+			Expect(service.Create(ctx, orgID, task)).To(MatchError(repositories.ErrTaskArchived))
+		})
+	})
+
+	Describe("Move", func() {
+		It("moves a subtree under a new parent and re-levels it", func() {
+			tree, err := service.Move(ctx, orgID, design, &standalone, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(titles(tree)).To(Equal([]string{"Design@2", "Wireframes@3"}))
+			Expect(*tasks.tasks[design].ParentTaskID).To(Equal(standalone))
+			Expect(*tasks.tasks[wireframes].ParentTaskID).To(Equal(design))
+		})
+
+		It("moves a subtree to the top level of another project", func() {
+			tree, err := service.Move(ctx, orgID, design, nil, &otherID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(titles(tree)).To(Equal([]string{"Design@1", "Wireframes@2"}))
+			Expect(tasks.tasks[design].ParentTaskID).To(BeNil())
+			Expect(tasks.tasks[design].ProjectID).To(Equal(otherID))
+			Expect(tasks.tasks[wireframes].ProjectID).To(Equal(otherID))
+		})
+
+		It("rejects moving a task under itself or its own subtask", func() {
+			_, err := service.Move(ctx, orgID, epic, &wireframes, nil)
+			Expect(err).To(MatchError(services.ErrInvalidHierarchy))
+			_, err = service.Move(ctx, orgID, epic, &epic, nil)
+			Expect(err).To(MatchError(services.ErrInvalidHierarchy))
+		})
+
+		It("rejects a move that would nest subtasks too deep", func() {
+			_, err := service.Move(ctx, orgID, epic, &standalone, nil)
+			Expect(err).To(MatchError(services.ErrInvalidHierarchy))
+			Expect(tasks.tasks[epic].ParentTaskID).To(BeNil())
+			Expect(tasks.tasks[wireframes].HierarchyLevel).To(Equal(3))
+		})
+
+		It("rejects a project that disagrees with the parent's", func() {
+			_, err := service.Move(ctx, orgID, build, &standalone, &otherID)
+			Expect(err).To(MatchError(services.ErrInvalidHierarchy))
+		})
+
+		It("reports an unknown target project as not found", func() {
+			missing := uuid.New()
+			_, err := service.Move(ctx, orgID, build, nil, &missing)
+			Expect(err).To(MatchError(gorm.ErrRecordNotFound))
+		})
+	})
+
+	Describe("DeleteSubtree", func() {
+		It("deletes a task with its subtasks and takes open work out of the workload", func() {
+			Expect(service.DeleteSubtree(ctx, orgID, epic)).To(Succeed())
+
+			remaining := []string{}
+			for _, t := range tasks.tasks {
+				remaining = append(remaining, t.Title)
+			}
+			Expect(remaining).To(ConsistOf("Standalone"))
+			Expect(workloads.entries[emma].AssignedTasks).To(Equal(1))
+			Expect(workloads.entries[emma].TotalEstimatedHours).To(Equal(10.0))
+		})
+	})
+
+	Describe("ArchiveSubtree", func() {
+		It("archives and unarchives a task with its subtasks", func() {
+			tree, err := service.ArchiveSubtree(ctx, orgID, design, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tree.Task.ArchivedAt).NotTo(BeNil())
+			Expect(tasks.tasks[wireframes].ArchivedAt).NotTo(BeNil())
+			Expect(tasks.tasks[epic].ArchivedAt).To(BeNil())
+			Expect(tasks.tasks[build].ArchivedAt).To(BeNil())
+
+			_, err = service.ArchiveSubtree(ctx, orgID, design, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks.tasks[design].ArchivedAt).To(BeNil())
+			Expect(tasks.tasks[wireframes].ArchivedAt).To(BeNil())
+		})
+	})
+
+	Describe("Trees", func() {
+		It("nests a task's subtasks to the full depth", func() {
+			tree, err := service.Tree(ctx, orgID, epic)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(titles(tree)).To(Equal([]string{"Epic@1", "Design@2", "Wireframes@3", "Build@2"}))
+		})
+
+		It("nests a whole project under its top-level tasks", func() {
+			roots, err := service.ProjectTree(ctx, orgID, projectID)
+			Expect(err).NotTo(HaveOccurred())
+			top := []string{}
+			for _, root := range roots {
+				top = append(top, root.Task.Title)
+			}
+			sort.Strings(top)
+			Expect(top).To(Equal([]string{"Epic", "Standalone"}))
+		})
+	})
+})
@@ -0,0 +1,419 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+// ErrInvalidPeriod is returned for an analytics period that ends before it starts
+var ErrInvalidPeriod = errors.New("invalid period")
+
+// defaultFlowPeriod is how far back flow metrics look when no start is given
+const defaultFlowPeriod = 12 * 7 * 24 * time.Hour
+
+// TaskHistoryService defines the interface for the task change log and the flow
+// analytics built on it
+type TaskHistoryService interface {
+	// RecordChanges logs the status, assignee, estimate and due date changes between
+	// two versions of a task
+	RecordChanges(ctx context.Context, orgID, actorID uuid.UUID, before, after *models.Task) error
+
+	// History returns a task's changes, oldest first
+	History(ctx context.Context, orgID, taskID uuid.UUID) ([]models.TaskEvent, error)
+
+	// ProjectFlow measures the tasks of a project completed in a period
+	ProjectFlow(ctx context.Context, orgID, projectID uuid.UUID, from, to time.Time) (*dto.FlowMetricsResponse, error)
+
+	// PersonFlow measures the tasks assigned to a person completed in a period
+	PersonFlow(ctx context.Context, orgID, userID uuid.UUID, from, to time.Time) (*dto.FlowMetricsResponse, error)
+}
+
+// RealTaskHistoryService implements TaskHistoryService
+type RealTaskHistoryService struct {
+	repos *repositories.Provider
+	now   func() time.Time
+}
+
+// NewTaskHistoryService creates a new task history service
+func NewTaskHistoryService(repos *repositories.Provider) *RealTaskHistoryService {
+	return &RealTaskHistoryService{repos: repos, now: time.Now}
+}
+
+// RecordChanges logs the changes between before and after as made by the actor now
+func (s *RealTaskHistoryService) RecordChanges(ctx context.Context, orgID, actorID uuid.UUID, before, after *models.Task) error {
+	return recordTaskChanges(ctx, s.repos, orgID, actorID, before, after, s.now())
+}
+
+// History loads a task's events once the task is known to be in the organization
+func (s *RealTaskHistoryService) History(ctx context.Context, orgID, taskID uuid.UUID) ([]models.TaskEvent, error) {
+	if _, err := s.repos.GetTask().GetByID(ctx, orgID, taskID); err != nil {
+		return nil, err
+	}
+	return s.repos.GetTaskEvent().ListByTask(ctx, orgID, taskID)
+}
+
+// ProjectFlow measures a project's completed tasks
+func (s *RealTaskHistoryService) ProjectFlow(ctx context.Context, orgID, projectID uuid.UUID, from, to time.Time) (*dto.FlowMetricsResponse, error) {
+	from, to, err := s.period(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repos.GetProject().GetByID(ctx, orgID, projectID); err != nil {
+		return nil, err
+	}
+	tasks, err := listProjectTasks(ctx, s.repos, orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := s.flow(ctx, orgID, tasks, from, to)
+	if err != nil {
+		return nil, err
+	}
+	metrics.ProjectID = projectID.String()
+	return metrics, nil
+}
+
+// PersonFlow measures the completed tasks currently assigned to a member
+func (s *RealTaskHistoryService) PersonFlow(ctx context.Context, orgID, userID uuid.UUID, from, to time.Time) (*dto.FlowMetricsResponse, error) {
+	from, to, err := s.period(from, to)
+	if err != nil {
+		return nil, err
+	}
+	member, err := s.repos.GetOrganization().IsMember(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !member {
+		return nil, fmt.Errorf("user not found: %w", gorm.ErrRecordNotFound)
+	}
+	tasks, err := listAssigneeTasks(ctx, s.repos, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := s.flow(ctx, orgID, tasks, from, to)
+	if err != nil {
+		return nil, err
+	}
+	metrics.UserID = userID.String()
+	return metrics, nil
+}
+
+// period fills in an open period, ending now and starting twelve weeks before its end
+func (s *RealTaskHistoryService) period(from, to time.Time) (time.Time, time.Time, error) {
+	if to.IsZero() {
+		to = s.now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultFlowPeriod)
+	}
+	if to.Before(from) {
+		return from, to, ErrInvalidPeriod
+	}
+	return from.UTC(), to.UTC(), nil
+}
+
+// flow computes the metrics of the tasks completed between from and to. Lead time runs
+// from creation to completion and cycle time from first starting work to completion.
+// Time in status replays the status changes logged before completion, so tasks done
+// before the log existed only count towards lead and cycle time.
+func (s *RealTaskHistoryService) flow(ctx context.Context, orgID uuid.UUID, tasks []models.Task, from, to time.Time) (*dto.FlowMetricsResponse, error) {
+	var completed []models.Task
+	for _, t := range tasks {
+		if t.Status == models.TaskStatusDone && t.CompletedAt != nil &&
+			!t.CompletedAt.Before(from) && !t.CompletedAt.After(to) {
+			completed = append(completed, t)
+		}
+	}
+
+	ids := make([]uuid.UUID, len(completed))
+	for i, t := range completed {
+		ids[i] = t.ID
+	}
+	events, err := s.repos.GetTaskEvent().ListByTasks(ctx, orgID, ids, models.TaskEventStatusChanged)
+	if err != nil {
+		return nil, err
+	}
+	history := map[uuid.UUID][]models.TaskEvent{}
+	for _, e := range events {
+		history[e.TaskID] = append(history[e.TaskID], e)
+	}
+	workflows := map[uuid.UUID]*models.Workflow{}
+	for _, t := range completed {
+		if _, ok := workflows[t.ProjectID]; ok || len(history[t.ID]) == 0 {
+			continue
+		}
+		workflow, err := projectWorkflow(ctx, s.repos, orgID, t.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		workflows[t.ProjectID] = workflow
+	}
+
+	var leadTimes, cycleTimes []float64
+	statusHours := map[string][]float64{}
+	categories := map[string]models.TaskStatus{}
+	throughput := map[time.Time]int{}
+	for _, t := range completed {
+		done := *t.CompletedAt
+		throughput[startOfWeek(done.UTC())]++
+		if lead := done.Sub(t.CreatedAt); lead >= 0 {
+			leadTimes = append(leadTimes, lead.Hours())
+		}
+		if started := workStarted(&t, workflows[t.ProjectID], history[t.ID]); started != nil && !done.Before(*started) {
+			cycleTimes = append(cycleTimes, done.Sub(*started).Hours())
+		}
+		for status, hours := range timeInStatus(&t, history[t.ID]) {
+			statusHours[status] = append(statusHours[status], hours)
+			if _, ok := categories[status]; !ok {
+				if known := workflowStatus(workflows[t.ProjectID], status); known != nil {
+					categories[status] = known.Category
+				}
+			}
+		}
+	}
+
+	metrics := &dto.FlowMetricsResponse{
+		From:           from,
+		To:             to,
+		CompletedTasks: len(completed),
+		LeadTime:       durationStats(leadTimes),
+		CycleTime:      durationStats(cycleTimes),
+		TimeInStatus:   []dto.StatusTime{},
+		Throughput:     []dto.WeeklyThroughput{},
+	}
+	for _, status := range sortedStatuses(statusHours, categories) {
+		hours := statusHours[status]
+		total := sum(hours)
+		metrics.TimeInStatus = append(metrics.TimeInStatus, dto.StatusTime{
+			Status:       status,
+			Tasks:        len(hours),
+			TotalHours:   roundHours(total),
+			AverageHours: roundHours(total / float64(len(hours))),
+		})
+	}
+	for week := startOfWeek(from); !week.After(to); week = week.AddDate(0, 0, 7) {
+		metrics.Throughput = append(metrics.Throughput, dto.WeeklyThroughput{WeekStart: week, Completed: throughput[week]})
+	}
+	return metrics, nil
+}
+
+// workStarted is when a task first moved to a status past ready, falling back to its
+// start date. Logged statuses are workflow keys, read through the project's workflow and
+// taken as canonical statuses when it no longer has them.
+func workStarted(task *models.Task, workflow *models.Workflow, events []models.TaskEvent) *time.Time {
+	for _, e := range events {
+		category := models.TaskStatus(e.ToValue)
+		if workflow != nil {
+			if status := workflowStatus(workflow, e.ToValue); status != nil {
+				category = status.Category
+			}
+		}
+		switch category {
+		case models.TaskStatusInProgress, models.TaskStatusReview, models.TaskStatusDone:
+			at := e.OccurredAt
+			return &at
+		}
+	}
+	return task.StartDate
+}
+
+// timeInStatus replays a task's status changes from its creation, adding up the hours
+// spent in each workflow status it left. A status logged at creation starts the replay.
+func timeInStatus(task *models.Task, events []models.TaskEvent) map[string]float64 {
+	if len(events) == 0 {
+		return nil
+	}
+	hours := map[string]float64{}
+	status, since := events[0].FromValue, task.CreatedAt
+	for _, e := range events {
+		switch {
+		case status == "":
+			// The task did not exist before its first event
+		case e.OccurredAt.After(since):
+			hours[status] += e.OccurredAt.Sub(since).Hours()
+		default:
+			if _, ok := hours[status]; !ok {
+				hours[status] = 0
+			}
+		}
+		status, since = e.ToValue, e.OccurredAt
+	}
+	return hours
+}
+
+// sortedStatuses orders statuses by their category along the canonical flow, a
+// category's own status first and the rest by name, then statuses of unknown category
+// by name
+func sortedStatuses(statuses map[string][]float64, categories map[string]models.TaskStatus) []string {
+	rank := map[models.TaskStatus]int{}
+	for i, s := range []models.TaskStatus{
+		models.TaskStatusBacklog, models.TaskStatusReady, models.TaskStatusInProgress,
+		models.TaskStatusReview, models.TaskStatusDone,
+	} {
+		rank[s] = i + 1
+	}
+	order := func(status string) int {
+		category, ok := categories[status]
+		if !ok {
+			category = models.TaskStatus(status)
+		}
+		if r := rank[category]; r > 0 {
+			return r
+		}
+		return len(rank) + 1
+	}
+	out := make([]string, 0, len(statuses))
+	for status := range statuses {
+		out = append(out, status)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		ri, rj := order(out[i]), order(out[j])
+		if ri != rj {
+			return ri < rj
+		}
+		ci, cj := rank[models.TaskStatus(out[i])] > 0, rank[models.TaskStatus(out[j])] > 0
+		if ci != cj {
+			return ci
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+// durationStats summarizes hours with their mean, median and 85th percentile
+func durationStats(hours []float64) dto.DurationStats {
+	if len(hours) == 0 {
+		return dto.DurationStats{}
+	}
+	sorted := append([]float64(nil), hours...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	median := sorted[n/2]
+	if n%2 == 0 {
+		median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return dto.DurationStats{
+		Count:        n,
+		AverageHours: roundHours(sum(sorted) / float64(n)),
+		MedianHours:  roundHours(median),
+		P85Hours:     roundHours(sorted[int(math.Ceil(0.85*float64(n)))-1]),
+	}
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// roundHours rounds to a hundredth of an hour
+func roundHours(hours float64) float64 {
+	return math.Round(hours*100) / 100
+}
+
+// listAssigneeTasks pages through all tasks assigned to a user
+func listAssigneeTasks(ctx context.Context, repos *repositories.Provider, orgID, userID uuid.UUID) ([]models.Task, error) {
+	params := repositories.ListParams{Limit: 100, SortBy: "created_at", SortOrder: "asc"}
+	var all []models.Task
+	for {
+		page, total, err := repos.GetTask().ListByAssignee(ctx, orgID, userID, params)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) == 0 || int64(len(all)) >= total {
+			return all, nil
+		}
+		params.Offset += len(page)
+	}
+}
+
+// recordTaskChanges logs an event for each of the task's status, assignee, estimate and
+// due date that differs between before and after. Statuses are logged by their workflow
+// key, so moves between two statuses of the same category are kept. A nil before logs
+// a new task's initial values. A nil actor ID records a change made by the system.
+func recordTaskChanges(ctx context.Context, repos *repositories.Provider, orgID, actorID uuid.UUID, before, after *models.Task, at time.Time) error {
+	var actor *uuid.UUID
+	if actorID != uuid.Nil {
+		actor = &actorID
+	}
+	record := func(eventType models.TaskEventType, from, to string) error {
+		if from == to {
+			return nil
+		}
+		return repos.GetTaskEvent().Record(ctx, orgID, &models.TaskEvent{
+			ProjectID:  after.ProjectID,
+			TaskID:     after.ID,
+			ActorID:    actor,
+			Type:       eventType,
+			FromValue:  from,
+			ToValue:    to,
+			OccurredAt: at,
+		})
+	}
+
+	from, to := taskValues(before), taskValues(after)
+	for _, eventType := range []models.TaskEventType{
+		models.TaskEventStatusChanged, models.TaskEventAssigneeChanged,
+		models.TaskEventEstimateChanged, models.TaskEventDueDateChanged,
+	} {
+		if err := record(eventType, from[eventType], to[eventType]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// taskValues are the values of a task's tracked fields as events store them, all empty
+// for a task that did not exist yet
+func taskValues(task *models.Task) map[models.TaskEventType]string {
+	if task == nil {
+		return map[models.TaskEventType]string{}
+	}
+	return map[models.TaskEventType]string{
+		models.TaskEventStatusChanged:   statusKey(task),
+		models.TaskEventAssigneeChanged: uuidValue(task.AssigneeID),
+		models.TaskEventEstimateChanged: hoursValue(task.EstimatedHours),
+		models.TaskEventDueDateChanged:  timeValue(task.DueDate),
+	}
+}
+
+// statusKey is the task's workflow status, or its canonical status when it has none
+func statusKey(task *models.Task) string {
+	if task.WorkflowStatus != "" {
+		return task.WorkflowStatus
+	}
+	return string(task.Status)
+}
+
+func uuidValue(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func hoursValue(hours float64) string {
+	return strconv.FormatFloat(hours, 'f', -1, 64)
+}
+
+func timeValue(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
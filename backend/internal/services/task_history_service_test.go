@@ -0,0 +1,217 @@
+package services_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// taskEventRepo keeps task events in the order they were recorded
+type taskEventRepo struct {
+	repositories.TaskEventRepository
+	events []models.TaskEvent
+}
+
+func (r *taskEventRepo) Record(ctx context.Context, orgID uuid.UUID, e *models.TaskEvent) error {
+	e.ID, e.OrganizationID = uuid.New(), orgID
+	r.events = append(r.events, *e)
+	return nil
+}
+
+func (r *taskEventRepo) ListByTask(ctx context.Context, orgID, taskID uuid.UUID) ([]models.TaskEvent, error) {
+	return r.ListByTasks(ctx, orgID, []uuid.UUID{taskID})
+}
+
+func (r *taskEventRepo) ListByTasks(ctx context.Context, orgID uuid.UUID, taskIDs []uuid.UUID, types ...models.TaskEventType) ([]models.TaskEvent, error) {
+	var out []models.TaskEvent
+	for _, e := range r.events {
+		for _, id := range taskIDs {
+			if e.TaskID == id && (len(types) == 0 || e.Type == types[0]) {
+				out = append(out, e)
+			}
+		}
+	}
+	return out, nil
+}
+
+var _ = Describe("Task History Service", func() {
+	var (
+		ctx       context.Context
+		service   services.TaskHistoryService
+		tasks     *hierarchyTaskRepo
+		events    *taskEventRepo
+		workflows *workflowRepo
+		orgID     uuid.UUID
+		projectID uuid.UUID
+		emma      uuid.UUID
+		actorID   uuid.UUID
+	)
+
+	day := func(d int) time.Time {
+		return time.Date(2026, time.September, d, 0, 0, 0, 0, time.UTC)
+	}
+	at := func(d int) *time.Time {
+		t := day(d)
+		return &t
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		orgID, projectID, emma, actorID = uuid.New(), uuid.New(), uuid.New(), uuid.New()
+		tasks = &hierarchyTaskRepo{tasks: map[uuid.UUID]*models.Task{}}
+		events = &taskEventRepo{}
+		workflows = &workflowRepo{workflows: map[uuid.UUID]*models.Workflow{}}
+
+		service = services.NewTaskHistoryService(&repositories.Provider{
+			Project: &lifecycleProjectRepo{
+				projects: map[uuid.UUID]*models.Project{projectID: {BaseModel: models.BaseModel{ID: projectID}, OrganizationID: orgID}},
+				deleted:  map[uuid.UUID]bool{},
+			},
+			Task:      tasks,
+			TaskEvent: events,
+			Workflow:  workflows,
+		})
+	})
+
+	Describe("RecordChanges", func() {
+		It("logs one event per tracked field that changed", func() {
+			before := models.Task{BaseModel: models.BaseModel{ID: uuid.New()}, ProjectID: projectID, Title: "Build", Status: models.TaskStatusReady, EstimatedHours: 8}
+			after := before
+			after.Title, after.Status, after.AssigneeID, after.DueDate = "Build API", models.TaskStatusInProgress, &emma, at(30)
+
+			Expect(service.RecordChanges(ctx, orgID, actorID, &before, &after)).To(Succeed())
+			Expect(events.events).To(HaveLen(3))
+
+			Expect(events.events[0].Type).To(Equal(models.TaskEventStatusChanged))
+			Expect(events.events[0].FromValue).To(Equal("ready"))
+			Expect(events.events[0].ToValue).To(Equal("in_progress"))
+			Expect(*events.events[0].ActorID).To(Equal(actorID))
+			Expect(events.events[1].Type).To(Equal(models.TaskEventAssigneeChanged))
+			Expect(events.events[1].ToValue).To(Equal(emma.String()))
+			Expect(events.events[2].Type).To(Equal(models.TaskEventDueDateChanged))
+			Expect(events.events[2].FromValue).To(BeEmpty())
+			Expect(events.events[2].ToValue).To(Equal("2026-09-30T00:00:00Z"))
+		})
+
+		It("logs moves between workflow statuses of the same category", func() {
+			before := models.Task{BaseModel: models.BaseModel{ID: uuid.New()}, ProjectID: projectID, Status: models.TaskStatusReview, WorkflowStatus: "code_review"}
+			after := before
+			after.WorkflowStatus = "qa"
+
+			Expect(service.RecordChanges(ctx, orgID, actorID, &before, &after)).To(Succeed())
+			Expect(events.events).To(HaveLen(1))
+			Expect(events.events[0].FromValue).To(Equal("code_review"))
+			Expect(events.events[0].ToValue).To(Equal("qa"))
+		})
+
+		It("records changes without an actor as the system's", func() {
+			before := models.Task{BaseModel: models.BaseModel{ID: uuid.New()}, ProjectID: projectID, EstimatedHours: 8}
+			after := before
+			after.EstimatedHours = 12.5
+
+			Expect(service.RecordChanges(ctx, orgID, uuid.Nil, &before, &after)).To(Succeed())
+			Expect(events.events).To(HaveLen(1))
+			Expect(events.events[0].ActorID).To(BeNil())
+			Expect(events.events[0].ToValue).To(Equal("12.5"))
+		})
+	})
+
+	Describe("History", func() {
+		It("rejects tasks outside the organization", func() {
+			_, err := service.History(ctx, orgID, uuid.New())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ProjectFlow", func() {
+		BeforeEach(func() {
+			// Shipped moved through every status a day at a time
+			shipped := tasks.add(models.Task{
+				BaseModel: models.BaseModel{CreatedAt: day(7)}, ProjectID: projectID, AssigneeID: &emma,
+				Status: models.TaskStatusDone, CompletedAt: at(10),
+			})
+			flow := []string{"ready", "in_progress", "review", "done"}
+			for i := 1; i < len(flow); i++ {
+				events.events = append(events.events, models.TaskEvent{
+					TaskID: shipped, Type: models.TaskEventStatusChanged, FromValue: flow[i-1], ToValue: flow[i], OccurredAt: day(7 + i),
+				})
+			}
+			// Hotfix was finished before the log existed, so only its dates count
+			tasks.add(models.Task{
+				BaseModel: models.BaseModel{CreatedAt: day(7)}, ProjectID: projectID,
+				Status: models.TaskStatusDone, StartDate: at(14), CompletedAt: at(15),
+			})
+			tasks.add(models.Task{BaseModel: models.BaseModel{CreatedAt: day(1)}, ProjectID: projectID, Status: models.TaskStatusDone, CompletedAt: at(2)})
+			tasks.add(models.Task{BaseModel: models.BaseModel{CreatedAt: day(7)}, ProjectID: projectID, Status: models.TaskStatusInProgress})
+		})
+
+		It("measures lead time, cycle time, time in status and throughput of completed tasks", func() {
+			metrics, err := service.ProjectFlow(ctx, orgID, projectID, day(7), day(20))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metrics.ProjectID).To(Equal(projectID.String()))
+			Expect(metrics.CompletedTasks).To(Equal(2))
+
+			Expect(metrics.LeadTime.Count).To(Equal(2))
+			Expect(metrics.LeadTime.AverageHours).To(Equal(132.0))
+			Expect(metrics.LeadTime.P85Hours).To(Equal(192.0))
+			Expect(metrics.CycleTime.MedianHours).To(Equal(36.0))
+			Expect(metrics.CycleTime.P85Hours).To(Equal(48.0))
+
+			Expect(metrics.TimeInStatus).To(HaveLen(3))
+			for i, status := range []string{"ready", "in_progress", "review"} {
+				Expect(metrics.TimeInStatus[i].Status).To(Equal(status))
+				Expect(metrics.TimeInStatus[i].Tasks).To(Equal(1))
+				Expect(metrics.TimeInStatus[i].TotalHours).To(Equal(24.0))
+			}
+
+			Expect(metrics.Throughput).To(HaveLen(2))
+			Expect(metrics.Throughput[0].WeekStart).To(Equal(day(7)))
+			Expect(metrics.Throughput[0].Completed).To(Equal(1))
+			Expect(metrics.Throughput[1].WeekStart).To(Equal(day(14)))
+			Expect(metrics.Throughput[1].Completed).To(Equal(1))
+		})
+
+		It("reports time in each workflow status of a custom workflow", func() {
+			workflows.workflows[projectID] = &models.Workflow{ProjectID: projectID, Statuses: []models.WorkflowStatus{
+				{Key: "todo", Category: models.TaskStatusReady},
+				{Key: "doing", Category: models.TaskStatusInProgress},
+				{Key: "qa", Category: models.TaskStatusReview},
+				{Key: "code_review", Category: models.TaskStatusReview},
+				{Key: "shipped", Category: models.TaskStatusDone},
+			}}
+			released := tasks.add(models.Task{
+				BaseModel: models.BaseModel{CreatedAt: day(7)}, ProjectID: projectID,
+				Status: models.TaskStatusDone, WorkflowStatus: "shipped", CompletedAt: at(11),
+			})
+			flow := []string{"todo", "doing", "code_review", "qa", "shipped"}
+			for i := 1; i < len(flow); i++ {
+				events.events = append(events.events, models.TaskEvent{
+					TaskID: released, Type: models.TaskEventStatusChanged, FromValue: flow[i-1], ToValue: flow[i], OccurredAt: day(7 + i),
+				})
+			}
+
+			metrics, err := service.ProjectFlow(ctx, orgID, projectID, day(7), day(20))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metrics.CompletedTasks).To(Equal(3))
+			Expect(metrics.CycleTime.MedianHours).To(Equal(48.0))
+
+			var statuses []string
+			for _, status := range metrics.TimeInStatus {
+				statuses = append(statuses, status.Status)
+			}
+			Expect(statuses).To(Equal([]string{"ready", "todo", "in_progress", "doing", "review", "code_review", "qa"}))
+		})
+
+		It("rejects a period that ends before it starts", func() {
+			_, err := service.ProjectFlow(ctx, orgID, projectID, day(20), day(7))
+			Expect(err).To(MatchError(services.ErrInvalidPeriod))
+		})
+	})
+})
@@ -0,0 +1,407 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+// TaskScheduleService defines the interface for auto-scheduling: moving the tasks that
+// depend on a task when its dates or status change
+type TaskScheduleService interface {
+	// Preview shows how a change to a task's dates or status would move the tasks
+	// depending on it, without saving anything
+	Preview(ctx context.Context, orgID, taskID uuid.UUID, change dto.ScheduleChangeRequest) (*dto.SchedulePreviewResponse, error)
+
+	// Cascade moves the tasks depending on a task that has just changed from before,
+	// and raises delay risk nudges for the milestones and target end date it pushes.
+	// It returns nil when the task's project does not schedule automatically.
+	Cascade(ctx context.Context, orgID, actorID uuid.UUID, before *models.Task) (*dto.SchedulePreviewResponse, error)
+}
+
+// RealTaskScheduleService implements TaskScheduleService over the dependencies within
+// a project
+type RealTaskScheduleService struct {
+	repos    *repositories.Provider
+	notifier NotificationDispatcher
+	now      func() time.Time
+}
+
+// NewTaskScheduleService creates a new task schedule service. Delay risk nudges are sent
+// through the notifier when one is given.
+func NewTaskScheduleService(repos *repositories.Provider, notifier NotificationDispatcher) *RealTaskScheduleService {
+	return &RealTaskScheduleService{repos: repos, notifier: notifier, now: time.Now}
+}
+
+// Preview applies the change to a copy of the task, the way a status transition would
+// set its start and completion dates, and cascades it
+func (s *RealTaskScheduleService) Preview(ctx context.Context, orgID, taskID uuid.UUID, change dto.ScheduleChangeRequest) (*dto.SchedulePreviewResponse, error) {
+	task, err := s.repos.GetTask().GetByID(ctx, orgID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	changed := *task
+	if change.StartDate != nil {
+		changed.StartDate = change.StartDate
+	}
+	if change.DueDate != nil {
+		changed.DueDate = change.DueDate
+	}
+	if change.Status != "" {
+		workflow, err := projectWorkflow(ctx, s.repos, orgID, task.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		target := workflowStatus(workflow, change.Status)
+		if target == nil {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownTaskStatus, change.Status)
+		}
+		now := s.now()
+		if changed.StartDate == nil && target.Category != models.TaskStatusBacklog && target.Category != models.TaskStatusReady {
+			changed.StartDate = &now
+		}
+		switch {
+		case target.Category != models.TaskStatusDone:
+			changed.CompletedAt = nil
+		case task.Status != models.TaskStatusDone || task.CompletedAt == nil:
+			changed.CompletedAt = &now
+		}
+		changed.Status = target.Category
+	}
+
+	plan, err := s.plan(ctx, orgID, task, &changed)
+	if err != nil {
+		return nil, err
+	}
+	return plan.response(), nil
+}
+
+// Cascade saves the moved dates of the dependent tasks in one transaction and logs them
+// in the tasks' history as the actor's changes
+func (s *RealTaskScheduleService) Cascade(ctx context.Context, orgID, actorID uuid.UUID, before *models.Task) (*dto.SchedulePreviewResponse, error) {
+	task, err := s.repos.GetTask().GetByID(ctx, orgID, before.ID)
+	if err != nil {
+		return nil, err
+	}
+	project, err := s.repos.GetProject().GetByID(ctx, orgID, task.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if !project.AutoSchedule {
+		return nil, nil
+	}
+	plan, err := s.plan(ctx, orgID, before, task)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	err = s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		for _, shift := range plan.shifts {
+			old, moved := plan.before[shift.taskID], plan.graph.tasks[shift.taskID]
+			if err := tx.GetTask().UpdateDates(ctx, orgID, shift.taskID, moved.StartDate, moved.DueDate); err != nil {
+				return err
+			}
+			if err := recordTaskChanges(ctx, tx, orgID, actorID, &old, moved, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := plan.response()
+	if resp.NudgeIDs, err = s.raiseDelayRisks(ctx, orgID, plan, resp, now); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// schedulePlan is a change to one task and how it moves the tasks depending on it
+type schedulePlan struct {
+	project *models.Project
+	source  uuid.UUID
+
+	// before holds the project's tasks as they were before the change; graph holds
+	// them with the change and its cascade applied
+	before map[uuid.UUID]models.Task
+	graph  *dependencyGraph
+
+	shifts    []scheduleShift
+	conflicts []dto.ScheduleConflict
+}
+
+// scheduleShift is how many working hours a task moves, and the predecessor that
+// pushed it
+type scheduleShift struct {
+	taskID, causedBy uuid.UUID
+	hours            float64
+}
+
+// plan cascades the change from before to after through the project's dependencies, in
+// dependency order, so each task moves far enough for every predecessor after they have
+// moved. Tasks only ever move later, by working hours so they keep their length in
+// working time. Done and archived tasks stay where they are, and so do tasks with
+// pinned dates, which are reported as conflicts instead.
+func (s *RealTaskScheduleService) plan(ctx context.Context, orgID uuid.UUID, before, after *models.Task) (*schedulePlan, error) {
+	project, err := s.repos.GetProject().GetByID(ctx, orgID, after.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := listProjectTasks(ctx, s.repos, orgID, after.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := s.repos.GetDependency().ListByProject(ctx, orgID, after.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &schedulePlan{project: project, source: after.ID, before: make(map[uuid.UUID]models.Task, len(tasks))}
+	for i := range tasks {
+		p.before[tasks[i].ID] = tasks[i]
+		if tasks[i].ID == after.ID {
+			tasks[i] = *after
+		}
+	}
+	p.before[before.ID] = *before
+	p.graph = newDependencyGraph(tasks, deps)
+	order, err := p.graph.topologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	downstream := map[uuid.UUID]bool{}
+	for _, chain := range p.graph.downstream(after.ID) {
+		downstream[chain.path[len(chain.path)-1]] = true
+	}
+	for _, id := range order {
+		task := p.graph.tasks[id]
+		if !downstream[id] || task.Status == models.TaskStatusDone || task.ArchivedAt != nil {
+			continue
+		}
+		hours, causedBy := p.requiredShift(id)
+		if hours <= 0 {
+			continue
+		}
+		if task.DatesPinned {
+			p.conflicts = append(p.conflicts, dto.ScheduleConflict{
+				TaskID:          id.String(),
+				Title:           task.Title,
+				DependsOnTaskID: causedBy.String(),
+				OverlapHours:    roundHours(hours),
+			})
+			continue
+		}
+		if task.StartDate != nil {
+			start := nextWorkingTime(addWorkingHours(*task.StartDate, hours))
+			task.StartDate = &start
+		}
+		if task.DueDate != nil {
+			due := addWorkingHours(*task.DueDate, hours)
+			task.DueDate = &due
+		}
+		p.shifts = append(p.shifts, scheduleShift{taskID: id, causedBy: causedBy, hours: hours})
+	}
+	return p, nil
+}
+
+// requiredShift is how many working hours later a task must move to satisfy all its
+// dependencies, with the predecessor needing the largest move. Finish dependencies wait for the
+// predecessor's completion once it is done, and its due date until then. A dependency
+// on a date either task lacks is left out.
+func (p *schedulePlan) requiredShift(id uuid.UUID) (float64, uuid.UUID) {
+	task := p.graph.tasks[id]
+	var shift float64
+	var causedBy uuid.UUID
+	for _, d := range p.graph.predecessors[id] {
+		predecessor := p.graph.tasks[d.DependsOnTaskID]
+		from, to := scheduledFinish(predecessor), task.StartDate
+		switch d.DependencyType {
+		case models.DependencyStartToStart:
+			from = predecessor.StartDate
+		case models.DependencyFinishToFinish:
+			to = task.DueDate
+		case models.DependencyStartToFinish:
+			from, to = predecessor.StartDate, task.DueDate
+		}
+		if from == nil || to == nil {
+			continue
+		}
+		required := addWorkingHours(*from, float64(d.LagHours))
+		if gap := workingHoursBetween(*to, required); required.After(*to) && gap > shift {
+			shift, causedBy = gap, d.DependsOnTaskID
+		}
+	}
+	return shift, causedBy
+}
+
+// response describes the plan's moves and what they do to the project's end date
+func (p *schedulePlan) response() *dto.SchedulePreviewResponse {
+	resp := &dto.SchedulePreviewResponse{
+		TaskID:                 p.source.String(),
+		ProjectID:              p.project.ID.String(),
+		AutoSchedule:           p.project.AutoSchedule,
+		Changes:                make([]dto.ScheduledTaskChange, 0, len(p.shifts)),
+		Conflicts:              p.conflicts,
+		PreviousProjectEndDate: p.projectEnd(func(id uuid.UUID) *models.Task { t := p.before[id]; return &t }),
+		ProjectEndDate:         p.projectEnd(func(id uuid.UUID) *models.Task { return p.graph.tasks[id] }),
+		TargetEndDate:          p.project.TargetEndDate,
+	}
+	if resp.Conflicts == nil {
+		resp.Conflicts = []dto.ScheduleConflict{}
+	}
+	for _, shift := range p.shifts {
+		old, moved := p.before[shift.taskID], p.graph.tasks[shift.taskID]
+		resp.Changes = append(resp.Changes, dto.ScheduledTaskChange{
+			TaskID:       shift.taskID.String(),
+			Title:        moved.Title,
+			IsMilestone:  moved.IsMilestone,
+			OldStartDate: old.StartDate,
+			NewStartDate: moved.StartDate,
+			OldDueDate:   old.DueDate,
+			NewDueDate:   moved.DueDate,
+			ShiftHours:   roundHours(shift.hours),
+			CausedBy:     shift.causedBy.String(),
+		})
+	}
+	end, target, previous := resp.ProjectEndDate, resp.TargetEndDate, resp.PreviousProjectEndDate
+	resp.PushesTargetEndDate = end != nil && target != nil && end.After(*target) && (previous == nil || end.After(*previous))
+	return resp
+}
+
+// projectEnd is the latest scheduled finish of the project's tasks, as given by version
+func (p *schedulePlan) projectEnd(version func(uuid.UUID) *models.Task) *time.Time {
+	var end *time.Time
+	for _, id := range p.graph.order {
+		task := version(id)
+		if finish := scheduledFinish(task); task.ArchivedAt == nil && finish != nil && (end == nil || finish.After(*end)) {
+			end = finish
+		}
+	}
+	return end
+}
+
+// slippedMilestones lists the milestones the change or its cascade moved to a later due
+// date
+func (p *schedulePlan) slippedMilestones() []uuid.UUID {
+	var slipped []uuid.UUID
+	candidates := []uuid.UUID{p.source}
+	for _, shift := range p.shifts {
+		candidates = append(candidates, shift.taskID)
+	}
+	for _, id := range candidates {
+		old, moved := p.before[id], p.graph.tasks[id]
+		if moved.IsMilestone && old.DueDate != nil && moved.DueDate != nil && moved.DueDate.After(*old.DueDate) {
+			slipped = append(slipped, id)
+		}
+	}
+	return slipped
+}
+
+// raiseDelayRisks opens delay risk nudges for the milestones the cascade pushed back and
+// for a target end date it pushed past, or refreshes the open ones for the same
+// condition. It returns the IDs of the nudges.
+func (s *RealTaskScheduleService) raiseDelayRisks(ctx context.Context, orgID uuid.UUID, plan *schedulePlan, resp *dto.SchedulePreviewResponse, now time.Time) ([]string, error) {
+	project := plan.project
+	var detected []models.Nudge
+	for _, id := range plan.slippedMilestones() {
+		old, moved := plan.before[id], plan.graph.tasks[id]
+		severity := models.NudgeSeverityMedium
+		if project.TargetEndDate != nil && moved.DueDate.After(*project.TargetEndDate) {
+			severity = models.NudgeSeverityHigh
+		}
+		projectID, taskID := project.ID, id
+		detected = append(detected, models.Nudge{
+			Severity:         severity,
+			Title:            fmt.Sprintf("Milestone %s slipped", moved.Title),
+			Description:      fmt.Sprintf("%s moved from %s to %s to stay after the work it depends on.", moved.Title, old.DueDate.Format("Jan 2"), moved.DueDate.Format("Jan 2")),
+			AIExplanation:    "Auto-scheduling pushed the milestone back because a task it depends on moved or finished late.",
+			SuggestedAction:  "Review the delayed predecessors or move scope off the milestone's path",
+			ConfidenceScore:  0.9,
+			RelatedProjectID: &projectID,
+			RelatedTaskID:    &taskID,
+			Metrics: models.JSONB{
+				"previousDueDate": old.DueDate.Format(time.RFC3339),
+				"newDueDate":      moved.DueDate.Format(time.RFC3339),
+				"slipHours":       roundHours(moved.DueDate.Sub(*old.DueDate).Hours()),
+				"isCriticalPath":  moved.IsCriticalPath,
+			},
+		})
+	}
+	if resp.PushesTargetEndDate {
+		projectID := project.ID
+		detected = append(detected, models.Nudge{
+			Severity:         models.NudgeSeverityHigh,
+			Title:            fmt.Sprintf("%s is projected to finish late", project.Name),
+			Description:      fmt.Sprintf("%s is now scheduled to finish on %s, after its target end date of %s.", project.Name, resp.ProjectEndDate.Format("Jan 2"), project.TargetEndDate.Format("Jan 2")),
+			AIExplanation:    "Auto-scheduling moved the project's last tasks past its target end date.",
+			SuggestedAction:  "Review scope or add capacity to critical path tasks",
+			ConfidenceScore:  0.9,
+			RelatedProjectID: &projectID,
+			Metrics: models.JSONB{
+				"projectedEndDate": resp.ProjectEndDate.Format(time.RFC3339),
+				"targetEndDate":    project.TargetEndDate.Format(time.RFC3339),
+				"overrunHours":     roundHours(resp.ProjectEndDate.Sub(*project.TargetEndDate).Hours()),
+			},
+		})
+	}
+	if len(detected) == 0 {
+		return nil, nil
+	}
+	for i := range detected {
+		completeNudge(&detected[i], models.NudgeTypeDelayRisk, now)
+	}
+
+	open, err := s.repos.GetNudge().ListOpen(ctx, orgID, []models.NudgeType{models.NudgeTypeDelayRisk})
+	if err != nil {
+		return nil, err
+	}
+	reconciled := ReconcileNudges(open, detected, func(models.Nudge) bool { return false }, now)
+	var ids []string
+	for i := range reconciled.Create {
+		if err := s.repos.GetNudge().Create(ctx, orgID, &reconciled.Create[i]); err != nil {
+			return nil, err
+		}
+		ids = append(ids, reconciled.Create[i].ID.String())
+		s.notify(ctx, &reconciled.Create[i])
+	}
+	for i := range reconciled.Refresh {
+		if err := s.repos.GetNudge().Update(ctx, orgID, &reconciled.Refresh[i]); err != nil {
+			return nil, err
+		}
+		ids = append(ids, reconciled.Refresh[i].ID.String())
+	}
+	return ids, nil
+}
+
+// notify sends a new nudge through the notifier, if there is one
+func (s *RealTaskScheduleService) notify(ctx context.Context, nudge *models.Nudge) {
+	if s.notifier == nil {
+		return
+	}
+	recipients, err := nudgeRecipients(ctx, s.repos, nudge)
+	if err == nil {
+		_, err = s.notifier.NotifyNudge(ctx, nudge, recipients)
+	}
+	if err != nil {
+		log.Printf("[TaskScheduleService] Error notifying about nudge %s: %v", nudge.ID, err)
+	}
+}
+
+// scheduledFinish is when a task finished, once it is done, or else when it is due
+func scheduledFinish(t *models.Task) *time.Time {
+	if t.Status == models.TaskStatusDone && t.CompletedAt != nil {
+		return t.CompletedAt
+	}
+	return t.DueDate
+}
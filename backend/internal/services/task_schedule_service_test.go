@@ -0,0 +1,255 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/SimpleAjax/Xephyr/internal/dto"
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+var _ = Describe("Task Schedule Service", func() {
+	var (
+		ctx       context.Context
+		service   services.TaskScheduleService
+		tasks     *hierarchyTaskRepo
+		deps      *graphDependencyRepo
+		projects  *lifecycleProjectRepo
+		events    *taskEventRepo
+		nudges    *memNudgeRepo
+		orgID     uuid.UUID
+		actorID   uuid.UUID
+		projectID uuid.UUID
+
+		design, api, ui, launch, docs uuid.UUID
+	)
+
+	// Working days run from 09:00 to 17:00; 7 September 2026 is a Monday
+	at := func(day, hour int) *time.Time {
+		t := time.Date(2026, time.September, day, hour, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	// moveDesign pushes the design task's due date two working days later, as an update
+	// would, and returns the task as it was
+	moveDesign := func() *models.Task {
+		before := *tasks.tasks[design]
+		tasks.tasks[design].DueDate = at(11, 17)
+		return &before
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		orgID, actorID, projectID = uuid.New(), uuid.New(), uuid.New()
+
+		// design -> api -> launch, with docs pinned after api and ui starting a day into design
+		tasks = &hierarchyTaskRepo{tasks: map[uuid.UUID]*models.Task{}}
+		design = tasks.add(models.Task{ProjectID: projectID, Title: "Design", Status: models.TaskStatusInProgress, StartDate: at(7, 9), DueDate: at(9, 17)})
+		api = tasks.add(models.Task{ProjectID: projectID, Title: "API", Status: models.TaskStatusReady, StartDate: at(10, 9), DueDate: at(11, 17)})
+		ui = tasks.add(models.Task{ProjectID: projectID, Title: "UI", Status: models.TaskStatusInProgress, StartDate: at(8, 9), DueDate: at(9, 17)})
+		launch = tasks.add(models.Task{ProjectID: projectID, Title: "Launch", Status: models.TaskStatusBacklog, IsMilestone: true, StartDate: at(14, 9), DueDate: at(14, 17)})
+		docs = tasks.add(models.Task{ProjectID: projectID, Title: "Docs", Status: models.TaskStatusBacklog, DatesPinned: true, StartDate: at(14, 9), DueDate: at(15, 17)})
+
+		deps = &graphDependencyRepo{tasks: tasks}
+		deps.add(api, design, models.DependencyFinishToStart)
+		deps.add(ui, design, models.DependencyStartToStart)
+		deps.deps[len(deps.deps)-1].LagHours = 8
+		deps.add(launch, api, models.DependencyFinishToStart)
+		deps.add(docs, api, models.DependencyFinishToStart)
+
+		projects = &lifecycleProjectRepo{
+			projects: map[uuid.UUID]*models.Project{projectID: {
+				BaseModel: models.BaseModel{ID: projectID}, OrganizationID: orgID, Name: "Checkout",
+				AutoSchedule: true, TargetEndDate: at(15, 17),
+			}},
+			deleted: map[uuid.UUID]bool{},
+		}
+		events = &taskEventRepo{}
+		nudges = &memNudgeRepo{nudges: map[uuid.UUID]*models.Nudge{}}
+
+		service = services.NewTaskScheduleService(&repositories.Provider{
+			Project:    projects,
+			Task:       tasks,
+			Dependency: deps,
+			TaskEvent:  events,
+			Nudge:      nudges,
+			Workflow:   &workflowRepo{workflows: map[uuid.UUID]*models.Workflow{}},
+		}, nil)
+	})
+
+	Describe("Preview", func() {
+		It("moves dependent tasks by working hours without saving them", func() {
+			preview, err := service.Preview(ctx, orgID, design, dto.ScheduleChangeRequest{DueDate: at(11, 17)})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(preview.Changes).To(HaveLen(2))
+			apiChange, launchChange := preview.Changes[0], preview.Changes[1]
+			Expect(apiChange.TaskID).To(Equal(api.String()))
+			Expect(apiChange.CausedBy).To(Equal(design.String()))
+			Expect(apiChange.ShiftHours).To(Equal(16.0))
+			Expect(*apiChange.NewStartDate).To(Equal(*at(14, 9)), "starts the next working morning")
+			Expect(*apiChange.NewDueDate).To(Equal(*at(15, 17)))
+
+			Expect(launchChange.TaskID).To(Equal(launch.String()))
+			Expect(launchChange.IsMilestone).To(BeTrue())
+			Expect(launchChange.CausedBy).To(Equal(api.String()))
+			Expect(*launchChange.OldDueDate).To(Equal(*at(14, 17)))
+			Expect(*launchChange.NewDueDate).To(Equal(*at(16, 17)))
+
+			Expect(*tasks.tasks[api].StartDate).To(Equal(*at(10, 9)))
+			Expect(events.events).To(BeEmpty())
+		})
+
+		It("reports pinned tasks as conflicts instead of moving them", func() {
+			preview, err := service.Preview(ctx, orgID, design, dto.ScheduleChangeRequest{DueDate: at(11, 17)})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(preview.Conflicts).To(HaveLen(1))
+			Expect(preview.Conflicts[0].TaskID).To(Equal(docs.String()))
+			Expect(preview.Conflicts[0].DependsOnTaskID).To(Equal(api.String()))
+			Expect(preview.Conflicts[0].OverlapHours).To(Equal(16.0))
+		})
+
+		It("reports when the change pushes the project past its target end date", func() {
+			preview, err := service.Preview(ctx, orgID, design, dto.ScheduleChangeRequest{DueDate: at(11, 17)})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(*preview.PreviousProjectEndDate).To(Equal(*at(15, 17)))
+			Expect(*preview.ProjectEndDate).To(Equal(*at(16, 17)))
+			Expect(preview.PushesTargetEndDate).To(BeTrue())
+		})
+
+		It("leaves tasks with room to spare where they are", func() {
+			preview, err := service.Preview(ctx, orgID, design, dto.ScheduleChangeRequest{DueDate: at(9, 12)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(preview.Changes).To(BeEmpty())
+			Expect(preview.Conflicts).To(BeEmpty())
+			Expect(preview.PushesTargetEndDate).To(BeFalse())
+		})
+
+		It("moves start-to-start successors when the start moves", func() {
+			preview, err := service.Preview(ctx, orgID, design, dto.ScheduleChangeRequest{StartDate: at(8, 9)})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(preview.Changes).To(HaveLen(1))
+			Expect(preview.Changes[0].TaskID).To(Equal(ui.String()))
+			Expect(*preview.Changes[0].NewStartDate).To(Equal(*at(9, 9)), "a working day of lag after design starts")
+		})
+
+		It("rejects a status the workflow does not have", func() {
+			_, err := service.Preview(ctx, orgID, design, dto.ScheduleChangeRequest{Status: "shipped"})
+			Expect(errors.Is(err, services.ErrUnknownTaskStatus)).To(BeTrue())
+		})
+	})
+
+	Describe("Cascade", func() {
+		It("does nothing when the project is not auto-scheduled", func() {
+			projects.projects[projectID].AutoSchedule = false
+
+			result, err := service.Cascade(ctx, orgID, actorID, moveDesign())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(BeNil())
+			Expect(*tasks.tasks[api].StartDate).To(Equal(*at(10, 9)))
+		})
+
+		It("saves the moved dates and records them in the tasks' history", func() {
+			_, err := service.Cascade(ctx, orgID, actorID, moveDesign())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(*tasks.tasks[api].StartDate).To(Equal(*at(14, 9)))
+			Expect(*tasks.tasks[launch].DueDate).To(Equal(*at(16, 17)))
+			Expect(*tasks.tasks[docs].StartDate).To(Equal(*at(14, 9)), "pinned dates stay")
+			Expect(*tasks.tasks[ui].StartDate).To(Equal(*at(8, 9)))
+
+			Expect(events.events).To(HaveLen(2))
+			for _, e := range events.events {
+				Expect(e.Type).To(Equal(models.TaskEventDueDateChanged))
+				Expect(*e.ActorID).To(Equal(actorID))
+			}
+		})
+
+		It("moves successors when a task finishes late", func() {
+			before := *tasks.tasks[design]
+			tasks.tasks[design].Status, tasks.tasks[design].CompletedAt = models.TaskStatusDone, at(10, 12)
+
+			result, err := service.Cascade(ctx, orgID, actorID, &before)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Changes).To(HaveLen(2))
+			Expect(*tasks.tasks[api].StartDate).To(Equal(*at(10, 12)))
+			Expect(*tasks.tasks[api].DueDate).To(Equal(*at(14, 12)))
+		})
+
+		It("raises delay risk nudges for the slipped milestone and the target end date", func() {
+			result, err := service.Cascade(ctx, orgID, actorID, moveDesign())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.NudgeIDs).To(HaveLen(2))
+
+			var milestone, project *models.Nudge
+			for _, n := range nudges.nudges {
+				Expect(n.Type).To(Equal(models.NudgeTypeDelayRisk))
+				Expect(n.OrganizationID).To(Equal(orgID))
+				Expect(*n.RelatedProjectID).To(Equal(projectID))
+				if n.RelatedTaskID != nil {
+					milestone = n
+				} else {
+					project = n
+				}
+			}
+			Expect(*milestone.RelatedTaskID).To(Equal(launch))
+			Expect(milestone.Severity).To(Equal(models.NudgeSeverityHigh), "the milestone now lands after the target")
+			Expect(project.Severity).To(Equal(models.NudgeSeverityHigh))
+			Expect(project.Metrics["overrunHours"]).To(Equal(24.0))
+		})
+
+		It("refreshes the open nudges rather than raising them again", func() {
+			first, err := service.Cascade(ctx, orgID, actorID, moveDesign())
+			Expect(err).NotTo(HaveOccurred())
+
+			tasks.tasks[design].DueDate = at(9, 17)
+			tasks.tasks[api].StartDate, tasks.tasks[api].DueDate = at(10, 9), at(11, 17)
+			tasks.tasks[launch].StartDate, tasks.tasks[launch].DueDate = at(14, 9), at(14, 17)
+			second, err := service.Cascade(ctx, orgID, actorID, moveDesign())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(nudges.nudges).To(HaveLen(2))
+			Expect(second.NudgeIDs).To(ConsistOf(first.NudgeIDs))
+		})
+
+		It("raises a medium nudge for a milestone moved within the target", func() {
+			before := *tasks.tasks[launch]
+			tasks.tasks[launch].DueDate = at(15, 12)
+
+			result, err := service.Cascade(ctx, orgID, actorID, &before)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.NudgeIDs).To(HaveLen(1))
+			nudgeID, _ := uuid.Parse(result.NudgeIDs[0])
+			Expect(nudges.nudges[nudgeID].Severity).To(Equal(models.NudgeSeverityMedium))
+		})
+	})
+
+	DescribeTable("the shift each dependency type asks for",
+		func(dependencyType models.DependencyType, lag int, start, due *time.Time) {
+			deps.deps = nil
+			deps.add(api, design, dependencyType)
+			deps.deps[0].LagHours = lag
+
+			preview, err := service.Preview(ctx, orgID, design, dto.ScheduleChangeRequest{StartDate: at(9, 9), DueDate: at(11, 17)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(preview.Changes).To(HaveLen(1))
+			Expect(*preview.Changes[0].NewStartDate).To(Equal(*start))
+			Expect(*preview.Changes[0].NewDueDate).To(Equal(*due))
+		},
+		Entry("finish to start", models.DependencyFinishToStart, 0, at(14, 9), at(15, 17)),
+		Entry("finish to start with a day of lag", models.DependencyFinishToStart, 8, at(15, 9), at(16, 17)),
+		Entry("start to start with two days of lag", models.DependencyStartToStart, 16, at(11, 9), at(14, 17)),
+		Entry("finish to finish", models.DependencyFinishToFinish, 4, at(10, 13), at(14, 13)),
+		Entry("start to finish", models.DependencyStartToFinish, 32, at(11, 9), at(14, 17)),
+	)
+})
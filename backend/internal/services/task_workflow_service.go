@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+)
+
+var (
+	// ErrInvalidWorkflow is returned when a workflow definition is inconsistent
+	ErrInvalidWorkflow = errors.New("invalid workflow")
+
+	// ErrUnknownTaskStatus is returned for a status that is not in the project's workflow
+	ErrUnknownTaskStatus = errors.New("unknown task status")
+
+	// ErrInvalidTaskTransition is returned when the workflow does not allow a move
+	ErrInvalidTaskTransition = errors.New("invalid task status transition")
+
+	// ErrAssigneeRequired is returned when a transition needs an assigned task
+	ErrAssigneeRequired = errors.New("task must be assigned first")
+
+	// ErrSubtasksIncomplete is returned when a transition needs every subtask done
+	ErrSubtasksIncomplete = errors.New("task has unfinished subtasks")
+
+	// ErrPredecessorsIncomplete is returned when a transition needs every
+	// finish_to_start predecessor done
+	ErrPredecessorsIncomplete = errors.New("task has unfinished predecessors")
+)
+
+// workflowGuards lists the guards transitions may use
+var workflowGuards = map[models.WorkflowGuard]bool{
+	models.WorkflowGuardAssignee:         true,
+	models.WorkflowGuardSubtasksDone:     true,
+	models.WorkflowGuardPredecessorsDone: true,
+}
+
+// categoryOrder lists the canonical statuses in the order work moves through them
+var categoryOrder = []models.TaskStatus{
+	models.TaskStatusBacklog,
+	models.TaskStatusReady,
+	models.TaskStatusInProgress,
+	models.TaskStatusReview,
+	models.TaskStatusDone,
+}
+
+// DefaultWorkflow is the workflow of projects that have not defined their own. Its
+// statuses are the canonical ones. Work starts from ready or backlog once assigned and
+// unblocked, and finishes from review or in progress once its subtasks are done.
+func DefaultWorkflow(projectID uuid.UUID) *models.Workflow {
+	workflow := &models.Workflow{ProjectID: projectID}
+	for i, category := range categoryOrder {
+		workflow.Statuses = append(workflow.Statuses, models.WorkflowStatus{
+			Key:      string(category),
+			Name:     strings.ReplaceAll(string(category), "_", " "),
+			Category: category,
+			Position: i,
+		})
+	}
+	start := string(models.WorkflowGuardAssignee) + "," + string(models.WorkflowGuardPredecessorsDone)
+	finish := string(models.WorkflowGuardSubtasksDone)
+	for _, t := range []struct {
+		from, to models.TaskStatus
+		guards   string
+	}{
+		{models.TaskStatusBacklog, models.TaskStatusReady, ""},
+		{models.TaskStatusReady, models.TaskStatusBacklog, ""},
+		{models.TaskStatusBacklog, models.TaskStatusInProgress, start},
+		{models.TaskStatusReady, models.TaskStatusInProgress, start},
+		{models.TaskStatusInProgress, models.TaskStatusReady, ""},
+		{models.TaskStatusInProgress, models.TaskStatusReview, ""},
+		{models.TaskStatusReview, models.TaskStatusInProgress, ""},
+		{models.TaskStatusInProgress, models.TaskStatusDone, finish},
+		{models.TaskStatusReview, models.TaskStatusDone, finish},
+		{models.TaskStatusDone, models.TaskStatusInProgress, ""},
+	} {
+		workflow.Transitions = append(workflow.Transitions, models.WorkflowTransition{
+			FromStatus: string(t.from),
+			ToStatus:   string(t.to),
+			Guards:     t.guards,
+		})
+	}
+	return workflow
+}
+
+// TaskWorkflowService defines the interface for project workflows and the task status
+// transitions they allow
+type TaskWorkflowService interface {
+	// Workflow returns a project's workflow, or the default one if it has none
+	Workflow(ctx context.Context, orgID, projectID uuid.UUID) (*models.Workflow, error)
+
+	// SetWorkflow validates and saves a project's workflow, replacing its current one
+	SetWorkflow(ctx context.Context, orgID uuid.UUID, workflow *models.Workflow) (*models.Workflow, error)
+
+	// ResetWorkflow returns a project to the default workflow
+	ResetWorkflow(ctx context.Context, orgID, projectID uuid.UUID) error
+
+	// Transition moves a task to a status of its project's workflow, given by key or by
+	// canonical category, on behalf of the actor
+	Transition(ctx context.Context, orgID, taskID, actorID uuid.UUID, status string) (*models.Task, error)
+
+	// InitialStatus puts a task about to be created in a status of its project's
+	// workflow, given by key or canonical category, or in the workflow's first status
+	InitialStatus(ctx context.Context, orgID uuid.UUID, task *models.Task, status string) error
+}
+
+// RealTaskWorkflowService implements TaskWorkflowService
+type RealTaskWorkflowService struct {
+	repos *repositories.Provider
+	now   func() time.Time
+}
+
+// NewTaskWorkflowService creates a new task workflow service
+func NewTaskWorkflowService(repos *repositories.Provider) *RealTaskWorkflowService {
+	return &RealTaskWorkflowService{repos: repos, now: time.Now}
+}
+
+// Workflow loads a project's workflow, falling back to the default
+func (s *RealTaskWorkflowService) Workflow(ctx context.Context, orgID, projectID uuid.UUID) (*models.Workflow, error) {
+	if _, err := s.repos.GetProject().GetByID(ctx, orgID, projectID); err != nil {
+		return nil, err
+	}
+	return projectWorkflow(ctx, s.repos, orgID, projectID)
+}
+
+// SetWorkflow saves a project's workflow once it is consistent. Tasks keep their
+// workflow status when the new workflow has it; the others are read as the first
+// status of their category.
+func (s *RealTaskWorkflowService) SetWorkflow(ctx context.Context, orgID uuid.UUID, workflow *models.Workflow) (*models.Workflow, error) {
+	if err := validateWorkflow(workflow); err != nil {
+		return nil, err
+	}
+	if _, err := s.repos.GetProject().GetByID(ctx, orgID, workflow.ProjectID); err != nil {
+		return nil, err
+	}
+	if err := s.repos.GetWorkflow().Replace(ctx, orgID, workflow); err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
+
+// ResetWorkflow deletes a project's own workflow
+func (s *RealTaskWorkflowService) ResetWorkflow(ctx context.Context, orgID, projectID uuid.UUID) error {
+	if _, err := s.repos.GetProject().GetByID(ctx, orgID, projectID); err != nil {
+		return err
+	}
+	return s.repos.GetWorkflow().DeleteByProject(ctx, orgID, projectID)
+}
+
+// Transition moves a task along its project's workflow. Moving to the current status
+// is a no-op.
+func (s *RealTaskWorkflowService) Transition(ctx context.Context, orgID, taskID, actorID uuid.UUID, status string) (*models.Task, error) {
+	var task *models.Task
+	err := s.repos.WithTransaction(ctx, func(tx *repositories.Provider) error {
+		var err error
+		task, err = tx.GetTask().GetByID(ctx, orgID, taskID)
+		if err != nil {
+			return err
+		}
+		return transitionTask(ctx, tx, orgID, actorID, task, status, s.now())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// InitialStatus sets a new task's status, workflow status and dates. No transition
+// leads into a task's first status, so no guards apply; a task created in progress
+// starts now and one created done is completed now.
+func (s *RealTaskWorkflowService) InitialStatus(ctx context.Context, orgID uuid.UUID, task *models.Task, status string) error {
+	return initialStatus(ctx, s.repos, orgID, task, status, s.now())
+}
+
+// initialStatus sets a new task's status from its project's workflow, defaulting to the
+// workflow's first status
+func initialStatus(ctx context.Context, repos *repositories.Provider, orgID uuid.UUID, task *models.Task, status string, now time.Time) error {
+	workflow, err := projectWorkflow(ctx, repos, orgID, task.ProjectID)
+	if err != nil {
+		return err
+	}
+	target := &workflow.Statuses[0]
+	if status != "" {
+		target = workflowStatus(workflow, status)
+	}
+	if target == nil {
+		return fmt.Errorf("%w: %q", ErrUnknownTaskStatus, status)
+	}
+	task.StartDate, task.CompletedAt = statusDates(task, target, now)
+	task.Status, task.WorkflowStatus = target.Category, target.Key
+	return nil
+}
+
+// statusDates are the start and completion dates of a task moving to a status:
+// starting work sets the start date if it is unset, finishing sets the completion date
+// and reopening clears it
+func statusDates(task *models.Task, target *models.WorkflowStatus, now time.Time) (*time.Time, *time.Time) {
+	startDate, completedAt := task.StartDate, task.CompletedAt
+	if startDate == nil && target.Category != models.TaskStatusBacklog && target.Category != models.TaskStatusReady {
+		startDate = &now
+	}
+	if target.Category == models.TaskStatusDone {
+		if task.Status != models.TaskStatusDone || completedAt == nil {
+			completedAt = &now
+		}
+	} else {
+		completedAt = nil
+	}
+	return startDate, completedAt
+}
+
+// transitionTask moves a loaded task to a workflow status, given by key or category,
+// and updates it in place. The transition must be in the workflow and its guards must
+// pass. Starting work sets the start date if it is unset; finishing sets the completion
+// date and reopening clears it. A change of canonical status is logged in the task's
+// history.
+func transitionTask(ctx context.Context, repos *repositories.Provider, orgID, actorID uuid.UUID, task *models.Task, status string, now time.Time) error {
+	workflow, err := projectWorkflow(ctx, repos, orgID, task.ProjectID)
+	if err != nil {
+		return err
+	}
+	target := workflowStatus(workflow, status)
+	if target == nil {
+		return fmt.Errorf("%w: %q", ErrUnknownTaskStatus, status)
+	}
+	current := currentWorkflowStatus(workflow, task)
+	if current != nil && current.Key == target.Key {
+		return nil
+	}
+
+	// A task whose status is not in the workflow, such as one left behind by a
+	// replaced workflow, may move anywhere to rejoin it
+	if current != nil {
+		transition := findTransition(workflow, current.Key, target.Key)
+		if transition == nil {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTaskTransition, current.Key, target.Key)
+		}
+		for _, guard := range transition.GuardList() {
+			if err := checkGuard(ctx, repos, orgID, task, guard); err != nil {
+				return err
+			}
+		}
+	}
+
+	startDate, completedAt := statusDates(task, target, now)
+	if err := repos.GetTask().UpdateWorkflowStatus(ctx, orgID, task.ID, target.Category, target.Key, startDate, completedAt); err != nil {
+		return err
+	}
+
+	before := *task
+	task.Status, task.WorkflowStatus = target.Category, target.Key
+	task.StartDate, task.CompletedAt = startDate, completedAt
+	if task.Status == models.TaskStatusDone {
+		task.ProgressPercentage = 100
+	}
+	return recordTaskChanges(ctx, repos, orgID, actorID, &before, task, now)
+}
+
+// projectWorkflow loads a project's workflow or the default one
+func projectWorkflow(ctx context.Context, repos *repositories.Provider, orgID, projectID uuid.UUID) (*models.Workflow, error) {
+	workflow, err := repos.GetWorkflow().GetByProject(ctx, orgID, projectID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultWorkflow(projectID), nil
+	}
+	return workflow, err
+}
+
+// checkGuard fails with the guard's error when the task does not meet it
+func checkGuard(ctx context.Context, repos *repositories.Provider, orgID uuid.UUID, task *models.Task, guard models.WorkflowGuard) error {
+	switch guard {
+	case models.WorkflowGuardAssignee:
+		if task.AssigneeID == nil {
+			return ErrAssigneeRequired
+		}
+	case models.WorkflowGuardSubtasksDone:
+		subtasks, err := repos.GetTask().ListSubtasks(ctx, orgID, task.ID)
+		if err != nil {
+			return err
+		}
+		var open []string
+		for _, t := range subtasks {
+			if t.Status != models.TaskStatusDone && t.ArchivedAt == nil {
+				open = append(open, t.Title)
+			}
+		}
+		if len(open) > 0 {
+			return fmt.Errorf("%w: %s", ErrSubtasksIncomplete, strings.Join(open, ", "))
+		}
+	case models.WorkflowGuardPredecessorsDone:
+		deps, err := repos.GetDependency().ListByTask(ctx, orgID, task.ID)
+		if err != nil {
+			return err
+		}
+		var open []string
+		for _, d := range deps {
+			if d.DependencyType == models.DependencyFinishToStart && d.DependsOnTask.Status != models.TaskStatusDone {
+				open = append(open, d.DependsOnTask.Title)
+			}
+		}
+		if len(open) > 0 {
+			return fmt.Errorf("%w: %s", ErrPredecessorsIncomplete, strings.Join(open, ", "))
+		}
+	}
+	return nil
+}
+
+// workflowStatus finds a status by key, or else the first status of a category
+func workflowStatus(workflow *models.Workflow, status string) *models.WorkflowStatus {
+	for i := range workflow.Statuses {
+		if workflow.Statuses[i].Key == status {
+			return &workflow.Statuses[i]
+		}
+	}
+	for i := range workflow.Statuses {
+		if string(workflow.Statuses[i].Category) == status {
+			return &workflow.Statuses[i]
+		}
+	}
+	return nil
+}
+
+// currentWorkflowStatus is the task's workflow status, read from its category when the
+// task has none or the workflow no longer has it
+func currentWorkflowStatus(workflow *models.Workflow, task *models.Task) *models.WorkflowStatus {
+	for i := range workflow.Statuses {
+		if task.WorkflowStatus != "" && workflow.Statuses[i].Key == task.WorkflowStatus {
+			return &workflow.Statuses[i]
+		}
+	}
+	for i := range workflow.Statuses {
+		if workflow.Statuses[i].Category == task.Status {
+			return &workflow.Statuses[i]
+		}
+	}
+	return nil
+}
+
+// findTransition finds the transition between two statuses
+func findTransition(workflow *models.Workflow, from, to string) *models.WorkflowTransition {
+	for i := range workflow.Transitions {
+		if workflow.Transitions[i].FromStatus == from && workflow.Transitions[i].ToStatus == to {
+			return &workflow.Transitions[i]
+		}
+	}
+	return nil
+}
+
+// validateWorkflow checks that statuses have unique keys and canonical categories, that
+// some status completes tasks, and that transitions join known statuses with known
+// guards
+func validateWorkflow(workflow *models.Workflow) error {
+	if len(workflow.Statuses) == 0 {
+		return fmt.Errorf("%w: at least one status is required", ErrInvalidWorkflow)
+	}
+	keys := make(map[string]bool, len(workflow.Statuses))
+	hasDone := false
+	for i, status := range workflow.Statuses {
+		if status.Key == "" {
+			return fmt.Errorf("%w: status %d has no key", ErrInvalidWorkflow, i+1)
+		}
+		if keys[status.Key] {
+			return fmt.Errorf("%w: status %q is defined twice", ErrInvalidWorkflow, status.Key)
+		}
+		if _, ok := statusProgress[status.Category]; !ok {
+			return fmt.Errorf("%w: status %q has unknown category %q", ErrInvalidWorkflow, status.Key, status.Category)
+		}
+		keys[status.Key] = true
+		hasDone = hasDone || status.Category == models.TaskStatusDone
+		workflow.Statuses[i].Position = i
+	}
+	if !hasDone {
+		return fmt.Errorf("%w: a status in the done category is required", ErrInvalidWorkflow)
+	}
+
+	seen := make(map[[2]string]bool, len(workflow.Transitions))
+	for _, t := range workflow.Transitions {
+		if !keys[t.FromStatus] || !keys[t.ToStatus] {
+			return fmt.Errorf("%w: transition %s to %s uses an unknown status", ErrInvalidWorkflow, t.FromStatus, t.ToStatus)
+		}
+		if t.FromStatus == t.ToStatus {
+			return fmt.Errorf("%w: status %q cannot transition to itself", ErrInvalidWorkflow, t.FromStatus)
+		}
+		if seen[[2]string{t.FromStatus, t.ToStatus}] {
+			return fmt.Errorf("%w: transition %s to %s is defined twice", ErrInvalidWorkflow, t.FromStatus, t.ToStatus)
+		}
+		seen[[2]string{t.FromStatus, t.ToStatus}] = true
+		for _, guard := range t.GuardList() {
+			if !workflowGuards[guard] {
+				return fmt.Errorf("%w: unknown guard %q", ErrInvalidWorkflow, guard)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package services_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+
+	"github.com/SimpleAjax/Xephyr/internal/models"
+	"github.com/SimpleAjax/Xephyr/internal/repositories"
+	"github.com/SimpleAjax/Xephyr/internal/services"
+)
+
+// workflowRepo keeps one workflow per project
+type workflowRepo struct {
+	repositories.WorkflowRepository
+	workflows map[uuid.UUID]*models.Workflow
+}
+
+func (r *workflowRepo) GetByProject(ctx context.Context, orgID, projectID uuid.UUID) (*models.Workflow, error) {
+	w, ok := r.workflows[projectID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return w, nil
+}
+
+func (r *workflowRepo) Replace(ctx context.Context, orgID uuid.UUID, w *models.Workflow) error {
+	w.ID, w.OrganizationID = uuid.New(), orgID
+	r.workflows[w.ProjectID] = w
+	return nil
+}
+
+func (r *workflowRepo) DeleteByProject(ctx context.Context, orgID, projectID uuid.UUID) error {
+	delete(r.workflows, projectID)
+	return nil
+}
+
+// workflowTaskRepo adds subtask listing and workflow status updates to the hierarchy fake
+type workflowTaskRepo struct {
+	*hierarchyTaskRepo
+}
+
+func (r *workflowTaskRepo) ListSubtasks(ctx context.Context, orgID, parentID uuid.UUID) ([]models.Task, error) {
+	var out []models.Task
+	for _, id := range r.order {
+		if t, ok := r.tasks[id]; ok && t.ParentTaskID != nil && *t.ParentTaskID == parentID {
+			out = append(out, *t)
+		}
+	}
+	return out, nil
+}
+
+func (r *workflowTaskRepo) UpdateWorkflowStatus(ctx context.Context, orgID, id uuid.UUID, status models.TaskStatus, key string, startDate, completedAt *time.Time) error {
+	t := r.tasks[id]
+	t.Status, t.WorkflowStatus, t.StartDate, t.CompletedAt = status, key, startDate, completedAt
+	return nil
+}
+
+// workflowDependencyRepo lists fixed dependencies with the tasks on both ends loaded
+type workflowDependencyRepo struct {
+	repositories.DependencyRepository
+	tasks *hierarchyTaskRepo
+	deps  []models.TaskDependency
+}
+
+func (r *workflowDependencyRepo) ListByTask(ctx context.Context, orgID, taskID uuid.UUID) ([]models.TaskDependency, error) {
+	var out []models.TaskDependency
+	for _, d := range r.deps {
+		if d.TaskID == taskID {
+			d.DependsOnTask = *r.tasks.tasks[d.DependsOnTaskID]
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (r *workflowDependencyRepo) ListByDependsOn(ctx context.Context, orgID, dependsOn uuid.UUID) ([]models.TaskDependency, error) {
+	var out []models.TaskDependency
+	for _, d := range r.deps {
+		if d.DependsOnTaskID == dependsOn {
+			d.Task = *r.tasks.tasks[d.TaskID]
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+var _ = Describe("Task Workflow Service", func() {
+	var (
+		ctx       context.Context
+		service   services.TaskWorkflowService
+		tasks     *hierarchyTaskRepo
+		deps      *workflowDependencyRepo
+		workflows *workflowRepo
+		events    *taskEventRepo
+		orgID     uuid.UUID
+		projectID uuid.UUID
+		emma      uuid.UUID
+
+		design, build, review uuid.UUID
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		orgID, projectID, emma = uuid.New(), uuid.New(), uuid.New()
+
+		tasks = &hierarchyTaskRepo{tasks: map[uuid.UUID]*models.Task{}}
+		design = tasks.add(models.Task{ProjectID: projectID, Title: "Design", Status: models.TaskStatusInProgress, AssigneeID: &emma})
+		build = tasks.add(models.Task{ProjectID: projectID, Title: "Build", Status: models.TaskStatusReady})
+		review = tasks.add(models.Task{ProjectID: projectID, ParentTaskID: &design, Title: "Review mockups", Status: models.TaskStatusReview})
+		deps = &workflowDependencyRepo{tasks: tasks, deps: []models.TaskDependency{
+			{TaskID: build, DependsOnTaskID: design, DependencyType: models.DependencyFinishToStart},
+		}}
+		workflows = &workflowRepo{workflows: map[uuid.UUID]*models.Workflow{}}
+		events = &taskEventRepo{}
+
+		service = services.NewTaskWorkflowService(&repositories.Provider{
+			Project: &lifecycleProjectRepo{
+				projects: map[uuid.UUID]*models.Project{projectID: {BaseModel: models.BaseModel{ID: projectID}, OrganizationID: orgID}},
+				deleted:  map[uuid.UUID]bool{},
+			},
+			Task:       &workflowTaskRepo{tasks},
+			Dependency: deps,
+			Workflow:   workflows,
+			TaskEvent:  events,
+		})
+	})
+
+	Describe("the default workflow", func() {
+		It("is used when the project has none", func() {
+			workflow, err := service.Workflow(ctx, orgID, projectID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(workflow.ID).To(Equal(uuid.Nil))
+			Expect(workflow.Statuses).To(HaveLen(5))
+		})
+
+		It("rejects skipping straight from backlog to done", func() {
+			tasks.tasks[build].Status = models.TaskStatusBacklog
+			_, err := service.Transition(ctx, orgID, build, emma, "done")
+			Expect(err).To(MatchError(services.ErrInvalidTaskTransition))
+			Expect(tasks.tasks[build].Status).To(Equal(models.TaskStatusBacklog))
+		})
+
+		It("rejects a status outside the workflow", func() {
+			_, err := service.Transition(ctx, orgID, build, emma, "blocked")
+			Expect(err).To(MatchError(services.ErrUnknownTaskStatus))
+		})
+
+		It("requires an assignee and finished predecessors to start work", func() {
+			_, err := service.Transition(ctx, orgID, build, emma, "in_progress")
+			Expect(err).To(MatchError(services.ErrAssigneeRequired))
+
+			tasks.tasks[build].AssigneeID = &emma
+			_, err = service.Transition(ctx, orgID, build, emma, "in_progress")
+			Expect(err).To(MatchError(services.ErrPredecessorsIncomplete))
+			Expect(err.Error()).To(ContainSubstring("Design"))
+
+			deps.deps[0].DependencyType = models.DependencyStartToStart
+			task, err := service.Transition(ctx, orgID, build, emma, "in_progress")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(task.Status).To(Equal(models.TaskStatusInProgress))
+			Expect(task.StartDate).NotTo(BeNil())
+		})
+
+		It("requires subtasks to be done before finishing, then sets and clears the completion date", func() {
+			_, err := service.Transition(ctx, orgID, design, emma, "done")
+			Expect(err).To(MatchError(services.ErrSubtasksIncomplete))
+
+			tasks.tasks[review].Status = models.TaskStatusDone
+			task, err := service.Transition(ctx, orgID, design, emma, "done")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(task.CompletedAt).NotTo(BeNil())
+			Expect(task.ProgressPercentage).To(Equal(100))
+			Expect(tasks.tasks[design].StartDate).NotTo(BeNil())
+
+			task, err = service.Transition(ctx, orgID, design, emma, "in_progress")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(task.CompletedAt).To(BeNil())
+		})
+
+		It("treats a move to the current status as a no-op", func() {
+			task, err := service.Transition(ctx, orgID, build, emma, "ready")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(task.StartDate).To(BeNil())
+			Expect(events.events).To(BeEmpty())
+		})
+
+		It("logs the status change in the task's history", func() {
+			tasks.tasks[review].Status = models.TaskStatusDone
+			_, err := service.Transition(ctx, orgID, design, emma, "done")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events.events).To(HaveLen(1))
+			Expect(events.events[0].TaskID).To(Equal(design))
+			Expect(events.events[0].FromValue).To(Equal("in_progress"))
+			Expect(events.events[0].ToValue).To(Equal("done"))
+			Expect(*events.events[0].ActorID).To(Equal(emma))
+		})
+	})
+
+	Describe("a custom workflow", func() {
+		BeforeEach(func() {
+			_, err := service.SetWorkflow(ctx, orgID, &models.Workflow{
+				ProjectID: projectID,
+				Statuses: []models.WorkflowStatus{
+					{Key: "todo", Name: "To do", Category: models.TaskStatusReady},
+					{Key: "doing", Name: "Doing", Category: models.TaskStatusInProgress},
+					{Key: "qa", Name: "QA", Category: models.TaskStatusReview},
+					{Key: "shipped", Name: "Shipped", Category: models.TaskStatusDone},
+				},
+				Transitions: []models.WorkflowTransition{
+					{FromStatus: "todo", ToStatus: "doing"},
+					{FromStatus: "doing", ToStatus: "qa", Guards: "assignee_required"},
+					{FromStatus: "qa", ToStatus: "shipped"},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("moves tasks by key and records the category", func() {
+			task, err := service.Transition(ctx, orgID, build, emma, "doing")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(task.WorkflowStatus).To(Equal("doing"))
+			Expect(task.Status).To(Equal(models.TaskStatusInProgress))
+
+			_, err = service.Transition(ctx, orgID, build, emma, "qa")
+			Expect(err).To(MatchError(services.ErrAssigneeRequired))
+		})
+
+		It("resolves a canonical category to the workflow's status", func() {
+			task, err := service.Transition(ctx, orgID, review, emma, "done")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(task.WorkflowStatus).To(Equal("shipped"))
+		})
+
+		It("only allows the transitions it defines", func() {
+			_, err := service.Transition(ctx, orgID, build, emma, "shipped")
+			Expect(err).To(MatchError(services.ErrInvalidTaskTransition))
+		})
+
+		It("lets tasks whose status it lacks move anywhere to join it", func() {
+			tasks.tasks[build].Status = models.TaskStatusBacklog
+			task, err := service.Transition(ctx, orgID, build, emma, "qa")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(task.Status).To(Equal(models.TaskStatusReview))
+		})
+
+		It("starts new tasks in its first status unless told otherwise", func() {
+			task := &models.Task{ProjectID: projectID}
+			Expect(service.InitialStatus(ctx, orgID, task, "")).To(Succeed())
+			Expect(task.WorkflowStatus).To(Equal("todo"))
+			Expect(task.Status).To(Equal(models.TaskStatusReady))
+			Expect(task.StartDate).To(BeNil())
+
+			task = &models.Task{ProjectID: projectID}
+			Expect(service.InitialStatus(ctx, orgID, task, "qa")).To(Succeed())
+			Expect(task.Status).To(Equal(models.TaskStatusReview))
+			Expect(task.StartDate).NotTo(BeNil())
+		})
+
+		It("completes tasks created done and rejects statuses it lacks", func() {
+			task := &models.Task{ProjectID: projectID}
+			Expect(service.InitialStatus(ctx, orgID, task, "done")).To(Succeed())
+			Expect(task.WorkflowStatus).To(Equal("shipped"))
+			Expect(task.CompletedAt).NotTo(BeNil())
+
+			Expect(service.InitialStatus(ctx, orgID, &models.Task{ProjectID: projectID}, "backlog")).To(MatchError(services.ErrUnknownTaskStatus))
+		})
+
+		It("is dropped for the default when reset", func() {
+			Expect(service.ResetWorkflow(ctx, orgID, projectID)).To(Succeed())
+			workflow, err := service.Workflow(ctx, orgID, projectID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(workflow.Statuses[0].Key).To(Equal("backlog"))
+		})
+	})
+
+	DescribeTable("rejects inconsistent workflows",
+		func(statuses []models.WorkflowStatus, transitions []models.WorkflowTransition) {
+			_, err := service.SetWorkflow(ctx, orgID, &models.Workflow{ProjectID: projectID, Statuses: statuses, Transitions: transitions})
+			Expect(err).To(MatchError(services.ErrInvalidWorkflow))
+			Expect(workflows.workflows).To(BeEmpty())
+		},
+		Entry("no statuses", nil, nil),
+		Entry("a duplicate key",
+			[]models.WorkflowStatus{{Key: "done", Category: models.TaskStatusDone}, {Key: "done", Category: models.TaskStatusDone}}, nil),
+		Entry("an unknown category",
+			[]models.WorkflowStatus{{Key: "done", Category: models.TaskStatusDone}, {Key: "blocked", Category: "blocked"}}, nil),
+		Entry("no done status",
+			[]models.WorkflowStatus{{Key: "todo", Category: models.TaskStatusReady}}, nil),
+		Entry("a transition to an unknown status",
+			[]models.WorkflowStatus{{Key: "done", Category: models.TaskStatusDone}},
+			[]models.WorkflowTransition{{FromStatus: "todo", ToStatus: "done"}}),
+		Entry("an unknown guard",
+			[]models.WorkflowStatus{{Key: "todo", Category: models.TaskStatusReady}, {Key: "done", Category: models.TaskStatusDone}},
+			[]models.WorkflowTransition{{FromStatus: "todo", ToStatus: "done", Guards: "signed_off"}}),
+	)
+})